@@ -1,18 +1,12 @@
-// ABOUTME: Backup and restore functionality for scope settings
-// ABOUTME: Manages ~/.claudeup/backups/ for scope recovery
+// ABOUTME: Shared helpers for the backup directory under ~/.claudeup/backups/
+// ABOUTME: Validates claudeupHome and creates the directory generations live in
 package backup
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"io/fs"
 	"os"
 	"path/filepath"
-	"time"
 )
 
 // validateClaudeupHome ensures claudeupHome is an absolute path
@@ -38,241 +32,5 @@ func EnsureBackupDir(claudeupHome string) (string, error) {
 	return backupDir, nil
 }
 
-// copyFile copies src to dst, preserving file permissions
-// Rejects symlinks to prevent symlink attacks
-func copyFile(src, dst string) error {
-	// Check source is not a symlink (Lstat doesn't follow symlinks)
-	srcLstat, err := os.Lstat(src)
-	if err != nil {
-		return fmt.Errorf("failed to stat source file: %w", err)
-	}
-	if srcLstat.Mode()&os.ModeSymlink != 0 {
-		return fmt.Errorf("source is a symlink, refusing to copy: %s", src)
-	}
-
-	// Check destination is not a symlink if it exists
-	dstLstat, err := os.Lstat(dst)
-	if err == nil {
-		if dstLstat.Mode()&os.ModeSymlink != 0 {
-			return fmt.Errorf("destination is a symlink, refusing to overwrite: %s", dst)
-		}
-	} else if !errors.Is(err, fs.ErrNotExist) {
-		return fmt.Errorf("failed to stat destination file: %w", err)
-	}
-
-	// Get source file info for permissions (follows symlinks, but we already checked)
-	srcInfo, err := os.Stat(src)
-	if err != nil {
-		return fmt.Errorf("failed to stat source file: %w", err)
-	}
-
-	srcFile, err := os.Open(src)
-	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
-	}
-	defer srcFile.Close()
-
-	dstFile, err := os.Create(dst)
-	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
-	}
-	defer dstFile.Close()
-
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
-		return fmt.Errorf("failed to copy file: %w", err)
-	}
-
-	// Preserve original file permissions
-	if err := os.Chmod(dst, srcInfo.Mode()); err != nil {
-		return fmt.Errorf("failed to set file permissions: %w", err)
-	}
-
-	return nil
-}
-
-// SaveScopeBackup copies the settings file to the backup directory
-// Returns the path to the backup file
-func SaveScopeBackup(claudeupHome, scope, settingsPath string) (string, error) {
-	backupDir, err := EnsureBackupDir(claudeupHome)
-	if err != nil {
-		return "", fmt.Errorf("failed to create backup directory: %w", err)
-	}
-
-	backupFileName := fmt.Sprintf("%s-scope.json", scope)
-	backupPath := filepath.Join(backupDir, backupFileName)
-
-	if err := copyFile(settingsPath, backupPath); err != nil {
-		return "", err
-	}
-
-	return backupPath, nil
-}
-
-// SaveLocalScopeBackup saves a backup with project-specific naming
-func SaveLocalScopeBackup(claudeupHome, projectDir, settingsPath string) (string, error) {
-	backupDir, err := EnsureBackupDir(claudeupHome)
-	if err != nil {
-		return "", fmt.Errorf("failed to create backup directory: %w", err)
-	}
-
-	// Create a short hash of the project path for unique naming
-	hash := sha256.Sum256([]byte(projectDir))
-	// Use first 8 hex characters of SHA256 (~16.7M combinations, sufficient for project disambiguation)
-	shortHash := hex.EncodeToString(hash[:])[:8]
-
-	backupFileName := fmt.Sprintf("local-scope-%s.json", shortHash)
-	backupPath := filepath.Join(backupDir, backupFileName)
-
-	if err := copyFile(settingsPath, backupPath); err != nil {
-		return "", err
-	}
-
-	return backupPath, nil
-}
-
-// ErrNoBackup is returned when no backup exists for a scope
+// ErrNoBackup is returned when no backup generation exists
 var ErrNoBackup = errors.New("no backup found")
-
-// RestoreScopeBackup copies the backup file back to the settings location
-func RestoreScopeBackup(claudeupHome, scope, settingsPath string) error {
-	if err := validateClaudeupHome(claudeupHome); err != nil {
-		return err
-	}
-	backupDir := filepath.Join(claudeupHome, "backups")
-	backupFileName := fmt.Sprintf("%s-scope.json", scope)
-	backupPath := filepath.Join(backupDir, backupFileName)
-
-	// Check backup exists
-	if _, err := os.Stat(backupPath); err != nil {
-		if errors.Is(err, fs.ErrNotExist) {
-			return ErrNoBackup
-		}
-		return err
-	}
-
-	// Use copyFile helper
-	return copyFile(backupPath, settingsPath)
-}
-
-// RestoreLocalScopeBackup restores a local scope backup using project-specific naming
-func RestoreLocalScopeBackup(claudeupHome, projectDir, settingsPath string) error {
-	if err := validateClaudeupHome(claudeupHome); err != nil {
-		return err
-	}
-	backupDir := filepath.Join(claudeupHome, "backups")
-
-	// Derive the same hash used when saving
-	hash := sha256.Sum256([]byte(projectDir))
-	shortHash := hex.EncodeToString(hash[:])[:8]
-
-	backupFileName := fmt.Sprintf("local-scope-%s.json", shortHash)
-	backupPath := filepath.Join(backupDir, backupFileName)
-
-	// Check backup exists
-	if _, err := os.Stat(backupPath); err != nil {
-		if errors.Is(err, fs.ErrNotExist) {
-			return ErrNoBackup
-		}
-		return err
-	}
-
-	// Use copyFile helper
-	return copyFile(backupPath, settingsPath)
-}
-
-// BackupInfo contains metadata about a backup
-type BackupInfo struct {
-	Exists      bool
-	Path        string
-	ModTime     time.Time
-	PluginCount int
-}
-
-// GetBackupInfo returns information about a scope's backup
-func GetBackupInfo(claudeupHome, scope string) (*BackupInfo, error) {
-	if err := validateClaudeupHome(claudeupHome); err != nil {
-		return nil, err
-	}
-	backupDir := filepath.Join(claudeupHome, "backups")
-	backupFileName := fmt.Sprintf("%s-scope.json", scope)
-	backupPath := filepath.Join(backupDir, backupFileName)
-
-	info := &BackupInfo{Path: backupPath}
-
-	stat, err := os.Stat(backupPath)
-	if errors.Is(err, fs.ErrNotExist) {
-		return info, nil
-	}
-	if err != nil {
-		return nil, err
-	}
-
-	info.Exists = true
-	info.ModTime = stat.ModTime()
-
-	// Count plugins
-	content, err := os.ReadFile(backupPath)
-	if err != nil {
-		return info, nil // Return partial info
-	}
-
-	var settings struct {
-		EnabledPlugins map[string]bool `json:"enabledPlugins"`
-	}
-	if err := json.Unmarshal(content, &settings); err == nil {
-		for _, enabled := range settings.EnabledPlugins {
-			if enabled {
-				info.PluginCount++
-			}
-		}
-	}
-
-	return info, nil
-}
-
-// GetLocalBackupInfo returns information about a local scope's backup using project-specific naming
-func GetLocalBackupInfo(claudeupHome, projectDir string) (*BackupInfo, error) {
-	if err := validateClaudeupHome(claudeupHome); err != nil {
-		return nil, err
-	}
-	backupDir := filepath.Join(claudeupHome, "backups")
-
-	// Derive the same hash used when saving
-	hash := sha256.Sum256([]byte(projectDir))
-	shortHash := hex.EncodeToString(hash[:])[:8]
-
-	backupFileName := fmt.Sprintf("local-scope-%s.json", shortHash)
-	backupPath := filepath.Join(backupDir, backupFileName)
-
-	info := &BackupInfo{Path: backupPath}
-
-	stat, err := os.Stat(backupPath)
-	if errors.Is(err, fs.ErrNotExist) {
-		return info, nil
-	}
-	if err != nil {
-		return nil, err
-	}
-
-	info.Exists = true
-	info.ModTime = stat.ModTime()
-
-	// Count plugins
-	content, err := os.ReadFile(backupPath)
-	if err != nil {
-		return info, nil // Return partial info if file can't be read
-	}
-
-	var settings struct {
-		EnabledPlugins map[string]bool `json:"enabledPlugins"`
-	}
-	if err := json.Unmarshal(content, &settings); err == nil {
-		for _, enabled := range settings.EnabledPlugins {
-			if enabled {
-				info.PluginCount++
-			}
-		}
-	}
-
-	return info, nil
-}
@@ -0,0 +1,474 @@
+// ABOUTME: Timestamped backup generations covering every claudeup-managed config file
+// ABOUTME: Creates, lists, prunes, and diffs/restores generations under ~/.claudeup/backups/generations/
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/claudeup/claudeup/v5/internal/events"
+)
+
+// DefaultRetention is how many generations are kept when pruning after a backup.
+const DefaultRetention = 10
+
+const (
+	generationsDirName = "generations"
+	generationManifest = "manifest.json"
+	// generationIDLayout sorts lexically in creation order
+	generationIDLayout = "20060102-150405.000"
+)
+
+// Keys identifying the files a generation can contain.
+const (
+	KeyUserSettings      = "user-settings"
+	KeyProjectSettings   = "project-settings"
+	KeyLocalSettings     = "local-settings"
+	KeyClaudeJSONMCP     = "claude-json-mcp"
+	KeyInstalledPlugins  = "installed-plugins"
+	KeyKnownMarketplaces = "known-marketplaces"
+	KeyEnabledExtensions = "enabled-extensions"
+	KeyProjectMCP        = "project-mcp"
+)
+
+// mcpServersKey is the only part of .claude.json a generation captures. The
+// rest of that file is Claude Code's own state and is left alone.
+const mcpServersKey = "mcpServers"
+
+// ErrNothingToBackup is returned when none of the managed files exist.
+var ErrNothingToBackup = errors.New("no managed files found to back up")
+
+// Sources locates the files a generation captures. ProjectDir is optional;
+// without it only user-level files are covered.
+type Sources struct {
+	ClaudeDir    string
+	ClaudeupHome string
+	ProjectDir   string
+}
+
+// GenerationFile records one file captured in a generation.
+type GenerationFile struct {
+	Key    string      `json:"key"`
+	Path   string      `json:"path"`
+	Scope  string      `json:"scope"`
+	SHA256 string      `json:"sha256"`
+	Mode   fs.FileMode `json:"mode"`
+}
+
+// Generation is one point-in-time backup of the managed files.
+type Generation struct {
+	ID         string           `json:"id"`
+	CreatedAt  time.Time        `json:"createdAt"`
+	Reason     string           `json:"reason,omitempty"`
+	ProjectDir string           `json:"projectDir,omitempty"`
+	Files      []GenerationFile `json:"files"`
+
+	dir string
+}
+
+// targets lists every managed file for these sources, skipping duplicates
+// (running from the home directory makes project and user settings coincide).
+func (s Sources) targets() []GenerationFile {
+	files := []GenerationFile{
+		{Key: KeyUserSettings, Path: filepath.Join(s.ClaudeDir, "settings.json"), Scope: "user"},
+		{Key: KeyClaudeJSONMCP, Path: filepath.Join(s.ClaudeDir, ".claude.json"), Scope: "user"},
+		{Key: KeyInstalledPlugins, Path: filepath.Join(s.ClaudeDir, "plugins", "installed_plugins.json"), Scope: "user"},
+		{Key: KeyKnownMarketplaces, Path: filepath.Join(s.ClaudeDir, "plugins", "known_marketplaces.json"), Scope: "user"},
+		{Key: KeyEnabledExtensions, Path: filepath.Join(s.ClaudeupHome, "enabled.json"), Scope: "user"},
+	}
+	if s.ProjectDir != "" {
+		files = append(files,
+			GenerationFile{Key: KeyProjectSettings, Path: filepath.Join(s.ProjectDir, ".claude", "settings.json"), Scope: "project"},
+			GenerationFile{Key: KeyLocalSettings, Path: filepath.Join(s.ProjectDir, ".claude", "settings.local.json"), Scope: "local"},
+			GenerationFile{Key: KeyProjectMCP, Path: filepath.Join(s.ProjectDir, ".mcp.json"), Scope: "project"},
+		)
+	}
+
+	seen := make(map[string]bool, len(files))
+	unique := files[:0]
+	for _, f := range files {
+		if seen[f.Path] {
+			continue
+		}
+		seen[f.Path] = true
+		unique = append(unique, f)
+	}
+	return unique
+}
+
+func generationsDir(claudeupHome string) string {
+	return filepath.Join(claudeupHome, "backups", generationsDirName)
+}
+
+// CreateGeneration captures the current state of every managed file that
+// exists. Missing files are not recorded, so restoring leaves them alone.
+func CreateGeneration(src Sources, reason string) (*Generation, error) {
+	return createGeneration(src, reason, time.Now())
+}
+
+func createGeneration(src Sources, reason string, now time.Time) (*Generation, error) {
+	if _, err := EnsureBackupDir(src.ClaudeupHome); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	type captured struct {
+		file    GenerationFile
+		content []byte
+	}
+	var files []captured
+	for _, target := range src.targets() {
+		content, mode, err := readManaged(target)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		target.Mode = mode
+		target.SHA256 = sha256Hex(content)
+		files = append(files, captured{file: target, content: content})
+	}
+	if len(files) == 0 {
+		return nil, ErrNothingToBackup
+	}
+
+	gen := &Generation{CreatedAt: now.UTC(), Reason: reason}
+	if err := os.MkdirAll(generationsDir(src.ClaudeupHome), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create generations directory: %w", err)
+	}
+	if err := gen.claimDir(src.ClaudeupHome); err != nil {
+		return nil, err
+	}
+
+	for _, c := range files {
+		if err := os.WriteFile(filepath.Join(gen.dir, c.file.Key+".json"), c.content, 0600); err != nil {
+			os.RemoveAll(gen.dir)
+			return nil, fmt.Errorf("failed to write backup of %s: %w", c.file.Path, err)
+		}
+		if c.file.Scope != "user" {
+			gen.ProjectDir = src.ProjectDir
+		}
+		gen.Files = append(gen.Files, c.file)
+	}
+
+	// The manifest is written last so a partially written generation is never listed
+	data, err := json.MarshalIndent(gen, "", "  ")
+	if err != nil {
+		os.RemoveAll(gen.dir)
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(gen.dir, generationManifest), data, 0600); err != nil {
+		os.RemoveAll(gen.dir)
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return gen, nil
+}
+
+// claimDir picks an unused ID based on the creation time and creates its directory.
+func (g *Generation) claimDir(claudeupHome string) error {
+	base := g.CreatedAt.Format(generationIDLayout)
+	for i := 1; i < 100; i++ {
+		id := base
+		if i > 1 {
+			id = fmt.Sprintf("%s-%d", base, i)
+		}
+		dir := filepath.Join(generationsDir(claudeupHome), id)
+		err := os.Mkdir(dir, 0700)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create generation directory: %w", err)
+		}
+		g.ID, g.dir = id, dir
+		return nil
+	}
+	return fmt.Errorf("too many backups created at %s", base)
+}
+
+// readManaged returns the content a generation stores for a file. For
+// .claude.json that is only the MCP server section.
+func readManaged(target GenerationFile) ([]byte, fs.FileMode, error) {
+	info, err := os.Lstat(target.Path)
+	if err != nil {
+		return nil, 0, err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return nil, 0, fmt.Errorf("%s is a symlink, refusing to back it up", target.Path)
+	}
+	content, err := os.ReadFile(target.Path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", target.Path, err)
+	}
+	if target.Key == KeyClaudeJSONMCP {
+		content, err = extractMCPSection(content)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read MCP servers from %s: %w", target.Path, err)
+		}
+	}
+	return content, info.Mode().Perm(), nil
+}
+
+func extractMCPSection(content []byte) ([]byte, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	section := map[string]json.RawMessage{}
+	if servers, ok := doc[mcpServersKey]; ok {
+		section[mcpServersKey] = servers
+	}
+	return json.MarshalIndent(section, "", "  ")
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// ListGenerations returns all generations, newest first. Directories without
+// a readable manifest are skipped.
+func ListGenerations(claudeupHome string) ([]Generation, error) {
+	if err := validateClaudeupHome(claudeupHome); err != nil {
+		return nil, err
+	}
+	root := generationsDir(claudeupHome)
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backups: %w", err)
+	}
+
+	var gens []Generation
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+		data, err := os.ReadFile(filepath.Join(dir, generationManifest))
+		if err != nil {
+			continue
+		}
+		var gen Generation
+		if err := json.Unmarshal(data, &gen); err != nil || gen.ID != entry.Name() {
+			continue
+		}
+		gen.dir = dir
+		gens = append(gens, gen)
+	}
+
+	sort.Slice(gens, func(i, j int) bool {
+		if !gens[i].CreatedAt.Equal(gens[j].CreatedAt) {
+			return gens[i].CreatedAt.After(gens[j].CreatedAt)
+		}
+		return gens[i].ID > gens[j].ID
+	})
+	return gens, nil
+}
+
+// timeLayouts are the accepted forms for a point in time, interpreted in local time.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// FindGeneration resolves at to a generation. An empty at selects the newest
+// generation; otherwise at is a generation ID, a unique ID prefix, or a time,
+// which selects the newest generation created at or before it.
+func FindGeneration(claudeupHome, at string) (*Generation, error) {
+	gens, err := ListGenerations(claudeupHome)
+	if err != nil {
+		return nil, err
+	}
+	if len(gens) == 0 {
+		return nil, ErrNoBackup
+	}
+	at = strings.TrimSpace(at)
+	if at == "" {
+		return &gens[0], nil
+	}
+
+	var matches []int
+	for i := range gens {
+		if gens[i].ID == at {
+			return &gens[i], nil
+		}
+		if strings.HasPrefix(gens[i].ID, at) {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 1 {
+		return &gens[matches[0]], nil
+	}
+	if len(matches) > 1 {
+		return nil, fmt.Errorf("%q matches %d backups; use a longer ID", at, len(matches))
+	}
+
+	for _, layout := range timeLayouts {
+		t, err := time.ParseInLocation(layout, at, time.Local)
+		if err != nil {
+			continue
+		}
+		for i := range gens {
+			if !gens[i].CreatedAt.After(t) {
+				return &gens[i], nil
+			}
+		}
+		return nil, fmt.Errorf("no backup from before %s", t.Format(time.RFC3339))
+	}
+	return nil, fmt.Errorf("no backup matches %q (expected an ID or a time like 2006-01-02 15:04)", at)
+}
+
+// PruneGenerations deletes all but the newest keep generations and returns
+// the ones removed.
+func PruneGenerations(claudeupHome string, keep int) ([]Generation, error) {
+	if keep < 0 {
+		return nil, fmt.Errorf("keep must not be negative: %d", keep)
+	}
+	gens, err := ListGenerations(claudeupHome)
+	if err != nil {
+		return nil, err
+	}
+	if len(gens) <= keep {
+		return nil, nil
+	}
+	removed := gens[keep:]
+	for _, gen := range removed {
+		if err := os.RemoveAll(gen.dir); err != nil {
+			return nil, fmt.Errorf("failed to remove backup %s: %w", gen.ID, err)
+		}
+	}
+	return removed, nil
+}
+
+// FileChange describes what restoring one file of a generation would change.
+// Diff compares the file on disk (before) with the backed-up copy (after).
+type FileChange struct {
+	File GenerationFile
+	Diff *events.DiffResult
+
+	content []byte
+}
+
+// PlanRestore compares a generation with the files currently on disk and
+// returns the files a restore would change.
+func PlanRestore(g *Generation) ([]FileChange, error) {
+	var changes []FileChange
+	for _, file := range g.Files {
+		content, err := os.ReadFile(filepath.Join(g.dir, file.Key+".json"))
+		if err != nil {
+			return nil, fmt.Errorf("backup %s is missing %s: %w", g.ID, file.Key, err)
+		}
+		if sha256Hex(content) != file.SHA256 {
+			return nil, fmt.Errorf("backup %s is corrupt: %s does not match its checksum", g.ID, file.Key)
+		}
+
+		var before *events.Snapshot
+		current, _, err := readManaged(file)
+		switch {
+		case err == nil:
+			before = snapshotOf(current)
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		}
+
+		diff := events.DiffSnapshots(before, snapshotOf(content), true)
+		if !diff.HasChanges {
+			continue
+		}
+		changes = append(changes, FileChange{File: file, Diff: diff, content: content})
+	}
+	return changes, nil
+}
+
+func snapshotOf(content []byte) *events.Snapshot {
+	return &events.Snapshot{Hash: sha256Hex(content), Size: int64(len(content)), Content: string(content)}
+}
+
+// Apply writes the backed-up content over the current file. The MCP section
+// is merged back into .claude.json rather than replacing the whole file.
+func (c FileChange) Apply() error {
+	content := c.content
+	mode := c.File.Mode
+	if info, err := os.Lstat(c.File.Path); err == nil {
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a symlink, refusing to overwrite", c.File.Path)
+		}
+		mode = info.Mode().Perm()
+	}
+	if mode == 0 {
+		mode = 0600
+	}
+
+	if c.File.Key == KeyClaudeJSONMCP {
+		merged, err := mergeMCPSection(c.File.Path, content)
+		if err != nil {
+			return err
+		}
+		content = merged
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.File.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", c.File.Path, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.File.Path), "."+filepath.Base(c.File.Path)+".restore-*")
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", c.File.Path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to restore %s: %w", c.File.Path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", c.File.Path, err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("failed to set permissions on %s: %w", c.File.Path, err)
+	}
+	if err := os.Rename(tmp.Name(), c.File.Path); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", c.File.Path, err)
+	}
+	return nil
+}
+
+// mergeMCPSection replaces the mcpServers key of the .claude.json at path
+// with the one from section, keeping every other key as it is now.
+func mergeMCPSection(path string, section []byte) ([]byte, error) {
+	doc := map[string]json.RawMessage{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+
+	var backedUp map[string]json.RawMessage
+	if err := json.Unmarshal(section, &backedUp); err != nil {
+		return nil, fmt.Errorf("invalid MCP backup: %w", err)
+	}
+	if servers, ok := backedUp[mcpServersKey]; ok {
+		doc[mcpServersKey] = servers
+	} else {
+		delete(doc, mcpServersKey)
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
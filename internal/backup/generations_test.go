@@ -0,0 +1,318 @@
+// ABOUTME: Tests for multi-file backup generations
+// ABOUTME: Covers capture, lookup by ID and time, pruning, and diffed restores
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestSources(t *testing.T) Sources {
+	t.Helper()
+	tempDir := t.TempDir()
+	src := Sources{
+		ClaudeDir:    filepath.Join(tempDir, ".claude"),
+		ClaudeupHome: filepath.Join(tempDir, ".claudeup"),
+		ProjectDir:   filepath.Join(tempDir, "project"),
+	}
+	for _, dir := range []string{filepath.Join(src.ClaudeDir, "plugins"), src.ClaudeupHome, src.ProjectDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return src
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateGenerationCapturesExistingFiles(t *testing.T) {
+	src := newTestSources(t)
+	writeTestFile(t, filepath.Join(src.ClaudeDir, "settings.json"), `{"enabledPlugins":{"a@m":true}}`)
+	writeTestFile(t, filepath.Join(src.ClaudeDir, ".claude.json"), `{"numStartups":3,"mcpServers":{"db":{"command":"db-mcp"}}}`)
+	writeTestFile(t, filepath.Join(src.ClaudeupHome, "enabled.json"), `{"agents":{"a.md":true}}`)
+	writeTestFile(t, filepath.Join(src.ProjectDir, ".mcp.json"), `{"mcpServers":{}}`)
+
+	gen, err := CreateGeneration(src, "manual")
+	if err != nil {
+		t.Fatalf("CreateGeneration failed: %v", err)
+	}
+
+	keys := make(map[string]string)
+	for _, f := range gen.Files {
+		keys[f.Key] = f.Path
+	}
+	for _, key := range []string{KeyUserSettings, KeyClaudeJSONMCP, KeyEnabledExtensions, KeyProjectMCP} {
+		if _, ok := keys[key]; !ok {
+			t.Errorf("expected %s to be captured, got %v", key, keys)
+		}
+	}
+	if _, ok := keys[KeyInstalledPlugins]; ok {
+		t.Error("missing installed_plugins.json should not be captured")
+	}
+	if gen.ProjectDir != src.ProjectDir {
+		t.Errorf("ProjectDir = %q, want %q", gen.ProjectDir, src.ProjectDir)
+	}
+
+	// Only the MCP section of .claude.json is kept
+	data, err := os.ReadFile(filepath.Join(gen.dir, KeyClaudeJSONMCP+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "numStartups") {
+		t.Errorf("expected only mcpServers in backup, got %s", data)
+	}
+}
+
+func TestCreateGenerationNothingToBackup(t *testing.T) {
+	src := newTestSources(t)
+	if _, err := CreateGeneration(src, "manual"); !errors.Is(err, ErrNothingToBackup) {
+		t.Errorf("expected ErrNothingToBackup, got %v", err)
+	}
+}
+
+func TestCreateGenerationRejectsSymlinks(t *testing.T) {
+	src := newTestSources(t)
+	target := filepath.Join(t.TempDir(), "elsewhere.json")
+	writeTestFile(t, target, `{}`)
+	if err := os.Symlink(target, filepath.Join(src.ClaudeDir, "settings.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := CreateGeneration(src, "manual")
+	if err == nil || !strings.Contains(err.Error(), "symlink") {
+		t.Errorf("expected symlink error, got %v", err)
+	}
+}
+
+func TestCreateGenerationSkipsDuplicatePaths(t *testing.T) {
+	src := newTestSources(t)
+	// Running from the home directory makes project settings the user settings
+	src.ProjectDir = filepath.Dir(src.ClaudeDir)
+	writeTestFile(t, filepath.Join(src.ClaudeDir, "settings.json"), `{}`)
+
+	gen, err := CreateGeneration(src, "manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gen.Files) != 1 || gen.Files[0].Key != KeyUserSettings {
+		t.Errorf("expected only user settings, got %+v", gen.Files)
+	}
+}
+
+func TestFindGeneration(t *testing.T) {
+	src := newTestSources(t)
+	writeTestFile(t, filepath.Join(src.ClaudeDir, "settings.json"), `{}`)
+
+	if _, err := FindGeneration(src.ClaudeupHome, ""); !errors.Is(err, ErrNoBackup) {
+		t.Fatalf("expected ErrNoBackup with no generations, got %v", err)
+	}
+
+	older, err := createGeneration(src, "first", time.Date(2026, 3, 1, 9, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newer, err := createGeneration(src, "second", time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		at      string
+		want    string
+		wantErr string
+	}{
+		{at: "", want: newer.ID},
+		{at: older.ID, want: older.ID},
+		{at: newer.ID[:12], want: newer.ID},
+		{at: "2026-03-01 12:00", want: older.ID},
+		{at: "2026-03-03", want: newer.ID},
+		{at: "2026-02-28", wantErr: "no backup from before"},
+		{at: "2026030", wantErr: "matches 2 backups"},
+		{at: "yesterday", wantErr: "no backup matches"},
+	}
+	for _, tt := range tests {
+		gen, err := FindGeneration(src.ClaudeupHome, tt.at)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("FindGeneration(%q) error = %v, want %q", tt.at, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("FindGeneration(%q) failed: %v", tt.at, err)
+			continue
+		}
+		if gen.ID != tt.want {
+			t.Errorf("FindGeneration(%q) = %s, want %s", tt.at, gen.ID, tt.want)
+		}
+	}
+}
+
+func TestCreateGenerationSameInstant(t *testing.T) {
+	src := newTestSources(t)
+	writeTestFile(t, filepath.Join(src.ClaudeDir, "settings.json"), `{}`)
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	first, err := createGeneration(src, "a", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := createGeneration(src, "b", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ID == second.ID {
+		t.Fatalf("expected distinct IDs, both %s", first.ID)
+	}
+}
+
+func TestPruneGenerations(t *testing.T) {
+	src := newTestSources(t)
+	writeTestFile(t, filepath.Join(src.ClaudeDir, "settings.json"), `{}`)
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		if _, err := createGeneration(src, "", start.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := PruneGenerations(src.ClaudeupHome, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 {
+		t.Fatalf("expected 2 removed, got %d", len(removed))
+	}
+
+	gens, err := ListGenerations(src.ClaudeupHome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gens) != 2 || !gens[0].CreatedAt.Equal(start.Add(3*time.Hour)) || !gens[1].CreatedAt.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("expected the two newest generations to remain, got %+v", gens)
+	}
+
+	if _, err := PruneGenerations(src.ClaudeupHome, -1); err == nil {
+		t.Error("expected error for negative keep")
+	}
+}
+
+func TestPlanAndApplyRestore(t *testing.T) {
+	src := newTestSources(t)
+	settingsPath := filepath.Join(src.ClaudeDir, "settings.json")
+	claudeJSONPath := filepath.Join(src.ClaudeDir, ".claude.json")
+	localPath := filepath.Join(src.ProjectDir, ".claude", "settings.local.json")
+	writeTestFile(t, settingsPath, `{"enabledPlugins":{"a@m":true}}`)
+	writeTestFile(t, claudeJSONPath, `{"numStartups":3,"mcpServers":{"db":{"command":"db-mcp"}}}`)
+	writeTestFile(t, localPath, `{"model":"opus"}`)
+
+	gen, err := CreateGeneration(src, "manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Change two files; Claude Code updates unrelated .claude.json state meanwhile
+	writeTestFile(t, settingsPath, `{"enabledPlugins":{"b@m":true}}`)
+	writeTestFile(t, claudeJSONPath, `{"numStartups":9,"mcpServers":{}}`)
+
+	changes, err := PlanRestore(gen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d: %+v", len(changes), changes)
+	}
+	for _, change := range changes {
+		if change.File.Key == KeyLocalSettings {
+			t.Error("unchanged local settings should not be in the plan")
+		}
+		if !strings.Contains(change.Diff.Summary, "~") && !strings.Contains(change.Diff.Summary, "+") {
+			t.Errorf("expected a field diff for %s, got %q", change.File.Key, change.Diff.Summary)
+		}
+		if err := change.Apply(); err != nil {
+			t.Fatalf("Apply %s failed: %v", change.File.Key, err)
+		}
+	}
+
+	data, _ := os.ReadFile(settingsPath)
+	if !strings.Contains(string(data), "a@m") {
+		t.Errorf("settings not restored: %s", data)
+	}
+
+	var claudeJSON map[string]any
+	data, _ = os.ReadFile(claudeJSONPath)
+	if err := json.Unmarshal(data, &claudeJSON); err != nil {
+		t.Fatal(err)
+	}
+	if claudeJSON["numStartups"] != float64(9) {
+		t.Errorf("restore should keep non-MCP keys, got %v", claudeJSON["numStartups"])
+	}
+	servers, _ := claudeJSON["mcpServers"].(map[string]any)
+	if _, ok := servers["db"]; !ok {
+		t.Errorf("MCP servers not restored: %v", claudeJSON["mcpServers"])
+	}
+
+	if changes, err := PlanRestore(gen); err != nil || len(changes) != 0 {
+		t.Errorf("expected nothing left to restore, got %d changes (err %v)", len(changes), err)
+	}
+}
+
+func TestPlanRestoreRecreatesDeletedFile(t *testing.T) {
+	src := newTestSources(t)
+	mcpPath := filepath.Join(src.ProjectDir, ".mcp.json")
+	writeTestFile(t, mcpPath, `{"mcpServers":{"x":{}}}`)
+
+	gen, err := CreateGeneration(src, "manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(mcpPath); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := PlanRestore(gen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || !strings.Contains(changes[0].Diff.Summary, "File created") {
+		t.Fatalf("expected the file to be recreated, got %+v", changes)
+	}
+	if err := changes[0].Apply(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(mcpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("expected original mode 0644, got %o", info.Mode().Perm())
+	}
+}
+
+func TestPlanRestoreDetectsCorruptBackup(t *testing.T) {
+	src := newTestSources(t)
+	writeTestFile(t, filepath.Join(src.ClaudeDir, "settings.json"), `{}`)
+
+	gen, err := CreateGeneration(src, "manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(gen.dir, KeyUserSettings+".json"), `{"tampered":true}`)
+
+	if _, err := PlanRestore(gen); err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Errorf("expected corrupt backup error, got %v", err)
+	}
+}
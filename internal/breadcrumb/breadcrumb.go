@@ -9,18 +9,43 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
 const filename = "last-applied.json"
 
 // Entry records when a profile was applied at a scope.
+// Layers lists the profiles of an ad-hoc stack applied together
+// (profile apply a b c); Profile then holds their joined name.
 type Entry struct {
 	Profile    string    `json:"profile"`
+	Layers     []string  `json:"layers,omitempty"`
 	AppliedAt  time.Time `json:"appliedAt"`
 	ProjectDir string    `json:"projectDir,omitempty"`
 }
 
+// LayerSeparator joins layer names into the Profile of a layered entry.
+const LayerSeparator = "+"
+
+// Profiles returns the layers of a layered entry, or its single profile.
+func (e Entry) Profiles() []string {
+	if len(e.Layers) > 0 {
+		return e.Layers
+	}
+	return []string{e.Profile}
+}
+
+// HasProfile reports whether the entry is for the profile or has it as a layer.
+func (e Entry) HasProfile(name string) bool {
+	for _, p := range e.Profiles() {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
 // File holds per-scope breadcrumb entries.
 type File map[string]Entry
 
@@ -75,9 +100,20 @@ func Save(claudeupHome string, f File) error {
 // breadcrumb file cannot be read, returns the error rather than
 // silently discarding existing entries.
 func Record(claudeupHome, profileName, projectDir string, scopes []string) error {
-	if profileName == "" {
+	return RecordLayers(claudeupHome, []string{profileName}, projectDir, scopes)
+}
+
+// RecordLayers is Record for profiles applied together as an ad-hoc stack.
+// A single layer is recorded exactly like Record.
+func RecordLayers(claudeupHome string, layers []string, projectDir string, scopes []string) error {
+	if len(layers) == 0 {
 		return fmt.Errorf("breadcrumb: profile name must not be empty")
 	}
+	for _, name := range layers {
+		if name == "" {
+			return fmt.Errorf("breadcrumb: profile name must not be empty")
+		}
+	}
 	f, err := Load(claudeupHome)
 	if err != nil {
 		return fmt.Errorf("loading existing breadcrumb: %w", err)
@@ -95,9 +131,12 @@ func Record(claudeupHome, profileName, projectDir string, scopes []string) error
 	now := time.Now().UTC()
 	for _, scope := range scopes {
 		entry := Entry{
-			Profile:   profileName,
+			Profile:   strings.Join(layers, LayerSeparator),
 			AppliedAt: now,
 		}
+		if len(layers) > 1 {
+			entry.Layers = append([]string(nil), layers...)
+		}
 		if scope != "user" && resolved != "" {
 			entry.ProjectDir = resolved
 		}
@@ -106,7 +145,8 @@ func Record(claudeupHome, profileName, projectDir string, scopes []string) error
 	return Save(claudeupHome, f)
 }
 
-// Remove deletes breadcrumb entries referencing the given profile name.
+// Remove deletes breadcrumb entries referencing the given profile name,
+// including layered entries that have it as a layer.
 // Deletes the breadcrumb file entirely when no entries remain.
 func Remove(claudeupHome, profileName string) error {
 	f, err := Load(claudeupHome)
@@ -118,7 +158,7 @@ func Remove(claudeupHome, profileName string) error {
 	}
 	changed := false
 	for scope, entry := range f {
-		if entry.Profile == profileName {
+		if entry.HasProfile(profileName) {
 			delete(f, scope)
 			changed = true
 		}
@@ -136,7 +176,8 @@ func Remove(claudeupHome, profileName string) error {
 	return Save(claudeupHome, f)
 }
 
-// Rename updates breadcrumb entries from oldName to newName.
+// Rename updates breadcrumb entries, and layers of layered entries,
+// from oldName to newName.
 func Rename(claudeupHome, oldName, newName string) error {
 	f, err := Load(claudeupHome)
 	if err != nil {
@@ -147,11 +188,24 @@ func Rename(claudeupHome, oldName, newName string) error {
 	}
 	changed := false
 	for scope, entry := range f {
-		if entry.Profile == oldName {
+		if !entry.HasProfile(oldName) {
+			continue
+		}
+		if len(entry.Layers) > 0 {
+			layers := make([]string, len(entry.Layers))
+			for i, l := range entry.Layers {
+				if l == oldName {
+					l = newName
+				}
+				layers[i] = l
+			}
+			entry.Layers = layers
+			entry.Profile = strings.Join(layers, LayerSeparator)
+		} else {
 			entry.Profile = newName
-			f[scope] = entry
-			changed = true
 		}
+		f[scope] = entry
+		changed = true
 	}
 	if !changed {
 		return nil
@@ -427,3 +427,53 @@ func TestFilterByDirNil(t *testing.T) {
 		t.Fatalf("expected empty result, got %d entries", len(filtered))
 	}
 }
+
+func TestRecordLayers(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := RecordLayers(dir, []string{"base", "python-dev", "security"}, "", []string{"user"}); err != nil {
+		t.Fatalf("record failed: %v", err)
+	}
+
+	f, _ := Load(dir)
+	entry := f["user"]
+	if entry.Profile != "base+python-dev+security" {
+		t.Fatalf("expected joined profile name, got %s", entry.Profile)
+	}
+	if len(entry.Layers) != 3 || !entry.HasProfile("python-dev") || entry.HasProfile("python") {
+		t.Fatalf("unexpected layers: %v", entry.Layers)
+	}
+
+	// A single layer is a plain entry
+	RecordLayers(dir, []string{"base"}, "", []string{"user"})
+	f, _ = Load(dir)
+	if f["user"].Profile != "base" || f["user"].Layers != nil {
+		t.Fatalf("expected plain entry, got %+v", f["user"])
+	}
+}
+
+func TestRenameAndRemoveLayer(t *testing.T) {
+	dir := t.TempDir()
+
+	RecordLayers(dir, []string{"base", "security"}, "", []string{"user"})
+	Record(dir, "other", "/projects/foo", []string{"project"})
+
+	if err := Rename(dir, "security", "hardened"); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+	f, _ := Load(dir)
+	if f["user"].Profile != "base+hardened" || f["user"].Layers[1] != "hardened" {
+		t.Fatalf("expected renamed layer, got %+v", f["user"])
+	}
+
+	if err := Remove(dir, "base"); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	f, _ = Load(dir)
+	if _, ok := f["user"]; ok {
+		t.Fatal("expected layered entry to be removed with one of its layers")
+	}
+	if f["project"].Profile != "other" {
+		t.Fatalf("expected other entry preserved, got %+v", f["project"])
+	}
+}
@@ -1,5 +1,5 @@
 // ABOUTME: Data structures and functions for managing Claude Code marketplaces
-// ABOUTME: Handles reading known_marketplaces.json
+// ABOUTME: Handles reading and writing known_marketplaces.json
 package claude
 
 import (
@@ -9,6 +9,8 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/internal/events"
 )
 
 // MarketplaceRegistry represents the known_marketplaces.json file structure
@@ -26,6 +28,42 @@ type MarketplaceSource struct {
 	Source string `json:"source"`
 	Repo   string `json:"repo,omitempty"`
 	URL    string `json:"url,omitempty"`
+	Path   string `json:"path,omitempty"` // Set for directory sources
+	Ref    string `json:"ref,omitempty"`  // Branch, tag, or commit for git sources
+}
+
+// Location returns the repo, URL, or directory path the marketplace comes from
+func (s MarketplaceSource) Location() string {
+	switch {
+	case s.Repo != "":
+		return s.Repo
+	case s.URL != "":
+		return s.URL
+	}
+	return s.Path
+}
+
+// IsDirectory reports whether the marketplace is a local directory rather than a git clone
+func (s MarketplaceSource) IsDirectory() bool {
+	return s.Source == "directory" || (s.Repo == "" && s.URL == "" && s.Path != "")
+}
+
+// matches reports whether identifier names this source's repo, URL, or directory path
+func (s MarketplaceSource) matches(identifier string) bool {
+	if identifier == "" {
+		return false
+	}
+	if s.Repo == identifier || s.URL == identifier {
+		return true
+	}
+	if s.Path == "" {
+		return false
+	}
+	if s.Path == identifier {
+		return true
+	}
+	abs, err := filepath.Abs(identifier)
+	return err == nil && filepath.Clean(s.Path) == abs
 }
 
 // MarketplaceIndex represents the .claude-plugin/marketplace.json file
@@ -106,20 +144,45 @@ func LoadMarketplaces(claudeDir string) (MarketplaceRegistry, error) {
 	return registry, nil
 }
 
-// MarketplaceExists checks if a marketplace with the given repo or URL is installed
+// SaveMarketplaces writes the registry back to known_marketplaces.json,
+// creating the plugins directory if needed
+func SaveMarketplaces(claudeDir string, registry MarketplaceRegistry) error {
+	pluginsDir := filepath.Join(claudeDir, "plugins")
+	if err := os.MkdirAll(pluginsDir, 0755); err != nil {
+		return fmt.Errorf("cannot create plugins directory %s: %w", pluginsDir, err)
+	}
+
+	data, err := json.MarshalIndent(registry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	marketplacesPath := filepath.Join(pluginsDir, "known_marketplaces.json")
+	return events.GlobalTracker().RecordFileWrite(
+		"marketplace update",
+		marketplacesPath,
+		"user",
+		func() error {
+			return os.WriteFile(marketplacesPath, data, 0644)
+		},
+	)
+}
+
+// MarketplaceExists checks if a marketplace with the given repo, URL, or directory path is installed
 func (r MarketplaceRegistry) MarketplaceExists(repoOrURL string) bool {
 	for _, meta := range r {
-		if meta.Source.Repo == repoOrURL || meta.Source.URL == repoOrURL {
+		if meta.Source.matches(repoOrURL) {
 			return true
 		}
 	}
 	return false
 }
 
-// GetMarketplaceByRepo returns the marketplace name for a given repo, or empty string if not found
+// GetMarketplaceByRepo returns the marketplace name for a given repo, URL, or directory path,
+// or empty string if not found
 func (r MarketplaceRegistry) GetMarketplaceByRepo(repoOrURL string) string {
 	for name, meta := range r {
-		if meta.Source.Repo == repoOrURL || meta.Source.URL == repoOrURL {
+		if meta.Source.matches(repoOrURL) {
 			return name
 		}
 	}
@@ -154,7 +217,7 @@ func LoadMarketplaceIndex(installLocation string) (*MarketplaceIndex, error) {
 	return &index, nil
 }
 
-// FindMarketplace finds a marketplace by name, repo, or URL
+// FindMarketplace finds a marketplace by name, repo, URL, or directory path
 // Returns the marketplace metadata, its key in the registry, and any error
 func FindMarketplace(claudeDir string, identifier string) (*MarketplaceMetadata, string, error) {
 	registry, err := LoadMarketplaces(claudeDir)
@@ -167,9 +230,9 @@ func FindMarketplace(claudeDir string, identifier string) (*MarketplaceMetadata,
 		return &meta, identifier, nil
 	}
 
-	// Check by repo or URL
+	// Check by repo, URL, or directory path
 	for name, meta := range registry {
-		if meta.Source.Repo == identifier || meta.Source.URL == identifier {
+		if meta.Source.matches(identifier) {
 			return &meta, name, nil
 		}
 	}
@@ -207,30 +207,84 @@ func (r *PluginRegistry) GetPluginsForContext(scopes []string, projectDir string
 		return all
 	}
 
-	// Resolve symlinks for reliable comparison (e.g., /var → /private/var on macOS)
-	resolvedDir, err := filepath.EvalSymlinks(projectDir)
-	if err != nil {
-		resolvedDir = filepath.Clean(projectDir)
-	}
+	resolvedDir := resolveDir(projectDir)
 	var result []ScopedPlugin
 	for _, sp := range all {
 		if sp.Scope == ScopeUser {
 			result = append(result, sp)
 			continue
 		}
-		pluginDir := sp.ProjectPath
-		if resolved, err := filepath.EvalSymlinks(pluginDir); err == nil {
-			pluginDir = resolved
-		} else {
-			pluginDir = filepath.Clean(pluginDir)
-		}
-		if pluginDir == resolvedDir {
+		if resolveDir(sp.ProjectPath) == resolvedDir {
 			result = append(result, sp)
 		}
 	}
 	return result
 }
 
+// resolveDir resolves symlinks for reliable path comparison
+// (e.g., /var → /private/var on macOS), falling back to a cleaned path.
+func resolveDir(dir string) string {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return resolved
+	}
+	return filepath.Clean(dir)
+}
+
+// RemovePluginInstance removes the instance of a plugin installed at scope
+// for the given project. User-scope instances match regardless of projectDir;
+// project and local instances must have a matching ProjectPath unless
+// projectDir is empty. Returns true if an instance was removed.
+func (r *PluginRegistry) RemovePluginInstance(pluginName, scope, projectDir string) bool {
+	instances, exists := r.Plugins[pluginName]
+	if !exists {
+		return false
+	}
+
+	for i, inst := range instances {
+		if inst.Scope != scope {
+			continue
+		}
+		if scope != ScopeUser && projectDir != "" && resolveDir(inst.ProjectPath) != resolveDir(projectDir) {
+			continue
+		}
+		remaining := slices.Delete(slices.Clone(instances), i, i+1)
+		if len(remaining) == 0 {
+			delete(r.Plugins, pluginName)
+		} else {
+			r.Plugins[pluginName] = remaining
+		}
+		return true
+	}
+	return false
+}
+
+// GetPluginInstance retrieves the instance of a plugin installed at scope
+// for the given project, matching the way RemovePluginInstance does.
+// Returns (metadata, true) if found, (zero, false) if not.
+func (r *PluginRegistry) GetPluginInstance(pluginName, scope, projectDir string) (PluginMetadata, bool) {
+	for _, inst := range r.Plugins[pluginName] {
+		if inst.Scope != scope {
+			continue
+		}
+		if scope != ScopeUser && projectDir != "" && resolveDir(inst.ProjectPath) != resolveDir(projectDir) {
+			continue
+		}
+		return inst, true
+	}
+	return PluginMetadata{}, false
+}
+
+// SetPluginInstance replaces the instance at metadata's scope and project
+// path, leaving instances of the same scope in other projects alone. Adds
+// the instance if none matches.
+func (r *PluginRegistry) SetPluginInstance(pluginName string, metadata PluginMetadata) {
+	if metadata.Scope == "" {
+		metadata.Scope = ScopeUser
+	}
+	r.RemovePluginInstance(pluginName, metadata.Scope, metadata.ProjectPath)
+	r.Plugins[pluginName] = append(r.Plugins[pluginName], metadata)
+}
+
 // PluginExistsAtScope checks if a plugin is installed at a specific scope
 func (r *PluginRegistry) PluginExistsAtScope(pluginName, scope string) bool {
 	_, exists := r.GetPluginAtScope(pluginName, scope)
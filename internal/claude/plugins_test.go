@@ -545,6 +545,76 @@ func TestRemovePluginAtScope(t *testing.T) {
 	}
 }
 
+func TestRemovePluginInstanceMatchesProject(t *testing.T) {
+	projectA := t.TempDir()
+	projectB := t.TempDir()
+	registry := &PluginRegistry{
+		Version: 2,
+		Plugins: map[string][]PluginMetadata{
+			"p@mp": {
+				{Scope: "user", Version: "1.0.0"},
+				{Scope: "project", Version: "1.0.0", ProjectPath: projectA},
+				{Scope: "project", Version: "1.0.0", ProjectPath: projectB},
+			},
+		},
+	}
+
+	// Project instances for other directories are left alone
+	if !registry.RemovePluginInstance("p@mp", "project", projectB) {
+		t.Fatal("should remove project instance for matching directory")
+	}
+	instances := registry.GetPluginInstances("p@mp")
+	if len(instances) != 2 {
+		t.Fatalf("expected 2 instances remaining, got %d", len(instances))
+	}
+	for _, inst := range instances {
+		if inst.ProjectPath == projectB {
+			t.Error("instance for projectB should have been removed")
+		}
+	}
+
+	// No instance left for projectB
+	if registry.RemovePluginInstance("p@mp", "project", projectB) {
+		t.Error("should return false when no instance matches the project")
+	}
+
+	// User scope ignores projectDir
+	if !registry.RemovePluginInstance("p@mp", "user", projectB) {
+		t.Error("should remove user instance regardless of projectDir")
+	}
+	if registry.PluginExistsAtScope("p@mp", "user") {
+		t.Error("user instance should be gone")
+	}
+}
+
+func TestPluginInstanceMatchesProject(t *testing.T) {
+	projectA := t.TempDir()
+	projectB := t.TempDir()
+	registry := &PluginRegistry{
+		Version: 2,
+		Plugins: map[string][]PluginMetadata{
+			"p@mp": {
+				{Scope: "project", Version: "1.0.0", ProjectPath: projectA},
+				{Scope: "project", Version: "1.0.0", ProjectPath: projectB},
+			},
+		},
+	}
+
+	registry.SetPluginInstance("p@mp", PluginMetadata{Scope: "project", Version: "2.0.0", ProjectPath: projectB})
+	if got := len(registry.GetPluginInstances("p@mp")); got != 2 {
+		t.Fatalf("expected 2 instances, got %d", got)
+	}
+	if inst, ok := registry.GetPluginInstance("p@mp", "project", projectB); !ok || inst.Version != "2.0.0" {
+		t.Errorf("projectB instance = %+v, %v; want version 2.0.0", inst, ok)
+	}
+	if inst, ok := registry.GetPluginInstance("p@mp", "project", projectA); !ok || inst.Version != "1.0.0" {
+		t.Errorf("projectA instance should be untouched, got %+v, %v", inst, ok)
+	}
+	if _, ok := registry.GetPluginInstance("p@mp", "local", projectA); ok {
+		t.Error("should not find an instance at another scope")
+	}
+}
+
 func TestSetPluginDeduplicatesScopeEntries(t *testing.T) {
 	registry := &PluginRegistry{
 		Version: 2,
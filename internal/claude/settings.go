@@ -413,6 +413,26 @@ func (s *Settings) RemovePlugin(pluginName string) {
 	delete(s.EnabledPlugins, pluginName)
 }
 
+// Get returns the raw value of a top-level settings key as decoded from JSON.
+func (s *Settings) Get(key string) (interface{}, bool) {
+	value, ok := s.raw[key]
+	return value, ok
+}
+
+// Set stores a top-level settings key. enabledPlugins is always written from
+// EnabledPlugins on save, so setting it here has no effect.
+func (s *Settings) Set(key string, value interface{}) {
+	if s.raw == nil {
+		s.raw = make(map[string]interface{})
+	}
+	s.raw[key] = value
+}
+
+// Delete removes a top-level settings key.
+func (s *Settings) Delete(key string) {
+	delete(s.raw, key)
+}
+
 // SaveSettings writes the settings back to settings.json
 func SaveSettings(claudeDir string, settings *Settings) error {
 	settingsPath := filepath.Join(claudeDir, "settings.json")
@@ -562,9 +582,11 @@ func SaveSettingsForScope(scope string, claudeDir string, projectDir string, set
 	)
 }
 
-// MergeHooks merges new hooks into settings, deduplicating by command string.
-// Hooks are added without removing existing ones.
-// newHooks format: map[eventType][]hookEntry where hookEntry has "type" and "command" keys.
+// MergeHooks merges hooks into settings by event type. Each hook map holds
+// "type", "command" or "prompt", and optionally "timeout" and "matcher". Hooks
+// are grouped under the matcher entry with the same pattern, which is created
+// when missing. A hook already in that group with the same command (or
+// prompt) is updated in place, so merging the same hooks again is a no-op.
 func (s *Settings) MergeHooks(newHooks map[string][]map[string]interface{}) error {
 	if s.raw == nil {
 		s.raw = make(map[string]interface{})
@@ -579,50 +601,138 @@ func (s *Settings) MergeHooks(newHooks map[string][]map[string]interface{}) erro
 		s.raw["hooks"] = hooks
 	}
 
-	// For each event type in newHooks
 	for eventType, entries := range newHooks {
-		// Get existing hooks for this event type
-		var existingEntries []interface{}
-		if existing, ok := hooks[eventType].([]interface{}); ok {
-			existingEntries = existing
-		}
+		groups, _ := hooks[eventType].([]interface{})
 
-		// Collect all existing commands for deduplication
-		existingCommands := make(map[string]bool)
-		for _, entry := range existingEntries {
-			if entryMap, ok := entry.(map[string]interface{}); ok {
-				if hooksList, ok := entryMap["hooks"].([]interface{}); ok {
-					for _, hook := range hooksList {
-						if hookMap, ok := hook.(map[string]interface{}); ok {
-							if cmd, ok := hookMap["command"].(string); ok {
-								existingCommands[cmd] = true
-							}
-						}
-					}
+		for _, entry := range entries {
+			matcher, hook := splitHookMatcher(entry)
+			key := hookKey(hook)
+			if key == "" {
+				continue
+			}
+
+			group := findHookGroup(groups, matcher)
+			if group == nil {
+				group = map[string]interface{}{"hooks": []interface{}{}}
+				if matcher != "" {
+					group["matcher"] = matcher
+				}
+				groups = append(groups, group)
+			}
+
+			list, _ := group["hooks"].([]interface{})
+			replaced := false
+			for i, existing := range list {
+				if existingMap, ok := existing.(map[string]interface{}); ok && hookKey(existingMap) == key {
+					list[i] = hook
+					replaced = true
+					break
 				}
 			}
+			if !replaced {
+				list = append(list, hook)
+			}
+			group["hooks"] = list
+		}
+
+		if len(groups) > 0 {
+			hooks[eventType] = groups
 		}
+	}
+
+	return nil
+}
+
+// RemoveHooks removes hooks matched by event type, matcher, and command (or
+// prompt), in the same format MergeHooks takes. Matcher groups and event
+// types left empty are dropped. Returns the number of hooks removed.
+func (s *Settings) RemoveHooks(remove map[string][]map[string]interface{}) int {
+	hooks, ok := s.raw["hooks"].(map[string]interface{})
+	if !ok {
+		return 0
+	}
 
-		// Filter new hooks to only include non-duplicates
-		var newHooksList []interface{}
+	removed := 0
+	for eventType, entries := range remove {
+		groups, _ := hooks[eventType].([]interface{})
 		for _, entry := range entries {
-			if cmd, ok := entry["command"].(string); ok {
-				if !existingCommands[cmd] {
-					newHooksList = append(newHooksList, entry)
-					existingCommands[cmd] = true
+			matcher, hook := splitHookMatcher(entry)
+			key := hookKey(hook)
+			group := findHookGroup(groups, matcher)
+			if group == nil || key == "" {
+				continue
+			}
+			list, _ := group["hooks"].([]interface{})
+			kept := make([]interface{}, 0, len(list))
+			for _, existing := range list {
+				if existingMap, ok := existing.(map[string]interface{}); ok && hookKey(existingMap) == key {
+					removed++
+					continue
 				}
+				kept = append(kept, existing)
 			}
+			group["hooks"] = kept
 		}
 
-		if len(newHooksList) > 0 {
-			// Add as a new entry with no matcher (applies to all)
-			newEntry := map[string]interface{}{
-				"hooks": newHooksList,
+		var keptGroups []interface{}
+		for _, group := range groups {
+			if groupMap, ok := group.(map[string]interface{}); ok {
+				if list, _ := groupMap["hooks"].([]interface{}); len(list) == 0 {
+					continue
+				}
 			}
-			existingEntries = append(existingEntries, newEntry)
-			hooks[eventType] = existingEntries
+			keptGroups = append(keptGroups, group)
+		}
+		if len(keptGroups) == 0 {
+			delete(hooks, eventType)
+		} else if _, exists := hooks[eventType]; exists {
+			hooks[eventType] = keptGroups
 		}
 	}
 
+	if len(hooks) == 0 {
+		delete(s.raw, "hooks")
+	}
+	return removed
+}
+
+// splitHookMatcher separates the "matcher" key from a hook map, returning the
+// matcher and a copy of the hook without it.
+func splitHookMatcher(entry map[string]interface{}) (string, map[string]interface{}) {
+	matcher, _ := entry["matcher"].(string)
+	hook := make(map[string]interface{}, len(entry))
+	for k, v := range entry {
+		if k != "matcher" {
+			hook[k] = v
+		}
+	}
+	return matcher, hook
+}
+
+// hookKey identifies a hook within a matcher group by its command, or by its
+// prompt for prompt hooks.
+func hookKey(hook map[string]interface{}) string {
+	if cmd, ok := hook["command"].(string); ok && cmd != "" {
+		return "command:" + cmd
+	}
+	if prompt, ok := hook["prompt"].(string); ok && prompt != "" {
+		return "prompt:" + prompt
+	}
+	return ""
+}
+
+// findHookGroup returns the matcher group for matcher in an event's group
+// list. Groups without a matcher match the empty pattern.
+func findHookGroup(groups []interface{}, matcher string) map[string]interface{} {
+	for _, group := range groups {
+		groupMap, ok := group.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		existing, _ := groupMap["matcher"].(string)
+		if existing == matcher {
+			return groupMap
+		}
+	}
 	return nil
 }
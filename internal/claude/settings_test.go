@@ -1148,3 +1148,114 @@ func TestNestedCanonicalKeyOrder(t *testing.T) {
 		t.Error("hookCommand: 'timeout' should appear after 'command'")
 	}
 }
+
+func TestSettingsRawKeyAccessors(t *testing.T) {
+	claudeDir := t.TempDir()
+	settings, err := LoadSettingsForScope("user", claudeDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings.Set("model", "opus")
+	settings.Set("env", map[string]interface{}{"DEBUG": "1"})
+	settings.Set("outputStyle", "Explanatory")
+	settings.Delete("outputStyle")
+	if err := SaveSettingsForScope("user", claudeDir, "", settings); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadSettingsForScope("user", claudeDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model, ok := reloaded.Get("model"); !ok || model != "opus" {
+		t.Errorf("model = %v, %v", model, ok)
+	}
+	if env, _ := reloaded.Get("env"); env.(map[string]interface{})["DEBUG"] != "1" {
+		t.Errorf("env = %v", env)
+	}
+	if _, ok := reloaded.Get("outputStyle"); ok {
+		t.Error("deleted key should not be saved")
+	}
+}
+
+func TestMergeHooksIdempotentPerMatcher(t *testing.T) {
+	settings := &Settings{
+		raw: map[string]interface{}{
+			"hooks": map[string]interface{}{
+				"PreToolUse": []interface{}{
+					map[string]interface{}{
+						"matcher": "Bash",
+						"hooks": []interface{}{
+							map[string]interface{}{"type": "command", "command": "guard.sh"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	newHooks := map[string][]map[string]interface{}{
+		"PreToolUse": {
+			{"matcher": "Edit|Write", "type": "command", "command": "guard.sh", "timeout": 30},
+			{"matcher": "Edit|Write", "type": "command", "command": "lint.sh"},
+			{"matcher": "Bash", "type": "command", "command": "guard.sh", "timeout": 10},
+		},
+	}
+	for i := 0; i < 2; i++ {
+		if err := settings.MergeHooks(newHooks); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups := settings.raw["hooks"].(map[string]interface{})["PreToolUse"].([]interface{})
+	if len(groups) != 2 {
+		t.Fatalf("expected Bash and Edit|Write groups, got %v", groups)
+	}
+	bash := groups[0].(map[string]interface{})
+	bashHooks := bash["hooks"].([]interface{})
+	if len(bashHooks) != 1 || bashHooks[0].(map[string]interface{})["timeout"] != 10 {
+		t.Errorf("Bash group should hold one updated hook, got %v", bashHooks)
+	}
+	edit := groups[1].(map[string]interface{})
+	if edit["matcher"] != "Edit|Write" || len(edit["hooks"].([]interface{})) != 2 {
+		t.Errorf("Edit|Write group = %v", edit)
+	}
+}
+
+func TestRemoveHooks(t *testing.T) {
+	settings := &Settings{}
+	if err := settings.MergeHooks(map[string][]map[string]interface{}{
+		"PreToolUse": {
+			{"matcher": "Bash", "type": "command", "command": "guard.sh"},
+			{"matcher": "Edit", "type": "command", "command": "guard.sh"},
+		},
+		"Stop": {{"type": "prompt", "prompt": "Check the tests pass"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	removed := settings.RemoveHooks(map[string][]map[string]interface{}{
+		"PreToolUse": {{"matcher": "Bash", "type": "command", "command": "guard.sh"}},
+		"Stop":       {{"type": "prompt", "prompt": "Check the tests pass"}},
+	})
+	if removed != 2 {
+		t.Errorf("removed = %d, want 2", removed)
+	}
+
+	hooks := settings.raw["hooks"].(map[string]interface{})
+	if _, ok := hooks["Stop"]; ok {
+		t.Error("empty event types should be dropped")
+	}
+	groups := hooks["PreToolUse"].([]interface{})
+	if len(groups) != 1 || groups[0].(map[string]interface{})["matcher"] != "Edit" {
+		t.Errorf("only the Edit group should remain, got %v", groups)
+	}
+
+	settings.RemoveHooks(map[string][]map[string]interface{}{
+		"PreToolUse": {{"matcher": "Edit", "type": "command", "command": "guard.sh"}},
+	})
+	if _, ok := settings.raw["hooks"]; ok {
+		t.Error("hooks key should be removed once empty")
+	}
+}
@@ -0,0 +1,274 @@
+// ABOUTME: Backup subcommand group for creating, listing, restoring, and pruning backup generations
+// ABOUTME: Each generation captures settings, MCP servers, plugin and marketplace registries, and extensions
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/backup"
+	"github.com/claudeup/claudeup/v5/internal/config"
+	"github.com/claudeup/claudeup/v5/internal/events"
+	"github.com/claudeup/claudeup/v5/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backupCreateReason string
+	backupKeep         int
+	backupRestoreAt    string
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Manage configuration backups",
+	Long: `Create, list, restore, and prune backups of claudeup-managed files.
+
+Each backup is a timestamped generation under ~/.claudeup/backups/generations/
+holding whichever of these files exist:
+
+  ~/.claude/settings.json, .claude/settings.json, .claude/settings.local.json
+  the mcpServers section of ~/.claude/.claude.json
+  ~/.claude/plugins/installed_plugins.json and known_marketplaces.json
+  ~/.claudeup/enabled.json and the project's .mcp.json
+
+Project files come from the current directory. 'profile apply --replace'
+creates a backup automatically. The newest 10 generations are kept unless
+'backup create --keep' chose another number, which later automatic backups
+remember.`,
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List backups, newest first",
+	Args:  cobra.NoArgs,
+	RunE:  runBackupList,
+}
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Back up the current configuration",
+	Args:  cobra.NoArgs,
+	RunE:  runBackupCreate,
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore files from a backup",
+	Long: `Restore the files captured in a backup, showing what would change first.
+
+--at accepts a backup ID (or a unique prefix of one) or a time, which selects
+the newest backup taken at or before it. Without --at the newest backup is
+used. The current state is backed up before anything is written, so a
+restore can itself be undone.`,
+	Example: `  claudeup backup restore
+  claudeup backup restore --at 20260301-091500.000
+  claudeup backup restore --at "2026-03-01 09:00"`,
+	Args: cobra.NoArgs,
+	RunE: runBackupRestore,
+}
+
+var backupPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old backups",
+	Args:  cobra.NoArgs,
+	RunE:  runBackupPrune,
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.AddCommand(backupListCmd)
+	backupCmd.AddCommand(backupCreateCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+	backupCmd.AddCommand(backupPruneCmd)
+
+	backupCreateCmd.Flags().StringVar(&backupCreateReason, "reason", "manual", "Note recorded with the backup")
+	backupCreateCmd.Flags().IntVar(&backupKeep, "keep", backup.DefaultRetention, "Number of backups to keep, remembered for automatic backups")
+	backupPruneCmd.Flags().IntVar(&backupKeep, "keep", backup.DefaultRetention, "Number of newest backups to keep (default: the remembered retention)")
+	backupRestoreCmd.Flags().StringVar(&backupRestoreAt, "at", "", "Backup ID or time to restore from (default: newest)")
+}
+
+// backupSources returns the files to back up, with project files taken from projectDir.
+func backupSources(projectDir string) backup.Sources {
+	return backup.Sources{ClaudeDir: claudeDir, ClaudeupHome: claudeupHome, ProjectDir: projectDir}
+}
+
+// backupRetention returns how many generations automatic pruning keeps: the
+// number last chosen with 'backup create --keep', or the default.
+func backupRetention() int {
+	cfg, err := config.LoadFrom(claudeupHome)
+	if err != nil || cfg.Preferences.BackupRetention < 1 {
+		return backup.DefaultRetention
+	}
+	return cfg.Preferences.BackupRetention
+}
+
+// saveBackupRetention remembers keep for later automatic pruning.
+func saveBackupRetention(keep int) error {
+	cfg, err := config.LoadFrom(claudeupHome)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	cfg.Preferences.BackupRetention = keep
+	if err := config.SaveTo(claudeupHome, cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
+
+// createBackupGeneration backs up the current configuration and prunes
+// generations beyond keep. A failed prune only warns, since the new backup
+// was still written.
+func createBackupGeneration(projectDir, reason string, keep int) (*backup.Generation, error) {
+	gen, err := backup.CreateGeneration(backupSources(projectDir), reason)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := backup.PruneGenerations(claudeupHome, keep); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not prune old backups: %v", err))
+	}
+	return gen, nil
+}
+
+func runBackupList(cmd *cobra.Command, args []string) error {
+	gens, err := backup.ListGenerations(claudeupHome)
+	if err != nil {
+		return err
+	}
+	if len(gens) == 0 {
+		fmt.Println("No backups")
+		fmt.Printf("\n%s Run '%s' to create one\n", ui.Muted(ui.SymbolArrow), ui.Bold("claudeup backup create"))
+		return nil
+	}
+
+	idWidth := len("ID")
+	for _, gen := range gens {
+		idWidth = max(idWidth, len(gen.ID))
+	}
+	rowFmt := fmt.Sprintf("%%-%ds  %%-19s  %%-5s  %%s", idWidth)
+	fmt.Println(ui.Bold(fmt.Sprintf(rowFmt, "ID", "CREATED", "FILES", "REASON")))
+	for _, gen := range gens {
+		reason := gen.Reason
+		if gen.ProjectDir != "" {
+			reason += " " + ui.Muted("("+gen.ProjectDir+")")
+		}
+		fmt.Printf(rowFmt+"\n", gen.ID, gen.CreatedAt.Local().Format("2006-01-02 15:04:05"), fmt.Sprintf("%d", len(gen.Files)), reason)
+	}
+	return nil
+}
+
+func runBackupCreate(cmd *cobra.Command, args []string) error {
+	if backupKeep < 1 {
+		return fmt.Errorf("--keep must be at least 1 when creating a backup")
+	}
+	keep := backupRetention()
+	if cmd.Flags().Changed("keep") {
+		if err := saveBackupRetention(backupKeep); err != nil {
+			return err
+		}
+		keep = backupKeep
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	gen, err := createBackupGeneration(cwd, backupCreateReason, keep)
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	ui.PrintSuccess(fmt.Sprintf("Created backup %s", gen.ID))
+	for _, file := range gen.Files {
+		fmt.Printf("  %s %s\n", ui.Muted(ui.SymbolArrow), file.Path)
+	}
+	return nil
+}
+
+func runBackupRestore(cmd *cobra.Command, args []string) error {
+	gen, err := backup.FindGeneration(claudeupHome, backupRestoreAt)
+	if errors.Is(err, backup.ErrNoBackup) {
+		return fmt.Errorf("no backups found; run 'claudeup backup create' first")
+	}
+	if err != nil {
+		return err
+	}
+
+	changes, err := backup.PlanRestore(gen)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		ui.PrintInfo(fmt.Sprintf("Nothing to restore: current files match backup %s", gen.ID))
+		return nil
+	}
+
+	fmt.Println(ui.RenderSection(fmt.Sprintf("Restore %s", gen.ID), len(changes)))
+	for _, change := range changes {
+		fmt.Println()
+		fmt.Printf("%s %s\n", ui.Bold(change.File.Path), ui.Muted("("+change.File.Scope+")"))
+		fmt.Println("  " + strings.ReplaceAll(change.Diff.Summary, "\n", "\n  "))
+	}
+	fmt.Println()
+
+	confirmed, err := ui.ConfirmYesNo(fmt.Sprintf("Restore %d file(s) from %s?", len(changes), gen.ID))
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		ui.PrintInfo("Restore cancelled")
+		return nil
+	}
+
+	projectDir := gen.ProjectDir
+	if projectDir == "" {
+		projectDir, _ = os.Getwd()
+	}
+	safety, err := backup.CreateGeneration(backupSources(projectDir), "before restore of "+gen.ID)
+	if err != nil && !errors.Is(err, backup.ErrNothingToBackup) {
+		return fmt.Errorf("failed to back up current files before restoring: %w", err)
+	}
+
+	var failed int
+	for _, change := range changes {
+		err := events.GlobalTracker().RecordFileWrite("backup restore", change.File.Path, change.File.Scope, change.Apply)
+		if err != nil {
+			failed++
+			ui.PrintError(err.Error())
+			continue
+		}
+		ui.PrintSuccess(fmt.Sprintf("Restored %s", change.File.Path))
+	}
+
+	if safety != nil {
+		fmt.Printf("\n%s Previous state saved as backup %s\n", ui.Muted(ui.SymbolArrow), safety.ID)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) could not be restored", failed)
+	}
+	return nil
+}
+
+func runBackupPrune(cmd *cobra.Command, args []string) error {
+	keep := backupKeep
+	if !cmd.Flags().Changed("keep") {
+		keep = backupRetention()
+	}
+	if keep < 0 {
+		return fmt.Errorf("--keep must not be negative")
+	}
+	removed, err := backup.PruneGenerations(claudeupHome, keep)
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		ui.PrintInfo(fmt.Sprintf("Nothing to prune (keeping up to %d backups)", keep))
+		return nil
+	}
+	for _, gen := range removed {
+		fmt.Printf("  %s %s\n", ui.Muted("Removed"), gen.ID)
+	}
+	ui.PrintSuccess(fmt.Sprintf("Removed %d backup(s)", len(removed)))
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+// ABOUTME: Unix detachment for background processes started by claudeup.
+// ABOUTME: Puts the child in its own session so terminal signals aimed at the parent don't reach it.
+package commands
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachProcess starts cmd in a new session, without a controlling
+// terminal, so it keeps running after the parent's terminal closes.
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+// ABOUTME: Windows no-op for detaching background processes started by claudeup.
+// ABOUTME: Windows has no Unix sessions; a started child already outlives its parent.
+package commands
+
+import "os/exec"
+
+// detachProcess is a no-op on Windows. The child still outlives the
+// parent; it may see console signals sent to the parent's console.
+func detachProcess(cmd *exec.Cmd) {}
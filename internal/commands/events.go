@@ -143,6 +143,11 @@ func displayEvent(event *events.FileOperation) {
 	}
 
 	// Print header
+	if event.ChangeType == events.ChangeTypeRun {
+		ui.PrintInfo(fmt.Sprintf("%s  %s  %s", statusIcon, timeStr, strings.ToUpper(event.Operation)))
+		displayRunEvent(event)
+		return
+	}
 	ui.PrintInfo(fmt.Sprintf("%s  %s  %s (%s scope)",
 		statusIcon,
 		timeStr,
@@ -181,6 +186,37 @@ func displayEvent(event *events.FileOperation) {
 	}
 }
 
+// displayRunEvent prints what a command event ran and how it ended.
+func displayRunEvent(event *events.FileOperation) {
+	for _, field := range []struct{ label, key string }{
+		{"Profile", "profile"},
+		{"Command", "command"},
+		{"Directory", "dir"},
+		{"Sandbox", "sandbox"},
+		{"Exit code", "exitCode"},
+	} {
+		if value, ok := event.Context[field.key]; ok && value != "" {
+			fmt.Printf("  %s: %v\n", field.label, value)
+		}
+	}
+	if ms, ok := event.Context["durationMs"].(float64); ok {
+		fmt.Printf("  Duration: %s\n", time.Duration(ms)*time.Millisecond)
+	}
+	for _, stream := range []string{"stdout", "stderr"} {
+		output, _ := event.Context[stream].(string)
+		if output = strings.TrimRight(output, "\n"); output != "" {
+			fmt.Printf("  %s:\n", strings.ToUpper(stream[:1])+stream[1:])
+			for _, line := range strings.Split(output, "\n") {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+	}
+
+	if event.Error != "" {
+		ui.PrintError(fmt.Sprintf("  Error: %s", event.Error))
+	}
+}
+
 // parseDuration parses duration strings like "24h", "7d", "30m"
 func parseDuration(s string) (time.Duration, error) {
 	// Handle days specially
@@ -1,5 +1,5 @@
 // ABOUTME: CLI commands for managing Claude Code extensions
-// ABOUTME: Provides list, enable, disable, view, sync, and import subcommands
+// ABOUTME: Provides list, enable, disable, view, sync, import, install, update, and lint subcommands
 package commands
 
 import (
@@ -42,8 +42,16 @@ Adding extensions:
   import      Move items from active directories to extension storage
   import-all  Bulk import across all categories at once
 
+Updating extensions:
+  update      Refresh installed items from their source
+  lint        Check items for problems before Claude Code loads them
+
 Removing extensions:
-  uninstall   Remove extensions and clean up symlinks`,
+  uninstall   Remove extensions and clean up symlinks
+
+Syncing between machines:
+  remote      Connect extension storage to a git repository
+  push/pull   Exchange changes with that repository`,
 }
 
 var extensionsListCmd = &cobra.Command{
@@ -169,24 +177,73 @@ Supports the same wildcards as enable/disable.`,
 }
 
 var extensionsInstallCmd = &cobra.Command{
-	Use:   "install <category> <path>",
-	Short: "Install extensions from an external path",
-	Long: `Install extensions from an external path (file or directory).
+	Use:   "install <category> <source>",
+	Short: "Install extensions from a path, GitHub repo, URL, or plugin",
+	Long: `Install extensions from an external source (file or directory).
 
 This copies files to extension storage and automatically enables them.
 Use this to install extensions from a git repo, downloads folder, or other location.
 
+Sources:
+  <path>                          Local file or directory
+  github:owner/repo[//path][@ref] GitHub repository, optional subpath and branch, tag, or commit
+  https://host/path/item.md       Single file downloaded over HTTPS
+  plugin:name@marketplace[//path] Installed plugin's files (default path: the category)
+
 For single files/directories: installed as-is.
 For directories containing multiple items: each item is installed individually.
 
-Existing items with the same name are skipped (not overwritten).`,
+Existing items with the same name are skipped (not overwritten).
+Where each item came from is recorded in ext-sources.json, so
+'claudeup extensions update' can refresh it later.`,
 	Example: `  claudeup extensions install agents ~/code/my-agents/
   claudeup extensions install hooks ~/Downloads/format-on-save.sh
-  claudeup extensions install skills ~/code/my-skills/awesome-skill`,
+  claudeup extensions install skills github:owner/repo//skills@v1.2.0
+  claudeup extensions install rules https://example.com/rules/style.md
+  claudeup extensions install agents plugin:tools@my-marketplace`,
 	Args: cobra.ExactArgs(2),
 	RunE: runExtensionsInstall,
 }
 
+var extensionsUpdateCmd = &cobra.Command{
+	Use:   "update [category] [items...]",
+	Short: "Refresh installed extensions from their source",
+	Long: `Refresh installed extensions from the source they were installed from.
+
+Items without local edits are replaced with the upstream version. Items
+edited locally are shown as a diff against upstream, and you are asked
+before your changes are overwritten.
+
+Only items installed with 'extensions install' have a recorded source.
+Sources pinned to a tag or commit stay on that ref.`,
+	Example: `  claudeup extensions update
+  claudeup extensions update skills
+  claudeup extensions update agents reviewer.md`,
+	RunE: runExtensionsUpdate,
+}
+
+var extensionsLintCmd = &cobra.Command{
+	Use:   "lint [category] [items...]",
+	Short: "Check extensions for problems before Claude Code loads them",
+	Long: `Check extensions in storage for problems that would otherwise only
+surface inside Claude Code:
+
+  agents         frontmatter with name and description
+  skills         a SKILL.md with name and description
+  commands       valid frontmatter fields, when present
+  hooks          executable scripts with a #! line
+  rules, styles  valid frontmatter, when present
+
+Items named like a component of an installed plugin are flagged too.
+Problems are reported as file:line. Exits non-zero when any error is found.
+
+Enable and install run the same checks on the items they touch.`,
+	Example: `  claudeup extensions lint
+  claudeup extensions lint skills
+  claudeup extensions lint agents gsd-*`,
+	RunE: runExtensionsLint,
+}
+
 func init() {
 	rootCmd.AddCommand(extensionsCmd)
 	extensionsCmd.AddCommand(extensionsListCmd)
@@ -198,6 +255,8 @@ func init() {
 	extensionsCmd.AddCommand(extensionsImportAllCmd)
 	extensionsCmd.AddCommand(extensionsInstallCmd)
 	extensionsCmd.AddCommand(extensionsUninstallCmd)
+	extensionsCmd.AddCommand(extensionsUpdateCmd)
+	extensionsCmd.AddCommand(extensionsLintCmd)
 
 	extensionsListCmd.Flags().BoolVarP(&extFilterEnabled, "enabled", "e", false, "Show only enabled items")
 	extensionsListCmd.Flags().BoolVarP(&extFilterDisabled, "disabled", "d", false, "Show only disabled items")
@@ -434,6 +493,7 @@ func runExtensionsEnable(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no items found matching patterns")
 	}
 
+	printLintFindings(manager.LintItems(category, enabled))
 	return nil
 }
 
@@ -595,9 +655,132 @@ func runExtensionsInstall(cmd *cobra.Command, args []string) error {
 		fmt.Println("All items already installed")
 	}
 
+	printLintFindings(manager.LintItems(category, installed))
 	return nil
 }
 
+func runExtensionsUpdate(cmd *cobra.Command, args []string) error {
+	var category string
+	var patterns []string
+	if len(args) > 0 {
+		category, patterns = args[0], args[1:]
+	}
+
+	manager := ext.NewManager(claudeDir, claudeupHome)
+	updates, err := manager.PlanUpdate(category, patterns)
+	if err != nil {
+		return err
+	}
+	if len(updates) == 0 {
+		if len(patterns) > 0 {
+			return fmt.Errorf("no installed items with a recorded source match %s", strings.Join(patterns, ", "))
+		}
+		fmt.Println("No extensions with a recorded source. Items added with 'claudeup extensions install' can be updated.")
+		return nil
+	}
+
+	updated, current, failed := 0, 0, 0
+	for _, u := range updates {
+		name := u.Category + "/" + u.Item
+		switch u.Status {
+		case ext.UpdateCurrent:
+			current++
+			continue
+		case ext.UpdateFailed:
+			failed++
+			ui.PrintWarning(fmt.Sprintf("%s: %v", name, u.Err))
+			continue
+		case ext.UpdateMissing:
+			ui.PrintWarning(fmt.Sprintf("%s was removed from extension storage; reinstall with 'claudeup extensions install %s %s'",
+				name, u.Category, u.Provenance.Source))
+			continue
+		case ext.UpdateModified:
+			fmt.Println()
+			fmt.Printf("%s %s\n", ui.Bold(name), ui.Muted("(edited locally; changes shown from your copy to upstream)"))
+			for _, line := range u.Diff {
+				fmt.Println("  " + line)
+			}
+			confirmed, err := ui.ConfirmYesNo(fmt.Sprintf("Overwrite your changes to %s?", name))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				ui.PrintInfo(fmt.Sprintf("Kept local version of %s", name))
+				continue
+			}
+		}
+
+		if err := manager.ApplyUpdate(u); err != nil {
+			return err
+		}
+		updated++
+		ui.PrintSuccess(fmt.Sprintf("Updated: %s", name))
+	}
+
+	if updated == 0 && failed == 0 && current == len(updates) {
+		ui.PrintSuccess(fmt.Sprintf("All %d item(s) are up to date", current))
+	} else if current > 0 {
+		fmt.Printf("  %s %d item(s) already up to date\n", ui.Muted(ui.SymbolArrow), current)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d item(s) could not be checked", failed)
+	}
+	return nil
+}
+
+func runExtensionsLint(cmd *cobra.Command, args []string) error {
+	var category string
+	var patterns []string
+	if len(args) > 0 {
+		category, patterns = args[0], args[1:]
+	}
+
+	findings, err := ext.NewManager(claudeDir, claudeupHome).Lint(category, patterns)
+	if err != nil {
+		return err
+	}
+	if len(findings) == 0 {
+		ui.PrintSuccess("No problems found")
+		return nil
+	}
+
+	errs := 0
+	for _, f := range findings {
+		printLintFinding(f)
+		if f.Severity == ext.SeverityError {
+			errs++
+		}
+	}
+	fmt.Println()
+	summary := fmt.Sprintf("%d error(s), %d warning(s)", errs, len(findings)-errs)
+	if errs > 0 {
+		return fmt.Errorf("%s", summary)
+	}
+	ui.PrintWarning(summary)
+	return nil
+}
+
+// printLintFindings reports problems in items that were just enabled or
+// installed. They are enabled regardless; this only warns early.
+func printLintFindings(findings []ext.Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	fmt.Println()
+	ui.PrintWarning(fmt.Sprintf("%d problem(s) found; Claude Code may not load these items as expected:", len(findings)))
+	for _, f := range findings {
+		printLintFinding(f)
+	}
+}
+
+func printLintFinding(f ext.Finding) {
+	if f.Severity == ext.SeverityError {
+		fmt.Println("  " + ui.Error(f.String()))
+	} else {
+		fmt.Println("  " + ui.Warning(f.String()))
+	}
+}
+
 func runExtensionsUninstall(cmd *cobra.Command, args []string) error {
 	category := args[0]
 	patterns := args[1:]
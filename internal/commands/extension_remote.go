@@ -0,0 +1,174 @@
+// ABOUTME: CLI commands for syncing the extension library through a git remote
+// ABOUTME: Provides extensions remote add/show/remove, push, and pull
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/internal/ext"
+	"github.com/claudeup/claudeup/v5/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var extRemoteSyncEnabled bool
+
+var extensionsRemoteCmd = &cobra.Command{
+	Use:   "remote",
+	Short: "Manage the git remote used to sync the extension library",
+	Long: `Manage the git remote used to sync extension storage between machines.
+
+Adding a remote turns ~/.claudeup/ext into a git working tree. Use
+'extensions push' and 'extensions pull' to exchange changes with it.`,
+}
+
+var extensionsRemoteAddCmd = &cobra.Command{
+	Use:   "add <git-url>",
+	Short: "Sync the extension library with a git repository",
+	Long: `Sync the extension library with a git repository.
+
+With --sync-enabled, enabled.json travels with the library, so machines
+also share which extensions are turned on. Without it, each machine keeps
+its own enabled state.`,
+	Example: `  claudeup extensions remote add git@github.com:me/claude-extensions.git
+  claudeup extensions remote add https://github.com/me/claude-extensions.git --sync-enabled`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExtensionsRemoteAdd,
+}
+
+var extensionsRemoteShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the extension library's remote",
+	Args:  cobra.NoArgs,
+	RunE:  runExtensionsRemoteShow,
+}
+
+var extensionsRemoteRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Stop syncing the extension library",
+	Long: `Stop syncing the extension library. Local extensions and their git
+history are kept.`,
+	Args: cobra.NoArgs,
+	RunE: runExtensionsRemoteRemove,
+}
+
+var extensionsPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Commit and push local extension changes",
+	Long: `Commit changes in extension storage and push them to the remote.
+
+If another machine has pushed since the last pull, nothing is pushed;
+run 'claudeup extensions pull' first.`,
+	Args: cobra.NoArgs,
+	RunE: runExtensionsPush,
+}
+
+var extensionsPullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Pull extension changes from the remote",
+	Long: `Merge extension changes from the remote into extension storage, then
+re-link enabled extensions into the Claude config directory.
+
+Local changes are committed first. Items changed both here and on the
+remote are listed and the pull is undone, leaving local files as they were.`,
+	Args: cobra.NoArgs,
+	RunE: runExtensionsPull,
+}
+
+func init() {
+	extensionsCmd.AddCommand(extensionsRemoteCmd)
+	extensionsCmd.AddCommand(extensionsPushCmd)
+	extensionsCmd.AddCommand(extensionsPullCmd)
+	extensionsRemoteCmd.AddCommand(extensionsRemoteAddCmd)
+	extensionsRemoteCmd.AddCommand(extensionsRemoteShowCmd)
+	extensionsRemoteCmd.AddCommand(extensionsRemoteRemoveCmd)
+
+	extensionsRemoteAddCmd.Flags().BoolVar(&extRemoteSyncEnabled, "sync-enabled", false, "Also sync enabled.json through the remote")
+}
+
+func runExtensionsRemoteAdd(cmd *cobra.Command, args []string) error {
+	manager := ext.NewManager(claudeDir, claudeupHome)
+	if err := manager.AddRemote(args[0], extRemoteSyncEnabled); err != nil {
+		return err
+	}
+	ui.PrintSuccess(fmt.Sprintf("Extension library now syncs with %s", args[0]))
+	if extRemoteSyncEnabled {
+		fmt.Printf("  %s enabled.json is synced too\n", ui.Muted(ui.SymbolArrow))
+	}
+	fmt.Printf("  %s Run '%s' to publish, or '%s' to fetch an existing library\n",
+		ui.Muted(ui.SymbolArrow), ui.Bold("claudeup extensions push"), ui.Bold("claudeup extensions pull"))
+	return nil
+}
+
+func runExtensionsRemoteShow(cmd *cobra.Command, args []string) error {
+	status, err := ext.NewManager(claudeDir, claudeupHome).Remote()
+	if err != nil {
+		return err
+	}
+	fmt.Println(ui.RenderDetail("Remote", status.URL))
+	fmt.Println(ui.RenderDetail("Sync enabled.json", fmt.Sprintf("%t", status.SyncEnabled)))
+	return nil
+}
+
+func runExtensionsRemoteRemove(cmd *cobra.Command, args []string) error {
+	if err := ext.NewManager(claudeDir, claudeupHome).RemoveRemote(); err != nil {
+		return err
+	}
+	ui.PrintSuccess("Extension library no longer syncs with a remote")
+	return nil
+}
+
+func runExtensionsPush(cmd *cobra.Command, args []string) error {
+	result, err := ext.NewManager(claudeDir, claudeupHome).Push()
+	if err != nil {
+		return err
+	}
+	for _, item := range result.Committed {
+		fmt.Printf("  %s %s\n", ui.Muted(ui.SymbolArrow), item)
+	}
+	if result.UpToDate {
+		ui.PrintInfo("Remote is already up to date")
+		return nil
+	}
+	ui.PrintSuccess("Pushed extension library")
+	return nil
+}
+
+func runExtensionsPull(cmd *cobra.Command, args []string) error {
+	manager := ext.NewManager(claudeDir, claudeupHome)
+	result, err := manager.Pull()
+	var conflict *ext.ConflictError
+	if errors.As(err, &conflict) {
+		ui.PrintError("Changed both locally and on the remote:")
+		for _, item := range conflict.Items {
+			fmt.Printf("  %s %s\n", ui.Warning(ui.SymbolWarning), item)
+		}
+		return fmt.Errorf("pull undone; merge %d conflicting item(s) with git in %s, then run 'claudeup extensions push'",
+			len(conflict.Items), filepath.Join(claudeupHome, "ext"))
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(result.Changed) == 0 {
+		ui.PrintInfo("Extension library is already up to date")
+	} else {
+		ui.PrintSuccess(fmt.Sprintf("Pulled %d changed item(s)", len(result.Changed)))
+		for _, item := range result.Changed {
+			fmt.Printf("  %s %s\n", ui.Muted(ui.SymbolArrow), item)
+		}
+	}
+	if result.ConfigUpdated {
+		ui.PrintInfo("Updated enabled.json from the remote")
+	}
+
+	skipped, err := manager.Sync()
+	if err != nil {
+		return fmt.Errorf("sync failed: %w", err)
+	}
+	for _, item := range skipped {
+		ui.PrintWarning(fmt.Sprintf("Source not found, skipping: %s", item))
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+// ABOUTME: Export and import commands for moving a claudeup installation to another machine
+// ABOUTME: Archives the ext library, profiles, and state; import can reinstall plugins via profile apply
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/claudeup/claudeup/v5/internal/ext"
+	"github.com/claudeup/claudeup/v5/internal/machine"
+	"github.com/claudeup/claudeup/v5/internal/profile"
+	"github.com/claudeup/claudeup/v5/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportOutput         string
+	importForce          bool
+	importInstallPlugins bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export this claudeup installation to an archive",
+	Long: `Write a .tar.gz archive of this installation for use on another machine.
+
+The archive contains the extension library (~/.claudeup/ext), enabled.json,
+extension sources, custom profiles, last-applied breadcrumbs, marketplace pins, and config, plus
+a snapshot of the user-scope plugins, marketplaces, and MCP servers.
+
+Secrets are stripped: string values under keys such as *_TOKEN, apiKey, or
+password are blanked, and recognizable tokens (GitHub, Slack, AWS, sk-...)
+are replaced with REDACTED. The locations are listed after exporting.`,
+	Example: `  claudeup export -o machine.tar.gz`,
+	Args:    cobra.NoArgs,
+	RunE:    runExport,
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <archive>",
+	Short: "Import an installation exported with 'claudeup export'",
+	Long: `Unpack an export archive into this machine's claudeup home.
+
+Absolute paths under the exporting machine's home directory,
+CLAUDE_CONFIG_DIR, and CLAUDEUP_HOME are rewritten to this machine's.
+Enabled extensions are linked into the Claude config directory.
+
+Files that already exist with different content are reported and the import
+stops; pass --force to overwrite them. Pass --install-plugins to also install
+the exported marketplaces and plugins through profile apply. MCP servers are
+not installed, since they run commands.
+Existing plugins are kept.`,
+	Example: `  claudeup import machine.tar.gz
+  claudeup import machine.tar.gz --install-plugins`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Archive path (default: claudeup-export-<date>.tar.gz)")
+	importCmd.Flags().BoolVar(&importForce, "force", false, "Overwrite files that differ from the archive")
+	importCmd.Flags().BoolVar(&importInstallPlugins, "install-plugins", false, "Install the exported plugins and their marketplaces")
+}
+
+// machinePaths describes this installation for export and import.
+func machinePaths() (machine.Paths, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return machine.Paths{}, fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	claudeDirAbs, err := filepath.Abs(claudeDir)
+	if err != nil {
+		return machine.Paths{}, err
+	}
+	return machine.Paths{Home: home, ClaudeDir: claudeDirAbs, ClaudeupHome: claudeupHome}, nil
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	paths, err := machinePaths()
+	if err != nil {
+		return err
+	}
+	output := exportOutput
+	if output == "" {
+		output = fmt.Sprintf("claudeup-export-%s.tar.gz", time.Now().Format("20060102"))
+	}
+	if _, err := os.Stat(output); err == nil {
+		confirmed, err := ui.ConfirmYesNo(fmt.Sprintf("%s already exists. Overwrite?", output))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			ui.PrintInfo("Export cancelled")
+			return nil
+		}
+	}
+
+	opts := machine.ExportOptions{Paths: paths, Version: rootCmd.Version}
+	snapshot, err := profile.Snapshot("machine-export", claudeDir, filepath.Join(claudeDir, ".claude.json"), claudeupHome)
+	if err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not snapshot plugins; the archive will not reinstall them: %v", err))
+	} else {
+		opts.Profile = snapshot
+	}
+
+	// Write beside the destination and rename, so a failed export never
+	// leaves a truncated archive behind
+	tmp, err := os.CreateTemp(filepath.Dir(output), ".claudeup-export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	manifest, err := machine.Export(tmp, opts)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), output); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Exported %d file(s) to %s", len(manifest.Files), output))
+	if opts.Profile != nil {
+		fmt.Printf("  %s %d plugin(s), %d marketplace(s), %d MCP server(s) recorded for reinstall\n",
+			ui.Muted(ui.SymbolArrow), len(opts.Profile.Plugins), len(opts.Profile.Marketplaces), len(opts.Profile.MCPServers))
+	}
+	for _, skipped := range manifest.Skipped {
+		ui.PrintWarning(fmt.Sprintf("Skipped symlink %s", skipped))
+	}
+	printRedactions(manifest.Redactions)
+	return nil
+}
+
+func printRedactions(redactions []machine.Redaction) {
+	if len(redactions) == 0 {
+		return
+	}
+	fmt.Println()
+	ui.PrintWarning(fmt.Sprintf("Removed %d secret(s); re-enter them after importing:", len(redactions)))
+	for _, r := range redactions {
+		fmt.Printf("  %s %s\n", r.File, ui.Muted(r.Location))
+	}
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+	archive, err := machine.Read(f)
+	if err != nil {
+		return err
+	}
+
+	paths, err := machinePaths()
+	if err != nil {
+		return err
+	}
+	plan, err := archive.Plan(paths)
+	if err != nil {
+		return err
+	}
+
+	manifest := archive.Manifest
+	fmt.Println(ui.RenderSection("Import", len(plan.Changes)))
+	fmt.Println(ui.RenderDetail("Exported", manifest.CreatedAt.Local().Format("2006-01-02 15:04")))
+	if manifest.Version != "" {
+		fmt.Println(ui.RenderDetail("Version", manifest.Version))
+	}
+	fmt.Println(ui.RenderDetail("From", manifest.Source.ClaudeupHome))
+	fmt.Println(ui.RenderDetail("To", paths.ClaudeupHome))
+	fmt.Printf("  %d new, %d unchanged, %d conflicting\n",
+		plan.Count(machine.StatusNew), plan.Count(machine.StatusUnchanged), plan.Count(machine.StatusConflict))
+	for _, change := range plan.Changes {
+		if change.Status == machine.StatusConflict {
+			fmt.Printf("  %s %s\n", ui.Warning(ui.SymbolWarning), change.Path)
+		}
+	}
+	fmt.Println()
+
+	conflicts := plan.Count(machine.StatusConflict)
+	if conflicts > 0 && !importForce {
+		return fmt.Errorf("%d file(s) already exist with different content; rerun with --force to overwrite them", conflicts)
+	}
+
+	if toWrite := len(plan.Changes) - plan.Count(machine.StatusUnchanged); toWrite > 0 {
+		confirmed, err := ui.ConfirmYesNo(fmt.Sprintf("Write %d file(s) to %s?", toWrite, paths.ClaudeupHome))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			ui.PrintInfo("Import cancelled")
+			return nil
+		}
+		if err := plan.Apply(importForce); err != nil {
+			return fmt.Errorf("import failed: %w", err)
+		}
+		ui.PrintSuccess(fmt.Sprintf("Imported %d file(s)", toWrite))
+	} else {
+		ui.PrintInfo("All files already match the archive")
+	}
+
+	// Enabled extensions are symlinks into the Claude config directory, which
+	// the archive does not carry
+	skipped, err := ext.NewManager(claudeDir, claudeupHome).Sync()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		ui.PrintWarning(fmt.Sprintf("Could not link enabled extensions: %v", err))
+	}
+	for _, item := range skipped {
+		ui.PrintWarning(fmt.Sprintf("Enabled extension %s is missing from the archive", item))
+	}
+	printRedactions(manifest.Redactions)
+
+	if plan.Profile == nil {
+		return nil
+	}
+	if !importInstallPlugins {
+		if len(plan.Profile.Plugins) > 0 {
+			fmt.Printf("\n%s Run '%s' to install %d plugin(s) from the archive\n",
+				ui.Muted(ui.SymbolArrow), ui.Bold("claudeup import "+args[0]+" --install-plugins"), len(plan.Profile.Plugins))
+		}
+		return nil
+	}
+
+	fmt.Println()
+	ui.PrintInfo("Installing plugins (user scope)...")
+	executor, err := newExecutor()
+	if err != nil {
+		return err
+	}
+	// Only plugins and their marketplaces, as the flag says. MCP servers and
+	// hooks in the snapshot run commands, so they go through profile apply
+	// and its trust checks instead
+	plugins := &profile.Profile{
+		Name:         plan.Profile.Name,
+		Marketplaces: plan.Profile.Marketplaces,
+		Plugins:      plan.Profile.Plugins,
+	}
+	if skipped := plan.Profile.CommandFields(); len(skipped) > 0 {
+		ui.PrintWarning(fmt.Sprintf("Not installing the archive's %s: they run commands. Add them from a profile you trust with 'claudeup profile apply'",
+			strings.Join(skipped, ", ")))
+	}
+	cwd, _ := os.Getwd()
+	claudeJSONPath := filepath.Join(claudeDir, ".claude.json")
+	before, beforeErr := profile.LiveItems(claudeDir, claudeJSONPath, cwd)
+	result, err := profile.ApplyAllScopes(plugins.AsPerScope(), claudeDir, claudeJSONPath, cwd, claudeupHome, buildSecretChain(), &profile.ApplyAllScopesOptions{Executor: executor})
+	if err != nil {
+		return fmt.Errorf("failed to install plugins: %w", err)
+	}
+	showApplyResults(result)
+	after, afterErr := profile.LiveItems(claudeDir, claudeJSONPath, cwd)
+	recordLedger([]string{plugins.Name}, nil, cwd, before, after, errors.Join(beforeErr, afterErr))
+	return nil
+}
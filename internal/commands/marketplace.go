@@ -0,0 +1,535 @@
+// ABOUTME: Marketplace subcommand group for adding, removing, inspecting, and pinning marketplaces
+// ABOUTME: Pins are stored in claudeup home and respected by upgrade and marketplace update
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+	"github.com/claudeup/claudeup/v5/internal/events"
+	"github.com/claudeup/claudeup/v5/internal/marketplace"
+	"github.com/claudeup/claudeup/v5/internal/profile"
+	"github.com/claudeup/claudeup/v5/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	marketplaceRemoveCascade bool
+	marketplacePinClear      bool
+)
+
+var marketplaceCmd = &cobra.Command{
+	Use:   "marketplace",
+	Short: "Manage marketplaces",
+	Long: `Manage plugin marketplaces - add, remove, list, show, pin, and update.
+
+Marketplaces are git repositories that publish plugins. Pinning a marketplace
+holds it at a tag or commit; pinned marketplaces are skipped by 'upgrade' and
+'marketplace update' until the pin is cleared.`,
+}
+
+var marketplaceAddCmd = &cobra.Command{
+	Use:   "add <repo|url>",
+	Short: "Add a marketplace",
+	Long: `Add a marketplace from a GitHub repository (owner/repo) or git URL using the claude CLI
+(or directly with --engine native).`,
+	Example: `  claudeup marketplace add anthropics/claude-code
+  claudeup marketplace add https://github.com/wshobson/agents.git`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMarketplaceAdd,
+}
+
+var marketplaceRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a marketplace",
+	Long: `Remove a marketplace.
+
+Removal is refused while enabled plugins still come from the marketplace,
+including project and local installs in other projects.
+Pass --cascade to uninstall those in the current project first; plugins
+enabled in other projects have to be uninstalled from there.`,
+	Example: `  claudeup marketplace remove superpowers-marketplace
+  claudeup marketplace remove superpowers-marketplace --cascade`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMarketplaceRemove,
+}
+
+var marketplaceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List installed marketplaces",
+	Long:  `Show each marketplace's source, git HEAD, last update, plugin count, and install location.`,
+	Args:  cobra.NoArgs,
+	RunE:  runMarketplaceList,
+}
+
+var marketplaceShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show marketplace details",
+	Long: `Show details for a marketplace and the plugins it provides.
+
+Accepts marketplace name, repo (user/repo), or URL as identifier.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMarketplaceShow,
+}
+
+var marketplacePinCmd = &cobra.Command{
+	Use:   "pin <name> [ref]",
+	Short: "Pin a marketplace to a tag or commit",
+	Long: `Check out a tag, commit, or branch in a marketplace and hold it there.
+
+Pinned marketplaces are skipped by 'upgrade' and 'marketplace update'.
+Use --clear to return the marketplace to its default branch.`,
+	Example: `  claudeup marketplace pin claude-code-workflows v1.2.0
+  claudeup marketplace pin claude-code-workflows 3f9c2ab
+  claudeup marketplace pin claude-code-workflows --clear`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runMarketplacePin,
+}
+
+var marketplaceUpdateCmd = &cobra.Command{
+	Use:   "update [names...]",
+	Short: "Pull the latest marketplace contents",
+	Long: `Pull the latest commits for the named marketplaces, or all of them.
+
+Pinned marketplaces are skipped. Installed plugins are not changed; run
+'claudeup upgrade' to update plugins from the refreshed marketplaces.`,
+	Args: cobra.ArbitraryArgs,
+	RunE: runMarketplaceUpdate,
+}
+
+func init() {
+	rootCmd.AddCommand(marketplaceCmd)
+	marketplaceCmd.AddCommand(marketplaceAddCmd)
+	marketplaceCmd.AddCommand(marketplaceRemoveCmd)
+	marketplaceCmd.AddCommand(marketplaceListCmd)
+	marketplaceCmd.AddCommand(marketplaceShowCmd)
+	marketplaceCmd.AddCommand(marketplacePinCmd)
+	marketplaceCmd.AddCommand(marketplaceUpdateCmd)
+
+	marketplaceRemoveCmd.Flags().BoolVar(&marketplaceRemoveCascade, "cascade", false, "Uninstall enabled plugins from this marketplace before removing it")
+	marketplacePinCmd.Flags().BoolVar(&marketplacePinClear, "clear", false, "Remove the pin and check out the default branch")
+}
+
+func marketplacesRegistryPath() string {
+	return filepath.Join(claudeDir, "plugins", "known_marketplaces.json")
+}
+
+// marketplaceSource returns a display string for where a marketplace comes from.
+func marketplaceSource(meta claude.MarketplaceMetadata) string {
+	if loc := meta.Source.Location(); loc != "" {
+		if meta.Source.Ref != "" {
+			return loc + "#" + meta.Source.Ref
+		}
+		return loc
+	}
+	if meta.Source.Source != "" {
+		return meta.Source.Source
+	}
+	return "-"
+}
+
+// formatLastUpdated shortens an RFC 3339 timestamp for display.
+func formatLastUpdated(value string) string {
+	if value == "" {
+		return "-"
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.Local().Format("2006-01-02 15:04")
+	}
+	return value
+}
+
+// marketplaceHead returns the short HEAD commit, or "-" when unavailable.
+func marketplaceHead(meta claude.MarketplaceMetadata) string {
+	if !marketplace.IsGitRepo(meta.InstallLocation) {
+		return "-"
+	}
+	head, err := marketplace.Head(meta.InstallLocation)
+	if err != nil {
+		return "-"
+	}
+	return truncateHash(head)
+}
+
+func runMarketplaceAdd(cmd *cobra.Command, args []string) error {
+	m, err := profile.ParseMarketplaceArg(args[0])
+	if err != nil {
+		return err
+	}
+	source := m.AddArg()
+
+	registry, err := claude.LoadMarketplaces(claudeDir)
+	if err != nil {
+		return fmt.Errorf("failed to load marketplaces: %w", err)
+	}
+	if name := registry.GetMarketplaceByRepo(m.Key()); name != "" {
+		ui.PrintInfo(fmt.Sprintf("Marketplace %s is already added as %s", source, name))
+		return nil
+	}
+
+	executor, err := newExecutor()
+	if err != nil {
+		return err
+	}
+	err = events.GlobalTracker().RecordFileWrite(
+		"marketplace add",
+		marketplacesRegistryPath(),
+		"user",
+		func() error {
+			output, err := executor.RunWithOutput("plugin", "marketplace", "add", source)
+			if err != nil {
+				return fmt.Errorf("failed to add marketplace %s: %w\n  Output: %s", source, err, strings.TrimSpace(output))
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if registry, err := claude.LoadMarketplaces(claudeDir); err == nil {
+		if name := registry.GetMarketplaceByRepo(m.Key()); name != "" {
+			ui.PrintSuccess(fmt.Sprintf("Added marketplace %s (%s)", name, source))
+			return nil
+		}
+	}
+	ui.PrintSuccess(fmt.Sprintf("Added marketplace %s", source))
+	return nil
+}
+
+func runMarketplaceRemove(cmd *cobra.Command, args []string) error {
+	_, name, err := claude.FindMarketplace(claudeDir, args[0])
+	if err != nil {
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	dependents, err := marketplace.EnabledDependents(claudeDir, cwd, name)
+	if err != nil {
+		return err
+	}
+
+	// --cascade only uninstalls here; plugins enabled in other projects have
+	// to be uninstalled from those projects
+	var elsewhere []string
+	for _, d := range dependents {
+		if d.ProjectDir != "" && d.ProjectDir != cwd {
+			elsewhere = append(elsewhere, fmt.Sprintf("  %s (%s, in %s)", d.Plugin, d.Scope, d.ProjectDir))
+		}
+	}
+	if len(elsewhere) > 0 {
+		return fmt.Errorf("marketplace %s is used by plugins enabled in other projects:\n%s\n\nUninstall them from those projects first",
+			name, strings.Join(elsewhere, "\n"))
+	}
+
+	if len(dependents) > 0 && !marketplaceRemoveCascade {
+		var lines []string
+		for _, d := range dependents {
+			lines = append(lines, fmt.Sprintf("  %s (%s)", d.Plugin, d.Scope))
+		}
+		return fmt.Errorf("marketplace %s is used by enabled plugins:\n%s\n\nDisable or uninstall them first, or pass --cascade to uninstall them",
+			name, strings.Join(lines, "\n"))
+	}
+
+	executor, err := newExecutor()
+	if err != nil {
+		return err
+	}
+	for _, d := range dependents {
+		opts := profile.PluginOpOptions{
+			Scope:      d.Scope,
+			ClaudeDir:  claudeDir,
+			ProjectDir: d.ProjectDir,
+			Executor:   executor,
+		}
+		if _, err := profile.UninstallPlugin(d.Plugin, opts); err != nil {
+			return fmt.Errorf("failed to uninstall %s before removing marketplace: %w", d.Plugin, err)
+		}
+		ui.PrintSuccess(fmt.Sprintf("Uninstalled %s (%s scope)", d.Plugin, d.Scope))
+	}
+
+	err = events.GlobalTracker().RecordFileWrite(
+		"marketplace remove",
+		marketplacesRegistryPath(),
+		"user",
+		func() error {
+			output, err := executor.RunWithOutput("plugin", "marketplace", "remove", name)
+			if err != nil {
+				return fmt.Errorf("failed to remove marketplace %s: %w\n  Output: %s", name, err, strings.TrimSpace(output))
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	pins, err := marketplace.LoadPins(claudeupHome)
+	if err == nil {
+		if _, ok := pins[name]; ok {
+			delete(pins, name)
+			if err := marketplace.SavePins(claudeupHome, pins); err != nil {
+				ui.PrintWarning(fmt.Sprintf("Could not clear pin for %s: %v", name, err))
+			}
+		}
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Removed marketplace %s", name))
+	return nil
+}
+
+func runMarketplaceList(cmd *cobra.Command, args []string) error {
+	registry, err := claude.LoadMarketplaces(claudeDir)
+	if err != nil {
+		return fmt.Errorf("failed to load marketplaces: %w", err)
+	}
+	if len(registry) == 0 {
+		fmt.Println("No marketplaces installed")
+		fmt.Printf("\n%s Run '%s' to add one\n", ui.Muted(ui.SymbolArrow), ui.Bold("claudeup marketplace add <repo>"))
+		return nil
+	}
+
+	pins, err := marketplace.LoadPins(claudeupHome)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	type row struct {
+		name, source, head, updated, plugins, location string
+	}
+	rows := make([]row, 0, len(names))
+	nameWidth, sourceWidth, headWidth := len("NAME"), len("SOURCE"), len("HEAD")
+	for _, name := range names {
+		meta := registry[name]
+		r := row{
+			name:     name,
+			source:   marketplaceSource(meta),
+			head:     marketplaceHead(meta),
+			updated:  formatLastUpdated(meta.LastUpdated),
+			plugins:  "-",
+			location: meta.InstallLocation,
+		}
+		if pin, ok := pins[name]; ok {
+			r.head += " (pinned " + pin.Ref + ")"
+		}
+		if index, err := claude.LoadMarketplaceIndex(meta.InstallLocation); err == nil {
+			r.plugins = fmt.Sprintf("%d", len(index.Plugins))
+		}
+		nameWidth = max(nameWidth, len(r.name))
+		sourceWidth = max(sourceWidth, len(r.source))
+		headWidth = max(headWidth, len(r.head))
+		rows = append(rows, r)
+	}
+
+	rowFmt := fmt.Sprintf("%%-%ds  %%-%ds  %%-%ds  %%-16s  %%-7s  %%s", nameWidth, sourceWidth, headWidth)
+	header := fmt.Sprintf(rowFmt, "NAME", "SOURCE", "HEAD", "UPDATED", "PLUGINS", "LOCATION")
+	fmt.Println(ui.Bold(header))
+	fmt.Println(ui.Muted(strings.Repeat("─", len(header))))
+	for _, r := range rows {
+		fmt.Printf(rowFmt+"\n", r.name, r.source, r.head, r.updated, r.plugins, ui.Muted(r.location))
+	}
+	return nil
+}
+
+func runMarketplaceShow(cmd *cobra.Command, args []string) error {
+	meta, name, err := claude.FindMarketplace(claudeDir, args[0])
+	if err != nil {
+		return err
+	}
+
+	pins, err := marketplace.LoadPins(claudeupHome)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(ui.RenderSection(name, -1))
+	fmt.Println(ui.Indent(ui.RenderDetail("Source", marketplaceSource(*meta)), 1))
+	fmt.Println(ui.Indent(ui.RenderDetail("Location", meta.InstallLocation), 1))
+	fmt.Println(ui.Indent(ui.RenderDetail("HEAD", marketplaceHead(*meta)), 1))
+	if pin, ok := pins[name]; ok {
+		fmt.Println(ui.Indent(ui.RenderDetail("Pinned", fmt.Sprintf("%s (%s)", pin.Ref, truncateHash(pin.Commit))), 1))
+	}
+	fmt.Println(ui.Indent(ui.RenderDetail("Updated", formatLastUpdated(meta.LastUpdated)), 1))
+
+	index, err := claude.LoadMarketplaceIndex(meta.InstallLocation)
+	if err != nil {
+		fmt.Println()
+		ui.PrintWarning(fmt.Sprintf("No plugin index found in %s", meta.InstallLocation))
+		return nil
+	}
+
+	plugins, err := claude.LoadPlugins(claudeDir)
+	if err != nil {
+		// Still show the index, just without installation status
+		plugins = nil
+	}
+
+	sorted := make([]claude.MarketplacePluginInfo, len(index.Plugins))
+	copy(sorted, index.Plugins)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	fmt.Println()
+	fmt.Println(ui.RenderSection("Plugins", len(sorted)))
+	for _, p := range sorted {
+		line := "  " + p.Name
+		if p.Version != "" {
+			line += " " + ui.Muted(p.Version)
+		}
+		if plugins != nil && plugins.PluginExistsAtAnyScope(p.Name+"@"+name) {
+			line += " " + ui.Success("installed")
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func runMarketplacePin(cmd *cobra.Command, args []string) error {
+	if marketplacePinClear && len(args) > 1 {
+		return fmt.Errorf("--clear does not take a ref")
+	}
+	if !marketplacePinClear && len(args) < 2 {
+		return fmt.Errorf("missing ref: specify a tag, commit, or branch, or use --clear")
+	}
+
+	meta, name, err := claude.FindMarketplace(claudeDir, args[0])
+	if err != nil {
+		return err
+	}
+	if meta.Source.IsDirectory() {
+		return fmt.Errorf("marketplace %s is a local directory; check out the ref in %s instead", name, meta.InstallLocation)
+	}
+	if !marketplace.IsGitRepo(meta.InstallLocation) {
+		return fmt.Errorf("marketplace %s at %s is not a git repository", name, meta.InstallLocation)
+	}
+
+	pins, err := marketplace.LoadPins(claudeupHome)
+	if err != nil {
+		return err
+	}
+
+	if marketplacePinClear {
+		if _, ok := pins[name]; !ok {
+			ui.PrintInfo(fmt.Sprintf("%s is not pinned", name))
+			return nil
+		}
+		branch, err := marketplace.DefaultBranch(meta.InstallLocation)
+		if err != nil {
+			return err
+		}
+		if err := marketplace.CheckoutBranch(meta.InstallLocation, branch); err != nil {
+			return err
+		}
+		delete(pins, name)
+		if err := marketplace.SavePins(claudeupHome, pins); err != nil {
+			return err
+		}
+		ui.PrintSuccess(fmt.Sprintf("Unpinned %s (now on %s)", name, branch))
+		fmt.Printf("%s Run '%s' to pull the latest commits\n", ui.Muted(ui.SymbolArrow), ui.Bold("claudeup marketplace update "+name))
+		return nil
+	}
+
+	ref := args[1]
+	if err := marketplace.Fetch(meta.InstallLocation); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not fetch %s, using local refs: %v", name, err))
+	}
+	commit, err := marketplace.ResolveRef(meta.InstallLocation, ref)
+	if err != nil {
+		return err
+	}
+	if err := marketplace.CheckoutDetached(meta.InstallLocation, commit); err != nil {
+		return err
+	}
+
+	pins[name] = marketplace.Pin{Ref: ref, Commit: commit, PinnedAt: time.Now().UTC()}
+	if err := marketplace.SavePins(claudeupHome, pins); err != nil {
+		return err
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Pinned %s at %s (%s)", name, ref, truncateHash(commit)))
+	fmt.Printf("%s Run '%s' to move installed plugins to this version\n", ui.Muted(ui.SymbolArrow), ui.Bold("claudeup upgrade"))
+	return nil
+}
+
+func runMarketplaceUpdate(cmd *cobra.Command, args []string) error {
+	registry, err := claude.LoadMarketplaces(claudeDir)
+	if err != nil {
+		return fmt.Errorf("failed to load marketplaces: %w", err)
+	}
+	pins, err := marketplace.LoadPins(claudeupHome)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	if len(args) == 0 {
+		for name := range registry {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	} else {
+		for _, arg := range args {
+			_, name, err := claude.FindMarketplace(claudeDir, arg)
+			if err != nil {
+				return err
+			}
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		fmt.Println("No marketplaces installed")
+		return nil
+	}
+
+	fmt.Println(ui.RenderSection("Updating Marketplaces", len(names)))
+	var failed int
+	for _, name := range names {
+		meta := registry[name]
+		if pin, ok := pins[name]; ok {
+			fmt.Printf("  %s %s: %s\n", ui.Muted(ui.SymbolArrow), name, ui.Muted("Pinned at "+pin.Ref+" (skipped)"))
+			continue
+		}
+		if meta.Source.IsDirectory() {
+			fmt.Printf("  %s %s: %s\n", ui.Muted(ui.SymbolArrow), name, ui.Muted("Local directory (skipped)"))
+			continue
+		}
+		if !marketplace.IsGitRepo(meta.InstallLocation) {
+			fmt.Printf("  %s %s: %s\n", ui.Muted(ui.SymbolArrow), name, ui.Muted("Not a git repository (skipped)"))
+			continue
+		}
+
+		before, _ := marketplace.Head(meta.InstallLocation)
+		if err := updateMarketplace(name, meta); err != nil {
+			failed++
+			ui.PrintError(fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		after, _ := marketplace.Head(meta.InstallLocation)
+		if before == after {
+			fmt.Printf("  %s %s: %s\n", ui.Success(ui.SymbolSuccess), name, ui.Muted("Up to date"))
+		} else {
+			fmt.Printf("  %s %s: %s %s %s\n", ui.Success(ui.SymbolSuccess), name, truncateHash(before), ui.SymbolArrow, truncateHash(after))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d marketplace(s) failed to update", failed)
+	}
+	return nil
+}
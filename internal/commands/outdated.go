@@ -5,8 +5,10 @@ package commands
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/claudeup/claudeup/v5/internal/claude"
+	"github.com/claudeup/claudeup/v5/internal/marketplace"
 	"github.com/claudeup/claudeup/v5/internal/selfupdate"
 	"github.com/claudeup/claudeup/v5/internal/ui"
 	"github.com/spf13/cobra"
@@ -27,21 +29,32 @@ This is a read-only command that only checks for updates without applying them.`
 	RunE: runOutdated,
 }
 
-var outdatedAll bool
+var (
+	outdatedAll     bool
+	outdatedRefresh bool
+)
 
 func init() {
 	rootCmd.AddCommand(outdatedCmd)
 	outdatedCmd.Flags().BoolVar(&outdatedAll, "all", false, "Check plugins across all scopes, not just the current context")
+	outdatedCmd.Flags().BoolVar(&outdatedRefresh, "refresh", false, "Fetch every marketplace instead of reusing recent check results")
 }
 
 func runOutdated(cmd *cobra.Command, args []string) error {
 	currentVersion := rootCmd.Version
 
+	ctx, stop := interruptContext(cmd.Context())
+	defer stop()
+
 	// Check CLI updates
 	fmt.Println()
 	fmt.Println(ui.RenderSection("CLI", -1))
 
-	latestVersion, err := selfupdate.CheckLatestVersion(selfupdate.DefaultAPIURL)
+	source, channel, err := updateSettings()
+	if err != nil {
+		return err
+	}
+	latestVersion, err := source.LatestVersion(channel)
 	if err != nil {
 		fmt.Printf("  %s claudeup: %s\n", ui.Warning(ui.SymbolWarning), ui.Muted("Unable to check for updates"))
 		fmt.Printf("    %s %s\n", ui.Muted("Error:"), ui.Muted(err.Error()))
@@ -70,15 +83,33 @@ func runOutdated(cmd *cobra.Command, args []string) error {
 	if len(marketplaces) == 0 {
 		fmt.Printf("  %s\n", ui.Muted("No marketplaces installed"))
 	} else {
-		checkMarketplaceUpdates(marketplaces, func(update MarketplaceUpdate) {
+		pins, err := marketplace.LoadPins(claudeupHome)
+		if err != nil {
+			return fmt.Errorf("failed to load marketplace pins: %w", err)
+		}
+		unpinned, pinnedUpdates := splitPinnedMarketplaces(marketplaces, pins)
+		for _, update := range pinnedUpdates {
+			fmt.Printf("  %s %s %s\n", ui.Muted(ui.SymbolArrow), update.Name, ui.Muted("(pinned at "+update.PinnedRef+")"))
+		}
+		cache := make(updateCheckCache)
+		if !outdatedRefresh {
+			cache = loadUpdateCheckCache(claudeupHome)
+		}
+		checkMarketplaceUpdates(ctx, unpinned, cache, func(update MarketplaceUpdate) {
 			if update.HasUpdate {
-				fmt.Printf("  %s %s %s %s %s\n", ui.Warning(ui.SymbolWarning), update.Name, update.CurrentCommit, ui.SymbolArrow, ui.Success(update.LatestCommit))
+				fmt.Printf("  %s %s %s %s %s\n", ui.Warning(ui.SymbolWarning), update.Name, truncateHash(update.CurrentCommit), ui.SymbolArrow, ui.Success(truncateHash(update.LatestCommit)))
+			} else if update.Local {
+				fmt.Printf("  %s %s %s\n", ui.Muted(ui.SymbolArrow), update.Name, ui.Muted("(local directory)"))
 			} else if update.CheckFailed {
 				fmt.Printf("  %s %s %s\n", ui.Warning(ui.SymbolWarning), update.Name, ui.Muted("(unable to check)"))
 			} else {
 				fmt.Printf("  %s %s %s\n", ui.Success(ui.SymbolSuccess), update.Name, ui.Muted("(up to date)"))
 			}
 		})
+		saveUpdateCheckCache(claudeupHome, cache, time.Now())
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("update check interrupted: %w", err)
+		}
 	}
 
 	// Check plugin updates
@@ -95,7 +126,7 @@ func runOutdated(cmd *cobra.Command, args []string) error {
 	if len(scopedPlugins) == 0 {
 		fmt.Printf("  %s\n", ui.Muted("No plugins installed"))
 	} else {
-		pluginUpdates := checkPluginUpdates(scopedPlugins, marketplaces)
+		pluginUpdates := checkPluginUpdates(ctx, scopedPlugins, marketplaces)
 		if len(pluginUpdates) == 0 {
 			fmt.Printf("  %s All plugins up to date\n", ui.Success(ui.SymbolSuccess))
 		} else {
@@ -103,7 +134,7 @@ func runOutdated(cmd *cobra.Command, args []string) error {
 			for _, update := range pluginUpdates {
 				if update.HasUpdate {
 					hasOutdated = true
-					fmt.Printf("  %s %s (%s) %s %s %s\n", ui.Warning(ui.SymbolWarning), update.Name, update.Scope, update.CurrentCommit, ui.SymbolArrow, ui.Success(update.LatestCommit))
+					fmt.Printf("  %s %s (%s) %s %s %s\n", ui.Warning(ui.SymbolWarning), update.Name, update.Scope, truncateHash(update.CurrentCommit), ui.SymbolArrow, ui.Success(truncateHash(update.LatestCommit)))
 				}
 			}
 			if !hasOutdated {
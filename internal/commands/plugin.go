@@ -1,5 +1,5 @@
 // ABOUTME: Plugin subcommand group for managing Claude Code plugins
-// ABOUTME: Provides list, browse, show, and search subcommands
+// ABOUTME: Provides list, browse, show, search, and install/enable subcommands
 package commands
 
 import (
@@ -32,7 +32,8 @@ var pluginCmd = &cobra.Command{
 	Short: "Manage plugins",
 	Long: `Manage Claude Code plugins - list, browse, show, and search.
 
-Use 'claude plugin install' and 'claude plugin uninstall' to add or remove plugins.`,
+Use 'install', 'uninstall', 'enable', and 'disable' to change plugins at a
+specific scope (--user, --project, or --local).`,
 }
 
 var pluginListCmd = &cobra.Command{
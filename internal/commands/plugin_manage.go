@@ -0,0 +1,251 @@
+// ABOUTME: Plugin install, uninstall, enable, and disable subcommands
+// ABOUTME: Scope-aware wrappers that can also record the change in a saved profile
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/claudeup/claudeup/v5/internal/profile"
+	"github.com/claudeup/claudeup/v5/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pluginManageScope   string
+	pluginManageUser    bool
+	pluginManageProject bool
+	pluginManageLocal   bool
+	pluginManageSaveTo  string
+)
+
+var pluginInstallCmd = &cobra.Command{
+	Use:   "install <plugin>@<marketplace>",
+	Short: "Install and enable a plugin",
+	Long: `Install a plugin from a registered marketplace and enable it at the chosen scope.
+
+The plugin is installed with the claude CLI (or by claudeup itself with
+--engine native), then claudeup makes sure the
+scope's settings file enables it. Use --save-to to also add the plugin to a
+saved profile so 'profile status' does not report drift.`,
+	Example: `  claudeup plugin install tdd-workflows@claude-code-workflows
+  claudeup plugin install tdd-workflows@claude-code-workflows --project
+  claudeup plugin install tdd-workflows@claude-code-workflows --save-to backend`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPluginInstall,
+}
+
+var pluginUninstallCmd = &cobra.Command{
+	Use:   "uninstall <plugin>@<marketplace>",
+	Short: "Uninstall a plugin",
+	Long: `Uninstall a plugin from the chosen scope.
+
+The plugin is removed with the claude CLI (or by claudeup itself with
+--engine native). Any settings or installed_plugins.json
+entries left behind at that scope are cleaned up. Use --save-to to also remove
+the plugin from a saved profile.`,
+	Example: `  claudeup plugin uninstall tdd-workflows@claude-code-workflows
+  claudeup plugin uninstall tdd-workflows@claude-code-workflows --local`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPluginUninstall,
+}
+
+var pluginEnableCmd = &cobra.Command{
+	Use:   "enable <plugin>@<marketplace>",
+	Short: "Enable an installed plugin",
+	Long:  `Enable an installed plugin in the chosen scope's settings file.`,
+	Example: `  claudeup plugin enable tdd-workflows@claude-code-workflows
+  claudeup plugin enable tdd-workflows@claude-code-workflows --scope project`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPluginEnable,
+}
+
+var pluginDisableCmd = &cobra.Command{
+	Use:   "disable <plugin>@<marketplace>",
+	Short: "Disable a plugin without uninstalling it",
+	Long: `Disable a plugin in the chosen scope's settings file.
+
+Disabling at project or local scope overrides a plugin enabled at user scope.
+Profiles have no disabled state, so --save-to is not accepted; use
+'plugin uninstall --save-to' to drop a plugin from a profile.`,
+	Example: `  claudeup plugin disable tdd-workflows@claude-code-workflows
+  claudeup plugin disable tdd-workflows@claude-code-workflows --local`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPluginDisable,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{pluginInstallCmd, pluginUninstallCmd, pluginEnableCmd, pluginDisableCmd} {
+		pluginCmd.AddCommand(cmd)
+		cmd.Flags().StringVar(&pluginManageScope, "scope", "", "Scope to change: user, project, or local (default: user)")
+		cmd.Flags().BoolVar(&pluginManageUser, "user", false, "Change user scope (~/.claude/settings.json)")
+		cmd.Flags().BoolVar(&pluginManageProject, "project", false, "Change project scope (.claude/settings.json)")
+		cmd.Flags().BoolVar(&pluginManageLocal, "local", false, "Change local scope (.claude/settings.local.json)")
+		cmd.Flags().StringVar(&pluginManageSaveTo, "save-to", "", "Also record the change in this saved profile")
+	}
+	// Kept only to reject it with an explanation
+	pluginDisableCmd.Flags().MarkHidden("save-to")
+}
+
+// pluginOpOptions builds operation options from the scope flags.
+func pluginOpOptions() (profile.PluginOpOptions, error) {
+	scope, err := resolveScopeFlags(pluginManageScope, pluginManageUser, pluginManageProject, pluginManageLocal)
+	if err != nil {
+		return profile.PluginOpOptions{}, err
+	}
+	if scope == "" {
+		scope = "user"
+	}
+
+	executor, err := newExecutor()
+	if err != nil {
+		return profile.PluginOpOptions{}, err
+	}
+	opts := profile.PluginOpOptions{
+		Scope:     scope,
+		ClaudeDir: claudeDir,
+		Executor:  executor,
+	}
+	if scope != "user" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return profile.PluginOpOptions{}, fmt.Errorf("failed to get current directory: %w", err)
+		}
+		opts.ProjectDir = cwd
+	}
+	return opts, nil
+}
+
+func runPluginInstall(cmd *cobra.Command, args []string) error {
+	opts, err := pluginOpOptions()
+	if err != nil {
+		return err
+	}
+
+	result, err := profile.InstallPlugin(args[0], opts)
+	if err != nil {
+		return err
+	}
+
+	if result.Changed {
+		ui.PrintSuccess(fmt.Sprintf("Installed %s (%s scope)", result.Plugin, result.Scope))
+	} else {
+		ui.PrintInfo(fmt.Sprintf("%s is already installed at %s scope", result.Plugin, result.Scope))
+	}
+	printPluginOpWarnings(result)
+
+	return savePluginChangeToProfile(result, true)
+}
+
+func runPluginUninstall(cmd *cobra.Command, args []string) error {
+	opts, err := pluginOpOptions()
+	if err != nil {
+		return err
+	}
+
+	result, err := profile.UninstallPlugin(args[0], opts)
+	if err != nil {
+		return err
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Uninstalled %s (%s scope)", result.Plugin, result.Scope))
+	printPluginOpWarnings(result)
+
+	return savePluginChangeToProfile(result, false)
+}
+
+func runPluginEnable(cmd *cobra.Command, args []string) error {
+	opts, err := pluginOpOptions()
+	if err != nil {
+		return err
+	}
+
+	result, err := profile.EnablePlugin(args[0], opts)
+	if err != nil {
+		return err
+	}
+
+	if result.Changed {
+		ui.PrintSuccess(fmt.Sprintf("Enabled %s (%s scope)", result.Plugin, result.Scope))
+	} else {
+		ui.PrintInfo(fmt.Sprintf("%s is already enabled at %s scope", result.Plugin, result.Scope))
+	}
+
+	return savePluginChangeToProfile(result, true)
+}
+
+func runPluginDisable(cmd *cobra.Command, args []string) error {
+	// Removing the plugin from the profile would uninstall it on the next
+	// apply, which is more than disabling it here
+	if pluginManageSaveTo != "" {
+		return fmt.Errorf("--save-to is not supported by disable: profiles cannot record a disabled plugin; use 'claudeup plugin uninstall --save-to %s' to remove it from the profile", pluginManageSaveTo)
+	}
+	opts, err := pluginOpOptions()
+	if err != nil {
+		return err
+	}
+
+	result, err := profile.DisablePlugin(args[0], opts)
+	if err != nil {
+		return err
+	}
+
+	if result.Changed {
+		ui.PrintSuccess(fmt.Sprintf("Disabled %s (%s scope)", result.Plugin, result.Scope))
+	} else {
+		ui.PrintInfo(fmt.Sprintf("%s is already disabled at %s scope", result.Plugin, result.Scope))
+	}
+
+	return nil
+}
+
+func printPluginOpWarnings(result *profile.PluginOpResult) {
+	for _, w := range result.Warnings {
+		ui.PrintWarning(w)
+	}
+}
+
+// savePluginChangeToProfile adds or removes the plugin in the --save-to
+// profile at the operation's scope. Adding a plugin also adds its marketplace
+// when the profile does not reference it yet. No-op without --save-to.
+func savePluginChangeToProfile(result *profile.PluginOpResult, add bool) error {
+	if pluginManageSaveTo == "" {
+		return nil
+	}
+
+	profilePath, err := resolveProfileArg(getProfilesDir(), pluginManageSaveTo)
+	if err != nil {
+		return err
+	}
+	p, err := profile.LoadFromPath(profilePath)
+	if err != nil {
+		return fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	var changed bool
+	if add {
+		changed, err = p.AddPlugin(result.Scope, result.Plugin)
+		if err != nil {
+			return err
+		}
+		if m, ok := profile.MarketplaceForPlugin(claudeDir, result.Plugin); ok && p.AddMarketplace(m) {
+			changed = true
+		}
+	} else {
+		changed, err = p.RemovePlugin(result.Scope, result.Plugin)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !changed {
+		ui.PrintMuted(fmt.Sprintf("Profile %q already up to date", p.Name))
+		return nil
+	}
+
+	if err := profile.SaveToPath(profilePath, p); err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+	ui.PrintSuccess(fmt.Sprintf("Updated profile %q", p.Name))
+	return nil
+}
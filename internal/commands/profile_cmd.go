@@ -4,6 +4,7 @@ package commands
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -15,10 +16,11 @@ import (
 	"strings"
 	"time"
 
-	"github.com/claudeup/claudeup/v5/internal/backup"
 	"github.com/claudeup/claudeup/v5/internal/breadcrumb"
 	"github.com/claudeup/claudeup/v5/internal/claude"
 	"github.com/claudeup/claudeup/v5/internal/config"
+	"github.com/claudeup/claudeup/v5/internal/ext"
+	"github.com/claudeup/claudeup/v5/internal/ledger"
 	"github.com/claudeup/claudeup/v5/internal/profile"
 	"github.com/claudeup/claudeup/v5/internal/ui"
 	"github.com/spf13/cobra"
@@ -61,7 +63,7 @@ Use 'claudeup profile status' to see effective configuration across all scopes.`
 }
 
 var profileApplyCmd = &cobra.Command{
-	Use:     "apply <name>",
+	Use:     "apply <name> [name...]",
 	Aliases: []string{"use"},
 	Short:   "Apply a profile to Claude Code",
 	Long: `Apply a profile's configuration to your Claude Code installation.
@@ -86,6 +88,13 @@ Use -y to skip the prompt and always keep extras (additive).
 DRY RUN:
   --dry-run        Show what would change without making any modifications.
 
+LAYERS:
+  Naming several profiles applies them together as an ad-hoc stack, merged
+  left to right with the same rules as a stack profile's includes. Each
+  layer is recorded, so 'profile status' and 'profile diff' show which layer
+  every plugin and MCP server came from, and 'profile remove-layer' peels
+  one layer off without touching the others.
+
 Precedence: local > project > user. Plugins from all scopes are active simultaneously.
 
 For team projects, use --project to create a shareable configuration that
@@ -108,8 +117,11 @@ Shows a diff of changes before applying. Prompts for confirmation unless -y is u
   claudeup profile apply backend-stack --project
 
   # Force the post-apply setup wizard to run
-  claudeup profile apply my-profile --setup`,
-	Args: cobra.ExactArgs(1),
+  claudeup profile apply my-profile --setup
+
+  # Apply several profiles together as layers
+  claudeup profile apply base python-dev security --user`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: runProfileApply,
 }
 
@@ -182,8 +194,18 @@ With -y flag, --from is required (no interactive selection).`,
 var profileShowCmd = &cobra.Command{
 	Use:   "show <name>",
 	Short: "Display a profile's contents",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runProfileShow,
+	Long: `Display a profile's contents.
+
+For stack profiles, shows the include tree, the merged result, and any
+conflicts between includes. With --resolved, each merged item is annotated
+with the include it came from.`,
+	Example: `  # Show a profile
+  claudeup profile show my-setup
+
+  # Show a stack's merged result and where each item came from
+  claudeup profile show team-stack --resolved`,
+	Args: cobra.ExactArgs(1),
+	RunE: runProfileShow,
 }
 
 var profileStatusCmd = &cobra.Command{
@@ -194,7 +216,9 @@ var profileStatusCmd = &cobra.Command{
 Shows plugins from all active scopes (user, project, local) with:
   - Scope grouping (user, project, local)
   - Enabled/disabled status
-  - Marketplace summary`,
+  - Marketplace summary
+  - The profile that added each plugin, MCP server, and marketplace
+  - Project extensions edited locally or behind the extension library`,
 	Example: `  # Show what Claude is actually running
   claudeup profile status`,
 	Args: cobra.NoArgs,
@@ -236,7 +260,15 @@ var profileSuggestCmd = &cobra.Command{
 var profileResetCmd = &cobra.Command{
 	Use:   "reset <name>",
 	Short: "Remove all components installed by a profile",
-	Long: `Removes all plugins, MCP servers, and marketplaces that a profile would install.
+	Long: `Removes the plugins, MCP servers, and marketplaces that a profile installed.
+
+Each apply records in ~/.claudeup/ledger.json which items the profile
+introduced, as opposed to items that were already installed. Reset removes
+only those items (for the user scope and the current project), so plugins
+you installed yourself or that another profile added are left alone.
+Profiles last applied before the ledger existed fall back to removing
+plugins from the profile's marketplaces, its MCP servers, and its
+marketplaces.
 
 This is useful for:
   - Testing a profile from scratch
@@ -298,6 +330,7 @@ cannot be renamed.`,
 var (
 	profileApplySetup         bool
 	profileApplyNoInteractive bool
+	profileApplyUnsandboxed   bool
 	profileApplyForce         bool
 	profileApplyScope         string
 	profileApplyReinstall     bool
@@ -321,6 +354,9 @@ var (
 // Flags for profile list command
 var profileListAll bool
 
+// Flags for profile show command
+var profileShowResolved bool
+
 // Flags for profile clean command
 var (
 	profileCleanScope   string
@@ -474,7 +510,7 @@ func profileExists(profilesDir, name string) bool {
 // profileExistsAtRoot checks if a profile file exists at the root of profilesDir.
 // Use this instead of profileExists when the operation writes to root (Save always writes to root).
 func profileExistsAtRoot(profilesDir, name string) bool {
-	_, err := os.Stat(filepath.Join(profilesDir, name+".json"))
+	_, err := os.Stat(profile.RootProfilePath(profilesDir, name))
 	return err == nil
 }
 
@@ -500,7 +536,7 @@ func resolveProfileArg(profilesDir, nameOrPath string) (string, error) {
 			if err != nil {
 				rel = p
 			}
-			relPaths[i] = strings.TrimSuffix(filepath.ToSlash(rel), ".json")
+			relPaths[i] = profile.TrimProfileExt(filepath.ToSlash(rel))
 		}
 
 		if config.YesFlag {
@@ -541,6 +577,7 @@ func init() {
 	profileCmd.AddCommand(profileCreateCmd)
 	profileCmd.AddCommand(profileCloneCmd)
 	profileCmd.AddCommand(profileShowCmd)
+	profileShowCmd.Flags().BoolVar(&profileShowResolved, "resolved", false, "For stacks, show which include each merged item came from")
 	profileCmd.AddCommand(profileStatusCmd)
 	profileCmd.AddCommand(profileDiffCmd)
 	profileCmd.AddCommand(profileSuggestCmd)
@@ -554,7 +591,7 @@ func init() {
 	profileCreateCmd.Flags().StringVar(&profileCreateDescription, "description", "", "Profile description")
 	profileCreateCmd.Flags().StringSliceVar(&profileCreateMarketplaces, "marketplace", nil, "Marketplace in owner/repo format (can be repeated)")
 	profileCreateCmd.Flags().StringSliceVar(&profileCreatePlugins, "plugin", nil, "Plugin in name@marketplace-ref format (can be repeated)")
-	profileCreateCmd.Flags().StringVar(&profileCreateFromFile, "from-file", "", "Create profile from JSON file")
+	profileCreateCmd.Flags().StringVar(&profileCreateFromFile, "from-file", "", "Create profile from a JSON or YAML file")
 	profileCreateCmd.Flags().BoolVar(&profileCreateFromStdin, "from-stdin", false, "Create profile from JSON on stdin")
 	profileCreateCmd.Flags().StringVar(&profileCreateScope, "scope", "", "Target scope for plugins/MCP servers: user, project, or local (default: user)")
 	profileCreateCmd.Flags().BoolVar(&profileCreateUser, "user", false, "Place plugins/MCP servers in user scope")
@@ -572,6 +609,7 @@ func init() {
 	// Add flags to profile apply command
 	profileApplyCmd.Flags().BoolVar(&profileApplySetup, "setup", false, "Force post-apply setup wizard to run")
 	profileApplyCmd.Flags().BoolVar(&profileApplyNoInteractive, "no-interactive", false, "Skip post-apply setup wizard (for CI/scripting)")
+	profileApplyCmd.Flags().BoolVar(&profileApplyUnsandboxed, "allow-unsandboxed-hooks", false, "Run hooks that ask for a sandbox unisolated when no sandbox is available")
 	profileApplyCmd.Flags().BoolVarP(&profileApplyForce, "force", "f", false, "Force reapply even with unsaved changes")
 	profileApplyCmd.Flags().StringVar(&profileApplyScope, "scope", "", "Apply scope: user, project, or local (default: user)")
 	profileApplyCmd.Flags().BoolVar(&profileApplyUser, "user", false, fmt.Sprintf("Apply to user scope (%s/)", config.ClaudeDirDisplay()))
@@ -815,11 +853,13 @@ func runProfileApply(cmd *cobra.Command, args []string) error {
 	}
 	profileApplyScope = resolvedScope
 
-	name := args[0]
+	name := strings.Join(args, " ")
 
 	// "current" is reserved for the live status view (profile show current)
-	if name == "current" {
-		return fmt.Errorf("'current' is a reserved name. Use 'claudeup profile status' to view current configuration")
+	for _, arg := range args {
+		if arg == "current" {
+			return fmt.Errorf("'current' is a reserved name. Use 'claudeup profile status' to view current configuration")
+		}
 	}
 
 	var scope profile.Scope
@@ -852,21 +892,12 @@ func runProfileApply(cmd *cobra.Command, args []string) error {
 
 		// Check if there's anything to clear
 		if _, err := os.Stat(settingsPath); err == nil {
-			claudeupHome := config.MustClaudeupHome()
-
-			// Create backup unless -y (silent mode) is used
-			if !config.YesFlag {
-				var backupPath string
-				if scopeStr == "local" {
-					backupPath, err = backup.SaveLocalScopeBackup(claudeupHome, cwd, settingsPath)
-				} else {
-					backupPath, err = backup.SaveScopeBackup(claudeupHome, scopeStr, settingsPath)
-				}
-				if err != nil {
-					ui.PrintWarning(fmt.Sprintf("Could not create backup: %v", err))
-				} else {
-					fmt.Printf("  Backup saved: %s\n", backupPath)
-				}
+			// Back up everything the replace may touch; -y only silences the message
+			gen, err := createBackupGeneration(cwd, fmt.Sprintf("profile apply %s --replace", name), backupRetention())
+			if err != nil {
+				ui.PrintWarning(fmt.Sprintf("Could not create backup: %v", err))
+			} else if !config.YesFlag {
+				fmt.Printf("  Backup saved: %s (restore with 'claudeup backup restore --at %s')\n", gen.ID, gen.ID)
 			}
 
 			// Clear the scope
@@ -879,84 +910,124 @@ func runProfileApply(cmd *cobra.Command, args []string) error {
 	}
 
 	explicitScope := profileApplyScope != ""
-	return applyProfileWithScope(name, scope, explicitScope)
+	return applyProfileLayers(args, scope, explicitScope)
 }
 
 // applyProfileWithScope applies a profile at the specified scope.
 // This is the core implementation shared by runProfileApply and runProfileCreate.
 // explicitScope indicates whether the user explicitly passed a scope flag.
 func applyProfileWithScope(name string, scope profile.Scope, explicitScope bool) error {
-	profilesDir := getProfilesDir()
-	cwd, _ := os.Getwd()
+	return applyProfileLayers([]string{name}, scope, explicitScope)
+}
 
-	// Resolve to exact path if ambiguous (handles nested profiles)
-	var p *profile.Profile
+// loadProfileForApply loads a profile from disk (resolving nested paths) or
+// the embedded profiles. Returns the profile and its name normalized to the
+// display format profile list uses, so breadcrumbs match lookups.
+func loadProfileForApply(profilesDir, name string) (*profile.Profile, string, error) {
 	resolvedPath, resolveErr := resolveProfileArg(profilesDir, name)
 	if resolveErr == nil {
 		// Found on disk -- load from resolved path
-		var loadErr error
-		p, loadErr = profile.LoadFromPath(resolvedPath)
+		p, loadErr := profile.LoadFromPath(resolvedPath)
 		if loadErr != nil {
-			return fmt.Errorf("failed to load profile %q: %w", name, loadErr)
+			return nil, "", fmt.Errorf("failed to load profile %q: %w", name, loadErr)
 		}
-		// Normalize name to the display name format (relative path without .json)
-		// so breadcrumbs match what profile list uses for lookups.
+		// Normalize name to the display name format (relative path without extension)
 		if relPath, err := filepath.Rel(profilesDir, resolvedPath); err == nil {
-			name = strings.TrimSuffix(relPath, ".json")
+			name = profile.TrimProfileExt(filepath.ToSlash(relPath))
 		}
-	} else {
-		// Surface ambiguity and other non-not-found errors directly
-		var ambigErr *profile.AmbiguousProfileError
-		if errors.As(resolveErr, &ambigErr) {
-			return resolveErr
+		return p, name, nil
+	}
+
+	// Surface ambiguity and other non-not-found errors directly
+	var ambigErr *profile.AmbiguousProfileError
+	if errors.As(resolveErr, &ambigErr) {
+		return nil, "", resolveErr
+	}
+	// Not found on disk -- try embedded profiles
+	p, embeddedErr := profile.GetEmbeddedProfile(name)
+	if embeddedErr != nil {
+		return nil, "", fmt.Errorf("profile %q not found: %w", name, resolveErr)
+	}
+	return p, name, nil
+}
+
+// applyProfileLayers applies one profile, or several composed left to right
+// into an ad-hoc stack with the same merge rules as a stack's includes.
+// An ad-hoc stack applies like a single profile: at the requested scope, or
+// at its own scopes when a layer defines per-scope settings.
+func applyProfileLayers(names []string, scope profile.Scope, explicitScope bool) error {
+	profilesDir := getProfilesDir()
+	cwd, _ := os.Getwd()
+
+	layers := make([]string, len(names))
+	hookLayer := ""
+	var p *profile.Profile
+	for i, arg := range names {
+		layer, layerName, err := loadProfileForApply(profilesDir, arg)
+		if err != nil {
+			return err
+		}
+		layers[i] = layerName
+		if p == nil {
+			p = layer
 		}
-		// Not found on disk -- try embedded profiles
-		var embeddedErr error
-		p, embeddedErr = profile.GetEmbeddedProfile(name)
-		if embeddedErr != nil {
-			return fmt.Errorf("profile %q not found: %w", name, resolveErr)
+		if layer.PostApply != nil || hookLayer == "" {
+			hookLayer = layerName
 		}
 	}
+	name := profile.LayeredName(layers)
+	applyArgs := strings.Join(layers, " ")
+
+	// Compose ad-hoc stacks; their layers are recorded separately so status,
+	// diff, and remove-layer can attribute items to the layer they came from.
+	var origins *profile.LayerOrigins
+	if len(layers) > 1 {
+		loader := &profile.DirLoader{ProfilesDir: profilesDir}
+		composed, report, err := profile.ComposeLayersWithReport(layers, loader)
+		if err != nil {
+			return fmt.Errorf("failed to compose profiles: %w", err)
+		}
+		if origins, err = profile.NewLayerOrigins(layers, loader); err != nil {
+			return fmt.Errorf("failed to compose profiles: %w", err)
+		}
+		warnMergeConflicts(report)
+		p = composed
+	}
 
 	// Resolve stack profiles (composable includes).
 	// Track whether the original profile was a stack so we always route through
 	// ApplyAllScopes, even if the resolved profile has only flat fields.
-	wasStack := p.IsStack()
+	wasStack := len(layers) == 1 && p.IsStack()
 	if wasStack {
 		if explicitScope {
 			return fmt.Errorf("stack profiles define their own scopes; --scope is not supported with stacks")
 		}
 		loader := &profile.DirLoader{ProfilesDir: profilesDir}
-		resolved, resolveIncludesErr := profile.ResolveIncludes(p, loader)
+		resolved, report, resolveIncludesErr := profile.ResolveIncludesWithReport(p, loader)
 		if resolveIncludesErr != nil {
 			return fmt.Errorf("failed to resolve includes: %w", resolveIncludesErr)
 		}
+		warnMergeConflicts(report)
 		p = resolved
 	}
 
-	// Security check FIRST: warn about hooks from non-embedded profiles
-	// Users should know about hooks before seeing the diff
-	if p.PostApply != nil && !profile.IsEmbeddedProfile(name) {
-		fmt.Println()
-		ui.PrintWarning("Security Warning: This profile contains a post-apply hook.")
-		fmt.Println("  Hooks execute arbitrary commands on your system.")
-		fmt.Println("  Only proceed if you trust the source of this profile.")
-		if p.PostApply.Script != "" {
-			fmt.Printf("  Script: %s\n", p.PostApply.Script)
-		}
-		if p.PostApply.Command != "" {
-			fmt.Printf("  Command: %s\n", p.PostApply.Command)
-		}
-		fmt.Println()
-		if !confirmProceed() {
-			fmt.Println("Cancelled.")
-			return nil
-		}
+	// Security check FIRST: users should know what an untrusted profile
+	// runs before seeing the diff
+	trustPolicy, trusted, verified, err := checkApplyTrust(profilesDir, layers, p)
+	if err != nil {
+		return err
+	}
+	if !trusted && trustPolicy == profile.TrustPrompt {
+		fmt.Println("Cancelled.")
+		return nil
 	}
 
 	// Use the global claudeDir from root.go (set via --claude-dir flag)
 	claudeJSONPath := filepath.Join(claudeDir, ".claude.json")
 
+	// What is installed now, so the ledger can record what this apply adds
+	before, beforeErr := profile.LiveItems(claudeDir, claudeJSONPath, cwd)
+
 	// Compute and show diff (scope-aware to avoid confusing Remove actions for user-scope items)
 	diff, err := profile.ComputeDiffWithScope(p, claudeDir, claudeJSONPath, claudeupHome, profile.DiffOptions{
 		Scope:      scope,
@@ -968,15 +1039,29 @@ func applyProfileWithScope(name string, scope profile.Scope, explicitScope bool)
 	}
 
 	// Check if we need to run the hook (before early return)
-	scriptDir := profile.GetEmbeddedProfileScriptDir(name)
-	if scriptDir != "" {
+	// Hooks run in their profile's directory: the directory holding the
+	// profile file whose signature was just checked, or the extracted
+	// scripts of a built-in profile
+	var scriptDir string
+	if path := verified[hookLayer]; path != "" {
+		scriptDir = filepath.Dir(path)
+	} else if scriptDir = profile.GetEmbeddedProfileScriptDir(hookLayer); scriptDir != "" {
 		defer os.RemoveAll(scriptDir)
 	}
 
+	hookEnv, err := hookEnvPreference()
+	if err != nil {
+		return err
+	}
 	hookOpts := profile.HookOptions{
-		ForceSetup:    profileApplySetup,
-		NoInteractive: profileApplyNoInteractive,
-		ScriptDir:     scriptDir,
+		ForceSetup:       profileApplySetup,
+		NoInteractive:    profileApplyNoInteractive,
+		ScriptDir:        scriptDir,
+		ClaudeupHome:     claudeupHome,
+		TrustPolicy:      trustPolicy,
+		Trusted:          trusted,
+		AllowEnv:         hookEnv,
+		AllowUnsandboxed: profileApplyUnsandboxed,
 	}
 
 	shouldRunHook := profile.ShouldRunHook(p, claudeDir, claudeJSONPath, claudeupHome, hookOpts)
@@ -992,7 +1077,8 @@ func applyProfileWithScope(name string, scope profile.Scope, explicitScope bool)
 		}
 
 		// Record breadcrumb even when no changes needed -- user applied this profile
-		recordBreadcrumb(name, cwd, scopesForBreadcrumb(scope, p))
+		recordBreadcrumb(layers, cwd, scopesForBreadcrumb(scope, p))
+		recordLedger(layers, origins, cwd, before, before, beforeErr)
 
 		if p.SkipPluginDiff {
 			ui.PrintSuccess("No configuration changes needed.")
@@ -1001,7 +1087,7 @@ func applyProfileWithScope(name string, scope profile.Scope, explicitScope bool)
 			// If profile has a post-apply hook (wizard), show instructions
 			if p.PostApply != nil {
 				ui.PrintInfo("This profile uses an interactive wizard to configure plugins.")
-				fmt.Printf("  Run: %s\n", ui.Bold(fmt.Sprintf("claudeup profile apply %s --setup", name)))
+				fmt.Printf("  Run: %s\n", ui.Bold(fmt.Sprintf("claudeup profile apply %s --setup", applyArgs)))
 			} else {
 				ui.PrintInfo("Note: This profile does not manage plugins.")
 				fmt.Println("      Your existing plugins will remain unchanged.")
@@ -1073,6 +1159,10 @@ func applyProfileWithScope(name string, scope profile.Scope, explicitScope bool)
 	fmt.Println()
 
 	chain := buildSecretChain()
+	executor, err := newExecutor()
+	if err != nil {
+		return err
+	}
 
 	var result *profile.ApplyResult
 
@@ -1083,6 +1173,7 @@ func applyProfileWithScope(name string, scope profile.Scope, explicitScope bool)
 			ReplaceUserScope: profileApplyReplace, // --replace flag controls user scope behavior
 			Reinstall:        profileApplyReinstall,
 			ShowProgress:     !profileApplyNoProgress,
+			Executor:         executor,
 		}
 		result, err = profile.ApplyAllScopes(p, claudeDir, claudeJSONPath, cwd, claudeupHome, chain, applyOpts)
 		if err != nil {
@@ -1098,6 +1189,7 @@ func applyProfileWithScope(name string, scope profile.Scope, explicitScope bool)
 			ProjectDir:   cwd,
 			Reinstall:    profileApplyReinstall,
 			ShowProgress: !profileApplyNoProgress, // Enable concurrent apply with progress UI
+			Executor:     executor,
 		}
 		// Add progress callback for sequential installs (user scope)
 		if !profileApplyNoProgress {
@@ -1112,12 +1204,27 @@ func applyProfileWithScope(name string, scope profile.Scope, explicitScope bool)
 
 	showApplyResults(result)
 
+	// Take back managed CLAUDE.md blocks for fragments the profile dropped
+	memoryScope := string(scope)
+	if wasStack && !p.IsMultiScope() {
+		memoryScope = string(profile.ScopeUser)
+	}
+	dropped, err := profile.RemoveDroppedMemory(p, memoryScope, layers, claudeDir, cwd, claudeupHome)
+	if err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not remove dropped memory fragments: %v", err))
+	}
+	for _, name := range dropped {
+		fmt.Printf("  %s Removed memory fragment %s\n", ui.Success(ui.SymbolSuccess), name)
+	}
+
 	// Silently clean up stale plugin entries
 	cleanupStalePlugins(claudeDir)
 
 	fmt.Println()
 	ui.PrintSuccess("Profile applied!")
-	recordBreadcrumb(name, cwd, scopesForBreadcrumb(scope, p))
+	recordBreadcrumb(layers, cwd, scopesForBreadcrumb(scope, p))
+	after, afterErr := profile.LiveItems(claudeDir, claudeJSONPath, cwd)
+	recordLedger(layers, origins, cwd, before, after, errors.Join(beforeErr, afterErr))
 
 	// Scope-specific post-apply messages
 	if scope == profile.ScopeProject {
@@ -1166,14 +1273,71 @@ func sameResolvedDir(a, b string) bool {
 	return ra == rb
 }
 
-// recordBreadcrumb writes a breadcrumb entry recording which profile was applied.
+// recordBreadcrumb writes a breadcrumb entry recording which profile, or
+// which layers of an ad-hoc stack, was applied.
 // Errors are logged but do not fail the operation.
-func recordBreadcrumb(name, projectDir string, scopes []string) {
-	if err := breadcrumb.Record(claudeupHome, name, projectDir, scopes); err != nil {
+func recordBreadcrumb(layers []string, projectDir string, scopes []string) {
+	if err := breadcrumb.RecordLayers(claudeupHome, layers, projectDir, scopes); err != nil {
 		ui.PrintWarning(fmt.Sprintf("Could not save breadcrumb: %v", err))
 	}
 }
 
+// recordLedger records the items an apply introduced as owned by the profile
+// and forgets ledger entries that are no longer installed. For an ad-hoc
+// stack each item is owned by the layer it came from.
+// readErr is the error from listing the live items. When either list is
+// partial the ledger is left alone: a missing source would make its items
+// look uninstalled, or look newly introduced.
+// Errors are logged but do not fail the operation.
+func recordLedger(layers []string, origins *profile.LayerOrigins, projectDir string, before, after []ledger.Item, readErr error) {
+	if readErr != nil {
+		ui.PrintWarning(fmt.Sprintf("Ownership ledger not updated; could not read what is installed: %v", readErr))
+		return
+	}
+	book, err := ledger.Load(claudeupHome)
+	if err == nil {
+		book.Prune(after, projectDir)
+		owned := make(map[string][]ledger.Item, len(layers))
+		for _, item := range profile.IntroducedItems(before, after) {
+			owner := layers[0]
+			if origins != nil {
+				owner = layerForItem(origins, item, layers[0])
+			}
+			owned[owner] = append(owned[owner], item)
+		}
+		now := time.Now()
+		for _, layer := range layers {
+			book.Record(layer, owned[layer], now)
+		}
+		err = ledger.Save(claudeupHome, book)
+	}
+	if err != nil {
+		ui.PrintWarning(fmt.Sprintf("Could not update ownership ledger: %v", err))
+	}
+}
+
+// layerForItem returns the layer a ledger item came from, or fallback when
+// no layer declares it.
+func layerForItem(origins *profile.LayerOrigins, item ledger.Item, fallback string) string {
+	var layer string
+	switch item.Kind {
+	case ledger.KindPlugin:
+		layer = origins.Plugin(item.Name)
+	case ledger.KindMCP:
+		layer = origins.MCPServer(item.Name)
+	case ledger.KindMarketplace:
+		layer = origins.Marketplace(item.Name)
+	case ledger.KindHook:
+		layer = origins.Hook(item.Name)
+	case ledger.KindMemory:
+		layer = origins.Origin(profile.DiffMemory, item.Name)
+	}
+	if layer == "" {
+		return fallback
+	}
+	return layer
+}
+
 // scopesForBreadcrumb determines which scopes a profile apply touched.
 func scopesForBreadcrumb(scope profile.Scope, p *profile.Profile) []string {
 	if p.PerScope != nil {
@@ -1262,6 +1426,9 @@ func runProfileSave(cmd *cobra.Command, args []string) error {
 			if !ok {
 				return fmt.Errorf("no profile has been applied at %s scope. Run: claudeup profile save <name>", resolvedScope)
 			}
+			if layers := bc[resolvedScope].Layers; len(layers) > 1 {
+				return fmt.Errorf("%s scope has layers %s applied together. Run: claudeup profile save <name>", resolvedScope, strings.Join(layers, ", "))
+			}
 			name = profileName
 			bcScope = fmt.Sprintf("applied %s, %s scope", appliedAt.Format("Jan 2, 2006"), resolvedScope)
 		} else {
@@ -1269,8 +1436,11 @@ func runProfileSave(cmd *cobra.Command, args []string) error {
 			if profileName == "" {
 				return fmt.Errorf("no profile has been applied yet. Run: claudeup profile save <name>")
 			}
-			name = profileName
 			entry := bc[scope]
+			if len(entry.Layers) > 1 {
+				return fmt.Errorf("%s scope has layers %s applied together. Run: claudeup profile save <name>", scope, strings.Join(entry.Layers, ", "))
+			}
+			name = profileName
 			bcScope = fmt.Sprintf("applied %s, %s scope", entry.AppliedAt.Format("Jan 2, 2006"), scope)
 		}
 		fmt.Printf("Saving to %q (%s)\n\n", name, bcScope)
@@ -1331,6 +1501,12 @@ func runProfileSave(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Re-capture managed CLAUDE.md blocks so edits made in place round-trip
+	captured, err := profile.CaptureMemory(p, claudeDir, cwd, claudeupHome)
+	if err != nil {
+		return fmt.Errorf("failed to capture memory fragments: %w", err)
+	}
+
 	// Save
 	if err := profile.Save(profilesDir, p); err != nil {
 		return fmt.Errorf("failed to save profile: %w", err)
@@ -1341,6 +1517,9 @@ func runProfileSave(cmd *cobra.Command, args []string) error {
 		scopeLabel = scopeLabelFromProfile(p)
 	}
 	ui.PrintSuccess(fmt.Sprintf("Saved profile %q (%s)", name, scopeLabel))
+	for _, fragment := range captured {
+		ui.PrintInfo(fmt.Sprintf("Updated memory fragment %q from CLAUDE.md", fragment))
+	}
 	fmt.Println()
 
 	// Show per-scope plugin counts for multi-scope profiles
@@ -1362,15 +1541,33 @@ func runProfileSave(cmd *cobra.Command, args []string) error {
 			if n := countExtensions(s.settings.Extensions); n > 0 {
 				fmt.Println(ui.Indent(ui.RenderDetail(s.label+" extensions", fmt.Sprintf("%d", n)), 1))
 			}
+			if n := len(s.settings.Settings.Lines()); n > 0 {
+				fmt.Println(ui.Indent(ui.RenderDetail(s.label+" settings", fmt.Sprintf("%d", n)), 1))
+			}
+			if n := len(s.settings.Memory); n > 0 {
+				fmt.Println(ui.Indent(ui.RenderDetail(s.label+" memory", fmt.Sprintf("%d", n)), 1))
+			}
 		}
 	} else {
 		fmt.Println(ui.Indent(ui.RenderDetail("Plugins", fmt.Sprintf("%d", len(p.Plugins))), 1))
 	}
 	fmt.Println(ui.Indent(ui.RenderDetail("Marketplaces", fmt.Sprintf("%d", len(p.Marketplaces))), 1))
+	if n := countSettingsHooks(p.SettingsHooks); n > 0 {
+		fmt.Println(ui.Indent(ui.RenderDetail("Hooks", fmt.Sprintf("%d", n)), 1))
+	}
 
 	return nil
 }
 
+// countSettingsHooks returns the number of hooks across all event types.
+func countSettingsHooks(hooks map[string][]profile.HookEntry) int {
+	n := 0
+	for _, entries := range hooks {
+		n += len(entries)
+	}
+	return n
+}
+
 // scopeLabelFromProfile returns a human-readable label describing which
 // scopes a profile contains (e.g. "user scope", "all scopes").
 func scopeLabelFromProfile(p *profile.Profile) string {
@@ -1416,8 +1613,11 @@ func runProfileShow(cmd *cobra.Command, args []string) error {
 	if p.Description != "" {
 		fmt.Printf("Description: %s\n", p.Description)
 	}
+	showSignature(profilesDir, name)
 
 	// Stack profiles: show include tree and resolved summary
+	var origin itemOrigin
+	var report *profile.MergeReport
 	if p.IsStack() {
 		fmt.Printf("Type:     stack\n")
 		fmt.Println()
@@ -1425,33 +1625,48 @@ func runProfileShow(cmd *cobra.Command, args []string) error {
 
 		// Resolve and show the merged profile details
 		loader := &profile.DirLoader{ProfilesDir: profilesDir}
-		resolved, resolveErr := profile.ResolveIncludes(p, loader)
+		resolved, merged, resolveErr := profile.ResolveIncludesWithReport(p, loader)
 		if resolveErr != nil {
 			fmt.Printf("\n%s Failed to resolve includes: %v\n", ui.SymbolError, resolveErr)
 			return nil
 		}
 
+		showMergeRules(p.Merge)
 		showResolvedSummary(resolved)
+		showMergeConflicts(merged)
 		fmt.Println()
-		p = resolved
+		p, report = resolved, merged
+		if profileShowResolved {
+			origin = provenance(report)
+		}
 	}
 
 	fmt.Println()
 
 	if p.IsMultiScope() {
-		showMultiScopeProfile(p)
+		showMultiScopeProfile(p, origin)
 	} else {
-		showLegacyProfile(p)
+		showLegacyProfile(p, origin)
 	}
 
 	if len(p.Marketplaces) > 0 {
 		fmt.Println("  Marketplaces:")
 		for _, m := range p.Marketplaces {
-			fmt.Printf("    - %s\n", m.DisplayName())
+			suffix := ""
+			if origin != nil {
+				suffix = fromSuffix(report.MarketplaceOrigin(m))
+			}
+			fmt.Printf("    - %s%s\n", m.DisplayName(), suffix)
 		}
 		fmt.Println()
 	}
 
+	if len(p.SettingsHooks) > 0 {
+		fmt.Println("  Hooks:")
+		displaySettingsHooks(p.SettingsHooks, "    ")
+		fmt.Println()
+	}
+
 	return nil
 }
 
@@ -1461,7 +1676,7 @@ type scopeEntry struct {
 	settings *profile.ScopeSettings
 }
 
-func showMultiScopeProfile(p *profile.Profile) {
+func showMultiScopeProfile(p *profile.Profile, origin itemOrigin) {
 	scopes := []scopeEntry{
 		{"User", p.PerScope.User},
 		{"Project", p.PerScope.Project},
@@ -1475,19 +1690,29 @@ func showMultiScopeProfile(p *profile.Profile) {
 		var plugins []string
 		var mcpServers []profile.MCPServer
 		var ext *profile.ExtensionSettings
+		var settings *profile.SettingsBlock
 
 		if s.settings != nil {
 			plugins = s.settings.Plugins
 			mcpServers = s.settings.MCPServers
 			ext = s.settings.Extensions
+			settings = s.settings.Settings
 		}
 
 		// For user scope, fall back to top-level extensions if scope has none
+		scope := strings.ToLower(s.label)
+		extScope := scope
 		if s.label == "User" && ext == nil && unscopedExt != nil {
 			ext = unscopedExt
+			extScope = ""
 		}
 
-		if len(plugins) == 0 && len(mcpServers) == 0 && countExtensions(ext) == 0 {
+		var memory []string
+		if s.settings != nil {
+			memory = s.settings.Memory
+		}
+
+		if len(plugins) == 0 && len(mcpServers) == 0 && countExtensions(ext) == 0 && settings == nil && len(memory) == 0 {
 			continue
 		}
 
@@ -1498,58 +1723,151 @@ func showMultiScopeProfile(p *profile.Profile) {
 		if len(plugins) > 0 {
 			fmt.Printf("%sPlugins:\n", indent)
 			for _, plug := range plugins {
-				fmt.Printf("%s  - %s\n", indent, plug)
+				fmt.Printf("%s  - %s%s\n", indent, plug, origin.suffix(profile.MergeFieldPlugins, scope, plug))
 			}
 		}
 
-		displayMCPServers(mcpServers, indent)
-		displayExtensionCategories(ext, indent)
+		displayMCPServers(mcpServers, indent, origin.forField(profile.MergeFieldMCPServers, scope))
+		displayExtensionCategories(ext, indent, origin.forField(profile.MergeFieldExtensions, extScope))
+		displaySettingsBlock(settings, indent)
+		displayMemory(memory, indent, origin.forField(profile.MergeFieldMemory, scope))
 
 		fmt.Println()
 	}
 }
 
-func showLegacyProfile(p *profile.Profile) {
+func showLegacyProfile(p *profile.Profile, origin itemOrigin) {
 	indent := "  "
 
 	if len(p.Plugins) > 0 {
 		fmt.Printf("%sPlugins:\n", indent)
 		for _, plug := range p.Plugins {
-			fmt.Printf("%s  - %s\n", indent, plug)
+			fmt.Printf("%s  - %s%s\n", indent, plug, origin.suffix(profile.MergeFieldPlugins, "", plug))
 		}
 	}
 
-	displayMCPServers(p.MCPServers, indent)
+	displayMCPServers(p.MCPServers, indent, origin.forField(profile.MergeFieldMCPServers, ""))
+
+	displayExtensionCategories(p.Extensions, indent, origin.forField(profile.MergeFieldExtensions, ""))
+
+	displaySettingsBlock(p.Settings, indent)
+
+	displayMemory(p.Memory, indent, origin.forField(profile.MergeFieldMemory, ""))
+
+	fmt.Println()
+}
+
+// itemOrigin annotates an item of a resolved stack with the include it came
+// from. A nil itemOrigin annotates nothing.
+type itemOrigin func(field, scope, item string) string
+
+// provenance annotates items with their origin in a merge report.
+func provenance(report *profile.MergeReport) itemOrigin {
+	return func(field, scope, item string) string {
+		return fromSuffix(report.Origin(field, scope, item))
+	}
+}
+
+func (o itemOrigin) suffix(field, scope, item string) string {
+	if o == nil {
+		return ""
+	}
+	return o(field, scope, item)
+}
+
+// forField adapts an itemOrigin to the owner callbacks of the display helpers.
+func (o itemOrigin) forField(field, scope string) func(name string) string {
+	if o == nil {
+		return nil
+	}
+	return func(name string) string {
+		return o(field, scope, name)
+	}
+}
 
-	displayExtensionCategories(p.Extensions, indent)
+// fromSuffix renders " (from name)", or "" without a name.
+func fromSuffix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return " " + ui.Muted("(from "+name+")")
+}
+
+// showMergeRules lists the merge rules a stack declares.
+func showMergeRules(rules map[string]profile.MergeRule) {
+	if len(rules) == 0 {
+		return
+	}
+	fields := make([]string, 0, len(rules))
+	for field := range rules {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	fmt.Println()
+	fmt.Println("Merge rules:")
+	for _, field := range fields {
+		rule := rules[field]
+		line := fmt.Sprintf("  %s: %s", field, rule.Strategy)
+		if len(rule.Items) > 0 {
+			line += " " + strings.Join(rule.Items, ", ")
+		}
+		fmt.Println(line)
+	}
+}
 
+// showMergeConflicts lists the conflicts resolving a stack's includes settled.
+func showMergeConflicts(report *profile.MergeReport) {
+	if report == nil || len(report.Conflicts) == 0 {
+		return
+	}
 	fmt.Println()
+	fmt.Println("Conflicts:")
+	for _, c := range report.Conflicts {
+		fmt.Printf("  %s %s\n", ui.SymbolWarning, c)
+	}
+	fmt.Printf("  %s\n", ui.Muted(`Set "merge" rules in the stack to choose a strategy.`))
+}
+
+// warnMergeConflicts prints a warning per conflict settled while merging
+// includes or layers for apply.
+func warnMergeConflicts(report *profile.MergeReport) {
+	if report == nil {
+		return
+	}
+	for _, c := range report.Conflicts {
+		ui.PrintWarning("Merge conflict: " + c.String())
+	}
 }
 
 // extensionCategory maps a display label to a getter for that category's extensions.
 type extensionCategory struct {
-	label  string
-	getter func(*profile.ExtensionSettings) []string
+	label    string
+	category string
+	getter   func(*profile.ExtensionSettings) []string
 }
 
 // extensionCategories defines the display order and accessors for extension categories.
 var extensionCategories = []extensionCategory{
-	{"Agents", func(l *profile.ExtensionSettings) []string { return l.Agents }},
-	{"Commands", func(l *profile.ExtensionSettings) []string { return l.Commands }},
-	{"Skills", func(l *profile.ExtensionSettings) []string { return l.Skills }},
-	{"Hooks", func(l *profile.ExtensionSettings) []string { return l.Hooks }},
-	{"Rules", func(l *profile.ExtensionSettings) []string { return l.Rules }},
-	{"Output Styles", func(l *profile.ExtensionSettings) []string { return l.OutputStyles }},
+	{"Agents", ext.CategoryAgents, func(l *profile.ExtensionSettings) []string { return l.Agents }},
+	{"Commands", ext.CategoryCommands, func(l *profile.ExtensionSettings) []string { return l.Commands }},
+	{"Skills", ext.CategorySkills, func(l *profile.ExtensionSettings) []string { return l.Skills }},
+	{"Hooks", ext.CategoryHooks, func(l *profile.ExtensionSettings) []string { return l.Hooks }},
+	{"Rules", ext.CategoryRules, func(l *profile.ExtensionSettings) []string { return l.Rules }},
+	{"Output Styles", ext.CategoryOutputStyles, func(l *profile.ExtensionSettings) []string { return l.OutputStyles }},
 }
 
 // displayMCPServers prints MCP server list at the given indent level.
-func displayMCPServers(servers []profile.MCPServer, indent string) {
+func displayMCPServers(servers []profile.MCPServer, indent string, owner func(name string) string) {
 	if len(servers) == 0 {
 		return
 	}
 	fmt.Printf("%sMCP Servers:\n", indent)
 	for _, m := range servers {
-		fmt.Printf("%s  - %s (%s)\n", indent, m.Name, m.Command)
+		suffix := ""
+		if owner != nil {
+			suffix = owner(m.Name)
+		}
+		fmt.Printf("%s  - %s (%s)%s\n", indent, m.Name, m.Command, suffix)
 		secretKeys := make([]string, 0, len(m.Secrets))
 		for envVar := range m.Secrets {
 			secretKeys = append(secretKeys, envVar)
@@ -1562,7 +1880,8 @@ func displayMCPServers(servers []profile.MCPServer, indent string) {
 }
 
 // displayExtensionCategories prints extensions grouped by category at the given indent level.
-func displayExtensionCategories(ext *profile.ExtensionSettings, indent string) {
+// A non-nil owner annotates each "category/item".
+func displayExtensionCategories(ext *profile.ExtensionSettings, indent string, owner func(name string) string) {
 	if ext == nil {
 		return
 	}
@@ -1584,9 +1903,131 @@ func displayExtensionCategories(ext *profile.ExtensionSettings, indent string) {
 		}
 		fmt.Printf("%s  %s:\n", indent, c.label)
 		for _, item := range items {
-			fmt.Printf("%s    - %s\n", indent, item)
+			suffix := ""
+			if owner != nil {
+				suffix = owner(c.category + "/" + item)
+			}
+			fmt.Printf("%s    - %s%s%s\n", indent, item, formatOverride(ext.Override(c.category, item)), suffix)
+		}
+	}
+}
+
+// displaySettingsBlock prints a profile's settings.json options at the given indent level.
+func displaySettingsBlock(settings *profile.SettingsBlock, indent string) {
+	lines := settings.Lines()
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Printf("%sSettings:\n", indent)
+	for _, line := range lines {
+		fmt.Printf("%s  - %s\n", indent, line)
+	}
+}
+
+// displayMemory prints a profile's CLAUDE.md fragments at the given indent level.
+func displayMemory(fragments []string, indent string, owner func(name string) string) {
+	if len(fragments) == 0 {
+		return
+	}
+	fmt.Printf("%sMemory:\n", indent)
+	for _, name := range fragments {
+		suffix := ""
+		if owner != nil {
+			suffix = owner(name)
+		}
+		fmt.Printf("%s  - %s%s\n", indent, name, suffix)
+	}
+}
+
+// displaySettingsHooks prints hooks grouped by event type, with each hook's
+// matcher and timeout, at the given indent level.
+func displaySettingsHooks(hooks map[string][]profile.HookEntry, indent string) {
+	events := make([]string, 0, len(hooks))
+	for eventType := range hooks {
+		events = append(events, eventType)
+	}
+	sort.Strings(events)
+
+	for _, eventType := range events {
+		fmt.Printf("%s%s:\n", indent, eventType)
+		for _, h := range hooks[eventType] {
+			line := h.Command
+			if line == "" {
+				line = "prompt: " + h.Prompt
+			}
+			var notes []string
+			if h.Matcher != "" {
+				notes = append(notes, "matcher "+h.Matcher)
+			}
+			if h.Timeout > 0 {
+				notes = append(notes, fmt.Sprintf("timeout %ds", h.Timeout))
+			}
+			if len(notes) > 0 {
+				line += " " + ui.Muted("("+strings.Join(notes, ", ")+")")
+			}
+			fmt.Printf("%s  - %s\n", indent, line)
+		}
+	}
+}
+
+// displayProjectExtensionDrift lists copied project extensions that were
+// edited in the project or have changed in the extension library since
+// they were copied, as recorded in .claude/.claudeup-ext.json.
+func displayProjectExtensionDrift(projectDir, indent string) {
+	statuses, err := ext.ProjectStatus(filepath.Join(claudeupHome, "ext"), projectDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s Failed to check project extensions: %v\n",
+			ui.Warning("Warning:"), err)
+		return
+	}
+
+	var lines []string
+	for _, s := range statuses {
+		var notes []string
+		switch {
+		case s.Deleted:
+			notes = append(notes, "deleted from the project")
+		case s.LocallyModified:
+			notes = append(notes, "edited locally")
+		}
+		switch {
+		case s.LibraryMissing:
+			notes = append(notes, "no longer in the extension library")
+		case s.Outdated:
+			notes = append(notes, "out of date with the extension library")
+		}
+		if len(notes) > 0 {
+			lines = append(lines, fmt.Sprintf("%s    %s %s/%s %s", indent, ui.SymbolWarning, s.Category, s.Item,
+				ui.Muted("("+strings.Join(notes, ", ")+")")))
 		}
 	}
+	if len(lines) == 0 {
+		return
+	}
+
+	fmt.Printf("%sExtension drift:\n", indent)
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	fmt.Printf("%s  %s\n", indent, ui.Muted("Re-apply the profile to update unedited items; edited items are kept."))
+}
+
+// formatOverride renders a frontmatter override as " (set key=value, ...)".
+func formatOverride(patch ext.FrontmatterPatch) string {
+	if len(patch) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(patch))
+	for key := range patch {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value, _ := json.Marshal(patch[key])
+		parts = append(parts, key+"="+strings.Trim(string(value), `"`))
+	}
+	return " " + ui.Muted("(set "+strings.Join(parts, ", ")+")")
 }
 
 // countExtensions returns the total number of extensions across all categories.
@@ -1691,12 +2132,16 @@ func runProfileStatus(cmd *cobra.Command, args []string) error {
 	// Display the highest-precedence applied profile and its drift status
 	profilesDir := getProfilesDir()
 	applied := loadAppliedProfiles(profilesDir)
+	var origins *profile.LayerOrigins
 	if info := highestPrecedenceApplied(applied); info != nil {
+		if len(info.Layers) > 1 {
+			origins, _ = profile.NewLayerOrigins(info.Layers, &profile.DirLoader{ProfilesDir: profilesDir})
+		}
 		modifiedMarker := ""
 		if info.Modified {
 			modifiedMarker = " " + ui.Muted("(modified)")
 		}
-		fmt.Printf("  Last applied: %s%s\n", ui.Bold(info.Name), modifiedMarker)
+		fmt.Printf("  Last applied: %s%s\n", ui.Bold(info.DisplayName()), modifiedMarker)
 		fmt.Printf("                %s\n\n",
 			ui.Muted(fmt.Sprintf("applied %s, %s scope", info.AppliedAt.Format("Jan 2, 2006"), info.Scope)))
 	}
@@ -1705,6 +2150,13 @@ func runProfileStatus(cmd *cobra.Command, args []string) error {
 	var allPluginNames []string
 	claudeJSONPath := filepath.Join(claudeDir, ".claude.json")
 
+	// The ownership ledger attributes installed items to the profile that added them
+	book, err := ledger.Load(claudeupHome)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s Failed to load ownership ledger: %v\n",
+			ui.Warning("Warning:"), err)
+	}
+
 	// Determine whether cwd has a distinct project scope.
 	// When cwd/.claude is the same directory as claudeDir (e.g., running
 	// from ~), project/local settings overlap with user settings.
@@ -1770,7 +2222,7 @@ func runProfileStatus(cmd *cobra.Command, args []string) error {
 		if len(enabled) > 0 {
 			fmt.Println("    Plugins:")
 			for _, name := range enabled {
-				fmt.Printf("      - %s\n", name)
+				fmt.Printf("      - %s%s\n", name, ownerSuffix(book, origins, ledger.KindPlugin, name, scope, cwd))
 			}
 		}
 
@@ -1778,12 +2230,17 @@ func runProfileStatus(cmd *cobra.Command, args []string) error {
 		if len(disabled) > 0 {
 			fmt.Println("    Disabled:")
 			for _, name := range disabled {
-				fmt.Printf("      - %s\n", name)
+				fmt.Printf("      - %s%s\n", name, ownerSuffix(book, origins, ledger.KindPlugin, name, scope, cwd))
 			}
 		}
 
-		displayMCPServers(mcpServers, "    ")
-		displayExtensionCategories(extensions, "    ")
+		displayMCPServers(mcpServers, "    ", func(name string) string {
+			return ownerSuffix(book, origins, ledger.KindMCP, name, scope, cwd)
+		})
+		displayExtensionCategories(extensions, "    ", nil)
+		if scope == "project" {
+			displayProjectExtensionDrift(cwd, "    ")
+		}
 
 		fmt.Println()
 	}
@@ -1795,9 +2252,11 @@ func runProfileStatus(cmd *cobra.Command, args []string) error {
 	// Marketplaces section
 	marketplaces, err := profile.UsedMarketplaces(claudeDir, allPluginNames)
 	if err == nil && len(marketplaces) > 0 {
+		repoToName := profile.BuildRepoToNameLookup(claudeDir)
 		fmt.Println("  Marketplaces:")
 		for _, m := range marketplaces {
-			fmt.Printf("    - %s\n", m.DisplayName())
+			fmt.Printf("    - %s%s\n", m.DisplayName(),
+				ownerSuffix(book, origins, ledger.KindMarketplace, repoToName[m.Location()], "user", ""))
 		}
 		fmt.Println()
 	}
@@ -1819,7 +2278,10 @@ func hasDiffChanges(diff *profile.Diff) bool {
 		len(diff.MCPToRemove) > 0 ||
 		len(diff.MCPToInstall) > 0 ||
 		len(diff.MarketplacesToAdd) > 0 ||
-		len(diff.MarketplacesToRemove) > 0
+		len(diff.MarketplacesToRemove) > 0 ||
+		len(diff.SettingsToChange) > 0 ||
+		len(diff.HooksToAdd) > 0 ||
+		len(diff.MemoryToWrite) > 0
 }
 
 func showMultiScopeSummary(p *profile.Profile) {
@@ -1850,6 +2312,9 @@ func showMultiScopeSummary(p *profile.Profile) {
 				parts = append(parts, fmt.Sprintf("%d extensions", n))
 			}
 		}
+		if n := len(s.settings.Settings.Lines()); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d settings", n))
+		}
 		if len(parts) > 0 {
 			fmt.Printf("    %-15s %s\n", s.label+" scope:", strings.Join(parts, ", "))
 		}
@@ -1889,6 +2354,48 @@ func showDiff(diff *profile.Diff) {
 			fmt.Printf("    %s %s%s\n", ui.Success("+"), ui.Muted("MCP: ")+m.Name, secretInfo)
 		}
 	}
+
+	// Settings diff items compare the profile to live, so "removed" entries
+	// are ones apply will add and "added" entries are ones it will drop
+	if len(diff.SettingsToChange) > 0 {
+		fmt.Printf("  %s\n", ui.Info("Settings:"))
+		for _, item := range diff.SettingsToChange {
+			symbol := ui.Warning("~")
+			switch item.Op {
+			case profile.DiffRemoved:
+				symbol = ui.Success("+")
+			case profile.DiffAdded:
+				symbol = ui.Warning("-")
+			}
+			detail := ""
+			if item.Detail != "" {
+				detail = " " + ui.Muted("("+item.Detail+")")
+			}
+			fmt.Printf("    %s %s%s\n", symbol, item.Name, detail)
+		}
+	}
+
+	if len(diff.HooksToAdd) > 0 {
+		fmt.Printf("  %s\n", ui.Info("Hooks:"))
+		for _, item := range diff.HooksToAdd {
+			symbol := ui.Success("+")
+			if item.Op == profile.DiffModified {
+				symbol = ui.Warning("~")
+			}
+			fmt.Printf("    %s %s %s\n", symbol, item.Name, ui.Muted("("+item.Detail+")"))
+		}
+	}
+
+	if len(diff.MemoryToWrite) > 0 {
+		fmt.Printf("  %s\n", ui.Info("Memory:"))
+		for _, item := range diff.MemoryToWrite {
+			symbol := ui.Success("+")
+			if item.Op == profile.DiffModified {
+				symbol = ui.Warning("~")
+			}
+			fmt.Printf("    %s %s %s\n", symbol, item.Name, ui.Muted("("+item.Detail+")"))
+		}
+	}
 }
 
 func runProfileDiff(cmd *cobra.Command, args []string) error {
@@ -1900,6 +2407,7 @@ func runProfileDiff(cmd *cobra.Command, args []string) error {
 	}
 
 	var name string
+	var layers []string
 	var breadcrumbScope string
 
 	// Resolve scope flags early to detect conflicts with explicit name
@@ -1938,6 +2446,7 @@ func runProfileDiff(cmd *cobra.Command, args []string) error {
 				return fmt.Errorf("no profile has been applied at %s scope. Run: claudeup profile diff <name>", resolvedScope)
 			}
 			name = profileName
+			layers = bc[resolvedScope].Layers
 			breadcrumbScope = fmt.Sprintf("applied %s, %s scope", appliedAt.Format("Jan 2, 2006"), resolvedScope)
 		} else {
 			profileName, scope := breadcrumb.HighestPrecedence(bc)
@@ -1946,14 +2455,29 @@ func runProfileDiff(cmd *cobra.Command, args []string) error {
 			}
 			name = profileName
 			entry := bc[scope]
+			layers = entry.Layers
 			breadcrumbScope = fmt.Sprintf("applied %s, %s scope", entry.AppliedAt.Format("Jan 2, 2006"), scope)
 		}
 	}
 
 	profilesDir := getProfilesDir()
 
-	// Load saved profile (disk first, fallback to embedded)
-	saved, err := loadProfileWithFallback(profilesDir, name)
+	// Load saved profile (disk first, fallback to embedded); layers applied
+	// together are composed again and each item is attributed to its layer
+	var saved *profile.Profile
+	var origins *profile.LayerOrigins
+	if len(layers) > 1 {
+		loader := &profile.DirLoader{ProfilesDir: profilesDir}
+		saved, err = profile.ComposeLayers(layers, loader)
+		if err == nil {
+			origins, err = profile.NewLayerOrigins(layers, loader)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to compose layers %s: %w", strings.Join(layers, ", "), err)
+		}
+	} else {
+		saved, err = loadProfileWithFallback(profilesDir, name)
+	}
 	if err != nil {
 		var ambigErr *profile.AmbiguousProfileError
 		if errors.As(err, &ambigErr) {
@@ -1983,19 +2507,27 @@ func runProfileDiff(cmd *cobra.Command, args []string) error {
 	// Compute and display diff (skip description -- live snapshots auto-generate descriptions)
 	diff := profile.ComputeProfileDiff(savedNorm, liveNorm)
 	diff.DescriptionChange = nil
+	for _, sd := range profile.MemoryDrift(savedNorm, claudeDir, cwd, claudeupHome) {
+		diff.AddScopeItems(sd.Scope, sd.Items)
+	}
 	if diff.IsEmpty() {
 		fmt.Printf("Profile '%s' matches live state. No differences.\n", name)
 		return nil
 	}
 
-	showProfileDiff(diff)
+	showProfileDiff(diff, origins)
 	fmt.Println()
+	if len(layers) > 1 {
+		fmt.Printf("%s Run 'claudeup profile apply %s' to restore the layers.\n", ui.Info(ui.SymbolArrow), strings.Join(layers, " "))
+		return nil
+	}
 	fmt.Printf("%s Run 'claudeup profile save %s' to update the profile.\n", ui.Info(ui.SymbolArrow), name)
 	return nil
 }
 
-// showProfileDiff displays a formatted diff between a profile and live state
-func showProfileDiff(diff *profile.ProfileDiff) {
+// showProfileDiff displays a formatted diff between a profile and live state.
+// origins, when set, names the layer each plugin and MCP server came from.
+func showProfileDiff(diff *profile.ProfileDiff, origins *profile.LayerOrigins) {
 	fmt.Printf("Profile '%s' vs live configuration:\n", diff.ProfileName)
 
 	if diff.DescriptionChange != nil {
@@ -2025,6 +2557,12 @@ func showProfileDiff(diff *profile.ProfileDiff) {
 				detail = fmt.Sprintf(" (%s)", item.Detail)
 			}
 
+			if origins != nil {
+				if layer := origins.Origin(item.Kind, item.Name); layer != "" {
+					detail += " " + ui.Muted("(from "+layer+")")
+				}
+			}
+
 			fmt.Printf("    %s %s: %s%s\n", symbol, item.Kind, item.Name, detail)
 		}
 	}
@@ -2315,15 +2853,25 @@ func loadProfileWithFallback(profilesDir, name string) (*profile.Profile, error)
 // and whether live settings have drifted from the saved profile.
 type appliedProfileInfo struct {
 	Name      string
+	Layers    []string // profiles applied together as an ad-hoc stack
 	Scope     string
 	AppliedAt time.Time
 	Modified  bool
 }
 
+// DisplayName returns the profile name, or its layers joined with " + ".
+func (i appliedProfileInfo) DisplayName() string {
+	if len(i.Layers) > 1 {
+		return strings.Join(i.Layers, " + ")
+	}
+	return i.Name
+}
+
 // loadAppliedProfiles loads the breadcrumb file once, then checks each
 // breadcrumbed profile for drift against the live configuration.
-// Returns a map from profile name to its applied info.
-// At most 3 entries exist (one per scope: user, project, local).
+// Returns a map from profile name to its applied info. Each layer of an
+// ad-hoc stack maps to the stack's info.
+// At most 3 breadcrumb entries exist (one per scope: user, project, local).
 func loadAppliedProfiles(profilesDir string) map[string]appliedProfileInfo {
 	bc, err := breadcrumb.Load(claudeupHome)
 	if err != nil {
@@ -2362,7 +2910,7 @@ func loadAppliedProfiles(profilesDir string) map[string]appliedProfileInfo {
 			continue
 		}
 
-		saved, err := loadProfileWithFallback(profilesDir, entry.Profile)
+		saved, err := loadBreadcrumbProfile(profilesDir, entry)
 		if err != nil {
 			continue
 		}
@@ -2379,6 +2927,9 @@ func loadAppliedProfiles(profilesDir string) map[string]appliedProfileInfo {
 		}
 		savedForDiff := profile.FilterToScopes(savedPerScope, activeScopes)
 		diff := profile.ComputeProfileDiff(savedForDiff, live.AsPerScope())
+		for _, sd := range profile.MemoryDrift(savedForDiff, claudeDir, cwd, claudeupHome) {
+			diff.AddScopeItems(sd.Scope, sd.Items)
+		}
 		// Snapshot descriptions are auto-generated and always differ from
 		// saved profile descriptions; exclude them from drift detection.
 		diff.DescriptionChange = nil
@@ -2424,16 +2975,32 @@ func loadAppliedProfiles(profilesDir string) map[string]appliedProfileInfo {
 		}
 		diff.Scopes = filtered
 
-		result[entry.Profile] = appliedProfileInfo{
+		info := appliedProfileInfo{
 			Name:      entry.Profile,
+			Layers:    entry.Layers,
 			Scope:     scope,
 			AppliedAt: entry.AppliedAt,
 			Modified:  !diff.IsEmpty(),
 		}
+		result[entry.Profile] = info
+		for _, layer := range entry.Layers {
+			if _, exists := result[layer]; !exists {
+				result[layer] = info
+			}
+		}
 	}
 	return result
 }
 
+// loadBreadcrumbProfile loads the profile a breadcrumb entry names,
+// composing the layers of an ad-hoc stack.
+func loadBreadcrumbProfile(profilesDir string, entry breadcrumb.Entry) (*profile.Profile, error) {
+	if len(entry.Layers) > 1 {
+		return profile.ComposeLayers(entry.Layers, &profile.DirLoader{ProfilesDir: profilesDir})
+	}
+	return loadProfileWithFallback(profilesDir, entry.Profile)
+}
+
 // highestPrecedenceApplied returns the applied profile info for the
 // highest-precedence scope (local > project > user), or nil if none.
 func highestPrecedenceApplied(applied map[string]appliedProfileInfo) *appliedProfileInfo {
@@ -2893,6 +3460,134 @@ func runProfileReset(cmd *cobra.Command, args []string) error {
 	// Use the global claudeDir from root.go (set via --claude-dir flag)
 	claudeJSONPath := filepath.Join(claudeDir, ".claude.json")
 
+	// Profiles tracked by the ownership ledger remove exactly what they added
+	cwd, _ := os.Getwd()
+	owned, tracked := profile.OwnedItems(name, claudeupHome, cwd)
+	if tracked {
+		if len(owned) == 0 {
+			fmt.Println("Nothing to remove - this profile did not add any installed components.")
+			return nil
+		}
+		fmt.Println("  Will remove:")
+		for _, e := range owned {
+			fmt.Println(ownedItemLine(e))
+		}
+	} else {
+		hooksToRemove := profile.InstalledHooks(p, claudeDir)
+		if !previewMarketplaceReset(p, claudeJSONPath, hooksToRemove) {
+			return nil
+		}
+		for _, hook := range hooksToRemove {
+			fmt.Printf("    - Hook: %s\n", hook)
+		}
+	}
+	fmt.Println()
+
+	if !confirmProceed() {
+		fmt.Println("Cancelled.")
+		return nil
+	}
+
+	// Execute reset
+	fmt.Println()
+	fmt.Println("Removing profile components...")
+
+	executor, err := newExecutor()
+	if err != nil {
+		return err
+	}
+	result, err := profile.ResetWithExecutor(p, claudeDir, claudeJSONPath, cwd, claudeupHome, executor)
+	if err != nil {
+		return fmt.Errorf("failed to reset profile: %w", err)
+	}
+
+	showResetResult(result)
+
+	fmt.Println()
+	ui.PrintSuccess("Profile reset complete!")
+
+	return nil
+}
+
+// showResetResult prints what a reset removed and any errors it hit.
+func showResetResult(result *profile.ResetResult) {
+	if len(result.PluginsRemoved) > 0 {
+		fmt.Printf("  Removed %d plugins\n", len(result.PluginsRemoved))
+	}
+	if len(result.MCPServersRemoved) > 0 {
+		fmt.Printf("  Removed %d MCP servers\n", len(result.MCPServersRemoved))
+	}
+	if len(result.MarketplacesRemoved) > 0 {
+		fmt.Printf("  Removed %d marketplaces\n", len(result.MarketplacesRemoved))
+	}
+	if len(result.HooksRemoved) > 0 {
+		fmt.Printf("  Removed %d hooks\n", len(result.HooksRemoved))
+	}
+	if len(result.MemoryRemoved) > 0 {
+		fmt.Printf("  Removed %d memory fragments\n", len(result.MemoryRemoved))
+	}
+
+	if len(result.Errors) > 0 {
+		fmt.Println()
+		fmt.Println("Some errors occurred:")
+		for _, err := range result.Errors {
+			ui.PrintError(fmt.Sprintf("%v", err))
+		}
+	}
+}
+
+// ownerSuffix returns a muted "(added by <profile>)" for an item the ownership
+// ledger attributes to a profile. Items that were already installed but that
+// a layer of the applied ad-hoc stack declares get "(from <layer>)".
+// Returns "" otherwise.
+func ownerSuffix(book *ledger.File, origins *profile.LayerOrigins, kind, name, scope, projectDir string) string {
+	if name == "" {
+		return ""
+	}
+	if book != nil {
+		if owner := book.Owner(ledger.NewItem(kind, name, scope, projectDir)); owner != "" {
+			return " " + ui.Muted("(added by "+owner+")")
+		}
+	}
+	if origins != nil && kind != ledger.KindMarketplace {
+		if layer := layerForItem(origins, ledger.Item{Kind: kind, Name: name}, ""); layer != "" {
+			return " " + ui.Muted("(from "+layer+")")
+		}
+	}
+	return ""
+}
+
+// ledgerKindLabel names a ledger item kind for reset previews.
+func ledgerKindLabel(kind string) string {
+	switch kind {
+	case ledger.KindPlugin:
+		return "Plugin"
+	case ledger.KindMCP:
+		return "MCP"
+	case ledger.KindMarketplace:
+		return "Marketplace"
+	case ledger.KindHook:
+		return "Hook"
+	case ledger.KindMemory:
+		return "Memory"
+	}
+	return kind
+}
+
+// ownedItemLine formats a ledger entry for reset previews. Hooks always
+// live in user settings.json, so they leave out the scope.
+func ownedItemLine(e ledger.Entry) string {
+	if e.Kind == ledger.KindHook {
+		return fmt.Sprintf("    - %s: %s", ledgerKindLabel(e.Kind), e.Name)
+	}
+	return fmt.Sprintf("    - %s: %s %s", ledgerKindLabel(e.Kind), e.Name, ui.Muted("("+e.Scope+")"))
+}
+
+// previewMarketplaceReset prints what reset removes for a profile the
+// ownership ledger does not track: plugins from the profile's marketplaces,
+// its MCP servers, and its marketplaces. Returns false if there is nothing
+// to remove.
+func previewMarketplaceReset(p *profile.Profile, claudeJSONPath string, hooksToRemove []string) bool {
 	// Get current state to show what plugins will be removed
 	current, _ := profile.Snapshot("current", claudeDir, claudeJSONPath, claudeupHome)
 
@@ -2912,11 +3607,11 @@ func runProfileReset(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	hasChanges := len(pluginsToRemove) > 0 || len(p.MCPServers) > 0 || len(p.Marketplaces) > 0
+	hasChanges := len(pluginsToRemove) > 0 || len(p.MCPServers) > 0 || len(p.Marketplaces) > 0 || len(hooksToRemove) > 0
 
 	if !hasChanges {
 		fmt.Println("Nothing to remove - profile has no installed components.")
-		return nil
+		return false
 	}
 
 	fmt.Println("  Will remove:")
@@ -2934,45 +3629,7 @@ func runProfileReset(cmd *cobra.Command, args []string) error {
 		}
 		fmt.Printf("    - Marketplace: %s\n", displayName)
 	}
-	fmt.Println()
-
-	if !confirmProceed() {
-		fmt.Println("Cancelled.")
-		return nil
-	}
-
-	// Execute reset
-	fmt.Println()
-	fmt.Println("Removing profile components...")
-
-	result, err := profile.Reset(p, claudeDir, claudeJSONPath, claudeupHome)
-	if err != nil {
-		return fmt.Errorf("failed to reset profile: %w", err)
-	}
-
-	// Show results
-	if len(result.PluginsRemoved) > 0 {
-		fmt.Printf("  Removed %d plugins\n", len(result.PluginsRemoved))
-	}
-	if len(result.MCPServersRemoved) > 0 {
-		fmt.Printf("  Removed %d MCP servers\n", len(result.MCPServersRemoved))
-	}
-	if len(result.MarketplacesRemoved) > 0 {
-		fmt.Printf("  Removed %d marketplaces\n", len(result.MarketplacesRemoved))
-	}
-
-	if len(result.Errors) > 0 {
-		fmt.Println()
-		fmt.Println("Some errors occurred:")
-		for _, err := range result.Errors {
-			ui.PrintError(fmt.Sprintf("%v", err))
-		}
-	}
-
-	fmt.Println()
-	ui.PrintSuccess("Profile reset complete!")
-
-	return nil
+	return true
 }
 
 func runProfileDelete(cmd *cobra.Command, args []string) error {
@@ -3088,13 +3745,12 @@ func runProfileRename(cmd *cobra.Command, args []string) error {
 	}
 
 	// Check if target profile already exists at root (Save writes to root)
-	newPath := filepath.Join(profilesDir, newName+".json")
 	if profileExistsAtRoot(profilesDir, newName) {
 		if !config.YesFlag {
 			return fmt.Errorf("profile %q already exists. Use -y to overwrite", newName)
 		}
 		// Remove the existing target at the known root path (Save writes to root)
-		if err := os.Remove(newPath); err != nil {
+		if err := os.Remove(profile.RootProfilePath(profilesDir, newName)); err != nil {
 			return fmt.Errorf("failed to remove existing profile: %w", err)
 		}
 	}
@@ -3108,8 +3764,18 @@ func runProfileRename(cmd *cobra.Command, args []string) error {
 	// Update the name
 	p.Name = newName
 
-	// Save with new name (always writes to root profilesDir)
-	if err := profile.Save(profilesDir, p); err != nil {
+	// Save with new name at the root, keeping the file's format. The old
+	// contents are copied first so saving carries over their comments.
+	newPath := filepath.Join(profilesDir, newName+filepath.Ext(oldPath))
+	oldData, err := os.ReadFile(oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to read profile: %w", err)
+	}
+	if err := os.WriteFile(newPath, oldData, 0644); err != nil {
+		return fmt.Errorf("failed to save renamed profile: %w", err)
+	}
+	if err := profile.SaveToPath(newPath, p); err != nil {
+		os.Remove(newPath)
 		return fmt.Errorf("failed to save renamed profile: %w", err)
 	}
 
@@ -3125,6 +3791,11 @@ func runProfileRename(cmd *cobra.Command, args []string) error {
 		ui.PrintWarning(fmt.Sprintf("Breadcrumb update failed: %v. 'profile diff' may reference the old name until you apply again.", err))
 	}
 
+	// Move ownership ledger entries so reset still finds what the profile added
+	if err := ledger.Rename(claudeupHome, oldName, newName); err != nil {
+		ui.PrintWarning(fmt.Sprintf("Ownership ledger update failed: %v. 'profile reset' may fall back to matching by marketplace.", err))
+	}
+
 	ui.PrintSuccess(fmt.Sprintf("Renamed profile %q to %q", oldName, newName))
 
 	return nil
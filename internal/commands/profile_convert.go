@@ -0,0 +1,81 @@
+// ABOUTME: profile convert rewrites a saved profile as JSON, JSON with comments, or YAML
+// ABOUTME: Comments carry over between JSONC and YAML; the original file is replaced
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/config"
+	"github.com/claudeup/claudeup/v5/internal/profile"
+	"github.com/claudeup/claudeup/v5/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var profileConvertTo string
+
+var profileConvertCmd = &cobra.Command{
+	Use:   "convert <name>",
+	Short: "Convert a saved profile to JSON, JSONC, or YAML",
+	Long: `Rewrites a saved profile in another format, next to the original, and
+removes the original. Profiles can be:
+
+  json    strict JSON (.json)
+  jsonc   JSON with // and /* */ comments and trailing commas (.jsonc)
+  yaml    YAML with # comments (.yaml or .yml)
+
+Comments carry over between JSONC and YAML. Later "profile save" runs
+update the file in its format and keep the comments on entries still present.`,
+	Example: `  # Annotate a team profile with comments
+  claudeup profile convert team --to yaml
+
+  # Back to strict JSON (comments are dropped)
+  claudeup profile convert team --to json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runProfileConvert,
+}
+
+func init() {
+	profileCmd.AddCommand(profileConvertCmd)
+	profileConvertCmd.Flags().StringVar(&profileConvertTo, "to", "", "Target format: "+strings.Join(profile.Formats(), ", "))
+	_ = profileConvertCmd.MarkFlagRequired("to")
+}
+
+func runProfileConvert(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	profilesDir := getProfilesDir()
+
+	ext, err := profile.ExtensionForFormat(strings.ToLower(profileConvertTo))
+	if err != nil {
+		return err
+	}
+
+	src, err := resolveProfileArg(profilesDir, name)
+	if err != nil {
+		if profile.IsEmbeddedProfile(name) {
+			return fmt.Errorf("profile %q is a built-in profile. Save a copy first: claudeup profile clone my-%s --from %s", name, name, name)
+		}
+		return err
+	}
+
+	format := profile.FormatForPath(src)
+	if format == profile.FormatForPath(ext) {
+		ui.PrintInfo(fmt.Sprintf("Profile %q is already %s (%s)", name, format, filepath.Base(src)))
+		return nil
+	}
+
+	dst := profile.TrimProfileExt(src) + ext
+	if _, err := os.Stat(dst); err == nil && !config.YesFlag {
+		return fmt.Errorf("%s already exists. Use -y to overwrite", dst)
+	}
+
+	if err := profile.Convert(src, dst); err != nil {
+		return fmt.Errorf("failed to convert profile: %w", err)
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Converted profile %q to %s", name, strings.TrimPrefix(ext, ".")))
+	fmt.Println(ui.RenderDetail("File", dst))
+	return nil
+}
@@ -0,0 +1,194 @@
+// ABOUTME: profile remove-layer peels one profile off an ad-hoc stack applied with profile apply a b c
+// ABOUTME: Removes only what that layer added and keeps the remaining layers applied
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/breadcrumb"
+	"github.com/claudeup/claudeup/v5/internal/ledger"
+	"github.com/claudeup/claudeup/v5/internal/profile"
+	"github.com/claudeup/claudeup/v5/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	profileRemoveLayerScope   string
+	profileRemoveLayerUser    bool
+	profileRemoveLayerProject bool
+	profileRemoveLayerLocal   bool
+)
+
+var profileRemoveLayerCmd = &cobra.Command{
+	Use:   "remove-layer <name>",
+	Short: "Remove one profile from profiles applied together",
+	Long: `Removes one layer from profiles applied together with
+'claudeup profile apply base python-dev security'.
+
+Uninstalls the plugins, MCP servers, and marketplaces the layer added and
+the settings hooks and CLAUDE.md memory fragments it added. Items another
+remaining layer also declares are kept and handed over to that layer. Layers
+that apply settings or extensions of their own can't be peeled off this way;
+apply the remaining layers with --replace instead. The remaining layers stay applied and are
+recorded as the last-applied profiles.
+
+Without a scope flag, the layer is looked up at every scope active in the
+current directory.`,
+	Example: `  # Peel the security layer off
+  claudeup profile remove-layer security
+
+  # Only at project scope
+  claudeup profile remove-layer security --project`,
+	Args: cobra.ExactArgs(1),
+	RunE: runProfileRemoveLayer,
+}
+
+func init() {
+	profileCmd.AddCommand(profileRemoveLayerCmd)
+	profileRemoveLayerCmd.Flags().StringVar(&profileRemoveLayerScope, "scope", "", "Remove the layer at this scope: user, project, local")
+	profileRemoveLayerCmd.Flags().BoolVar(&profileRemoveLayerUser, "user", false, "Remove the layer at user scope")
+	profileRemoveLayerCmd.Flags().BoolVar(&profileRemoveLayerProject, "project", false, "Remove the layer at project scope")
+	profileRemoveLayerCmd.Flags().BoolVar(&profileRemoveLayerLocal, "local", false, "Remove the layer at local scope")
+}
+
+// unremovableKindLabels names the item kinds remove-layer refuses to leave
+// behind.
+var unremovableKindLabels = map[profile.DiffItemKind]string{
+	profile.DiffSetting:   "settings",
+	profile.DiffExtension: "extensions",
+}
+
+func runProfileRemoveLayer(cmd *cobra.Command, args []string) error {
+	layer := args[0]
+
+	resolvedScope, err := resolveScopeFlags(profileRemoveLayerScope, profileRemoveLayerUser, profileRemoveLayerProject, profileRemoveLayerLocal)
+	if err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	bc, err := breadcrumb.Load(claudeupHome)
+	if err != nil {
+		return fmt.Errorf("failed to read breadcrumb: %w", err)
+	}
+	bc = breadcrumb.FilterByDir(bc, cwd)
+
+	// Find the scopes whose layered entry includes the layer
+	var scopes []string
+	var entry breadcrumb.Entry
+	for _, scope := range []string{"local", "project", "user"} {
+		if resolvedScope != "" && scope != resolvedScope {
+			continue
+		}
+		e, ok := bc[scope]
+		if !ok || len(e.Layers) < 2 || !e.HasProfile(layer) {
+			continue
+		}
+		if len(scopes) > 0 && e.Profile != entry.Profile {
+			return fmt.Errorf("%q is a layer at more than one scope. Use --scope to pick one", layer)
+		}
+		scopes = append(scopes, scope)
+		entry = e
+	}
+	if len(scopes) == 0 {
+		return fmt.Errorf("%q is not a layer of the profiles applied here. Run: claudeup profile status", layer)
+	}
+
+	var remaining []string
+	for _, l := range entry.Layers {
+		if l != layer {
+			remaining = append(remaining, l)
+		}
+	}
+
+	profilesDir := getProfilesDir()
+	loader := &profile.DirLoader{ProfilesDir: profilesDir}
+	removed, err := loader.LoadProfile(layer)
+	if err == nil {
+		removed, err = profile.ResolveIncludes(removed, loader)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load layer %q: %w", layer, err)
+	}
+	origins, err := profile.NewLayerOrigins(remaining, loader)
+	if err != nil {
+		return fmt.Errorf("failed to compose remaining layers: %w", err)
+	}
+
+	// Reset can't take back settings or extensions, so refuse rather
+	// than leave part of the layer applied
+	if kinds := profile.UnremovableKinds(removed, origins); len(kinds) > 0 {
+		labels := make([]string, len(kinds))
+		for i, kind := range kinds {
+			labels[i] = unremovableKindLabels[kind]
+		}
+		return fmt.Errorf("layer %q also applies %s, which remove-layer can't undo. Apply the remaining layers in its place instead: claudeup profile apply %s --replace",
+			layer, strings.Join(labels, ", "), strings.Join(remaining, " "))
+	}
+
+	book, err := ledger.Load(claudeupHome)
+	if err != nil {
+		return fmt.Errorf("failed to read ownership ledger: %w", err)
+	}
+	if !book.Tracked(layer) {
+		return fmt.Errorf("no record of what %q added. Run: claudeup profile apply %s", layer, strings.Join(entry.Layers, " "))
+	}
+
+	// Items a remaining layer also declares change owner instead of being removed
+	for _, e := range book.Entries(layer, cwd) {
+		if owner := layerForItem(origins, e.Item, ""); owner != "" {
+			book.Transfer(e.Item, layer, owner)
+		}
+	}
+
+	owned := book.Entries(layer, cwd)
+
+	fmt.Println(ui.RenderDetail("Remove layer", ui.Bold(layer)))
+	fmt.Println(ui.RenderDetail("From", strings.Join(entry.Layers, " + ")+" "+ui.Muted("("+strings.Join(scopes, ", ")+" scope)")))
+	fmt.Println()
+	if len(owned) == 0 {
+		fmt.Println("  Nothing to remove - the remaining layers keep everything this layer added.")
+	} else {
+		fmt.Println("  Will remove:")
+		for _, e := range owned {
+			fmt.Println(ownedItemLine(e))
+		}
+	}
+	fmt.Println()
+
+	if !confirmProceed() {
+		ui.PrintMuted("Cancelled.")
+		return nil
+	}
+
+	// Save transfers first; reset reads the ledger to decide what to remove
+	if err := ledger.Save(claudeupHome, book); err != nil {
+		return fmt.Errorf("failed to update ownership ledger: %w", err)
+	}
+
+	if len(owned) > 0 {
+		executor, err := newExecutor()
+		if err != nil {
+			return err
+		}
+		claudeJSONPath := filepath.Join(claudeDir, ".claude.json")
+		result, err := profile.ResetWithExecutor(removed, claudeDir, claudeJSONPath, cwd, claudeupHome, executor)
+		if err != nil {
+			return fmt.Errorf("failed to remove layer: %w", err)
+		}
+		showResetResult(result)
+		fmt.Println()
+	}
+
+	for _, scope := range scopes {
+		recordBreadcrumb(remaining, cwd, []string{scope})
+	}
+	ui.PrintSuccess(fmt.Sprintf("Removed layer %q; %s still applied", layer, strings.Join(remaining, " + ")))
+	return nil
+}
@@ -0,0 +1,352 @@
+// ABOUTME: Profile signing and trust: keygen, sign, the trusted key store, and the unsigned-profile policy
+// ABOUTME: Also holds the trust check apply runs before profiles with hooks or MCP servers are applied
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/config"
+	"github.com/claudeup/claudeup/v5/internal/minisign"
+	"github.com/claudeup/claudeup/v5/internal/profile"
+	"github.com/claudeup/claudeup/v5/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	profileSignKey      string
+	profileTrustAddName string
+)
+
+var profileKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Create a key for signing profiles",
+	Long: `Creates an Ed25519 signing key in ~/.claudeup/keys: signing.key, readable
+only by you, and signing.pub, the public key to give to people who apply your
+profiles. The public key is in minisign format.`,
+	Args: cobra.NoArgs,
+	RunE: runProfileKeygen,
+}
+
+var profileSignCmd = &cobra.Command{
+	Use:   "sign <name>",
+	Short: "Sign a saved profile",
+	Long: `Writes a detached signature next to a saved profile ("team.json.minisig").
+Share the signature file with the profile. Anyone who trusts your public key
+can then apply the profile's hooks and MCP servers without a trust prompt.
+
+Signatures are minisign-compatible: 'minisign -V -p signing.pub -m team.json'
+verifies them, and profiles signed with 'minisign -S -l' verify in claudeup.
+Sign again after editing the profile.`,
+	Example: `  claudeup profile keygen
+  claudeup profile sign team`,
+	Args: cobra.ExactArgs(1),
+	RunE: runProfileSign,
+}
+
+var profileTrustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Manage the keys trusted to sign profiles",
+	Long: `Profiles with a post-apply hook, settings hooks, or MCP servers run commands
+on your machine. Such a profile is trusted when it is built in or signed by a
+key in your trust store (~/.claudeup/trusted-keys). The trust policy decides
+what happens to the others:
+
+  refuse   don't apply them
+  prompt   show what they run and ask first (default)
+  allow    apply them without asking`,
+}
+
+var profileTrustAddCmd = &cobra.Command{
+	Use:     "add <public-key-file>",
+	Short:   "Trust a public key to sign profiles",
+	Example: `  claudeup profile trust add ./alice.pub --name alice`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runProfileTrustAdd,
+}
+
+var profileTrustListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List trusted keys and the trust policy",
+	Args:  cobra.NoArgs,
+	RunE:  runProfileTrustList,
+}
+
+var profileTrustRemoveCmd = &cobra.Command{
+	Use:   "remove <name|key-id>",
+	Short: "Stop trusting a key",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProfileTrustRemove,
+}
+
+var profileTrustPolicyCmd = &cobra.Command{
+	Use:   "policy [refuse|prompt|allow]",
+	Short: "Show or set the policy for unsigned profiles that run commands",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runProfileTrustPolicy,
+}
+
+func init() {
+	profileCmd.AddCommand(profileKeygenCmd)
+	profileCmd.AddCommand(profileSignCmd)
+	profileCmd.AddCommand(profileTrustCmd)
+	profileTrustCmd.AddCommand(profileTrustAddCmd)
+	profileTrustCmd.AddCommand(profileTrustListCmd)
+	profileTrustCmd.AddCommand(profileTrustRemoveCmd)
+	profileTrustCmd.AddCommand(profileTrustPolicyCmd)
+
+	profileSignCmd.Flags().StringVar(&profileSignKey, "key", "", "Signing key (default ~/.claudeup/keys/signing.key)")
+	profileTrustAddCmd.Flags().StringVar(&profileTrustAddName, "name", "", "Name to show as the signer (default: the key file's name)")
+}
+
+// signingKeyPath is where profile keygen writes the signing key.
+func signingKeyPath() string {
+	return filepath.Join(claudeupHome, "keys", "signing.key")
+}
+
+func runProfileKeygen(cmd *cobra.Command, args []string) error {
+	keyPath := signingKeyPath()
+	pubPath := strings.TrimSuffix(keyPath, ".key") + ".pub"
+	if _, err := os.Stat(keyPath); err == nil && !config.YesFlag {
+		return fmt.Errorf("%s already exists. Use -y to replace it; profiles signed with it will no longer verify", keyPath)
+	}
+
+	key, err := minisign.GenerateKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		return fmt.Errorf("failed to create keys directory: %w", err)
+	}
+	if err := os.WriteFile(keyPath, key.Marshal(), 0600); err != nil {
+		return fmt.Errorf("failed to write signing key: %w", err)
+	}
+	public := key.Public()
+	if err := os.WriteFile(pubPath, public.Marshal(), 0644); err != nil {
+		return fmt.Errorf("failed to write public key: %w", err)
+	}
+
+	ui.PrintSuccess(fmt.Sprintf("Created signing key %s", public.IDString()))
+	fmt.Println(ui.RenderDetail("Secret key", keyPath))
+	fmt.Println(ui.RenderDetail("Public key", pubPath))
+	fmt.Println()
+	ui.PrintInfo(fmt.Sprintf("To trust your own profiles here, run: claudeup profile trust add %s --name me", pubPath))
+	return nil
+}
+
+func runProfileSign(cmd *cobra.Command, args []string) error {
+	profilesDir := getProfilesDir()
+	path, err := resolveProfileArg(profilesDir, args[0])
+	if err != nil {
+		return err
+	}
+
+	keyPath := profileSignKey
+	if keyPath == "" {
+		keyPath = signingKeyPath()
+	}
+	data, err := os.ReadFile(keyPath)
+	if errors.Is(err, fs.ErrNotExist) && profileSignKey == "" {
+		return fmt.Errorf("no signing key. Run: claudeup profile keygen")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read signing key: %w", err)
+	}
+	key, err := minisign.ParseSecretKey(data)
+	if err != nil {
+		return err
+	}
+
+	sigPath, err := profile.SignFile(path, key)
+	if err != nil {
+		return fmt.Errorf("failed to sign profile: %w", err)
+	}
+	ui.PrintSuccess(fmt.Sprintf("Signed profile %q with key %s", args[0], key.Public().IDString()))
+	fmt.Println(ui.RenderDetail("Signature", sigPath))
+	return nil
+}
+
+func runProfileTrustAdd(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read public key: %w", err)
+	}
+	key, err := minisign.ParsePublicKey(data)
+	if err != nil {
+		return err
+	}
+	name := profileTrustAddName
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+	}
+	if err := profile.NewTrustStore(claudeupHome).Add(name, key); err != nil {
+		return err
+	}
+	ui.PrintSuccess(fmt.Sprintf("Trusted key %s as %q", key.IDString(), name))
+	return nil
+}
+
+func runProfileTrustList(cmd *cobra.Command, args []string) error {
+	policy, err := trustPolicy()
+	if err != nil {
+		return err
+	}
+	keys, err := profile.NewTrustStore(claudeupHome).List()
+	if err != nil {
+		return fmt.Errorf("failed to read trusted keys: %w", err)
+	}
+
+	fmt.Println(ui.RenderDetail("Unsigned profiles", string(policy)))
+	fmt.Println()
+	if len(keys) == 0 {
+		ui.PrintMuted("No trusted keys. Add one with: claudeup profile trust add <public-key-file>")
+		return nil
+	}
+	for _, key := range keys {
+		fmt.Printf("  %-20s %s\n", key.Name, ui.Muted(key.IDString()))
+	}
+	return nil
+}
+
+func runProfileTrustRemove(cmd *cobra.Command, args []string) error {
+	key, err := profile.NewTrustStore(claudeupHome).Remove(args[0])
+	if err != nil {
+		return err
+	}
+	ui.PrintSuccess(fmt.Sprintf("No longer trusting %q (key %s)", key.Name, key.IDString()))
+	return nil
+}
+
+func runProfileTrustPolicy(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		policy, err := trustPolicy()
+		if err != nil {
+			return err
+		}
+		fmt.Println(policy)
+		return nil
+	}
+
+	policy, err := profile.ParseTrustPolicy(args[0])
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadFrom(claudeupHome)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	cfg.Preferences.UnsignedProfiles = string(policy)
+	if err := config.SaveTo(claudeupHome, cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	ui.PrintSuccess(fmt.Sprintf("Unsigned profiles that run commands: %s", policy))
+	return nil
+}
+
+// trustPolicy reads the unsigned-profile policy from the claudeup config.
+func trustPolicy() (profile.TrustPolicy, error) {
+	cfg, err := config.LoadFrom(claudeupHome)
+	if err != nil {
+		return "", fmt.Errorf("failed to read config: %w", err)
+	}
+	policy, err := profile.ParseTrustPolicy(cfg.Preferences.UnsignedProfiles)
+	if err != nil {
+		return "", fmt.Errorf("config.json preferences.unsignedProfiles: %w", err)
+	}
+	return policy, nil
+}
+
+// hookEnvPreference returns the environment variables the user lets
+// post-apply hooks see beyond the default allowlist.
+func hookEnvPreference() ([]string, error) {
+	cfg, err := config.LoadFrom(claudeupHome)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return cfg.Preferences.HookEnv, nil
+}
+
+// checkApplyTrust verifies the signatures of the profiles being applied and
+// their includes, and applies the trust policy to those that run commands
+// without a trusted signature. It returns the policy and whether the
+// profiles are trusted: built in, signed by trusted keys, or accepted at
+// the prompt. Under the prompt policy an untrusted result means the user
+// declined. It also returns, per profile checked, the file whose signature
+// was verified ("" for built-in profiles), so later steps use that file
+// rather than resolving the name again.
+func checkApplyTrust(profilesDir string, layers []string, p *profile.Profile) (profile.TrustPolicy, bool, map[string]string, error) {
+	policy, err := trustPolicy()
+	if err != nil {
+		return "", false, nil, err
+	}
+	results, err := profile.CheckTrust(profilesDir, layers, profile.NewTrustStore(claudeupHome))
+	if err != nil {
+		return "", false, nil, fmt.Errorf("failed to verify profile signatures: %w", err)
+	}
+	verified := make(map[string]string, len(results))
+	for _, r := range results {
+		verified[r.Name] = r.Path
+	}
+	untrusted := profile.UntrustedCommands(results)
+	if len(untrusted) == 0 {
+		return policy, true, verified, nil
+	}
+
+	switch policy {
+	case profile.TrustAllow:
+		return policy, false, verified, nil
+	case profile.TrustRefuse:
+		u := untrusted[0]
+		return policy, false, nil, fmt.Errorf("profile %q runs commands (%s) but is %s. The trust policy refuses it: sign it with a trusted key, or run: claudeup profile trust policy prompt",
+			u.Name, strings.Join(u.Commands, ", "), u.Status)
+	}
+
+	fmt.Println()
+	ui.PrintWarning("Security Warning: This profile runs commands and is not signed by a trusted key.")
+	for _, u := range untrusted {
+		fmt.Printf("  %s: %s (%s)\n", u.Name, strings.Join(u.Commands, ", "), u.Status)
+	}
+	fmt.Println("  Hooks and MCP servers execute commands on your system.")
+	fmt.Println("  Only proceed if you trust the source of this profile.")
+	if p.PostApply != nil {
+		if p.PostApply.Script != "" {
+			fmt.Printf("  Script: %s\n", p.PostApply.Script)
+		}
+		if p.PostApply.Command != "" {
+			fmt.Printf("  Command: %s\n", p.PostApply.Command)
+		}
+	}
+	fmt.Println()
+	return policy, confirmProceed(), verified, nil
+}
+
+// showSignature prints who signed a profile, for profiles that are signed
+// or that run commands and so are subject to the trust policy.
+func showSignature(profilesDir, name string) {
+	results, err := profile.CheckTrust(profilesDir, []string{name}, profile.NewTrustStore(claudeupHome))
+	if err != nil || len(results) == 0 {
+		return
+	}
+	status := results[0].Status
+	if errors.Is(status.Err, profile.ErrUnsigned) && len(results[0].Commands) == 0 {
+		return
+	}
+	switch {
+	case status.BuiltIn:
+		fmt.Println("Signed by: claudeup (built-in)")
+	case status.Trusted():
+		signed := ""
+		if !status.Signed.IsZero() {
+			signed = " on " + status.Signed.Format("Jan 2, 2006")
+		}
+		fmt.Printf("Signed by: %s (key %s)%s\n", status.Signer, status.KeyID, signed)
+	case errors.Is(status.Err, profile.ErrUnsigned):
+		fmt.Printf("Signature: %s\n", ui.Warning("unsigned; runs "+strings.Join(results[0].Commands, ", ")))
+	default:
+		fmt.Printf("Signature: %s\n", ui.Error(status.Err.Error()))
+	}
+}
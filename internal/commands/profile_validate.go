@@ -0,0 +1,169 @@
+// ABOUTME: profile validate checks a profile file or saved profile and reports problems by JSON pointer
+// ABOUTME: profile schema prints the profile JSON Schema used for editor autocompletion
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/profile"
+	"github.com/claudeup/claudeup/v5/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var profileValidateCmd = &cobra.Command{
+	Use:   "validate <file|name>",
+	Short: "Check a profile for mistakes",
+	Long: `Checks a profile file (JSON, JSONC, or YAML), or a saved profile by name,
+and lists each problem with a JSON pointer to where it is:
+
+  - Unknown keys, such as "mcpservers" for "mcpServers", and values of the wrong type
+  - A schemaVersion newer than this claudeup supports
+  - Plugins not in name@marketplace form
+  - Plugin marketplaces neither listed in the profile nor installed
+  - Invalid merge rules, missing includes, and include cycles
+
+Deprecated keys are reported as warnings. Exits non-zero when errors are found.`,
+	Example: `  # Validate a file before sharing it
+  claudeup profile validate ./team.json
+
+  # Validate a saved profile
+  claudeup profile validate my-setup`,
+	Args: cobra.ExactArgs(1),
+	// Problems found are listed above the error; usage would bury them
+	SilenceUsage: true,
+	RunE:         runProfileValidate,
+}
+
+var profileSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the profile JSON Schema",
+	Long: `Prints the JSON Schema for profile files. Editors use it for autocompletion
+and to flag mistakes as you type. Reference the published copy from a profile:
+
+  "$schema": "` + profile.SchemaURL + `"`,
+	Example: `  claudeup profile schema > profile.schema.json`,
+	Args:    cobra.NoArgs,
+	RunE:    runProfileSchema,
+}
+
+func init() {
+	profileCmd.AddCommand(profileValidateCmd)
+	profileCmd.AddCommand(profileSchemaCmd)
+}
+
+func runProfileValidate(cmd *cobra.Command, args []string) error {
+	profilesDir := getProfilesDir()
+	data, source, name, format, err := readProfileDocument(profilesDir, args[0])
+	if err != nil {
+		return err
+	}
+
+	registryKeys, err := registryKeysFromInstalled()
+	if err != nil {
+		return fmt.Errorf("cannot validate plugin marketplaces: %w", err)
+	}
+	issues := profile.ValidateDocument(data, profile.ValidateOptions{
+		Name:         name,
+		Format:       format,
+		RegistryKeys: registryKeys,
+		Loader:       &profile.DirLoader{ProfilesDir: profilesDir},
+	})
+
+	fmt.Println(ui.RenderDetail("Validating", source))
+	fmt.Println()
+
+	errorCount, warningCount := 0, 0
+	for _, issue := range issues {
+		symbol := ui.Error(ui.SymbolError)
+		if issue.Warning {
+			symbol = ui.Warning(ui.SymbolWarning)
+			warningCount++
+		} else {
+			errorCount++
+		}
+		fmt.Printf("  %s %s\n", symbol, issue)
+	}
+	if len(issues) > 0 {
+		fmt.Println()
+	}
+
+	if errorCount > 0 {
+		return fmt.Errorf("%s", countIssues(errorCount, warningCount))
+	}
+	if warningCount > 0 {
+		ui.PrintWarning(fmt.Sprintf("Valid with %s", countIssues(errorCount, warningCount)))
+		return nil
+	}
+	ui.PrintSuccess("Profile is valid")
+	return nil
+}
+
+// readProfileDocument reads a profile from a file path or, failing that,
+// by name from the profiles directory or the built-in profiles. It returns
+// the document, a description of where it came from, the name implied by
+// its file, and its format.
+func readProfileDocument(profilesDir, arg string) ([]byte, string, string, string, error) {
+	if info, err := os.Stat(arg); err == nil && !info.IsDir() {
+		data, err := os.ReadFile(arg)
+		if err != nil {
+			return nil, "", "", "", fmt.Errorf("failed to read %s: %w", arg, err)
+		}
+		format := profile.FormatForPath(arg)
+		if format == "" {
+			format = profile.DetectFormat(data)
+		}
+		return data, arg, profile.TrimProfileExt(filepath.Base(arg)), format, nil
+	}
+
+	paths, err := profile.FindProfilePaths(profilesDir, arg)
+	if err != nil {
+		return nil, "", "", "", err
+	}
+	switch len(paths) {
+	case 0:
+		data, err := profile.GetEmbeddedProfileData(arg)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, "", "", "", fmt.Errorf("no profile file or saved profile named %q", arg)
+		}
+		if err != nil {
+			return nil, "", "", "", err
+		}
+		return data, arg + " (built-in)", arg, profile.FormatJSON, nil
+	case 1:
+		data, err := os.ReadFile(paths[0])
+		if err != nil {
+			return nil, "", "", "", fmt.Errorf("failed to read %s: %w", paths[0], err)
+		}
+		return data, paths[0], arg, profile.FormatForPath(paths[0]), nil
+	default:
+		_, err := profile.Load(profilesDir, arg)
+		return nil, "", "", "", err
+	}
+}
+
+// countIssues renders "2 errors, 1 warning".
+func countIssues(errorCount, warningCount int) string {
+	var parts []string
+	if errorCount > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", errorCount, pluralize(errorCount, "error", "errors")))
+	}
+	if warningCount > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", warningCount, pluralize(warningCount, "warning", "warnings")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func runProfileSchema(cmd *cobra.Command, args []string) error {
+	data, err := json.MarshalIndent(profile.JSONSchema(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to render schema: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -4,6 +4,7 @@ package commands
 
 import (
 	"github.com/claudeup/claudeup/v5/internal/config"
+	"github.com/claudeup/claudeup/v5/internal/profile"
 	"github.com/claudeup/claudeup/v5/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -11,6 +12,7 @@ import (
 var (
 	claudeDir    string
 	claudeupHome string
+	engine       string
 )
 
 var rootCmd = &cobra.Command{
@@ -36,6 +38,11 @@ func SetVersion(version string) {
 func init() {
 	cobra.OnInitialize(initConfig)
 
+	// Print the update notice after commands; set here because it refers back to rootCmd
+	rootCmd.PersistentPostRun = func(cmd *cobra.Command, args []string) {
+		notifyUpdates(cmd)
+	}
+
 	// Set up custom help template with lipgloss styling
 	ui.SetupHelpTemplate(rootCmd)
 
@@ -43,6 +50,12 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&claudeDir, "claude-dir", config.MustClaudeDir(), "Claude installation directory")
 	rootCmd.PersistentFlags().StringVar(&claudeupHome, "claudeup-home", config.MustClaudeupHome(), "claudeup home directory")
 	rootCmd.PersistentFlags().BoolVarP(&config.YesFlag, "yes", "y", false, "Skip all prompts, use defaults")
+	rootCmd.PersistentFlags().StringVar(&engine, "engine", profile.EngineCLI, "How plugins and MCP servers are installed: cli (run the claude CLI) or native (edit config files directly)")
+}
+
+// newExecutor returns the command executor selected by --engine.
+func newExecutor() (profile.CommandExecutor, error) {
+	return profile.NewExecutor(engine, claudeDir)
 }
 
 func initConfig() {
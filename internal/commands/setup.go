@@ -91,6 +91,14 @@ func runSetup(cmd *cobra.Command, args []string) error {
 			}
 			return fmt.Errorf("failed to load profile %q: %w", setupProfile, err)
 		}
+		trustPolicy, trusted, _, err := checkApplyTrust(profilesDir, []string{setupProfile}, p)
+		if err != nil {
+			return err
+		}
+		if !trusted && trustPolicy == profile.TrustPrompt {
+			ui.PrintMuted("Setup cancelled.")
+			return nil
+		}
 		if err := applyProfileForFreshInstall(p, claudeJSONPath); err != nil {
 			return err
 		}
@@ -181,13 +189,20 @@ func applyProfileForFreshInstall(p *profile.Profile, claudeJSONPath string) erro
 	fmt.Println()
 	ui.PrintInfo("Applying profile...")
 
+	executor, err := newExecutor()
+	if err != nil {
+		return err
+	}
 	chain := buildSecretChain()
-	result, err := profile.Apply(p, claudeDir, claudeJSONPath, claudeupHome, chain)
+	before, beforeErr := profile.LiveItems(claudeDir, claudeJSONPath, "")
+	result, err := profile.ApplyWithExecutor(p, claudeDir, claudeJSONPath, claudeupHome, chain, executor)
 	if err != nil {
 		return fmt.Errorf("failed to apply profile: %w", err)
 	}
 
 	showApplyResults(result)
+	after, afterErr := profile.LiveItems(claudeDir, claudeJSONPath, "")
+	recordLedger([]string{p.Name}, nil, "", before, after, errors.Join(beforeErr, afterErr))
 
 	if len(result.Errors) > 0 {
 		ui.PrintWarning("Some operations had errors. Review the issues above.")
@@ -516,7 +531,10 @@ func installPluginsFromProfile(p *profile.Profile, claudeDir string) error {
 	// Use InstallPluginsWithProgress for additive-only behavior.
 	// Unlike profile.Apply() which is declarative (removes items not in profile),
 	// this only installs missing plugins without affecting existing configuration.
-	executor := &profile.DefaultExecutor{ClaudeDir: claudeDir}
+	executor, err := newExecutor()
+	if err != nil {
+		return err
+	}
 	result := profile.InstallPluginsWithProgress(p.Plugins, executor, profile.InstallPluginsOptions{
 		Scope: "", // user scope
 	})
@@ -1,57 +1,215 @@
 // ABOUTME: Update command for self-updating the claudeup CLI
-// ABOUTME: Downloads and installs the latest version from GitHub
+// ABOUTME: Installs the newest release on a channel or a pinned version, and rolls back the last update
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 
+	"github.com/claudeup/claudeup/v5/internal/config"
 	"github.com/claudeup/claudeup/v5/internal/selfupdate"
 	"github.com/claudeup/claudeup/v5/internal/ui"
 	"github.com/spf13/cobra"
 )
 
+var (
+	updateChannel  string
+	updateVersion  string
+	updateBaseURL  string
+	updateRollback bool
+	updateInsecure bool
+)
+
 var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Update the claudeup CLI to the latest version",
 	Long: `Update the claudeup CLI binary to the latest version from GitHub.
 
-This command checks GitHub releases for a newer version and, if found,
-downloads and installs it automatically.`,
+This command checks GitHub releases for a newer version on your release
+channel and, if found, downloads and installs it automatically. The
+download is checked against the release's checksums.txt, whose signature
+is verified with the release key built into claudeup.
+
+Builds without a release key (such as 'go install') can't verify the
+signature and refuse to update, or to use a mirror, unless
+--insecure-skip-verify is given.
+
+The binary being replaced is kept, so --rollback can restore it.
+
+--channel and --base-url are remembered for later updates.`,
 	Example: `  # Update claudeup to the latest version
-  claudeup update`,
+  claudeup update
+
+  # Follow pre-releases from now on
+  claudeup update --channel beta
+
+  # Install a specific version (including an older one)
+  claudeup update --version v5.2.0
+
+  # Undo the last update
+  claudeup update --rollback
+
+  # Update from an internal mirror of the release files
+  claudeup update --base-url https://mirror.example.com/claudeup`,
 	Args: cobra.NoArgs,
 	RunE: runUpdate,
 }
 
 func init() {
 	rootCmd.AddCommand(updateCmd)
+	updateCmd.Flags().StringVar(&updateChannel, "channel", "", "Release channel to follow: stable or beta (remembered)")
+	updateCmd.Flags().StringVar(&updateVersion, "version", "", "Install this version instead of the newest one")
+	updateCmd.Flags().StringVar(&updateBaseURL, "base-url", "", "Download releases from this mirror; empty for GitHub (remembered)")
+	updateCmd.Flags().BoolVar(&updateRollback, "rollback", false, "Restore the version the last update replaced")
+	updateCmd.Flags().BoolVar(&updateInsecure, "insecure-skip-verify", false, "Update without checking the release signature when this build has no release key")
+	updateCmd.MarkFlagsMutuallyExclusive("rollback", "version")
+	updateCmd.MarkFlagsMutuallyExclusive("rollback", "channel")
+	updateCmd.MarkFlagsMutuallyExclusive("rollback", "insecure-skip-verify")
+}
+
+// updateBackupDir is where update keeps the binary it replaced.
+func updateBackupDir() string {
+	return filepath.Join(claudeupHome, "update-backup")
 }
 
+// updateSettings returns the release source and channel from the
+// preferences in config.json.
+func updateSettings() (selfupdate.Source, string, error) {
+	cfg, err := config.LoadFrom(claudeupHome)
+	if err != nil {
+		return selfupdate.Source{}, "", fmt.Errorf("failed to load config: %w", err)
+	}
+	channel, err := selfupdate.ParseChannel(cfg.Preferences.UpdateChannel)
+	if err != nil {
+		return selfupdate.Source{}, "", err
+	}
+	return selfupdate.NewSource(cfg.Preferences.UpdateBaseURL), channel, nil
+}
+
+// saveUpdateFlags remembers --channel and --base-url when they were given.
+func saveUpdateFlags(cmd *cobra.Command) error {
+	channelSet, baseURLSet := cmd.Flags().Changed("channel"), cmd.Flags().Changed("base-url")
+	if !channelSet && !baseURLSet {
+		return nil
+	}
+	cfg, err := config.LoadFrom(claudeupHome)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if channelSet {
+		channel, err := selfupdate.ParseChannel(updateChannel)
+		if err != nil {
+			return err
+		}
+		cfg.Preferences.UpdateChannel = channel
+	}
+	if baseURLSet {
+		cfg.Preferences.UpdateBaseURL = updateBaseURL
+	}
+	if err := config.SaveTo(claudeupHome, cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	if channelSet {
+		ui.PrintInfo(fmt.Sprintf("Update channel: %s", cfg.Preferences.UpdateChannel))
+	}
+	if baseURLSet {
+		if updateBaseURL == "" {
+			ui.PrintInfo("Updating from GitHub releases")
+		} else {
+			ui.PrintInfo(fmt.Sprintf("Updating from mirror: %s", updateBaseURL))
+		}
+	}
+	return nil
+}
+
+// signedBuildHint tells users of a build without a release key, such as one
+// from 'go install', how to get one that can verify updates.
+const signedBuildHint = "Install a signed release build with 'curl -fsSL https://claudeup.github.io/install.sh | bash' " +
+	"or from https://github.com/claudeup/claudeup/releases, or pass --insecure-skip-verify to update without a signature check"
+
 func runUpdate(cmd *cobra.Command, args []string) error {
 	currentVersion := rootCmd.Version
 
-	ui.PrintInfo("Checking for updates...")
+	if updateRollback {
+		return runUpdateRollback(currentVersion)
+	}
+
+	key, err := selfupdate.ReleaseKey()
+	if err != nil {
+		return err
+	}
+	if key == nil && !updateInsecure && cmd.Flags().Changed("base-url") && updateBaseURL != "" {
+		return fmt.Errorf("%w; a mirror can only be used with a build that can check signatures. %s", selfupdate.ErrNoReleaseKey, signedBuildHint)
+	}
 
-	// Check latest version
-	latestVersion, err := selfupdate.CheckLatestVersion(selfupdate.DefaultAPIURL)
+	if err := saveUpdateFlags(cmd); err != nil {
+		return err
+	}
+	source, channel, err := updateSettings()
 	if err != nil {
-		return fmt.Errorf("failed to check for updates: %w", err)
+		return err
 	}
 
-	// Check if update needed
-	if !selfupdate.IsNewer(currentVersion, latestVersion) {
-		ui.PrintSuccess(fmt.Sprintf("Already up to date (%s)", currentVersion))
-		return nil
+	if key == nil {
+		if !updateInsecure && source != selfupdate.NewSource("") {
+			return fmt.Errorf("%w; the configured mirror %s needs a build that can check signatures (or reset it with --base-url \"\"). %s",
+				selfupdate.ErrNoReleaseKey, source.APIURL, signedBuildHint)
+		}
+		if updateInsecure {
+			ui.PrintWarning("This build has no release signing key; checksums.txt will not be signature-checked.")
+		}
 	}
 
-	ui.PrintInfo(fmt.Sprintf("Updating %s → %s...", currentVersion, latestVersion))
+	targetVersion := updateVersion
+	if targetVersion == "" {
+		ui.PrintInfo("Checking for updates...")
+
+		// Check latest version
+		targetVersion, err = source.LatestVersion(channel)
+		if err != nil {
+			return fmt.Errorf("failed to check for updates: %w", err)
+		}
+
+		// Check if update needed
+		if !selfupdate.IsNewer(currentVersion, targetVersion) {
+			ui.PrintSuccess(fmt.Sprintf("Already up to date (%s)", currentVersion))
+			return nil
+		}
+	}
+
+	ui.PrintInfo(fmt.Sprintf("Updating %s → %s...", currentVersion, targetVersion))
 
 	// Perform update
-	result := selfupdate.Update(currentVersion, latestVersion, "")
+	result := selfupdate.Update(currentVersion, targetVersion, selfupdate.Options{
+		Source:             source,
+		PublicKey:          key,
+		BackupDir:          updateBackupDir(),
+		Pinned:             updateVersion != "",
+		InsecureSkipVerify: updateInsecure,
+	})
+	if errors.Is(result.Error, selfupdate.ErrNoReleaseKey) {
+		return fmt.Errorf("update failed: %w. %s", result.Error, signedBuildHint)
+	}
 	if result.Error != nil {
 		return fmt.Errorf("update failed: %w", result.Error)
 	}
+	if result.AlreadyUpToDate {
+		ui.PrintSuccess(fmt.Sprintf("Already at %s", currentVersion))
+		return nil
+	}
 
 	ui.PrintSuccess(fmt.Sprintf("Updated claudeup %s → %s", result.OldVersion, result.NewVersion))
+	fmt.Printf("  %s\n", ui.Muted("Run 'claudeup update --rollback' to go back"))
+	return nil
+}
+
+func runUpdateRollback(currentVersion string) error {
+	backup, err := selfupdate.Rollback(updateBackupDir())
+	if err != nil {
+		return fmt.Errorf("rollback failed: %w", err)
+	}
+	ui.PrintSuccess(fmt.Sprintf("Rolled back claudeup %s → %s", currentVersion, backup.Version))
+	fmt.Println(ui.RenderDetail("Binary", backup.BinaryPath))
 	return nil
 }
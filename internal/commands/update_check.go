@@ -0,0 +1,123 @@
+// ABOUTME: Shared plumbing for outdated and upgrade checks: cancellation, progress, and caching
+// ABOUTME: Caches marketplace fetch results briefly so back-to-back commands fetch once
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/claudeup/claudeup/v5/internal/ui"
+	"golang.org/x/term"
+)
+
+const (
+	updateCheckCacheFile = "update-check-cache.json"
+	// updateCheckCacheTTL is how long a fetch result is trusted without re-fetching
+	updateCheckCacheTTL = 10 * time.Minute
+)
+
+// cachedMarketplaceCheck records what a marketplace fetch found. It is only
+// reused while the local HEAD and tracked ref are unchanged.
+type cachedMarketplaceCheck struct {
+	Head      string    `json:"head"`
+	Remote    string    `json:"remote"`
+	Ref       string    `json:"ref,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// updateCheckCache maps marketplace names to their last fetch result.
+type updateCheckCache map[string]cachedMarketplaceCheck
+
+// lookup returns the cached remote commit for a marketplace if the entry is
+// fresh and still describes the same checkout.
+func (c updateCheckCache) lookup(name, head, ref string, now time.Time) (string, bool) {
+	entry, ok := c[name]
+	if !ok || entry.Head != head || entry.Ref != ref || entry.Remote == "" {
+		return "", false
+	}
+	if age := now.Sub(entry.CheckedAt); age < 0 || age > updateCheckCacheTTL {
+		return "", false
+	}
+	return entry.Remote, true
+}
+
+// loadUpdateCheckCache reads the cache. A missing or unreadable cache is
+// treated as empty, since it only saves network round trips.
+func loadUpdateCheckCache(home string) updateCheckCache {
+	cache := make(updateCheckCache)
+	data, err := os.ReadFile(filepath.Join(home, updateCheckCacheFile))
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return make(updateCheckCache)
+	}
+	return cache
+}
+
+// saveUpdateCheckCache writes the cache, dropping expired entries. Failures
+// are ignored for the same reason loading failures are.
+func saveUpdateCheckCache(home string, cache updateCheckCache, now time.Time) {
+	for name, entry := range cache {
+		if now.Sub(entry.CheckedAt) > updateCheckCacheTTL {
+			delete(cache, name)
+		}
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(home, 0755); err != nil {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(home, updateCheckCacheFile), data, 0644)
+}
+
+// interruptContext returns a context cancelled on Ctrl-C or SIGTERM so
+// in-flight git commands are killed instead of leaving the terminal hanging.
+func interruptContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	return signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+}
+
+// checkProgress draws a progress bar while checks run. It only renders on a
+// terminal; piped output gets just the final results.
+type checkProgress struct {
+	tracker *ui.ProgressTracker
+	phase   string
+}
+
+func newCheckProgress(phase string, total int) *checkProgress {
+	if total == 0 || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return &checkProgress{}
+	}
+	tracker := ui.NewProgressTracker(ui.TrackerConfig{Phases: []string{phase}, Window: 3})
+	tracker.SetPhaseTotals(phase, total, total)
+	tracker.Render(os.Stdout)
+	return &checkProgress{tracker: tracker, phase: phase}
+}
+
+func (p *checkProgress) record(name string, err error) {
+	if p.tracker == nil {
+		return
+	}
+	result := ui.ItemResult{Name: name, Success: err == nil}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	p.tracker.RecordResult(p.phase, result)
+	p.tracker.RenderUpdate(os.Stdout, p.phase, result)
+}
+
+func (p *checkProgress) finish() {
+	if p.tracker != nil {
+		p.tracker.Finish(os.Stdout)
+	}
+}
@@ -0,0 +1,270 @@
+// ABOUTME: Background update check and the one-line notice printed after ordinary commands
+// ABOUTME: A detached update-check run caches what's new; later commands read the cache and never wait on the network
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+	"github.com/claudeup/claudeup/v5/internal/config"
+	"github.com/claudeup/claudeup/v5/internal/marketplace"
+	"github.com/claudeup/claudeup/v5/internal/selfupdate"
+	"github.com/claudeup/claudeup/v5/internal/ui"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	updateNoticeFile = "update-notice.json"
+	// defaultUpdateCheckInterval is used when preferences don't set one
+	defaultUpdateCheckInterval = 24 * time.Hour
+	// updateCheckTimeout bounds a background check so a hung fetch can't
+	// linger
+	updateCheckTimeout = 2 * time.Minute
+)
+
+// quietCommands never print the notice: they report updates themselves,
+// or their output is meant for other programs.
+var quietCommands = map[string]bool{
+	"update":     true,
+	"outdated":   true,
+	"upgrade":    true,
+	"help":       true,
+	"completion": true,
+	"__complete": true,
+}
+
+var updateCheckCmd = &cobra.Command{
+	Use:    "update-check",
+	Short:  "Check for updates in the background and cache the results",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE:   runUpdateCheck,
+}
+
+func init() {
+	rootCmd.AddCommand(updateCheckCmd)
+}
+
+// updateNotice is what the last background check found.
+type updateNotice struct {
+	CheckedAt     time.Time `json:"checkedAt"`
+	LatestVersion string    `json:"latestVersion,omitempty"`
+	Marketplaces  []string  `json:"marketplaces,omitempty"`
+	Plugins       []string  `json:"plugins,omitempty"`
+}
+
+// due reports whether the next check should start.
+func (n *updateNotice) due(now time.Time, interval time.Duration) bool {
+	age := now.Sub(n.CheckedAt)
+	return age < 0 || age >= interval
+}
+
+// message returns the notice line for currentVersion, or "" when there is
+// nothing new. The CLI version is compared again so the notice goes away as
+// soon as claudeup is updated.
+func (n *updateNotice) message(currentVersion string) string {
+	var parts []string
+	cliUpdate := n.LatestVersion != "" && selfupdate.IsNewer(currentVersion, n.LatestVersion)
+	if cliUpdate {
+		parts = append(parts, fmt.Sprintf("claudeup %s → %s", currentVersion, n.LatestVersion))
+	}
+	if count := len(n.Marketplaces); count > 0 {
+		parts = append(parts, plural(count, "marketplace", "marketplaces"))
+	}
+	if count := len(n.Plugins); count > 0 {
+		parts = append(parts, plural(count, "plugin", "plugins"))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	run := "claudeup outdated"
+	if cliUpdate && len(parts) == 1 {
+		run = "claudeup update"
+	}
+	return fmt.Sprintf("Updates available: %s. Run '%s'.", strings.Join(parts, ", "), run)
+}
+
+func plural(count int, one, many string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", one)
+	}
+	return fmt.Sprintf("%d %s", count, many)
+}
+
+// loadUpdateNotice reads the cached check. A missing or unreadable file is
+// treated as a check that never ran.
+func loadUpdateNotice(home string) *updateNotice {
+	notice := &updateNotice{}
+	data, err := os.ReadFile(filepath.Join(home, updateNoticeFile))
+	if err != nil {
+		return notice
+	}
+	if err := json.Unmarshal(data, notice); err != nil {
+		return &updateNotice{}
+	}
+	return notice
+}
+
+func saveUpdateNotice(home string, notice *updateNotice) error {
+	data, err := json.MarshalIndent(notice, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(home, 0755); err != nil {
+		return err
+	}
+	// The detached check and the command that started it may both write
+	// the notice, so write a temp file and rename it over the old one
+	// rather than let a reader see a half-written file.
+	path := filepath.Join(home, updateNoticeFile)
+	tmp, err := os.CreateTemp(home, "."+updateNoticeFile+".write-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// updateCheckInterval reads the interval preference, falling back to the
+// default when it is unset or invalid.
+func updateCheckInterval(prefs config.Preferences) time.Duration {
+	if interval, err := time.ParseDuration(prefs.UpdateCheckInterval); err == nil && interval > 0 {
+		return interval
+	}
+	return defaultUpdateCheckInterval
+}
+
+// updateNoticeAllowed reports whether cmd may print the notice: only on a
+// terminal, never for JSON output, for development builds, or for commands
+// that report updates themselves.
+func updateNoticeAllowed(cmd *cobra.Command, interactive bool, version string) bool {
+	if !interactive || version == "" || version == "dev" || version == "(devel)" {
+		return false
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Hidden || quietCommands[c.Name()] {
+			return false
+		}
+	}
+	for _, name := range []string{"format", "output"} {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Value.String() == "json" {
+			return false
+		}
+	}
+	if flag := cmd.Flags().Lookup("json"); flag != nil && flag.Value.String() == "true" {
+		return false
+	}
+	return true
+}
+
+// notifyUpdates runs after every successful command. It prints what the
+// last background check found and starts the next check when one is due.
+// It never touches the network itself, and every failure is ignored.
+func notifyUpdates(cmd *cobra.Command) {
+	// Running outdated or upgrade shows everything the notice would
+	if name := cmd.Name(); name == "outdated" || name == "upgrade" {
+		if notice := loadUpdateNotice(claudeupHome); len(notice.Marketplaces)+len(notice.Plugins) > 0 {
+			notice.Marketplaces, notice.Plugins = nil, nil
+			_ = saveUpdateNotice(claudeupHome, notice)
+		}
+	}
+
+	interactive := term.IsTerminal(int(os.Stdout.Fd())) && term.IsTerminal(int(os.Stderr.Fd()))
+	if !updateNoticeAllowed(cmd, interactive, rootCmd.Version) {
+		return
+	}
+	cfg, err := config.LoadFrom(claudeupHome)
+	if err != nil || cfg.Preferences.DisableUpdateNotifications {
+		return
+	}
+
+	notice := loadUpdateNotice(claudeupHome)
+	if msg := notice.message(rootCmd.Version); msg != "" {
+		fmt.Fprintf(os.Stderr, "\n%s %s\n", ui.Info(ui.SymbolInfo), ui.Muted(msg))
+	}
+
+	now := time.Now()
+	if !notice.due(now, updateCheckInterval(cfg.Preferences)) {
+		return
+	}
+	// Claim this interval before starting, so commands run while the check
+	// is in flight don't start another one
+	notice.CheckedAt = now
+	if err := saveUpdateNotice(claudeupHome, notice); err != nil {
+		return
+	}
+	startUpdateCheck()
+}
+
+// startUpdateCheck runs update-check as a detached process so the current
+// command exits without waiting for it, and a Ctrl-C or closed terminal
+// aimed at the command doesn't reach the check.
+func startUpdateCheck() {
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	check := exec.Command(exe, "update-check", "--claude-dir", claudeDir, "--claudeup-home", claudeupHome)
+	detachProcess(check)
+	if err := check.Start(); err != nil {
+		return
+	}
+	_ = check.Process.Release()
+}
+
+func runUpdateCheck(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(cmd.Context(), updateCheckTimeout)
+	defer cancel()
+
+	notice := &updateNotice{CheckedAt: time.Now()}
+	if source, channel, err := updateSettings(); err == nil {
+		if latest, err := source.LatestVersion(channel); err == nil {
+			notice.LatestVersion = latest
+		}
+	}
+
+	marketplaces, err := claude.LoadMarketplaces(claudeDir)
+	if err == nil && len(marketplaces) > 0 {
+		if pins, err := marketplace.LoadPins(claudeupHome); err == nil {
+			unpinned, _ := splitPinnedMarketplaces(marketplaces, pins)
+			cache := loadUpdateCheckCache(claudeupHome)
+			for _, update := range checkMarketplaceUpdates(ctx, unpinned, cache, nil) {
+				if update.HasUpdate {
+					notice.Marketplaces = append(notice.Marketplaces, update.Name)
+				}
+			}
+			saveUpdateCheckCache(claudeupHome, cache, time.Now())
+		}
+
+		if plugins, err := claude.LoadPlugins(claudeDir); err == nil {
+			scoped := plugins.GetPluginsForContext(availableScopes(true, ""), "")
+			seen := make(map[string]bool)
+			for _, update := range checkPluginUpdates(ctx, scoped, marketplaces) {
+				if update.HasUpdate && !seen[update.Name] {
+					seen[update.Name] = true
+					notice.Plugins = append(notice.Plugins, update.Name)
+				}
+			}
+		}
+	}
+
+	return saveUpdateNotice(claudeupHome, notice)
+}
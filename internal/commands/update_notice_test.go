@@ -0,0 +1,108 @@
+// ABOUTME: Tests for the background update notice: its wording, schedule, and when it is suppressed
+// ABOUTME: Covers non-terminal output, JSON formats, development builds, and commands that report updates
+package commands
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/claudeup/claudeup/v5/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func TestUpdateNoticeMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		notice updateNotice
+		want   string
+	}{
+		{"nothing new", updateNotice{LatestVersion: "v5.2.0"}, ""},
+		{"cli only", updateNotice{LatestVersion: "v5.3.0"}, "Updates available: claudeup v5.2.0 → v5.3.0. Run 'claudeup update'."},
+		{"plugins only", updateNotice{Plugins: []string{"lint@m"}}, "Updates available: 1 plugin. Run 'claudeup outdated'."},
+		{
+			"everything",
+			updateNotice{LatestVersion: "v5.3.0", Marketplaces: []string{"a", "b"}, Plugins: []string{"lint@a", "fmt@b"}},
+			"Updates available: claudeup v5.2.0 → v5.3.0, 2 marketplaces, 2 plugins. Run 'claudeup outdated'.",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.notice.message("v5.2.0"); got != tc.want {
+				t.Errorf("message() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUpdateNoticeDue(t *testing.T) {
+	now := time.Now()
+	if !(&updateNotice{}).due(now, time.Hour) {
+		t.Error("a check that never ran should be due")
+	}
+	if (&updateNotice{CheckedAt: now.Add(-30 * time.Minute)}).due(now, time.Hour) {
+		t.Error("a check inside the interval should not be due")
+	}
+	if !(&updateNotice{CheckedAt: now.Add(time.Hour)}).due(now, time.Hour) {
+		t.Error("a check stamped in the future should be due")
+	}
+
+	if got := updateCheckInterval(config.Preferences{UpdateCheckInterval: "168h"}); got != 168*time.Hour {
+		t.Errorf("updateCheckInterval() = %v", got)
+	}
+	if got := updateCheckInterval(config.Preferences{UpdateCheckInterval: "weekly"}); got != defaultUpdateCheckInterval {
+		t.Errorf("an invalid interval should fall back to the default, got %v", got)
+	}
+}
+
+func TestUpdateNoticeAllowed(t *testing.T) {
+	root := &cobra.Command{Use: "claudeup"}
+	status := &cobra.Command{Use: "status"}
+	search := &cobra.Command{Use: "search"}
+	search.Flags().String("format", "", "")
+	outdated := &cobra.Command{Use: "outdated"}
+	root.AddCommand(status, search, outdated)
+
+	if !updateNoticeAllowed(status, true, "v5.2.0") {
+		t.Error("ordinary commands on a terminal should show the notice")
+	}
+	if updateNoticeAllowed(status, false, "v5.2.0") {
+		t.Error("the notice should not print when output is not a terminal")
+	}
+	if updateNoticeAllowed(status, true, "dev") {
+		t.Error("development builds should not show the notice")
+	}
+	if updateNoticeAllowed(outdated, true, "v5.2.0") {
+		t.Error("outdated reports updates itself")
+	}
+	if err := search.Flags().Set("format", "json"); err != nil {
+		t.Fatal(err)
+	}
+	if updateNoticeAllowed(search, true, "v5.2.0") {
+		t.Error("JSON output should not get a notice")
+	}
+}
+
+func TestSaveUpdateNoticeReplacesFile(t *testing.T) {
+	home := t.TempDir()
+	first := &updateNotice{CheckedAt: time.Now().Add(-time.Hour).UTC()}
+	if err := saveUpdateNotice(home, first); err != nil {
+		t.Fatal(err)
+	}
+	second := &updateNotice{CheckedAt: time.Now().UTC(), LatestVersion: "v5.3.0", Plugins: []string{"a@m"}}
+	if err := saveUpdateNotice(home, second); err != nil {
+		t.Fatal(err)
+	}
+
+	got := loadUpdateNotice(home)
+	if !got.CheckedAt.Equal(second.CheckedAt) || got.LatestVersion != "v5.3.0" || len(got.Plugins) != 1 {
+		t.Errorf("loadUpdateNotice() = %+v, want %+v", got, second)
+	}
+	entries, err := os.ReadDir(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != updateNoticeFile {
+		t.Errorf("home should hold only %s, got %v", updateNoticeFile, entries)
+	}
+}
@@ -10,10 +10,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/claudeup/claudeup/v5/internal/claude"
+	"github.com/claudeup/claudeup/v5/internal/marketplace"
+	"github.com/claudeup/claudeup/v5/internal/profile"
 	"github.com/claudeup/claudeup/v5/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -39,16 +42,30 @@ var upgradeCmd = &cobra.Command{
   claudeup upgrade superpowers-marketplace
 
   # Upgrade a specific plugin
-  claudeup upgrade hookify@claude-plugins-official`,
+  claudeup upgrade hookify@claude-plugins-official
+
+  # Review incoming commits and choose what to upgrade
+  claudeup upgrade --interactive
+
+  # Restore the version a plugin had before its last upgrade
+  claudeup upgrade --rollback hookify@claude-plugins-official`,
 	Args: cobra.ArbitraryArgs,
 	RunE: runUpgrade,
 }
 
-var upgradeAll bool
+var (
+	upgradeAll         bool
+	upgradeInteractive bool
+	upgradeRefresh     bool
+	upgradeRollback    string
+)
 
 func init() {
 	rootCmd.AddCommand(upgradeCmd)
 	upgradeCmd.Flags().BoolVar(&upgradeAll, "all", false, "Upgrade plugins across all scopes, not just the current context")
+	upgradeCmd.Flags().BoolVarP(&upgradeInteractive, "interactive", "i", false, "Preview incoming changes and choose which marketplaces and plugins to upgrade")
+	upgradeCmd.Flags().BoolVar(&upgradeRefresh, "refresh", false, "Fetch every marketplace instead of reusing recent check results")
+	upgradeCmd.Flags().StringVar(&upgradeRollback, "rollback", "", "Restore a plugin to the version it had before its last upgrade")
 }
 
 func availableScopes(allFlag bool, projectDir string) []string {
@@ -68,8 +85,10 @@ type MarketplaceUpdate struct {
 	Name          string
 	HasUpdate     bool
 	CheckFailed   bool
-	CurrentCommit string
+	CurrentCommit string // full SHAs; shorten with truncateHash for display
 	LatestCommit  string
+	PinnedRef     string // Set for pinned marketplaces, which are never checked
+	Local         bool   // Directory marketplaces are used in place and never fetched
 }
 
 // PluginUpdate represents the update status of an installed plugin.
@@ -78,7 +97,7 @@ type PluginUpdate struct {
 	Name          string
 	Scope         string
 	HasUpdate     bool
-	CurrentCommit string
+	CurrentCommit string // full SHAs; shorten with truncateHash for display
 	LatestCommit  string
 }
 
@@ -131,6 +150,16 @@ func findUnmatchedTargets(targetMarketplaces, targetPlugins []string, marketplac
 }
 
 func runUpgrade(cmd *cobra.Command, args []string) error {
+	if upgradeRollback != "" {
+		if len(args) > 0 || upgradeInteractive {
+			return fmt.Errorf("--rollback cannot be combined with targets or --interactive")
+		}
+		return runUpgradeRollback(upgradeRollback)
+	}
+
+	ctx, stop := interruptContext(cmd.Context())
+	defer stop()
+
 	ui.PrintInfo("Checking for updates...")
 
 	// Parse target filters (if any)
@@ -149,11 +178,24 @@ func runUpgrade(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load plugins: %w", err)
 	}
 
+	pins, err := marketplace.LoadPins(claudeupHome)
+	if err != nil {
+		return fmt.Errorf("failed to load marketplace pins: %w", err)
+	}
+	unpinned, pinnedUpdates := splitPinnedMarketplaces(marketplaces, pins)
+
 	// Check and apply marketplace updates first, so plugin checks see current HEAD
 	fmt.Println()
 	fmt.Println(ui.RenderSection("Checking Marketplaces", len(marketplaces)))
+	for _, update := range pinnedUpdates {
+		fmt.Printf("  %s %s: %s\n", ui.Muted(ui.SymbolArrow), update.Name, ui.Muted("Pinned at "+update.PinnedRef))
+	}
 	var outdatedMarketplaces []string
-	marketplaceUpdates := checkMarketplaceUpdates(marketplaces, func(update MarketplaceUpdate) {
+	cache := make(updateCheckCache)
+	if !upgradeRefresh {
+		cache = loadUpdateCheckCache(claudeupHome)
+	}
+	marketplaceUpdates := checkMarketplaceUpdates(ctx, unpinned, cache, func(update MarketplaceUpdate) {
 		if update.HasUpdate {
 			// Filter by target if specified
 			if hasTargets {
@@ -177,12 +219,19 @@ func runUpgrade(cmd *cobra.Command, args []string) error {
 			}
 			fmt.Printf("  %s %s: %s\n", ui.Warning(ui.SymbolWarning), update.Name, ui.Warning("Update available"))
 			outdatedMarketplaces = append(outdatedMarketplaces, update.Name)
+		} else if update.Local {
+			fmt.Printf("  %s %s: %s\n", ui.Muted(ui.SymbolArrow), update.Name, ui.Muted("Local directory"))
 		} else if update.CheckFailed {
 			fmt.Printf("  %s %s: %s\n", ui.Warning(ui.SymbolWarning), update.Name, ui.Muted("Unable to check for updates"))
 		} else {
 			fmt.Printf("  %s %s: %s\n", ui.Success(ui.SymbolSuccess), update.Name, ui.Muted("Up to date"))
 		}
 	})
+	saveUpdateCheckCache(claudeupHome, cache, time.Now())
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("update check interrupted: %w", err)
+	}
+	marketplaceUpdates = append(marketplaceUpdates, pinnedUpdates...)
 
 	// Apply marketplace updates before checking plugins.
 	// Plugin update detection compares against the marketplace's local HEAD,
@@ -209,11 +258,16 @@ func runUpgrade(cmd *cobra.Command, args []string) error {
 			}
 		}
 	}
+	if upgradeInteractive && len(marketplacesToPull) > 0 {
+		fmt.Println()
+		fmt.Println(ui.RenderSection("Incoming Changes", len(marketplacesToPull)))
+		marketplacesToPull = selectMarketplaceUpdates(marketplacesToPull, marketplaceUpdates, marketplaces, confirmUpgradeStep)
+	}
 	if len(marketplacesToPull) > 0 {
 		fmt.Println()
 		fmt.Println(ui.RenderSection("Updating Marketplaces", len(marketplacesToPull)))
 		for _, name := range marketplacesToPull {
-			if err := updateMarketplace(name, marketplaces[name].InstallLocation); err != nil {
+			if err := updateMarketplace(name, marketplaces[name]); err != nil {
 				ui.PrintError(fmt.Sprintf("%s: %v", name, err))
 			} else {
 				ui.PrintSuccess(fmt.Sprintf("%s: Updated", name))
@@ -232,7 +286,10 @@ func runUpgrade(cmd *cobra.Command, args []string) error {
 	scopes := availableScopes(upgradeAll, projectDir)
 	scopedPlugins := plugins.GetPluginsForContext(scopes, projectDir)
 	fmt.Println(ui.RenderSection("Checking Plugins", len(scopedPlugins)))
-	pluginUpdates := checkPluginUpdates(scopedPlugins, marketplaces)
+	pluginUpdates := checkPluginUpdates(ctx, scopedPlugins, marketplaces)
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("update check interrupted: %w", err)
+	}
 
 	var outdatedUpdates []PluginUpdate
 	for _, update := range pluginUpdates {
@@ -286,6 +343,17 @@ func runUpgrade(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if upgradeInteractive && len(outdatedUpdates) > 0 {
+		fmt.Println()
+		fmt.Println(ui.RenderSection("Select Plugins", len(outdatedUpdates)))
+		outdatedUpdates = selectPluginUpdates(outdatedUpdates, confirmUpgradeStep)
+		if len(outdatedUpdates) == 0 && len(marketplacesToPull) == 0 {
+			fmt.Println()
+			ui.PrintInfo("No updates selected")
+			return nil
+		}
+	}
+
 	// Apply plugin updates
 	if len(outdatedUpdates) > 0 {
 		fmt.Println()
@@ -321,107 +389,150 @@ func runUpgrade(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func checkMarketplaceUpdates(marketplaces claude.MarketplaceRegistry, onResult func(MarketplaceUpdate)) []MarketplaceUpdate {
-	var updates []MarketplaceUpdate
+// splitPinnedMarketplaces separates pinned marketplaces from the registry.
+// Pinned ones are returned as MarketplaceUpdate entries sorted by name so they
+// can be reported and matched as targets without being fetched or pulled.
+func splitPinnedMarketplaces(marketplaces claude.MarketplaceRegistry, pins marketplace.Pins) (claude.MarketplaceRegistry, []MarketplaceUpdate) {
+	unpinned := make(claude.MarketplaceRegistry, len(marketplaces))
+	var pinned []MarketplaceUpdate
+	for name, meta := range marketplaces {
+		if pin, ok := pins[name]; ok {
+			pinned = append(pinned, MarketplaceUpdate{
+				Name:          name,
+				CurrentCommit: pin.Commit,
+				PinnedRef:     pin.Ref,
+			})
+			continue
+		}
+		unpinned[name] = meta
+	}
+	sort.Slice(pinned, func(i, j int) bool {
+		return pinned[i].Name < pinned[j].Name
+	})
+	return unpinned, pinned
+}
 
-	for name, marketplace := range marketplaces {
-		var update MarketplaceUpdate
+// checkMarketplaceUpdates fetches marketplaces concurrently in the worker pool
+// and reports results to onResult in name order once all checks finish. A
+// fresh cache entry for an unchanged checkout is used instead of fetching;
+// cache is updated in place with new fetch results. Cancelling ctx kills
+// in-flight fetches and reports the remaining marketplaces as failed checks.
+func checkMarketplaceUpdates(ctx context.Context, marketplaces claude.MarketplaceRegistry, cache updateCheckCache, onResult func(MarketplaceUpdate)) []MarketplaceUpdate {
+	names := make([]string, 0, len(marketplaces))
+	for name := range marketplaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+	updates := make([]MarketplaceUpdate, len(names))
+	entries := make([]*cachedMarketplaceCheck, len(names))
+	jobs := make([]profile.Job, len(names))
+	for i, name := range names {
+		meta := marketplaces[name]
+		jobs[i] = profile.Job{
+			Name: name,
+			Type: "marketplace",
+			Execute: func() error {
+				updates[i], entries[i] = checkMarketplace(ctx, name, meta, cache, now)
+				return nil
+			},
+		}
+	}
+
+	progress := newCheckProgress("Marketplaces", len(jobs))
+	profile.RunWorkerPoolContext(ctx, jobs, profile.DefaultWorkers, func(jr profile.JobResult) {
+		progress.record(jr.Name, jr.Error)
+	})
+	progress.finish()
 
-		// Fetch latest from remote
-		gitDir := filepath.Join(marketplace.InstallLocation, ".git")
-		if _, err := os.Stat(gitDir); errors.Is(err, fs.ErrNotExist) {
-			// Not a git repo, skip
-			update = MarketplaceUpdate{
-				Name:      name,
-				HasUpdate: false,
-			}
-			updates = append(updates, update)
-			if onResult != nil {
-				onResult(update)
-			}
-			continue
+	for i, name := range names {
+		// Jobs skipped after cancellation never filled in their slot
+		if updates[i].Name == "" {
+			updates[i] = MarketplaceUpdate{Name: name, CheckFailed: true}
+		}
+		if entries[i] != nil && cache != nil {
+			cache[name] = *entries[i]
 		}
+		if onResult != nil {
+			onResult(updates[i])
+		}
+	}
 
-		// Get current commit
-		currentCmd := exec.Command("git", "-C", marketplace.InstallLocation, "rev-parse", "HEAD")
-		currentOutput, err := currentCmd.Output()
-		if err != nil {
-			update = MarketplaceUpdate{
-				Name:      name,
-				HasUpdate: false,
-			}
-			updates = append(updates, update)
-			if onResult != nil {
-				onResult(update)
-			}
-			continue
+	return updates
+}
+
+// checkMarketplace compares one marketplace checkout with its remote. It
+// returns a cache entry when the remote commit was determined.
+func checkMarketplace(ctx context.Context, name string, meta claude.MarketplaceMetadata, cache updateCheckCache, now time.Time) (MarketplaceUpdate, *cachedMarketplaceCheck) {
+	noUpdate := MarketplaceUpdate{Name: name}
+	dir := meta.InstallLocation
+
+	// Directory marketplaces point at the user's own checkout; never fetch there
+	if meta.Source.IsDirectory() {
+		return MarketplaceUpdate{Name: name, Local: true}, nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); errors.Is(err, fs.ErrNotExist) {
+		return noUpdate, nil
+	}
+
+	currentOutput, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return MarketplaceUpdate{Name: name, CheckFailed: true}, nil
 		}
-		currentCommit := strings.TrimSpace(string(currentOutput))
+		return noUpdate, nil
+	}
+	currentCommit := strings.TrimSpace(string(currentOutput))
 
-		// Fetch from remote with timeout
-		ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
-		fetchCmd := exec.CommandContext(ctx, "git", "-C", marketplace.InstallLocation, "fetch", "origin")
-		fetchErr := fetchCmd.Run()
+	ref := meta.Source.Ref
+	remoteCommit, cached := cache.lookup(name, currentCommit, ref, now)
+	if !cached {
+		// Fetch from remote with a per-marketplace timeout
+		fetchCtx, cancel := context.WithTimeout(ctx, gitTimeout)
+		fetchErr := exec.CommandContext(fetchCtx, "git", "-C", dir, "fetch", "origin").Run()
 		cancel()
 		if fetchErr != nil {
-			update = MarketplaceUpdate{
-				Name:        name,
-				CheckFailed: true,
-			}
-			updates = append(updates, update)
-			if onResult != nil {
-				onResult(update)
-			}
-			continue
+			return MarketplaceUpdate{Name: name, CheckFailed: true}, nil
 		}
 
-		// Get remote commit
-		remoteCmd := exec.Command("git", "-C", marketplace.InstallLocation, "rev-parse", "origin/HEAD")
-		remoteOutput, err := remoteCmd.Output()
-		if err != nil {
-			// Try main branch
-			remoteCmd = exec.Command("git", "-C", marketplace.InstallLocation, "rev-parse", "origin/main")
-			remoteOutput, err = remoteCmd.Output()
-			if err != nil {
-				// Try master branch
-				remoteCmd = exec.Command("git", "-C", marketplace.InstallLocation, "rev-parse", "origin/master")
-				remoteOutput, err = remoteCmd.Output()
-				if err != nil {
-					update = MarketplaceUpdate{
-						Name:      name,
-						HasUpdate: false,
-					}
-					updates = append(updates, update)
-					if onResult != nil {
-						onResult(update)
-					}
-					continue
-				}
+		// Marketplaces added with a ref track that branch; tag and commit
+		// refs have no remote branch, so they never report updates.
+		remoteRefs := []string{"origin/HEAD", "origin/main", "origin/master"}
+		if ref != "" {
+			remoteRefs = []string{"origin/" + ref}
+		}
+		for _, candidate := range remoteRefs {
+			output, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "--verify", "--quiet", candidate+"^{commit}").Output()
+			if err == nil {
+				remoteCommit = strings.TrimSpace(string(output))
+				break
 			}
 		}
-		remoteCommit := strings.TrimSpace(string(remoteOutput))
-
-		// Only flag as update available when the remote has commits not in local.
-		// A simple != check falsely triggers when local is ahead of remote.
-		hasUpdate := false
-		if currentCommit != remoteCommit {
-			ancestorCmd := exec.Command("git", "-C", marketplace.InstallLocation, "merge-base", "--is-ancestor", remoteCommit, currentCommit)
-			hasUpdate = ancestorCmd.Run() != nil
+		if remoteCommit == "" {
+			return noUpdate, nil
 		}
+	}
 
-		update = MarketplaceUpdate{
-			Name:          name,
-			HasUpdate:     hasUpdate,
-			CurrentCommit: truncateHash(currentCommit),
-			LatestCommit:  truncateHash(remoteCommit),
-		}
-		updates = append(updates, update)
-		if onResult != nil {
-			onResult(update)
-		}
+	// Only flag as update available when the remote has commits not in local.
+	// A simple != check falsely triggers when local is ahead of remote.
+	hasUpdate := false
+	if currentCommit != remoteCommit {
+		ancestorCmd := exec.CommandContext(ctx, "git", "-C", dir, "merge-base", "--is-ancestor", remoteCommit, currentCommit)
+		hasUpdate = ancestorCmd.Run() != nil
 	}
 
-	return updates
+	update := MarketplaceUpdate{
+		Name:          name,
+		HasUpdate:     hasUpdate,
+		CurrentCommit: currentCommit,
+		LatestCommit:  remoteCommit,
+	}
+	entry := &cachedMarketplaceCheck{Head: currentCommit, Remote: remoteCommit, Ref: ref, CheckedAt: now}
+	if cached {
+		entry = nil // Keep the original timestamp so the entry still expires
+	}
+	return update, entry
 }
 
 // findMarketplacePath resolves the marketplace install location for a plugin.
@@ -443,43 +554,65 @@ func findMarketplacePath(pluginName string, installPath string, marketplaces cla
 	return ""
 }
 
-func checkPluginUpdates(scopedPlugins []claude.ScopedPlugin, marketplaces claude.MarketplaceRegistry) []PluginUpdate {
-	var updates []PluginUpdate
+func checkPluginUpdates(ctx context.Context, scopedPlugins []claude.ScopedPlugin, marketplaces claude.MarketplaceRegistry) []PluginUpdate {
+	// Resolve each plugin's marketplace, then read every distinct marketplace
+	// HEAD once, concurrently, instead of once per plugin.
+	pluginPaths := make([]string, len(scopedPlugins))
+	var paths []string
+	seen := make(map[string]bool)
+	for i, sp := range scopedPlugins {
+		if !sp.PathExists() {
+			continue
+		}
+		path := findMarketplacePath(sp.Name, sp.InstallPath, marketplaces)
+		pluginPaths[i] = path
+		if path != "" && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	heads := make([]string, len(paths))
+	jobs := make([]profile.Job, len(paths))
+	for i, path := range paths {
+		jobs[i] = profile.Job{
+			Name: path,
+			Type: "marketplace",
+			Execute: func() error {
+				if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+					return err
+				}
+				output, err := exec.CommandContext(ctx, "git", "-C", path, "rev-parse", "HEAD").Output()
+				if err != nil {
+					return err
+				}
+				heads[i] = strings.TrimSpace(string(output))
+				return nil
+			},
+		}
+	}
+	profile.RunWorkerPoolContext(ctx, jobs, profile.DefaultWorkers, nil)
+	headByPath := make(map[string]string, len(paths))
+	for i, path := range paths {
+		headByPath[path] = heads[i]
+	}
 
 	// Cache marketplace indexes to avoid re-reading for each plugin
 	indexCache := make(map[string]*claude.MarketplaceIndex)
 
-	for _, sp := range scopedPlugins {
+	var updates []PluginUpdate
+	for i, sp := range scopedPlugins {
 		name := sp.Name
 		plugin := sp.PluginMetadata
+		marketplacePath := pluginPaths[i]
 
-		// Skip if plugin path doesn't exist
-		if !plugin.PathExists() {
+		// Skip plugins whose path or marketplace checkout is missing
+		currentCommit := headByPath[marketplacePath]
+		if marketplacePath == "" || currentCommit == "" {
 			updates = append(updates, PluginUpdate{Name: name, Scope: plugin.Scope})
 			continue
 		}
 
-		marketplacePath := findMarketplacePath(name, plugin.InstallPath, marketplaces)
-		if marketplacePath == "" {
-			updates = append(updates, PluginUpdate{Name: name, Scope: plugin.Scope})
-			continue
-		}
-
-		// Get current commit from marketplace
-		gitDir := filepath.Join(marketplacePath, ".git")
-		if _, err := os.Stat(gitDir); errors.Is(err, fs.ErrNotExist) {
-			updates = append(updates, PluginUpdate{Name: name, Scope: plugin.Scope})
-			continue
-		}
-
-		currentCmd := exec.Command("git", "-C", marketplacePath, "rev-parse", "HEAD")
-		currentOutput, err := currentCmd.Output()
-		if err != nil {
-			updates = append(updates, PluginUpdate{Name: name, Scope: plugin.Scope})
-			continue
-		}
-		currentCommit := strings.TrimSpace(string(currentOutput))
-
 		// Compare with plugin's gitCommitSha
 		hasUpdate := plugin.GitCommitSha != currentCommit
 
@@ -495,8 +628,8 @@ func checkPluginUpdates(scopedPlugins []claude.ScopedPlugin, marketplaces claude
 			Name:          name,
 			Scope:         plugin.Scope,
 			HasUpdate:     hasUpdate,
-			CurrentCommit: truncateHash(plugin.GitCommitSha),
-			LatestCommit:  truncateHash(currentCommit),
+			CurrentCommit: plugin.GitCommitSha,
+			LatestCommit:  currentCommit,
 		})
 	}
 
@@ -532,7 +665,20 @@ func marketplaceIndexVersion(marketplacePath, qualifiedName string, cache map[st
 	return ""
 }
 
-func updateMarketplace(name, path string) error {
+func updateMarketplace(name string, meta claude.MarketplaceMetadata) error {
+	path := meta.InstallLocation
+	// Marketplaces added with a branch ref track origin/<ref>. Older clones
+	// sit on a detached HEAD, which git pull refuses, so move them onto the
+	// branch at the fetched remote commit instead.
+	if ref := meta.Source.Ref; ref != "" && marketplace.IsDetached(path) {
+		if err := marketplace.Fetch(path); err != nil {
+			return err
+		}
+		if marketplace.IsRemoteBranch(path, ref) {
+			return marketplace.CheckoutTrackingBranch(path, ref)
+		}
+	}
+
 	// Git pull to update
 	cmd := exec.Command("git", "-C", path, "pull", "--ff-only")
 	if err := cmd.Run(); err != nil {
@@ -560,8 +706,13 @@ func updatePlugin(name string, scope string, plugins *claude.PluginRegistry, mar
 	}
 	latestCommit := strings.TrimSpace(string(output))
 
-	// For cached plugins (isLocal: false), re-copy from marketplace to cache
+	// For cached plugins (isLocal: false), re-copy from marketplace to cache.
+	// The previous copy is kept so `upgrade --rollback` can restore it.
 	if !plugin.IsLocal {
+		if err := backupPlugin(claudeupHome, name, plugin); err != nil {
+			return err
+		}
+
 		pluginBaseName := strings.Split(name, "@")[0]
 
 		// Sanitize: prevent path traversal attacks
@@ -0,0 +1,157 @@
+// ABOUTME: Interactive upgrade support: incoming change previews and per-item selection
+// ABOUTME: Shows commit logs and changed plugin files before marketplaces are pulled
+package commands
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+	"github.com/claudeup/claudeup/v5/internal/ui"
+)
+
+// Preview output limits, so a large marketplace update stays readable.
+const (
+	previewMaxCommits = 20
+	previewMaxFiles   = 10
+)
+
+// otherChangesKey groups changed files that do not belong to a plugin directory.
+const otherChangesKey = ""
+
+// marketplaceCommitLog returns one-line summaries of commits in from..to, newest first.
+func marketplaceCommitLog(path, from, to string) ([]string, error) {
+	output, err := exec.Command("git", "-C", path, "log", "--oneline", "--no-decorate", from+".."+to).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log failed: %w", err)
+	}
+	return nonEmptyLines(string(output)), nil
+}
+
+// marketplaceChangedFiles returns the files that differ between from and to.
+func marketplaceChangedFiles(path, from, to string) ([]string, error) {
+	output, err := exec.Command("git", "-C", path, "diff", "--name-only", from, to).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff failed: %w", err)
+	}
+	return nonEmptyLines(string(output)), nil
+}
+
+func nonEmptyLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// groupChangedFiles groups marketplace paths by the plugin directory they live
+// in (plugins/<name>/... or skills/<name>/...). Everything else is grouped
+// under otherChangesKey.
+func groupChangedFiles(files []string) map[string][]string {
+	groups := make(map[string][]string)
+	for _, file := range files {
+		key := otherChangesKey
+		parts := strings.SplitN(file, "/", 3)
+		if len(parts) == 3 && (parts[0] == "plugins" || parts[0] == "skills") {
+			key = parts[1]
+		}
+		groups[key] = append(groups[key], file)
+	}
+	return groups
+}
+
+// printMarketplacePreview shows the commits and per-plugin file changes a
+// marketplace pull would bring in.
+func printMarketplacePreview(update MarketplaceUpdate, path string) {
+	fmt.Println()
+	fmt.Printf("%s %s %s %s\n", ui.Bold(update.Name), truncateHash(update.CurrentCommit), ui.SymbolArrow, ui.Success(truncateHash(update.LatestCommit)))
+
+	commits, err := marketplaceCommitLog(path, update.CurrentCommit, update.LatestCommit)
+	if err != nil {
+		fmt.Printf("  %s\n", ui.Muted("Unable to read commit log: "+err.Error()))
+	} else {
+		fmt.Printf("  %s\n", ui.Muted(fmt.Sprintf("Commits (%d):", len(commits))))
+		for i, commit := range commits {
+			if i == previewMaxCommits {
+				fmt.Printf("    %s\n", ui.Muted(fmt.Sprintf("... and %d more", len(commits)-previewMaxCommits)))
+				break
+			}
+			fmt.Printf("    %s\n", commit)
+		}
+	}
+
+	files, err := marketplaceChangedFiles(path, update.CurrentCommit, update.LatestCommit)
+	if err != nil {
+		fmt.Printf("  %s\n", ui.Muted("Unable to list changed files: "+err.Error()))
+		return
+	}
+	groups := groupChangedFiles(files)
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	// Plugins sorted by name, with non-plugin files last
+	sort.Slice(keys, func(i, j int) bool {
+		if (keys[i] == otherChangesKey) != (keys[j] == otherChangesKey) {
+			return keys[j] == otherChangesKey
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys {
+		label := key
+		if key == otherChangesKey {
+			label = "other files"
+		}
+		fmt.Printf("  %s\n", ui.Muted(fmt.Sprintf("%s (%d changed):", label, len(groups[key]))))
+		for i, file := range groups[key] {
+			if i == previewMaxFiles {
+				fmt.Printf("    %s\n", ui.Muted(fmt.Sprintf("... and %d more", len(groups[key])-previewMaxFiles)))
+				break
+			}
+			fmt.Printf("    %s\n", file)
+		}
+	}
+}
+
+// selectMarketplaceUpdates previews each marketplace and keeps those the user accepts.
+func selectMarketplaceUpdates(names []string, updates []MarketplaceUpdate, marketplaces claude.MarketplaceRegistry, confirm func(string) bool) []string {
+	byName := make(map[string]MarketplaceUpdate, len(updates))
+	for _, update := range updates {
+		byName[update.Name] = update
+	}
+
+	var selected []string
+	for _, name := range names {
+		printMarketplacePreview(byName[name], marketplaces[name].InstallLocation)
+		if confirm(fmt.Sprintf("Pull %s?", name)) {
+			selected = append(selected, name)
+		} else {
+			fmt.Printf("  %s %s: %s\n", ui.Muted(ui.SymbolArrow), name, ui.Muted("Skipped"))
+		}
+	}
+	return selected
+}
+
+// selectPluginUpdates asks about each outdated plugin and keeps those the user accepts.
+func selectPluginUpdates(updates []PluginUpdate, confirm func(string) bool) []PluginUpdate {
+	var selected []PluginUpdate
+	for _, update := range updates {
+		prompt := fmt.Sprintf("Upgrade %s (%s) %s %s %s?", update.Name, update.Scope, truncateHash(update.CurrentCommit), ui.SymbolArrow, truncateHash(update.LatestCommit))
+		if confirm(prompt) {
+			selected = append(selected, update)
+		}
+	}
+	return selected
+}
+
+// confirmUpgradeStep prompts for a single interactive choice. A failed read
+// (such as closed stdin) counts as a rejection.
+func confirmUpgradeStep(prompt string) bool {
+	confirmed, err := ui.ConfirmYesNo("  " + prompt)
+	return err == nil && confirmed
+}
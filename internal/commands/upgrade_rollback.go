@@ -0,0 +1,193 @@
+// ABOUTME: Keeps the previous copy of each upgraded plugin and restores it
+// ABOUTME: Implements `upgrade --rollback` with one backup per plugin, scope, and project
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+	"github.com/claudeup/claudeup/v5/internal/ui"
+)
+
+const (
+	upgradeBackupsDir    = "upgrade-backups"
+	pluginBackupManifest = "backup.json"
+	pluginBackupContent  = "plugin"
+)
+
+// pluginBackup records the registry entry a plugin had before its last upgrade.
+type pluginBackup struct {
+	Name       string                `json:"name"`
+	Plugin     claude.PluginMetadata `json:"plugin"`
+	BackedUpAt time.Time             `json:"backedUpAt"`
+}
+
+// pluginBackupDir returns where the backup for a plugin instance is kept.
+// Project and local installs are keyed by a hash of their project path, so
+// installs of the same plugin in different projects keep separate backups.
+func pluginBackupDir(home, name string, plugin claude.PluginMetadata) string {
+	safeName := strings.ReplaceAll(name, string(filepath.Separator), "_")
+	key := plugin.Scope
+	if plugin.Scope != claude.ScopeUser && plugin.ProjectPath != "" {
+		sum := sha256.Sum256([]byte(filepath.Clean(plugin.ProjectPath)))
+		key += "-" + hex.EncodeToString(sum[:])[:12]
+	}
+	return filepath.Join(home, upgradeBackupsDir, safeName, key)
+}
+
+// backupPlugin copies a plugin's install directory aside before it is
+// replaced, overwriting any backup from an earlier upgrade. A missing
+// install directory leaves nothing to keep and is not an error.
+func backupPlugin(home, name string, plugin claude.PluginMetadata) error {
+	if !plugin.PathExists() {
+		return nil
+	}
+	dir := pluginBackupDir(home, name, plugin)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear previous backup: %w", err)
+	}
+	if err := copyDir(plugin.InstallPath, filepath.Join(dir, pluginBackupContent)); err != nil {
+		return fmt.Errorf("failed to back up plugin: %w", err)
+	}
+
+	data, err := json.MarshalIndent(pluginBackup{Name: name, Plugin: plugin, BackedUpAt: time.Now().UTC()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode backup manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, pluginBackupManifest), data, 0644); err != nil {
+		return fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+	return nil
+}
+
+// loadPluginBackups returns the backups kept for a plugin, sorted by scope
+// precedence and then project path.
+func loadPluginBackups(home, name string) ([]pluginBackup, error) {
+	root := filepath.Dir(pluginBackupDir(home, name, claude.PluginMetadata{Scope: claude.ScopeUser}))
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backups: %w", err)
+	}
+
+	var backups []pluginBackup
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, entry.Name(), pluginBackupManifest))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup manifest: %w", err)
+		}
+		var backup pluginBackup
+		if err := json.Unmarshal(data, &backup); err != nil {
+			return nil, fmt.Errorf("invalid backup manifest for %s (%s): %w", name, entry.Name(), err)
+		}
+		backups = append(backups, backup)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		a, b := backups[i].Plugin, backups[j].Plugin
+		if a.Scope != b.Scope {
+			return claude.ScopePrecedence(a.Scope) < claude.ScopePrecedence(b.Scope)
+		}
+		return a.ProjectPath < b.ProjectPath
+	})
+	return backups, nil
+}
+
+// restorePluginBackup puts the backed-up directory back at its original
+// install path, restores the registry entry of the same scope and project,
+// and discards the backup.
+func restorePluginBackup(home string, backup pluginBackup, plugins *claude.PluginRegistry) error {
+	scope := backup.Plugin.Scope
+	current, ok := plugins.GetPluginInstance(backup.Name, scope, backup.Plugin.ProjectPath)
+	if !ok {
+		if scope != claude.ScopeUser && backup.Plugin.ProjectPath != "" {
+			return fmt.Errorf("%s is no longer installed at %s scope in %s", backup.Name, scope, backup.Plugin.ProjectPath)
+		}
+		return fmt.Errorf("%s is no longer installed at %s scope", backup.Name, scope)
+	}
+
+	dir := pluginBackupDir(home, backup.Name, backup.Plugin)
+	if !current.IsLocal && current.InstallPath != "" && current.InstallPath != backup.Plugin.InstallPath {
+		if err := os.RemoveAll(current.InstallPath); err != nil {
+			return fmt.Errorf("failed to remove upgraded plugin: %w", err)
+		}
+	}
+	if err := os.RemoveAll(backup.Plugin.InstallPath); err != nil {
+		return fmt.Errorf("failed to clear install path: %w", err)
+	}
+	if err := copyDir(filepath.Join(dir, pluginBackupContent), backup.Plugin.InstallPath); err != nil {
+		return fmt.Errorf("failed to restore plugin: %w", err)
+	}
+
+	plugins.SetPluginInstance(backup.Name, backup.Plugin)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove used backup: %w", err)
+	}
+	return nil
+}
+
+func runUpgradeRollback(name string) error {
+	if !strings.Contains(name, "@") {
+		return fmt.Errorf("invalid plugin %q: expected plugin@marketplace", name)
+	}
+	backups, err := loadPluginBackups(claudeupHome, name)
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		return fmt.Errorf("no upgrade backup for %s", name)
+	}
+
+	plugins, err := claude.LoadPlugins(claudeDir)
+	if err != nil {
+		return fmt.Errorf("failed to load plugins: %w", err)
+	}
+
+	fmt.Println(ui.RenderSection("Rolling Back", len(backups)))
+	var failed int
+	for _, backup := range backups {
+		displayName := fmt.Sprintf("%s (%s)", backup.Name, backup.Plugin.Scope)
+		if backup.Plugin.Scope != claude.ScopeUser && backup.Plugin.ProjectPath != "" {
+			displayName = fmt.Sprintf("%s (%s: %s)", backup.Name, backup.Plugin.Scope, backup.Plugin.ProjectPath)
+		}
+		if err := restorePluginBackup(claudeupHome, backup, plugins); err != nil {
+			failed++
+			ui.PrintError(fmt.Sprintf("%s: %v", displayName, err))
+			continue
+		}
+		restored := truncateHash(backup.Plugin.GitCommitSha)
+		if backup.Plugin.Version != "" {
+			restored = backup.Plugin.Version + " " + ui.Muted("("+restored+")")
+		}
+		ui.PrintSuccess(fmt.Sprintf("%s: Restored %s", displayName, restored))
+	}
+
+	if err := claude.SavePlugins(claudeDir, plugins); err != nil {
+		return fmt.Errorf("failed to save plugins: %w", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d rollback(s) failed", failed)
+	}
+
+	fmt.Println()
+	fmt.Println(ui.Muted("The next `claudeup upgrade` will offer this update again; pin the marketplace to hold it back."))
+	return nil
+}
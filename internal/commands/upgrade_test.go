@@ -1,16 +1,20 @@
 // ABOUTME: Unit tests for upgrade command internals
-// ABOUTME: Tests target parsing, plugin update logic, source resolution, and scope handling
+// ABOUTME: Tests target parsing, update checks and caching, plugin updates, and rollback
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/claudeup/claudeup/v5/internal/claude"
+	"github.com/claudeup/claudeup/v5/internal/marketplace"
+	"github.com/claudeup/claudeup/v5/internal/profile"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -425,6 +429,7 @@ var _ = Describe("updatePlugin", func() {
 		marketplaceDir string
 		cacheDir       string
 		origPath       string
+		origHome       string
 	)
 
 	BeforeEach(func() {
@@ -432,6 +437,9 @@ var _ = Describe("updatePlugin", func() {
 		tempDir, err = os.MkdirTemp("", "update-plugin-test-*")
 		Expect(err).NotTo(HaveOccurred())
 
+		origHome = claudeupHome
+		claudeupHome = filepath.Join(tempDir, "claudeup")
+
 		// Create a fake marketplace git repo
 		marketplaceDir = filepath.Join(tempDir, "marketplace")
 		Expect(os.MkdirAll(marketplaceDir, 0755)).To(Succeed())
@@ -466,9 +474,37 @@ var _ = Describe("updatePlugin", func() {
 
 	AfterEach(func() {
 		os.Setenv("PATH", origPath)
+		claudeupHome = origHome
 		os.RemoveAll(tempDir)
 	})
 
+	It("keeps the previous copy for rollback", func() {
+		Expect(os.WriteFile(filepath.Join(cacheDir, "plugin.json"), []byte(`{"version":"5.0.0"}`), 0644)).To(Succeed())
+		plugins := &claude.PluginRegistry{
+			Version: 2,
+			Plugins: make(map[string][]claude.PluginMetadata),
+		}
+		plugins.SetPlugin("superpowers@test-marketplace", claude.PluginMetadata{
+			Scope:        "user",
+			Version:      "5.0.0",
+			InstallPath:  cacheDir,
+			GitCommitSha: "oldsha123",
+		})
+		marketplaces := claude.MarketplaceRegistry{
+			"test-marketplace": claude.MarketplaceMetadata{InstallLocation: marketplaceDir},
+		}
+
+		Expect(updatePlugin("superpowers@test-marketplace", "user", plugins, marketplaces)).To(Succeed())
+
+		backups, err := loadPluginBackups(claudeupHome, "superpowers@test-marketplace")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backups).To(HaveLen(1))
+		Expect(backups[0].Plugin.Version).To(Equal("5.0.0"))
+		Expect(backups[0].Plugin.InstallPath).To(Equal(cacheDir))
+		saved := filepath.Join(pluginBackupDir(claudeupHome, "superpowers@test-marketplace", claude.PluginMetadata{Scope: "user"}), pluginBackupContent, "plugin.json")
+		Expect(saved).To(BeAnExistingFile())
+	})
+
 	It("updates version for URL-sourced plugins", func() {
 		// Get the marketplace HEAD commit
 		headCmd := exec.Command("git", "-C", marketplaceDir, "rev-parse", "HEAD")
@@ -613,7 +649,7 @@ var _ = Describe("checkPluginUpdates", func() {
 			},
 		}
 
-		updates := checkPluginUpdates(scopedPlugins, marketplaces)
+		updates := checkPluginUpdates(context.Background(), scopedPlugins, marketplaces)
 		Expect(updates).To(HaveLen(1))
 		Expect(updates[0].HasUpdate).To(BeTrue(), "should detect version mismatch even when SHA matches")
 	})
@@ -654,7 +690,7 @@ var _ = Describe("checkPluginUpdates", func() {
 			},
 		}
 
-		updates := checkPluginUpdates(scopedPlugins, marketplaces)
+		updates := checkPluginUpdates(context.Background(), scopedPlugins, marketplaces)
 		Expect(updates).To(HaveLen(1))
 		Expect(updates[0].HasUpdate).To(BeFalse(), "should not flag update when SHA and version both match")
 	})
@@ -761,3 +797,362 @@ var _ = Describe("availableScopes", func() {
 		})
 	})
 })
+
+var _ = Describe("splitPinnedMarketplaces", func() {
+	It("separates pinned marketplaces and reports their refs", func() {
+		registry := claude.MarketplaceRegistry{
+			"tracked": {InstallLocation: "/tmp/tracked"},
+			"held-b":  {InstallLocation: "/tmp/held-b"},
+			"held-a":  {InstallLocation: "/tmp/held-a"},
+		}
+		pins := marketplace.Pins{
+			"held-a": {Ref: "v1.0.0", Commit: "0123456789abcdef"},
+			"held-b": {Ref: "abc1234", Commit: "abc1234ffff"},
+		}
+
+		unpinned, pinned := splitPinnedMarketplaces(registry, pins)
+
+		Expect(unpinned).To(HaveLen(1))
+		Expect(unpinned).To(HaveKey("tracked"))
+		Expect(pinned).To(HaveLen(2))
+		Expect(pinned[0]).To(Equal(MarketplaceUpdate{Name: "held-a", CurrentCommit: "0123456789abcdef", PinnedRef: "v1.0.0"}))
+		Expect(pinned[1].Name).To(Equal("held-b"))
+	})
+
+	It("ignores pins for marketplaces that are no longer installed", func() {
+		registry := claude.MarketplaceRegistry{"tracked": {}}
+		unpinned, pinned := splitPinnedMarketplaces(registry, marketplace.Pins{"gone": {Ref: "v1"}})
+
+		Expect(unpinned).To(HaveKey("tracked"))
+		Expect(pinned).To(BeEmpty())
+	})
+})
+
+var _ = Describe("restorePluginBackup", func() {
+	var (
+		home     string
+		cacheDir string
+		plugins  *claude.PluginRegistry
+	)
+
+	BeforeEach(func() {
+		tempDir := GinkgoT().TempDir()
+		home = filepath.Join(tempDir, "claudeup")
+		cacheDir = filepath.Join(tempDir, "cache", "tool", "1.0.0")
+		Expect(os.MkdirAll(cacheDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(cacheDir, "plugin.json"), []byte("old"), 0644)).To(Succeed())
+
+		plugins = &claude.PluginRegistry{Version: 2, Plugins: make(map[string][]claude.PluginMetadata)}
+		old := claude.PluginMetadata{Scope: "user", Version: "1.0.0", InstallPath: cacheDir, GitCommitSha: "aaaaaaa"}
+		plugins.SetPlugin("tool@market", old)
+		Expect(backupPlugin(home, "tool@market", old)).To(Succeed())
+	})
+
+	It("restores the old directory and registry entry", func() {
+		newDir := filepath.Join(filepath.Dir(cacheDir), "2.0.0")
+		Expect(os.RemoveAll(cacheDir)).To(Succeed())
+		Expect(os.MkdirAll(newDir, 0755)).To(Succeed())
+		plugins.SetPlugin("tool@market", claude.PluginMetadata{Scope: "user", Version: "2.0.0", InstallPath: newDir, GitCommitSha: "bbbbbbb"})
+
+		backups, err := loadPluginBackups(home, "tool@market")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backups).To(HaveLen(1))
+		Expect(restorePluginBackup(home, backups[0], plugins)).To(Succeed())
+
+		restored, ok := plugins.GetPluginAtScope("tool@market", "user")
+		Expect(ok).To(BeTrue())
+		Expect(restored.Version).To(Equal("1.0.0"))
+		Expect(restored.GitCommitSha).To(Equal("aaaaaaa"))
+		Expect(os.ReadFile(filepath.Join(cacheDir, "plugin.json"))).To(Equal([]byte("old")))
+		Expect(newDir).NotTo(BeADirectory())
+
+		remaining, err := loadPluginBackups(home, "tool@market")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remaining).To(BeEmpty(), "a rollback consumes its backup")
+	})
+
+	It("refuses when the plugin is no longer installed at that scope", func() {
+		backups, err := loadPluginBackups(home, "tool@market")
+		Expect(err).NotTo(HaveOccurred())
+		plugins.RemovePluginAtScope("tool@market", "user")
+
+		err = restorePluginBackup(home, backups[0], plugins)
+		Expect(err).To(MatchError(ContainSubstring("no longer installed at user scope")))
+	})
+
+	It("keeps separate backups for project installs in different projects", func() {
+		projectA, projectB := GinkgoT().TempDir(), GinkgoT().TempDir()
+		dirs := map[string]string{}
+		for _, project := range []string{projectA, projectB} {
+			dir := filepath.Join(project, "cache", "tool", "1.0.0")
+			Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "plugin.json"), []byte(project), 0644)).To(Succeed())
+			old := claude.PluginMetadata{Scope: "project", ProjectPath: project, Version: "1.0.0", InstallPath: dir, GitCommitSha: "aaaaaaa"}
+			plugins.SetPluginInstance("tool@market", old)
+			Expect(backupPlugin(home, "tool@market", old)).To(Succeed())
+			dirs[project] = dir
+		}
+
+		backups, err := loadPluginBackups(home, "tool@market")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backups).To(HaveLen(3), "one user backup and one per project")
+
+		for _, project := range []string{projectA, projectB} {
+			Expect(os.WriteFile(filepath.Join(dirs[project], "plugin.json"), []byte("new"), 0644)).To(Succeed())
+			plugins.SetPluginInstance("tool@market", claude.PluginMetadata{Scope: "project", ProjectPath: project, Version: "2.0.0", InstallPath: dirs[project], GitCommitSha: "bbbbbbb"})
+		}
+		for _, backup := range backups {
+			if backup.Plugin.ProjectPath == projectB {
+				Expect(restorePluginBackup(home, backup, plugins)).To(Succeed())
+			}
+		}
+
+		Expect(os.ReadFile(filepath.Join(dirs[projectB], "plugin.json"))).To(Equal([]byte(projectB)))
+		Expect(os.ReadFile(filepath.Join(dirs[projectA], "plugin.json"))).To(Equal([]byte("new")))
+		restored, ok := plugins.GetPluginInstance("tool@market", "project", projectB)
+		Expect(ok).To(BeTrue())
+		Expect(restored.Version).To(Equal("1.0.0"))
+		untouched, ok := plugins.GetPluginInstance("tool@market", "project", projectA)
+		Expect(ok).To(BeTrue())
+		Expect(untouched.Version).To(Equal("2.0.0"))
+	})
+})
+
+var _ = Describe("groupChangedFiles", func() {
+	It("groups files by plugin directory", func() {
+		groups := groupChangedFiles([]string{
+			"plugins/alpha/commands/run.md",
+			"plugins/alpha/plugin.json",
+			"skills/beta/SKILL.md",
+			"README.md",
+			".claude-plugin/marketplace.json",
+			"plugins/README.md",
+		})
+		Expect(groups).To(HaveKeyWithValue("alpha", []string{"plugins/alpha/commands/run.md", "plugins/alpha/plugin.json"}))
+		Expect(groups).To(HaveKeyWithValue("beta", []string{"skills/beta/SKILL.md"}))
+		Expect(groups).To(HaveKeyWithValue(otherChangesKey, []string{"README.md", ".claude-plugin/marketplace.json", "plugins/README.md"}))
+	})
+})
+
+var _ = Describe("selectPluginUpdates", func() {
+	It("keeps only accepted plugins", func() {
+		updates := []PluginUpdate{
+			{Name: "a@m", Scope: "user", HasUpdate: true},
+			{Name: "b@m", Scope: "user", HasUpdate: true},
+			{Name: "a@m", Scope: "project", HasUpdate: true},
+		}
+		var prompts []string
+		selected := selectPluginUpdates(updates, func(prompt string) bool {
+			prompts = append(prompts, prompt)
+			return !strings.HasPrefix(prompt, "Upgrade b@m")
+		})
+		Expect(prompts).To(HaveLen(3))
+		Expect(selected).To(Equal([]PluginUpdate{updates[0], updates[2]}))
+	})
+})
+
+var _ = Describe("updateCheckCache", func() {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	cache := updateCheckCache{
+		"market": {Head: "aaa", Remote: "bbb", CheckedAt: now.Add(-time.Minute)},
+		"pinned": {Head: "aaa", Remote: "bbb", Ref: "v1", CheckedAt: now.Add(-time.Minute)},
+		"stale":  {Head: "aaa", Remote: "bbb", CheckedAt: now.Add(-updateCheckCacheTTL - time.Second)},
+	}
+
+	It("reuses a fresh entry for the same checkout", func() {
+		remote, ok := cache.lookup("market", "aaa", "", now)
+		Expect(ok).To(BeTrue())
+		Expect(remote).To(Equal("bbb"))
+	})
+
+	It("ignores entries whose HEAD or ref changed", func() {
+		_, ok := cache.lookup("market", "ccc", "", now)
+		Expect(ok).To(BeFalse())
+		_, ok = cache.lookup("pinned", "aaa", "v2", now)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("ignores expired and unknown entries", func() {
+		_, ok := cache.lookup("stale", "aaa", "", now)
+		Expect(ok).To(BeFalse())
+		_, ok = cache.lookup("missing", "aaa", "", now)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("drops expired entries when saved", func() {
+		home := GinkgoT().TempDir()
+		saveUpdateCheckCache(home, updateCheckCache{
+			"fresh": {Head: "a", Remote: "b", CheckedAt: now},
+			"stale": {Head: "a", Remote: "b", CheckedAt: now.Add(-time.Hour)},
+		}, now)
+		loaded := loadUpdateCheckCache(home)
+		Expect(loaded).To(HaveKey("fresh"))
+		Expect(loaded).NotTo(HaveKey("stale"))
+	})
+
+	It("treats a corrupt cache file as empty", func() {
+		home := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(home, updateCheckCacheFile), []byte("{"), 0644)).To(Succeed())
+		Expect(loadUpdateCheckCache(home)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("checkMarketplaceUpdates", func() {
+	var (
+		repoDir      string
+		firstCommit  string
+		secondCommit string
+		marketplaces claude.MarketplaceRegistry
+	)
+
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", repoDir, "-c", "user.name=test", "-c", "user.email=test@test.com", "-c", "commit.gpgsign=false"}, args...)...)
+		output, err := cmd.CombinedOutput()
+		Expect(err).NotTo(HaveOccurred(), string(output))
+		return strings.TrimSpace(string(output))
+	}
+
+	BeforeEach(func() {
+		// A checkout with no reachable origin, so any fetch attempt fails
+		repoDir = GinkgoT().TempDir()
+		git("init")
+		git("commit", "--allow-empty", "-m", "first")
+		firstCommit = git("rev-parse", "HEAD")
+		git("commit", "--allow-empty", "-m", "second")
+		secondCommit = git("rev-parse", "HEAD")
+		git("reset", "--hard", firstCommit)
+
+		marketplaces = claude.MarketplaceRegistry{
+			"market": claude.MarketplaceMetadata{InstallLocation: repoDir},
+			"local": claude.MarketplaceMetadata{
+				Source:          claude.MarketplaceSource{Source: "directory", Path: repoDir},
+				InstallLocation: repoDir,
+			},
+		}
+	})
+
+	It("uses a fresh cache entry instead of fetching", func() {
+		cache := updateCheckCache{
+			"market": {Head: firstCommit, Remote: secondCommit, CheckedAt: time.Now()},
+		}
+		var reported []string
+		updates := checkMarketplaceUpdates(context.Background(), marketplaces, cache, func(u MarketplaceUpdate) {
+			reported = append(reported, u.Name)
+		})
+
+		Expect(reported).To(Equal([]string{"local", "market"}), "results are reported in name order")
+		Expect(updates[0].Local).To(BeTrue())
+		Expect(updates[1].HasUpdate).To(BeTrue())
+		Expect(updates[1].CheckFailed).To(BeFalse())
+		Expect(updates[1].LatestCommit).To(Equal(secondCommit))
+	})
+
+	It("reports full commit SHAs for the interactive preview", func() {
+		cache := updateCheckCache{
+			"market": {Head: firstCommit, Remote: secondCommit, CheckedAt: time.Now()},
+		}
+		updates := checkMarketplaceUpdates(context.Background(), marketplaces, cache, nil)
+
+		Expect(updates[1].CurrentCommit).To(Equal(firstCommit))
+		Expect(updates[1].LatestCommit).To(Equal(secondCommit))
+		commits, err := marketplaceCommitLog(repoDir, updates[1].CurrentCommit, updates[1].LatestCommit)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commits).To(HaveLen(1))
+		Expect(commits[0]).To(HaveSuffix("second"))
+	})
+
+	It("fetches when there is no cache entry", func() {
+		updates := checkMarketplaceUpdates(context.Background(), marketplaces, updateCheckCache{}, nil)
+		Expect(updates[1].Name).To(Equal("market"))
+		Expect(updates[1].CheckFailed).To(BeTrue(), "fetch has no origin to reach")
+	})
+
+	It("reports every marketplace as failed once cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		updates := checkMarketplaceUpdates(ctx, marketplaces, nil, nil)
+		Expect(updates).To(HaveLen(2))
+		for _, update := range updates {
+			Expect(update.CheckFailed).To(BeTrue(), update.Name)
+		}
+	})
+})
+
+var _ = Describe("updateMarketplace", func() {
+	var (
+		workDir   string
+		claudeDir string
+		source    string
+	)
+
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@test.com", "-c", "commit.gpgsign=false"}, args...)...)
+		output, err := cmd.CombinedOutput()
+		Expect(err).NotTo(HaveOccurred(), string(output))
+		return strings.TrimSpace(string(output))
+	}
+
+	commit := func(message string) string {
+		Expect(os.WriteFile(filepath.Join(workDir, "CHANGELOG.md"), []byte(message), 0644)).To(Succeed())
+		git(workDir, "add", "--all")
+		git(workDir, "commit", "-m", message)
+		git(workDir, "push", "--quiet", "origin", "stable")
+		return git(workDir, "rev-parse", "HEAD")
+	}
+
+	BeforeEach(func() {
+		root := GinkgoT().TempDir()
+		bare := filepath.Join(root, "remote.git")
+		workDir = filepath.Join(root, "work")
+		claudeDir = filepath.Join(root, "claude")
+		source = "file://" + bare + "#stable"
+
+		git(root, "init", "--quiet", "--bare", "--initial-branch=main", bare)
+		git(root, "clone", "--quiet", bare, workDir)
+		git(workDir, "checkout", "--quiet", "-b", "main")
+		Expect(os.MkdirAll(filepath.Join(workDir, ".claude-plugin"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(workDir, ".claude-plugin", "marketplace.json"),
+			[]byte(`{"name": "branch-market", "plugins": []}`), 0644)).To(Succeed())
+		git(workDir, "add", "--all")
+		git(workDir, "commit", "-m", "initial")
+		git(workDir, "push", "--quiet", "origin", "main")
+		git(workDir, "checkout", "--quiet", "-b", "stable")
+		commit("stable release")
+	})
+
+	addMarketplace := func() claude.MarketplaceMetadata {
+		executor := &profile.NativeExecutor{ClaudeDir: claudeDir}
+		_, err := executor.RunWithOutput("plugin", "marketplace", "add", source)
+		Expect(err).NotTo(HaveOccurred())
+		registry, err := claude.LoadMarketplaces(claudeDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(registry).To(HaveKey("branch-market"))
+		return registry["branch-market"]
+	}
+
+	It("upgrades a marketplace that tracks a branch", func() {
+		meta := addMarketplace()
+		Expect(marketplace.IsDetached(meta.InstallLocation)).To(BeFalse(), "a branch ref is checked out as a branch")
+
+		latest := commit("next release")
+		update, _ := checkMarketplace(context.Background(), "branch-market", meta, nil, time.Now())
+		Expect(update.HasUpdate).To(BeTrue())
+
+		Expect(updateMarketplace("branch-market", meta)).To(Succeed())
+		head, err := marketplace.Head(meta.InstallLocation)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(head).To(Equal(latest))
+	})
+
+	It("moves a clone left on a detached HEAD onto its branch", func() {
+		meta := addMarketplace()
+		git(meta.InstallLocation, "checkout", "--quiet", "--detach", "HEAD")
+
+		latest := commit("next release")
+		Expect(updateMarketplace("branch-market", meta)).To(Succeed())
+		head, err := marketplace.Head(meta.InstallLocation)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(head).To(Equal(latest))
+		Expect(marketplace.IsDetached(meta.InstallLocation)).To(BeFalse())
+	})
+})
@@ -17,6 +17,31 @@ type GlobalConfig struct {
 
 // Preferences represents user preferences
 type Preferences struct {
+	// UnsignedProfiles is the trust policy for profiles that run commands
+	// but are not signed by a trusted key: "refuse", "prompt", or "allow".
+	// Empty means "prompt".
+	UnsignedProfiles string `json:"unsignedProfiles,omitempty"`
+	// HookEnv names environment variables post-apply hooks may see beyond
+	// the default allowlist, whether or not the profile is signed.
+	HookEnv []string `json:"hookEnv,omitempty"`
+
+	// UpdateChannel is the release channel claudeup update follows:
+	// "stable" or "beta". Empty means "stable".
+	UpdateChannel string `json:"updateChannel,omitempty"`
+	// UpdateBaseURL points claudeup update at a release mirror instead of
+	// GitHub.
+	UpdateBaseURL string `json:"updateBaseURL,omitempty"`
+
+	// DisableUpdateNotifications turns off the background update check and
+	// the notice printed after commands.
+	DisableUpdateNotifications bool `json:"disableUpdateNotifications,omitempty"`
+	// UpdateCheckInterval is how often the background check runs, as a Go
+	// duration ("24h", "168h"). Empty means once a day.
+	UpdateCheckInterval string `json:"updateCheckInterval,omitempty"`
+
+	// BackupRetention is how many backup generations automatic pruning
+	// keeps, as last set with 'backup create --keep'. Zero means the default.
+	BackupRetention int `json:"backupRetention,omitempty"`
 }
 
 // DefaultConfig returns a new config with default values
@@ -28,24 +53,33 @@ func DefaultConfig() *GlobalConfig {
 
 // configPath returns the path to the global config file
 func configPath() string {
-	return filepath.Join(MustClaudeupHome(), "config.json")
+	return configPathIn(MustClaudeupHome())
+}
+
+func configPathIn(claudeupHome string) string {
+	return filepath.Join(claudeupHome, "config.json")
 }
 
 // Load reads the global config file, creating it with defaults if it doesn't exist
 func Load() (*GlobalConfig, error) {
-	cfgPath := configPath()
-
 	// If config doesn't exist, create it with defaults
-	if _, err := os.Stat(cfgPath); errors.Is(err, fs.ErrNotExist) {
+	if _, err := os.Stat(configPath()); errors.Is(err, fs.ErrNotExist) {
 		cfg := DefaultConfig()
 		if err := Save(cfg); err != nil {
 			return nil, err
 		}
 		return cfg, nil
 	}
+	return LoadFrom(MustClaudeupHome())
+}
 
-	// Read existing config
-	data, err := os.ReadFile(cfgPath)
+// LoadFrom reads the global config in claudeupHome, returning defaults
+// without creating the file if it doesn't exist
+func LoadFrom(claudeupHome string) (*GlobalConfig, error) {
+	data, err := os.ReadFile(configPathIn(claudeupHome))
+	if errors.Is(err, fs.ErrNotExist) {
+		return DefaultConfig(), nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +94,12 @@ func Load() (*GlobalConfig, error) {
 
 // Save writes the global config to disk
 func Save(cfg *GlobalConfig) error {
-	cfgPath := configPath()
+	return SaveTo(MustClaudeupHome(), cfg)
+}
+
+// SaveTo writes the global config in claudeupHome
+func SaveTo(claudeupHome string, cfg *GlobalConfig) error {
+	cfgPath := configPathIn(claudeupHome)
 
 	// Ensure directory exists
 	dir := filepath.Dir(cfgPath)
@@ -18,6 +18,7 @@ const (
 	ChangeTypeDelete   = "delete"
 	ChangeTypeNoChange = "no-change"
 	ChangeTypeUnknown  = "unknown"
+	ChangeTypeRun      = "run" // a command claudeup ran rather than a file it wrote
 )
 
 // FileOperation represents a single file modification event
@@ -118,6 +119,25 @@ func (t *Tracker) RecordFileWrite(operation string, file string, scope string, f
 	return err
 }
 
+// RecordCommand records a command claudeup ran, such as a post-apply hook.
+// What ran and its output go in context; file is the script run, if any.
+func (t *Tracker) RecordCommand(operation, file string, context map[string]interface{}, err error) {
+	if !t.enabled || t.writer == nil {
+		return
+	}
+	if file != "" {
+		file = filepath.Clean(file)
+	}
+	_ = t.writer.Write(&FileOperation{
+		Timestamp:  time.Now(),
+		Operation:  operation,
+		File:       file,
+		ChangeType: ChangeTypeRun,
+		Context:    context,
+		Error:      errToString(err),
+	})
+}
+
 // snapshot creates a snapshot of a file's current state
 func (t *Tracker) snapshot(path string) *Snapshot {
 	info, err := os.Stat(path)
@@ -328,6 +328,27 @@ var _ = Describe("Tracker", func() {
 			})
 		})
 	})
+
+	Describe("RecordCommand", func() {
+		It("records what ran, its context, and its error", func() {
+			tracker.RecordCommand("hook run", "/profiles/./setup.sh", map[string]interface{}{"exitCode": 3}, errors.New("exit status 3"))
+
+			recordedEvents := writer.getEvents()
+			Expect(recordedEvents).To(HaveLen(1))
+			event := recordedEvents[0]
+			Expect(event.Operation).To(Equal("hook run"))
+			Expect(event.File).To(Equal("/profiles/setup.sh"))
+			Expect(event.ChangeType).To(Equal(events.ChangeTypeRun))
+			Expect(event.Context).To(HaveKeyWithValue("exitCode", 3))
+			Expect(event.Error).To(Equal("exit status 3"))
+		})
+
+		It("records nothing when disabled", func() {
+			events.NewTracker(writer, false).RecordCommand("hook run", "", nil, nil)
+
+			Expect(writer.getEvents()).To(BeEmpty())
+		})
+	})
 })
 
 // fakeEventWriter for testing
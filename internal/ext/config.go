@@ -16,9 +16,12 @@ type Config map[string]map[string]bool
 
 // Manager handles extension operations
 type Manager struct {
-	claudeDir  string
-	extDir     string
-	configFile string
+	claudeDir    string
+	extDir       string
+	configFile   string
+	sourcesFile  string
+	variantsFile string
+	variantsDir  string
 }
 
 // NewManager creates a new Manager for managing extensions.
@@ -40,9 +43,12 @@ func NewManager(claudeDir, claudeupHome string) *Manager {
 	}
 
 	return &Manager{
-		claudeDir:  claudeDir,
-		extDir:     extDir,
-		configFile: filepath.Join(claudeupHome, "enabled.json"),
+		claudeDir:    claudeDir,
+		extDir:       extDir,
+		configFile:   filepath.Join(claudeupHome, "enabled.json"),
+		sourcesFile:  filepath.Join(claudeupHome, SourcesFile),
+		variantsFile: filepath.Join(claudeupHome, VariantsFile),
+		variantsDir:  filepath.Join(claudeupHome, variantsDirName),
 	}
 }
 
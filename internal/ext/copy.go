@@ -10,30 +10,48 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 )
 
+// CopyResult reports what CopyToProject did.
+type CopyResult struct {
+	// Copied lists items that now match the extension library.
+	Copied []string
+	// Kept lists items left alone because they were edited in the project
+	// since they were last copied.
+	Kept []string
+	// NotFound lists patterns that matched no item.
+	NotFound []string
+}
+
 // CopyToProject copies extensions matching patterns into the project's .claude/{category}/ directory.
 // Source items are read from {extDir}/{category}/{item}.
 // Destination is {projectDir}/.claude/{category}/{item}.
 // Skill directories (containing SKILL.md) are copied recursively.
-// Returns (copied items, not found patterns, error).
-func CopyToProject(extDir, category string, patterns []string, projectDir string) ([]string, []string, error) {
+// patches holds frontmatter overrides keyed by pattern; matching items are
+// copied with their frontmatter patched.
+// Each copy is recorded in .claude/.claudeup-ext.json with its content hash, so
+// re-copying refreshes unedited items but keeps ones edited in the project.
+func CopyToProject(extDir, category string, patterns []string, patches map[string]FrontmatterPatch, projectDir string) (*CopyResult, error) {
 	sourceDir := filepath.Join(extDir, category)
 
 	// List available items in extension storage
 	allItems, err := listItems(sourceDir, category)
 	if err != nil {
-		return nil, nil, fmt.Errorf("list items for %s: %w", category, err)
+		return nil, fmt.Errorf("list items for %s: %w", category, err)
 	}
 
-	var copied []string
-	var notFound []string
+	manifest, err := LoadProjectManifest(projectDir)
+	if err != nil {
+		return nil, err
+	}
 
+	result := &CopyResult{}
 	destBase := filepath.Clean(filepath.Join(projectDir, ".claude", category))
 	for _, pattern := range patterns {
 		matched := MatchWildcard(pattern, allItems)
 		if len(matched) == 0 {
-			notFound = append(notFound, pattern)
+			result.NotFound = append(result.NotFound, pattern)
 			continue
 		}
 
@@ -42,17 +60,85 @@ func CopyToProject(extDir, category string, patterns []string, projectDir string
 			destPath := filepath.Clean(filepath.Join(destBase, item))
 
 			if err := validateDestPath(destPath, destBase); err != nil {
-				return nil, nil, fmt.Errorf("item %q: %w", item, err)
+				return nil, fmt.Errorf("item %q: %w", item, err)
 			}
 
-			if err := copyItemToProject(srcPath, destPath); err != nil {
-				return nil, nil, fmt.Errorf("copy %s/%s from %s to %s: %w", category, item, srcPath, destPath, err)
+			patch := patches[pattern]
+			record, tracked := manifest[category][item]
+			kept, err := copyTrackedItem(srcPath, destPath, patch, record, tracked)
+			if err != nil {
+				return nil, fmt.Errorf("copy %s/%s from %s to %s: %w", category, item, srcPath, destPath, err)
+			}
+			if kept {
+				result.Kept = append(result.Kept, item)
+				continue
 			}
-			copied = append(copied, item)
+
+			hash, err := hashPath(destPath)
+			if err != nil {
+				return nil, fmt.Errorf("hash %s: %w", destPath, err)
+			}
+			if !tracked || record.Hash != hash {
+				record.Hash, record.CopiedAt = hash, time.Now().UTC()
+			}
+			record.Source, record.Set = category+"/"+item, patch
+			manifest.set(category, item, record)
+			result.Copied = append(result.Copied, item)
+		}
+	}
+
+	if len(result.Copied) > 0 {
+		if err := SaveProjectManifest(projectDir, manifest); err != nil {
+			return nil, fmt.Errorf("save %s: %w", ProjectManifestFile, err)
 		}
 	}
+	return result, nil
+}
+
+// copyTrackedItem brings the project copy at dest up to date with src, with
+// patch applied to its frontmatter when set.
+// Items recorded in the manifest are replaced whole, unless the project copy
+// no longer matches the recorded hash, in which case it is kept and
+// copyTrackedItem returns true. Untracked items are copied over.
+func copyTrackedItem(src, dest string, patch FrontmatterPatch, record ProjectItem, tracked bool) (bool, error) {
+	files, err := libraryFiles(src, patch)
+	if err != nil {
+		return false, err
+	}
+	write := func() error {
+		if len(patch) == 0 {
+			return copyItemToProject(src, dest)
+		}
+		return replaceItem(dest, files)
+	}
 
-	return copied, notFound, nil
+	if !tracked {
+		return false, write()
+	}
+	projectHash, err := hashPath(dest)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, write()
+	}
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case projectHash == hashItem(files):
+		return false, nil
+	case projectHash != record.Hash:
+		return true, nil
+	default:
+		return false, replaceItem(dest, files)
+	}
+}
+
+// libraryFiles reads a library item, patching its frontmatter when patch is set.
+func libraryFiles(path string, patch FrontmatterPatch) ([]itemFile, error) {
+	if len(patch) == 0 {
+		return readItem(path)
+	}
+	return patchItem(path, patch)
 }
 
 // validateDestPath checks that destPath stays within baseDir.
@@ -1,5 +1,5 @@
 // ABOUTME: Tests for CopyToProject - copies extensions into project .claude/ directory
-// ABOUTME: Validates file copy, directory creation, skill dirs, wildcards, and overwrite
+// ABOUTME: Validates file copy, skill dirs, wildcards, overwrite, and the project manifest
 package ext
 
 import (
@@ -7,6 +7,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -23,10 +24,11 @@ func TestCopyToProjectCopiesFiles(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	copied, notFound, err := CopyToProject(extDir, "rules", []string{"golang.md"}, projectDir)
+	result, err := CopyToProject(extDir, "rules", []string{"golang.md"}, nil, projectDir)
 	if err != nil {
 		t.Fatalf("CopyToProject failed: %v", err)
 	}
+	copied, notFound := result.Copied, result.NotFound
 
 	if len(notFound) != 0 {
 		t.Errorf("expected no notFound, got %v", notFound)
@@ -68,10 +70,11 @@ func TestCopyToProjectCreatesDirectories(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	copied, _, err := CopyToProject(extDir, "agents", []string{"review-team/reviewer.md"}, projectDir)
+	result, err := CopyToProject(extDir, "agents", []string{"review-team/reviewer.md"}, nil, projectDir)
 	if err != nil {
 		t.Fatalf("CopyToProject failed: %v", err)
 	}
+	copied := result.Copied
 
 	if len(copied) != 1 {
 		t.Errorf("expected 1 copied, got %d", len(copied))
@@ -100,10 +103,11 @@ func TestCopyToProjectHandlesSkillDirectories(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	copied, _, err := CopyToProject(extDir, "skills", []string{"session-notes"}, projectDir)
+	result, err := CopyToProject(extDir, "skills", []string{"session-notes"}, nil, projectDir)
 	if err != nil {
 		t.Fatalf("CopyToProject failed: %v", err)
 	}
+	copied := result.Copied
 
 	if len(copied) != 1 || copied[0] != "session-notes" {
 		t.Errorf("expected copied [session-notes], got %v", copied)
@@ -135,10 +139,11 @@ func TestCopyToProjectWithWildcards(t *testing.T) {
 		}
 	}
 
-	copied, _, err := CopyToProject(extDir, "rules", []string{"go*"}, projectDir)
+	result, err := CopyToProject(extDir, "rules", []string{"go*"}, nil, projectDir)
 	if err != nil {
 		t.Fatalf("CopyToProject failed: %v", err)
 	}
+	copied := result.Copied
 
 	if len(copied) != 2 {
 		t.Errorf("expected 2 copied (go* matching golang.md, go-testing.md), got %d: %v", len(copied), copied)
@@ -173,8 +178,7 @@ func TestCopyToProjectOverwrites(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, _, err := CopyToProject(extDir, "rules", []string{"golang.md"}, projectDir)
-	if err != nil {
+	if _, err := CopyToProject(extDir, "rules", []string{"golang.md"}, nil, projectDir); err != nil {
 		t.Fatalf("CopyToProject failed: %v", err)
 	}
 
@@ -197,10 +201,11 @@ func TestCopyToProjectNotFound(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	copied, notFound, err := CopyToProject(extDir, "rules", []string{"nonexistent.md"}, projectDir)
+	result, err := CopyToProject(extDir, "rules", []string{"nonexistent.md"}, nil, projectDir)
 	if err != nil {
 		t.Fatalf("CopyToProject failed: %v", err)
 	}
+	copied, notFound := result.Copied, result.NotFound
 
 	if len(copied) != 0 {
 		t.Errorf("expected 0 copied, got %v", copied)
@@ -211,18 +216,10 @@ func TestCopyToProjectNotFound(t *testing.T) {
 }
 
 func TestProjectScopeCategories(t *testing.T) {
-	// agents and rules should be valid project-scope categories
-	if !ProjectScopeCategories[CategoryAgents] {
-		t.Error("expected agents to be a valid project-scope category")
-	}
-	if !ProjectScopeCategories[CategoryRules] {
-		t.Error("expected rules to be a valid project-scope category")
-	}
-
-	// commands, skills, hooks, output-styles should NOT be
-	for _, cat := range []string{CategoryCommands, CategorySkills, CategoryHooks, CategoryOutputStyles} {
-		if ProjectScopeCategories[cat] {
-			t.Errorf("expected %s to NOT be a valid project-scope category", cat)
+	// Claude Code reads every extension category from project .claude/ directories
+	for _, cat := range AllCategories() {
+		if !ProjectScopeCategories[cat] {
+			t.Errorf("expected %s to be a valid project-scope category", cat)
 		}
 	}
 }
@@ -257,19 +254,124 @@ func TestValidateDestPath(t *testing.T) {
 }
 
 func TestValidateProjectScopeCategories(t *testing.T) {
-	// Valid categories
-	if err := ValidateProjectScope("agents"); err != nil {
-		t.Errorf("agents should be valid: %v", err)
+	for _, cat := range []string{"agents", "rules", "commands", "skills", "hooks", "output-styles"} {
+		if err := ValidateProjectScope(cat); err != nil {
+			t.Errorf("%s should be valid: %v", cat, err)
+		}
+	}
+
+	if err := ValidateProjectScope("plugins"); err == nil {
+		t.Error("plugins should be invalid for project scope")
+	}
+}
+
+func TestCopyToProjectRecordsManifest(t *testing.T) {
+	extDir := t.TempDir()
+	projectDir := t.TempDir()
+	writeExtItem(t, extDir, "commands/deploy.md", "Deploy the app")
+	writeExtItem(t, extDir, "skills/notes/SKILL.md", "# Notes")
+
+	for _, cat := range []string{CategoryCommands, CategorySkills} {
+		if _, err := CopyToProject(extDir, cat, []string{"*"}, nil, projectDir); err != nil {
+			t.Fatalf("CopyToProject(%s) failed: %v", cat, err)
+		}
+	}
+
+	manifest, err := LoadProjectManifest(projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deploy := manifest[CategoryCommands]["deploy.md"]
+	if deploy.Source != "commands/deploy.md" || !strings.HasPrefix(deploy.Hash, "sha256:") || deploy.CopiedAt.IsZero() {
+		t.Errorf("unexpected record: %+v", deploy)
+	}
+	if manifest[CategorySkills]["notes"].Source != "skills/notes" {
+		t.Errorf("skill not recorded: %+v", manifest[CategorySkills])
+	}
+
+	statuses, err := ProjectStatus(extDir, projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || !statuses[0].Current() || !statuses[1].Current() {
+		t.Errorf("expected two current items, got %+v", statuses)
+	}
+}
+
+func TestCopyToProjectKeepsLocalEdits(t *testing.T) {
+	extDir := t.TempDir()
+	projectDir := t.TempDir()
+	writeExtItem(t, extDir, "rules/golang.md", "v1")
+	writeExtItem(t, extDir, "rules/bash.md", "v1")
+	if _, err := CopyToProject(extDir, CategoryRules, []string{"*"}, nil, projectDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// The library moves on for both; the project edits one of them
+	writeExtItem(t, extDir, "rules/golang.md", "v2")
+	writeExtItem(t, extDir, "rules/bash.md", "v2")
+	edited := filepath.Join(projectDir, ".claude", "rules", "bash.md")
+	if err := os.WriteFile(edited, []byte("project version"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := ProjectStatus(extDir, projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ProjectItemStatus{
+		{Category: CategoryRules, Item: "bash.md", LocallyModified: true, Outdated: true},
+		{Category: CategoryRules, Item: "golang.md", Outdated: true},
+	}
+	if len(statuses) != 2 || statuses[0] != want[0] || statuses[1] != want[1] {
+		t.Errorf("ProjectStatus = %+v, want %+v", statuses, want)
+	}
+
+	result, err := CopyToProject(extDir, CategoryRules, []string{"*"}, nil, projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Copied) != 1 || result.Copied[0] != "golang.md" || len(result.Kept) != 1 || result.Kept[0] != "bash.md" {
+		t.Errorf("unexpected result: %+v", result)
 	}
-	if err := ValidateProjectScope("rules"); err != nil {
-		t.Errorf("rules should be valid: %v", err)
+	if data, _ := os.ReadFile(edited); string(data) != "project version" {
+		t.Errorf("local edit overwritten: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(projectDir, ".claude", "rules", "golang.md")); string(data) != "v2" {
+		t.Errorf("unedited item not refreshed: %q", data)
+	}
+
+	statuses, _ = ProjectStatus(extDir, projectDir)
+	if !statuses[1].Current() || statuses[0].Current() {
+		t.Errorf("after re-copy: %+v", statuses)
 	}
 
-	// Invalid categories
-	if err := ValidateProjectScope("commands"); err == nil {
-		t.Error("commands should be invalid for project scope")
+	// Deleted copies are restored; items gone from the library are reported
+	if err := os.Remove(filepath.Join(projectDir, ".claude", "rules", "golang.md")); err != nil {
+		t.Fatal(err)
 	}
-	if err := ValidateProjectScope("skills"); err == nil {
-		t.Error("skills should be invalid for project scope")
+	if err := os.Remove(filepath.Join(extDir, "rules", "bash.md")); err != nil {
+		t.Fatal(err)
+	}
+	statuses, _ = ProjectStatus(extDir, projectDir)
+	if !statuses[0].LibraryMissing || !statuses[1].Deleted {
+		t.Errorf("expected missing library item and deleted copy: %+v", statuses)
+	}
+	if _, err := CopyToProject(extDir, CategoryRules, []string{"golang.md"}, nil, projectDir); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(projectDir, ".claude", "rules", "golang.md")); string(data) != "v2" {
+		t.Errorf("deleted copy not restored: %q", data)
+	}
+}
+
+func writeExtItem(t *testing.T, extDir, rel, content string) {
+	t.Helper()
+	path := filepath.Join(extDir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
 	}
 }
@@ -39,14 +39,18 @@ func ValidateCategory(category string) error {
 
 // ProjectScopeCategories defines which categories Claude Code reads from project .claude/ directories
 var ProjectScopeCategories = map[string]bool{
-	CategoryAgents: true,
-	CategoryRules:  true,
+	CategoryAgents:       true,
+	CategoryCommands:     true,
+	CategorySkills:       true,
+	CategoryHooks:        true,
+	CategoryRules:        true,
+	CategoryOutputStyles: true,
 }
 
 // ValidateProjectScope checks if a category is valid for project scope
 func ValidateProjectScope(category string) error {
 	if !ProjectScopeCategories[category] {
-		return fmt.Errorf("category %q is not supported at project scope (only agents, rules)", category)
+		return fmt.Errorf("category %q is not supported at project scope", category)
 	}
 	return nil
 }
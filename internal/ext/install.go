@@ -1,32 +1,54 @@
-// ABOUTME: Installs items from external paths to extension storage
-// ABOUTME: Copies files/directories and auto-enables them
+// ABOUTME: Installs items from paths, GitHub repos, URLs, and plugin caches to extension storage
+// ABOUTME: Copies files/directories, auto-enables them, and records their provenance
 package ext
 
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
-// Install copies items from sourcePath to extension storage and enables them.
+// Install copies items from source to extension storage, enables them, and
+// records where each came from in ext-sources.json. source is a local path
+// or a github:, https://, or plugin: spec (see ParseSource).
 // For single files/directories: copies as-is.
 // For containers with multiple items: copies each item individually.
 // Returns (installed items, skipped items, error).
-func (m *Manager) Install(category string, sourcePath string) ([]string, []string, error) {
+func (m *Manager) Install(category string, source string) ([]string, []string, error) {
 	if err := ValidateCategory(category); err != nil {
 		return nil, nil, err
 	}
 
-	source, err := filepath.Abs(sourcePath)
+	src, err := ParseSource(source)
 	if err != nil {
 		return nil, nil, err
 	}
+	f, err := m.fetch(src, category)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.cleanup()
 
-	info, err := os.Stat(source)
+	info, err := os.Stat(f.root)
 	if err != nil {
-		return nil, nil, fmt.Errorf("source not found: %s", sourcePath)
+		return nil, nil, fmt.Errorf("source not found: %s", source)
+	}
+	// copyFile and copyDir follow symlinks, so a fetched source may only
+	// contain regular files and directories
+	copyChecked := func(src, dst string, dir bool) error {
+		if !f.local {
+			if err := checkNoSymlinks(src); err != nil {
+				return err
+			}
+		}
+		if dir {
+			return copyDir(src, dst)
+		}
+		return copyFile(src, dst)
 	}
 
 	extDir := filepath.Join(m.extDir, category)
@@ -36,74 +58,58 @@ func (m *Manager) Install(category string, sourcePath string) ([]string, []strin
 
 	var installed []string
 	var skipped []string
+	// origins maps each installed item to its path within the source
+	origins := make(map[string]string)
+
+	if info.IsDir() && !m.isSingleItemDir(category, f.root) {
+		// Container: copy each child item individually
+		entries, err := os.ReadDir(f.root)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	if info.IsDir() {
-		// Determine if source is a single item or container of multiple items
-		isSingleItem := m.isSingleItemDir(category, source)
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
 
-		if isSingleItem {
-			// Copy the directory as a single item
-			itemName := filepath.Base(source)
+			itemName := entry.Name()
+			srcPath := filepath.Join(f.root, itemName)
 			destPath := filepath.Join(extDir, itemName)
 
 			if pathExists(destPath) {
 				skipped = append(skipped, itemName)
-			} else {
-				if err := copyDir(source, destPath); err != nil {
-					return nil, nil, err
-				}
-				installed = append(installed, itemName)
-			}
-		} else {
-			// Container: copy each child item individually
-			entries, err := os.ReadDir(source)
-			if err != nil {
-				return nil, nil, err
+				continue
 			}
 
-			for _, entry := range entries {
-				if strings.HasPrefix(entry.Name(), ".") {
-					continue
-				}
-
-				itemName := entry.Name()
-				srcPath := filepath.Join(source, itemName)
-				destPath := filepath.Join(extDir, itemName)
-
-				if pathExists(destPath) {
-					skipped = append(skipped, itemName)
-					continue
-				}
-
-				if entry.IsDir() {
-					if err := copyDir(srcPath, destPath); err != nil {
-						return nil, nil, err
-					}
-				} else {
-					if err := copyFile(srcPath, destPath); err != nil {
-						return nil, nil, err
-					}
-				}
-				installed = append(installed, itemName)
+			if err := copyChecked(srcPath, destPath, entry.IsDir()); err != nil {
+				return nil, nil, err
 			}
+			installed = append(installed, itemName)
+			origins[itemName] = itemName
 		}
 	} else {
-		// Single file
-		itemName := filepath.Base(source)
+		// Single file or single-item directory: copy as-is
+		itemName := filepath.Base(f.root)
 		destPath := filepath.Join(extDir, itemName)
 
 		if pathExists(destPath) {
 			skipped = append(skipped, itemName)
 		} else {
-			if err := copyFile(source, destPath); err != nil {
+			if err := copyChecked(f.root, destPath, info.IsDir()); err != nil {
 				return nil, nil, err
 			}
 			installed = append(installed, itemName)
+			origins[itemName] = ""
 		}
 	}
 
-	// Enable all installed items
 	if len(installed) > 0 {
+		if err := m.recordProvenance(category, src, f.ref, origins); err != nil {
+			return nil, nil, err
+		}
+
+		// Enable all installed items
 		_, _, err := m.Enable(category, installed)
 		if err != nil {
 			return nil, nil, err
@@ -113,6 +119,30 @@ func (m *Manager) Install(category string, sourcePath string) ([]string, []strin
 	return installed, skipped, nil
 }
 
+// recordProvenance stores the source of freshly installed items, hashing
+// them as they now sit in extension storage.
+func (m *Manager) recordProvenance(category string, src Source, ref string, origins map[string]string) error {
+	manifest, err := m.LoadSources()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for item, rel := range origins {
+		hash, err := hashPath(filepath.Join(m.extDir, category, item))
+		if err != nil {
+			return err
+		}
+		manifest.set(category, item, Provenance{
+			Source:      src.String(),
+			Path:        rel,
+			Ref:         ref,
+			Hash:        hash,
+			InstalledAt: now,
+		})
+	}
+	return m.SaveSources(manifest)
+}
+
 // isSingleItemDir determines if a directory should be treated as a single item
 // or as a container of multiple items.
 func (m *Manager) isSingleItemDir(category string, dirPath string) bool {
@@ -209,6 +239,21 @@ func pathExists(path string) bool {
 	return err == nil
 }
 
+// checkNoSymlinks refuses an item from a fetched source that is or contains
+// a symlink: a link such as agents/x.md -> ~/.ssh/id_rsa would copy a file
+// from this machine into extension storage.
+func checkNoSymlinks(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("refusing to install %s: remote sources may not contain symlinks", filepath.Base(path))
+		}
+		return nil
+	})
+}
+
 func copyFile(src, dst string) error {
 	srcFile, err := os.Open(src)
 	if err != nil {
@@ -0,0 +1,463 @@
+// ABOUTME: Validates extension items before they are enabled
+// ABOUTME: Per-category checks for frontmatter, required files, script permissions, and plugin name collisions
+package ext
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+	"go.yaml.in/yaml/v3"
+)
+
+// Severities for lint findings. Errors mean Claude Code will not load the
+// item as intended; warnings are worth a look.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Finding is one problem found in an extension item.
+type Finding struct {
+	Category string
+	Item     string
+	// File is the absolute path of the offending file.
+	File string
+	// Line is 1-based, or 0 when the finding is about the whole file.
+	Line     int
+	Severity string
+	Message  string
+}
+
+// String formats the finding as file:line: severity: message.
+func (f Finding) String() string {
+	pos := f.File
+	if f.Line > 0 {
+		pos += ":" + strconv.Itoa(f.Line)
+	}
+	return fmt.Sprintf("%s: %s: %s", pos, f.Severity, f.Message)
+}
+
+// HasErrors reports whether any finding is an error.
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// frontmatterField describes one allowed frontmatter key.
+type frontmatterField struct {
+	required bool
+	// kind is "string", "bool", or "list" (a string or a list of strings).
+	kind string
+}
+
+// frontmatterSchemas lists the frontmatter keys Claude Code reads per
+// category, and whether the block itself is required.
+var frontmatterSchemas = map[string]struct {
+	required bool
+	fields   map[string]frontmatterField
+}{
+	CategoryAgents: {required: true, fields: map[string]frontmatterField{
+		"name":        {required: true, kind: "string"},
+		"description": {required: true, kind: "string"},
+		"tools":       {kind: "list"},
+		"model":       {kind: "string"},
+		"color":       {kind: "string"},
+	}},
+	CategorySkills: {required: true, fields: map[string]frontmatterField{
+		"name":          {required: true, kind: "string"},
+		"description":   {required: true, kind: "string"},
+		"allowed-tools": {kind: "list"},
+		"license":       {kind: "string"},
+		"model":         {kind: "string"},
+	}},
+	CategoryCommands: {fields: map[string]frontmatterField{
+		"description":              {kind: "string"},
+		"allowed-tools":            {kind: "list"},
+		"argument-hint":            {kind: "string"},
+		"model":                    {kind: "string"},
+		"disable-model-invocation": {kind: "bool"},
+	}},
+	CategoryOutputStyles: {fields: map[string]frontmatterField{
+		"name":                     {kind: "string"},
+		"description":              {kind: "string"},
+		"keep-coding-instructions": {kind: "bool"},
+	}},
+	CategoryRules: {fields: map[string]frontmatterField{
+		"description": {kind: "string"},
+		"paths":       {kind: "list"},
+	}},
+}
+
+var (
+	componentNamePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	yamlLinePattern      = regexp.MustCompile(`line (\d+)`)
+)
+
+// maxSkillDescription is the longest skill description Claude Code accepts.
+const maxSkillDescription = 1024
+
+// Lint checks items in category (all categories when empty) matching
+// patterns (all items when none).
+func (m *Manager) Lint(category string, patterns []string) ([]Finding, error) {
+	categories := AllCategories()
+	if category != "" {
+		if err := ValidateCategory(category); err != nil {
+			return nil, err
+		}
+		categories = []string{category}
+	}
+
+	components := m.pluginComponents()
+	var findings []Finding
+	for _, cat := range categories {
+		items, err := m.lintTargets(cat)
+		if err != nil {
+			return nil, err
+		}
+		if len(patterns) > 0 {
+			var selected []string
+			for _, pattern := range patterns {
+				selected = append(selected, MatchWildcard(pattern, items)...)
+			}
+			items = dedupe(selected)
+		}
+		for _, item := range items {
+			findings = append(findings, m.lintItem(cat, item, components)...)
+		}
+	}
+	return findings, nil
+}
+
+// LintItems checks specific items, as returned by Enable or Install.
+func (m *Manager) LintItems(category string, items []string) []Finding {
+	if category == CategorySkills {
+		// A skill directory without SKILL.md lists as its files; lint the directory
+		var dirs []string
+		for _, item := range items {
+			dirs = append(dirs, strings.SplitN(item, "/", 2)[0])
+		}
+		items = dedupe(dirs)
+	}
+	components := m.pluginComponents()
+	var findings []Finding
+	for _, item := range items {
+		findings = append(findings, m.lintItem(category, item, components)...)
+	}
+	return findings
+}
+
+// lintTargets lists the items to lint. Skills are listed by top-level
+// entry, so a directory missing SKILL.md is linted as the broken skill it
+// is rather than as loose files.
+func (m *Manager) lintTargets(category string) ([]string, error) {
+	if category != CategorySkills {
+		return m.ListItems(category)
+	}
+	entries, err := os.ReadDir(filepath.Join(m.extDir, category))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var items []string
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), ".") && entry.Name() != "CLAUDE.md" {
+			items = append(items, entry.Name())
+		}
+	}
+	return items, nil
+}
+
+func (m *Manager) lintItem(category, item string, components map[string]map[string]string) []Finding {
+	path := filepath.Join(m.extDir, category, item)
+	l := &linter{category: category, item: item}
+
+	switch category {
+	case CategorySkills:
+		info, err := os.Stat(path)
+		if err != nil {
+			l.add(path, 0, SeverityError, err.Error())
+			break
+		}
+		if !info.IsDir() {
+			l.add(path, 0, SeverityError, "skills must be directories containing SKILL.md")
+			break
+		}
+		skillFile := filepath.Join(path, "SKILL.md")
+		if _, err := os.Stat(skillFile); err != nil {
+			l.add(path, 0, SeverityError, "missing SKILL.md")
+			break
+		}
+		fields := l.lintMarkdown(skillFile)
+		if name, ok := fields["name"]; ok && name.value != item {
+			l.add(skillFile, name.line, SeverityWarning, fmt.Sprintf("name %q does not match directory name %q", name.value, item))
+		}
+		if desc, ok := fields["description"]; ok && len(desc.value) > maxSkillDescription {
+			l.add(skillFile, desc.line, SeverityError, fmt.Sprintf("description is %d characters; the limit is %d", len(desc.value), maxSkillDescription))
+		}
+
+	case CategoryHooks:
+		l.lintHook(path)
+
+	default:
+		if strings.HasSuffix(path, ".md") {
+			l.lintMarkdown(path)
+		}
+	}
+
+	name := componentName(item)
+	if plugin, ok := components[category][name]; ok {
+		l.add(path, 0, SeverityWarning, fmt.Sprintf("%s %q is also provided by plugin %s", strings.TrimSuffix(category, "s"), name, plugin))
+	}
+	return l.findings
+}
+
+// linter accumulates findings for one item.
+type linter struct {
+	category string
+	item     string
+	findings []Finding
+}
+
+func (l *linter) add(file string, line int, severity, message string) {
+	l.findings = append(l.findings, Finding{
+		Category: l.category,
+		Item:     l.item,
+		File:     file,
+		Line:     line,
+		Severity: severity,
+		Message:  message,
+	})
+}
+
+// fieldValue is a scalar frontmatter value and the file line it is on.
+type fieldValue struct {
+	value string
+	line  int
+}
+
+// lintMarkdown checks a markdown file's frontmatter against its category's
+// schema and returns the scalar fields it found.
+func (l *linter) lintMarkdown(path string) map[string]fieldValue {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		l.add(path, 0, SeverityError, err.Error())
+		return nil
+	}
+	schema := frontmatterSchemas[l.category]
+
+	block, ok, closed := frontmatter(data)
+	if !ok {
+		if schema.required {
+			l.add(path, 1, SeverityError, "missing frontmatter (expected a --- block with name and description)")
+		}
+		return nil
+	}
+	if !closed {
+		l.add(path, 1, SeverityError, "frontmatter is not closed with ---")
+		return nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(block, &doc); err != nil {
+		line := 1
+		if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			line = n + 1 // the block starts after the opening ---
+		}
+		l.add(path, line, SeverityError, "invalid frontmatter: "+strings.TrimPrefix(err.Error(), "yaml: "))
+		return nil
+	}
+	fields := make(map[string]fieldValue)
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		l.add(path, mapping.Line+1, SeverityError, "frontmatter must be a set of key: value pairs")
+		return nil
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		line := key.Line + 1
+		field, known := schema.fields[key.Value]
+		if !known {
+			l.add(path, line, SeverityWarning, fmt.Sprintf("unknown frontmatter field %q", key.Value))
+			continue
+		}
+		if msg := checkKind(value, field.kind); msg != "" {
+			l.add(path, line, SeverityError, fmt.Sprintf("%s %s", key.Value, msg))
+			continue
+		}
+		if value.Kind == yaml.ScalarNode {
+			fields[key.Value] = fieldValue{value: value.Value, line: line}
+		}
+	}
+
+	var missing []string
+	for name, field := range schema.fields {
+		if field.required && strings.TrimSpace(fields[name].value) == "" {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		l.add(path, 1, SeverityError, fmt.Sprintf("frontmatter is missing required field %q", name))
+	}
+	if name, ok := fields["name"]; ok && l.category == CategoryAgents && !componentNamePattern.MatchString(name.value) {
+		l.add(path, name.line, SeverityWarning, fmt.Sprintf("name %q should use lowercase letters, digits, and hyphens", name.value))
+	}
+	return fields
+}
+
+// checkKind returns a complaint when value does not have the expected kind.
+func checkKind(value *yaml.Node, kind string) string {
+	switch kind {
+	case "bool":
+		if value.Kind != yaml.ScalarNode || value.Tag != "!!bool" {
+			return "must be true or false"
+		}
+	case "list":
+		if value.Kind == yaml.SequenceNode {
+			for _, item := range value.Content {
+				if item.Kind != yaml.ScalarNode {
+					return "must be a string or a list of strings"
+				}
+			}
+			return ""
+		}
+		if value.Kind != yaml.ScalarNode {
+			return "must be a string or a list of strings"
+		}
+	default:
+		if value.Kind != yaml.ScalarNode || value.Tag == "!!null" {
+			return "must be a string"
+		}
+	}
+	return ""
+}
+
+// frontmatter extracts the YAML block between leading --- lines. ok is false
+// when the file has no frontmatter; closed is false when it never ends.
+func frontmatter(data []byte) (block []byte, ok, closed bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	if !scanner.Scan() || strings.TrimRight(scanner.Text(), " \r") != "---" {
+		return nil, false, false
+	}
+	var buf bytes.Buffer
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimRight(line, " \r") == "---" {
+			return buf.Bytes(), true, true
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	return nil, true, false
+}
+
+// lintHook checks that hook scripts can be executed.
+func (l *linter) lintHook(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		l.add(path, 0, SeverityError, err.Error())
+		return
+	}
+	if info.IsDir() {
+		return
+	}
+	switch filepath.Ext(path) {
+	case ".md", ".json", ".txt":
+		return
+	}
+	if info.Mode().Perm()&0111 == 0 {
+		l.add(path, 0, SeverityError, fmt.Sprintf("hook script is not executable (run: chmod +x %s)", path))
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		l.add(path, 0, SeverityError, err.Error())
+		return
+	}
+	if !bytes.HasPrefix(data, []byte("#!")) {
+		l.add(path, 1, SeverityWarning, "hook script has no #! line; it will run with the default shell")
+	}
+}
+
+// componentName is the name Claude Code knows an item by.
+func componentName(item string) string {
+	return strings.TrimSuffix(filepath.Base(item), ".md")
+}
+
+// pluginComponents maps category -> component name -> plugin for every
+// installed plugin, so items shadowed by (or shadowing) a plugin can be flagged.
+func (m *Manager) pluginComponents() map[string]map[string]string {
+	components := make(map[string]map[string]string)
+	registry, err := claude.LoadPlugins(m.claudeDir)
+	if err != nil {
+		return components
+	}
+	var plugins []string
+	for name := range registry.Plugins {
+		plugins = append(plugins, name)
+	}
+	sort.Strings(plugins)
+
+	for _, plugin := range plugins {
+		for _, instance := range registry.Plugins[plugin] {
+			if instance.InstallPath == "" {
+				continue
+			}
+			for _, category := range []string{CategoryAgents, CategoryCommands, CategorySkills, CategoryOutputStyles} {
+				entries, err := os.ReadDir(filepath.Join(instance.InstallPath, category))
+				if err != nil {
+					continue
+				}
+				for _, entry := range entries {
+					name := entry.Name()
+					if strings.HasPrefix(name, ".") {
+						continue
+					}
+					if (category == CategorySkills) != entry.IsDir() {
+						continue
+					}
+					if components[category] == nil {
+						components[category] = make(map[string]string)
+					}
+					if _, seen := components[category][componentName(name)]; !seen {
+						components[category][componentName(name)] = plugin
+					}
+				}
+			}
+		}
+	}
+	return components
+}
+
+func dedupe(items []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			out = append(out, item)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,132 @@
+// ABOUTME: Tests for linting extension items
+// ABOUTME: Covers frontmatter errors with line numbers, skills, hooks, and plugin collisions
+package ext
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeLintFile(t *testing.T, m *Manager, rel, content string, mode os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(m.extDir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func findingStrings(findings []Finding) []string {
+	var out []string
+	for _, f := range findings {
+		out = append(out, f.String())
+	}
+	return out
+}
+
+func TestLintAgents(t *testing.T) {
+	manager := NewManager(t.TempDir(), t.TempDir())
+	good := writeLintFile(t, manager, "agents/reviewer.md", "---\nname: reviewer\ndescription: Reviews code\ntools: [Read, Grep]\n---\n# Reviewer\n", 0644)
+	broken := writeLintFile(t, manager, "agents/broken.md", "---\nname: broken\ndescription: x: y\n---\n", 0644)
+	bare := writeLintFile(t, manager, "agents/bare.md", "# No frontmatter\n", 0644)
+	typed := writeLintFile(t, manager, "agents/typed.md", "---\nname: Typed Agent\ndescription: ok\ntools:\n  nested: true\nmood: happy\n---\n", 0644)
+
+	findings, err := manager.Lint(CategoryAgents, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(findingStrings(findings), "\n")
+
+	for _, want := range []string{
+		bare + ":1: error: missing frontmatter",
+		broken + ":3: error: invalid frontmatter: line 2: mapping values are not allowed",
+		typed + ":4: error: tools must be a string or a list of strings",
+		typed + ":6: warning: unknown frontmatter field \"mood\"",
+		typed + ":2: warning: name \"Typed Agent\" should use lowercase",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing finding %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, good) {
+		t.Errorf("valid agent should have no findings:\n%s", got)
+	}
+	if !HasErrors(findings) {
+		t.Error("expected errors")
+	}
+}
+
+func TestLintSkillsAndHooks(t *testing.T) {
+	manager := NewManager(t.TempDir(), t.TempDir())
+	writeLintFile(t, manager, "skills/deploy/SKILL.md", "---\nname: deploy\ndescription: Deploys\n---\n", 0644)
+	writeLintFile(t, manager, "skills/notes/README.md", "no skill file", 0644)
+	renamed := writeLintFile(t, manager, "skills/build/SKILL.md", "---\nname: builder\n---\n", 0644)
+	script := writeLintFile(t, manager, "hooks/format.sh", "#!/bin/sh\necho hi\n", 0644)
+	writeLintFile(t, manager, "hooks/ok.sh", "#!/bin/sh\n", 0755)
+	noShebang := writeLintFile(t, manager, "hooks/plain.sh", "echo hi\n", 0755)
+
+	findings, err := manager.Lint("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(findingStrings(findings), "\n")
+
+	for _, want := range []string{
+		filepath.Join(manager.extDir, "skills", "notes") + ": error: missing SKILL.md",
+		renamed + ":1: error: frontmatter is missing required field \"description\"",
+		renamed + ":2: warning: name \"builder\" does not match directory name \"build\"",
+		script + ": error: hook script is not executable",
+		noShebang + ":1: warning: hook script has no #! line",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing finding %q in:\n%s", want, got)
+		}
+	}
+	if len(findings) != 5 {
+		t.Errorf("expected 5 findings, got:\n%s", got)
+	}
+
+	// Enable reports skills by their files when SKILL.md is missing
+	itemFindings := manager.LintItems(CategorySkills, []string{"notes/README.md"})
+	if len(itemFindings) != 1 || itemFindings[0].Message != "missing SKILL.md" {
+		t.Errorf("LintItems = %v", findingStrings(itemFindings))
+	}
+}
+
+func TestLintPluginCollisions(t *testing.T) {
+	claudeDir := t.TempDir()
+	pluginDir := filepath.Join(t.TempDir(), "tools")
+	if err := os.MkdirAll(filepath.Join(pluginDir, "commands"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pluginDir, "commands", "deploy.md"), []byte("# Deploy"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(claudeDir, "plugins"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	registry := `{"version":2,"plugins":{"tools@market":[{"scope":"user","installPath":"` + pluginDir + `"}]}}`
+	if err := os.WriteFile(filepath.Join(claudeDir, "plugins", "installed_plugins.json"), []byte(registry), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager(claudeDir, t.TempDir())
+	writeLintFile(t, manager, "commands/deploy.md", "Deploy the app\n", 0644)
+	writeLintFile(t, manager, "commands/test.md", "Run tests\n", 0644)
+
+	findings, err := manager.Lint(CategoryCommands, []string{"*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, `command "deploy" is also provided by plugin tools@market`) {
+		t.Errorf("unexpected findings: %v", findingStrings(findings))
+	}
+	if HasErrors(findings) {
+		t.Error("collisions should be warnings")
+	}
+}
@@ -0,0 +1,189 @@
+// ABOUTME: CLAUDE.md memory fragments stored in the extension library
+// ABOUTME: Reads and writes fragments and edits claudeup-managed blocks inside CLAUDE.md text
+package ext
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MemoryDirName is the library directory holding CLAUDE.md fragments. It is
+// not an extension category: fragments are composed into CLAUDE.md rather
+// than linked into ~/.claude.
+const MemoryDirName = "memory"
+
+const (
+	memoryBeginMarker = "<!-- claudeup:begin %s -->"
+	memoryEndMarker   = "<!-- claudeup:end %s -->"
+)
+
+// MemoryBlock is a claudeup-managed fragment found in a CLAUDE.md file.
+type MemoryBlock struct {
+	Name    string
+	Content string
+}
+
+// MemoryFragmentPath returns the library path of a fragment (<extDir>/memory/<name>.md).
+func MemoryFragmentPath(extDir, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.TrimSpace(name) != name {
+		return "", fmt.Errorf("invalid memory fragment name %q", name)
+	}
+	if err := validateItemPath(name); err != nil {
+		return "", err
+	}
+	return filepath.Join(extDir, MemoryDirName, name+".md"), nil
+}
+
+// ReadMemoryFragment returns a fragment's content without trailing newlines.
+func ReadMemoryFragment(extDir, name string) (string, error) {
+	path, err := MemoryFragmentPath(extDir, name)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("memory fragment %q not found in %s", name, filepath.Dir(path))
+		}
+		return "", fmt.Errorf("reading memory fragment %q: %w", name, err)
+	}
+	return normalizeMemoryContent(string(data)), nil
+}
+
+// WriteMemoryFragment stores a fragment in the library, creating the memory directory as needed.
+func WriteMemoryFragment(extDir, name, content string) error {
+	path, err := MemoryFragmentPath(extDir, name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating memory directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(normalizeMemoryContent(content)+"\n"), 0644); err != nil {
+		return fmt.Errorf("writing memory fragment %q: %w", name, err)
+	}
+	return nil
+}
+
+// ListMemoryFragments returns the names of fragments in the library, sorted.
+func ListMemoryFragments(extDir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(extDir, MemoryDirName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading memory directory: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(entry.Name(), ".md"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ParseMemoryBlocks returns the managed blocks in CLAUDE.md text, in file
+// order. A begin marker without its end marker is treated as user content.
+func ParseMemoryBlocks(text string) []MemoryBlock {
+	lines := strings.Split(text, "\n")
+	var blocks []MemoryBlock
+	for i := 0; i < len(lines); i++ {
+		name, ok := memoryMarkerName(lines[i], memoryBeginMarker)
+		if !ok {
+			continue
+		}
+		end := findMemoryEnd(lines, i+1, name)
+		if end < 0 {
+			continue
+		}
+		blocks = append(blocks, MemoryBlock{
+			Name:    name,
+			Content: normalizeMemoryContent(strings.Join(lines[i+1:end], "\n")),
+		})
+		i = end
+	}
+	return blocks
+}
+
+// SetMemoryBlock replaces the body of the named block in place, or appends
+// the block after the existing text when it is not present yet.
+func SetMemoryBlock(text, name, content string) string {
+	block := []string{fmt.Sprintf(memoryBeginMarker, name)}
+	if body := normalizeMemoryContent(content); body != "" {
+		block = append(block, body)
+	}
+	block = append(block, fmt.Sprintf(memoryEndMarker, name))
+
+	lines := strings.Split(text, "\n")
+	if start, end := findMemoryBlock(lines, name); start >= 0 {
+		result := append([]string{}, lines[:start]...)
+		result = append(result, block...)
+		return strings.Join(append(result, lines[end+1:]...), "\n")
+	}
+
+	existing := strings.TrimRight(text, "\n")
+	if existing == "" {
+		return strings.Join(block, "\n") + "\n"
+	}
+	return existing + "\n\n" + strings.Join(block, "\n") + "\n"
+}
+
+// RemoveMemoryBlock deletes the named block and its markers, along with one
+// blank line separating it from preceding content.
+func RemoveMemoryBlock(text, name string) string {
+	lines := strings.Split(text, "\n")
+	start, end := findMemoryBlock(lines, name)
+	if start < 0 {
+		return text
+	}
+	if start > 0 && strings.TrimSpace(lines[start-1]) == "" {
+		start--
+	}
+	result := append([]string{}, lines[:start]...)
+	return strings.Join(append(result, lines[end+1:]...), "\n")
+}
+
+// findMemoryBlock returns the line indexes of a block's begin and end
+// markers, or -1, -1 when the block is absent or unterminated.
+func findMemoryBlock(lines []string, name string) (int, int) {
+	for i, line := range lines {
+		if found, ok := memoryMarkerName(line, memoryBeginMarker); ok && found == name {
+			if end := findMemoryEnd(lines, i+1, name); end >= 0 {
+				return i, end
+			}
+		}
+	}
+	return -1, -1
+}
+
+func findMemoryEnd(lines []string, from int, name string) int {
+	for j := from; j < len(lines); j++ {
+		if found, ok := memoryMarkerName(lines[j], memoryEndMarker); ok && found == name {
+			return j
+		}
+	}
+	return -1
+}
+
+// memoryMarkerName extracts the fragment name from a begin or end marker line.
+func memoryMarkerName(line, marker string) (string, bool) {
+	prefix, suffix, _ := strings.Cut(marker, "%s")
+	line = strings.TrimSpace(line)
+	if len(line) < len(prefix)+len(suffix) || !strings.HasPrefix(line, prefix) || !strings.HasSuffix(line, suffix) {
+		return "", false
+	}
+	name := strings.TrimSpace(line[len(prefix) : len(line)-len(suffix)])
+	return name, name != ""
+}
+
+func normalizeMemoryContent(content string) string {
+	return strings.Trim(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+}
@@ -0,0 +1,80 @@
+// ABOUTME: Tests for CLAUDE.md memory fragments and managed blocks
+// ABOUTME: Covers library read/write, block parsing, in-place updates, and removal
+package ext
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMemoryFragmentLibrary(t *testing.T) {
+	extDir := t.TempDir()
+
+	if err := WriteMemoryFragment(extDir, "go-style", "# Go\n\nUse gofmt.\n\n"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadMemoryFragment(extDir, "go-style")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "# Go\n\nUse gofmt." {
+		t.Errorf("ReadMemoryFragment = %q", got)
+	}
+
+	names, err := ListMemoryFragments(extDir)
+	if err != nil || !reflect.DeepEqual(names, []string{"go-style"}) {
+		t.Errorf("ListMemoryFragments = %v, %v", names, err)
+	}
+
+	if _, err := ReadMemoryFragment(extDir, "missing"); err == nil {
+		t.Error("expected error for missing fragment")
+	}
+	for _, bad := range []string{"", "../escape", "a/b"} {
+		if err := WriteMemoryFragment(extDir, bad, "x"); err == nil {
+			t.Errorf("expected error for fragment name %q", bad)
+		}
+	}
+}
+
+func TestSetMemoryBlock(t *testing.T) {
+	text := "# My notes\n\nWritten by hand.\n"
+
+	text = SetMemoryBlock(text, "tdd", "Write tests first.")
+	want := "# My notes\n\nWritten by hand.\n\n<!-- claudeup:begin tdd -->\nWrite tests first.\n<!-- claudeup:end tdd -->\n"
+	if text != want {
+		t.Fatalf("append:\n got %q\nwant %q", text, want)
+	}
+
+	// Updating replaces the body in place and keeps surrounding text
+	text = text + "\nMore hand-written notes.\n"
+	text = SetMemoryBlock(text, "tdd", "Write tests first.\nKeep them fast.")
+	want = "# My notes\n\nWritten by hand.\n\n<!-- claudeup:begin tdd -->\nWrite tests first.\nKeep them fast.\n<!-- claudeup:end tdd -->\n\nMore hand-written notes.\n"
+	if text != want {
+		t.Fatalf("update:\n got %q\nwant %q", text, want)
+	}
+
+	if again := SetMemoryBlock(text, "tdd", "Write tests first.\nKeep them fast.\n"); again != text {
+		t.Errorf("setting the same content should be a no-op:\n got %q", again)
+	}
+
+	blocks := ParseMemoryBlocks(text)
+	if !reflect.DeepEqual(blocks, []MemoryBlock{{Name: "tdd", Content: "Write tests first.\nKeep them fast."}}) {
+		t.Errorf("ParseMemoryBlocks = %+v", blocks)
+	}
+
+	text = RemoveMemoryBlock(text, "tdd")
+	if text != "# My notes\n\nWritten by hand.\n\nMore hand-written notes.\n" {
+		t.Errorf("remove:\n got %q", text)
+	}
+}
+
+func TestParseMemoryBlocksIgnoresUnterminated(t *testing.T) {
+	text := "<!-- claudeup:begin a -->\nA\n<!-- claudeup:end a -->\n<!-- claudeup:begin b -->\nno end\n<!-- claudeup:begin -->\n"
+	blocks := ParseMemoryBlocks(text)
+	if !reflect.DeepEqual(blocks, []MemoryBlock{{Name: "a", Content: "A"}}) {
+		t.Errorf("ParseMemoryBlocks = %+v", blocks)
+	}
+	if got := SetMemoryBlock("", "empty", ""); got != "<!-- claudeup:begin empty -->\n<!-- claudeup:end empty -->\n" {
+		t.Errorf("empty block = %q", got)
+	}
+}
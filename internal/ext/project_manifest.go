@@ -0,0 +1,154 @@
+// ABOUTME: Tracks extensions copied into a project's .claude/ directory in .claudeup-ext.json
+// ABOUTME: Compares project copies against the manifest and the extension library to find drift
+package ext
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ProjectManifestFile is written to {projectDir}/.claude/ and is meant to be
+// committed alongside the copied items.
+const ProjectManifestFile = ".claudeup-ext.json"
+
+// ProjectItem records one extension copied into a project.
+type ProjectItem struct {
+	// Source is the item's path in the extension library, as category/item.
+	Source string `json:"source"`
+	// Set is the frontmatter override applied to the copy, if any.
+	Set FrontmatterPatch `json:"set,omitempty"`
+	// Hash is the content hash of the item as copied.
+	Hash     string    `json:"hash"`
+	CopiedAt time.Time `json:"copiedAt"`
+}
+
+// ProjectManifest maps category -> item -> copy record.
+type ProjectManifest map[string]map[string]ProjectItem
+
+func projectManifestPath(projectDir string) string {
+	return filepath.Join(projectDir, ".claude", ProjectManifestFile)
+}
+
+// LoadProjectManifest reads the project's .claudeup-ext.json. A missing file
+// is an empty manifest.
+func LoadProjectManifest(projectDir string) (ProjectManifest, error) {
+	data, err := os.ReadFile(projectManifestPath(projectDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return make(ProjectManifest), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest ProjectManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ProjectManifestFile, err)
+	}
+	if manifest == nil {
+		manifest = make(ProjectManifest)
+	}
+	return manifest, nil
+}
+
+// SaveProjectManifest writes the project's .claudeup-ext.json.
+func SaveProjectManifest(projectDir string, manifest ProjectManifest) error {
+	for category, items := range manifest {
+		if len(items) == 0 {
+			delete(manifest, category)
+		}
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if err := os.MkdirAll(filepath.Join(projectDir, ".claude"), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(projectManifestPath(projectDir), data, 0644)
+}
+
+func (p ProjectManifest) set(category, item string, record ProjectItem) {
+	if p[category] == nil {
+		p[category] = make(map[string]ProjectItem)
+	}
+	p[category][item] = record
+}
+
+// ProjectItemStatus describes how a copied item compares to what was copied
+// and to the current extension library.
+type ProjectItemStatus struct {
+	Category string
+	Item     string
+	// LocallyModified is true when the project copy was edited after copying.
+	LocallyModified bool
+	// Outdated is true when the library item changed after copying.
+	Outdated bool
+	// Deleted is true when the project copy no longer exists.
+	Deleted bool
+	// LibraryMissing is true when the library no longer has the item.
+	LibraryMissing bool
+}
+
+// Current reports whether the copy matches both the manifest and the library.
+func (s ProjectItemStatus) Current() bool {
+	return !s.LocallyModified && !s.Outdated && !s.Deleted && !s.LibraryMissing
+}
+
+// ProjectStatus checks every item recorded in the project's manifest against
+// the project copy and the library in extDir, sorted by category and item.
+func ProjectStatus(extDir, projectDir string) ([]ProjectItemStatus, error) {
+	manifest, err := LoadProjectManifest(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []ProjectItemStatus
+	for category, items := range manifest {
+		for item, record := range items {
+			s := ProjectItemStatus{Category: category, Item: item}
+			if validateItemPath(item) != nil {
+				continue
+			}
+
+			projectHash, err := hashPath(filepath.Join(projectDir, ".claude", category, item))
+			switch {
+			case errors.Is(err, fs.ErrNotExist):
+				s.Deleted = true
+			case err != nil:
+				return nil, fmt.Errorf("%s/%s: %w", category, item, err)
+			default:
+				s.LocallyModified = projectHash != record.Hash
+			}
+
+			var libraryHash string
+			files, err := libraryFiles(filepath.Join(extDir, category, item), record.Set)
+			if err == nil {
+				libraryHash = hashItem(files)
+			}
+			switch {
+			case errors.Is(err, fs.ErrNotExist):
+				s.LibraryMissing = true
+			case err != nil:
+				return nil, fmt.Errorf("%s/%s: %w", category, item, err)
+			default:
+				// A project copy already matching the library is not behind it
+				s.Outdated = libraryHash != record.Hash && libraryHash != projectHash
+			}
+			statuses = append(statuses, s)
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Category != statuses[j].Category {
+			return statuses[i].Category < statuses[j].Category
+		}
+		return statuses[i].Item < statuses[j].Item
+	})
+	return statuses, nil
+}
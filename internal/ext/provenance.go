@@ -0,0 +1,156 @@
+// ABOUTME: Records where installed extensions came from in ext-sources.json
+// ABOUTME: Stores source, fetched ref, and content hash per item so updates can detect local edits
+package ext
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SourcesFile is the provenance manifest, kept next to enabled.json.
+const SourcesFile = "ext-sources.json"
+
+// Provenance records where an installed item came from.
+type Provenance struct {
+	// Source is the spec the item was installed from (see ParseSource).
+	Source string `json:"source"`
+	// Path locates the item within the source; empty when the source is the item.
+	Path string `json:"path,omitempty"`
+	// Ref is the fetched commit or plugin version, when the source has one.
+	Ref string `json:"ref,omitempty"`
+	// Hash is the content hash of the item as installed.
+	Hash        string    `json:"hash"`
+	InstalledAt time.Time `json:"installedAt"`
+}
+
+// SourceManifest maps category -> item -> provenance.
+type SourceManifest map[string]map[string]Provenance
+
+// LoadSources reads ext-sources.json. A missing file is an empty manifest.
+func (m *Manager) LoadSources() (SourceManifest, error) {
+	data, err := os.ReadFile(m.sourcesFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return make(SourceManifest), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest SourceManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", SourcesFile, err)
+	}
+	if manifest == nil {
+		manifest = make(SourceManifest)
+	}
+	return manifest, nil
+}
+
+// SaveSources writes ext-sources.json.
+func (m *Manager) SaveSources(manifest SourceManifest) error {
+	for category, items := range manifest {
+		if len(items) == 0 {
+			delete(manifest, category)
+		}
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	return os.WriteFile(m.sourcesFile, data, 0644)
+}
+
+func (s SourceManifest) set(category, item string, p Provenance) {
+	if s[category] == nil {
+		s[category] = make(map[string]Provenance)
+	}
+	s[category][item] = p
+}
+
+// itemFile is one file of an extension item, held in memory.
+// A single-file item has one itemFile with an empty rel.
+type itemFile struct {
+	rel     string
+	mode    fs.FileMode
+	content []byte
+}
+
+// readItem loads a file or directory item into memory, skipping hidden
+// files such as .git.
+func readItem(root string) ([]itemFile, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		content, err := os.ReadFile(root)
+		if err != nil {
+			return nil, err
+		}
+		return []itemFile{{mode: info.Mode().Perm(), content: content}}, nil
+	}
+
+	var files []itemFile
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root && d.Name()[0] == '.' {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, itemFile{rel: filepath.ToSlash(rel), mode: info.Mode().Perm(), content: content})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].rel < files[j].rel })
+	return files, nil
+}
+
+// hashItem returns a content hash covering every file's path and content.
+func hashItem(files []itemFile) string {
+	h := sha256.New()
+	for _, f := range files {
+		h.Write([]byte(f.rel))
+		h.Write([]byte{0})
+		h.Write(f.content)
+		h.Write([]byte{0})
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// hashPath hashes the item at path.
+func hashPath(path string) (string, error) {
+	files, err := readItem(path)
+	if err != nil {
+		return "", err
+	}
+	return hashItem(files), nil
+}
@@ -0,0 +1,368 @@
+// ABOUTME: Git-backed sync for the extension library across machines
+// ABOUTME: Treats the ext directory as a working tree with push, pull, and per-item conflict reporting
+package ext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// RemoteName is the git remote the library syncs with.
+	RemoteName = "origin"
+	// RemoteBranch is the branch the library is kept on, locally and remotely.
+	RemoteBranch = "main"
+	// syncedConfigFile is where enabled.json is kept inside the library when it syncs.
+	syncedConfigFile = "enabled.json"
+	// syncEnabledKey is a git config key in the library recording whether enabled.json syncs.
+	syncEnabledKey = "claudeup.syncEnabled"
+	// remoteTimeout bounds git operations that talk to the remote.
+	remoteTimeout = 60 * time.Second
+)
+
+// ErrNoRemote is returned when the library has no remote configured.
+var ErrNoRemote = errors.New("no extension remote configured; run 'claudeup extensions remote add <git-url>' first")
+
+// ErrRemoteAhead is returned by Push when the remote has commits the library lacks.
+var ErrRemoteAhead = errors.New("the remote has changes that are not in the local library; run 'claudeup extensions pull' first")
+
+// ConflictError lists the items changed both locally and on the remote.
+// The library is left as it was before the pull.
+type ConflictError struct {
+	Items []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%d item(s) changed both locally and on the remote: %s", len(e.Items), strings.Join(e.Items, ", "))
+}
+
+// RemoteStatus describes the library's remote configuration.
+type RemoteStatus struct {
+	URL         string
+	SyncEnabled bool
+}
+
+// PushResult reports what a push sent.
+type PushResult struct {
+	// Committed lists items with local changes committed before pushing.
+	Committed []string
+	// UpToDate is true when the remote already had everything.
+	UpToDate bool
+}
+
+// PullResult reports what a pull brought in.
+type PullResult struct {
+	// Changed lists items added, modified, or removed by the pull.
+	Changed []string
+	// ConfigUpdated is true when a synced enabled.json replaced the local one.
+	ConfigUpdated bool
+}
+
+// Remote returns the configured remote, or ErrNoRemote.
+func (m *Manager) Remote() (*RemoteStatus, error) {
+	if !m.isRepo() {
+		return nil, ErrNoRemote
+	}
+	url, err := m.git("remote", "get-url", RemoteName)
+	if err != nil {
+		return nil, ErrNoRemote
+	}
+	return &RemoteStatus{URL: url, SyncEnabled: m.syncEnabled()}, nil
+}
+
+// AddRemote makes the library a git working tree (if it is not one already)
+// and points it at url. syncEnabled controls whether enabled.json travels
+// with the library.
+func (m *Manager) AddRemote(url string, syncEnabled bool) error {
+	if strings.TrimSpace(url) == "" || strings.HasPrefix(url, "-") {
+		return fmt.Errorf("invalid remote url %q", url)
+	}
+	if err := os.MkdirAll(m.extDir, 0755); err != nil {
+		return err
+	}
+	if !m.isRepo() {
+		if _, err := m.git("init", "--quiet"); err != nil {
+			return fmt.Errorf("git init failed: %w", err)
+		}
+		if _, err := m.git("symbolic-ref", "HEAD", "refs/heads/"+RemoteBranch); err != nil {
+			return fmt.Errorf("failed to set branch: %w", err)
+		}
+	}
+	if existing, err := m.git("remote", "get-url", RemoteName); err == nil {
+		return fmt.Errorf("remote already configured: %s; remove it first", existing)
+	}
+	if _, err := m.git("remote", "add", RemoteName, url); err != nil {
+		return fmt.Errorf("git remote add failed: %w", err)
+	}
+	if _, err := m.git("config", syncEnabledKey, fmt.Sprintf("%t", syncEnabled)); err != nil {
+		return fmt.Errorf("failed to record sync setting: %w", err)
+	}
+	return nil
+}
+
+// RemoveRemote detaches the library from its remote. The local git history
+// is kept.
+func (m *Manager) RemoveRemote() error {
+	if _, err := m.Remote(); err != nil {
+		return err
+	}
+	if _, err := m.git("remote", "remove", RemoteName); err != nil {
+		return fmt.Errorf("git remote remove failed: %w", err)
+	}
+	_, _ = m.git("config", "--unset", syncEnabledKey)
+	return nil
+}
+
+// Push commits local changes and pushes them. It refuses when the remote
+// has commits the library does not, so a push never discards another
+// machine's work.
+func (m *Manager) Push() (*PushResult, error) {
+	if _, err := m.Remote(); err != nil {
+		return nil, err
+	}
+	committed, err := m.commitLocalChanges()
+	if err != nil {
+		return nil, err
+	}
+	result := &PushResult{Committed: committed}
+	if !m.hasCommits() {
+		result.UpToDate = true
+		return result, nil
+	}
+
+	if err := m.fetchRemote(); err != nil {
+		return nil, err
+	}
+	if remote, ok := m.remoteHead(); ok {
+		if _, err := m.git("merge-base", "--is-ancestor", remote, "HEAD"); err != nil {
+			return nil, ErrRemoteAhead
+		}
+		if head, _ := m.git("rev-parse", "HEAD"); head == remote {
+			result.UpToDate = true
+			return result, nil
+		}
+	}
+
+	if _, err := m.gitRemote("push", "--quiet", RemoteName, "HEAD:refs/heads/"+RemoteBranch); err != nil {
+		return nil, fmt.Errorf("git push failed: %w", err)
+	}
+	return result, nil
+}
+
+// Pull commits local changes, then merges the remote library into them.
+// On conflicts the merge is aborted and a *ConflictError names the items.
+func (m *Manager) Pull() (*PullResult, error) {
+	if _, err := m.Remote(); err != nil {
+		return nil, err
+	}
+	if _, err := m.commitLocalChanges(); err != nil {
+		return nil, err
+	}
+	if err := m.fetchRemote(); err != nil {
+		return nil, err
+	}
+	remote, ok := m.remoteHead()
+	if !ok {
+		return &PullResult{}, nil
+	}
+
+	result := &PullResult{}
+	if !m.hasCommits() {
+		if _, err := m.git("reset", "--quiet", "--hard", remote); err != nil {
+			return nil, fmt.Errorf("failed to check out remote library: %w", err)
+		}
+		files, err := m.git("ls-files")
+		if err != nil {
+			return nil, err
+		}
+		result.Changed = itemsForPaths(splitLines(files))
+	} else {
+		before, err := m.git("rev-parse", "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := m.git(append(m.identityArgs(), "merge", "--quiet", "--no-edit", "--allow-unrelated-histories", remote)...); err != nil {
+			conflicted, _ := m.git("diff", "--name-only", "--diff-filter=U")
+			_, _ = m.git("merge", "--abort")
+			if items := itemsForPaths(splitLines(conflicted)); len(items) > 0 {
+				return nil, &ConflictError{Items: items}
+			}
+			return nil, fmt.Errorf("git merge failed: %w", err)
+		}
+		changed, err := m.git("diff", "--name-only", before, "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		result.Changed = itemsForPaths(splitLines(changed))
+	}
+
+	if m.syncEnabled() {
+		updated, err := m.restoreSyncedConfig()
+		if err != nil {
+			return nil, err
+		}
+		result.ConfigUpdated = updated
+	}
+	return result, nil
+}
+
+// commitLocalChanges stages everything in the library and commits it,
+// returning the items that changed. enabled.json is copied in first when it syncs.
+func (m *Manager) commitLocalChanges() ([]string, error) {
+	if m.syncEnabled() {
+		data, err := os.ReadFile(m.configFile)
+		switch {
+		case err == nil:
+			if err := os.WriteFile(filepath.Join(m.extDir, syncedConfigFile), data, 0644); err != nil {
+				return nil, fmt.Errorf("failed to copy enabled.json into the library: %w", err)
+			}
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		}
+	}
+
+	if _, err := m.git("add", "--all"); err != nil {
+		return nil, fmt.Errorf("git add failed: %w", err)
+	}
+	staged, err := m.git("diff", "--cached", "--name-only")
+	if err != nil {
+		return nil, err
+	}
+	if staged == "" {
+		return nil, nil
+	}
+
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "unknown host"
+	}
+	if _, err := m.git(append(m.identityArgs(), "commit", "--quiet", "-m", "Update extensions from "+host)...); err != nil {
+		return nil, fmt.Errorf("git commit failed: %w", err)
+	}
+	return itemsForPaths(splitLines(staged)), nil
+}
+
+// restoreSyncedConfig copies the library's enabled.json over the local one,
+// reporting whether it changed.
+func (m *Manager) restoreSyncedConfig() (bool, error) {
+	data, err := os.ReadFile(filepath.Join(m.extDir, syncedConfigFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if current, err := os.ReadFile(m.configFile); err == nil && string(current) == string(data) {
+		return false, nil
+	}
+	if err := os.WriteFile(m.configFile, data, 0644); err != nil {
+		return false, fmt.Errorf("failed to update enabled.json: %w", err)
+	}
+	return true, nil
+}
+
+// itemsForPaths maps library file paths to the items they belong to
+// (category/item), so a skill's many files are reported once.
+func itemsForPaths(paths []string) []string {
+	seen := make(map[string]bool)
+	var items []string
+	for _, path := range paths {
+		parts := strings.SplitN(path, "/", 3)
+		item := path
+		if len(parts) >= 2 {
+			item = parts[0] + "/" + parts[1]
+		}
+		if !seen[item] {
+			seen[item] = true
+			items = append(items, item)
+		}
+	}
+	sort.Strings(items)
+	return items
+}
+
+func splitLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func (m *Manager) isRepo() bool {
+	_, err := os.Stat(filepath.Join(m.extDir, ".git"))
+	return err == nil
+}
+
+func (m *Manager) hasCommits() bool {
+	_, err := m.git("rev-parse", "--verify", "--quiet", "HEAD")
+	return err == nil
+}
+
+func (m *Manager) syncEnabled() bool {
+	value, err := m.git("config", "--get", syncEnabledKey)
+	return err == nil && value == "true"
+}
+
+// remoteHead returns the remote branch's commit, if the remote has one.
+func (m *Manager) remoteHead() (string, bool) {
+	commit, err := m.git("rev-parse", "--verify", "--quiet", "refs/remotes/"+RemoteName+"/"+RemoteBranch)
+	return commit, err == nil && commit != ""
+}
+
+func (m *Manager) fetchRemote() error {
+	if _, err := m.gitRemote("fetch", "--quiet", RemoteName); err != nil {
+		return fmt.Errorf("git fetch failed: %w", err)
+	}
+	return nil
+}
+
+// identityArgs supplies a committer identity when git has none configured,
+// so library commits work on fresh machines.
+func (m *Manager) identityArgs() []string {
+	if email, err := m.git("config", "user.email"); err == nil && email != "" {
+		return nil
+	}
+	return []string{"-c", "user.name=claudeup", "-c", "user.email=claudeup@localhost"}
+}
+
+func (m *Manager) git(args ...string) (string, error) {
+	return runGit(context.Background(), m.extDir, args...)
+}
+
+// gitRemote runs a git command that talks to the remote, with a timeout.
+func (m *Manager) gitRemote(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+	output, err := runGit(ctx, m.extDir, args...)
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("timed out after %s", remoteTimeout)
+	}
+	return output, err
+}
+
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	// Never stop to ask for credentials; fail so the caller can report it
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,237 @@
+// ABOUTME: Tests for syncing the extension library through a git remote
+// ABOUTME: Uses a local bare repository shared by two managers standing in for two machines
+package ext
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// isolateGit hides the user's git configuration so tests exercise the
+// fallback identity and never sign commits.
+func isolateGit(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	global := filepath.Join(t.TempDir(), "gitconfig")
+	if err := os.WriteFile(global, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", global)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+}
+
+func newBareRemote(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "library.git")
+	if _, err := runGit(context.Background(), t.TempDir(), "init", "--quiet", "--bare", dir); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func newMachine(t *testing.T, remote string, syncEnabled bool) *Manager {
+	t.Helper()
+	manager := NewManager(t.TempDir(), t.TempDir())
+	if err := manager.AddRemote(remote, syncEnabled); err != nil {
+		t.Fatalf("AddRemote failed: %v", err)
+	}
+	return manager
+}
+
+func writeExtFile(t *testing.T, m *Manager, rel, content string) {
+	t.Helper()
+	path := filepath.Join(m.extDir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoteRoundTrip(t *testing.T) {
+	isolateGit(t)
+	remote := newBareRemote(t)
+	laptop := newMachine(t, remote, false)
+	desktop := newMachine(t, remote, false)
+
+	writeExtFile(t, laptop, "agents/reviewer.md", "# Reviewer")
+	writeExtFile(t, laptop, "skills/deploy/SKILL.md", "# Deploy")
+	writeExtFile(t, laptop, "skills/deploy/notes.md", "notes")
+
+	pushed, err := laptop.Push()
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if want := []string{"agents/reviewer.md", "skills/deploy"}; !reflect.DeepEqual(pushed.Committed, want) {
+		t.Errorf("Committed = %v, want %v", pushed.Committed, want)
+	}
+
+	pulled, err := desktop.Pull()
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if want := []string{"agents/reviewer.md", "skills/deploy"}; !reflect.DeepEqual(pulled.Changed, want) {
+		t.Errorf("Changed = %v, want %v", pulled.Changed, want)
+	}
+	if _, err := os.Stat(filepath.Join(desktop.extDir, "skills", "deploy", "notes.md")); err != nil {
+		t.Errorf("pulled skill missing: %v", err)
+	}
+
+	// Independent changes to different items merge cleanly
+	writeExtFile(t, desktop, "rules/style.md", "# Style")
+	if _, err := desktop.Push(); err != nil {
+		t.Fatalf("desktop Push failed: %v", err)
+	}
+	writeExtFile(t, laptop, "agents/planner.md", "# Planner")
+	if _, err := laptop.Push(); !errors.Is(err, ErrRemoteAhead) {
+		t.Fatalf("expected ErrRemoteAhead, got %v", err)
+	}
+	pulled, err = laptop.Pull()
+	if err != nil {
+		t.Fatalf("laptop Pull failed: %v", err)
+	}
+	if want := []string{"rules/style.md"}; !reflect.DeepEqual(pulled.Changed, want) {
+		t.Errorf("Changed = %v, want %v", pulled.Changed, want)
+	}
+	if _, err := laptop.Push(); err != nil {
+		t.Fatalf("Push after pull failed: %v", err)
+	}
+
+	again, err := laptop.Push()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.UpToDate || len(again.Committed) != 0 {
+		t.Errorf("expected an up-to-date push, got %+v", again)
+	}
+}
+
+func TestPullReportsConflictsPerItem(t *testing.T) {
+	isolateGit(t)
+	remote := newBareRemote(t)
+	laptop := newMachine(t, remote, false)
+	desktop := newMachine(t, remote, false)
+
+	writeExtFile(t, laptop, "agents/reviewer.md", "# Reviewer\n")
+	writeExtFile(t, laptop, "skills/deploy/SKILL.md", "# Deploy\n")
+	if _, err := laptop.Push(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := desktop.Pull(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeExtFile(t, laptop, "agents/reviewer.md", "# Reviewer from laptop\n")
+	writeExtFile(t, laptop, "skills/deploy/SKILL.md", "# Deploy from laptop\n")
+	if _, err := laptop.Push(); err != nil {
+		t.Fatal(err)
+	}
+	writeExtFile(t, desktop, "agents/reviewer.md", "# Reviewer from desktop\n")
+	writeExtFile(t, desktop, "skills/deploy/SKILL.md", "# Deploy from desktop\n")
+
+	_, err := desktop.Pull()
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected ConflictError, got %v", err)
+	}
+	if want := []string{"agents/reviewer.md", "skills/deploy"}; !reflect.DeepEqual(conflict.Items, want) {
+		t.Errorf("conflict items = %v, want %v", conflict.Items, want)
+	}
+
+	// The merge is aborted, leaving the local version in place
+	data, _ := os.ReadFile(filepath.Join(desktop.extDir, "agents", "reviewer.md"))
+	if string(data) != "# Reviewer from desktop\n" {
+		t.Errorf("local item should be untouched after a conflict, got %q", data)
+	}
+	if status, _ := desktop.git("status", "--porcelain"); status != "" {
+		t.Errorf("working tree should be clean after aborting, got:\n%s", status)
+	}
+}
+
+func TestPullSyncsEnabledConfig(t *testing.T) {
+	isolateGit(t)
+	remote := newBareRemote(t)
+	laptop := newMachine(t, remote, true)
+	desktop := newMachine(t, remote, true)
+	private := newMachine(t, remote, false)
+
+	writeExtFile(t, laptop, "agents/reviewer.md", "# Reviewer")
+	if err := os.WriteFile(laptop.configFile, []byte(`{"agents":{"reviewer.md":true}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := laptop.Push(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := desktop.Pull()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.ConfigUpdated {
+		t.Error("expected enabled.json to be updated")
+	}
+	config, err := desktop.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config["agents"]["reviewer.md"] {
+		t.Errorf("synced config not applied: %v", config)
+	}
+
+	// A machine that opted out keeps its own enabled.json
+	result, err = private.Pull()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ConfigUpdated {
+		t.Error("enabled.json should not sync when disabled")
+	}
+	if _, err := os.Stat(private.configFile); !os.IsNotExist(err) {
+		t.Errorf("enabled.json should not be written, stat err = %v", err)
+	}
+}
+
+func TestRemoteConfiguration(t *testing.T) {
+	isolateGit(t)
+	manager := NewManager(t.TempDir(), t.TempDir())
+
+	if _, err := manager.Remote(); !errors.Is(err, ErrNoRemote) {
+		t.Errorf("expected ErrNoRemote, got %v", err)
+	}
+	if _, err := manager.Push(); !errors.Is(err, ErrNoRemote) {
+		t.Errorf("Push without remote: expected ErrNoRemote, got %v", err)
+	}
+	if err := manager.AddRemote("--upload-pack=evil", false); err == nil {
+		t.Error("expected option-like url to be rejected")
+	}
+
+	if err := manager.AddRemote("https://example.com/library.git", true); err != nil {
+		t.Fatal(err)
+	}
+	status, err := manager.Remote()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.URL != "https://example.com/library.git" || !status.SyncEnabled {
+		t.Errorf("unexpected remote status: %+v", status)
+	}
+	if err := manager.AddRemote("https://example.com/other.git", false); err == nil || !strings.Contains(err.Error(), "already configured") {
+		t.Errorf("expected already configured error, got %v", err)
+	}
+
+	if err := manager.RemoveRemote(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.Remote(); !errors.Is(err, ErrNoRemote) {
+		t.Errorf("expected ErrNoRemote after removal, got %v", err)
+	}
+}
@@ -0,0 +1,287 @@
+// ABOUTME: Parses and fetches extension install sources (paths, GitHub repos, URLs, plugin caches)
+// ABOUTME: Fetching materializes a source as a local directory or file for Install and Update
+package ext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+)
+
+// Source kinds accepted by Install.
+const (
+	SourceLocal  = "local"
+	SourceGitHub = "github"
+	SourceURL    = "url"
+	SourcePlugin = "plugin"
+)
+
+const (
+	githubPrefix = "github:"
+	pluginPrefix = "plugin:"
+	// maxDownloadSize bounds a file fetched from a URL.
+	maxDownloadSize = 10 << 20
+	// downloadTimeout bounds fetching a file from a URL.
+	downloadTimeout = 30 * time.Second
+)
+
+var githubRepoPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+// githubCloneURL returns the clone URL for an owner/repo. Tests point it at
+// local repositories.
+var githubCloneURL = func(repo string) string {
+	return "https://github.com/" + repo + ".git"
+}
+
+// httpClient fetches URL sources. Tests replace it with a TLS test client.
+var httpClient = &http.Client{Timeout: downloadTimeout}
+
+// Source is where extension items are installed from.
+//
+//	~/code/agents                       local path
+//	github:owner/repo//agents@v1.2      GitHub repository, optional subpath and ref
+//	https://example.com/reviewer.md     single file
+//	plugin:tools@marketplace//agents    installed plugin's cache directory
+type Source struct {
+	Kind string
+	// Location is the absolute path, owner/repo, URL, or plugin name.
+	Location string
+	// Path is a subpath within a repository or plugin.
+	Path string
+	// Ref is the requested branch, tag, or commit (GitHub only).
+	Ref string
+}
+
+// ParseSource parses an install source. Anything that is not a
+// github:, https://, or plugin: spec is treated as a local path.
+func ParseSource(spec string) (Source, error) {
+	switch {
+	case strings.HasPrefix(spec, githubPrefix):
+		rest := strings.TrimPrefix(spec, githubPrefix)
+		src := Source{Kind: SourceGitHub}
+		if i := strings.LastIndex(rest, "@"); i >= 0 {
+			rest, src.Ref = rest[:i], rest[i+1:]
+			if src.Ref == "" {
+				return Source{}, fmt.Errorf("invalid source %q: empty ref after @", spec)
+			}
+		}
+		src.Location, src.Path, _ = strings.Cut(rest, "//")
+		if !githubRepoPattern.MatchString(src.Location) {
+			return Source{}, fmt.Errorf("invalid source %q: expected github:owner/repo[//path][@ref]", spec)
+		}
+		if err := validateSubpath(spec, src.Path); err != nil {
+			return Source{}, err
+		}
+		return src, nil
+
+	case strings.HasPrefix(spec, pluginPrefix):
+		rest := strings.TrimPrefix(spec, pluginPrefix)
+		src := Source{Kind: SourcePlugin}
+		src.Location, src.Path, _ = strings.Cut(rest, "//")
+		if name, market, ok := strings.Cut(src.Location, "@"); !ok || name == "" || market == "" {
+			return Source{}, fmt.Errorf("invalid source %q: expected plugin:name@marketplace[//path]", spec)
+		}
+		if err := validateSubpath(spec, src.Path); err != nil {
+			return Source{}, err
+		}
+		return src, nil
+
+	case strings.HasPrefix(spec, "https://"):
+		u, err := url.Parse(spec)
+		if err != nil || u.Host == "" {
+			return Source{}, fmt.Errorf("invalid source %q: %v", spec, err)
+		}
+		if name := path.Base(u.Path); name == "" || name == "/" || name == "." {
+			return Source{}, fmt.Errorf("invalid source %q: URL must name a file", spec)
+		}
+		return Source{Kind: SourceURL, Location: spec}, nil
+
+	case strings.HasPrefix(spec, "http://"):
+		return Source{}, fmt.Errorf("invalid source %q: only https:// URLs are supported", spec)
+	}
+
+	abs, err := filepath.Abs(spec)
+	if err != nil {
+		return Source{}, err
+	}
+	return Source{Kind: SourceLocal, Location: abs}, nil
+}
+
+func validateSubpath(spec, subpath string) error {
+	if subpath == "" {
+		return nil
+	}
+	if !filepath.IsLocal(filepath.FromSlash(subpath)) {
+		return fmt.Errorf("invalid source %q: path %q escapes the source", spec, subpath)
+	}
+	return nil
+}
+
+// String returns the canonical spec, which ParseSource reads back.
+func (s Source) String() string {
+	switch s.Kind {
+	case SourceGitHub:
+		spec := githubPrefix + s.Location
+		if s.Path != "" {
+			spec += "//" + s.Path
+		}
+		if s.Ref != "" {
+			spec += "@" + s.Ref
+		}
+		return spec
+	case SourcePlugin:
+		spec := pluginPrefix + s.Location
+		if s.Path != "" {
+			spec += "//" + s.Path
+		}
+		return spec
+	}
+	return s.Location
+}
+
+// fetched is a source materialized on disk.
+type fetched struct {
+	// root is the file or directory to install from.
+	root string
+	// ref identifies the fetched revision: a commit or plugin version.
+	ref string
+	// local is set for a path the user chose. Other sources are not
+	// trusted to contain symlinks.
+	local   bool
+	cleanup func()
+}
+
+// fetch materializes src locally. category picks the default subpath of a
+// plugin, whose items live in directories named after their category.
+func (m *Manager) fetch(src Source, category string) (*fetched, error) {
+	switch src.Kind {
+	case SourceGitHub:
+		return fetchGitHub(src)
+	case SourceURL:
+		return fetchURL(src)
+	case SourcePlugin:
+		return m.fetchPlugin(src, category)
+	}
+	if _, err := os.Stat(src.Location); err != nil {
+		return nil, fmt.Errorf("source not found: %s", src.Location)
+	}
+	return &fetched{root: src.Location, local: true, cleanup: func() {}}, nil
+}
+
+func fetchGitHub(src Source) (*fetched, error) {
+	tmp, err := os.MkdirTemp("", "claudeup-ext-*")
+	if err != nil {
+		return nil, err
+	}
+	cleanup := func() { os.RemoveAll(tmp) }
+
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+	cloneURL := githubCloneURL(src.Location)
+	if src.Ref == "" {
+		_, err = runGit(ctx, tmp, "clone", "--quiet", "--depth", "1", "--", cloneURL, ".")
+	} else {
+		// fetch accepts branches, tags, and commits alike; clone --branch
+		// does not take commits
+		if _, err = runGit(ctx, tmp, "init", "--quiet"); err == nil {
+			if _, err = runGit(ctx, tmp, "fetch", "--quiet", "--depth", "1", "--", cloneURL, src.Ref); err == nil {
+				_, err = runGit(ctx, tmp, "checkout", "--quiet", "FETCH_HEAD")
+			}
+		}
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", remoteTimeout)
+	}
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to fetch %s: %w", src, err)
+	}
+
+	commit, err := runGit(context.Background(), tmp, "rev-parse", "HEAD")
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	root := filepath.Join(tmp, filepath.FromSlash(src.Path))
+	if _, err := os.Stat(root); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("%s not found in %s", src.Path, src.Location)
+	}
+	return &fetched{root: root, ref: commit, cleanup: cleanup}, nil
+}
+
+func fetchURL(src Source) (*fetched, error) {
+	resp, err := httpClient.Get(src.Location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", src.Location, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", src.Location, resp.Status)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", src.Location, err)
+	}
+	if len(content) > maxDownloadSize {
+		return nil, fmt.Errorf("%s is larger than %d MB", src.Location, maxDownloadSize>>20)
+	}
+
+	tmp, err := os.MkdirTemp("", "claudeup-ext-*")
+	if err != nil {
+		return nil, err
+	}
+	u, _ := url.Parse(src.Location)
+	root := filepath.Join(tmp, path.Base(u.Path))
+	if err := os.WriteFile(root, content, 0644); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	return &fetched{root: root, cleanup: func() { os.RemoveAll(tmp) }}, nil
+}
+
+func (m *Manager) fetchPlugin(src Source, category string) (*fetched, error) {
+	registry, err := claude.LoadPlugins(m.claudeDir)
+	if err != nil {
+		return nil, err
+	}
+	instances := registry.GetPluginInstances(src.Location)
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("plugin %s is not installed", src.Location)
+	}
+	instance := instances[0]
+	if user, ok := registry.GetPluginAtScope(src.Location, "user"); ok {
+		instance = user
+	}
+	if instance.InstallPath == "" {
+		return nil, fmt.Errorf("plugin %s has no install path", src.Location)
+	}
+
+	subpath := src.Path
+	if subpath == "" {
+		subpath = category
+	}
+	root := filepath.Join(instance.InstallPath, filepath.FromSlash(subpath))
+	if _, err := os.Stat(root); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("plugin %s has no %s", src.Location, subpath)
+	} else if err != nil {
+		return nil, err
+	}
+
+	ref := instance.GitCommitSha
+	if ref == "" {
+		ref = instance.Version
+	}
+	return &fetched{root: root, ref: ref, cleanup: func() {}}, nil
+}
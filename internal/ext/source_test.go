@@ -0,0 +1,67 @@
+// ABOUTME: Tests for parsing extension install sources
+// ABOUTME: Covers github:, https://, plugin: specs, local paths, and rejected inputs
+package ext
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Source
+	}{
+		{"github:owner/repo", Source{Kind: SourceGitHub, Location: "owner/repo"}},
+		{"github:owner/repo//agents/reviewer.md@v1.2", Source{Kind: SourceGitHub, Location: "owner/repo", Path: "agents/reviewer.md", Ref: "v1.2"}},
+		{"github:owner/repo@main", Source{Kind: SourceGitHub, Location: "owner/repo", Ref: "main"}},
+		{"https://example.com/a/reviewer.md", Source{Kind: SourceURL, Location: "https://example.com/a/reviewer.md"}},
+		{"plugin:tools@market", Source{Kind: SourcePlugin, Location: "tools@market"}},
+		{"plugin:tools@market//agents/x.md", Source{Kind: SourcePlugin, Location: "tools@market", Path: "agents/x.md"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseSource(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseSource(%q) error = %v", tt.spec, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSource(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+			if got.String() != tt.spec {
+				t.Errorf("String() = %q, want %q", got.String(), tt.spec)
+			}
+		})
+	}
+
+	local, err := ParseSource("some/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if local.Kind != SourceLocal || !filepath.IsAbs(local.Location) {
+		t.Errorf("expected an absolute local path, got %+v", local)
+	}
+}
+
+func TestParseSourceRejects(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr string
+	}{
+		{"github:owner", "expected github:owner/repo"},
+		{"github:owner/repo@", "empty ref"},
+		{"github:owner/repo//../escape", "escapes the source"},
+		{"plugin:tools", "expected plugin:name@marketplace"},
+		{"https://example.com/", "must name a file"},
+		{"http://example.com/a.md", "only https://"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := ParseSource(tt.spec)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseSource(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+			}
+		})
+	}
+}
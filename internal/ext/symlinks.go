@@ -251,15 +251,24 @@ func (m *Manager) syncCategory(category string, config Config) ([]string, error)
 		catConfig = make(map[string]bool)
 	}
 
+	// Variants are rewritten from the library on every sync
+	variants, err := m.LoadVariants()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(filepath.Join(m.variantsDir, category)); err != nil {
+		return nil, err
+	}
+
 	if category == CategoryAgents {
-		return m.syncAgents(targetDir, catConfig)
+		return m.syncAgents(targetDir, catConfig, variants[category])
 	}
 
-	return m.syncFlatCategory(category, targetDir, catConfig)
+	return m.syncFlatCategory(category, targetDir, catConfig, variants[category])
 }
 
 // syncFlatCategory syncs non-agent categories. Returns skipped item names (bare, without category prefix).
-func (m *Manager) syncFlatCategory(category string, targetDir string, catConfig map[string]bool) ([]string, error) {
+func (m *Manager) syncFlatCategory(category string, targetDir string, catConfig map[string]bool, patches map[string]FrontmatterPatch) ([]string, error) {
 	// Validate all items before making any changes (fail fast)
 	for item, enabled := range catConfig {
 		if !enabled {
@@ -289,6 +298,11 @@ func (m *Manager) syncFlatCategory(category string, targetDir string, catConfig
 			return skipped, fmt.Errorf("checking source %s: %w", source, err)
 		}
 
+		source, err := m.linkSource(category, item, patches)
+		if err != nil {
+			return skipped, err
+		}
+
 		target := filepath.Join(targetDir, item)
 
 		// For nested items (e.g., gsd/new-project.md), create parent directories
@@ -331,7 +345,7 @@ func (m *Manager) cleanupSymlinksRecursive(dir string) {
 }
 
 // syncAgents syncs the agents category (supports grouped agents). Returns skipped item names (bare, without category prefix).
-func (m *Manager) syncAgents(targetDir string, catConfig map[string]bool) ([]string, error) {
+func (m *Manager) syncAgents(targetDir string, catConfig map[string]bool, patches map[string]FrontmatterPatch) ([]string, error) {
 	// Validate all items before making any changes (fail fast)
 	for item, enabled := range catConfig {
 		if !enabled {
@@ -397,6 +411,11 @@ func (m *Manager) syncAgents(targetDir string, catConfig map[string]bool) ([]str
 				return skipped, err
 			}
 
+			source, err := m.linkSource(CategoryAgents, item, patches)
+			if err != nil {
+				return skipped, err
+			}
+
 			target := filepath.Join(groupTargetDir, agent)
 			if err := createOrVerifySymlink(source, target); err != nil {
 				return skipped, err
@@ -412,6 +431,11 @@ func (m *Manager) syncAgents(targetDir string, catConfig map[string]bool) ([]str
 				return skipped, fmt.Errorf("checking source %s: %w", source, err)
 			}
 
+			source, err := m.linkSource(CategoryAgents, item, patches)
+			if err != nil {
+				return skipped, err
+			}
+
 			target := filepath.Join(targetDir, item)
 			if err := createOrVerifySymlink(source, target); err != nil {
 				return skipped, err
@@ -77,7 +77,29 @@ func (m *Manager) Uninstall(category string, patterns []string) ([]string, []str
 			return nil, nil, err
 		}
 		notFound = append(notFound, skippedSync...)
+		if err := m.forgetProvenance(category, removed); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	return removed, notFound, nil
 }
+
+// forgetProvenance drops the recorded sources of removed items.
+func (m *Manager) forgetProvenance(category string, items []string) error {
+	manifest, err := m.LoadSources()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, item := range items {
+		if _, ok := manifest[category][item]; ok {
+			delete(manifest[category], item)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return m.SaveSources(manifest)
+}
@@ -0,0 +1,323 @@
+// ABOUTME: Refreshes installed extensions from the sources recorded in ext-sources.json
+// ABOUTME: Plans per-item updates, detects local edits, and diffs them against upstream
+package ext
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Update statuses for an installed item.
+const (
+	// UpdateCurrent: upstream has not changed since install.
+	UpdateCurrent = "current"
+	// UpdateAvailable: upstream changed and the item has no local edits.
+	UpdateAvailable = "available"
+	// UpdateModified: upstream changed and the item was also edited locally.
+	UpdateModified = "modified"
+	// UpdateMissing: the item was deleted from extension storage.
+	UpdateMissing = "missing"
+	// UpdateFailed: the source could not be fetched.
+	UpdateFailed = "failed"
+)
+
+// ItemUpdate describes refreshing one installed item from its source.
+type ItemUpdate struct {
+	Category   string
+	Item       string
+	Provenance Provenance
+	Status     string
+	// LocallyModified is true when the item differs from what was installed.
+	LocallyModified bool
+	// NewRef is the upstream revision, when the source has one.
+	NewRef string
+	// Diff lists line changes from the local item to upstream, for modified items.
+	Diff []string
+	Err  error
+
+	files []itemFile
+	hash  string
+}
+
+// PlanUpdate fetches the source of every installed item in category
+// (all categories when empty) matching patterns (all items when none),
+// and reports what updating each would do. Nothing is written.
+func (m *Manager) PlanUpdate(category string, patterns []string) ([]ItemUpdate, error) {
+	if category != "" {
+		if err := ValidateCategory(category); err != nil {
+			return nil, err
+		}
+	}
+	manifest, err := m.LoadSources()
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []ItemUpdate
+	for _, cat := range AllCategories() {
+		if category != "" && cat != category {
+			continue
+		}
+		var names []string
+		for item := range manifest[cat] {
+			names = append(names, item)
+		}
+		selected := names
+		if len(patterns) > 0 {
+			seen := make(map[string]bool)
+			selected = nil
+			for _, pattern := range patterns {
+				for _, item := range MatchWildcard(pattern, names) {
+					if !seen[item] {
+						seen[item] = true
+						selected = append(selected, item)
+					}
+				}
+			}
+		}
+		sort.Strings(selected)
+		for _, item := range selected {
+			updates = append(updates, ItemUpdate{Category: cat, Item: item, Provenance: manifest[cat][item]})
+		}
+	}
+
+	// Fetch each source once, however many items came from it
+	cache := make(map[string]*fetched)
+	fetchErrs := make(map[string]error)
+	defer func() {
+		for _, f := range cache {
+			f.cleanup()
+		}
+	}()
+
+	for i := range updates {
+		u := &updates[i]
+		key := u.Category + "\x00" + u.Provenance.Source
+		f, fetchErr := cache[key], fetchErrs[key]
+		if f == nil && fetchErr == nil {
+			src, err := ParseSource(u.Provenance.Source)
+			if err == nil {
+				f, err = m.fetch(src, u.Category)
+			}
+			if err != nil {
+				fetchErrs[key], fetchErr = err, err
+			} else {
+				cache[key] = f
+			}
+		}
+		if fetchErr != nil {
+			u.Status, u.Err = UpdateFailed, fetchErr
+			continue
+		}
+		m.planItem(u, f)
+	}
+	return updates, nil
+}
+
+func (m *Manager) planItem(u *ItemUpdate, f *fetched) {
+	u.NewRef = f.ref
+	rel := filepath.FromSlash(u.Provenance.Path)
+	if rel != "" && !filepath.IsLocal(rel) {
+		u.Status, u.Err = UpdateFailed, fmt.Errorf("invalid path %q recorded for %s", u.Provenance.Path, u.Item)
+		return
+	}
+	upstreamPath := filepath.Join(f.root, rel)
+	if !f.local {
+		if err := checkNoSymlinks(upstreamPath); err != nil {
+			u.Status, u.Err = UpdateFailed, err
+			return
+		}
+	}
+	upstream, err := readItem(upstreamPath)
+	if err != nil {
+		u.Status, u.Err = UpdateFailed, fmt.Errorf("%s no longer has %s: %w", u.Provenance.Source, u.Item, err)
+		return
+	}
+	u.files, u.hash = upstream, hashItem(upstream)
+
+	local, err := readItem(filepath.Join(m.extDir, u.Category, u.Item))
+	if errors.Is(err, fs.ErrNotExist) {
+		u.Status = UpdateMissing
+		return
+	}
+	if err != nil {
+		u.Status, u.Err = UpdateFailed, err
+		return
+	}
+	localHash := hashItem(local)
+	u.LocallyModified = localHash != u.Provenance.Hash
+
+	switch {
+	case u.hash == u.Provenance.Hash || u.hash == localHash:
+		u.Status = UpdateCurrent
+	case u.LocallyModified:
+		u.Status = UpdateModified
+		u.Diff = diffItems(local, upstream)
+	default:
+		u.Status = UpdateAvailable
+	}
+}
+
+// ApplyUpdate replaces the item with its upstream content and records the
+// new revision. Locally edited items are overwritten, so callers should
+// confirm UpdateModified items first.
+func (m *Manager) ApplyUpdate(u ItemUpdate) error {
+	if u.files == nil {
+		return fmt.Errorf("%s/%s has no update to apply", u.Category, u.Item)
+	}
+	if err := validateItemPath(u.Item); err != nil {
+		return err
+	}
+	dest := filepath.Join(m.extDir, u.Category, u.Item)
+	if err := replaceItem(dest, u.files); err != nil {
+		return fmt.Errorf("failed to update %s/%s: %w", u.Category, u.Item, err)
+	}
+
+	manifest, err := m.LoadSources()
+	if err != nil {
+		return err
+	}
+	p := u.Provenance
+	p.Ref, p.Hash, p.InstalledAt = u.NewRef, u.hash, time.Now().UTC()
+	manifest.set(u.Category, u.Item, p)
+	return m.SaveSources(manifest)
+}
+
+// replaceItem writes files to a sibling of dest and swaps it into place, so
+// an interrupted update never leaves a half-written item.
+func replaceItem(dest string, files []itemFile) error {
+	parent := filepath.Dir(dest)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return err
+	}
+	staging, err := os.MkdirTemp(parent, "."+filepath.Base(dest)+".update-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	newPath := filepath.Join(staging, "item")
+	for _, f := range files {
+		path := newPath
+		if f.rel != "" {
+			path = filepath.Join(newPath, filepath.FromSlash(f.rel))
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		mode := f.mode
+		if mode == 0 {
+			mode = 0644
+		}
+		if err := os.WriteFile(path, f.content, mode); err != nil {
+			return err
+		}
+	}
+
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	return os.Rename(newPath, dest)
+}
+
+// diffItems lists per-file line changes from local to upstream.
+func diffItems(local, upstream []itemFile) []string {
+	byRel := func(files []itemFile) map[string][]byte {
+		m := make(map[string][]byte, len(files))
+		for _, f := range files {
+			m[f.rel] = f.content
+		}
+		return m
+	}
+	before, after := byRel(local), byRel(upstream)
+	rels := make(map[string]bool)
+	for rel := range before {
+		rels[rel] = true
+	}
+	for rel := range after {
+		rels[rel] = true
+	}
+	var sorted []string
+	for rel := range rels {
+		sorted = append(sorted, rel)
+	}
+	sort.Strings(sorted)
+
+	var out []string
+	for _, rel := range sorted {
+		old, hadOld := before[rel]
+		updated, hasNew := after[rel]
+		if hadOld && hasNew && string(old) == string(updated) {
+			continue
+		}
+		if rel != "" {
+			switch {
+			case !hadOld:
+				out = append(out, "added "+rel)
+			case !hasNew:
+				out = append(out, "removed "+rel)
+			default:
+				out = append(out, "changed "+rel)
+			}
+		}
+		out = append(out, diffLines(string(old), string(updated))...)
+	}
+	return out
+}
+
+// maxDiffLines bounds the size of files diffed line by line.
+const maxDiffLines = 2000
+
+// diffLines returns "-"/"+" lines turning before into after, using the
+// longest common subsequence of lines.
+func diffLines(before, after string) []string {
+	a, b := splitContent(before), splitContent(after)
+	if len(a) > maxDiffLines || len(b) > maxDiffLines {
+		return []string{fmt.Sprintf("~ %d lines -> %d lines (too large to diff)", len(a), len(b))}
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, "- "+a[i])
+			i++
+		default:
+			out = append(out, "+ "+b[j])
+			j++
+		}
+	}
+	return out
+}
+
+func splitContent(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,294 @@
+// ABOUTME: Tests for installing extensions from remote sources and updating them
+// ABOUTME: Uses local git repos, a TLS test server, and a fake plugin cache as origins
+package ext
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newOriginRepo creates a git repository standing in for github:owner/repo.
+func newOriginRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	isolateGit(t)
+	dir := t.TempDir()
+	if _, err := runGit(context.Background(), dir, "init", "--quiet"); err != nil {
+		t.Fatal(err)
+	}
+	commitOrigin(t, dir, files)
+
+	original := githubCloneURL
+	githubCloneURL = func(repo string) string {
+		if repo != "owner/repo" {
+			t.Fatalf("unexpected repo %s", repo)
+		}
+		return dir
+	}
+	t.Cleanup(func() { githubCloneURL = original })
+	return dir
+}
+
+func commitOrigin(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"add", "--all"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "update"},
+	} {
+		if _, err := runGit(context.Background(), dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInstallFromGitHubAndUpdate(t *testing.T) {
+	origin := newOriginRepo(t, map[string]string{
+		"rules/reviewer.md": "# Reviewer\nBe thorough.\n",
+		"rules/planner.md":  "# Planner\n",
+	})
+	manager := NewManager(t.TempDir(), t.TempDir())
+
+	installed, _, err := manager.Install(CategoryRules, "github:owner/repo//rules")
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if strings.Join(installed, ",") != "planner.md,reviewer.md" {
+		t.Errorf("installed = %v", installed)
+	}
+
+	sources, err := manager.LoadSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := sources[CategoryRules]["reviewer.md"]
+	head, _ := runGit(context.Background(), origin, "rev-parse", "HEAD")
+	if p.Source != "github:owner/repo//rules" || p.Path != "reviewer.md" || p.Ref != head || !strings.HasPrefix(p.Hash, "sha256:") {
+		t.Errorf("unexpected provenance: %+v", p)
+	}
+
+	updates, err := manager.PlanUpdate("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range updates {
+		if u.Status != UpdateCurrent {
+			t.Errorf("%s: status = %s, want current", u.Item, u.Status)
+		}
+	}
+
+	// Upstream changes to an untouched item apply cleanly
+	commitOrigin(t, origin, map[string]string{"rules/reviewer.md": "# Reviewer\nBe very thorough.\n"})
+	updates, err = manager.PlanUpdate(CategoryRules, []string{"reviewer.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 1 || updates[0].Status != UpdateAvailable {
+		t.Fatalf("expected one available update, got %+v", updates)
+	}
+	if err := manager.ApplyUpdate(updates[0]); err != nil {
+		t.Fatalf("ApplyUpdate failed: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(manager.extDir, "rules", "reviewer.md"))
+	if !strings.Contains(string(data), "very thorough") {
+		t.Errorf("item not updated: %s", data)
+	}
+	sources, _ = manager.LoadSources()
+	newHead, _ := runGit(context.Background(), origin, "rev-parse", "HEAD")
+	if sources[CategoryRules]["reviewer.md"].Ref != newHead {
+		t.Errorf("ref not recorded after update: %+v", sources[CategoryRules]["reviewer.md"])
+	}
+
+	// Local edits are reported with a diff against upstream
+	if err := os.WriteFile(filepath.Join(manager.extDir, "rules", "reviewer.md"), []byte("# Reviewer\nMy own rules.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	commitOrigin(t, origin, map[string]string{"rules/reviewer.md": "# Reviewer\nUpstream rules.\n"})
+	updates, err = manager.PlanUpdate(CategoryRules, []string{"reviewer.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := updates[0]
+	if u.Status != UpdateModified || !u.LocallyModified {
+		t.Fatalf("expected modified status, got %+v", u)
+	}
+	if got := strings.Join(u.Diff, "\n"); got != "- My own rules.\n+ Upstream rules." {
+		t.Errorf("unexpected diff:\n%s", got)
+	}
+}
+
+func TestInstallFromGitHubRef(t *testing.T) {
+	origin := newOriginRepo(t, map[string]string{"rules/style.md": "v1\n"})
+	first, _ := runGit(context.Background(), origin, "rev-parse", "HEAD")
+	commitOrigin(t, origin, map[string]string{"rules/style.md": "v2\n"})
+
+	manager := NewManager(t.TempDir(), t.TempDir())
+	if _, _, err := manager.Install(CategoryRules, "github:owner/repo//rules/style.md@"+first); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(manager.extDir, "rules", "style.md"))
+	if string(data) != "v1\n" {
+		t.Errorf("expected pinned content, got %q", data)
+	}
+
+	// A pinned source stays on its ref
+	updates, err := manager.PlanUpdate(CategoryRules, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 1 || updates[0].Status != UpdateCurrent || updates[0].Provenance.Path != "" {
+		t.Errorf("expected pinned item to be current, got %+v", updates)
+	}
+}
+
+func TestInstallFromGitHubRefusesSymlinks(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "id_rsa")
+	if err := os.WriteFile(secret, []byte("private key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	origin := newOriginRepo(t, map[string]string{"rules/style.md": "v1\n"})
+	if err := os.Symlink(secret, filepath.Join(origin, "rules", "leak.md")); err != nil {
+		t.Fatal(err)
+	}
+	commitOrigin(t, origin, nil)
+
+	manager := NewManager(t.TempDir(), t.TempDir())
+	_, _, err := manager.Install(CategoryRules, "github:owner/repo//rules")
+	if err == nil || !strings.Contains(err.Error(), "may not contain symlinks") {
+		t.Fatalf("expected symlink refusal, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(manager.extDir, "rules", "leak.md")); !os.IsNotExist(err) {
+		t.Errorf("symlink target was copied into the library: %v", err)
+	}
+}
+
+func TestUpdateRejectsEscapingProvenancePath(t *testing.T) {
+	newOriginRepo(t, map[string]string{"rules/style.md": "v1\n"})
+	manager := NewManager(t.TempDir(), t.TempDir())
+	if _, _, err := manager.Install(CategoryRules, "github:owner/repo//rules"); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	sources, err := manager.LoadSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := sources[CategoryRules]["style.md"]
+	p.Path = "../../../etc/passwd"
+	sources[CategoryRules]["style.md"] = p
+	if err := manager.SaveSources(sources); err != nil {
+		t.Fatal(err)
+	}
+
+	updates, err := manager.PlanUpdate(CategoryRules, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 1 || updates[0].Status != UpdateFailed || !strings.Contains(updates[0].Err.Error(), "invalid path") {
+		t.Errorf("expected invalid path failure, got %+v", updates)
+	}
+}
+
+func TestInstallFromURL(t *testing.T) {
+	body := "# Remote rule\n"
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rules/remote.md" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	original := httpClient
+	httpClient = server.Client()
+	defer func() { httpClient = original }()
+
+	manager := NewManager(t.TempDir(), t.TempDir())
+	installed, _, err := manager.Install(CategoryRules, server.URL+"/rules/remote.md")
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if len(installed) != 1 || installed[0] != "remote.md" {
+		t.Errorf("installed = %v", installed)
+	}
+
+	body = "# Remote rule, revised\n"
+	updates, err := manager.PlanUpdate(CategoryRules, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 1 || updates[0].Status != UpdateAvailable {
+		t.Fatalf("expected an available update, got %+v", updates)
+	}
+
+	if _, _, err := manager.Install(CategoryRules, server.URL+"/rules/missing.md"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected 404 error, got %v", err)
+	}
+}
+
+func TestInstallFromPluginCache(t *testing.T) {
+	claudeDir := t.TempDir()
+	pluginDir := filepath.Join(t.TempDir(), "cache", "tools")
+	skillDir := filepath.Join(pluginDir, "skills", "deploy")
+	if err := os.MkdirAll(skillDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte("# Deploy"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	registry := `{"version":2,"plugins":{"tools@market":[{"scope":"user","version":"1.4.0","installPath":"` + pluginDir + `"}]}}`
+	if err := os.MkdirAll(filepath.Join(claudeDir, "plugins"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(claudeDir, "plugins", "installed_plugins.json"), []byte(registry), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewManager(claudeDir, t.TempDir())
+	installed, _, err := manager.Install(CategorySkills, "plugin:tools@market")
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if len(installed) != 1 || installed[0] != "deploy" {
+		t.Errorf("installed = %v", installed)
+	}
+	sources, _ := manager.LoadSources()
+	if p := sources[CategorySkills]["deploy"]; p.Ref != "1.4.0" || p.Source != "plugin:tools@market" || p.Path != "deploy" {
+		t.Errorf("unexpected provenance: %+v", p)
+	}
+
+	if _, _, err := manager.Install(CategorySkills, "plugin:other@market"); err == nil || !strings.Contains(err.Error(), "not installed") {
+		t.Errorf("expected not installed error, got %v", err)
+	}
+
+	// Uninstalling forgets where the item came from
+	if _, _, err := manager.Uninstall(CategorySkills, []string{"deploy"}); err != nil {
+		t.Fatal(err)
+	}
+	sources, _ = manager.LoadSources()
+	if _, ok := sources[CategorySkills]["deploy"]; ok {
+		t.Error("provenance should be removed on uninstall")
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	got := diffLines("a\nb\nc\n", "a\nc\nd\n")
+	want := "- b\n+ d"
+	if strings.Join(got, "\n") != want {
+		t.Errorf("diffLines = %q, want %q", got, want)
+	}
+	if len(diffLines("same\n", "same\n")) != 0 {
+		t.Error("identical content should have no diff")
+	}
+}
@@ -0,0 +1,256 @@
+// ABOUTME: Per-item frontmatter overrides that turn a library item into a variant
+// ABOUTME: Variants are recorded in ext-variants.json and materialised as patched copies on sync
+package ext
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// VariantsFile records frontmatter overrides for enabled items, kept next to enabled.json.
+const VariantsFile = "ext-variants.json"
+
+// variantsDirName holds the patched copies that variant symlinks point at.
+const variantsDirName = "ext-variants"
+
+// FrontmatterPatch sets frontmatter keys on an item. A nil value removes the key.
+type FrontmatterPatch map[string]interface{}
+
+// Variants maps category -> item -> frontmatter patch.
+type Variants map[string]map[string]FrontmatterPatch
+
+// LoadVariants reads ext-variants.json. A missing file means no variants.
+func (m *Manager) LoadVariants() (Variants, error) {
+	data, err := os.ReadFile(m.variantsFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return make(Variants), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var variants Variants
+	if err := json.Unmarshal(data, &variants); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", VariantsFile, err)
+	}
+	if variants == nil {
+		variants = make(Variants)
+	}
+	return variants, nil
+}
+
+// SaveVariants writes ext-variants.json, removing the file when no variants remain.
+func (m *Manager) SaveVariants(variants Variants) error {
+	for category, items := range variants {
+		if len(items) == 0 {
+			delete(variants, category)
+		}
+	}
+	if len(variants) == 0 {
+		if err := os.Remove(m.variantsFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(variants, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	return os.WriteFile(m.variantsFile, data, 0644)
+}
+
+// SetVariants records the frontmatter patch for each of items in category and
+// re-syncs the category so their symlinks point at patched copies. patches is
+// keyed by item name or pattern as written in a profile; items matching no
+// patch lose any variant they had. Returns the items that became variants.
+func (m *Manager) SetVariants(category string, items []string, patches map[string]FrontmatterPatch) ([]string, error) {
+	if err := ValidateCategory(category); err != nil {
+		return nil, err
+	}
+	variants, err := m.LoadVariants()
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[string]FrontmatterPatch)
+	if len(patches) > 0 {
+		allItems, err := m.ListItems(category)
+		if err != nil {
+			return nil, err
+		}
+		var patterns []string
+		for pattern := range patches {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+		for _, pattern := range patterns {
+			matched, _, _ := m.resolvePattern(category, pattern, allItems)
+			for _, item := range matched {
+				resolved[item] = patches[pattern]
+			}
+		}
+	}
+
+	var patched []string
+	for _, item := range items {
+		patch, ok := resolved[item]
+		if !ok || len(patch) == 0 {
+			delete(variants[category], item)
+			continue
+		}
+		if _, err := m.variantFiles(category, item, patch); err != nil {
+			return nil, err
+		}
+		if variants[category] == nil {
+			variants[category] = make(map[string]FrontmatterPatch)
+		}
+		variants[category][item] = patch
+		patched = append(patched, item)
+	}
+
+	if err := m.SaveVariants(variants); err != nil {
+		return nil, err
+	}
+	config, err := m.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.syncCategory(category, config); err != nil {
+		return nil, err
+	}
+	sort.Strings(patched)
+	return patched, nil
+}
+
+// linkSource returns the path an enabled item's symlink should point at:
+// a freshly patched copy for variants, otherwise the library item.
+func (m *Manager) linkSource(category, item string, patches map[string]FrontmatterPatch) (string, error) {
+	source := filepath.Join(m.extDir, category, item)
+	patch := patches[item]
+	if len(patch) == 0 {
+		return source, nil
+	}
+	files, err := m.variantFiles(category, item, patch)
+	if err != nil {
+		return "", err
+	}
+	dest := filepath.Join(m.variantsDir, category, item)
+	if err := replaceItem(dest, files); err != nil {
+		return "", fmt.Errorf("writing variant of %s/%s: %w", category, item, err)
+	}
+	return dest, nil
+}
+
+// variantFiles reads a library item and applies patch to its frontmatter.
+func (m *Manager) variantFiles(category, item string, patch FrontmatterPatch) ([]itemFile, error) {
+	if err := validateItemPath(item); err != nil {
+		return nil, err
+	}
+	return patchItem(filepath.Join(m.extDir, category, item), patch)
+}
+
+// patchItem loads the item at path and patches the frontmatter of its
+// markdown file: the file itself, or SKILL.md for a skill directory.
+func patchItem(path string, patch FrontmatterPatch) ([]itemFile, error) {
+	files, err := readItem(path)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range files {
+		if (f.rel == "" && strings.HasSuffix(path, ".md")) || f.rel == "SKILL.md" {
+			content, err := patchFrontmatter(f.content, patch)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			files[i].content = content
+			return files, nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no markdown frontmatter to override", path)
+}
+
+// patchFrontmatter sets or removes keys in a markdown file's frontmatter,
+// keeping the order and formatting of untouched keys. A file without
+// frontmatter gains a block.
+func patchFrontmatter(data []byte, patch FrontmatterPatch) ([]byte, error) {
+	block, ok, closed := frontmatter(data)
+	if ok && !closed {
+		return nil, errors.New("frontmatter is not closed with ---")
+	}
+
+	body := data
+	var doc yaml.Node
+	if ok {
+		lines := bytes.SplitAfter(data, []byte("\n"))
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimRight(string(lines[i]), " \r\n") == "---" {
+				body = bytes.Join(lines[i+1:], nil)
+				break
+			}
+		}
+		if err := yaml.Unmarshal(block, &doc); err != nil {
+			return nil, fmt.Errorf("invalid frontmatter: %w", err)
+		}
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return nil, errors.New("frontmatter must be a set of key: value pairs")
+	}
+
+	keys := make([]string, 0, len(patch))
+	for key := range patch {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		index := -1
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if mapping.Content[i].Value == key {
+				index = i
+				break
+			}
+		}
+		value := patch[key]
+		if value == nil {
+			if index >= 0 {
+				mapping.Content = append(mapping.Content[:index], mapping.Content[index+2:]...)
+			}
+			continue
+		}
+		var node yaml.Node
+		if err := node.Encode(value); err != nil {
+			return nil, fmt.Errorf("encoding %s: %w", key, err)
+		}
+		if index >= 0 {
+			mapping.Content[index+1] = &node
+		} else {
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &node)
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("---\n")
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	out.WriteString("---\n")
+	out.Write(body)
+	return out.Bytes(), nil
+}
@@ -0,0 +1,121 @@
+// ABOUTME: Tests for extension variants built from frontmatter overrides
+// ABOUTME: Covers frontmatter patching, materialised copies on enable, and clearing variants
+package ext
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPatchFrontmatter(t *testing.T) {
+	input := "---\nname: reviewer\n# keep this comment\nmodel: sonnet\ntools: Read\n---\n# Reviewer\nBody text.\n"
+
+	out, err := patchFrontmatter([]byte(input), FrontmatterPatch{
+		"model": "opus",
+		"tools": nil,
+		"color": "blue",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "---\nname: reviewer\n# keep this comment\nmodel: opus\ncolor: blue\n---\n# Reviewer\nBody text.\n"
+	if string(out) != want {
+		t.Errorf("patchFrontmatter =\n%s\nwant\n%s", out, want)
+	}
+
+	// Files without frontmatter gain a block
+	out, err = patchFrontmatter([]byte("Plain body\n"), FrontmatterPatch{"tools": []interface{}{"Read", "Grep"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "---\ntools:\n  - Read\n  - Grep\n---\nPlain body\n" {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	if _, err := patchFrontmatter([]byte("---\nname: x\n"), FrontmatterPatch{"model": "opus"}); err == nil {
+		t.Error("expected error for unclosed frontmatter")
+	}
+}
+
+func TestSetVariantsMaterialisesPatchedCopies(t *testing.T) {
+	claudeDir := t.TempDir()
+	claudeupHome := t.TempDir()
+	manager := NewManager(claudeDir, claudeupHome)
+	writeLintFile(t, manager, "agents/reviewer.md", "---\nname: reviewer\nmodel: sonnet\n---\nReview.\n", 0644)
+	writeLintFile(t, manager, "agents/planner.md", "---\nname: planner\n---\nPlan.\n", 0644)
+	writeLintFile(t, manager, "skills/notes/SKILL.md", "---\nname: notes\n---\nNotes.\n", 0644)
+	writeLintFile(t, manager, "hooks/format.sh", "#!/bin/sh\n", 0755)
+
+	enabled, _, err := manager.Enable(CategoryAgents, []string{"*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	patched, err := manager.SetVariants(CategoryAgents, enabled, map[string]FrontmatterPatch{
+		"reviewer": {"model": "opus"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(patched, ",") != "reviewer.md" {
+		t.Errorf("patched = %v", patched)
+	}
+
+	link := filepath.Join(claudeDir, "agents", "reviewer.md")
+	target, err := os.Readlink(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != filepath.Join(claudeupHome, "ext-variants", "agents", "reviewer.md") {
+		t.Errorf("variant symlink points at %s", target)
+	}
+	data, _ := os.ReadFile(link)
+	if !strings.Contains(string(data), "model: opus") {
+		t.Errorf("variant not patched:\n%s", data)
+	}
+	library, _ := os.ReadFile(filepath.Join(manager.extDir, "agents", "reviewer.md"))
+	if !strings.Contains(string(library), "model: sonnet") {
+		t.Error("library item must not change")
+	}
+	if target, _ := os.Readlink(filepath.Join(claudeDir, "agents", "planner.md")); target != filepath.Join(manager.extDir, "agents", "planner.md") {
+		t.Errorf("plain item should link to the library, got %s", target)
+	}
+
+	// Library edits flow into the variant on the next sync
+	writeLintFile(t, manager, "agents/reviewer.md", "---\nname: reviewer\nmodel: sonnet\n---\nReview carefully.\n", 0644)
+	if _, err := manager.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(link)
+	if !strings.Contains(string(data), "Review carefully.") || !strings.Contains(string(data), "model: opus") {
+		t.Errorf("variant not refreshed on sync:\n%s", data)
+	}
+
+	// Skills patch SKILL.md; items without frontmatter are rejected
+	if _, _, err := manager.Enable(CategorySkills, []string{"notes"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.SetVariants(CategorySkills, []string{"notes"}, map[string]FrontmatterPatch{"notes": {"description": "Team notes"}}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(filepath.Join(claudeDir, "skills", "notes", "SKILL.md"))
+	if !strings.Contains(string(data), "description: Team notes") {
+		t.Errorf("skill variant not patched:\n%s", data)
+	}
+	if _, err := manager.SetVariants(CategoryHooks, []string{"format.sh"}, map[string]FrontmatterPatch{"format.sh": {"x": 1}}); err == nil {
+		t.Error("expected error overriding a hook script")
+	}
+
+	// Re-applying without overrides returns the item to a plain symlink
+	if _, err := manager.SetVariants(CategoryAgents, enabled, nil); err != nil {
+		t.Fatal(err)
+	}
+	if target, _ := os.Readlink(link); target != filepath.Join(manager.extDir, "agents", "reviewer.md") {
+		t.Errorf("expected plain symlink after clearing, got %s", target)
+	}
+	variants, _ := manager.LoadVariants()
+	if _, ok := variants[CategoryAgents]; ok {
+		t.Errorf("agent variants should be cleared: %v", variants)
+	}
+}
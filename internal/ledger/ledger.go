@@ -0,0 +1,229 @@
+// ABOUTME: Records which plugins, MCP servers, marketplaces, hooks, and memory blocks each profile introduced
+// ABOUTME: Lets profile reset undo only what an apply added and profile status show owners
+package ledger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const filename = "ledger.json"
+
+// Item kinds recorded in the ledger.
+const (
+	KindPlugin      = "plugin"
+	KindMCP         = "mcp"
+	KindMarketplace = "marketplace"
+	KindHook        = "hook"   // a hook in user settings.json
+	KindMemory      = "memory" // a managed block in a scope's CLAUDE.md
+)
+
+// Item identifies an installed plugin, MCP server, marketplace, hook, or
+// memory block at a scope.
+// ProjectDir is set for project and local scope items.
+type Item struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Scope      string `json:"scope"`
+	ProjectDir string `json:"projectDir,omitempty"`
+}
+
+// NewItem builds an Item, normalizing projectDir via filepath.EvalSymlinks
+// and dropping it for user scope.
+func NewItem(kind, name, scope, projectDir string) Item {
+	item := Item{Kind: kind, Name: name, Scope: scope}
+	if scope != "user" && projectDir != "" {
+		item.ProjectDir = NormalizeDir(projectDir)
+	}
+	return item
+}
+
+// NormalizeDir resolves symlinks so the same project compares equal
+// regardless of how its path was spelled.
+func NormalizeDir(dir string) string {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return resolved
+	}
+	return filepath.Clean(dir)
+}
+
+// InProject reports whether the item belongs to the user scope or to projectDir.
+func (i Item) InProject(projectDir string) bool {
+	return i.Scope == "user" || (projectDir != "" && i.ProjectDir == NormalizeDir(projectDir))
+}
+
+// Entry is an item a profile introduced, with the time it was first recorded.
+type Entry struct {
+	Item
+	AddedAt time.Time `json:"addedAt"`
+}
+
+// File maps profile names to the entries they own. A profile that is present
+// with no entries has been applied but introduced nothing.
+type File struct {
+	Profiles map[string][]Entry `json:"profiles"`
+}
+
+// Load reads the ledger from claudeupHome.
+// Returns an empty File if the ledger does not exist.
+func Load(claudeupHome string) (*File, error) {
+	path := filepath.Join(claudeupHome, filename)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &File{Profiles: map[string][]Entry{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if f.Profiles == nil {
+		f.Profiles = map[string][]Entry{}
+	}
+	return &f, nil
+}
+
+// Save writes the ledger atomically: to a uniquely named temp file in the
+// same directory, then renamed into place, so concurrent saves never share a
+// temp file and a half-written ledger is never renamed over the old one.
+func Save(claudeupHome string, f *File) error {
+	path := filepath.Join(claudeupHome, filename)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating ledger directory: %w", err)
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filename+".write-*")
+	if err != nil {
+		return fmt.Errorf("writing ledger: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing ledger: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing ledger: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("writing ledger: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("writing ledger: %w", err)
+	}
+	return nil
+}
+
+// Tracked reports whether the profile has been applied since the ledger
+// existed, even if it introduced nothing.
+func (f *File) Tracked(profile string) bool {
+	_, ok := f.Profiles[profile]
+	return ok
+}
+
+// Owner returns the profile that introduced item, or "".
+func (f *File) Owner(item Item) string {
+	for profile, entries := range f.Profiles {
+		for _, e := range entries {
+			if e.Item == item {
+				return profile
+			}
+		}
+	}
+	return ""
+}
+
+// Record marks profile as applied and adds the items it introduced.
+// Items already owned by any profile keep their original owner.
+func (f *File) Record(profile string, items []Item, now time.Time) {
+	if f.Profiles[profile] == nil {
+		f.Profiles[profile] = []Entry{}
+	}
+	for _, item := range items {
+		if f.Owner(item) == "" {
+			f.Profiles[profile] = append(f.Profiles[profile], Entry{Item: item, AddedAt: now})
+		}
+	}
+}
+
+// Entries returns the profile's entries for the user scope and projectDir.
+func (f *File) Entries(profile, projectDir string) []Entry {
+	var result []Entry
+	for _, e := range f.Profiles[profile] {
+		if e.InProject(projectDir) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Forget removes item from the profile's entries. The profile stays tracked.
+func (f *File) Forget(profile string, item Item) {
+	entries := f.Profiles[profile]
+	kept := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		if e.Item != item {
+			kept = append(kept, e)
+		}
+	}
+	if _, ok := f.Profiles[profile]; ok {
+		f.Profiles[profile] = kept
+	}
+}
+
+// Transfer hands an item from one profile to another, keeping the time it
+// was first recorded. Does nothing if from does not own the item.
+func (f *File) Transfer(item Item, from, to string) {
+	for _, e := range f.Profiles[from] {
+		if e.Item == item {
+			f.Forget(from, item)
+			f.Profiles[to] = append(f.Profiles[to], e)
+			return
+		}
+	}
+}
+
+// Prune forgets entries for the user scope and projectDir that are no longer
+// in live, e.g. because they were uninstalled outside claudeup.
+func (f *File) Prune(live []Item, projectDir string) {
+	present := make(map[Item]bool, len(live))
+	for _, item := range live {
+		present[item] = true
+	}
+	for profile, entries := range f.Profiles {
+		kept := make([]Entry, 0, len(entries))
+		for _, e := range entries {
+			if !e.InProject(projectDir) || present[e.Item] {
+				kept = append(kept, e)
+			}
+		}
+		f.Profiles[profile] = kept
+	}
+}
+
+// Rename moves a profile's entries to a new name in the saved ledger.
+// A missing ledger or profile is not an error.
+func Rename(claudeupHome, from, to string) error {
+	f, err := Load(claudeupHome)
+	if err != nil {
+		return err
+	}
+	entries, ok := f.Profiles[from]
+	if !ok {
+		return nil
+	}
+	delete(f.Profiles, from)
+	f.Profiles[to] = append(f.Profiles[to], entries...)
+	return Save(claudeupHome, f)
+}
@@ -0,0 +1,166 @@
+// ABOUTME: Tests for the profile ownership ledger
+// ABOUTME: Covers recording, first-owner semantics, pruning, forgetting, rename, and concurrent saves
+package ledger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func TestLoadMissingFile(t *testing.T) {
+	f, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(f.Profiles) != 0 || f.Tracked("any") {
+		t.Fatalf("expected empty ledger, got %+v", f.Profiles)
+	}
+}
+
+func TestRecordKeepsFirstOwner(t *testing.T) {
+	dir := t.TempDir()
+	f, _ := Load(dir)
+	plugin := NewItem(KindPlugin, "tdd@tools", "user", "/ignored")
+	mcp := NewItem(KindMCP, "notes", "project", dir)
+
+	f.Record("backend", []Item{plugin, mcp}, now)
+	f.Record("frontend", []Item{plugin}, now)
+	f.Record("empty", nil, now)
+
+	if got := f.Owner(plugin); got != "backend" {
+		t.Errorf("Owner(plugin) = %q, want backend", got)
+	}
+	if plugin.ProjectDir != "" {
+		t.Errorf("user-scope items should not store a project dir: %+v", plugin)
+	}
+	if !f.Tracked("empty") || len(f.Profiles["frontend"]) != 0 {
+		t.Errorf("profiles that introduced nothing should be tracked with no entries: %+v", f.Profiles)
+	}
+
+	if err := Save(dir, f); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Tracked("empty") {
+		t.Error("empty profile should stay tracked after a round trip")
+	}
+	if got := loaded.Entries("backend", dir); len(got) != 2 {
+		t.Errorf("Entries(backend, project) = %+v", got)
+	}
+	if got := loaded.Entries("backend", t.TempDir()); len(got) != 1 || got[0].Item != plugin {
+		t.Errorf("entries for another project should only include user scope: %+v", got)
+	}
+}
+
+func TestForgetAndPrune(t *testing.T) {
+	projectDir := t.TempDir()
+	otherDir := t.TempDir()
+	f := &File{Profiles: map[string][]Entry{}}
+	kept := NewItem(KindPlugin, "kept@tools", "user", "")
+	gone := NewItem(KindPlugin, "gone@tools", "user", "")
+	elsewhere := NewItem(KindMCP, "db", "local", otherDir)
+	f.Record("p", []Item{kept, gone, elsewhere}, now)
+
+	// Items outside projectDir are not judged by a live listing of projectDir
+	f.Prune([]Item{kept}, projectDir)
+	var names []string
+	for _, e := range f.Profiles["p"] {
+		names = append(names, e.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"kept@tools", "db"}) {
+		t.Errorf("after prune = %v", names)
+	}
+
+	f.Forget("p", kept)
+	f.Forget("p", elsewhere)
+	if !f.Tracked("p") || len(f.Profiles["p"]) != 0 {
+		t.Errorf("forgetting every item should leave the profile tracked: %+v", f.Profiles)
+	}
+}
+
+func TestRename(t *testing.T) {
+	dir := t.TempDir()
+	f, _ := Load(dir)
+	f.Record("old", []Item{NewItem(KindMarketplace, "tools", "user", "")}, now)
+	if err := Save(dir, f); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Rename(dir, "old", "new"); err != nil {
+		t.Fatal(err)
+	}
+	loaded, _ := Load(dir)
+	if loaded.Tracked("old") || len(loaded.Profiles["new"]) != 1 {
+		t.Errorf("after rename = %+v", loaded.Profiles)
+	}
+	if err := Rename(filepath.Join(dir, "missing"), "a", "b"); err != nil {
+		t.Errorf("renaming in a missing ledger should be a no-op: %v", err)
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	f := &File{Profiles: map[string][]Entry{}}
+	item := NewItem(KindPlugin, "lint@tools", "user", "")
+	f.Record("security", []Item{item}, now)
+	f.Record("base", nil, now)
+
+	f.Transfer(item, "base", "other")
+	if f.Owner(item) != "security" {
+		t.Fatalf("transfer from a non-owner should do nothing: %+v", f.Profiles)
+	}
+
+	f.Transfer(item, "security", "base")
+	if f.Owner(item) != "base" || !f.Profiles["base"][0].AddedAt.Equal(now) {
+		t.Errorf("after transfer = %+v", f.Profiles)
+	}
+	if !f.Tracked("security") || len(f.Profiles["security"]) != 0 {
+		t.Errorf("previous owner should stay tracked with no entries: %+v", f.Profiles)
+	}
+}
+
+func TestConcurrentSavesLeaveAValidLedger(t *testing.T) {
+	home := t.TempDir()
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f := &File{Profiles: map[string][]Entry{}}
+			f.Record(fmt.Sprintf("p%d", i), []Item{NewItem(KindPlugin, "a@m", "user", "")}, now)
+			errs <- Save(home, f)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Save: %v", err)
+		}
+	}
+
+	f, err := Load(home)
+	if err != nil {
+		t.Fatalf("ledger is not valid after concurrent saves: %v", err)
+	}
+	if len(f.Profiles) != 1 {
+		t.Errorf("expected one writer's ledger, got %+v", f.Profiles)
+	}
+	entries, err := os.ReadDir(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+}
@@ -0,0 +1,146 @@
+// ABOUTME: Writes a claudeup installation to a gzip-compressed tar archive
+// ABOUTME: Carries the ext library, profiles, enabled.json, breadcrumbs, and config, with secrets stripped
+package machine
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/claudeup/claudeup/v5/internal/profile"
+)
+
+// ExportOptions controls what an export carries.
+type ExportOptions struct {
+	Paths   Paths
+	Version string
+	// Profile is a snapshot of the user-scope plugins, marketplaces, and MCP
+	// servers, replayed by import when plugins are reinstalled. Optional.
+	Profile *profile.Profile
+}
+
+type archiveEntry struct {
+	name    string
+	mode    fs.FileMode
+	content []byte
+}
+
+// Export writes the installation described by opts to w and returns the
+// manifest it recorded. Symlinks are skipped and listed in the manifest.
+func Export(w io.Writer, opts ExportOptions) (*Manifest, error) {
+	manifest := &Manifest{
+		FormatVersion: FormatVersion,
+		CreatedAt:     time.Now().UTC(),
+		Version:       opts.Version,
+		Source:        opts.Paths,
+	}
+
+	var entries []archiveEntry
+	for _, name := range includedEntries {
+		root := filepath.Join(opts.Paths.ClaudeupHome, name)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(opts.Paths.ClaudeupHome, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if d.Type()&fs.ModeSymlink != 0 {
+				manifest.Skipped = append(manifest.Skipped, rel)
+				return nil
+			}
+			// A library synced with 'extensions remote' carries git metadata
+			// that belongs to this machine's clone, not the installation
+			if d.IsDir() && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if d.IsDir() || !d.Type().IsRegular() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			content, redactions := redact(rel, content)
+			manifest.Redactions = append(manifest.Redactions, redactions...)
+			manifest.Files = append(manifest.Files, File{
+				Path:   rel,
+				Mode:   uint32(info.Mode().Perm()),
+				Size:   int64(len(content)),
+				SHA256: sha256Hex(content),
+			})
+			entries = append(entries, archiveEntry{name: homePrefix + rel, mode: info.Mode().Perm(), content: content})
+			return nil
+		})
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect %s: %w", name, err)
+		}
+	}
+
+	if opts.Profile != nil {
+		data, err := json.MarshalIndent(opts.Profile, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode profile snapshot: %w", err)
+		}
+		data, redactions := redact(profileEntry, data)
+		manifest.Redactions = append(manifest.Redactions, redactions...)
+		manifest.HasProfile = true
+		entries = append(entries, archiveEntry{name: profileEntry, mode: 0644, content: data})
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	// The manifest goes first so readers can reject an archive early
+	entries = append([]archiveEntry{{name: manifestEntry, mode: 0644, content: manifestData}}, entries...)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     entry.name,
+			Mode:     int64(entry.mode),
+			Size:     int64(len(entry.content)),
+			ModTime:  manifest.CreatedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("failed to write archive: %w", err)
+		}
+		if _, err := tw.Write(entry.content); err != nil {
+			return nil, fmt.Errorf("failed to write archive: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return manifest, nil
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
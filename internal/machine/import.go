@@ -0,0 +1,238 @@
+// ABOUTME: Reads an export archive and writes it into another claudeup installation
+// ABOUTME: Verifies checksums, rewrites absolute paths, and reports conflicts before writing
+package machine
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/profile"
+)
+
+// Import statuses for a file in the archive.
+const (
+	StatusNew       = "new"
+	StatusUnchanged = "unchanged"
+	StatusConflict  = "conflict"
+)
+
+// maxEntrySize bounds a single archive entry, so a corrupt or hostile
+// archive cannot exhaust memory.
+const maxEntrySize = 64 << 20
+
+// Archive is an export read back into memory.
+type Archive struct {
+	Manifest Manifest
+
+	files   map[string][]byte
+	profile []byte
+}
+
+// Read loads and verifies an archive written by Export.
+func Read(r io.Reader) (*Archive, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a claudeup export (expected .tar.gz): %w", err)
+	}
+	defer gz.Close()
+
+	archive := &Archive{files: make(map[string][]byte)}
+	var manifestData []byte
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unsupported archive entry %s", header.Name)
+		}
+		if header.Size > maxEntrySize {
+			return nil, fmt.Errorf("archive entry %s is too large", header.Name)
+		}
+		content, err := io.ReadAll(io.LimitReader(tr, maxEntrySize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+
+		switch name := header.Name; {
+		case name == manifestEntry:
+			manifestData = content
+		case name == profileEntry:
+			archive.profile = content
+		case strings.HasPrefix(name, homePrefix):
+			rel := strings.TrimPrefix(name, homePrefix)
+			if !filepath.IsLocal(filepath.FromSlash(rel)) || path.Clean(rel) != rel {
+				return nil, fmt.Errorf("archive entry %s escapes the claudeup directory", name)
+			}
+			if !isIncluded(rel) {
+				return nil, fmt.Errorf("archive entry %s is not something 'claudeup export' writes", name)
+			}
+			archive.files[rel] = content
+		default:
+			return nil, fmt.Errorf("unexpected archive entry %s", name)
+		}
+	}
+
+	if manifestData == nil {
+		return nil, fmt.Errorf("archive has no %s; was it created by 'claudeup export'?", manifestEntry)
+	}
+	if err := json.Unmarshal(manifestData, &archive.Manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if archive.Manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("archive format %d is newer than this claudeup supports (%d); upgrade claudeup first",
+			archive.Manifest.FormatVersion, FormatVersion)
+	}
+
+	listed := make(map[string]bool, len(archive.Manifest.Files))
+	for _, file := range archive.Manifest.Files {
+		content, ok := archive.files[file.Path]
+		if !ok {
+			return nil, fmt.Errorf("archive is missing %s", file.Path)
+		}
+		if sha256Hex(content) != file.SHA256 {
+			return nil, fmt.Errorf("archive is corrupt: %s does not match its checksum", file.Path)
+		}
+		listed[file.Path] = true
+	}
+	for rel := range archive.files {
+		if !listed[rel] {
+			return nil, fmt.Errorf("archive entry %s is not in the manifest", rel)
+		}
+	}
+	return archive, nil
+}
+
+// Change is one file an import would write.
+type Change struct {
+	Path      string
+	Status    string
+	Rewritten bool
+
+	content []byte
+	mode    fs.FileMode
+}
+
+// Plan describes what importing an archive into an installation would do.
+type Plan struct {
+	Changes []Change
+	// Profile is the exported user-scope snapshot with paths rewritten, or nil.
+	Profile *profile.Profile
+}
+
+// Plan compares the archive with the installation at dst, rewriting
+// absolute paths from the exporting machine along the way.
+func (a *Archive) Plan(dst Paths) (*Plan, error) {
+	rewriter := newPathRewriter(a.Manifest.Source, dst)
+	plan := &Plan{}
+
+	for _, file := range a.Manifest.Files {
+		content, rewritten := rewriter.rewrite(a.files[file.Path])
+		change := Change{
+			Path:      filepath.Join(dst.ClaudeupHome, filepath.FromSlash(file.Path)),
+			Status:    StatusNew,
+			Rewritten: rewritten,
+			content:   content,
+			mode:      fs.FileMode(file.Mode).Perm(),
+		}
+		current, err := os.ReadFile(change.Path)
+		switch {
+		case err == nil && string(current) == string(content):
+			change.Status = StatusUnchanged
+		case err == nil:
+			change.Status = StatusConflict
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, fmt.Errorf("failed to read %s: %w", change.Path, err)
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+	sort.Slice(plan.Changes, func(i, j int) bool { return plan.Changes[i].Path < plan.Changes[j].Path })
+
+	if a.profile != nil {
+		content, _ := rewriter.rewrite(a.profile)
+		var p profile.Profile
+		if err := json.Unmarshal(content, &p); err != nil {
+			return nil, fmt.Errorf("invalid profile snapshot in archive: %w", err)
+		}
+		plan.Profile = &p
+	}
+	return plan, nil
+}
+
+// Count returns how many changes have the given status.
+func (p *Plan) Count(status string) int {
+	n := 0
+	for _, change := range p.Changes {
+		if change.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Apply writes new files and, when overwrite is set, replaces conflicting
+// ones. Without overwrite, any conflict aborts the import before writing.
+func (p *Plan) Apply(overwrite bool) error {
+	if conflicts := p.Count(StatusConflict); conflicts > 0 && !overwrite {
+		return fmt.Errorf("%d file(s) already exist with different content", conflicts)
+	}
+	for _, change := range p.Changes {
+		if change.Status == StatusUnchanged {
+			continue
+		}
+		if err := writeFile(change.Path, change.content, change.mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFile replaces path atomically, refusing to write through a symlink.
+func writeFile(path string, content []byte, mode fs.FileMode) error {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("%s is a symlink, refusing to overwrite", path)
+	}
+	if mode == 0 {
+		mode = 0644
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".import-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("failed to set permissions on %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// ABOUTME: Shared types for exporting and importing a whole claudeup installation
+// ABOUTME: Defines the archive manifest, the paths it records, and which files it carries
+package machine
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// FormatVersion is the archive layout version written to the manifest.
+// Import refuses archives with a newer version.
+const FormatVersion = 1
+
+// Archive entry names.
+const (
+	manifestEntry = "manifest.json"
+	profileEntry  = "user-profile.json"
+	homePrefix    = "claudeup/"
+)
+
+// includedEntries are the top-level names under CLAUDEUP_HOME that an export
+// carries. Caches, logs, and backups are machine-specific and left out.
+var includedEntries = []string{
+	"config.json",
+	"enabled.json",
+	"ext",
+	"ext-sources.json",
+	"ext-variants.json",
+	"last-applied.json",
+	"marketplace-pins.json",
+	"profiles",
+}
+
+// isIncluded reports whether rel, a slash-separated path under
+// CLAUDEUP_HOME, falls under one of includedEntries.
+func isIncluded(rel string) bool {
+	first, _, _ := strings.Cut(rel, "/")
+	return slices.Contains(includedEntries, first)
+}
+
+// Paths locates an installation. Absolute paths under these directories are
+// rewritten on import so the archive works under a different home directory,
+// CLAUDE_CONFIG_DIR, or CLAUDEUP_HOME.
+type Paths struct {
+	Home         string `json:"home"`
+	ClaudeDir    string `json:"claudeDir"`
+	ClaudeupHome string `json:"claudeupHome"`
+}
+
+// Manifest describes an archive's contents and where it was exported from.
+type Manifest struct {
+	FormatVersion int         `json:"formatVersion"`
+	CreatedAt     time.Time   `json:"createdAt"`
+	Version       string      `json:"claudeupVersion,omitempty"`
+	Source        Paths       `json:"source"`
+	Files         []File      `json:"files"`
+	HasProfile    bool        `json:"hasProfile"`
+	Redactions    []Redaction `json:"redactions,omitempty"`
+	Skipped       []string    `json:"skipped,omitempty"`
+}
+
+// File is one file from CLAUDEUP_HOME, by path relative to it.
+type File struct {
+	Path   string `json:"path"`
+	Mode   uint32 `json:"mode"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Redaction records a secret removed during export, so the user knows what
+// to re-enter after importing.
+type Redaction struct {
+	File     string `json:"file"`
+	Location string `json:"location"`
+}
@@ -0,0 +1,221 @@
+// ABOUTME: Round-trip tests for exporting and importing an installation
+// ABOUTME: Covers included files, path rewriting, conflicts, and rejecting bad archives
+package machine
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/claudeup/claudeup/v5/internal/profile"
+)
+
+func newTestPaths(t *testing.T, root string) Paths {
+	t.Helper()
+	paths := Paths{
+		Home:         root,
+		ClaudeDir:    filepath.Join(root, ".claude"),
+		ClaudeupHome: filepath.Join(root, ".claudeup"),
+	}
+	if err := os.MkdirAll(paths.ClaudeupHome, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+func writeHomeFile(t *testing.T, paths Paths, rel, content string) {
+	t.Helper()
+	path := filepath.Join(paths.ClaudeupHome, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exportArchive(t *testing.T, opts ExportOptions) (*bytes.Buffer, *Manifest) {
+	t.Helper()
+	var buf bytes.Buffer
+	manifest, err := Export(&buf, opts)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	return &buf, manifest
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	src := newTestPaths(t, filepath.Join(t.TempDir(), "old"))
+	writeHomeFile(t, src, "enabled.json", `{"agents":{"reviewer.md":true}}`)
+	writeHomeFile(t, src, "ext/agents/reviewer.md", "# Reviewer\n")
+	writeHomeFile(t, src, "ext/hooks/format.sh", "#!/bin/sh\n"+src.ClaudeupHome+"/ext/hooks/lib.sh\n")
+	writeHomeFile(t, src, "profiles/team/dev.json", `{"name":"dev","mcpServers":[{"name":"db","command":"db","args":["--password","x"]}]}`)
+	writeHomeFile(t, src, "last-applied.json", `{"user":{"profile":"dev","projectDir":"`+src.Home+`/code/app"}}`)
+	writeHomeFile(t, src, "events/operations.log", "not exported")
+	writeHomeFile(t, src, "backups/user-scope.json", "{}")
+	writeHomeFile(t, src, "ext/.git/config", "[remote \"origin\"]\n")
+	if err := os.Symlink(filepath.Join(src.ClaudeupHome, "ext", "agents", "reviewer.md"), filepath.Join(src.ClaudeupHome, "ext", "agents", "alias.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := &profile.Profile{
+		Name:         "machine",
+		Plugins:      []string{"tool@market"},
+		Marketplaces: []profile.Marketplace{{Source: "directory", Path: src.Home + "/marketplaces/local"}},
+	}
+	buf, manifest := exportArchive(t, ExportOptions{Paths: src, Version: "1.2.3", Profile: snapshot})
+
+	var exported []string
+	for _, f := range manifest.Files {
+		exported = append(exported, f.Path)
+	}
+	want := "enabled.json,ext/agents/reviewer.md,ext/hooks/format.sh,last-applied.json,profiles/team/dev.json"
+	if got := strings.Join(exported, ","); got != want {
+		t.Errorf("exported files = %s, want %s", got, want)
+	}
+	if len(manifest.Skipped) != 1 || manifest.Skipped[0] != "ext/agents/alias.md" {
+		t.Errorf("expected the symlink to be skipped, got %v", manifest.Skipped)
+	}
+
+	archive, err := Read(buf)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if archive.Manifest.Version != "1.2.3" || !archive.Manifest.HasProfile {
+		t.Errorf("unexpected manifest: %+v", archive.Manifest)
+	}
+
+	dst := newTestPaths(t, filepath.Join(t.TempDir(), "new"))
+	plan, err := archive.Plan(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Count(StatusNew) != 5 || plan.Count(StatusConflict) != 0 {
+		t.Errorf("expected 5 new files, got %+v", plan.Changes)
+	}
+	if plan.Profile == nil || plan.Profile.Marketplaces[0].Path != dst.Home+"/marketplaces/local" {
+		t.Errorf("profile paths not rewritten: %+v", plan.Profile)
+	}
+	if err := plan.Apply(false); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	hook, err := os.ReadFile(filepath.Join(dst.ClaudeupHome, "ext", "hooks", "format.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(hook), dst.ClaudeupHome+"/ext/hooks/lib.sh") {
+		t.Errorf("hook path not rewritten:\n%s", hook)
+	}
+	crumbs, _ := os.ReadFile(filepath.Join(dst.ClaudeupHome, "last-applied.json"))
+	if !strings.Contains(string(crumbs), dst.Home+"/code/app") {
+		t.Errorf("breadcrumb project path not rewritten: %s", crumbs)
+	}
+	profileData, _ := os.ReadFile(filepath.Join(dst.ClaudeupHome, "profiles", "team", "dev.json"))
+	if !strings.Contains(string(profileData), `"--password"`) {
+		t.Errorf("profile args should survive export: %s", profileData)
+	}
+	if _, err := os.Stat(filepath.Join(dst.ClaudeupHome, "events")); !os.IsNotExist(err) {
+		t.Error("events should not be exported")
+	}
+
+	// Importing the same archive again changes nothing
+	again, err := archive.Plan(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Count(StatusUnchanged) != 5 {
+		t.Errorf("expected all files unchanged on re-import, got %+v", again.Changes)
+	}
+}
+
+func TestImportConflicts(t *testing.T) {
+	src := newTestPaths(t, filepath.Join(t.TempDir(), "old"))
+	writeHomeFile(t, src, "enabled.json", `{"agents":{"a.md":true}}`)
+	buf, _ := exportArchive(t, ExportOptions{Paths: src})
+
+	dst := newTestPaths(t, filepath.Join(t.TempDir(), "new"))
+	writeHomeFile(t, dst, "enabled.json", `{"agents":{"b.md":true}}`)
+
+	archive, err := Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := archive.Plan(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Count(StatusConflict) != 1 {
+		t.Fatalf("expected a conflict, got %+v", plan.Changes)
+	}
+	if err := plan.Apply(false); err == nil || !strings.Contains(err.Error(), "already exist") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dst.ClaudeupHome, "enabled.json"))
+	if !strings.Contains(string(data), "b.md") {
+		t.Error("conflicting file should be untouched without overwrite")
+	}
+
+	if err := plan.Apply(true); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(filepath.Join(dst.ClaudeupHome, "enabled.json"))
+	if !strings.Contains(string(data), "a.md") {
+		t.Error("overwrite should replace the conflicting file")
+	}
+}
+
+func writeRawArchive(t *testing.T, entries map[string]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range entries {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return &buf
+}
+
+func TestReadRejectsBadArchives(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries map[string]string
+		wantErr string
+	}{
+		{"no manifest", map[string]string{"claudeup/config.json": "{}"}, "no manifest.json"},
+		{"path traversal", map[string]string{"manifest.json": `{"formatVersion":1}`, "claudeup/../evil": "x"}, "escapes"},
+		{"newer format", map[string]string{"manifest.json": `{"formatVersion":99}`}, "newer than this claudeup supports"},
+		{"unlisted file", map[string]string{"manifest.json": `{"formatVersion":1}`, "claudeup/config.json": "{}"}, "not in the manifest"},
+		{"bad checksum", map[string]string{
+			"manifest.json":        `{"formatVersion":1,"files":[{"path":"config.json","sha256":"00"}]}`,
+			"claudeup/config.json": "{}",
+		}, "does not match its checksum"},
+		{"entry outside the export set", map[string]string{
+			"manifest.json":                  `{"formatVersion":1,"files":[{"path":"trusted-keys/evil.pub","sha256":"00"}]}`,
+			"claudeup/trusted-keys/evil.pub": "untrusted comment: key\nRWQ=\n",
+		}, "not something 'claudeup export' writes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(writeRawArchive(t, tt.entries))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Read error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := Read(strings.NewReader("plain text")); err == nil {
+		t.Error("expected error for non-gzip input")
+	}
+}
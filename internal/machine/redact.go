@@ -0,0 +1,324 @@
+// ABOUTME: Strips secrets from files before they are written to an export archive
+// ABOUTME: Blanks sensitive keys in JSON, JSONC, and YAML files and replaces well-known token formats in any text file
+package machine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/claudeup/claudeup/v5/internal/profile"
+	"go.yaml.in/yaml/v3"
+)
+
+// redactedValue replaces tokens found in free text.
+const redactedValue = "REDACTED"
+
+// sensitiveKeyWords are the words that mark a JSON key's string value as a
+// secret. They match whole words of the key, so GITHUB_TOKEN and apiKey are
+// secrets but MAX_TOKENS and secretary are not.
+var sensitiveKeyWords = map[string]bool{
+	"token":         true,
+	"secret":        true,
+	"password":      true,
+	"passwd":        true,
+	"credential":    true,
+	"credentials":   true,
+	"apikey":        true,
+	"privatekey":    true,
+	"auth":          true,
+	"authorization": true,
+}
+
+// isSensitiveKey reports whether a word of key, or two adjacent words
+// ("api" "key"), is one of sensitiveKeyWords.
+func isSensitiveKey(key string) bool {
+	words := keyWords(key)
+	for i, word := range words {
+		if sensitiveKeyWords[word] || (i > 0 && sensitiveKeyWords[words[i-1]+word]) {
+			return true
+		}
+	}
+	return false
+}
+
+// keyWords splits a key into lowercase words at separators and camelCase
+// boundaries: "GITHUB_TOKEN" is [github token], "openAIApiKey" is
+// [open ai api key].
+func keyWords(key string) []string {
+	var words []string
+	runes := []rune(key)
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, strings.ToLower(string(runes[start:i])))
+				start = -1
+			}
+			continue
+		}
+		if start >= 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, strings.ToLower(string(runes[start:i])))
+				start = i
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, strings.ToLower(string(runes[start:])))
+	}
+	return words
+}
+
+// tokenPatterns match credentials with recognizable prefixes wherever they appear.
+var tokenPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`),
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
+}
+
+// isText reports whether content looks like text that is safe to edit.
+func isText(content []byte) bool {
+	return !bytes.Contains(content, []byte{0}) && utf8.Valid(content)
+}
+
+// redact returns content with secrets removed, along with where each was found.
+// Binary files are returned unchanged.
+func redact(name string, content []byte) ([]byte, []Redaction) {
+	if !isText(content) {
+		return content, nil
+	}
+
+	var redactions []Redaction
+	var locations []string
+	switch profile.FormatForPath(name) {
+	case profile.FormatJSON:
+		var doc any
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&doc); err == nil {
+			var found []string
+			redactJSON(doc, "", &found)
+			if len(found) > 0 {
+				if encoded, err := encodeJSON(doc); err == nil {
+					content, locations = encoded, found
+				}
+			}
+		}
+	case profile.FormatJSONC:
+		content, locations = redactJSONC(content)
+	case profile.FormatYAML:
+		content, locations = redactYAML(content)
+	}
+	for _, loc := range locations {
+		redactions = append(redactions, Redaction{File: name, Location: loc})
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+	changed := false
+	for i, line := range lines {
+		for _, pattern := range tokenPatterns {
+			if pattern.MatchString(line) {
+				line = pattern.ReplaceAllString(line, redactedValue)
+				changed = true
+				redactions = append(redactions, Redaction{File: name, Location: fmt.Sprintf("line %d", i+1)})
+			}
+		}
+		lines[i] = line
+	}
+	if changed {
+		content = []byte(strings.Join(lines, ""))
+	}
+	return content, redactions
+}
+
+// redactJSON blanks string values under sensitive keys, recording their dotted paths.
+// Values that reference an environment variable ("$TOKEN", "${TOKEN}") are kept.
+func redactJSON(value any, path string, locations *[]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			if s, ok := v[key].(string); ok && isSensitiveKey(key) {
+				if s != "" && !strings.HasPrefix(s, "$") {
+					v[key] = ""
+					*locations = append(*locations, child)
+				}
+				continue
+			}
+			redactJSON(v[key], child, locations)
+		}
+	case []any:
+		for i, item := range v {
+			redactJSON(item, fmt.Sprintf("%s[%d]", path, i), locations)
+		}
+	}
+}
+
+// redactJSONC blanks string values under sensitive keys in a JSON document
+// with comments, editing the values in place so comments and layout are
+// kept. Returns the content unchanged when it does not parse.
+func redactJSONC(content []byte) ([]byte, []string) {
+	stripped, err := profile.DocumentJSON(content, profile.FormatJSONC)
+	if err != nil {
+		return content, nil
+	}
+	// Stripping blanks comments without moving anything, so offsets into
+	// stripped are offsets into content
+	w := &jsoncRedactor{src: stripped, dec: json.NewDecoder(bytes.NewReader(stripped))}
+	tok, err := w.dec.Token()
+	if err != nil || w.walk(tok, "") != nil || len(w.spans) == 0 {
+		return content, nil
+	}
+	out := append([]byte(nil), content...)
+	for i := len(w.spans) - 1; i >= 0; i-- {
+		span := w.spans[i]
+		out = append(out[:span[0]], append([]byte(`""`), out[span[1]:]...)...)
+	}
+	return out, w.locations
+}
+
+// jsoncRedactor walks a document's tokens, collecting the byte spans of
+// string values under sensitive keys.
+type jsoncRedactor struct {
+	src       []byte
+	dec       *json.Decoder
+	spans     [][2]int
+	locations []string
+}
+
+func (w *jsoncRedactor) walk(tok json.Token, path string) error {
+	switch tok {
+	case json.Delim('{'):
+		for w.dec.More() {
+			keyTok, err := w.dec.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := keyTok.(string)
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			start := w.dec.InputOffset()
+			value, err := w.dec.Token()
+			if err != nil {
+				return err
+			}
+			if s, ok := value.(string); ok {
+				if isSensitiveKey(key) && s != "" && !strings.HasPrefix(s, "$") {
+					end := int(w.dec.InputOffset())
+					open := int(start) + bytes.IndexByte(w.src[start:end], '"')
+					w.spans = append(w.spans, [2]int{open, end})
+					w.locations = append(w.locations, child)
+				}
+				continue
+			}
+			if err := w.walk(value, child); err != nil {
+				return err
+			}
+		}
+		_, err := w.dec.Token()
+		return err
+	case json.Delim('['):
+		for i := 0; w.dec.More(); i++ {
+			item, err := w.dec.Token()
+			if err != nil {
+				return err
+			}
+			if err := w.walk(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		_, err := w.dec.Token()
+		return err
+	}
+	return nil
+}
+
+// redactYAML blanks string values under sensitive keys in a YAML document,
+// walking the node tree so comments survive. Returns the content unchanged
+// when it does not parse or has nothing to redact.
+func redactYAML(content []byte) ([]byte, []string) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return content, nil
+	}
+	var locations []string
+	redactYAMLNode(&doc, "", &locations)
+	if len(locations) == 0 {
+		return content, nil
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return content, nil
+	}
+	if err := encoder.Close(); err != nil {
+		return content, nil
+	}
+	return buf.Bytes(), locations
+}
+
+// redactYAMLNode is redactJSON for a YAML node tree.
+func redactYAMLNode(node *yaml.Node, path string, locations *[]string) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			redactYAMLNode(child, path, locations)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			child := key.Value
+			if path != "" {
+				child = path + "." + key.Value
+			}
+			if value.Kind == yaml.ScalarNode && value.ShortTag() == "!!str" && isSensitiveKey(key.Value) {
+				if value.Value != "" && !strings.HasPrefix(value.Value, "$") {
+					value.Value = ""
+					value.Style = yaml.DoubleQuotedStyle
+					*locations = append(*locations, child)
+				}
+				continue
+			}
+			redactYAMLNode(value, child, locations)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			redactYAMLNode(item, fmt.Sprintf("%s[%d]", path, i), locations)
+		}
+	}
+}
+
+func encodeJSON(doc any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
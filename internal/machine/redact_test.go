@@ -0,0 +1,207 @@
+// ABOUTME: Tests for stripping secrets from exported files
+// ABOUTME: Covers sensitive keys in JSON, JSONC, and YAML, env references, token formats, and binary files
+package machine
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+)
+
+func TestRedactJSONSensitiveKeys(t *testing.T) {
+	input := `{
+  "mcpServers": {
+    "github": {
+      "command": "gh-mcp",
+      "env": {"GITHUB_TOKEN": "abc123", "API_BASE": "https://api.github.com", "NPM_TOKEN": "${NPM_TOKEN}"}
+    }
+  },
+  "apiKey": "k-1",
+  "maxTokens": 4096
+}`
+	out, redactions := redact("profiles/dev.json", []byte(input))
+
+	var doc map[string]any
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("redacted output is not JSON: %v\n%s", err, out)
+	}
+	env := doc["mcpServers"].(map[string]any)["github"].(map[string]any)["env"].(map[string]any)
+	if env["GITHUB_TOKEN"] != "" {
+		t.Errorf("GITHUB_TOKEN not blanked: %v", env["GITHUB_TOKEN"])
+	}
+	if env["NPM_TOKEN"] != "${NPM_TOKEN}" {
+		t.Errorf("env reference should be kept, got %v", env["NPM_TOKEN"])
+	}
+	if env["API_BASE"] != "https://api.github.com" {
+		t.Errorf("non-secret value changed: %v", env["API_BASE"])
+	}
+	if doc["maxTokens"] != float64(4096) {
+		t.Errorf("numeric value under token-like key changed: %v", doc["maxTokens"])
+	}
+
+	var locations []string
+	for _, r := range redactions {
+		if r.File != "profiles/dev.json" {
+			t.Errorf("unexpected file %q", r.File)
+		}
+		locations = append(locations, r.Location)
+	}
+	want := "apiKey,mcpServers.github.env.GITHUB_TOKEN"
+	if got := strings.Join(locations, ","); got != want {
+		t.Errorf("locations = %s, want %s", got, want)
+	}
+}
+
+func TestRedactKeepsKeysThatOnlyContainSensitiveWords(t *testing.T) {
+	input := `{"env": {"MAX_TOKENS": "8192", "CLAUDE_CODE_MAX_OUTPUT_TOKENS": "32000", "SECRETARY": "pat", "OPENAI_API_KEY": "sk", "awsSecretAccessKey": "x"}}`
+	out, redactions := redact("settings.json", []byte(input))
+
+	var doc map[string]map[string]any
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"MAX_TOKENS", "CLAUDE_CODE_MAX_OUTPUT_TOKENS", "SECRETARY"} {
+		if doc["env"][key] == "" {
+			t.Errorf("%s should not be treated as a secret", key)
+		}
+	}
+	var locations []string
+	for _, r := range redactions {
+		locations = append(locations, r.Location)
+	}
+	if got := strings.Join(locations, ","); got != "env.OPENAI_API_KEY,env.awsSecretAccessKey" {
+		t.Errorf("redacted %s", got)
+	}
+}
+
+func TestKeyWords(t *testing.T) {
+	tests := map[string]string{
+		"GITHUB_TOKEN": "github token",
+		"openAIApiKey": "open ai api key",
+		"apiKey":       "api key",
+		"private-key":  "private key",
+		"maxTokens":    "max tokens",
+	}
+	for key, want := range tests {
+		if got := strings.Join(keyWords(key), " "); got != want {
+			t.Errorf("keyWords(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestRedactTokenPatternsInText(t *testing.T) {
+	token := "ghp_" + strings.Repeat("a", 36)
+	input := "#!/bin/sh\ncurl -H \"Authorization: token " + token + "\" https://api.github.com\necho done\n"
+
+	out, redactions := redact("ext/hooks/notify.sh", []byte(input))
+
+	if strings.Contains(string(out), token) {
+		t.Errorf("token not removed:\n%s", out)
+	}
+	if !strings.Contains(string(out), redactedValue) || !strings.HasSuffix(string(out), "echo done\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if len(redactions) != 1 || redactions[0].Location != "line 2" {
+		t.Errorf("redactions = %+v, want one on line 2", redactions)
+	}
+}
+
+func TestRedactLeavesCleanAndBinaryFilesAlone(t *testing.T) {
+	clean := []byte(`{"agents":{"reviewer.md":true}}`)
+	if out, redactions := redact("enabled.json", clean); string(out) != string(clean) || len(redactions) != 0 {
+		t.Errorf("clean file changed: %s %+v", out, redactions)
+	}
+
+	binary := append([]byte("sk-"+strings.Repeat("x", 30)), 0, 1, 2)
+	if out, redactions := redact("ext/skills/tool/bin", binary); string(out) != string(binary) || len(redactions) != 0 {
+		t.Error("binary file should not be edited")
+	}
+}
+
+func TestRedactYAMLProfile(t *testing.T) {
+	input := `# Development profile
+name: dev
+mcpServers:
+  - name: github
+    command: gh-mcp
+    env:
+      GITHUB_TOKEN: abc123 # personal token
+      NPM_TOKEN: ${NPM_TOKEN}
+      MAX_TOKENS: "8192"
+settings:
+  apiKey: k-1
+`
+	out, redactions := redact("profiles/dev.yaml", []byte(input))
+
+	var doc map[string]any
+	if err := yaml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("redacted output is not YAML: %v\n%s", err, out)
+	}
+	env := doc["mcpServers"].([]any)[0].(map[string]any)["env"].(map[string]any)
+	if env["GITHUB_TOKEN"] != "" {
+		t.Errorf("GITHUB_TOKEN not blanked: %v", env["GITHUB_TOKEN"])
+	}
+	if env["NPM_TOKEN"] != "${NPM_TOKEN}" || env["MAX_TOKENS"] != "8192" {
+		t.Errorf("non-secret values changed: %v", env)
+	}
+	if doc["settings"].(map[string]any)["apiKey"] != "" {
+		t.Error("apiKey not blanked")
+	}
+	if !strings.Contains(string(out), "# Development profile") {
+		t.Errorf("comments should be kept:\n%s", out)
+	}
+
+	var locations []string
+	for _, r := range redactions {
+		locations = append(locations, r.Location)
+	}
+	want := "mcpServers[0].env.GITHUB_TOKEN,settings.apiKey"
+	if got := strings.Join(locations, ","); got != want {
+		t.Errorf("locations = %s, want %s", got, want)
+	}
+}
+
+func TestRedactJSONCProfile(t *testing.T) {
+	input := `{
+  // Development profile
+  "name": "dev",
+  "mcpServers": [
+    {
+      "name": "github",
+      "env": {"GITHUB_TOKEN": "abc\"123", "NPM_TOKEN": "${NPM_TOKEN}"}, // tokens
+    },
+  ],
+}
+`
+	out, redactions := redact("profiles/dev.jsonc", []byte(input))
+
+	want := strings.Replace(input, `"abc\"123"`, `""`, 1)
+	if string(out) != want {
+		t.Errorf("redacted output:\n%s\nwant:\n%s", out, want)
+	}
+	if len(redactions) != 1 || redactions[0].Location != "mcpServers[0].env.GITHUB_TOKEN" {
+		t.Errorf("redactions = %+v", redactions)
+	}
+}
+
+func TestRedactAuthorizationHeaders(t *testing.T) {
+	input := `{"mcpServers": {"api": {"type": "http", "headers": {"Authorization": "Bearer abc", "X-Auth": "k", "Accept": "application/json"}}}}`
+	out, redactions := redact("profiles/dev.json", []byte(input))
+
+	var doc map[string]any
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	headers := doc["mcpServers"].(map[string]any)["api"].(map[string]any)["headers"].(map[string]any)
+	if headers["Authorization"] != "" || headers["X-Auth"] != "" {
+		t.Errorf("auth headers not blanked: %v", headers)
+	}
+	if headers["Accept"] != "application/json" {
+		t.Errorf("non-secret header changed: %v", headers["Accept"])
+	}
+	if len(redactions) != 2 {
+		t.Errorf("redactions = %v", redactions)
+	}
+}
@@ -0,0 +1,72 @@
+// ABOUTME: Rewrites absolute paths from the exporting machine to the importing one
+// ABOUTME: Maps CLAUDEUP_HOME, CLAUDE_CONFIG_DIR, and the home directory, most specific first
+package machine
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// pathRewriter replaces absolute paths of one installation with another's.
+type pathRewriter struct {
+	pattern *regexp.Regexp
+	from    []string
+	to      map[string]string
+}
+
+// newPathRewriter builds a rewriter from the source to the destination
+// paths. It returns nil when there is nothing to rewrite.
+func newPathRewriter(from, to Paths) *pathRewriter {
+	pairs := map[string]string{}
+	for _, pair := range [][2]string{
+		{from.ClaudeupHome, to.ClaudeupHome},
+		{from.ClaudeDir, to.ClaudeDir},
+		{from.Home, to.Home},
+	} {
+		src, dst := strings.TrimRight(pair[0], "/"), strings.TrimRight(pair[1], "/")
+		if src == "" || dst == "" || src == dst {
+			continue
+		}
+		if _, seen := pairs[src]; !seen {
+			pairs[src] = dst
+		}
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	r := &pathRewriter{to: pairs}
+	for src := range pairs {
+		r.from = append(r.from, src)
+	}
+	// Longest first, so ~/.claude is rewritten as a unit before ~ is
+	sort.Slice(r.from, func(i, j int) bool { return len(r.from[i]) > len(r.from[j]) })
+
+	quoted := make([]string, len(r.from))
+	for i, src := range r.from {
+		quoted[i] = regexp.QuoteMeta(src)
+	}
+	// A path only matches when followed by a separator or delimiter, so
+	// /Users/al is not rewritten inside /Users/alice
+	r.pattern = regexp.MustCompile(`(?:` + strings.Join(quoted, "|") + `)(?:[/"'\s:,;)\]}\\]|$)`)
+	return r
+}
+
+// rewrite returns content with every known path replaced, and whether anything changed.
+func (r *pathRewriter) rewrite(content []byte) ([]byte, bool) {
+	if r == nil || !isText(content) {
+		return content, false
+	}
+	changed := false
+	out := r.pattern.ReplaceAllStringFunc(string(content), func(match string) string {
+		for _, src := range r.from {
+			if strings.HasPrefix(match, src) && len(match)-len(src) <= 1 {
+				changed = true
+				return r.to[src] + match[len(src):]
+			}
+		}
+		return match
+	})
+	return []byte(out), changed
+}
@@ -0,0 +1,43 @@
+// ABOUTME: Tests for rewriting absolute paths between installations
+// ABOUTME: Covers ordering of nested roots, path boundaries, and no-op rewrites
+package machine
+
+import "testing"
+
+func TestPathRewriter(t *testing.T) {
+	from := Paths{Home: "/Users/al", ClaudeDir: "/Users/al/.claude", ClaudeupHome: "/opt/claudeup"}
+	to := Paths{Home: "/home/al", ClaudeDir: "/srv/claude", ClaudeupHome: "/home/al/.claudeup"}
+	r := newPathRewriter(from, to)
+
+	tests := []struct {
+		in, want string
+	}{
+		{`"/opt/claudeup/ext/hooks/x.sh"`, `"/home/al/.claudeup/ext/hooks/x.sh"`},
+		{`{"projectDir":"/Users/al/code/app"}`, `{"projectDir":"/home/al/code/app"}`},
+		{`cat /Users/al/.claude/settings.json`, `cat /srv/claude/settings.json`},
+		{`cd /Users/al`, `cd /home/al`},
+		{`/Users/alice/code`, `/Users/alice/code`},
+		{`PATH=/Users/al/bin:/usr/bin`, `PATH=/home/al/bin:/usr/bin`},
+	}
+	for _, tt := range tests {
+		got, changed := r.rewrite([]byte(tt.in))
+		if string(got) != tt.want {
+			t.Errorf("rewrite(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if changed != (tt.in != tt.want) {
+			t.Errorf("rewrite(%q) changed = %v", tt.in, changed)
+		}
+	}
+}
+
+func TestPathRewriterSamePaths(t *testing.T) {
+	paths := Paths{Home: "/home/al", ClaudeDir: "/home/al/.claude", ClaudeupHome: "/home/al/.claudeup"}
+	if r := newPathRewriter(paths, paths); r != nil {
+		t.Error("expected no rewriter when paths are identical")
+	}
+	// A nil rewriter is a no-op
+	var r *pathRewriter
+	if out, changed := r.rewrite([]byte("/home/al")); string(out) != "/home/al" || changed {
+		t.Error("nil rewriter should leave content alone")
+	}
+}
@@ -0,0 +1,98 @@
+// ABOUTME: Finds enabled plugins that come from a given marketplace
+// ABOUTME: Used to block marketplace removal while plugins still depend on it
+package marketplace
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+)
+
+// Dependent is a plugin enabled at a scope whose name references a marketplace.
+type Dependent struct {
+	Plugin string
+	Scope  string
+	// ProjectDir is the project a project or local dependent is enabled in.
+	ProjectDir string
+}
+
+// EnabledDependents returns plugins named "<plugin>@<name>" that are enabled
+// in user settings, plus project and local settings when projectDir is a
+// Claude project. Project and local installs in other projects are found
+// through the projectPath installed_plugins.json records for them, and count
+// when that project's settings still enable them. Results are sorted by
+// scope precedence, then project, then plugin name.
+func EnabledDependents(claudeDir, projectDir, name string) ([]Dependent, error) {
+	scopes := []string{claude.ScopeUser}
+	inProject := projectDir != "" && claude.IsProjectContext(claudeDir, projectDir)
+	if inProject {
+		scopes = append(scopes, claude.ScopeProject, claude.ScopeLocal)
+	}
+
+	suffix := "@" + name
+	var deps []Dependent
+	for _, scope := range scopes {
+		settings, err := claude.LoadSettingsForScope(scope, claudeDir, projectDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s settings: %w", scope, err)
+		}
+		for plugin, enabled := range settings.EnabledPlugins {
+			if enabled && strings.HasSuffix(plugin, suffix) {
+				dep := Dependent{Plugin: plugin, Scope: scope}
+				if scope != claude.ScopeUser {
+					dep.ProjectDir = projectDir
+				}
+				deps = append(deps, dep)
+			}
+		}
+	}
+
+	registry, err := claude.LoadPlugins(claudeDir)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[Dependent]bool)
+	for _, sp := range registry.GetPluginsAtScopes([]string{claude.ScopeProject, claude.ScopeLocal}) {
+		if !strings.HasSuffix(sp.Name, suffix) || sp.ProjectPath == "" {
+			continue
+		}
+		if inProject && sameDir(sp.ProjectPath, projectDir) {
+			continue
+		}
+		dep := Dependent{Plugin: sp.Name, Scope: sp.Scope, ProjectDir: sp.ProjectPath}
+		if seen[dep] {
+			continue
+		}
+		seen[dep] = true
+		// A project that is gone, or no longer enables the plugin, doesn't need the marketplace
+		settings, err := claude.LoadSettingsForScope(sp.Scope, claudeDir, sp.ProjectPath)
+		if err != nil || !settings.EnabledPlugins[sp.Name] {
+			continue
+		}
+		deps = append(deps, dep)
+	}
+
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Scope != deps[j].Scope {
+			return claude.ScopePrecedence(deps[i].Scope) < claude.ScopePrecedence(deps[j].Scope)
+		}
+		if deps[i].ProjectDir != deps[j].ProjectDir {
+			return deps[i].ProjectDir < deps[j].ProjectDir
+		}
+		return deps[i].Plugin < deps[j].Plugin
+	})
+	return deps, nil
+}
+
+// sameDir reports whether a and b name the same existing directory.
+func sameDir(a, b string) bool {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	return err == nil && os.SameFile(ai, bi)
+}
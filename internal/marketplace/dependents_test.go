@@ -0,0 +1,112 @@
+// ABOUTME: Unit tests for finding enabled plugins that depend on a marketplace
+// ABOUTME: Covers user, project, and local scope settings, including other projects
+package marketplace
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeSettings(t *testing.T, path string, enabled map[string]bool) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(map[string]any{"enabledPlugins": enabled})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnabledDependents(t *testing.T) {
+	root := t.TempDir()
+	claudeDir := filepath.Join(root, ".claude")
+	projectDir := filepath.Join(root, "project")
+
+	writeSettings(t, filepath.Join(claudeDir, "settings.json"), map[string]bool{
+		"b-plugin@acme":     true,
+		"a-plugin@acme":     true,
+		"disabled@acme":     false,
+		"other@acme-extras": true,
+	})
+	writeSettings(t, filepath.Join(projectDir, ".claude", "settings.json"), map[string]bool{
+		"a-plugin@acme": true,
+	})
+	writeSettings(t, filepath.Join(projectDir, ".claude", "settings.local.json"), map[string]bool{
+		"local-only@acme": true,
+	})
+
+	deps, err := EnabledDependents(claudeDir, projectDir, "acme")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Dependent{
+		{Plugin: "a-plugin@acme", Scope: "user"},
+		{Plugin: "b-plugin@acme", Scope: "user"},
+		{Plugin: "a-plugin@acme", Scope: "project", ProjectDir: projectDir},
+		{Plugin: "local-only@acme", Scope: "local", ProjectDir: projectDir},
+	}
+	if !reflect.DeepEqual(deps, want) {
+		t.Fatalf("got %+v, want %+v", deps, want)
+	}
+}
+
+func TestEnabledDependentsIgnoresNonProjectDir(t *testing.T) {
+	root := t.TempDir()
+	claudeDir := filepath.Join(root, ".claude")
+	writeSettings(t, filepath.Join(claudeDir, "settings.json"), map[string]bool{"x@acme": true})
+
+	deps, err := EnabledDependents(claudeDir, filepath.Join(root, "not-a-project"), "acme")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deps) != 1 || deps[0].Scope != "user" {
+		t.Fatalf("expected only the user-scope dependent, got %+v", deps)
+	}
+}
+
+func TestEnabledDependentsFindsOtherProjects(t *testing.T) {
+	root := t.TempDir()
+	claudeDir := filepath.Join(root, ".claude")
+	here := filepath.Join(root, "here")
+	other := filepath.Join(root, "other")
+	gone := filepath.Join(root, "gone")
+	writeSettings(t, filepath.Join(claudeDir, "settings.json"), map[string]bool{})
+	writeSettings(t, filepath.Join(other, ".claude", "settings.json"), map[string]bool{"lint@acme": true})
+	writeSettings(t, filepath.Join(other, ".claude", "settings.local.json"), map[string]bool{"off@acme": false})
+
+	registry := map[string]any{
+		"version": 2,
+		"plugins": map[string]any{
+			"lint@acme": []map[string]any{{"scope": "project", "projectPath": other}},
+			"off@acme":  []map[string]any{{"scope": "local", "projectPath": other}},
+			"old@acme":  []map[string]any{{"scope": "project", "projectPath": gone}},
+			"x@extras":  []map[string]any{{"scope": "project", "projectPath": other}},
+		},
+	}
+	data, err := json.Marshal(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(claudeDir, "plugins"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(claudeDir, "plugins", "installed_plugins.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := EnabledDependents(claudeDir, here, "acme")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Dependent{{Plugin: "lint@acme", Scope: "project", ProjectDir: other}}
+	if !reflect.DeepEqual(deps, want) {
+		t.Fatalf("got %+v, want %+v", deps, want)
+	}
+}
@@ -0,0 +1,151 @@
+// ABOUTME: Git helpers for cloning, inspecting, and moving marketplace checkouts
+// ABOUTME: Wraps rev-parse, fetch, and checkout with timeouts for network operations
+package marketplace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// GitTimeout bounds git operations that talk to the remote.
+const GitTimeout = 30 * time.Second
+
+// CloneTimeout bounds a fresh clone, which transfers the full history.
+const CloneTimeout = 5 * time.Minute
+
+// IsGitRepo reports whether dir is the root of a git checkout.
+func IsGitRepo(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// Head returns the full commit hash checked out in dir.
+func Head(dir string) (string, error) {
+	return gitOutput(dir, "rev-parse", "HEAD")
+}
+
+// Clone clones url into dir, which must not exist yet.
+func Clone(url, dir string) error {
+	if strings.HasPrefix(url, "-") {
+		return fmt.Errorf("invalid clone URL %q", url)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), CloneTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "clone", "--quiet", "--", url, dir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("git clone timed out after %s", CloneTimeout)
+		}
+		return fmt.Errorf("git clone failed: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Fetch fetches branches and tags from origin.
+func Fetch(dir string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), GitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "-C", dir, "fetch", "--tags", "origin")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("git fetch timed out after %s", GitTimeout)
+		}
+		return fmt.Errorf("git fetch failed: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ResolveRef resolves a tag, commit, or branch name to a full commit hash.
+// Branch names are also tried as origin/<ref> so remote-only branches resolve.
+func ResolveRef(dir, ref string) (string, error) {
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("invalid ref %q", ref)
+	}
+	for _, candidate := range []string{ref, "origin/" + ref} {
+		if commit, err := gitOutput(dir, "rev-parse", "--verify", "--quiet", candidate+"^{commit}"); err == nil {
+			return commit, nil
+		}
+	}
+	return "", fmt.Errorf("ref %q not found in %s", ref, dir)
+}
+
+// CheckoutDetached checks out commit in dir with a detached HEAD.
+func CheckoutDetached(dir, commit string) error {
+	if _, err := gitOutput(dir, "checkout", "--quiet", "--detach", commit); err != nil {
+		return fmt.Errorf("git checkout %s failed: %w", commit, err)
+	}
+	return nil
+}
+
+// DefaultBranch returns the branch origin/HEAD points at, falling back to
+// main or master when the remote HEAD is not recorded locally.
+func DefaultBranch(dir string) (string, error) {
+	if ref, err := gitOutput(dir, "symbolic-ref", "--quiet", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimPrefix(ref, "refs/remotes/origin/"), nil
+	}
+	for _, branch := range []string{"main", "master"} {
+		if _, err := gitOutput(dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+			return branch, nil
+		}
+		if _, err := gitOutput(dir, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+branch); err == nil {
+			return branch, nil
+		}
+	}
+	return "", fmt.Errorf("cannot determine default branch in %s", dir)
+}
+
+// CheckoutBranch switches dir back onto branch, creating a local tracking
+// branch from origin when needed.
+func CheckoutBranch(dir, branch string) error {
+	if _, err := gitOutput(dir, "checkout", "--quiet", branch); err != nil {
+		return fmt.Errorf("git checkout %s failed: %w", branch, err)
+	}
+	return nil
+}
+
+// IsRemoteBranch reports whether ref names a branch on origin, as opposed
+// to a tag or commit.
+func IsRemoteBranch(dir, ref string) bool {
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return false
+	}
+	_, err := gitOutput(dir, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+ref)
+	return err == nil
+}
+
+// IsDetached reports whether dir has a detached HEAD.
+func IsDetached(dir string) bool {
+	_, err := gitOutput(dir, "symbolic-ref", "--quiet", "HEAD")
+	return err != nil
+}
+
+// CheckoutTrackingBranch checks out branch at origin/<branch>, creating or
+// resetting the local branch so later pulls follow the remote.
+func CheckoutTrackingBranch(dir, branch string) error {
+	if _, err := gitOutput(dir, "checkout", "--quiet", "-B", branch, "--track", "origin/"+branch); err != nil {
+		return fmt.Errorf("git checkout %s failed: %w", branch, err)
+	}
+	return nil
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,148 @@
+// ABOUTME: Unit tests for marketplace git helpers
+// ABOUTME: Uses a local bare remote to exercise fetch, ref resolution, and checkout
+package marketplace
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runGit runs a git command in dir with a fixed identity and no signing.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "commit.gpgsign=false", "-c", "tag.gpgsign=false"}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test",
+		"GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test",
+		"GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func commitFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "commit", "-m", "update "+name)
+	return runGit(t, dir, "rev-parse", "HEAD")
+}
+
+// setupClone creates a bare remote with two commits (the first tagged v1)
+// and returns a clone of it along with both commit hashes.
+func setupClone(t *testing.T) (clone, first, second string) {
+	t.Helper()
+	root := t.TempDir()
+	bare := filepath.Join(root, "remote.git")
+	work := filepath.Join(root, "work")
+	clone = filepath.Join(root, "clone")
+
+	runGit(t, root, "init", "--bare", "--initial-branch=main", bare)
+	runGit(t, root, "clone", bare, work)
+	runGit(t, work, "checkout", "-b", "main")
+	first = commitFile(t, work, "README.md", "one")
+	runGit(t, work, "tag", "v1")
+	second = commitFile(t, work, "README.md", "two")
+	runGit(t, work, "push", "--tags", "origin", "main")
+	runGit(t, root, "clone", bare, clone)
+	return clone, first, second
+}
+
+func TestIsGitRepo(t *testing.T) {
+	clone, _, _ := setupClone(t)
+	if !IsGitRepo(clone) {
+		t.Fatal("expected clone to be a git repo")
+	}
+	if IsGitRepo(t.TempDir()) {
+		t.Fatal("expected empty dir not to be a git repo")
+	}
+}
+
+func TestPinAndUnpinRoundTrip(t *testing.T) {
+	clone, first, second := setupClone(t)
+
+	if err := Fetch(clone); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	commit, err := ResolveRef(clone, "v1")
+	if err != nil {
+		t.Fatalf("resolve tag failed: %v", err)
+	}
+	if commit != first {
+		t.Fatalf("expected %s, got %s", first, commit)
+	}
+
+	if err := CheckoutDetached(clone, commit); err != nil {
+		t.Fatalf("checkout failed: %v", err)
+	}
+	if head, _ := Head(clone); head != first {
+		t.Fatalf("expected HEAD %s, got %s", first, head)
+	}
+
+	branch, err := DefaultBranch(clone)
+	if err != nil {
+		t.Fatalf("default branch failed: %v", err)
+	}
+	if branch != "main" {
+		t.Fatalf("expected main, got %s", branch)
+	}
+	if err := CheckoutBranch(clone, branch); err != nil {
+		t.Fatalf("checkout branch failed: %v", err)
+	}
+	if head, _ := Head(clone); head != second {
+		t.Fatalf("expected HEAD %s after unpin, got %s", second, head)
+	}
+}
+
+func TestResolveRefAcceptsShortHashesAndRemoteBranches(t *testing.T) {
+	clone, first, second := setupClone(t)
+
+	commit, err := ResolveRef(clone, first[:7])
+	if err != nil || commit != first {
+		t.Fatalf("short hash: got %q, %v", commit, err)
+	}
+
+	runGit(t, clone, "checkout", "--detach", first)
+	runGit(t, clone, "branch", "-D", "main")
+	commit, err = ResolveRef(clone, "main")
+	if err != nil || commit != second {
+		t.Fatalf("remote branch: got %q, %v", commit, err)
+	}
+}
+
+func TestResolveRefRejectsUnknownAndOptionLikeRefs(t *testing.T) {
+	clone, _, _ := setupClone(t)
+
+	for _, ref := range []string{"does-not-exist", "--all", ""} {
+		if _, err := ResolveRef(clone, ref); err == nil {
+			t.Errorf("expected error for ref %q", ref)
+		}
+	}
+}
+
+func TestCloneChecksOutDefaultBranch(t *testing.T) {
+	clone, _, second := setupClone(t)
+	dest := filepath.Join(t.TempDir(), "copy")
+
+	if err := Clone(clone, dest); err != nil {
+		t.Fatal(err)
+	}
+	if head, _ := Head(dest); head != second {
+		t.Fatalf("expected HEAD %s after clone, got %s", second, head)
+	}
+	if err := Clone(clone, dest); err == nil {
+		t.Error("expected error cloning into an existing checkout")
+	}
+	if err := Clone("--upload-pack=evil", filepath.Join(t.TempDir(), "x")); err == nil {
+		t.Error("expected error for option-like URL")
+	}
+}
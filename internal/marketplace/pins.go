@@ -0,0 +1,69 @@
+// ABOUTME: Persists marketplace pins recording the ref each marketplace is held at
+// ABOUTME: Pinned marketplaces are skipped by upgrade and marketplace update
+package marketplace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const pinsFilename = "marketplace-pins.json"
+
+// Pin records the ref a marketplace was pinned to and the commit it resolved to.
+type Pin struct {
+	Ref      string    `json:"ref"`
+	Commit   string    `json:"commit"`
+	PinnedAt time.Time `json:"pinnedAt"`
+}
+
+// Pins maps marketplace names (keys in known_marketplaces.json) to their pins.
+type Pins map[string]Pin
+
+// LoadPins reads the pin file from claudeupHome.
+// Returns an empty Pins if the file does not exist.
+func LoadPins(claudeupHome string) (Pins, error) {
+	path := filepath.Join(claudeupHome, pinsFilename)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Pins{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var p Pins
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if p == nil {
+		p = Pins{}
+	}
+	return p, nil
+}
+
+// SavePins writes the pin file atomically (write-tmp + rename).
+func SavePins(claudeupHome string, p Pins) error {
+	path := filepath.Join(claudeupHome, pinsFilename)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating pin directory: %w", err)
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp) // best-effort cleanup
+		return fmt.Errorf("writing marketplace pins: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// ABOUTME: Unit tests for the marketplace pin store
+// ABOUTME: Covers missing files, round-trips, and parse errors
+package marketplace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadPinsMissingFile(t *testing.T) {
+	pins, err := LoadPins(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pins) != 0 {
+		t.Fatalf("expected no pins, got %d", len(pins))
+	}
+}
+
+func TestSaveAndLoadPins(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "claudeup")
+	pinnedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	pins := Pins{
+		"acme": {Ref: "v1.2.0", Commit: "0123456789abcdef", PinnedAt: pinnedAt},
+	}
+	if err := SavePins(dir, pins); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	loaded, err := LoadPins(dir)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	got, ok := loaded["acme"]
+	if !ok {
+		t.Fatal("missing acme pin")
+	}
+	if got.Ref != "v1.2.0" || got.Commit != "0123456789abcdef" || !got.PinnedAt.Equal(pinnedAt) {
+		t.Fatalf("unexpected pin: %+v", got)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, pinsFilename+".tmp")); !os.IsNotExist(err) {
+		t.Fatal("temporary file left behind")
+	}
+}
+
+func TestLoadPinsInvalidJSON(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, pinsFilename), []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPins(dir); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
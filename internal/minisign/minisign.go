@@ -0,0 +1,206 @@
+// ABOUTME: minisign-compatible Ed25519 keys and detached signatures, using only the standard library
+// ABOUTME: Shared by signed profiles and by self-update's verification of release checksums
+package minisign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Extension is appended to a file's name for its detached signature
+// ("checksums.txt.minisig").
+const Extension = ".minisig"
+
+// Signature algorithm tags used by minisign. Only the original algorithm,
+// which signs the file itself, is supported; the prehashed one needs
+// BLAKE2b.
+var (
+	algEd        = []byte("Ed")
+	algPrehashed = []byte("ED")
+)
+
+// Errors returned by PublicKey.Verify.
+var (
+	ErrMismatch       = errors.New("signature does not match")
+	ErrCommentAltered = errors.New("the signature's trusted comment was altered")
+)
+
+// PublicKey is an Ed25519 public key with its minisign key ID.
+type PublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// IDString renders the key ID the way minisign prints it.
+func (k PublicKey) IDString() string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(k.ID[:]))
+}
+
+// Marshal renders the key as a minisign public key file.
+func (k PublicKey) Marshal() []byte {
+	blob := append(append(append([]byte(nil), algEd...), k.ID[:]...), k.Key...)
+	return []byte(fmt.Sprintf("untrusted comment: minisign public key %s\n%s\n", k.IDString(), base64.StdEncoding.EncodeToString(blob)))
+}
+
+// Verify checks that sig was made by this key over data, and that the
+// signature's trusted comment is the one that was signed.
+func (k PublicKey) Verify(data []byte, sig *Signature) error {
+	if sig.KeyID != k.ID {
+		return fmt.Errorf("signed by key %s, not %s", PublicKey{ID: sig.KeyID}.IDString(), k.IDString())
+	}
+	if !ed25519.Verify(k.Key, data, sig.sig) {
+		return ErrMismatch
+	}
+	if !ed25519.Verify(k.Key, append(append([]byte(nil), sig.sig...), sig.TrustedComment...), sig.global) {
+		return ErrCommentAltered
+	}
+	return nil
+}
+
+// ParsePublicKey reads a minisign public key file, or its bare base64 line.
+func ParsePublicKey(data []byte) (*PublicKey, error) {
+	lines := nonEmptyLines(data)
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "untrusted comment:") {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("invalid public key: empty")
+	}
+	blob, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(blob) != 2+8+ed25519.PublicKeySize || !bytes.Equal(blob[:2], algEd) {
+		return nil, fmt.Errorf("invalid public key: expected a minisign Ed25519 key")
+	}
+	k := &PublicKey{Key: ed25519.PublicKey(blob[10:])}
+	copy(k.ID[:], blob[2:10])
+	return k, nil
+}
+
+// SecretKey is an Ed25519 signing key. claudeup stores it unencrypted,
+// readable only by its owner, in a minisign-like file of its own format.
+type SecretKey struct {
+	ID  [8]byte
+	Key ed25519.PrivateKey
+}
+
+// GenerateKey creates a signing key with a random key ID.
+func GenerateKey() (*SecretKey, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	k := &SecretKey{Key: private}
+	if _, err := rand.Read(k.ID[:]); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Public returns the key's public half.
+func (k *SecretKey) Public() PublicKey {
+	return PublicKey{ID: k.ID, Key: k.Key.Public().(ed25519.PublicKey)}
+}
+
+// Marshal renders the key as a claudeup secret key file.
+func (k *SecretKey) Marshal() []byte {
+	blob := append(append(append([]byte(nil), algEd...), k.ID[:]...), k.Key...)
+	return []byte(fmt.Sprintf("untrusted comment: claudeup secret key %s\n%s\n", k.Public().IDString(), base64.StdEncoding.EncodeToString(blob)))
+}
+
+// ParseSecretKey reads a key written by SecretKey.Marshal.
+func ParseSecretKey(data []byte) (*SecretKey, error) {
+	lines := nonEmptyLines(data)
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "untrusted comment: claudeup secret key") {
+		return nil, fmt.Errorf("invalid secret key: not a claudeup signing key")
+	}
+	blob, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(blob) != 2+8+ed25519.PrivateKeySize || !bytes.Equal(blob[:2], algEd) {
+		return nil, fmt.Errorf("invalid secret key: corrupt key data")
+	}
+	k := &SecretKey{Key: ed25519.PrivateKey(blob[10:])}
+	copy(k.ID[:], blob[2:10])
+	return k, nil
+}
+
+// Sign returns a minisign signature file for data. The trusted comment is
+// signed too, so it can't be changed without breaking the signature.
+func Sign(data []byte, key *SecretKey, trustedComment string) []byte {
+	sig := ed25519.Sign(key.Key, data)
+	global := ed25519.Sign(key.Key, append(append([]byte(nil), sig...), trustedComment...))
+	blob := append(append(append([]byte(nil), algEd...), key.ID[:]...), sig...)
+	return []byte(fmt.Sprintf("untrusted comment: signature from claudeup secret key %s\n%s\ntrusted comment: %s\n%s\n",
+		key.Public().IDString(), base64.StdEncoding.EncodeToString(blob), trustedComment, base64.StdEncoding.EncodeToString(global)))
+}
+
+// Signature is a parsed minisign signature file.
+type Signature struct {
+	KeyID          [8]byte
+	TrustedComment string
+	sig            []byte
+	global         []byte
+}
+
+// ParseSignature reads a minisign signature file.
+func ParseSignature(data []byte) (*Signature, error) {
+	lines := nonEmptyLines(data)
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "untrusted comment:") || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return nil, fmt.Errorf("invalid signature file")
+	}
+	blob, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(blob) != 2+8+ed25519.SignatureSize {
+		return nil, fmt.Errorf("invalid signature file")
+	}
+	if bytes.Equal(blob[:2], algPrehashed) {
+		return nil, fmt.Errorf("prehashed minisign signatures are not supported; sign with 'minisign -S -l'")
+	}
+	if !bytes.Equal(blob[:2], algEd) {
+		return nil, fmt.Errorf("invalid signature file: unknown algorithm")
+	}
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("invalid signature file")
+	}
+	s := &Signature{sig: blob[10:], TrustedComment: strings.TrimPrefix(lines[2], "trusted comment: "), global: global}
+	copy(s.KeyID[:], blob[2:10])
+	return s, nil
+}
+
+// Timestamp reads the "timestamp:" field minisign puts in trusted comments.
+func (s *Signature) Timestamp() time.Time {
+	for _, field := range strings.Fields(s.TrustedComment) {
+		if value, ok := strings.CutPrefix(field, "timestamp:"); ok {
+			var seconds int64
+			if _, err := fmt.Sscanf(value, "%d", &seconds); err == nil {
+				return time.Unix(seconds, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// File reads the "file:" field minisign puts in trusted comments. Fields
+// are tab-separated, so the name may contain spaces.
+func (s *Signature) File() string {
+	for _, field := range strings.Split(s.TrustedComment, "\t") {
+		if value, ok := strings.CutPrefix(field, "file:"); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func nonEmptyLines(data []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
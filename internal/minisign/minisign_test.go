@@ -0,0 +1,69 @@
+// ABOUTME: Tests for minisign key files and signatures
+// ABOUTME: Covers key round trips, verification failures, and rejection of prehashed signatures
+package minisign
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeyFilesRoundTrip(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseSecretKey(key.Marshal())
+	if err != nil || parsed.ID != key.ID || !parsed.Key.Equal(key.Key) {
+		t.Fatalf("secret key did not round trip: %v", err)
+	}
+	public := key.Public()
+	parsedPublic, err := ParsePublicKey(public.Marshal())
+	if err != nil || parsedPublic.IDString() != public.IDString() || !parsedPublic.Key.Equal(public.Key) {
+		t.Fatalf("public key did not round trip: %v", err)
+	}
+	if !strings.HasPrefix(string(public.Marshal()), "untrusted comment: minisign public key "+public.IDString()) {
+		t.Errorf("public key should use the minisign format:\n%s", public.Marshal())
+	}
+}
+
+func TestVerify(t *testing.T) {
+	key, _ := GenerateKey()
+	other, _ := GenerateKey()
+	public := key.Public()
+	sig, err := ParseSignature(Sign([]byte("data"), key, "timestamp:1700000000\tfile:data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := public.Verify([]byte("data"), sig); err != nil {
+		t.Errorf("Verify() = %v", err)
+	}
+	if got := sig.Timestamp().Unix(); got != 1700000000 {
+		t.Errorf("Timestamp() = %d", got)
+	}
+	if got := sig.File(); got != "data" {
+		t.Errorf("File() = %q", got)
+	}
+	if err := public.Verify([]byte("date"), sig); !errors.Is(err, ErrMismatch) {
+		t.Errorf("changed data should not verify, got %v", err)
+	}
+	if err := other.Public().Verify([]byte("data"), sig); err == nil || !strings.Contains(err.Error(), "signed by key "+public.IDString()) {
+		t.Errorf("another key should not verify, got %v", err)
+	}
+	sig.TrustedComment = "timestamp:1800000000"
+	if err := public.Verify([]byte("data"), sig); !errors.Is(err, ErrCommentAltered) {
+		t.Errorf("an edited trusted comment should not verify, got %v", err)
+	}
+}
+
+func TestParseSignatureRejectsPrehashed(t *testing.T) {
+	key, _ := GenerateKey()
+	lines := strings.Split(string(Sign([]byte("data"), key, "c")), "\n")
+	lines[1] = "RUQ" + lines[1][3:]
+
+	_, err := ParseSignature([]byte(strings.Join(lines, "\n")))
+	if err == nil || !strings.Contains(err.Error(), "minisign -S -l") {
+		t.Errorf("expected prehashed signatures to be rejected, got %v", err)
+	}
+}
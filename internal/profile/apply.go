@@ -7,7 +7,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/fs"
 	"net/url"
 	"os"
 	"os/exec"
@@ -17,6 +16,7 @@ import (
 	"github.com/claudeup/claudeup/v5/internal/claude"
 	"github.com/claudeup/claudeup/v5/internal/config"
 	"github.com/claudeup/claudeup/v5/internal/ext"
+	"github.com/claudeup/claudeup/v5/internal/ledger"
 	"github.com/claudeup/claudeup/v5/internal/secrets"
 	"github.com/claudeup/claudeup/v5/internal/ui"
 )
@@ -29,6 +29,7 @@ type ApplyOptions struct {
 	Reinstall    bool             // If true, reinstall even if already installed
 	ShowProgress bool             // If true, use concurrent apply with progress UI (project/local scope only)
 	Progress     ProgressCallback // Optional progress callback for sequential installs
+	Executor     CommandExecutor  // Runs plugin and MCP commands; nil = DefaultExecutor
 }
 
 // CommandExecutor runs claude CLI commands
@@ -47,9 +48,13 @@ func (e *DefaultExecutor) Run(args ...string) error {
 	return runClaude(e.ClaudeDir, args...)
 }
 
-// RunWithOutput executes the claude CLI and returns captured output
+// RunWithOutput executes the claude CLI and returns captured output.
+// Removing an item that is not there returns an error matching
+// ErrNotInstalled; installing a plugin that is already there returns one
+// matching ErrAlreadyInstalled.
 func (e *DefaultExecutor) RunWithOutput(args ...string) (string, error) {
-	return runClaudeWithOutput(e.ClaudeDir, args...)
+	output, err := runClaudeWithOutput(e.ClaudeDir, args...)
+	return output, classifyCLIError(args, output, err)
 }
 
 // ApplyResult contains the results of applying a profile
@@ -75,6 +80,15 @@ type Diff struct {
 	MCPToInstall         []MCPServer
 	MarketplacesToAdd    []Marketplace
 	MarketplacesToRemove []Marketplace
+	// SettingsToChange lists settings block keys where the target scope
+	// differs from the profile (see diffSettings for the Op meanings)
+	SettingsToChange []DiffItem
+	// HooksToAdd lists profile hooks missing from user settings.json or
+	// differing there (Op removed or modified, as diffSettingsHooks reports them)
+	HooksToAdd []DiffItem
+	// MemoryToWrite lists memory fragments whose managed block in the target
+	// scope's CLAUDE.md is missing (Op removed) or differs from the library (Op modified)
+	MemoryToWrite []DiffItem
 }
 
 // DiffOptions controls how a diff is computed
@@ -214,6 +228,27 @@ func ComputeDiffWithScope(profile *Profile, claudeDir, claudeJSONPath, claudeupH
 		}
 	}
 
+	// Settings block: compare against the target scope's settings file
+	if profile.Settings != nil {
+		if live, err := claude.LoadSettingsForScope(string(scope), claudeDir, opts.ProjectDir); err == nil {
+			diff.SettingsToChange = diffSettings(profile.Settings, readSettingsBlock(live))
+		}
+	}
+
+	diff.MemoryToWrite = memoryChanges(profile.Memory, string(scope), claudeDir, opts.ProjectDir, claudeupHome)
+
+	// Hooks always merge into user settings.json; hooks already there from
+	// other sources are left alone, so only missing or changed ones count
+	if len(profile.SettingsHooks) > 0 {
+		if live, err := claude.LoadSettingsOrEmpty(claudeDir); err == nil {
+			for _, item := range diffSettingsHooks(profile.SettingsHooks, readSettingsHooks(live)) {
+				if item.Op != DiffAdded {
+					diff.HooksToAdd = append(diff.HooksToAdd, item)
+				}
+			}
+		}
+	}
+
 	return diff, nil
 }
 
@@ -233,7 +268,10 @@ func ApplyWithOptions(profile *Profile, claudeDir, claudeJSONPath, claudeupHome
 		return nil, fmt.Errorf("project directory required for %s scope", opts.Scope)
 	}
 
-	executor := &DefaultExecutor{ClaudeDir: claudeDir}
+	executor := opts.Executor
+	if executor == nil {
+		executor = &DefaultExecutor{ClaudeDir: claudeDir}
+	}
 
 	// Use concurrent apply with progress tracking for project/local scope.
 	// User scope always uses sequential apply because it needs declarative behavior
@@ -264,6 +302,12 @@ func ApplyWithOptions(profile *Profile, claudeDir, claudeJSONPath, claudeupHome
 				return nil, err
 			}
 		}
+		if err := applyScopeSettingsBlock(profile.Settings, string(opts.Scope), claudeDir, opts.ProjectDir); err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+		if err := applyScopeMemory(profile.Memory, string(opts.Scope), claudeDir, opts.ProjectDir, claudeupHome); err != nil {
+			result.Errors = append(result.Errors, err)
+		}
 
 		return result, nil
 	}
@@ -355,10 +399,11 @@ func applyProjectScope(profile *Profile, claudeDir, claudeJSONPath, claudeupHome
 	}
 
 	// 2. Add marketplaces (user-level, needed to resolve plugins)
-	validMarketplaces := filterValidMarketplaceKeys(profile.Marketplaces)
-	for i, key := range validMarketplaces {
+	validMarketplaces := filterValidMarketplaces(profile.Marketplaces)
+	for i, m := range validMarketplaces {
+		key := marketplaceKey(m)
 		fmt.Printf("  [%d/%d] Adding marketplace %s\n", i+1, len(validMarketplaces), key)
-		output, err := executor.RunWithOutput("plugin", "marketplace", "add", key)
+		output, err := executor.RunWithOutput("plugin", "marketplace", "add", marketplaceAddArg(m))
 		if err != nil {
 			// Check if already installed - treat as success
 			if strings.Contains(output, "already installed") {
@@ -409,6 +454,16 @@ func applyProjectScope(profile *Profile, claudeDir, claudeJSONPath, claudeupHome
 		result.Errors = append(result.Errors, err)
 	}
 
+	// 7. Apply the settings block to project settings.json
+	if err := applyScopeSettingsBlock(profile.Settings, "project", claudeDir, opts.ProjectDir); err != nil {
+		result.Errors = append(result.Errors, err)
+	}
+
+	// 8. Compose memory fragments into the project CLAUDE.md
+	if err := applyScopeMemory(profile.Memory, "project", claudeDir, opts.ProjectDir, claudeupHome); err != nil {
+		result.Errors = append(result.Errors, err)
+	}
+
 	return result, nil
 }
 
@@ -468,10 +523,11 @@ func applyLocalScope(profile *Profile, claudeDir, claudeJSONPath, claudeupHome s
 	}
 
 	// 3. Add marketplaces (user-level)
-	validMarketplaces := filterValidMarketplaceKeys(profile.Marketplaces)
-	for i, key := range validMarketplaces {
+	validMarketplaces := filterValidMarketplaces(profile.Marketplaces)
+	for i, m := range validMarketplaces {
+		key := marketplaceKey(m)
 		fmt.Printf("  [%d/%d] Adding marketplace %s\n", i+1, len(validMarketplaces), key)
-		output, err := executor.RunWithOutput("plugin", "marketplace", "add", key)
+		output, err := executor.RunWithOutput("plugin", "marketplace", "add", marketplaceAddArg(m))
 		if err != nil {
 			if strings.Contains(output, "already installed") {
 				result.MarketplacesAdded = append(result.MarketplacesAdded, key)
@@ -521,6 +577,16 @@ func applyLocalScope(profile *Profile, claudeDir, claudeJSONPath, claudeupHome s
 		result.Errors = append(result.Errors, err)
 	}
 
+	// 8. Apply the settings block to settings.local.json
+	if err := applyScopeSettingsBlock(profile.Settings, "local", claudeDir, opts.ProjectDir); err != nil {
+		result.Errors = append(result.Errors, err)
+	}
+
+	// 9. Compose memory fragments into CLAUDE.local.md
+	if err := applyScopeMemory(profile.Memory, "local", claudeDir, opts.ProjectDir, claudeupHome); err != nil {
+		result.Errors = append(result.Errors, err)
+	}
+
 	return result, nil
 }
 
@@ -622,11 +688,7 @@ func applyUserScope(profile *Profile, claudeDir, claudeJSONPath, claudeupHome st
 			var marketplaceName string
 			repoKey := marketplaceKey(m)
 			for name, meta := range marketplaceRegistry {
-				metaKey := meta.Source.Repo
-				if metaKey == "" {
-					metaKey = meta.Source.URL
-				}
-				if metaKey == repoKey {
+				if marketplaceKey(marketplaceFromSource(meta.Source)) == repoKey {
 					marketplaceName = name
 					break
 				}
@@ -636,7 +698,7 @@ func applyUserScope(profile *Profile, claudeDir, claudeJSONPath, claudeupHome st
 				output, err := executor.RunWithOutput("plugin", "marketplace", "remove", marketplaceName)
 				if err != nil {
 					// Check if already removed - treat as success
-					if strings.Contains(output, "not found") || strings.Contains(output, "not installed") {
+					if errors.Is(err, ErrNotInstalled) {
 						result.MarketplacesRemoved = append(result.MarketplacesRemoved, repoKey)
 					} else {
 						result.Errors = append(result.Errors, fmt.Errorf("failed to remove marketplace %s (%s): %w\n  Output: %s", marketplaceName, repoKey, err, strings.TrimSpace(output)))
@@ -649,10 +711,11 @@ func applyUserScope(profile *Profile, claudeDir, claudeJSONPath, claudeupHome st
 	}
 
 	// Add marketplaces
-	validMarketplaces := filterValidMarketplaceKeys(diff.MarketplacesToAdd)
-	for i, key := range validMarketplaces {
+	validMarketplaces := filterValidMarketplaces(diff.MarketplacesToAdd)
+	for i, m := range validMarketplaces {
+		key := marketplaceKey(m)
 		fmt.Printf("  [%d/%d] Adding marketplace %s\n", i+1, len(validMarketplaces), key)
-		output, err := executor.RunWithOutput("plugin", "marketplace", "add", key)
+		output, err := executor.RunWithOutput("plugin", "marketplace", "add", marketplaceAddArg(m))
 		if err != nil {
 			// Check if already installed - treat as success
 			if strings.Contains(output, "already installed") {
@@ -716,6 +779,16 @@ func applyUserScope(profile *Profile, claudeDir, claudeJSONPath, claudeupHome st
 		result.Errors = append(result.Errors, err)
 	}
 
+	// Apply the settings block to user settings.json
+	if err := applyScopeSettingsBlock(profile.Settings, "user", claudeDir, ""); err != nil {
+		result.Errors = append(result.Errors, err)
+	}
+
+	// Compose memory fragments into ~/.claude/CLAUDE.md
+	if err := applyScopeMemory(profile.Memory, "user", claudeDir, "", claudeupHome); err != nil {
+		result.Errors = append(result.Errors, err)
+	}
+
 	return result, nil
 }
 
@@ -805,14 +878,37 @@ func toSet(slice []string) map[string]struct{} {
 
 // HookOptions controls post-apply hook behavior
 type HookOptions struct {
-	ForceSetup    bool   // Run hook even if first-run check would skip
+	ForceSetup    bool   // Run hook even if first-run or on-change checks would skip
 	NoInteractive bool   // Skip hook entirely (for CI/scripting)
-	ScriptDir     string // Directory containing hook scripts (for built-in profiles)
+	ScriptDir     string // Profile directory: where the hook runs and its script is found
+	ClaudeupHome  string // Where on-change hashes are kept; "" records none
+
+	// AllowEnv names extra variables the user lets hooks see
+	// (preferences.hookEnv).
+	AllowEnv []string
+	// AllowUnsandboxed runs hooks that ask for a sandbox unisolated when no
+	// sandbox is available, instead of refusing them.
+	AllowUnsandboxed bool
+
+	// TrustPolicy is enforced for hooks from profiles that are not Trusted.
+	// "" leaves trust to the caller.
+	TrustPolicy TrustPolicy
+	// Trusted is set when the profile is built in or signed by a trusted
+	// key, or the user accepted it at the trust prompt.
+	Trusted bool
+}
+
+// ErrUntrustedHook is returned by RunHook for a hook the trust policy refuses.
+var ErrUntrustedHook = errors.New("post-apply hook is not from a trusted profile")
+
+// hookAllowed reports whether the trust policy lets the hook run.
+func (o HookOptions) hookAllowed() bool {
+	return o.Trusted || o.TrustPolicy == "" || o.TrustPolicy == TrustAllow
 }
 
-// ShouldRunHook checks if the post-apply hook should run based on condition and current state
+// ShouldRunHook checks if the post-apply hook should run based on condition, current state, and trust
 func ShouldRunHook(profile *Profile, claudeDir, claudeJSONPath, claudeupHome string, opts HookOptions) bool {
-	if opts.NoInteractive {
+	if opts.NoInteractive || !opts.hookAllowed() {
 		return false
 	}
 
@@ -830,6 +926,8 @@ func ShouldRunHook(profile *Profile, claudeDir, claudeJSONPath, claudeupHome str
 		return true
 	case "first-run":
 		return isFirstRun(profile, claudeDir, claudeJSONPath, claudeupHome)
+	case "on-change":
+		return profileChangedSinceHook(profile, claudeupHome)
 	default:
 		return false
 	}
@@ -865,21 +963,20 @@ func isFirstRun(profile *Profile, claudeDir, claudeJSONPath, claudeupHome string
 	return true
 }
 
-// marketplaceKey returns the lookup key for a marketplace (Repo or URL)
+// marketplaceKey returns the lookup key for a marketplace: the repo, the URL,
+// or the absolute directory path for directory sources
 func marketplaceKey(m Marketplace) string {
-	if m.Repo != "" {
-		return m.Repo
+	if m.Repo == "" && m.URL == "" && m.Path != "" {
+		return resolveMarketplacePath(m.Path)
 	}
-	return m.URL
+	return m.Location()
 }
 
 // filterValidMarketplaceKeys returns only non-empty marketplace keys
 func filterValidMarketplaceKeys(marketplaces []Marketplace) []string {
 	var keys []string
-	for _, m := range marketplaces {
-		if key := marketplaceKey(m); key != "" {
-			keys = append(keys, key)
-		}
+	for _, m := range filterValidMarketplaces(marketplaces) {
+		keys = append(keys, marketplaceKey(m))
 	}
 	return keys
 }
@@ -896,11 +993,7 @@ func installPluginsForScope(plugins []string, scope string, reinstall bool, exec
 	// When not reinstalling, pre-filter using installed_plugins.json to skip
 	// already-installed plugins without hitting the CLI for each one.
 	if !reinstall {
-		claudeDir := ""
-		if de, ok := executor.(*DefaultExecutor); ok {
-			claudeDir = de.ClaudeDir
-		}
-		if claudeDir != "" {
+		if claudeDir := executorClaudeDir(executor); claudeDir != "" {
 			if registry, err := claude.LoadPlugins(claudeDir); err == nil {
 				installed := make(map[string]bool)
 				// Normalize scope for lookup: empty string means user scope
@@ -1003,12 +1096,13 @@ func installMCPServersCLI(servers []MCPServer, scope string, secretChain *secret
 // user-scoped and must be registered before plugins that reference them.
 // Progress messages are written to w; pass nil to suppress output.
 func installMarketplaces(marketplaces []Marketplace, executor CommandExecutor, w io.Writer) (added []string, errs []error) {
-	validKeys := filterValidMarketplaceKeys(marketplaces)
-	for i, key := range validKeys {
+	valid := filterValidMarketplaces(marketplaces)
+	for i, m := range valid {
+		key := marketplaceKey(m)
 		if w != nil {
-			fmt.Fprintf(w, "  [%d/%d] Adding marketplace %s\n", i+1, len(validKeys), key)
+			fmt.Fprintf(w, "  [%d/%d] Adding marketplace %s\n", i+1, len(valid), key)
 		}
-		output, err := executor.RunWithOutput("plugin", "marketplace", "add", key)
+		output, err := executor.RunWithOutput("plugin", "marketplace", "add", marketplaceAddArg(m))
 		if err != nil {
 			if strings.Contains(output, "already installed") {
 				added = append(added, key)
@@ -1022,16 +1116,22 @@ func installMarketplaces(marketplaces []Marketplace, executor CommandExecutor, w
 	return added, errs
 }
 
-// marketplaceName extracts the marketplace name from a repo path or URL
+// marketplaceName extracts the marketplace name from a repo path, URL, or
+// directory (whose name is its last path segment)
 func marketplaceName(m Marketplace) string {
-	key := m.Repo
-	if key == "" {
-		key = m.URL
+	if m.IsDirectory() {
+		return marketplaceLocationBase(m)
 	}
+	key := m.Location()
 	if key == "" {
 		return ""
 	}
 
+	// scp-style URLs: "git@host:group/repo.git" -> "group/repo"
+	if !strings.Contains(key, "://") && isGitURL(key) {
+		key = strings.TrimSuffix(key[strings.Index(key, ":")+1:], ".git")
+	}
+
 	// Handle URLs by extracting the path portion
 	// e.g., "https://github.com/user/repo.git" -> "user/repo"
 	if strings.Contains(key, "://") {
@@ -1055,23 +1155,50 @@ type ResetResult struct {
 	PluginsRemoved      []string
 	MCPServersRemoved   []string
 	MarketplacesRemoved []string
+	HooksRemoved        []string
+	MemoryRemoved       []string
 	Errors              []error
 }
 
-// Reset removes everything a profile installed (plugins, MCP servers, marketplaces)
-func Reset(profile *Profile, claudeDir, claudeJSONPath, claudeupHome string) (*ResetResult, error) {
-	return ResetWithExecutor(profile, claudeDir, claudeJSONPath, claudeupHome, &DefaultExecutor{ClaudeDir: claudeDir})
+// Reset removes everything a profile installed (plugins, MCP servers, marketplaces, hooks, memory blocks)
+func Reset(profile *Profile, claudeDir, claudeJSONPath, projectDir, claudeupHome string) (*ResetResult, error) {
+	return ResetWithExecutor(profile, claudeDir, claudeJSONPath, projectDir, claudeupHome, &DefaultExecutor{ClaudeDir: claudeDir})
 }
 
-// ResetWithExecutor removes everything a profile installed using the provided executor
-func ResetWithExecutor(profile *Profile, claudeDir, claudeJSONPath, claudeupHome string, executor CommandExecutor) (*ResetResult, error) {
+// ResetWithExecutor removes everything a profile installed using the provided executor.
+// Profiles applied since the ownership ledger existed remove exactly the
+// plugins, MCP servers, marketplaces, hooks, and memory blocks they
+// introduced (for the user scope and projectDir's scopes);
+// older profiles fall back to
+// matching plugins by the profile's marketplaces and hooks by its current
+// hook list.
+func ResetWithExecutor(profile *Profile, claudeDir, claudeJSONPath, projectDir, claudeupHome string, executor CommandExecutor) (*ResetResult, error) {
 	result := &ResetResult{}
 
+	if book, err := ledger.Load(claudeupHome); err == nil && book.Tracked(profile.Name) {
+		resetFromLedger(profile, book, claudeDir, projectDir, claudeupHome, executor, result)
+		return result, nil
+	}
+
+	resetByMarketplace(profile, claudeDir, claudeJSONPath, claudeupHome, executor, result)
+	removed, err := resetSettingsHooks(profile, claudeDir)
+	if err != nil {
+		result.Errors = append(result.Errors, err)
+	}
+	result.HooksRemoved = removed
+
+	return result, nil
+}
+
+// resetByMarketplace removes plugins from the profile's marketplaces, its
+// MCP servers by name, and its marketplaces. Used for profiles the ownership
+// ledger does not track.
+func resetByMarketplace(profile *Profile, claudeDir, claudeJSONPath, claudeupHome string, executor CommandExecutor, result *ResetResult) {
 	// Get current state to find installed plugins
 	current, err := Snapshot("current", claudeDir, claudeJSONPath, claudeupHome)
 	if err != nil {
 		// Can't read current state - nothing to remove
-		return result, nil
+		return
 	}
 
 	// Build lookup from repo to marketplace name for removal
@@ -1092,8 +1219,8 @@ func ResetWithExecutor(profile *Profile, claudeDir, claudeJSONPath, claudeupHome
 			if strings.HasSuffix(plugin, suffix) {
 				output, err := executor.RunWithOutput("plugin", "uninstall", plugin)
 				if err != nil {
-					// Check if the error is just "already uninstalled" or "not found" - treat as success
-					if strings.Contains(output, "already uninstalled") || strings.Contains(output, "not found") {
+					// A plugin that is already uninstalled counts as removed
+					if errors.Is(err, ErrNotInstalled) {
 						result.PluginsRemoved = append(result.PluginsRemoved, plugin)
 					} else {
 						result.Errors = append(result.Errors, fmt.Errorf("failed to uninstall plugin %s: %w\n  Output: %s", plugin, err, strings.TrimSpace(output)))
@@ -1140,8 +1267,63 @@ func ResetWithExecutor(profile *Profile, claudeDir, claudeJSONPath, claudeupHome
 			result.MarketplacesRemoved = append(result.MarketplacesRemoved, lookupKey)
 		}
 	}
+}
 
-	return result, nil
+// InstalledHooks returns "Event: command" for each of the profile's hooks
+// currently present in user settings.json.
+func InstalledHooks(profile *Profile, claudeDir string) []string {
+	if len(profile.SettingsHooks) == 0 {
+		return nil
+	}
+	settings, err := claude.LoadSettingsOrEmpty(claudeDir)
+	if err != nil {
+		return nil
+	}
+	return installedHooks(profile, settings)
+}
+
+func installedHooks(profile *Profile, settings *claude.Settings) []string {
+	live := readSettingsHooks(settings)
+	var installed []string
+	for _, eventType := range sortedHookEvents(profile.SettingsHooks) {
+		liveKeys := make(map[string]bool)
+		for _, h := range live[eventType] {
+			liveKeys[h.key()] = true
+		}
+		for _, h := range profile.SettingsHooks[eventType] {
+			if liveKeys[h.key()] {
+				installed = append(installed, eventType+": "+h.label())
+			}
+		}
+	}
+	return installed
+}
+
+// resetSettingsHooks removes the profile's hooks from user settings.json,
+// matching each by event type, matcher, and command (or prompt). Used for
+// profiles the ownership ledger does not track, which can't tell the
+// profile's hooks from identical ones the user added. Returns
+// "Event: command" for each removed hook.
+func resetSettingsHooks(profile *Profile, claudeDir string) ([]string, error) {
+	if len(profile.SettingsHooks) == 0 {
+		return nil, nil
+	}
+
+	settings, err := claude.LoadSettingsOrEmpty(claudeDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	removed := installedHooks(profile, settings)
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	settings.RemoveHooks(settingsHooksMaps(profile.SettingsHooks))
+	if err := claude.SaveSettings(claudeDir, settings); err != nil {
+		return nil, fmt.Errorf("failed to save settings: %w", err)
+	}
+	return removed, nil
 }
 
 // BuildRepoToNameLookup reads known_marketplaces.json and builds a map from repo to name
@@ -1166,45 +1348,12 @@ func BuildRepoToNameLookup(claudeDir string) map[string]string {
 		if meta.Source.URL != "" {
 			result[meta.Source.URL] = name
 		}
-	}
-
-	return result
-}
-
-// RunHook executes the post-apply hook
-func RunHook(profile *Profile, opts HookOptions) error {
-	if profile.PostApply == nil {
-		return nil
-	}
-
-	hook := profile.PostApply
-
-	// Determine what to run
-	var cmd *exec.Cmd
-	if hook.Script != "" {
-		// Script path - resolve relative to ScriptDir
-		scriptPath := hook.Script
-		if opts.ScriptDir != "" && !filepath.IsAbs(scriptPath) {
-			scriptPath = filepath.Join(opts.ScriptDir, scriptPath)
-		}
-		// Verify script exists before attempting to run
-		if _, err := os.Stat(scriptPath); errors.Is(err, fs.ErrNotExist) {
-			return fmt.Errorf("hook script not found: %s", scriptPath)
+		if meta.Source.Path != "" {
+			result[resolveMarketplacePath(meta.Source.Path)] = name
 		}
-		cmd = exec.Command("bash", scriptPath)
-	} else if hook.Command != "" {
-		// Direct command
-		cmd = exec.Command("bash", "-c", hook.Command)
-	} else {
-		return nil // Nothing to run
 	}
 
-	// Run interactively
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	return cmd.Run()
+	return result
 }
 
 // ApplyAllScopesOptions controls how multi-scope profiles are applied.
@@ -1244,7 +1393,14 @@ func ApplyAllScopes(profile *Profile, claudeDir, claudeJSONPath, projectDir, cla
 
 	// If legacy profile (no PerScope), apply to user scope only
 	if !profile.IsMultiScope() {
-		return applyUserScopeSettings(profile, claudeDir, projectDir, opts.ReplaceUserScope)
+		result, err := applyUserScopeSettings(profile, claudeDir, projectDir, opts.ReplaceUserScope)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyScopeMemory(profile.Memory, "user", claudeDir, projectDir, claudeupHome); err != nil {
+			result.Errors = append(result.Errors, err)
+		}
+		return result, nil
 	}
 
 	// Register marketplaces before any plugin installs. Marketplaces are always
@@ -1296,6 +1452,10 @@ func ApplyAllScopes(profile *Profile, claudeDir, claudeJSONPath, projectDir, cla
 				result.Errors = append(result.Errors, fmt.Errorf("user-scope extensions: %w", err))
 			}
 		}
+
+		if err := applyScopeMemory(scopeProfile.Memory, "user", claudeDir, projectDir, claudeupHome); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("user-scope memory: %w", err))
+		}
 	}
 
 	if profile.PerScope.Project != nil && projectDir != "" {
@@ -1325,6 +1485,10 @@ func ApplyAllScopes(profile *Profile, claudeDir, claudeJSONPath, projectDir, cla
 				result.Errors = append(result.Errors, fmt.Errorf("project-scope extensions: %w", err))
 			}
 		}
+
+		if err := applyScopeMemory(scopeProfile.Memory, "project", claudeDir, projectDir, claudeupHome); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("project-scope memory: %w", err))
+		}
 	}
 
 	if profile.PerScope.Local != nil && projectDir != "" {
@@ -1344,6 +1508,10 @@ func ApplyAllScopes(profile *Profile, claudeDir, claudeJSONPath, projectDir, cla
 				result.Errors = append(result.Errors, fmt.Errorf("local-scope extensions: %w", err))
 			}
 		}
+
+		if err := applyScopeMemory(scopeProfile.Memory, "local", claudeDir, projectDir, claudeupHome); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("local-scope memory: %w", err))
+		}
 	}
 
 	// Merge profile hooks into settings.json (always user-scoped)
@@ -1386,6 +1554,10 @@ func applyUserScopeSettings(profile *Profile, claudeDir, projectDir string, repl
 		}
 	}
 
+	if err := applySettingsBlock(settings, profile.Settings); err != nil {
+		return nil, err
+	}
+
 	// Save settings
 	if err := claude.SaveSettings(claudeDir, settings); err != nil {
 		return nil, fmt.Errorf("failed to save user settings: %w", err)
@@ -1410,6 +1582,9 @@ func applyUserScopeSettingsOnly(profile *Profile, claudeDir, projectDir string,
 	for _, plugin := range profile.Plugins {
 		settings.EnabledPlugins[plugin] = true
 	}
+	if err := applySettingsBlock(settings, profile.Settings); err != nil {
+		return err
+	}
 
 	if err := claude.SaveSettings(claudeDir, settings); err != nil {
 		return fmt.Errorf("failed to save user settings: %w", err)
@@ -1429,6 +1604,9 @@ func applyProjectScopeSettingsOnly(profile *Profile, claudeDir, projectDir strin
 	for _, plugin := range profile.Plugins {
 		settings.EnabledPlugins[plugin] = true
 	}
+	if err := applySettingsBlock(settings, profile.Settings); err != nil {
+		return err
+	}
 
 	if err := claude.SaveSettingsForScope("project", claudeDir, projectDir, settings); err != nil {
 		return fmt.Errorf("failed to save project settings: %w", err)
@@ -1448,6 +1626,9 @@ func applyLocalScopeSettingsOnly(profile *Profile, claudeDir, projectDir string)
 	for _, plugin := range profile.Plugins {
 		settings.EnabledPlugins[plugin] = true
 	}
+	if err := applySettingsBlock(settings, profile.Settings); err != nil {
+		return err
+	}
 
 	if err := claude.SaveSettingsForScope("local", claudeDir, projectDir, settings); err != nil {
 		return fmt.Errorf("failed to save local settings: %w", err)
@@ -1496,6 +1677,7 @@ func warnNotFoundExtensions(notFound []string) {
 }
 
 // applyExtensionsSymlink enables extensions via symlinks (user scope).
+// Items with frontmatter overrides are linked to patched copies instead.
 // Returns a list of unmatched patterns in "category/pattern" format.
 func applyExtensionsSymlink(items *ExtensionSettings, claudeDir, claudeupHome string) ([]string, error) {
 	manager := ext.NewManager(claudeDir, claudeupHome)
@@ -1517,13 +1699,17 @@ func applyExtensionsSymlink(items *ExtensionSettings, claudeDir, claudeupHome st
 	var allNotFound []string
 	for _, ci := range categories {
 		if len(ci.patterns) > 0 {
-			_, notFound, err := manager.Enable(ci.category, ci.patterns)
+			enabled, notFound, err := manager.Enable(ci.category, ci.patterns)
 			if err != nil {
 				return allNotFound, fmt.Errorf("failed to enable %s: %w", ci.category, err)
 			}
 			for _, item := range notFound {
 				allNotFound = append(allNotFound, ci.category+"/"+item)
 			}
+			// Items with frontmatter overrides link to patched copies
+			if _, err := manager.SetVariants(ci.category, enabled, items.Overrides[ci.category]); err != nil {
+				return allNotFound, fmt.Errorf("failed to apply %s overrides: %w", ci.category, err)
+			}
 		}
 	}
 
@@ -1532,6 +1718,8 @@ func applyExtensionsSymlink(items *ExtensionSettings, claudeDir, claudeupHome st
 
 // applyExtensionsCopy copies extensions into the project's .claude/ directory.
 // Only categories permitted at project/local scope are allowed; others return an error.
+// Items edited in the project since they were last copied are kept, with a warning.
+// Items with frontmatter overrides are copied with the overrides applied.
 // Returns a list of not-found patterns in "category/pattern" format.
 func applyExtensionsCopy(items *ExtensionSettings, claudeupHome, projectDir string) ([]string, error) {
 	localDir := filepath.Join(claudeupHome, "ext")
@@ -1560,11 +1748,14 @@ func applyExtensionsCopy(items *ExtensionSettings, claudeupHome, projectDir stri
 			return allNotFound, fmt.Errorf("cannot copy %s to project scope: %w", ci.category, err)
 		}
 
-		_, notFound, err := ext.CopyToProject(localDir, ci.category, ci.patterns, projectDir)
+		result, err := ext.CopyToProject(localDir, ci.category, ci.patterns, items.Overrides[ci.category], projectDir)
 		if err != nil {
 			return allNotFound, fmt.Errorf("failed to copy %s to project: %w", ci.category, err)
 		}
-		for _, item := range notFound {
+		for _, item := range result.Kept {
+			ui.PrintWarning(fmt.Sprintf("Kept %s/%s: edited in the project since it was copied", ci.category, item))
+		}
+		for _, item := range result.NotFound {
 			allNotFound = append(allNotFound, ci.category+"/"+item)
 		}
 	}
@@ -1583,18 +1774,7 @@ func applySettingsHooks(profile *Profile, claudeDir string) error {
 		return fmt.Errorf("failed to load settings: %w", err)
 	}
 
-	// Convert HookEntry to map format for MergeHooks
-	hooksMap := make(map[string][]map[string]interface{})
-	for eventType, entries := range profile.SettingsHooks {
-		for _, entry := range entries {
-			hooksMap[eventType] = append(hooksMap[eventType], map[string]interface{}{
-				"type":    entry.Type,
-				"command": entry.Command,
-			})
-		}
-	}
-
-	if err := settings.MergeHooks(hooksMap); err != nil {
+	if err := settings.MergeHooks(settingsHooksMaps(profile.SettingsHooks)); err != nil {
 		return fmt.Errorf("failed to merge hooks: %w", err)
 	}
 
@@ -96,11 +96,12 @@ func ApplyConcurrently(profile *Profile, opts ConcurrentApplyOptions) (*Concurre
 		marketplaceJobs := make([]Job, len(marketplacesToInstall))
 		for i, m := range marketplacesToInstall {
 			key := marketplaceKey(m)
+			addArg := marketplaceAddArg(m)
 			marketplaceJobs[i] = Job{
 				Name: key,
 				Type: "marketplace",
 				Execute: func() error {
-					_, err := opts.Executor.RunWithOutput("plugin", "marketplace", "add", key)
+					_, err := opts.Executor.RunWithOutput("plugin", "marketplace", "add", addArg)
 					return err
 				},
 			}
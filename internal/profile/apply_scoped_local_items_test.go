@@ -67,7 +67,7 @@ func TestApplyExtensionsProjectScopeCopiesFiles(t *testing.T) {
 	}
 }
 
-func TestApplyExtensionsProjectScopeRejectsUnsupportedCategories(t *testing.T) {
+func TestApplyExtensionsProjectScopeCopiesSkills(t *testing.T) {
 	tempDir := t.TempDir()
 	claudeDir := filepath.Join(tempDir, ".claude")
 	claudeupHome := filepath.Join(tempDir, ".claudeup")
@@ -86,10 +86,18 @@ func TestApplyExtensionsProjectScopeRejectsUnsupportedCategories(t *testing.T) {
 		Skills: []string{"test-skill"},
 	}
 
-	// Apply at project scope should fail for unsupported category
+	// Skills are read from project .claude/ directories like agents and rules
 	_, err := applyExtensionsScoped(profile, extensions, ScopeProject, claudeDir, claudeupHome, projectDir)
-	if err == nil {
-		t.Fatal("expected error for unsupported category at project scope")
+	if err != nil {
+		t.Fatalf("applyExtensionsScoped failed: %v", err)
+	}
+
+	skillPath := filepath.Join(projectDir, ".claude", "skills", "test-skill", "SKILL.md")
+	if _, err := os.Stat(skillPath); err != nil {
+		t.Errorf("expected skill to be copied to %s: %v", skillPath, err)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, ".claude", ".claudeup-ext.json")); err != nil {
+		t.Errorf("expected project manifest: %v", err)
 	}
 }
 
@@ -714,6 +714,8 @@ func TestMarketplaceKey(t *testing.T) {
 		{"repo only", Marketplace{Repo: "user/repo"}, "user/repo"},
 		{"url only", Marketplace{URL: "https://github.com/user/repo.git"}, "https://github.com/user/repo.git"},
 		{"both prefers repo", Marketplace{Repo: "user/repo", URL: "https://example.com"}, "user/repo"},
+		{"absolute directory", Marketplace{Source: "directory", Path: "/srv/market"}, "/srv/market"},
+		{"ref not part of key", Marketplace{Repo: "user/repo", Ref: "v1"}, "user/repo"},
 		{"empty", Marketplace{}, ""},
 	}
 
@@ -744,6 +746,8 @@ func TestMarketplaceName(t *testing.T) {
 		{"self-hosted with org", Marketplace{URL: "https://gitlab.corp.com/team/project.git"}, "team-project"},
 		{"deep path", Marketplace{URL: "https://github.com/org/group/subgroup/repo.git"}, "org-group-subgroup-repo"},
 		{"url with port", Marketplace{URL: "https://git.example.com:8443/user/repo.git"}, "user-repo"},
+		{"scp-style url", Marketplace{URL: "git@gitlab.com:team/plugins.git"}, "team-plugins"},
+		{"directory", Marketplace{Source: "directory", Path: "/srv/marketplaces/internal-tools"}, "internal-tools"},
 	}
 
 	for _, tc := range tests {
@@ -756,6 +760,32 @@ func TestMarketplaceName(t *testing.T) {
 	}
 }
 
+func TestMarketplaceAddArg(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		marketplace Marketplace
+		expected    string
+	}{
+		{"github repo", Marketplace{Repo: "user/repo"}, "user/repo"},
+		{"github repo with ref", Marketplace{Repo: "user/repo", Ref: "v2"}, "user/repo#v2"},
+		{"git url with ref", Marketplace{URL: "https://gitlab.com/a/b.git", Ref: "main"}, "https://gitlab.com/a/b.git#main"},
+		{"relative directory resolved", Marketplace{Source: "directory", Path: "market"}, filepath.Join(cwd, "market")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := marketplaceAddArg(tc.marketplace); got != tc.expected {
+				t.Errorf("marketplaceAddArg(%+v) = %q, want %q", tc.marketplace, got, tc.expected)
+			}
+		})
+	}
+}
+
 func TestIsFirstRun(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -1136,7 +1166,7 @@ func TestResetRemovesPluginsFromMarketplace(t *testing.T) {
 	}
 
 	executor := &mockExecutor{}
-	result, err := ResetWithExecutor(profile, claudeDir, filepath.Join(tmpDir, ".claude.json"), claudeDir, executor)
+	result, err := ResetWithExecutor(profile, claudeDir, filepath.Join(tmpDir, ".claude.json"), "", claudeDir, executor)
 	if err != nil {
 		t.Fatalf("Reset failed: %v", err)
 	}
@@ -1191,7 +1221,7 @@ func TestResetRemovesMCPServers(t *testing.T) {
 	}
 
 	executor := &mockExecutor{}
-	result, err := ResetWithExecutor(profile, claudeDir, filepath.Join(tmpDir, ".claude.json"), claudeDir, executor)
+	result, err := ResetWithExecutor(profile, claudeDir, filepath.Join(tmpDir, ".claude.json"), "", claudeDir, executor)
 	if err != nil {
 		t.Fatalf("Reset failed: %v", err)
 	}
@@ -1246,7 +1276,7 @@ func TestResetUsesMarketplaceNameNotRepo(t *testing.T) {
 	}
 
 	executor := &mockExecutor{}
-	_, err := ResetWithExecutor(profile, claudeDir, filepath.Join(tmpDir, ".claude.json"), claudeDir, executor)
+	_, err := ResetWithExecutor(profile, claudeDir, filepath.Join(tmpDir, ".claude.json"), "", claudeDir, executor)
 	if err != nil {
 		t.Fatalf("Reset failed: %v", err)
 	}
@@ -1312,7 +1342,7 @@ func TestResetHandlesErrors(t *testing.T) {
 			"plugin uninstall test-plugin@test-marketplace": true,
 		},
 	}
-	result, err := ResetWithExecutor(profile, claudeDir, filepath.Join(tmpDir, ".claude.json"), claudeDir, executor)
+	result, err := ResetWithExecutor(profile, claudeDir, filepath.Join(tmpDir, ".claude.json"), "", claudeDir, executor)
 	if err != nil {
 		t.Fatalf("Reset should not return error, but collect errors: %v", err)
 	}
@@ -1340,9 +1370,10 @@ func (m *mockExecutorWithOutput) RunWithOutput(args ...string) (string, error) {
 	if len(args) > 0 && m.outputs != nil {
 		key := strings.Join(args, " ")
 		if output, ok := m.outputs[key]; ok {
-			// If output contains error keywords, return error
+			// If output contains error keywords, return error the way
+			// DefaultExecutor does
 			if strings.Contains(output, "not found") || strings.Contains(output, "Failed") || strings.Contains(output, "already exists") {
-				return output, fmt.Errorf("exit status 1")
+				return output, classifyCLIError(args, output, fmt.Errorf("exit status 1"))
 			}
 		}
 	}
@@ -1878,7 +1909,7 @@ func TestResetHandlesPluginNotFoundError(t *testing.T) {
 		},
 	}
 
-	result, err := ResetWithExecutor(profile, claudeDir, filepath.Join(tmpDir, ".claude.json"), claudeDir, executor)
+	result, err := ResetWithExecutor(profile, claudeDir, filepath.Join(tmpDir, ".claude.json"), "", claudeDir, executor)
 	if err != nil {
 		t.Fatalf("Reset should not return error: %v", err)
 	}
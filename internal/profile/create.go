@@ -8,33 +8,68 @@ import (
 	"io"
 	"slices"
 	"strings"
+	"unicode"
 )
 
-// ParseMarketplaceArg parses a marketplace argument in "owner/repo" format.
+// ParseMarketplaceArg parses a marketplace argument. Accepted forms:
+//
+//	owner/repo                  GitHub repository
+//	host.tld/group/repo         Repository on a self-hosted GitLab or Gitea server
+//	https://host/group/repo.git Any git URL (https, ssh, git, file, or user@host:path)
+//	./path, /abs/path, ~/path   Local marketplace directory
+//
+// GitHub and git sources accept a trailing "#ref" naming a branch, tag, or commit.
 // Whitespace around owner and repo is trimmed for robustness.
 // Additional path segments (owner/repo/extra) are preserved in Repo field
 // and will be validated when the marketplace is actually accessed.
 func ParseMarketplaceArg(arg string) (Marketplace, error) {
+	// Paths are recognized before trimming leading whitespace, so "  /repo"
+	// is still treated as a malformed owner/repo rather than a root directory.
+	untrimmed := strings.TrimRightFunc(arg, unicode.IsSpace)
 	arg = strings.TrimSpace(arg)
 	if arg == "" {
 		return Marketplace{}, fmt.Errorf("marketplace cannot be empty")
 	}
 
-	parts := strings.SplitN(arg, "/", 2)
+	if isLocalMarketplacePath(untrimmed) {
+		if strings.Contains(arg, "#") {
+			return Marketplace{}, fmt.Errorf("invalid marketplace %q: ref is not supported for directory sources", arg)
+		}
+		return Marketplace{Source: MarketplaceSourceDirectory, Path: arg}, nil
+	}
+
+	location, ref := splitMarketplaceRef(arg)
+	if strings.Contains(arg, "#") && (location == "" || ref == "") {
+		return Marketplace{}, fmt.Errorf("invalid marketplace format %q: expected location#ref", arg)
+	}
+
+	if isGitURL(location) {
+		if err := validateGitURL(location); err != nil {
+			return Marketplace{}, err
+		}
+		return Marketplace{Source: MarketplaceSourceGit, URL: location, Ref: ref}, nil
+	}
+
+	if u, ok := hostShorthandURL(location); ok {
+		return Marketplace{Source: MarketplaceSourceGit, URL: u, Ref: ref}, nil
+	}
+
+	parts := strings.SplitN(location, "/", 2)
 	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
-		return Marketplace{}, fmt.Errorf("invalid marketplace format %q: expected owner/repo", arg)
+		return Marketplace{}, fmt.Errorf("invalid marketplace format %q: expected owner/repo, a git URL, or a local path", arg)
 	}
 
 	owner := strings.TrimSpace(parts[0])
 	repo := strings.TrimSpace(parts[1])
 
 	if owner == "" || repo == "" {
-		return Marketplace{}, fmt.Errorf("invalid marketplace format %q: expected owner/repo", arg)
+		return Marketplace{}, fmt.Errorf("invalid marketplace format %q: expected owner/repo, a git URL, or a local path", arg)
 	}
 
 	return Marketplace{
-		Source: "github",
+		Source: MarketplaceSourceGitHub,
 		Repo:   owner + "/" + repo,
+		Ref:    ref,
 	}, nil
 }
 
@@ -69,6 +104,20 @@ func ValidatePluginFormat(plugin string) error {
 // Requires a description and at least one marketplace.
 // Validates marketplace and plugin formats using ParseMarketplaceArg and ValidatePluginFormat.
 func ValidateCreateSpec(description string, marketplaces []string, plugins []string) error {
+	parsed := make([]Marketplace, 0, len(marketplaces))
+	for _, arg := range marketplaces {
+		m, err := ParseMarketplaceArg(arg)
+		if err != nil {
+			return err
+		}
+		parsed = append(parsed, m)
+	}
+	return validateCreateSpecMarketplaces(description, parsed, plugins)
+}
+
+// validateCreateSpecMarketplaces checks the description, marketplace count,
+// and plugin formats for already-parsed marketplaces.
+func validateCreateSpecMarketplaces(description string, marketplaces []Marketplace, plugins []string) error {
 	if strings.TrimSpace(description) == "" {
 		return fmt.Errorf("description is required")
 	}
@@ -77,12 +126,6 @@ func ValidateCreateSpec(description string, marketplaces []string, plugins []str
 		return fmt.Errorf("at least one marketplace is required")
 	}
 
-	for _, m := range marketplaces {
-		if _, err := ParseMarketplaceArg(m); err != nil {
-			return err
-		}
-	}
-
 	for _, p := range plugins {
 		if err := ValidatePluginFormat(p); err != nil {
 			return err
@@ -180,11 +223,15 @@ func ValidatePluginMarketplaces(plugins []string, marketplaces []Marketplace, re
 
 // matchesMarketplace checks if a plugin ref matches any marketplace in the list.
 // Uses the same logic as filterMarketplacesToPlugins: match full repo or last segment.
+// Git and directory sources match on the last segment of their URL or path.
 func matchesMarketplace(ref string, marketplaces []Marketplace) bool {
 	for _, m := range marketplaces {
 		if m.Repo == ref || strings.HasSuffix(m.Repo, "/"+ref) {
 			return true
 		}
+		if m.Repo == "" && (marketplaceLocationBase(m) == ref || marketplaceName(m) == ref) {
+			return true
+		}
 	}
 	return false
 }
@@ -204,10 +251,11 @@ type CreateSpec struct {
 	Detect       DetectRules       `json:"detect,omitempty"`
 }
 
-// MaxInputSize is the maximum size for JSON input (10MB)
+// MaxInputSize is the maximum size for JSON or YAML input (10MB)
 const MaxInputSize = 10 * 1024 * 1024
 
-// CreateFromReader creates a profile from JSON input.
+// CreateFromReader creates a profile from JSON, JSON with comments, or YAML
+// input; JSON starts with a brace and anything else is read as YAML.
 // If the input contains perScope, it is used directly and the scope argument is unused.
 // If scopeExplicit is true and the input contains perScope, an error is returned
 // because the caller's explicit scope flag would be silently disregarded.
@@ -224,6 +272,10 @@ func CreateFromReader(name string, r io.Reader, descOverride string, scope strin
 		return nil, fmt.Errorf("input too large: maximum size is %d bytes", MaxInputSize)
 	}
 
+	data, err = DocumentJSON(data, DetectFormat(data))
+	if err != nil {
+		return nil, err
+	}
 	var spec CreateSpec
 	if err := json.Unmarshal(data, &spec); err != nil {
 		return nil, fmt.Errorf("invalid JSON: %w", err)
@@ -247,13 +299,8 @@ func CreateFromReader(name string, r io.Reader, descOverride string, scope strin
 		description = descOverride
 	}
 
-	// Convert to string args for validation
-	marketArgs := make([]string, len(marketplaces))
-	for i, m := range marketplaces {
-		marketArgs[i] = m.Repo
-	}
-
-	if err := ValidateCreateSpec(description, marketArgs, spec.Plugins); err != nil {
+	// Marketplaces were validated while parsing; validate the rest of the spec
+	if err := validateCreateSpecMarketplaces(description, marketplaces, spec.Plugins); err != nil {
 		return nil, err
 	}
 
@@ -332,10 +379,10 @@ func parseMarketplacesJSON(raw json.RawMessage) ([]Marketplace, error) {
 		return nil, fmt.Errorf("invalid marketplace format: expected array of strings or objects")
 	}
 
-	// Validate object-format marketplaces have required fields
+	// Validate object-format marketplaces have a location matching their source
 	for i, m := range objMarkets {
-		if m.Repo == "" {
-			return nil, fmt.Errorf("marketplace repo cannot be empty (marketplace %d)", i+1)
+		if err := m.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid marketplace %d: %w", i+1, err)
 		}
 	}
 
@@ -50,6 +50,51 @@ func TestParseMarketplaceArg(t *testing.T) {
 			arg:  "owner/repo/extra/path",
 			want: Marketplace{Source: "github", Repo: "owner/repo/extra/path"},
 		},
+		{
+			name: "github shorthand with ref",
+			arg:  "owner/repo#v1.2.0",
+			want: Marketplace{Source: "github", Repo: "owner/repo", Ref: "v1.2.0"},
+		},
+		{
+			name: "https git URL",
+			arg:  "https://gitlab.com/team/plugins.git",
+			want: Marketplace{Source: "git", URL: "https://gitlab.com/team/plugins.git"},
+		},
+		{
+			name: "scp-style git URL with ref",
+			arg:  "git@gitlab.example.com:team/plugins.git#main",
+			want: Marketplace{Source: "git", URL: "git@gitlab.example.com:team/plugins.git", Ref: "main"},
+		},
+		{
+			name: "self-hosted host shorthand",
+			arg:  "gitlab.example.com/team/plugins",
+			want: Marketplace{Source: "git", URL: "https://gitlab.example.com/team/plugins.git"},
+		},
+		{
+			name: "absolute directory path",
+			arg:  "/srv/marketplaces/internal",
+			want: Marketplace{Source: "directory", Path: "/srv/marketplaces/internal"},
+		},
+		{
+			name: "relative directory path",
+			arg:  "./marketplace",
+			want: Marketplace{Source: "directory", Path: "./marketplace"},
+		},
+		{
+			name:    "directory path with ref rejected",
+			arg:     "./marketplace#main",
+			wantErr: true,
+		},
+		{
+			name:    "empty ref rejected",
+			arg:     "owner/repo#",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported URL scheme rejected",
+			arg:     "ftp://example.com/plugins.git",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -59,7 +104,7 @@ func TestParseMarketplaceArg(t *testing.T) {
 				t.Errorf("ParseMarketplaceArg() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !tt.wantErr && (got.Source != tt.want.Source || got.Repo != tt.want.Repo) {
+			if !tt.wantErr && got != tt.want {
 				t.Errorf("ParseMarketplaceArg() = %v, want %v", got, tt.want)
 			}
 		})
@@ -667,3 +712,36 @@ func TestValidatePluginMarketplaces(t *testing.T) {
 		})
 	}
 }
+
+func TestMarketplaceValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		m       Marketplace
+		wantErr string
+	}{
+		{name: "github repo", m: Marketplace{Source: "github", Repo: "owner/repo"}},
+		{name: "git url with ref", m: Marketplace{Source: "git", URL: "https://gitlab.com/a/b.git", Ref: "v1"}},
+		{name: "directory", m: Marketplace{Source: "directory", Path: "/tmp/market"}},
+		{name: "github without repo", m: Marketplace{Source: "github"}, wantErr: "repo cannot be empty"},
+		{name: "git without url", m: Marketplace{Source: "git"}, wantErr: "url cannot be empty"},
+		{name: "directory without path", m: Marketplace{Source: "directory"}, wantErr: "path cannot be empty"},
+		{name: "unknown source", m: Marketplace{Source: "svn", URL: "svn://x"}, wantErr: "unknown source"},
+		{name: "directory with ref", m: Marketplace{Source: "directory", Path: "/tmp/m", Ref: "main"}, wantErr: "ref is not supported"},
+		{name: "url without host", m: Marketplace{Source: "git", URL: "https:///plugins.git"}, wantErr: "missing host"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.m.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
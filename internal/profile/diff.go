@@ -21,6 +21,9 @@ const (
 	DiffMCP         DiffItemKind = "mcp"
 	DiffExtension   DiffItemKind = "extension"
 	DiffMarketplace DiffItemKind = "marketplace"
+	DiffSetting     DiffItemKind = "setting"
+	DiffHook        DiffItemKind = "hook"
+	DiffMemory      DiffItemKind = "memory"
 )
 
 // DiffItem represents a single difference
@@ -57,6 +60,31 @@ func (d *ProfileDiff) IsEmpty() bool {
 	return true
 }
 
+// AddScopeItems appends items to a scope's diff, creating the scope entry
+// (in user, project, local order) if it has no differences yet.
+func (d *ProfileDiff) AddScopeItems(scope string, items []DiffItem) {
+	if len(items) == 0 {
+		return
+	}
+	for i := range d.Scopes {
+		if d.Scopes[i].Scope == scope {
+			d.Scopes[i].Items = append(d.Scopes[i].Items, items...)
+			return
+		}
+	}
+	order := map[string]int{"user": 0, "project": 1, "local": 2}
+	pos := len(d.Scopes)
+	for i, sd := range d.Scopes {
+		if order[sd.Scope] > order[scope] {
+			pos = i
+			break
+		}
+	}
+	d.Scopes = append(d.Scopes, ScopeDiff{})
+	copy(d.Scopes[pos+1:], d.Scopes[pos:])
+	d.Scopes[pos] = ScopeDiff{Scope: scope, Items: items}
+}
+
 // Counts returns the number of additions, removals, and modifications across all scopes
 func (d *ProfileDiff) Counts() (added, removed, modified int) {
 	for _, sd := range d.Scopes {
@@ -82,9 +110,10 @@ func (p *Profile) AsPerScope() *Profile {
 	}
 
 	result := &Profile{
-		Name:         p.Name,
-		Description:  p.Description,
-		Marketplaces: p.Marketplaces,
+		Name:          p.Name,
+		Description:   p.Description,
+		Marketplaces:  p.Marketplaces,
+		SettingsHooks: p.SettingsHooks,
 	}
 
 	if p.PerScope != nil {
@@ -102,6 +131,8 @@ func (p *Profile) AsPerScope() *Profile {
 			Plugins:    p.Plugins,
 			MCPServers: p.MCPServers,
 			Extensions: p.Extensions,
+			Settings:   p.Settings,
+			Memory:     p.Memory,
 		},
 	}
 
@@ -109,8 +140,8 @@ func (p *Profile) AsPerScope() *Profile {
 }
 
 // FilterToScopes returns a copy of the profile containing only the scopes
-// present in the given map (keyed by scope name). Marketplaces are included
-// only when user scope is active (they are always user-scoped).
+// present in the given map (keyed by scope name). Marketplaces and hooks are
+// included only when user scope is active (they are always user-scoped).
 func FilterToScopes(p *Profile, scopes map[string]bool) *Profile {
 	if p == nil {
 		return nil
@@ -136,6 +167,7 @@ func FilterToScopes(p *Profile, scopes map[string]bool) *Profile {
 	// breadcrumbs are active).
 	if scopes["user"] {
 		result.Marketplaces = p.Marketplaces
+		result.SettingsHooks = p.SettingsHooks
 	}
 
 	return result
@@ -160,9 +192,10 @@ func ComputeProfileDiff(saved, live *Profile) *ProfileDiff {
 
 		items := diffScope(savedScope, liveScope)
 
-		// Marketplaces are always user-scoped
+		// Marketplaces and hooks are always user-scoped
 		if scope == "user" {
 			items = append(items, diffMarketplaces(saved.Marketplaces, live.Marketplaces)...)
+			items = append(items, diffSettingsHooks(saved.SettingsHooks, live.SettingsHooks)...)
 		}
 
 		if len(items) > 0 {
@@ -233,6 +266,10 @@ func diffScope(saved, live *ScopeSettings) []DiffItem {
 
 	items = append(items, diffExtensions(scopeExtensions(saved), scopeExtensions(live))...)
 
+	items = append(items, diffSettings(scopeSettingsBlock(saved), scopeSettingsBlock(live))...)
+
+	items = append(items, diffMemory(scopeMemory(saved), scopeMemory(live))...)
+
 	return items
 }
 
@@ -257,6 +294,13 @@ func scopeExtensions(s *ScopeSettings) *ExtensionSettings {
 	return s.Extensions
 }
 
+func scopeSettingsBlock(s *ScopeSettings) *SettingsBlock {
+	if s == nil {
+		return nil
+	}
+	return s.Settings
+}
+
 // diffStringSet computes added/removed items between two string slices
 func diffStringSet(saved, live []string, kind DiffItemKind) []DiffItem {
 	savedSet := make(map[string]bool, len(saved))
@@ -345,7 +389,8 @@ func mcpDiffDetail(saved, live MCPServer) string {
 	return strings.Join(changes, ", ") + " changed"
 }
 
-// diffExtensions computes added/removed extensions per category
+// diffExtensions computes added/removed extensions per category and
+// changed frontmatter overrides on items present on both sides
 func diffExtensions(saved, live *ExtensionSettings) []DiffItem {
 	var items []DiffItem
 
@@ -369,6 +414,8 @@ func diffExtensions(saved, live *ExtensionSettings) []DiffItem {
 		}
 	}
 
+	items = append(items, diffOverrides(saved, live)...)
+
 	return items
 }
 
@@ -394,30 +441,30 @@ func extSlice(e *ExtensionSettings, category string) []string {
 	return nil
 }
 
-// diffMarketplaces computes added/removed marketplaces
+// diffMarketplaces computes added/removed marketplaces. Marketplaces are
+// compared by location and ref, so a relative directory path in the profile
+// matches the absolute path recorded by Claude Code.
 func diffMarketplaces(saved, live []Marketplace) []DiffItem {
 	savedSet := make(map[string]bool, len(saved))
 	for _, m := range saved {
-		savedSet[m.DisplayName()] = true
+		savedSet[marketplaceIdentity(m)] = true
 	}
 	liveSet := make(map[string]bool, len(live))
 	for _, m := range live {
-		liveSet[m.DisplayName()] = true
+		liveSet[marketplaceIdentity(m)] = true
 	}
 
 	var items []DiffItem
 
 	for _, m := range live {
-		name := m.DisplayName()
-		if !savedSet[name] {
-			items = append(items, DiffItem{Op: DiffAdded, Kind: DiffMarketplace, Name: name})
+		if !savedSet[marketplaceIdentity(m)] {
+			items = append(items, DiffItem{Op: DiffAdded, Kind: DiffMarketplace, Name: m.DisplayName()})
 		}
 	}
 
 	for _, m := range saved {
-		name := m.DisplayName()
-		if !liveSet[name] {
-			items = append(items, DiffItem{Op: DiffRemoved, Kind: DiffMarketplace, Name: name})
+		if !liveSet[marketplaceIdentity(m)] {
+			items = append(items, DiffItem{Op: DiffRemoved, Kind: DiffMarketplace, Name: m.DisplayName()})
 		}
 	}
 
@@ -3,6 +3,9 @@
 package profile
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -789,3 +792,33 @@ func TestComputeProfileDiff_TotalCounts(t *testing.T) {
 		t.Errorf("expected 0 modifications, got %d", modified)
 	}
 }
+
+func TestDiffMarketplaces_SourceTypes(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("relative and absolute directory paths match", func(t *testing.T) {
+		saved := []Marketplace{{Source: "directory", Path: "market"}}
+		live := []Marketplace{{Source: "directory", Path: filepath.Join(cwd, "market")}}
+		if items := diffMarketplaces(saved, live); len(items) != 0 {
+			t.Errorf("expected no differences, got %+v", items)
+		}
+	})
+
+	t.Run("different refs differ", func(t *testing.T) {
+		saved := []Marketplace{{Source: "git", URL: "https://gitlab.com/a/b.git", Ref: "v1"}}
+		live := []Marketplace{{Source: "git", URL: "https://gitlab.com/a/b.git", Ref: "v2"}}
+		items := diffMarketplaces(saved, live)
+		if len(items) != 2 {
+			t.Fatalf("expected one removal and one addition, got %+v", items)
+		}
+		names := []string{items[0].Name, items[1].Name}
+		for _, want := range []string{"https://gitlab.com/a/b.git#v1", "https://gitlab.com/a/b.git#v2"} {
+			if !slices.Contains(names, want) {
+				t.Errorf("expected %q in %v", want, names)
+			}
+		}
+	})
+}
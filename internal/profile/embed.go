@@ -57,9 +57,14 @@ func EnsureDefaultProfiles(profilesDir string) error {
 	return nil
 }
 
+// GetEmbeddedProfileData returns the JSON of an embedded profile by name
+func GetEmbeddedProfileData(name string) ([]byte, error) {
+	return embeddedProfiles.ReadFile("profiles/" + name + ".json")
+}
+
 // GetEmbeddedProfile returns an embedded profile by name
 func GetEmbeddedProfile(name string) (*Profile, error) {
-	data, err := embeddedProfiles.ReadFile("profiles/" + name + ".json")
+	data, err := GetEmbeddedProfileData(name)
 	if err != nil {
 		return nil, err
 	}
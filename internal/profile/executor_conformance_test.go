@@ -0,0 +1,341 @@
+// ABOUTME: Conformance suite shared by the CLI and native command executors
+// ABOUTME: Checks the files each executor leaves behind for marketplace, plugin, and MCP commands
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+)
+
+// conformanceMarketplace creates a directory marketplace named "conformance"
+// holding one plugin, "greeter", at ./plugins/greeter.
+func conformanceMarketplace(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "marketplace")
+	for _, sub := range []string{".claude-plugin", "plugins/greeter/.claude-plugin", "plugins/greeter/commands"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeJSON(t, filepath.Join(dir, ".claude-plugin", "marketplace.json"), map[string]interface{}{
+		"name":  "conformance",
+		"owner": map[string]string{"name": "claudeup"},
+		"plugins": []map[string]string{
+			{"name": "greeter", "source": "./plugins/greeter", "description": "Says hello", "version": "1.0.0"},
+		},
+	})
+	writeJSON(t, filepath.Join(dir, "plugins", "greeter", ".claude-plugin", "plugin.json"), map[string]string{
+		"name": "greeter", "version": "1.0.0", "description": "Says hello",
+	})
+	if err := os.WriteFile(filepath.Join(dir, "plugins", "greeter", "commands", "hello.md"), []byte("Say hello.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// runExecutorConformance drives an executor through the commands claudeup
+// issues and checks the resulting config files. newExecutor receives the
+// Claude directory; the working directory is the project.
+func runExecutorConformance(t *testing.T, newExecutor func(claudeDir string) CommandExecutor) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	if err := os.MkdirAll(claudeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	projectDir := t.TempDir()
+	t.Chdir(projectDir)
+	marketplaceDir := conformanceMarketplace(t)
+	executor := newExecutor(claudeDir)
+	const plugin = "greeter@conformance"
+
+	run := func(args ...string) string {
+		t.Helper()
+		output, err := executor.RunWithOutput(args...)
+		if err != nil {
+			t.Fatalf("%s: %v\n  Output: %s", strings.Join(args, " "), err, output)
+		}
+		return output
+	}
+
+	t.Run("marketplace add registers a directory marketplace", func(t *testing.T) {
+		run("plugin", "marketplace", "add", marketplaceDir)
+		registry, err := claude.LoadMarketplaces(claudeDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta, ok := registry["conformance"]
+		if !ok {
+			t.Fatalf("marketplace not registered: %v", registry)
+		}
+		if !sameDir(meta.Source.Path, marketplaceDir) || !meta.Source.IsDirectory() {
+			t.Errorf("source = %+v, want directory %s", meta.Source, marketplaceDir)
+		}
+		if _, err := claude.LoadMarketplaceIndex(meta.InstallLocation); err != nil {
+			t.Errorf("install location %s has no index: %v", meta.InstallLocation, err)
+		}
+	})
+
+	t.Run("plugin install records the plugin at user scope", func(t *testing.T) {
+		run("plugin", "install", plugin)
+		registry, err := claude.LoadPlugins(claudeDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta, ok := registry.GetPluginAtScope(plugin, "user")
+		if !ok {
+			t.Fatalf("plugin not in registry: %+v", registry.Plugins)
+		}
+		if !meta.PathExists() {
+			t.Errorf("install path %s does not exist", meta.InstallPath)
+		}
+		if _, err := os.Stat(filepath.Join(meta.InstallPath, "commands", "hello.md")); err != nil {
+			t.Errorf("plugin files not cached: %v", err)
+		}
+		settings, err := claude.LoadSettings(claudeDir)
+		if err != nil || !settings.IsPluginEnabled(plugin) {
+			t.Errorf("plugin not enabled in user settings (err %v)", err)
+		}
+	})
+
+	t.Run("installing again succeeds or reports ErrAlreadyInstalled", func(t *testing.T) {
+		output, err := executor.RunWithOutput("plugin", "install", plugin)
+		if err != nil && !errors.Is(err, ErrAlreadyInstalled) {
+			t.Errorf("second install: %v\n  Output: %s", err, output)
+		}
+	})
+
+	t.Run("plugin install --scope project records the project", func(t *testing.T) {
+		run("plugin", "install", "--scope", "project", plugin)
+		registry, err := claude.LoadPlugins(claudeDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, sp := range registry.GetPluginsForContext([]string{"project"}, projectDir) {
+			found = found || sp.Name == plugin
+		}
+		if !found {
+			t.Errorf("project instance not recorded for %s: %+v", projectDir, registry.Plugins[plugin])
+		}
+		settings, err := claude.LoadSettingsForScope("project", claudeDir, projectDir)
+		if err != nil || !settings.IsPluginEnabled(plugin) {
+			t.Errorf("plugin not enabled in project settings (err %v)", err)
+		}
+	})
+
+	t.Run("plugin uninstall removes only the user instance", func(t *testing.T) {
+		run("plugin", "uninstall", plugin)
+		registry, err := claude.LoadPlugins(claudeDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if registry.PluginExistsAtScope(plugin, "user") {
+			t.Error("user instance still in registry")
+		}
+		if !registry.PluginExistsAtScope(plugin, "project") {
+			t.Error("project instance should remain")
+		}
+		settings, err := claude.LoadSettings(claudeDir)
+		if err != nil || settings.IsPluginEnabled(plugin) {
+			t.Errorf("plugin still enabled in user settings (err %v)", err)
+		}
+	})
+
+	t.Run("uninstalling a plugin that is not installed reports ErrNotInstalled", func(t *testing.T) {
+		if _, err := executor.RunWithOutput("plugin", "uninstall", plugin); !errors.Is(err, ErrNotInstalled) {
+			t.Errorf("second uninstall = %v, want ErrNotInstalled", err)
+		}
+	})
+
+	t.Run("mcp add writes user and project servers", func(t *testing.T) {
+		run(buildMCPAddArgs(MCPServer{Name: "conformance-user", Command: "echo", Args: []string{"user"}}, nil)...)
+		run(buildMCPAddArgs(MCPServer{Name: "conformance-project", Command: "echo", Args: []string{"project"}, Scope: "project"}, nil)...)
+
+		user, err := ReadMCPServersForScope(filepath.Join(claudeDir, ".claude.json"), projectDir, "user")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(user) != 1 || user[0].Name != "conformance-user" || user[0].Command != "echo" || strings.Join(user[0].Args, " ") != "user" {
+			t.Errorf("user MCP servers = %+v", user)
+		}
+		project, err := ReadMCPServersForScope("", projectDir, "project")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(project) != 1 || project[0].Name != "conformance-project" {
+			t.Errorf("project MCP servers = %+v", project)
+		}
+	})
+
+	t.Run("adding an existing MCP server reports already exists", func(t *testing.T) {
+		output, err := executor.RunWithOutput(buildMCPAddArgs(MCPServer{Name: "conformance-user", Command: "echo"}, nil)...)
+		if mcpErr := checkMCPAlreadyExists(output, err); !errors.Is(mcpErr, errMCPAlreadyExists) {
+			t.Errorf("duplicate add = %v, want errMCPAlreadyExists", mcpErr)
+		}
+	})
+
+	t.Run("mcp remove deletes the server", func(t *testing.T) {
+		run("mcp", "remove", "conformance-user")
+		user, err := ReadMCPServersForScope(filepath.Join(claudeDir, ".claude.json"), projectDir, "user")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(user) != 0 {
+			t.Errorf("user MCP servers after remove = %+v", user)
+		}
+		if _, err := executor.RunWithOutput("mcp", "remove", "conformance-user"); !errors.Is(err, ErrNotInstalled) {
+			t.Errorf("removing a missing server = %v, want ErrNotInstalled", err)
+		}
+	})
+
+	t.Run("marketplace remove unregisters it", func(t *testing.T) {
+		run("plugin", "marketplace", "remove", "conformance")
+		registry, err := claude.LoadMarketplaces(claudeDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := registry["conformance"]; ok {
+			t.Error("marketplace still registered")
+		}
+		if _, err := os.Stat(filepath.Join(marketplaceDir, ".claude-plugin", "marketplace.json")); err != nil {
+			t.Errorf("directory marketplace should be left on disk: %v", err)
+		}
+		if _, err := executor.RunWithOutput("plugin", "marketplace", "remove", "conformance"); !errors.Is(err, ErrNotInstalled) {
+			t.Errorf("removing a missing marketplace = %v, want ErrNotInstalled", err)
+		}
+	})
+}
+
+func TestNativeExecutorConformance(t *testing.T) {
+	runExecutorConformance(t, func(claudeDir string) CommandExecutor {
+		return &NativeExecutor{ClaudeDir: claudeDir}
+	})
+}
+
+func TestNativeExecutorMCPWriteKeepsFile(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "claude.json")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte(`{"oauthAccount": {"id": "x"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, ".claude.json")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	executor := &NativeExecutor{ClaudeDir: dir, ClaudeJSONPath: link, ProjectDir: dir}
+	if _, err := executor.RunWithOutput(buildMCPAddArgs(MCPServer{Name: "notes", Command: "echo"}, nil)...); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("symlink should be kept (err %v)", err)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"oauthAccount"`) || !strings.Contains(string(data), `"notes"`) {
+		t.Errorf("config = %s", data)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(target))
+	if len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+}
+
+// The CLI run needs a working claude binary, so it is opt-in. CI opts in
+// (the cli-conformance job), and there a missing binary is a failure rather
+// than a skip so the comparison cannot silently stop running.
+func TestCLIExecutorConformance(t *testing.T) {
+	if os.Getenv("CLAUDEUP_CLI_CONFORMANCE") == "" {
+		t.Skip("set CLAUDEUP_CLI_CONFORMANCE=1 to run against the claude CLI")
+	}
+	if _, err := exec.LookPath("claude"); err != nil {
+		t.Fatal("CLAUDEUP_CLI_CONFORMANCE is set but the claude CLI is not on PATH")
+	}
+	runExecutorConformance(t, func(claudeDir string) CommandExecutor {
+		return &DefaultExecutor{ClaudeDir: claudeDir}
+	})
+}
+
+func TestNativeExecutorPreservesClaudeJSON(t *testing.T) {
+	claudeDir := t.TempDir()
+	claudeJSON := filepath.Join(claudeDir, ".claude.json")
+	writeSettingsJSON(t, claudeJSON, `{"numStartups": 3, "mcpServers": {}}`)
+	projectDir := t.TempDir()
+	executor := &NativeExecutor{ClaudeDir: claudeDir, ProjectDir: projectDir}
+
+	if _, err := executor.RunWithOutput("mcp", "add", "db", "-s", "local", "-e", "TOKEN=x", "--", "db-server", "--port", "5432"); err != nil {
+		t.Fatal(err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(readFile(t, claudeJSON)), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw["numStartups"] != float64(3) {
+		t.Errorf("unrelated keys should be preserved: %v", raw)
+	}
+	project := raw["projects"].(map[string]interface{})[projectDir].(map[string]interface{})
+	server := project["mcpServers"].(map[string]interface{})["db"].(map[string]interface{})
+	if server["command"] != "db-server" || len(server["args"].([]interface{})) != 2 || server["env"].(map[string]interface{})["TOKEN"] != "x" {
+		t.Errorf("local server = %v", server)
+	}
+
+	if _, err := executor.RunWithOutput("plugin", "update", "x@y"); err == nil {
+		t.Error("expected error for unsupported command")
+	}
+	if _, err := NewExecutor("docker", claudeDir); err == nil {
+		t.Error("expected error for unknown engine")
+	}
+}
+
+func TestNativeExecutorRejectsTraversalMarketplaceName(t *testing.T) {
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	if err := os.MkdirAll(filepath.Join(claudeDir, "plugins"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	settings := filepath.Join(claudeDir, "settings.json")
+	writeSettingsJSON(t, settings, `{"model": "opus"}`)
+
+	for _, name := range []string{"../..", "..", ".", "a/b", "/tmp/x"} {
+		dir := filepath.Join(t.TempDir(), "marketplace")
+		if err := os.MkdirAll(filepath.Join(dir, ".claude-plugin"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeJSON(t, filepath.Join(dir, ".claude-plugin", "marketplace.json"), map[string]interface{}{
+			"name":    name,
+			"owner":   map[string]string{"name": "claudeup"},
+			"plugins": []map[string]string{},
+		})
+
+		executor := &NativeExecutor{ClaudeDir: claudeDir}
+		if _, err := executor.RunWithOutput("plugin", "marketplace", "add", dir); err == nil {
+			t.Errorf("name %q: expected error", name)
+		}
+	}
+	if _, err := os.Stat(settings); err != nil {
+		t.Errorf("settings.json should survive: %v", err)
+	}
+	registry, err := claude.LoadMarketplaces(claudeDir)
+	if err == nil && len(registry) != 0 {
+		t.Errorf("nothing should be registered: %v", registry)
+	}
+}
@@ -0,0 +1,70 @@
+// ABOUTME: Errors a CommandExecutor returns for conditions callers act on
+// ABOUTME: Maps the claude CLI's "not installed" and "already installed" output to ErrNotInstalled and ErrAlreadyInstalled
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotInstalled is returned by an executor when the plugin, MCP server, or
+// marketplace it was asked to remove is not there. Callers removing items
+// treat it as success; check it with errors.Is.
+var ErrNotInstalled = errors.New("not installed")
+
+// ErrAlreadyInstalled is returned by an executor when the plugin it was
+// asked to install is already installed at that scope. Callers installing
+// plugins treat it as success with nothing changed; check it with errors.Is.
+var ErrAlreadyInstalled = errors.New("already installed")
+
+// executorError carries the executor's own message while matching one of
+// the sentinel errors above.
+type executorError struct {
+	msg    string
+	target error
+}
+
+func (e *executorError) Error() string { return e.msg }
+
+func (e *executorError) Is(target error) bool { return target == e.target }
+
+// notInstalledf formats a message as an error matching ErrNotInstalled.
+func notInstalledf(format string, args ...interface{}) error {
+	return &executorError{msg: fmt.Sprintf(format, args...), target: ErrNotInstalled}
+}
+
+// alreadyInstalledf formats a message as an error matching ErrAlreadyInstalled.
+func alreadyInstalledf(format string, args ...interface{}) error {
+	return &executorError{msg: fmt.Sprintf(format, args...), target: ErrAlreadyInstalled}
+}
+
+// classifyCLIError wraps err as ErrNotInstalled when the claude CLI's output
+// for a removal says the item itself is missing, and as ErrAlreadyInstalled
+// when a plugin install says the plugin is already there. Only the item
+// being removed counts: "marketplace not found" from a plugin uninstall
+// stays a failure.
+func classifyCLIError(args []string, output string, err error) error {
+	if err == nil {
+		return nil
+	}
+	lower := strings.ToLower(output)
+	if hasArgs(args, "plugin", "install") && strings.Contains(lower, "already installed") {
+		return fmt.Errorf("%w: %w", ErrAlreadyInstalled, err)
+	}
+	var missing bool
+	switch {
+	case hasArgs(args, "plugin", "uninstall"):
+		missing = strings.Contains(lower, "already uninstalled") ||
+			strings.Contains(lower, "not installed") ||
+			strings.Contains(lower, "not found in installed plugins")
+	case hasArgs(args, "plugin", "marketplace", "remove"):
+		missing = strings.Contains(lower, "not found")
+	case hasArgs(args, "mcp", "remove"):
+		missing = strings.Contains(lower, "no mcp server found") || strings.Contains(lower, "not found")
+	}
+	if !missing {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrNotInstalled, err)
+}
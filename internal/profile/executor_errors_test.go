@@ -0,0 +1,43 @@
+// ABOUTME: Tests for mapping claude CLI output to ErrNotInstalled and ErrAlreadyInstalled
+// ABOUTME: Only the item being removed counts as missing; other "not found" output stays an error
+package profile
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClassifyCLIError(t *testing.T) {
+	failed := errors.New("exit status 1")
+	tests := []struct {
+		name   string
+		args   []string
+		output string
+		want   bool
+	}{
+		{"plugin not in registry", []string{"plugin", "uninstall", "p@m"}, `Plugin "p@m" not found in installed plugins`, true},
+		{"plugin already uninstalled", []string{"plugin", "uninstall", "p@m"}, "Plugin p@m is already uninstalled", true},
+		{"plugin's marketplace missing", []string{"plugin", "uninstall", "p@m"}, "marketplace not found", false},
+		{"marketplace missing on remove", []string{"plugin", "marketplace", "remove", "m"}, "Marketplace 'm' not found", true},
+		{"mcp server missing", []string{"mcp", "remove", "s", "-s", "user"}, "No MCP server found with name: s", true},
+		{"install failure", []string{"plugin", "install", "p@m"}, "marketplace not found", false},
+		{"plugin already installed", []string{"plugin", "install", "p@m"}, "Plugin p@m is already installed", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := classifyCLIError(tc.args, tc.output, failed)
+			if got := errors.Is(err, ErrNotInstalled); got != tc.want {
+				t.Errorf("errors.Is(ErrNotInstalled) = %v, want %v", got, tc.want)
+			}
+			if !errors.Is(err, failed) {
+				t.Error("the original error should stay wrapped")
+			}
+		})
+	}
+	if err := classifyCLIError([]string{"plugin", "install", "p@m"}, "Plugin p@m is already installed", failed); !errors.Is(err, ErrAlreadyInstalled) || !errors.Is(err, failed) {
+		t.Errorf("install of an installed plugin = %v, want ErrAlreadyInstalled", err)
+	}
+	if classifyCLIError([]string{"plugin", "uninstall", "p@m"}, "not installed", nil) != nil {
+		t.Error("success should stay nil")
+	}
+}
@@ -0,0 +1,251 @@
+// ABOUTME: Per-item frontmatter overrides on profile extension entries
+// ABOUTME: Reads and writes {"name", "set"} list entries and merges, clones, and diffs overrides
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/ext"
+)
+
+// extensionEntry is the object form of an extension list entry.
+type extensionEntry struct {
+	Name string               `json:"name"`
+	Set  ext.FrontmatterPatch `json:"set,omitempty"`
+}
+
+// Items returns the item names listed for category.
+func (e *ExtensionSettings) Items(category string) []string {
+	if e == nil {
+		return nil
+	}
+	if items := e.itemsFor(category); items != nil {
+		return *items
+	}
+	return nil
+}
+
+// Override returns the frontmatter patch for an item, or nil.
+func (e *ExtensionSettings) Override(category, name string) ext.FrontmatterPatch {
+	if e == nil {
+		return nil
+	}
+	return e.Overrides[category][name]
+}
+
+// SetOverride records a frontmatter patch for an item. An empty patch
+// removes the override.
+func (e *ExtensionSettings) SetOverride(category, name string, patch ext.FrontmatterPatch) {
+	if len(patch) == 0 {
+		delete(e.Overrides[category], name)
+		if len(e.Overrides[category]) == 0 {
+			delete(e.Overrides, category)
+		}
+		return
+	}
+	if e.Overrides == nil {
+		e.Overrides = make(map[string]map[string]ext.FrontmatterPatch)
+	}
+	if e.Overrides[category] == nil {
+		e.Overrides[category] = make(map[string]ext.FrontmatterPatch)
+	}
+	e.Overrides[category][name] = patch
+}
+
+func (e *ExtensionSettings) itemsFor(category string) *[]string {
+	switch category {
+	case ext.CategoryAgents:
+		return &e.Agents
+	case ext.CategoryCommands:
+		return &e.Commands
+	case ext.CategorySkills:
+		return &e.Skills
+	case ext.CategoryHooks:
+		return &e.Hooks
+	case ext.CategoryRules:
+		return &e.Rules
+	case ext.CategoryOutputStyles:
+		return &e.OutputStyles
+	}
+	return nil
+}
+
+// UnmarshalJSON accepts each list entry as a plain name or as an object
+// naming the item and the frontmatter keys to set on it.
+func (e *ExtensionSettings) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*e = ExtensionSettings{}
+	for _, category := range ext.AllCategories() {
+		value, ok := raw[category]
+		if !ok {
+			continue
+		}
+		var entries []json.RawMessage
+		if err := json.Unmarshal(value, &entries); err != nil {
+			return fmt.Errorf("extensions.%s: %w", category, err)
+		}
+		items := e.itemsFor(category)
+		for _, entry := range entries {
+			var name string
+			if err := json.Unmarshal(entry, &name); err == nil {
+				*items = append(*items, name)
+				continue
+			}
+			var obj extensionEntry
+			if err := json.Unmarshal(entry, &obj); err != nil || obj.Name == "" {
+				return fmt.Errorf("extensions.%s: entries must be a name or {\"name\": ..., \"set\": {...}}", category)
+			}
+			*items = append(*items, obj.Name)
+			e.SetOverride(category, obj.Name, obj.Set)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON writes items with overrides in object form and the rest as names.
+func (e ExtensionSettings) MarshalJSON() ([]byte, error) {
+	entries := func(category string, items []string) []interface{} {
+		var out []interface{}
+		for _, item := range items {
+			if patch := e.Overrides[category][item]; len(patch) > 0 {
+				out = append(out, extensionEntry{Name: item, Set: patch})
+			} else {
+				out = append(out, item)
+			}
+		}
+		return out
+	}
+	return json.Marshal(struct {
+		Agents       []interface{} `json:"agents,omitempty"`
+		Commands     []interface{} `json:"commands,omitempty"`
+		Skills       []interface{} `json:"skills,omitempty"`
+		Hooks        []interface{} `json:"hooks,omitempty"`
+		Rules        []interface{} `json:"rules,omitempty"`
+		OutputStyles []interface{} `json:"output-styles,omitempty"`
+	}{
+		Agents:       entries(ext.CategoryAgents, e.Agents),
+		Commands:     entries(ext.CategoryCommands, e.Commands),
+		Skills:       entries(ext.CategorySkills, e.Skills),
+		Hooks:        entries(ext.CategoryHooks, e.Hooks),
+		Rules:        entries(ext.CategoryRules, e.Rules),
+		OutputStyles: entries(ext.CategoryOutputStyles, e.OutputStyles),
+	})
+}
+
+// mergeOverrides layers src's overrides onto dst; later profiles win per item.
+func mergeOverrides(dst, src map[string]map[string]ext.FrontmatterPatch) map[string]map[string]ext.FrontmatterPatch {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]map[string]ext.FrontmatterPatch)
+	}
+	for category, items := range src {
+		if dst[category] == nil {
+			dst[category] = make(map[string]ext.FrontmatterPatch)
+		}
+		for item, patch := range items {
+			dst[category][item] = patch
+		}
+	}
+	return dst
+}
+
+// cloneOverrides deep-copies overrides down to the patch maps.
+func cloneOverrides(overrides map[string]map[string]ext.FrontmatterPatch) map[string]map[string]ext.FrontmatterPatch {
+	if len(overrides) == 0 {
+		return nil
+	}
+	clone := make(map[string]map[string]ext.FrontmatterPatch, len(overrides))
+	for category, items := range overrides {
+		clone[category] = make(map[string]ext.FrontmatterPatch, len(items))
+		for item, patch := range items {
+			copied := make(ext.FrontmatterPatch, len(patch))
+			for k, v := range patch {
+				copied[k] = v
+			}
+			clone[category][item] = copied
+		}
+	}
+	return clone
+}
+
+// overridesEqual compares overrides, treating nil and empty as equal.
+func overridesEqual(a, b map[string]map[string]ext.FrontmatterPatch) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// diffOverrides reports items listed in both saved and live whose
+// frontmatter overrides differ, with the changed keys as detail.
+func diffOverrides(saved, live *ExtensionSettings) []DiffItem {
+	var items []DiffItem
+	for _, category := range ext.AllCategories() {
+		liveItems := make(map[string]bool)
+		for _, item := range live.Items(category) {
+			liveItems[item] = true
+		}
+		for _, item := range saved.Items(category) {
+			if !liveItems[item] {
+				continue
+			}
+			savedPatch, livePatch := saved.Override(category, item), live.Override(category, item)
+			if changes := patchChanges(savedPatch, livePatch); len(changes) > 0 {
+				items = append(items, DiffItem{
+					Op:     DiffModified,
+					Kind:   DiffExtension,
+					Name:   item,
+					Detail: category + ", " + strings.Join(changes, ", "),
+				})
+			}
+		}
+	}
+	return items
+}
+
+// patchChanges lists "key: profile → live" for every key whose value differs.
+func patchChanges(saved, live ext.FrontmatterPatch) []string {
+	keys := make(map[string]bool)
+	for k := range saved {
+		keys[k] = true
+	}
+	for k := range live {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var changes []string
+	for _, k := range sorted {
+		before, hadBefore := saved[k]
+		after, hasAfter := live[k]
+		if hadBefore == hasAfter && reflect.DeepEqual(before, after) {
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("%s: %s → %s", k, patchValue(before, hadBefore), patchValue(after, hasAfter)))
+	}
+	return changes
+}
+
+func patchValue(v interface{}, ok bool) string {
+	if !ok {
+		return "(not set)"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.Trim(string(data), `"`)
+}
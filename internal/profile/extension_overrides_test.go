@@ -0,0 +1,82 @@
+// ABOUTME: Tests for frontmatter overrides on profile extension entries
+// ABOUTME: Covers JSON round trips, stack merging, and diffs of changed overrides
+package profile
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/claudeup/claudeup/v5/internal/ext"
+)
+
+func TestExtensionOverridesJSONRoundTrip(t *testing.T) {
+	input := `{"agents":["planner.md",{"name":"reviewer","set":{"model":"opus","tools":["Read"]}}],"rules":["golang.md"]}`
+
+	var settings ExtensionSettings
+	if err := json.Unmarshal([]byte(input), &settings); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(settings.Agents, ",") != "planner.md,reviewer" {
+		t.Errorf("agents = %v", settings.Agents)
+	}
+	if got := settings.Override(ext.CategoryAgents, "reviewer"); got["model"] != "opus" {
+		t.Errorf("override = %v", got)
+	}
+	if settings.Override(ext.CategoryAgents, "planner.md") != nil {
+		t.Error("plain entries have no override")
+	}
+
+	data, err := json.Marshal(&settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != input {
+		t.Errorf("round trip:\n got %s\nwant %s", data, input)
+	}
+
+	if err := json.Unmarshal([]byte(`{"agents":[{"set":{"model":"opus"}}]}`), &settings); err == nil {
+		t.Error("expected error for entry without a name")
+	}
+}
+
+func TestMergeExtensionOverridesLaterWins(t *testing.T) {
+	base := &Profile{Extensions: &ExtensionSettings{Agents: []string{"reviewer"}}}
+	base.Extensions.SetOverride(ext.CategoryAgents, "reviewer", ext.FrontmatterPatch{"model": "sonnet"})
+	team := &Profile{Extensions: &ExtensionSettings{Agents: []string{"reviewer"}}}
+	team.Extensions.SetOverride(ext.CategoryAgents, "reviewer", ext.FrontmatterPatch{"model": "opus"})
+
+	merged := &Profile{}
+	mergeExtensions(merged, base)
+	mergeExtensions(merged, team)
+
+	if len(merged.Extensions.Agents) != 1 {
+		t.Errorf("agents = %v", merged.Extensions.Agents)
+	}
+	if got := merged.Extensions.Override(ext.CategoryAgents, "reviewer")["model"]; got != "opus" {
+		t.Errorf("model = %v, want opus", got)
+	}
+}
+
+func TestDiffExtensionsReportsChangedOverrides(t *testing.T) {
+	saved := &ExtensionSettings{Agents: []string{"reviewer.md", "planner.md"}}
+	saved.SetOverride(ext.CategoryAgents, "reviewer.md", ext.FrontmatterPatch{"model": "opus"})
+	live := &ExtensionSettings{Agents: []string{"reviewer.md", "planner.md"}}
+	live.SetOverride(ext.CategoryAgents, "reviewer.md", ext.FrontmatterPatch{"model": "sonnet", "color": "blue"})
+
+	items := diffExtensions(saved, live)
+	if len(items) != 1 {
+		t.Fatalf("expected one diff item, got %+v", items)
+	}
+	want := DiffItem{Op: DiffModified, Kind: DiffExtension, Name: "reviewer.md", Detail: "agents, color: (not set) → blue, model: opus → sonnet"}
+	if items[0] != want {
+		t.Errorf("got %+v, want %+v", items[0], want)
+	}
+
+	if !extensionSettingsEqual(cloneExtensionSettings(saved), saved) {
+		t.Error("clone should equal original")
+	}
+	if extensionSettingsEqual(saved, live) {
+		t.Error("settings with different overrides should not be equal")
+	}
+}
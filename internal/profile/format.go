@@ -0,0 +1,336 @@
+// ABOUTME: Profile file formats: JSON, JSON with comments (.jsonc), and YAML (.yaml, .yml)
+// ABOUTME: Every format decodes through JSON; saving over a commented file keeps its comments
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Profile file formats.
+const (
+	FormatJSON  = "json"
+	FormatJSONC = "jsonc"
+	FormatYAML  = "yaml"
+)
+
+// profileExtensions are the file extensions recognised as profiles, in the
+// order a name is looked up when several files share it.
+var profileExtensions = []string{".json", ".jsonc", ".yaml", ".yml"}
+
+// Formats lists the formats profiles can be written in.
+func Formats() []string {
+	return []string{FormatJSON, FormatJSONC, FormatYAML}
+}
+
+// FormatForPath returns the format of a profile file by its extension, or
+// "" when the extension is not a profile one.
+func FormatForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".jsonc":
+		return FormatJSONC
+	case ".yaml", ".yml":
+		return FormatYAML
+	}
+	return ""
+}
+
+// ExtensionForFormat returns the file extension profiles in format are saved with.
+func ExtensionForFormat(format string) (string, error) {
+	switch format {
+	case FormatJSON, FormatJSONC:
+		return "." + format, nil
+	case FormatYAML, "yml":
+		return ".yaml", nil
+	}
+	return "", fmt.Errorf("unknown profile format %q (use %s)", format, strings.Join(Formats(), ", "))
+}
+
+// TrimProfileExt removes a profile file extension from a file name or
+// relative path, leaving other names unchanged.
+func TrimProfileExt(name string) string {
+	if FormatForPath(name) == "" {
+		return name
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// RootProfilePath returns the file a profile named name occupies at the root
+// of profilesDir, in whichever format it was written, or the .json path a
+// new profile is saved to.
+func RootProfilePath(profilesDir, name string) string {
+	for _, ext := range profileExtensions {
+		path := filepath.Join(profilesDir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(profilesDir, name+".json")
+}
+
+// DocumentJSON converts a profile document in format to JSON. JSONC keeps
+// its layout, with comments and trailing commas blanked out, so JSON
+// syntax errors still point at the right line and column.
+func DocumentJSON(data []byte, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return data, nil
+	case FormatJSONC:
+		return stripJSONC(data), nil
+	case FormatYAML:
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		out, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unknown profile format %q", format)
+}
+
+// DetectFormat guesses the format of a document with no file name, such as
+// one piped to profile create: JSON (with or without comments) starts with
+// a brace, anything else is read as YAML.
+func DetectFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(stripJSONC(data))
+	if len(trimmed) > 0 && trimmed[0] != '{' && trimmed[0] != '[' {
+		return FormatYAML
+	}
+	if bytes.Equal(stripJSONC(data), data) {
+		return FormatJSON
+	}
+	return FormatJSONC
+}
+
+// decodeProfile unmarshals a profile document in format.
+func decodeProfile(data []byte, format string, p *Profile) error {
+	data, err := DocumentJSON(data, format)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, p)
+}
+
+// encodeProfile renders a profile in format. When old holds the comment
+// tree of the file's previous contents, its comments are carried over to
+// the keys and list entries that are still present.
+func encodeProfile(p *Profile, format string, old *yaml.Node) ([]byte, error) {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatJSON:
+		return append(data, '\n'), nil
+	case FormatJSONC:
+		doc, err := parseJSONC(data)
+		if err != nil {
+			return nil, err
+		}
+		if old != nil {
+			carryComments(old, doc)
+		}
+		return writeJSONC(doc), nil
+	case FormatYAML:
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		blockStyle(&doc)
+		if old != nil {
+			carryComments(old, &doc)
+		}
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&doc); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unknown profile format %q", format)
+}
+
+// commentTree parses a document in format into a tree holding its
+// comments, or returns nil when the format has none or the document does
+// not parse.
+func commentTree(data []byte, format string) *yaml.Node {
+	switch format {
+	case FormatJSONC:
+		if doc, err := parseJSONC(data); err == nil {
+			return doc
+		}
+	case FormatYAML:
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err == nil && doc.Kind == yaml.DocumentNode {
+			return &doc
+		}
+	}
+	return nil
+}
+
+// fileComments returns the comment tree of the profile file at path, or
+// nil when it is absent or has no comments to keep.
+func fileComments(path, format string) *yaml.Node {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return commentTree(data, format)
+}
+
+// remarkComments rewrites every comment in the tree with the comment
+// marker of format ("# " for YAML, "// " for JSONC).
+func remarkComments(n *yaml.Node, format string) {
+	marker := "// "
+	if format == FormatYAML {
+		marker = "# "
+	}
+	for _, comment := range []*string{&n.HeadComment, &n.LineComment, &n.FootComment} {
+		if *comment == "" {
+			continue
+		}
+		var lines []string
+		for _, line := range strings.Split(*comment, "\n") {
+			text := strings.TrimSpace(line)
+			for _, prefix := range []string{"#", "//", "/*", "*/", "*"} {
+				text = strings.TrimPrefix(text, prefix)
+			}
+			text = strings.TrimSpace(strings.TrimSuffix(text, "*/"))
+			switch {
+			case text != "":
+				lines = append(lines, marker+text)
+			case line == "":
+				lines = append(lines, "") // blank line between comment blocks
+			}
+		}
+		*comment = strings.Join(lines, "\n")
+	}
+	for _, child := range n.Content {
+		remarkComments(child, format)
+	}
+}
+
+// Convert rewrites the profile file at src as dst, in the format dst's
+// extension names, carrying over src's comments, then removes src.
+func Convert(src, dst string) error {
+	format := FormatForPath(dst)
+	if format == "" {
+		return fmt.Errorf("unknown profile format for %s", dst)
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	p, err := LoadFromPath(src)
+	if err != nil {
+		return err
+	}
+
+	old := commentTree(data, FormatForPath(src))
+	if old != nil {
+		remarkComments(old, format)
+	}
+	stamped := *p
+	stamped.SchemaVersion = CurrentSchemaVersion
+	out, err := encodeProfile(&stamped, format, old)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dst, out, 0644); err != nil {
+		return err
+	}
+	if err := os.Remove(src); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return nil
+}
+
+// blockStyle clears the flow styles YAML gives a tree parsed from JSON, so
+// it is written as ordinary block YAML.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, child := range n.Content {
+		blockStyle(child)
+	}
+}
+
+// carryComments copies the comments of old onto the matching nodes of
+// updated. Mapping values match by key and list entries by value, or by
+// "name" for objects, so a comment stays with its plugin when the list is
+// reordered. Comments on removed entries are dropped.
+func carryComments(old, updated *yaml.Node) {
+	if old.Kind != updated.Kind {
+		return
+	}
+	copyComments(old, updated)
+
+	switch updated.Kind {
+	case yaml.DocumentNode:
+		if len(old.Content) > 0 && len(updated.Content) > 0 {
+			carryComments(old.Content[0], updated.Content[0])
+		}
+	case yaml.MappingNode:
+		oldPairs := make(map[string][2]*yaml.Node, len(old.Content)/2)
+		for i := 0; i+1 < len(old.Content); i += 2 {
+			oldPairs[old.Content[i].Value] = [2]*yaml.Node{old.Content[i], old.Content[i+1]}
+		}
+		for i := 0; i+1 < len(updated.Content); i += 2 {
+			if pair, ok := oldPairs[updated.Content[i].Value]; ok {
+				copyComments(pair[0], updated.Content[i])
+				carryComments(pair[1], updated.Content[i+1])
+			}
+		}
+	case yaml.SequenceNode:
+		oldItems := make(map[string]*yaml.Node, len(old.Content))
+		for i, item := range old.Content {
+			oldItems[itemIdentity(item, i)] = item
+		}
+		for i, item := range updated.Content {
+			if match, ok := oldItems[itemIdentity(item, i)]; ok {
+				carryComments(match, item)
+			}
+		}
+	}
+}
+
+func copyComments(from, to *yaml.Node) {
+	to.HeadComment = from.HeadComment
+	to.LineComment = from.LineComment
+	to.FootComment = from.FootComment
+}
+
+// itemIdentity identifies a list entry across saves: scalars by value,
+// objects by their "name" (MCP servers, extensions) or "repo"
+// (marketplaces), and anything else by position.
+func itemIdentity(item *yaml.Node, index int) string {
+	switch item.Kind {
+	case yaml.ScalarNode:
+		return "value:" + item.Value
+	case yaml.MappingNode:
+		for _, key := range []string{"name", "repo", "url", "path"} {
+			for i := 0; i+1 < len(item.Content); i += 2 {
+				if item.Content[i].Value == key {
+					return key + ":" + item.Content[i+1].Value
+				}
+			}
+		}
+	}
+	return fmt.Sprintf("index:%d", index)
+}
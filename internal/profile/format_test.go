@@ -0,0 +1,204 @@
+// ABOUTME: Tests for JSONC and YAML profile files: loading, lookup by name, and conversion
+// ABOUTME: Covers comments surviving saves that change the profile and conversion between formats
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const commentedYAML = `# Team profile, owned by platform
+name: team
+description: Team setup # shown in profile list
+plugins:
+  # CI runs the same linter
+  - lint@tools
+  - fmt@tools # formatting on save
+marketplaces:
+  - source: github
+    repo: acme/tools
+`
+
+const commentedJSONC = `// Team profile, owned by platform
+{
+  "name": "team",
+  "description": "Team setup", // shown in profile list
+  "plugins": [
+    /* CI runs the same linter */
+    "lint@tools",
+    "fmt@tools", // formatting on save
+  ],
+  "marketplaces": [{"source": "github", "repo": "acme/tools"}],
+}
+`
+
+func TestLoadCommentedFormats(t *testing.T) {
+	dir := t.TempDir()
+	for file, content := range map[string]string{"team.yaml": commentedYAML, "other.jsonc": commentedJSONC, "short.yml": "plugins: [a@m]\n"} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"team", "other"} {
+		paths, err := FindProfilePaths(dir, name)
+		if err != nil || len(paths) != 1 {
+			t.Fatalf("FindProfilePaths(%q) = %v, %v", name, paths, err)
+		}
+		p, err := LoadFromPath(paths[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"lint@tools", "fmt@tools"}; !reflect.DeepEqual(p.Plugins, want) || p.Description != "Team setup" {
+			t.Errorf("%s: loaded %+v", name, p)
+		}
+	}
+
+	entries, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.DisplayName())
+	}
+	if want := []string{"short", "other", "team"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List display names = %v, want %v", names, want)
+	}
+}
+
+func TestSaveKeepsComments(t *testing.T) {
+	for _, c := range []struct{ file, content, headComment, lineComment string }{
+		{"team.yaml", commentedYAML, "# CI runs the same linter", "# formatting on save"},
+		{"team.jsonc", commentedJSONC, "/* CI runs the same linter */", "// formatting on save"},
+	} {
+		t.Run(c.file, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, c.file)
+			if err := os.WriteFile(path, []byte(c.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			p, err := LoadFromPath(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Reorder, add, and keep entries, as a snapshot would
+			p.Plugins = []string{"fmt@tools", "new@tools", "lint@tools"}
+			if err := Save(dir, p); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := string(data)
+
+			for _, want := range []string{"Team profile, owned by platform", "shown in profile list", c.lineComment} {
+				if !strings.Contains(out, want) {
+					t.Errorf("comment %q lost:\n%s", want, out)
+				}
+			}
+			// The comment stays with its entry, not its old position
+			if !strings.Contains(out, c.headComment+"\n    \"lint@tools\"") && !strings.Contains(out, c.headComment+"\n  - lint@tools") {
+				t.Errorf("comment did not follow lint@tools:\n%s", out)
+			}
+			if !strings.Contains(out, "fmt@tools") || !strings.Contains(out, c.lineComment) {
+				t.Errorf("line comment did not follow fmt@tools:\n%s", out)
+			}
+
+			reloaded, err := LoadFromPath(path)
+			if err != nil {
+				t.Fatalf("saved file does not load: %v\n%s", err, out)
+			}
+			if !reflect.DeepEqual(reloaded.Plugins, p.Plugins) {
+				t.Errorf("plugins = %v, want %v", reloaded.Plugins, p.Plugins)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "team.json")); err == nil {
+				t.Error("Save should update the existing file rather than create team.json")
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "team.yaml")
+	if err := os.WriteFile(src, []byte(commentedYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "team.jsonc")
+	if err := Convert(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Error("Convert should remove the original")
+	}
+	data, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"// Team profile, owned by platform", "// CI runs the same linter", `"fmt@tools" // formatting on save`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("missing %q in:\n%s", want, data)
+		}
+	}
+
+	back := filepath.Join(dir, "team.yaml")
+	if err := Convert(dst, back); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadFromPath(back)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Plugins, []string{"lint@tools", "fmt@tools"}) || len(p.Marketplaces) != 1 {
+		t.Errorf("round trip changed the profile: %+v", p)
+	}
+	data, _ = os.ReadFile(back)
+	if !strings.Contains(string(data), "  # CI runs the same linter\n  - lint@tools") {
+		t.Errorf("comment lost on the way back:\n%s", data)
+	}
+}
+
+func TestStripJSONCKeepsLayout(t *testing.T) {
+	in := "{\n  // a comment with \"quotes\" and a URL: https://x\n  \"url\": \"https://example.com\", /* inline */\n  \"list\": [1, 2,],\n}\n"
+	out := stripJSONC([]byte(in))
+	if len(out) != len(in) || strings.Count(string(out), "\n") != strings.Count(in, "\n") {
+		t.Fatalf("layout changed:\n%s", out)
+	}
+	if !strings.Contains(string(out), `"https://example.com"`) || strings.Contains(string(out), "comment") {
+		t.Errorf("unexpected stripping:\n%s", out)
+	}
+
+	// Errors in JSONC documents point at the line in the original file
+	issues := ValidateDocument([]byte("// header\n{\n  \"plugins\": [,]\n}\n"), ValidateOptions{Format: FormatJSONC})
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, "line 3") {
+		t.Errorf("expected a syntax error on line 3, got %v", issues)
+	}
+}
+
+func TestCreateFromReaderYAML(t *testing.T) {
+	spec := `# spec for CI machines
+description: CI
+marketplaces:
+  - acme/tools
+plugins:
+  - lint@tools
+`
+	p, err := CreateFromReader("ci", strings.NewReader(spec), "", "user", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Description != "CI" || !reflect.DeepEqual(p.PerScope.User.Plugins, []string{"lint@tools"}) {
+		t.Errorf("unexpected profile %+v", p)
+	}
+
+	if _, err := CreateFromReader("ci", strings.NewReader("description: [unclosed"), "", "user", false); err == nil || !strings.Contains(err.Error(), "invalid YAML") {
+		t.Errorf("expected a YAML error, got %v", err)
+	}
+}
@@ -0,0 +1,323 @@
+// ABOUTME: Runs post-apply hooks with a timeout, a filtered environment, and optional isolation
+// ABOUTME: Records each run in the events log and tracks profile hashes for the on-change condition
+package profile
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/claudeup/claudeup/v5/internal/events"
+)
+
+// DefaultHookTimeout bounds a post-apply hook that does not set a timeout.
+// Hooks are often interactive setup wizards, so it is generous.
+const DefaultHookTimeout = 10 * time.Minute
+
+// HookRunOperation is the events log operation recorded for each hook run.
+const HookRunOperation = "hook run"
+
+// hookOutputLimit is how much of each output stream the events log keeps;
+// longer output keeps its tail.
+const hookOutputLimit = 16 * 1024
+
+// hookStateFile records, per profile, the content hash of the last
+// successful hook run.
+const hookStateFile = "hook-state.json"
+
+// Sandbox modes recorded for a hook run. Only bwrap counts as a sandbox:
+// namespaces alone leave the filesystem writable.
+const (
+	SandboxNone       = "none"
+	SandboxBwrap      = "bwrap"
+	SandboxNamespaces = "namespace-only"
+)
+
+// hookEnvAllowlist lists the variables hooks inherit. Anything else, such
+// as API tokens in the user's shell, must be granted by the user (see
+// HookOptions.AllowEnv) or requested by a trusted profile's hook env.
+var hookEnvAllowlist = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "COLORTERM",
+	"LANG", "TZ", "TMPDIR", "CLAUDE_CONFIG_DIR", "CLAUDEUP_HOME",
+}
+
+// ErrSandboxUnavailable is returned by RunHook when a hook asks for a
+// sandbox that can't be set up here and HookOptions.AllowUnsandboxed is not
+// set.
+var ErrSandboxUnavailable = errors.New("the post-apply hook asks for a sandbox, but none is available")
+
+// hookEnvGrant returns the extra variables the hook may see: those the user
+// allows, plus the hook's own env list when the profile is trusted. An
+// untrusted profile can't name secrets into its hook's environment; the
+// names it asked for but did not get are returned as denied.
+func hookEnvGrant(hook *PostApplyHook, opts HookOptions) (granted, denied []string) {
+	granted = append(granted, opts.AllowEnv...)
+	for _, name := range hook.Env {
+		switch {
+		case slices.Contains(opts.AllowEnv, name):
+		case opts.Trusted:
+			granted = append(granted, name)
+		default:
+			denied = append(denied, name)
+		}
+	}
+	return granted, denied
+}
+
+// hookEnv builds the hook's environment from environ: allowlisted
+// variables, LC_* locale settings, the extra names granted, and
+// CLAUDEUP_PROFILE and CLAUDEUP_PROFILE_DIR describing the profile.
+func hookEnv(environ []string, extra []string, profileName, dir string) []string {
+	allowed := make(map[string]bool, len(hookEnvAllowlist)+len(extra))
+	for _, name := range hookEnvAllowlist {
+		allowed[name] = true
+	}
+	for _, name := range extra {
+		allowed[name] = true
+	}
+
+	var env []string
+	for _, kv := range environ {
+		name, _, ok := strings.Cut(kv, "=")
+		if !ok || strings.HasPrefix(name, "CLAUDEUP_PROFILE") {
+			continue
+		}
+		if allowed[name] || strings.HasPrefix(name, "LC_") {
+			env = append(env, kv)
+		}
+	}
+	env = append(env, "CLAUDEUP_PROFILE="+profileName)
+	if dir != "" {
+		env = append(env, "CLAUDEUP_PROFILE_DIR="+dir)
+	}
+	return env
+}
+
+// timeout returns how long the hook may run.
+func (h *PostApplyHook) timeout() time.Duration {
+	if h.Timeout > 0 {
+		return time.Duration(h.Timeout) * time.Second
+	}
+	return DefaultHookTimeout
+}
+
+// tailBuffer keeps the last limit bytes written to it.
+type tailBuffer struct {
+	limit     int
+	data      []byte
+	truncated bool
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	if over := len(b.data) - b.limit; over > 0 {
+		b.data = append(b.data[:0], b.data[over:]...)
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	if b.truncated {
+		return "..." + string(b.data)
+	}
+	return string(b.data)
+}
+
+// RunHook executes the post-apply hook, refusing hooks the trust policy does
+// not allow. The policy comes from opts: callers set opts.TrustPolicy,
+// and opts.Trusted from CheckTrust or the trust prompt, as profile apply
+// does. The zero TrustPolicy skips the check and leaves trust to the
+// caller. The hook runs in opts.ScriptDir with a filtered environment
+// (see hookEnvGrant) and is stopped after its timeout. When the hook asks
+// for a sandbox it runs under bwrap. Without bwrap it is not run unless
+// opts.AllowUnsandboxed is set, in which case it gets new Linux namespaces
+// where the kernel allows them and runs unisolated otherwise. Its output
+// still reaches the terminal; the exit code and the tail of stdout and
+// stderr are recorded in the events log.
+func RunHook(profile *Profile, opts HookOptions) error {
+	if profile.PostApply == nil {
+		return nil
+	}
+	if !opts.hookAllowed() {
+		return ErrUntrustedHook
+	}
+
+	hook := profile.PostApply
+
+	// Determine what to run
+	var args []string
+	scriptPath := ""
+	if hook.Script != "" {
+		// Script path - resolve relative to the profile directory
+		scriptPath = hook.Script
+		if opts.ScriptDir != "" && !filepath.IsAbs(scriptPath) {
+			scriptPath = filepath.Join(opts.ScriptDir, scriptPath)
+		}
+		// Verify script exists before attempting to run
+		if _, err := os.Stat(scriptPath); errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("hook script not found: %s", scriptPath)
+		}
+		args = []string{"bash", scriptPath}
+	} else if hook.Command != "" {
+		// Direct command
+		args = []string{"bash", "-c", hook.Command}
+	} else {
+		return nil // Nothing to run
+	}
+
+	timeout := hook.timeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	extraEnv, deniedEnv := hookEnvGrant(hook, opts)
+	if len(deniedEnv) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: not passing %s to the post-apply hook: the profile is not signed by a trusted key. Add them to preferences.hookEnv in config.json to allow them.\n",
+			strings.Join(deniedEnv, ", "))
+	}
+
+	stdout := &tailBuffer{limit: hookOutputLimit}
+	stderr := &tailBuffer{limit: hookOutputLimit}
+	newCmd := func(sandbox bool) (*exec.Cmd, string) {
+		cmd, mode := exec.CommandContext(ctx, args[0], args[1:]...), SandboxNone
+		if sandbox {
+			cmd, mode = isolatedCommand(ctx, args, opts.ScriptDir)
+		}
+		cmd.Dir = opts.ScriptDir
+		cmd.Env = hookEnv(os.Environ(), extraEnv, profile.Name, opts.ScriptDir)
+		// Run interactively, keeping a copy of the output for the events log
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = io.MultiWriter(os.Stdout, stdout)
+		cmd.Stderr = io.MultiWriter(os.Stderr, stderr)
+		cmd.WaitDelay = 2 * time.Second
+		return cmd, mode
+	}
+
+	cmd, mode := newCmd(hook.Sandbox)
+	switch {
+	case !hook.Sandbox || mode == SandboxBwrap:
+	case !opts.AllowUnsandboxed && mode == SandboxNamespaces:
+		return fmt.Errorf("%w: bwrap is not installed (install bwrap, or use --allow-unsandboxed-hooks to run it with namespaces only)", ErrSandboxUnavailable)
+	case !opts.AllowUnsandboxed:
+		return fmt.Errorf("%w on this platform (use --allow-unsandboxed-hooks to run it anyway)", ErrSandboxUnavailable)
+	case mode == SandboxNamespaces:
+		fmt.Fprintln(os.Stderr, "Warning: bwrap is not installed; running the post-apply hook in namespaces only, with a writable filesystem")
+	default:
+		fmt.Fprintln(os.Stderr, "Warning: no sandbox is available here; running the post-apply hook unisolated")
+	}
+	start := time.Now()
+	err := cmd.Start()
+	if err != nil && mode == SandboxNamespaces {
+		// User namespaces can be disabled by the kernel or a container
+		fmt.Fprintf(os.Stderr, "Warning: could not isolate the post-apply hook (%v); running it unisolated\n", err)
+		cmd, mode = newCmd(false)
+		err = cmd.Start()
+	}
+	if err == nil {
+		err = cmd.Wait()
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("hook timed out after %s", timeout)
+	}
+
+	exitCode := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		exitCode = -1
+	}
+	events.GlobalTracker().RecordCommand(HookRunOperation, scriptPath, map[string]interface{}{
+		"profile":    profile.Name,
+		"command":    strings.Join(args, " "),
+		"dir":        opts.ScriptDir,
+		"sandbox":    mode,
+		"exitCode":   exitCode,
+		"durationMs": time.Since(start).Milliseconds(),
+		"stdout":     stdout.String(),
+		"stderr":     stderr.String(),
+	}, err)
+
+	if err == nil && opts.ClaudeupHome != "" {
+		if recordErr := recordHookRun(profile, opts.ClaudeupHome); recordErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record post-apply hook run: %v\n", recordErr)
+		}
+	}
+	return err
+}
+
+// ContentHash returns a hash of the profile's settings. Comments,
+// formatting, and the file format do not affect it.
+func ContentHash(p *Profile) string {
+	data, _ := json.Marshal(p)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hookState maps profile names to the content hash of their last
+// successful hook run.
+type hookState struct {
+	Profiles map[string]hookRun `json:"profiles"`
+}
+
+type hookRun struct {
+	Hash  string    `json:"hash"`
+	RanAt time.Time `json:"ranAt"`
+}
+
+func loadHookState(claudeupHome string) (*hookState, error) {
+	state := &hookState{Profiles: map[string]hookRun{}}
+	data, err := os.ReadFile(filepath.Join(claudeupHome, hookStateFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", hookStateFile, err)
+	}
+	if state.Profiles == nil {
+		state.Profiles = map[string]hookRun{}
+	}
+	return state, nil
+}
+
+// recordHookRun stores the profile's content hash after its hook succeeds.
+func recordHookRun(p *Profile, claudeupHome string) error {
+	state, err := loadHookState(claudeupHome)
+	if err != nil {
+		return err
+	}
+	state.Profiles[p.Name] = hookRun{Hash: ContentHash(p), RanAt: time.Now().UTC()}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(claudeupHome, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(claudeupHome, hookStateFile), append(data, '\n'), 0644)
+}
+
+// profileChangedSinceHook reports whether the profile's content differs from
+// when its hook last succeeded. A profile whose hook never ran has changed.
+func profileChangedSinceHook(p *Profile, claudeupHome string) bool {
+	state, err := loadHookState(claudeupHome)
+	if err != nil {
+		return true
+	}
+	last, ok := state.Profiles[p.Name]
+	return !ok || last.Hash != ContentHash(p)
+}
@@ -0,0 +1,168 @@
+// ABOUTME: Tests for how post-apply hooks run: environment, directory, timeout, and isolation
+// ABOUTME: Also covers the on-change condition and the hashes recorded after successful runs
+package profile
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestHookEnv(t *testing.T) {
+	environ := []string{
+		"PATH=/bin", "HOME=/home/me", "LC_ALL=C", "GITHUB_TOKEN=secret",
+		"NPM_TOKEN=npm", "CLAUDEUP_PROFILE=stale",
+	}
+	env := hookEnv(environ, []string{"NPM_TOKEN"}, "team", "/profiles")
+
+	want := []string{"PATH=/bin", "HOME=/home/me", "LC_ALL=C", "NPM_TOKEN=npm", "CLAUDEUP_PROFILE=team", "CLAUDEUP_PROFILE_DIR=/profiles"}
+	if strings.Join(env, " ") != strings.Join(want, " ") {
+		t.Errorf("hookEnv() = %v, want %v", env, want)
+	}
+}
+
+func TestHookEnvGrant(t *testing.T) {
+	hook := &PostApplyHook{Env: []string{"NPM_TOKEN", "AWS_SECRET_ACCESS_KEY"}}
+
+	granted, denied := hookEnvGrant(hook, HookOptions{AllowEnv: []string{"NPM_TOKEN"}})
+	if strings.Join(granted, ",") != "NPM_TOKEN" || strings.Join(denied, ",") != "AWS_SECRET_ACCESS_KEY" {
+		t.Errorf("an untrusted profile should only get what the user allows: granted %v, denied %v", granted, denied)
+	}
+
+	granted, denied = hookEnvGrant(hook, HookOptions{Trusted: true})
+	if strings.Join(granted, ",") != "NPM_TOKEN,AWS_SECRET_ACCESS_KEY" || len(denied) != 0 {
+		t.Errorf("a trusted profile should get its env list: granted %v, denied %v", granted, denied)
+	}
+}
+
+func TestRunHookUsesProfileDirAndFilteredEnv(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CLAUDEUP_TEST_SECRET", "leaked")
+	p := &Profile{Name: "team", PostApply: &PostApplyHook{
+		Command: `printf '%s|%s|%s' "$PWD" "$CLAUDEUP_TEST_SECRET" "$CLAUDEUP_PROFILE" > out`,
+	}}
+
+	if err := RunHook(p, HookOptions{ScriptDir: dir}); err != nil {
+		t.Fatalf("RunHook() unexpected error: %v", err)
+	}
+	out, err := os.ReadFile(filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatalf("hook did not run in the profile directory: %v", err)
+	}
+	resolved, _ := filepath.EvalSymlinks(dir)
+	if got := string(out); got != resolved+"||team" && got != dir+"||team" {
+		t.Errorf("hook saw %q", got)
+	}
+}
+
+func TestRunHookTimeout(t *testing.T) {
+	p := &Profile{Name: "slow", PostApply: &PostApplyHook{Command: "sleep 5", Timeout: 1}}
+
+	err := RunHook(p, HookOptions{ScriptDir: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 1s") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
+
+func TestRunHookSandbox(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("hook isolation is Linux-only")
+	}
+	dir := t.TempDir()
+	p := &Profile{Name: "team", PostApply: &PostApplyHook{Command: "echo $$ > pid", Sandbox: true}}
+
+	err := RunHook(p, HookOptions{ScriptDir: dir})
+	if errors.Is(err, ErrSandboxUnavailable) {
+		// Machines without bwrap refuse the hook unless unsandboxed runs
+		// are allowed
+		if err := RunHook(p, HookOptions{ScriptDir: dir, AllowUnsandboxed: true}); err != nil {
+			t.Fatalf("RunHook() with AllowUnsandboxed: %v", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("RunHook() unexpected error: %v", err)
+	}
+	out, err := os.ReadFile(filepath.Join(dir, "pid"))
+	if err != nil {
+		t.Fatalf("sandboxed hook could not write the profile directory: %v", err)
+	}
+	// The hook is PID 1 (namespaces) or 2 (under bwrap) when isolated
+	if pid := strings.TrimSpace(string(out)); pid != "1" && pid != "2" {
+		t.Errorf("sandboxed hook ran with pid %s", pid)
+	}
+}
+
+func TestRunHookSandboxRequiresBwrap(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("hook isolation is Linux-only")
+	}
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	bin := t.TempDir()
+	if err := os.Symlink(bash, filepath.Join(bin, "bash")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	p := &Profile{Name: "team", PostApply: &PostApplyHook{Command: "true", Sandbox: true}}
+
+	err = RunHook(p, HookOptions{ScriptDir: t.TempDir()})
+	if !errors.Is(err, ErrSandboxUnavailable) || !strings.Contains(err.Error(), "bwrap is not installed") {
+		t.Errorf("namespaces alone should not count as a sandbox, got %v", err)
+	}
+	if err := RunHook(p, HookOptions{ScriptDir: t.TempDir(), AllowUnsandboxed: true}); err != nil {
+		t.Errorf("AllowUnsandboxed should run the hook: %v", err)
+	}
+}
+
+func TestRunHookSandboxFailsClosed(t *testing.T) {
+	if runtime.GOOS == "linux" {
+		t.Skip("Linux can usually isolate hooks")
+	}
+	p := &Profile{Name: "team", PostApply: &PostApplyHook{Command: "true", Sandbox: true}}
+
+	if err := RunHook(p, HookOptions{ScriptDir: t.TempDir()}); !errors.Is(err, ErrSandboxUnavailable) {
+		t.Errorf("expected the hook to be refused, got %v", err)
+	}
+	if err := RunHook(p, HookOptions{ScriptDir: t.TempDir(), AllowUnsandboxed: true}); err != nil {
+		t.Errorf("AllowUnsandboxed should run the hook: %v", err)
+	}
+}
+
+func TestShouldRunHookOnChange(t *testing.T) {
+	home := t.TempDir()
+	p := &Profile{Name: "team", Plugins: []string{"a@m"}, PostApply: &PostApplyHook{Command: "true", Condition: "on-change"}}
+
+	if !ShouldRunHook(p, "", "", home, HookOptions{}) {
+		t.Fatal("a hook that never ran should run")
+	}
+	if err := RunHook(p, HookOptions{ScriptDir: t.TempDir(), ClaudeupHome: home}); err != nil {
+		t.Fatal(err)
+	}
+	if ShouldRunHook(p, "", "", home, HookOptions{}) {
+		t.Error("an unchanged profile should not run its hook again")
+	}
+	if !ShouldRunHook(p, "", "", home, HookOptions{ForceSetup: true}) {
+		t.Error("--setup should run the hook anyway")
+	}
+
+	p.Plugins = append(p.Plugins, "b@m")
+	if !ShouldRunHook(p, "", "", home, HookOptions{}) {
+		t.Error("a changed profile should run its hook")
+	}
+
+	// A failed run leaves the recorded hash alone
+	p.PostApply.Command = "false"
+	if err := RunHook(p, HookOptions{ScriptDir: t.TempDir(), ClaudeupHome: home}); err == nil {
+		t.Fatal("expected the hook to fail")
+	}
+	if !ShouldRunHook(p, "", "", home, HookOptions{}) {
+		t.Error("a failed hook should run again next time")
+	}
+}
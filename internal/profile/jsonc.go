@@ -0,0 +1,386 @@
+// ABOUTME: JSON with comments for .jsonc profiles: stripping to plain JSON, and a comment-keeping parser
+// ABOUTME: The parser builds the same yaml.Node trees YAML profiles use so both formats share comment carrying
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// stripJSONC blanks out comments and trailing commas with spaces, keeping
+// newlines, so the result is JSON with the original layout.
+func stripJSONC(data []byte) []byte {
+	out := []byte(nil)
+	blank := func(from, to int) {
+		if out == nil {
+			out = append([]byte(nil), data...)
+		}
+		for i := from; i < to; i++ {
+			if out[i] != '\n' {
+				out[i] = ' '
+			}
+		}
+	}
+
+	// A comma is trailing when it follows a value and closes nothing;
+	// one straight after an opening bracket or comma is left as an error
+	var last byte
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; c {
+		case '"':
+			i = stringEnd(data, i) - 1
+			last = c
+		case '/':
+			if end := commentEnd(data, i); end > i {
+				blank(i, end)
+				i = end - 1
+				continue
+			}
+			last = c
+		case ' ', '\t', '\r', '\n':
+		case ',':
+			next := skipJSONCSpace(data, i+1)
+			if last != '{' && last != '[' && last != ',' && next < len(data) && (data[next] == '}' || data[next] == ']') {
+				blank(i, i+1)
+			}
+			last = c
+		default:
+			last = c
+		}
+	}
+	if out == nil {
+		return data
+	}
+	return out
+}
+
+// stringEnd returns the index just past the string literal starting at i.
+func stringEnd(data []byte, i int) int {
+	for j := i + 1; j < len(data); j++ {
+		switch data[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return len(data)
+}
+
+// commentEnd returns the index just past the comment starting at i, or i
+// when there is none. Line comments end before their newline.
+func commentEnd(data []byte, i int) int {
+	if i+1 >= len(data) {
+		return i
+	}
+	switch data[i+1] {
+	case '/':
+		if end := bytes.IndexByte(data[i:], '\n'); end >= 0 {
+			return i + end
+		}
+		return len(data)
+	case '*':
+		if end := bytes.Index(data[i+2:], []byte("*/")); end >= 0 {
+			return i + 2 + end + 2
+		}
+		return len(data)
+	}
+	return i
+}
+
+// skipJSONCSpace returns the index of the next byte that is neither
+// whitespace nor part of a comment.
+func skipJSONCSpace(data []byte, i int) int {
+	for i < len(data) {
+		switch data[i] {
+		case ' ', '\t', '\r', '\n':
+			i++
+		case '/':
+			end := commentEnd(data, i)
+			if end == i {
+				return i
+			}
+			i = end
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// jsoncParser reads JSONC into a yaml.Node tree, attaching each comment to
+// the value it annotates: comments on their own lines lead the next key or
+// entry, and comments after a value on its line trail it.
+type jsoncParser struct {
+	data []byte
+	pos  int
+	line int
+}
+
+func parseJSONC(data []byte) (*yaml.Node, error) {
+	p := &jsoncParser{data: data, line: 1}
+	trailing, leading := p.comments()
+	root, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	endTrailing, endLeading := p.comments()
+	if p.pos < len(p.data) {
+		return nil, p.errorf("unexpected %q after the document", p.data[p.pos])
+	}
+	return &yaml.Node{
+		Kind:        yaml.DocumentNode,
+		HeadComment: joinComments(append(trailing, leading...), "\n"),
+		FootComment: joinComments(append(endTrailing, endLeading...), "\n"),
+		Content:     []*yaml.Node{root},
+	}, nil
+}
+
+func (p *jsoncParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid JSONC at line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+// comments skips whitespace and comments. Those starting on the line the
+// previous token ended on are trailing; the others lead the next token.
+func (p *jsoncParser) comments() (trailing, leading []string) {
+	startLine := p.line
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case '\n':
+			p.line++
+			p.pos++
+		case ' ', '\t', '\r':
+			p.pos++
+		case '/':
+			end := commentEnd(p.data, p.pos)
+			if end == p.pos {
+				return trailing, leading
+			}
+			text := string(p.data[p.pos:end])
+			onStartLine := p.line == startLine
+			p.line += strings.Count(text, "\n")
+			p.pos = end
+			lines := strings.Split(strings.TrimRight(text, " \t\r"), "\n")
+			for i := range lines {
+				lines[i] = strings.TrimSpace(lines[i])
+			}
+			if onStartLine {
+				trailing = append(trailing, strings.Join(lines, " "))
+			} else {
+				leading = append(leading, strings.Join(lines, "\n"))
+			}
+		default:
+			return trailing, leading
+		}
+	}
+	return trailing, leading
+}
+
+func (p *jsoncParser) value() (*yaml.Node, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("unexpected end of input")
+	}
+	switch c := p.data[p.pos]; {
+	case c == '{':
+		return p.container('}')
+	case c == '[':
+		return p.container(']')
+	case c == '"':
+		s, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.data) && strings.IndexByte("+-.0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ", p.data[p.pos]) >= 0 {
+		p.pos++
+	}
+	token := string(p.data[start:p.pos])
+	switch {
+	case token == "true" || token == "false":
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: token}, nil
+	case token == "null":
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: token}, nil
+	case token != "" && json.Valid([]byte(token)):
+		tag := "!!int"
+		if strings.ContainsAny(token, ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: token}, nil
+	}
+	if token == "" {
+		return nil, p.errorf("unexpected %q", p.data[p.pos])
+	}
+	return nil, p.errorf("unexpected %q", token)
+}
+
+func (p *jsoncParser) str() (string, error) {
+	end := stringEnd(p.data, p.pos)
+	var s string
+	if err := json.Unmarshal(p.data[p.pos:end], &s); err != nil {
+		return "", p.errorf("bad string: %v", err)
+	}
+	p.pos = end
+	return s, nil
+}
+
+// container parses an object (closed by '}') or array (closed by ']').
+func (p *jsoncParser) container(closing byte) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	if closing == '}' {
+		node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+	p.pos++
+	trailing, leading := p.comments()
+	node.LineComment = joinComments(trailing, " ")
+
+	for {
+		if p.pos >= len(p.data) {
+			return nil, p.errorf("unexpected end of input, expected %q", closing)
+		}
+		if p.data[p.pos] == closing {
+			p.pos++
+			node.FootComment = joinComments(leading, "\n")
+			return node, nil
+		}
+
+		head := leading
+		var key *yaml.Node
+		if closing == '}' {
+			if p.data[p.pos] != '"' {
+				return nil, p.errorf("expected a key, got %q", p.data[p.pos])
+			}
+			name, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			key = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name, HeadComment: joinComments(head, "\n")}
+			trailing, leading = p.comments()
+			if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+				return nil, p.errorf("expected ':' after key %q", name)
+			}
+			p.pos++
+			moreTrailing, moreLeading := p.comments()
+			key.LineComment = joinComments(append(append(append(trailing, leading...), moreTrailing...), moreLeading...), " ")
+		}
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if key != nil {
+			node.Content = append(node.Content, key, value)
+		} else {
+			value.HeadComment = joinComments(head, "\n")
+			node.Content = append(node.Content, value)
+		}
+
+		trailing, leading = p.comments()
+		if p.pos < len(p.data) && p.data[p.pos] == ',' {
+			p.pos++
+			moreTrailing, moreLeading := p.comments()
+			trailing = append(trailing, moreTrailing...)
+			leading = append(leading, moreLeading...)
+		} else if p.pos < len(p.data) && p.data[p.pos] != closing {
+			return nil, p.errorf("expected ',' or %q, got %q", closing, p.data[p.pos])
+		}
+		if len(trailing) > 0 {
+			value.LineComment = joinComments(append(splitComments(value.LineComment), trailing...), " ")
+		}
+	}
+}
+
+func joinComments(comments []string, sep string) string {
+	return strings.Join(comments, sep)
+}
+
+func splitComments(comment string) []string {
+	if comment == "" {
+		return nil
+	}
+	return []string{comment}
+}
+
+// writeJSONC renders a tree from parseJSONC as indented JSONC.
+func writeJSONC(doc *yaml.Node) []byte {
+	var buf bytes.Buffer
+	writeCommentLines(&buf, doc.HeadComment, "")
+	if len(doc.Content) > 0 {
+		writeJSONCValue(&buf, doc.Content[0], "")
+	}
+	buf.WriteByte('\n')
+	writeCommentLines(&buf, doc.FootComment, "")
+	return buf.Bytes()
+}
+
+func writeJSONCValue(buf *bytes.Buffer, n *yaml.Node, indent string) {
+	switch n.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, closing, step := "[", "]", 1
+		if n.Kind == yaml.MappingNode {
+			open, closing, step = "{", "}", 2
+		}
+		if len(n.Content) == 0 && n.LineComment == "" && n.FootComment == "" {
+			buf.WriteString(open + closing)
+			return
+		}
+		buf.WriteString(open)
+		if n.LineComment != "" {
+			buf.WriteString(" " + n.LineComment)
+		}
+		buf.WriteByte('\n')
+		inner := indent + "  "
+		for i := 0; i < len(n.Content); i += step {
+			entry, value := n.Content[i], n.Content[i+step-1]
+			writeCommentLines(buf, entry.HeadComment, inner)
+			buf.WriteString(inner)
+			if step == 2 {
+				buf.WriteString(quoteJSON(entry.Value) + ": ")
+			}
+			writeJSONCValue(buf, value, inner)
+			if i+step < len(n.Content) {
+				buf.WriteByte(',')
+			}
+			var line []string
+			if step == 2 && entry.LineComment != "" {
+				line = append(line, entry.LineComment)
+			}
+			if value.Kind == yaml.ScalarNode && value.LineComment != "" {
+				line = append(line, value.LineComment)
+			}
+			if len(line) > 0 {
+				buf.WriteString(" " + strings.Join(line, " "))
+			}
+			buf.WriteByte('\n')
+		}
+		writeCommentLines(buf, n.FootComment, inner)
+		buf.WriteString(indent + closing)
+	default:
+		switch n.Tag {
+		case "!!str":
+			buf.WriteString(quoteJSON(n.Value))
+		default:
+			buf.WriteString(n.Value)
+		}
+	}
+}
+
+func writeCommentLines(buf *bytes.Buffer, comment, indent string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		buf.WriteString(indent + line + "\n")
+	}
+}
+
+func quoteJSON(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
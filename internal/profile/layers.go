@@ -0,0 +1,201 @@
+// ABOUTME: Ad-hoc profile stacks composed at apply time from several named profiles
+// ABOUTME: Merges layers with the include rules and attributes each item to its layer
+package profile
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/ext"
+)
+
+// LayerSeparator joins layer names into the name of an ad-hoc stack.
+const LayerSeparator = "+"
+
+// LayeredName returns the display name of an ad-hoc stack of layers.
+func LayeredName(layers []string) string {
+	return strings.Join(layers, LayerSeparator)
+}
+
+// ComposeLayers merges the named profiles left to right with the same rules
+// ResolveIncludes applies to a stack profile's includes. Layers may
+// themselves be stacks. The result is named after the layers.
+func ComposeLayers(layers []string, loader ProfileLoader) (*Profile, error) {
+	p, _, err := ComposeLayersWithReport(layers, loader)
+	return p, err
+}
+
+// ComposeLayersWithReport composes layers like ComposeLayers and also
+// returns the merge report.
+func ComposeLayersWithReport(layers []string, loader ProfileLoader) (*Profile, *MergeReport, error) {
+	if len(layers) == 0 {
+		return nil, nil, fmt.Errorf("no profiles to compose")
+	}
+	seen := make(map[string]bool, len(layers))
+	for _, name := range layers {
+		if seen[name] {
+			return nil, nil, fmt.Errorf("profile %q is listed more than once", name)
+		}
+		seen[name] = true
+	}
+	stack := &Profile{Name: LayeredName(layers), Includes: layers}
+	return ResolveIncludesWithReport(stack, loader)
+}
+
+// LayerOrigins records which layer of an ad-hoc stack each item in the
+// composed profile came from.
+type LayerOrigins struct {
+	items map[DiffItemKind]map[string]string
+	// hooks maps ownership ledger hook names to layers
+	hooks map[string]string
+	order []string
+}
+
+// NewLayerOrigins attributes items to layers following the merge rules:
+// list items (plugins, extensions, memory fragments, hooks, permission
+// rules) come from the first layer that lists them; MCP servers and
+// settings values from the last one (later definitions win).
+func NewLayerOrigins(layers []string, loader ProfileLoader) (*LayerOrigins, error) {
+	o := &LayerOrigins{items: map[DiffItemKind]map[string]string{}, hooks: map[string]string{}}
+	for _, name := range layers {
+		p, err := loader.LoadProfile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load profile %q: %w", name, err)
+		}
+		if p, err = ResolveIncludes(p, loader); err != nil {
+			return nil, fmt.Errorf("failed to resolve includes for %q: %w", name, err)
+		}
+		o.order = append(o.order, name)
+		for _, item := range layerItems(p) {
+			from := o.items[item.kind]
+			if from == nil {
+				from = map[string]string{}
+				o.items[item.kind] = from
+			}
+			if _, ok := from[item.name]; !ok || item.lastWins {
+				from[item.name] = name
+			}
+		}
+		for event, hooks := range p.SettingsHooks {
+			for _, h := range hooks {
+				if key := hookItem(event, h).Name; o.hooks[key] == "" {
+					o.hooks[key] = name
+				}
+			}
+		}
+	}
+	return o, nil
+}
+
+// layerItem is one item a profile applies, named as diffs name it.
+type layerItem struct {
+	kind     DiffItemKind
+	name     string
+	lastWins bool
+}
+
+// layerItems lists every item p applies, at any scope.
+func layerItems(p *Profile) []layerItem {
+	var items []layerItem
+	add := func(kind DiffItemKind, lastWins bool, names ...string) {
+		for _, name := range names {
+			items = append(items, layerItem{kind: kind, name: name, lastWins: lastWins})
+		}
+	}
+	perScope := p.AsPerScope().PerScope
+	for _, s := range []*ScopeSettings{perScope.User, perScope.Project, perScope.Local} {
+		if s == nil {
+			continue
+		}
+		add(DiffPlugin, false, s.Plugins...)
+		for _, server := range s.MCPServers {
+			add(DiffMCP, true, server.Name)
+		}
+		for _, category := range ext.AllCategories() {
+			add(DiffExtension, false, s.Extensions.Items(category)...)
+		}
+		add(DiffMemory, false, s.Memory...)
+		if b := s.Settings; b != nil {
+			for key := range b.scalars() {
+				add(DiffSetting, true, key)
+			}
+			for key := range b.Env {
+				add(DiffSetting, true, "env."+key)
+			}
+			for _, key := range permissionListKeys {
+				add(DiffSetting, false, b.Permissions.list(key)...)
+			}
+			if b.Permissions != nil && b.Permissions.DefaultMode != "" {
+				add(DiffSetting, true, "permissions.defaultMode")
+			}
+		}
+	}
+	for _, hooks := range p.SettingsHooks {
+		for _, h := range hooks {
+			add(DiffHook, false, h.label())
+		}
+	}
+	return items
+}
+
+// Plugin returns the layer that contributed the plugin, or "".
+func (o *LayerOrigins) Plugin(name string) string {
+	return o.items[DiffPlugin][name]
+}
+
+// MCPServer returns the layer that contributed the MCP server, or "".
+func (o *LayerOrigins) MCPServer(name string) string {
+	return o.items[DiffMCP][name]
+}
+
+// Hook returns the layer that contributed a hook, named as the ownership
+// ledger names it, or "".
+func (o *LayerOrigins) Hook(name string) string {
+	return o.hooks[name]
+}
+
+// Marketplace returns the first layer, in layer order, with a plugin from
+// the named marketplace, or "" if no layer's plugins use it.
+func (o *LayerOrigins) Marketplace(name string) string {
+	for _, layer := range o.order {
+		for plugin, from := range o.items[DiffPlugin] {
+			if from == layer && strings.HasSuffix(plugin, "@"+name) {
+				return layer
+			}
+		}
+	}
+	return ""
+}
+
+// Origin returns the layer a diff item came from, or "" when no layer
+// declares it.
+func (o *LayerOrigins) Origin(kind DiffItemKind, name string) string {
+	if kind == DiffMarketplace {
+		return o.Marketplace(name)
+	}
+	return o.items[kind][name]
+}
+
+// UnremovableKinds returns the kinds of item in layer that profile
+// remove-layer can't undo: settings and extensions, since reset only removes
+// plugins, MCP servers, marketplaces, hooks, and memory blocks.
+// List items a remaining layer (described by remaining) also declares stay
+// applied anyway and don't count; a settings value always counts, because
+// the layer's value may be the one that is live.
+func UnremovableKinds(layer *Profile, remaining *LayerOrigins) []DiffItemKind {
+	found := make(map[DiffItemKind]bool)
+	var kinds []DiffItemKind
+	for _, item := range layerItems(layer) {
+		switch item.kind {
+		case DiffSetting, DiffExtension:
+		default:
+			continue
+		}
+		shared := !item.lastWins && remaining.Origin(item.kind, item.name) != ""
+		if !found[item.kind] && !shared {
+			found[item.kind] = true
+			kinds = append(kinds, item.kind)
+		}
+	}
+	return kinds
+}
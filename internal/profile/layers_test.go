@@ -0,0 +1,138 @@
+// ABOUTME: Tests for ad-hoc profile stacks composed from several named profiles
+// ABOUTME: Covers merging, duplicate layers, per-layer attribution, and shared hooks
+package profile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func layerTestLoader() *mockLoader {
+	return &mockLoader{profiles: map[string]*Profile{
+		"base": {
+			Name:       "base",
+			Plugins:    []string{"git@tools", "format@tools"},
+			MCPServers: []MCPServer{{Name: "notes", Command: "notes-v1"}},
+		},
+		"python-dev": {
+			Name:       "python-dev",
+			Plugins:    []string{"format@tools", "pytest@py"},
+			MCPServers: []MCPServer{{Name: "notes", Command: "notes-v2"}},
+		},
+		"security": {Name: "security", Includes: []string{"audit"}},
+		"audit": {
+			Name:          "audit",
+			Plugins:       []string{"scan@sec"},
+			SettingsHooks: map[string][]HookEntry{"PreToolUse": {{Matcher: "Bash", Command: "audit.sh"}, {Matcher: "Bash", Command: "log.sh"}}},
+			Memory:        []string{"secure-coding"},
+			Settings: &SettingsBlock{
+				Model:       "opus",
+				Permissions: &PermissionSettings{Deny: []string{"Bash(curl:*)"}},
+			},
+			Extensions: &ExtensionSettings{Rules: []string{"no-secrets"}},
+		},
+	}}
+}
+
+func TestComposeLayers(t *testing.T) {
+	loader := layerTestLoader()
+
+	p, err := ComposeLayers([]string{"base", "python-dev", "security"}, loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "base+python-dev+security" {
+		t.Errorf("Name = %q", p.Name)
+	}
+	plugins := p.AsPerScope().PerScope.User.Plugins
+	if want := []string{"git@tools", "format@tools", "pytest@py", "scan@sec"}; !reflect.DeepEqual(plugins, want) {
+		t.Errorf("plugins = %v, want %v", plugins, want)
+	}
+	servers := p.AsPerScope().PerScope.User.MCPServers
+	if len(servers) != 1 || servers[0].Command != "notes-v2" {
+		t.Errorf("later layers should win for MCP servers: %+v", servers)
+	}
+
+	if _, err := ComposeLayers([]string{"base", "base"}, loader); err == nil {
+		t.Error("expected error for a repeated layer")
+	}
+	if _, err := ComposeLayers([]string{"base", "missing"}, loader); err == nil {
+		t.Error("expected error for a missing layer")
+	}
+}
+
+func TestLayerOrigins(t *testing.T) {
+	origins, err := NewLayerOrigins([]string{"base", "python-dev", "security"}, layerTestLoader())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		got, want string
+	}{
+		{origins.Plugin("format@tools"), "base"},
+		{origins.Plugin("pytest@py"), "python-dev"},
+		{origins.Plugin("scan@sec"), "security"},
+		{origins.Plugin("unknown@x"), ""},
+		{origins.MCPServer("notes"), "python-dev"},
+		{origins.Marketplace("py"), "python-dev"},
+		{origins.Marketplace("unused"), ""},
+		{origins.Origin(DiffPlugin, "git@tools"), "base"},
+		{origins.Origin(DiffHook, "git@tools"), ""},
+		{origins.Origin(DiffHook, "audit.sh"), "security"},
+		{origins.Origin(DiffSetting, "model"), "security"},
+		{origins.Origin(DiffSetting, "Bash(curl:*)"), "security"},
+		{origins.Origin(DiffMemory, "secure-coding"), "security"},
+		{origins.Origin(DiffExtension, "no-secrets"), "security"},
+	}
+	for i, c := range cases {
+		if c.got != c.want {
+			t.Errorf("case %d: got %q, want %q", i, c.got, c.want)
+		}
+	}
+}
+
+func TestUnremovableKinds(t *testing.T) {
+	loader := layerTestLoader()
+	security, err := ResolveIncludes(loader.profiles["security"], loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remaining, err := NewLayerOrigins([]string{"base"}, loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := UnremovableKinds(security, remaining); !reflect.DeepEqual(got, []DiffItemKind{DiffExtension, DiffSetting}) {
+		t.Errorf("UnremovableKinds() = %v", got)
+	}
+
+	python, _ := loader.LoadProfile("python-dev")
+	if got := UnremovableKinds(python, remaining); len(got) != 0 {
+		t.Errorf("a layer with only plugins and MCP servers can be removed, got %v", got)
+	}
+
+	// List items another layer also declares stay applied anyway
+	loader.profiles["strict"] = &Profile{Name: "strict", Memory: []string{"secure-coding"}, Extensions: &ExtensionSettings{Rules: []string{"no-secrets"}},
+		Settings: &SettingsBlock{Permissions: &PermissionSettings{Deny: []string{"Bash(curl:*)"}}}}
+	security.Settings.Model = ""
+	remaining, err = NewLayerOrigins([]string{"base", "strict"}, loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := UnremovableKinds(security, remaining); len(got) != 0 {
+		t.Errorf("shared list items should not block removal, got %v", got)
+	}
+}
+
+func TestLayerOriginsHook(t *testing.T) {
+	origins, err := NewLayerOrigins([]string{"base", "security"}, layerTestLoader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := origins.Hook(hookItem("PreToolUse", HookEntry{Matcher: "Bash", Command: "audit.sh"}).Name); got != "security" {
+		t.Errorf("Hook(audit.sh) = %q, want security", got)
+	}
+	if got := origins.Hook("PreToolUse: audit.sh"); got != "" {
+		t.Errorf("a hook with another matcher should not match, got %q", got)
+	}
+}
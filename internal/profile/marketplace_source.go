@@ -0,0 +1,192 @@
+// ABOUTME: Marketplace source handling for GitHub repos, git URLs, and local directories
+// ABOUTME: Provides lookup keys, claude CLI arguments, and registry conversion per source type
+package profile
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+)
+
+// Marketplace source types, matching the "source" field in known_marketplaces.json
+const (
+	MarketplaceSourceGitHub    = "github"
+	MarketplaceSourceGit       = "git"
+	MarketplaceSourceDirectory = "directory"
+)
+
+// isLocalMarketplacePath reports whether arg looks like a filesystem path
+// rather than a repo shorthand or URL.
+func isLocalMarketplacePath(arg string) bool {
+	return arg == "." || arg == "~" ||
+		strings.HasPrefix(arg, "/") ||
+		strings.HasPrefix(arg, "./") ||
+		strings.HasPrefix(arg, "../") ||
+		strings.HasPrefix(arg, "~/") ||
+		filepath.IsAbs(arg)
+}
+
+// isGitURL reports whether arg is a URL git can clone: scheme URLs
+// (https, ssh, git, file) or scp-style "user@host:path".
+func isGitURL(arg string) bool {
+	if strings.Contains(arg, "://") {
+		return true
+	}
+	at := strings.Index(arg, "@")
+	colon := strings.Index(arg, ":")
+	return at > 0 && colon > at+1 && colon < len(arg)-1 && !strings.Contains(arg[:at], "/")
+}
+
+// validateGitURL checks a scheme URL has a supported scheme and a host.
+// scp-style URLs are accepted as-is.
+func validateGitURL(raw string) error {
+	if !strings.Contains(raw, "://") {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid marketplace URL %q: %w", raw, err)
+	}
+	switch u.Scheme {
+	case "https", "http", "ssh", "git":
+		if u.Host == "" {
+			return fmt.Errorf("invalid marketplace URL %q: missing host", raw)
+		}
+	case "file":
+	default:
+		return fmt.Errorf("invalid marketplace URL %q: unsupported scheme %q", raw, u.Scheme)
+	}
+	return nil
+}
+
+// splitMarketplaceRef splits a trailing "#ref" from a marketplace argument.
+func splitMarketplaceRef(arg string) (string, string) {
+	if idx := strings.LastIndex(arg, "#"); idx != -1 {
+		return arg[:idx], arg[idx+1:]
+	}
+	return arg, ""
+}
+
+// hostShorthandURL turns "host.tld/group/repo" into an https clone URL.
+// GitHub owners cannot contain dots, so a dotted first segment with at least
+// two more segments is treated as a self-hosted GitLab or Gitea repo.
+func hostShorthandURL(arg string) (string, bool) {
+	parts := strings.Split(arg, "/")
+	if len(parts) < 3 || !strings.Contains(parts[0], ".") {
+		return "", false
+	}
+	for _, part := range parts {
+		if part == "" {
+			return "", false
+		}
+	}
+	if !strings.HasSuffix(arg, ".git") {
+		arg += ".git"
+	}
+	return "https://" + arg, true
+}
+
+// Validate checks that the marketplace has a location matching its source type.
+func (m Marketplace) Validate() error {
+	switch {
+	case m.Source == MarketplaceSourceGitHub && m.Repo == "":
+		return fmt.Errorf("marketplace repo cannot be empty")
+	case m.Source == MarketplaceSourceGit && m.URL == "":
+		return fmt.Errorf("marketplace url cannot be empty")
+	case m.Source == MarketplaceSourceDirectory && m.Path == "":
+		return fmt.Errorf("marketplace path cannot be empty")
+	case m.Location() == "":
+		return fmt.Errorf("marketplace needs a repo, url, or path")
+	}
+	switch m.Source {
+	case "", MarketplaceSourceGitHub, MarketplaceSourceGit, MarketplaceSourceDirectory:
+	default:
+		return fmt.Errorf("marketplace %s: unknown source %q (expected github, git, or directory)", m.Location(), m.Source)
+	}
+	if m.IsDirectory() && m.Ref != "" {
+		return fmt.Errorf("marketplace %s: ref is not supported for directory sources", m.Path)
+	}
+	if m.Repo == "" && m.URL != "" {
+		return validateGitURL(m.URL)
+	}
+	return nil
+}
+
+// IsDirectory reports whether the marketplace is a local directory.
+func (m Marketplace) IsDirectory() bool {
+	return m.Source == MarketplaceSourceDirectory || (m.Repo == "" && m.URL == "" && m.Path != "")
+}
+
+// resolveMarketplacePath expands "~" and makes a directory marketplace path
+// absolute relative to the current working directory.
+func resolveMarketplacePath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// marketplaceAddArg returns the argument passed to `claude plugin marketplace add`.
+// Directory paths are resolved to absolute paths; refs are appended as "#ref".
+func marketplaceAddArg(m Marketplace) string {
+	key := marketplaceKey(m)
+	if key == "" || m.Ref == "" || m.IsDirectory() {
+		return key
+	}
+	return key + "#" + m.Ref
+}
+
+// Key returns the location used to look the marketplace up in the registry,
+// with directory paths made absolute.
+func (m Marketplace) Key() string {
+	return marketplaceKey(m)
+}
+
+// AddArg returns the argument for `claude plugin marketplace add`.
+func (m Marketplace) AddArg() string {
+	return marketplaceAddArg(m)
+}
+
+// marketplaceIdentity returns the key plus ref, so the same location pinned
+// to different refs compares as different marketplaces.
+func marketplaceIdentity(m Marketplace) string {
+	key := marketplaceKey(m)
+	if m.Ref != "" {
+		return key + "#" + m.Ref
+	}
+	return key
+}
+
+// marketplaceLocationBase returns the last path segment of the marketplace
+// location without a ".git" suffix (e.g. "group/sub/plugins.git" -> "plugins").
+func marketplaceLocationBase(m Marketplace) string {
+	loc := strings.TrimRight(m.Location(), "/")
+	if loc == "" {
+		return ""
+	}
+	if idx := strings.LastIndexAny(loc, "/:"); idx != -1 {
+		loc = loc[idx+1:]
+	}
+	return strings.TrimSuffix(loc, ".git")
+}
+
+// marketplaceFromSource converts a known_marketplaces.json source entry into
+// a profile Marketplace.
+func marketplaceFromSource(src claude.MarketplaceSource) Marketplace {
+	return Marketplace{
+		Source: src.Source,
+		Repo:   src.Repo,
+		URL:    src.URL,
+		Path:   src.Path,
+		Ref:    src.Ref,
+	}
+}
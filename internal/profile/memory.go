@@ -0,0 +1,285 @@
+// ABOUTME: Composes CLAUDE.md memory files from fragments in the extension library
+// ABOUTME: Applies fragments as managed blocks per scope, snapshots them, and detects content drift
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/claudeup/claudeup/v5/internal/ext"
+	"github.com/claudeup/claudeup/v5/internal/ledger"
+)
+
+// memoryFilePath returns the CLAUDE.md file a scope's fragments are composed
+// into: ~/.claude/CLAUDE.md, <project>/CLAUDE.md, or <project>/CLAUDE.local.md.
+func memoryFilePath(scope, claudeDir, projectDir string) (string, error) {
+	switch scope {
+	case "user":
+		return filepath.Join(claudeDir, "CLAUDE.md"), nil
+	case "project", "local":
+		if projectDir == "" {
+			return "", fmt.Errorf("project directory required for %s-scope memory", scope)
+		}
+		if scope == "local" {
+			return filepath.Join(projectDir, "CLAUDE.local.md"), nil
+		}
+		return filepath.Join(projectDir, "CLAUDE.md"), nil
+	}
+	return "", fmt.Errorf("invalid scope %q", scope)
+}
+
+// readMemoryFile returns a CLAUDE.md file's text, or "" if it does not exist.
+func readMemoryFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// applyScopeMemory writes each fragment into the scope's CLAUDE.md as a
+// managed block, updating blocks in place and leaving other content alone.
+func applyScopeMemory(fragments []string, scope, claudeDir, projectDir, claudeupHome string) error {
+	if len(fragments) == 0 {
+		return nil
+	}
+	path, err := memoryFilePath(scope, claudeDir, projectDir)
+	if err != nil {
+		return err
+	}
+	text, err := readMemoryFile(path)
+	if err != nil {
+		return err
+	}
+
+	extDir := filepath.Join(claudeupHome, "ext")
+	updated := text
+	for _, name := range fragments {
+		content, err := ext.ReadMemoryFragment(extDir, name)
+		if err != nil {
+			return err
+		}
+		updated = ext.SetMemoryBlock(updated, name, content)
+	}
+	if updated == text {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// removeMemoryBlocks deletes the named managed blocks from a scope's
+// CLAUDE.md. Blocks that are already gone are skipped.
+func removeMemoryBlocks(names []string, scope, claudeDir, projectDir string) error {
+	path, err := memoryFilePath(scope, claudeDir, projectDir)
+	if err != nil {
+		return err
+	}
+	text, err := readMemoryFile(path)
+	if err != nil {
+		return err
+	}
+	updated := text
+	for _, name := range names {
+		updated = ext.RemoveMemoryBlock(updated, name)
+	}
+	if updated == text {
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// RemoveDroppedMemory removes the managed CLAUDE.md blocks that the ownership
+// ledger records one of owners as having added but that p no longer lists.
+// A flat profile is only compared at scope, the scope it was applied at; a
+// per-scope profile is compared at every scope. Returns the removed names.
+func RemoveDroppedMemory(p *Profile, scope string, owners []string, claudeDir, projectDir, claudeupHome string) ([]string, error) {
+	book, err := ledger.Load(claudeupHome)
+	if err != nil {
+		return nil, err
+	}
+	wanted := map[string][]string{scope: p.Memory}
+	if p.PerScope != nil {
+		wanted = map[string][]string{}
+		for s, settings := range map[string]*ScopeSettings{"user": p.PerScope.User, "project": p.PerScope.Project, "local": p.PerScope.Local} {
+			var names []string
+			if settings != nil {
+				names = settings.Memory
+			}
+			wanted[s] = names
+		}
+	}
+
+	var removed []string
+	for _, owner := range owners {
+		for _, e := range book.Entries(owner, projectDir) {
+			listed, compared := wanted[e.Scope]
+			if e.Kind != ledger.KindMemory || !compared || slices.Contains(listed, e.Name) {
+				continue
+			}
+			if err := removeMemoryBlocks([]string{e.Name}, e.Scope, claudeDir, projectDir); err != nil {
+				return removed, err
+			}
+			removed = append(removed, e.Name)
+		}
+	}
+	return removed, nil
+}
+
+// readMemoryForScope returns the names of managed blocks in a scope's
+// CLAUDE.md. Unreadable files yield nil, like the other snapshot readers.
+func readMemoryForScope(scope, claudeDir, projectDir string) []string {
+	names, _ := memoryBlockNames(scope, claudeDir, projectDir)
+	return names
+}
+
+// memoryBlockNames returns the names of managed blocks in a scope's
+// CLAUDE.md, or an error if the file exists but cannot be read.
+func memoryBlockNames(scope, claudeDir, projectDir string) ([]string, error) {
+	path, err := memoryFilePath(scope, claudeDir, projectDir)
+	if err != nil {
+		return nil, err
+	}
+	text, err := readMemoryFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, block := range ext.ParseMemoryBlocks(text) {
+		names = append(names, block.Name)
+	}
+	return names, nil
+}
+
+// memoryChanges compares fragments against the managed blocks in a scope's
+// CLAUDE.md. A block that is missing is reported as removed; one whose text
+// differs from the library fragment is reported as modified.
+func memoryChanges(fragments []string, scope, claudeDir, projectDir, claudeupHome string) []DiffItem {
+	if len(fragments) == 0 {
+		return nil
+	}
+	path, err := memoryFilePath(scope, claudeDir, projectDir)
+	if err != nil {
+		return nil
+	}
+	text, err := readMemoryFile(path)
+	if err != nil {
+		return nil
+	}
+	live := make(map[string]string)
+	for _, block := range ext.ParseMemoryBlocks(text) {
+		live[block.Name] = block.Content
+	}
+
+	extDir := filepath.Join(claudeupHome, "ext")
+	file := filepath.Base(path)
+	var items []DiffItem
+	for _, name := range fragments {
+		content, exists := live[name]
+		if !exists {
+			items = append(items, DiffItem{Op: DiffRemoved, Kind: DiffMemory, Name: name, Detail: file})
+			continue
+		}
+		library, err := ext.ReadMemoryFragment(extDir, name)
+		switch {
+		case err != nil:
+			items = append(items, DiffItem{Op: DiffModified, Kind: DiffMemory, Name: name, Detail: "not in library"})
+		case library != content:
+			items = append(items, DiffItem{Op: DiffModified, Kind: DiffMemory, Name: name, Detail: file + " differs from library"})
+		}
+	}
+	return items
+}
+
+// MemoryDrift reports, per scope, managed CLAUDE.md blocks whose text no
+// longer matches the profile's fragments in the library. Missing blocks are
+// left to ComputeProfileDiff, which compares fragment names.
+func MemoryDrift(p *Profile, claudeDir, projectDir, claudeupHome string) []ScopeDiff {
+	p = p.AsPerScope()
+	var scopes []ScopeDiff
+	for _, scope := range []string{"user", "project", "local"} {
+		if scope != "user" && projectDir == "" {
+			continue
+		}
+		var items []DiffItem
+		for _, item := range memoryChanges(scopeMemory(getScopeSettings(p, scope)), scope, claudeDir, projectDir, claudeupHome) {
+			if item.Op == DiffModified {
+				items = append(items, item)
+			}
+		}
+		if len(items) > 0 {
+			scopes = append(scopes, ScopeDiff{Scope: scope, Items: items})
+		}
+	}
+	return scopes
+}
+
+// CaptureMemory writes the managed blocks of the profile's fragments back to
+// the library when they were edited in CLAUDE.md (or are missing from the
+// library), so a saved profile re-applies what is live. Returns the names of
+// the fragments written.
+func CaptureMemory(p *Profile, claudeDir, projectDir, claudeupHome string) ([]string, error) {
+	p = p.AsPerScope()
+	extDir := filepath.Join(claudeupHome, "ext")
+	var captured []string
+	for _, scope := range []string{"user", "project", "local"} {
+		fragments := scopeMemory(getScopeSettings(p, scope))
+		if len(fragments) == 0 {
+			continue
+		}
+		path, err := memoryFilePath(scope, claudeDir, projectDir)
+		if err != nil {
+			continue
+		}
+		text, err := readMemoryFile(path)
+		if err != nil {
+			return captured, err
+		}
+		wanted := toSet(fragments)
+		for _, block := range ext.ParseMemoryBlocks(text) {
+			if _, ok := wanted[block.Name]; !ok {
+				continue
+			}
+			if library, err := ext.ReadMemoryFragment(extDir, block.Name); err == nil && library == block.Content {
+				continue
+			}
+			if err := ext.WriteMemoryFragment(extDir, block.Name, block.Content); err != nil {
+				return captured, err
+			}
+			captured = append(captured, block.Name)
+		}
+	}
+	return captured, nil
+}
+
+// diffMemory compares fragment names. A profile without memory does not
+// manage CLAUDE.md, so live blocks are not reported against it.
+func diffMemory(saved, live []string) []DiffItem {
+	if len(saved) == 0 {
+		return nil
+	}
+	return diffStringSet(saved, live, DiffMemory)
+}
+
+func scopeMemory(s *ScopeSettings) []string {
+	if s == nil {
+		return nil
+	}
+	return s.Memory
+}
@@ -0,0 +1,224 @@
+// ABOUTME: Tests for composing CLAUDE.md from memory fragments in profiles
+// ABOUTME: Covers apply per scope, snapshots, drift detection, save capture, and stack merging
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/claudeup/claudeup/v5/internal/ext"
+	"github.com/claudeup/claudeup/v5/internal/ledger"
+)
+
+func writeMemoryFragments(t *testing.T, claudeupHome string, fragments map[string]string) {
+	t.Helper()
+	for name, content := range fragments {
+		if err := ext.WriteMemoryFragment(filepath.Join(claudeupHome, "ext"), name, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestApplyScopeMemoryPreservesUserContent(t *testing.T) {
+	claudeDir := t.TempDir()
+	claudeupHome := t.TempDir()
+	writeMemoryFragments(t, claudeupHome, map[string]string{"go-style": "Use gofmt.", "tdd": "Tests first."})
+	writeSettingsJSON(t, filepath.Join(claudeDir, "CLAUDE.md"), "# Personal notes\n")
+
+	for i := 0; i < 2; i++ {
+		if err := applyScopeMemory([]string{"go-style", "tdd"}, "user", claudeDir, "", claudeupHome); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := "# Personal notes\n\n" +
+		"<!-- claudeup:begin go-style -->\nUse gofmt.\n<!-- claudeup:end go-style -->\n\n" +
+		"<!-- claudeup:begin tdd -->\nTests first.\n<!-- claudeup:end tdd -->\n"
+	if got := readFile(t, filepath.Join(claudeDir, "CLAUDE.md")); got != want {
+		t.Errorf("CLAUDE.md after applying twice:\n got %q\nwant %q", got, want)
+	}
+
+	if err := applyScopeMemory([]string{"missing"}, "user", claudeDir, "", claudeupHome); err == nil {
+		t.Error("expected error for a fragment not in the library")
+	}
+}
+
+func TestDroppedMemoryFragmentsAreRemoved(t *testing.T) {
+	tmpDir := t.TempDir()
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	claudeupHome := filepath.Join(tmpDir, ".claudeup")
+	projectDir := t.TempDir()
+	writeMemoryFragments(t, claudeupHome, map[string]string{"go-style": "Use gofmt.", "tdd": "Tests first.", "mine": "Mine."})
+	claudeMD := filepath.Join(claudeDir, "CLAUDE.md")
+	writeSettingsJSON(t, claudeMD, "# Personal notes\n")
+	// A block the user composed before the profile was applied
+	if err := applyScopeMemory([]string{"mine"}, "user", claudeDir, "", claudeupHome); err != nil {
+		t.Fatal(err)
+	}
+
+	claudeJSONPath := filepath.Join(tmpDir, ".claude.json")
+	before := liveItems(t, claudeDir, claudeJSONPath, projectDir)
+	if err := applyScopeMemory([]string{"go-style", "tdd"}, "user", claudeDir, "", claudeupHome); err != nil {
+		t.Fatal(err)
+	}
+	book, err := ledger.Load(claudeupHome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	book.Record("team", IntroducedItems(before, liveItems(t, claudeDir, claudeJSONPath, projectDir)), time.Now())
+	if err := ledger.Save(claudeupHome, book); err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-applying without tdd takes its block back and leaves the rest
+	p := &Profile{Name: "team", Memory: []string{"go-style"}}
+	removed, err := RemoveDroppedMemory(p, "user", []string{"team"}, claudeDir, projectDir, claudeupHome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(removed, []string{"tdd"}) {
+		t.Errorf("removed = %v, want [tdd]", removed)
+	}
+	if got := readMemoryForScope("user", claudeDir, ""); !reflect.DeepEqual(got, []string{"mine", "go-style"}) {
+		t.Errorf("blocks after re-apply = %v", got)
+	}
+
+	// Reset removes the blocks the profile added, and only those
+	result, err := ResetWithExecutor(p, claudeDir, claudeJSONPath, projectDir, claudeupHome, &mockExecutor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.MemoryRemoved, []string{"go-style", "tdd"}) {
+		t.Errorf("MemoryRemoved = %v", result.MemoryRemoved)
+	}
+	want := "# Personal notes\n\n<!-- claudeup:begin mine -->\nMine.\n<!-- claudeup:end mine -->\n"
+	if got := readFile(t, claudeMD); got != want {
+		t.Errorf("CLAUDE.md after reset:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestMemoryFilesPerScope(t *testing.T) {
+	claudeDir := t.TempDir()
+	projectDir := t.TempDir()
+	claudeupHome := t.TempDir()
+	writeMemoryFragments(t, claudeupHome, map[string]string{"team": "Team rules.", "mine": "My rules."})
+
+	if err := applyScopeMemory([]string{"team"}, "project", claudeDir, projectDir, claudeupHome); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyScopeMemory([]string{"mine"}, "local", claudeDir, projectDir, claudeupHome); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := SnapshotAllScopes("live", claudeDir, filepath.Join(claudeDir, ".claude.json"), projectDir, claudeupHome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.PerScope.Project == nil || !reflect.DeepEqual(p.PerScope.Project.Memory, []string{"team"}) {
+		t.Errorf("project memory = %+v", p.PerScope.Project)
+	}
+	if p.PerScope.Local == nil || !reflect.DeepEqual(p.PerScope.Local.Memory, []string{"mine"}) {
+		t.Errorf("local memory = %+v", p.PerScope.Local)
+	}
+	if !strings.Contains(readFile(t, filepath.Join(projectDir, "CLAUDE.local.md")), "My rules.") {
+		t.Error("local fragment should be written to CLAUDE.local.md")
+	}
+}
+
+func TestMemoryDriftAndCapture(t *testing.T) {
+	claudeDir := t.TempDir()
+	claudeupHome := t.TempDir()
+	writeMemoryFragments(t, claudeupHome, map[string]string{"go-style": "Use gofmt."})
+
+	saved := &Profile{Name: "go", Memory: []string{"go-style"}}
+	if err := applyScopeMemory(saved.Memory, "user", claudeDir, "", claudeupHome); err != nil {
+		t.Fatal(err)
+	}
+	if drift := MemoryDrift(saved, claudeDir, "", claudeupHome); len(drift) != 0 {
+		t.Fatalf("expected no drift right after apply, got %+v", drift)
+	}
+
+	// Edit the managed block by hand
+	path := filepath.Join(claudeDir, "CLAUDE.md")
+	edited := strings.Replace(readFile(t, path), "Use gofmt.", "Use gofmt and go vet.", 1)
+	writeSettingsJSON(t, path, edited)
+
+	drift := MemoryDrift(saved, claudeDir, "", claudeupHome)
+	want := []ScopeDiff{{Scope: "user", Items: []DiffItem{
+		{Op: DiffModified, Kind: DiffMemory, Name: "go-style", Detail: "CLAUDE.md differs from library"},
+	}}}
+	if !reflect.DeepEqual(drift, want) {
+		t.Errorf("MemoryDrift = %+v", drift)
+	}
+
+	captured, err := CaptureMemory(saved, claudeDir, "", claudeupHome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(captured, []string{"go-style"}) {
+		t.Errorf("captured = %v", captured)
+	}
+	if got, _ := ext.ReadMemoryFragment(filepath.Join(claudeupHome, "ext"), "go-style"); got != "Use gofmt and go vet." {
+		t.Errorf("library fragment after capture = %q", got)
+	}
+	if drift := MemoryDrift(saved, claudeDir, "", claudeupHome); len(drift) != 0 {
+		t.Errorf("expected no drift after capture, got %+v", drift)
+	}
+}
+
+func TestDiffMemoryNames(t *testing.T) {
+	saved := &Profile{PerScope: &PerScopeSettings{User: &ScopeSettings{Memory: []string{"go-style", "tdd"}}}}
+	live := &Profile{PerScope: &PerScopeSettings{User: &ScopeSettings{Memory: []string{"go-style", "extra"}}}}
+
+	diff := ComputeProfileDiff(saved, live)
+	var got []string
+	for _, sd := range diff.Scopes {
+		for _, item := range sd.Items {
+			got = append(got, string(item.Op)+" "+string(item.Kind)+" "+item.Name)
+		}
+	}
+	want := []string{"added memory extra", "removed memory tdd"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diff = %v, want %v", got, want)
+	}
+
+	// Profiles without memory do not report live managed blocks
+	if d := ComputeProfileDiff(&Profile{PerScope: &PerScopeSettings{}}, live); !d.IsEmpty() {
+		t.Errorf("expected empty diff, got %+v", d.Scopes)
+	}
+
+	merged := mergeProfiles([]*Profile{{Memory: []string{"a", "b"}}, {Memory: []string{"b", "c"}}})
+	if !reflect.DeepEqual(merged.Memory, []string{"a", "b", "c"}) {
+		t.Errorf("merged memory = %v", merged.Memory)
+	}
+}
+
+func TestProfileDiffAddScopeItems(t *testing.T) {
+	d := &ProfileDiff{Scopes: []ScopeDiff{{Scope: "user"}, {Scope: "local"}}}
+	d.AddScopeItems("project", []DiffItem{{Op: DiffModified, Kind: DiffMemory, Name: "team"}})
+	d.AddScopeItems("user", []DiffItem{{Op: DiffModified, Kind: DiffMemory, Name: "mine"}})
+
+	var scopes []string
+	for _, sd := range d.Scopes {
+		scopes = append(scopes, sd.Scope)
+	}
+	if !reflect.DeepEqual(scopes, []string{"user", "project", "local"}) {
+		t.Errorf("scope order = %v", scopes)
+	}
+	if len(d.Scopes[0].Items) != 1 || d.Scopes[0].Items[0].Name != "mine" {
+		t.Errorf("user items = %+v", d.Scopes[0].Items)
+	}
+}
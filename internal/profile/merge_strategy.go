@@ -0,0 +1,459 @@
+// ABOUTME: Per-field merge rules for stack profiles and the conflict report of include resolution
+// ABOUTME: Detects items defined differently by two includes, applies strategies, and tracks provenance
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/ext"
+)
+
+// MergeStrategy decides how a stack merges one field across its includes.
+type MergeStrategy string
+
+const (
+	// MergeOverride lets the later include win a conflict (the default).
+	MergeOverride MergeStrategy = "override"
+	// MergeError fails resolution when two includes conflict.
+	MergeError MergeStrategy = "error"
+	// MergeAppend keeps the first definition; later includes only add new items.
+	MergeAppend MergeStrategy = "append"
+	// MergeRemove subtracts the listed items inherited from the includes.
+	MergeRemove MergeStrategy = "remove"
+)
+
+// Fields that accept merge rules, as named in profile JSON.
+const (
+	MergeFieldPlugins    = "plugins"
+	MergeFieldMCPServers = "mcpServers"
+	MergeFieldExtensions = "extensions"
+	MergeFieldMemory     = "memory"
+)
+
+// conflictFields can hold two different definitions of the same item.
+// The other fields are unions of names and never conflict.
+var conflictFields = map[string]bool{
+	MergeFieldMCPServers: true,
+	MergeFieldExtensions: true,
+}
+
+var mergeFields = []string{MergeFieldPlugins, MergeFieldMCPServers, MergeFieldExtensions, MergeFieldMemory}
+
+// MergeRule is a stack's strategy for one field. Items lists what the remove
+// strategy subtracts: plugin names, MCP server names, "category/item"
+// extensions, or memory fragments. In profile JSON a rule is written as the
+// bare strategy ("error") or as {"strategy": "remove", "items": [...]}.
+type MergeRule struct {
+	Strategy MergeStrategy `json:"strategy"`
+	Items    []string      `json:"items,omitempty"`
+}
+
+// UnmarshalJSON accepts the bare strategy string or the object form.
+func (r *MergeRule) UnmarshalJSON(data []byte) error {
+	var strategy string
+	if err := json.Unmarshal(data, &strategy); err == nil {
+		*r = MergeRule{Strategy: MergeStrategy(strategy)}
+		return nil
+	}
+	type rawRule MergeRule
+	var raw rawRule
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("merge rule must be a strategy name or an object: %w", err)
+	}
+	*r = MergeRule(raw)
+	return nil
+}
+
+// MarshalJSON writes rules without items as the bare strategy string.
+func (r MergeRule) MarshalJSON() ([]byte, error) {
+	if len(r.Items) == 0 {
+		return json.Marshal(string(r.Strategy))
+	}
+	type rawRule MergeRule
+	return json.Marshal(rawRule(r))
+}
+
+// validateMergeRules checks a profile's merge rules name known fields and
+// strategies that apply to them.
+func validateMergeRules(p *Profile) error {
+	if len(p.Merge) == 0 {
+		return nil
+	}
+	if !p.IsStack() {
+		return fmt.Errorf("profile %q has merge rules but no includes; merge rules only apply to stacks", p.Name)
+	}
+	for field, rule := range p.Merge {
+		known := false
+		for _, f := range mergeFields {
+			known = known || f == field
+		}
+		if !known {
+			return fmt.Errorf("profile %q: unknown merge field %q (valid: %s)", p.Name, field, strings.Join(mergeFields, ", "))
+		}
+		switch rule.Strategy {
+		case MergeRemove:
+			if len(rule.Items) == 0 {
+				return fmt.Errorf("profile %q: merge rule for %q removes nothing; list the items to remove", p.Name, field)
+			}
+		case MergeOverride, MergeError, MergeAppend:
+			if !conflictFields[field] {
+				return fmt.Errorf("profile %q: %q is combined from every include; only the %q strategy applies to it", p.Name, field, MergeRemove)
+			}
+			if len(rule.Items) > 0 {
+				return fmt.Errorf("profile %q: merge rule for %q lists items, which only the %q strategy uses", p.Name, field, MergeRemove)
+			}
+		default:
+			return fmt.Errorf("profile %q: unknown merge strategy %q for %q (valid: override, error, append, remove)", p.Name, rule.Strategy, field)
+		}
+	}
+	return nil
+}
+
+// cloneMergeRules deep-copies merge rules.
+func cloneMergeRules(rules map[string]MergeRule) map[string]MergeRule {
+	if len(rules) == 0 {
+		return nil
+	}
+	clone := make(map[string]MergeRule, len(rules))
+	for field, rule := range rules {
+		if len(rule.Items) > 0 {
+			rule.Items = append([]string(nil), rule.Items...)
+		}
+		clone[field] = rule
+	}
+	return clone
+}
+
+// mergeRulesEqual compares merge rules, treating nil and empty as equal.
+func mergeRulesEqual(a, b map[string]MergeRule) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for field, rule := range a {
+		other, ok := b[field]
+		if !ok || rule.Strategy != other.Strategy || !strSlicesEqual(rule.Items, other.Items) {
+			return false
+		}
+	}
+	return true
+}
+
+// MergeConflict is an item two or more includes define differently.
+type MergeConflict struct {
+	Field    string   // mcpServers or extensions
+	Scope    string   // user, project, local, or "" for top-level fields
+	Item     string   // MCP server name or "category/item" extension
+	Profiles []string // includes defining the item, in include order
+	Kept     string   // include whose definition the merged profile uses
+}
+
+// String describes the conflict and how it was resolved.
+func (c MergeConflict) String() string {
+	return c.conflictText() + "; using " + c.Kept
+}
+
+// conflictText describes the conflict without its resolution.
+func (c MergeConflict) conflictText() string {
+	what := fmt.Sprintf("MCP server %q", c.Item)
+	if c.Field == MergeFieldExtensions {
+		what = fmt.Sprintf("extension %q", c.Item)
+	}
+	if c.Scope != "" {
+		what += " (" + c.Scope + " scope)"
+	}
+	return fmt.Sprintf("%s is defined differently by %s", what, strings.Join(c.Profiles, " and "))
+}
+
+// MergeReport describes how ResolveIncludes merged a stack: the conflicts
+// it resolved and the include each merged item came from.
+type MergeReport struct {
+	Conflicts []MergeConflict
+	origins   map[string]string
+}
+
+// Origin returns the include a merged item came from, or "". Field is a
+// MergeField* name, scope is "" for top-level fields, and extensions are
+// named "category/item".
+func (r *MergeReport) Origin(field, scope, item string) string {
+	if r == nil {
+		return ""
+	}
+	return r.origins[originKey(field, scope, item)]
+}
+
+// MarketplaceOrigin returns the include a merged marketplace came from, or "".
+func (r *MergeReport) MarketplaceOrigin(m Marketplace) string {
+	return r.Origin(originMarketplaces, "", marketplaceKey(m))
+}
+
+// originMarketplaces keys marketplace provenance; marketplaces take no merge rules.
+const originMarketplaces = "marketplaces"
+
+func originKey(field, scope, item string) string {
+	return field + "\x00" + scope + "\x00" + item
+}
+
+// profileScope pairs each settings location of a profile with its scope
+// name; the top-level fields have scope "".
+type profileScope struct {
+	scope    string
+	settings *ScopeSettings
+}
+
+func scopesOf(p *Profile) []profileScope {
+	scopes := []profileScope{{"", &ScopeSettings{
+		Plugins: p.Plugins, MCPServers: p.MCPServers, Extensions: p.Extensions, Memory: p.Memory,
+	}}}
+	if p.PerScope != nil {
+		for _, s := range []profileScope{{"user", p.PerScope.User}, {"project", p.PerScope.Project}, {"local", p.PerScope.Local}} {
+			if s.settings != nil {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	return scopes
+}
+
+// scopeOf returns the live settings of dst at a scope, or nil.
+func scopeOf(p *Profile, scope string) *ScopeSettings {
+	for _, s := range scopesOf(p) {
+		if s.scope == scope {
+			return s.settings
+		}
+	}
+	return nil
+}
+
+// keptValue is a conflicting definition the append strategy restores after
+// a later include overwrote it.
+type keptValue struct {
+	scope  string
+	server *MCPServer
+	ext    [2]string
+	patch  ext.FrontmatterPatch
+}
+
+// mergeLeaves merges leaf profiles left to right like mergeProfiles,
+// applying the stack's merge rules and reporting conflicts and provenance.
+// Names are the include names the leaves were loaded by.
+func mergeLeaves(leaves []*Profile, names []string, rules map[string]MergeRule) (*Profile, *MergeReport, error) {
+	result := &Profile{}
+	report := &MergeReport{origins: make(map[string]string)}
+	conflictIndex := make(map[string]int)
+
+	for i, src := range leaves {
+		name := names[i]
+		var kept []keptValue
+
+		for _, s := range scopesOf(src) {
+			existing := scopeOf(result, s.scope)
+			for _, server := range s.settings.MCPServers {
+				key := originKey(MergeFieldMCPServers, s.scope, server.Name)
+				var prev *MCPServer
+				if existing != nil {
+					for j := range existing.MCPServers {
+						if existing.MCPServers[j].Name == server.Name {
+							prev = &existing.MCPServers[j]
+						}
+					}
+				}
+				if prev == nil {
+					report.origins[key] = name
+					continue
+				}
+				if mcpServersEqual(*prev, server) {
+					continue
+				}
+				strategy := rules[MergeFieldMCPServers].Strategy
+				report.recordConflict(conflictIndex, MergeFieldMCPServers, s.scope, server.Name, report.origins[key], name)
+				switch strategy {
+				case MergeError:
+					return nil, nil, fmt.Errorf("merge conflict: %s", report.Conflicts[conflictIndex[key]].conflictText())
+				case MergeAppend:
+					saved := *prev
+					kept = append(kept, keptValue{scope: s.scope, server: &saved})
+				default:
+					report.origins[key] = name
+				}
+				report.Conflicts[conflictIndex[key]].Kept = report.origins[key]
+			}
+
+			for _, category := range ext.AllCategories() {
+				for _, item := range s.settings.Extensions.Items(category) {
+					extName := category + "/" + item
+					key := originKey(MergeFieldExtensions, s.scope, extName)
+					if _, ok := report.origins[key]; !ok {
+						report.origins[key] = name
+					}
+					patch := s.settings.Extensions.Override(category, item)
+					var prev ext.FrontmatterPatch
+					if existing != nil {
+						prev = existing.Extensions.Override(category, item)
+					}
+					if len(patch) == 0 || len(prev) == 0 || reflect.DeepEqual(prev, patch) {
+						continue
+					}
+					report.recordConflict(conflictIndex, MergeFieldExtensions, s.scope, extName, report.origins[key], name)
+					switch rules[MergeFieldExtensions].Strategy {
+					case MergeError:
+						return nil, nil, fmt.Errorf("merge conflict: %s", report.Conflicts[conflictIndex[key]].conflictText())
+					case MergeAppend:
+						kept = append(kept, keptValue{scope: s.scope, ext: [2]string{category, item}, patch: prev})
+					default:
+						report.origins[key] = name
+					}
+					report.Conflicts[conflictIndex[key]].Kept = report.origins[key]
+				}
+			}
+
+			for _, plugin := range s.settings.Plugins {
+				report.recordOrigin(MergeFieldPlugins, s.scope, plugin, name)
+			}
+			for _, fragment := range s.settings.Memory {
+				report.recordOrigin(MergeFieldMemory, s.scope, fragment, name)
+			}
+		}
+		for _, m := range src.Marketplaces {
+			report.recordOrigin(originMarketplaces, "", marketplaceKey(m), name)
+		}
+
+		mergeProfile(result, src)
+
+		// The append strategy keeps the earlier definitions
+		for _, k := range kept {
+			dst := scopeOf(result, k.scope)
+			if k.server != nil {
+				for j := range dst.MCPServers {
+					if dst.MCPServers[j].Name == k.server.Name {
+						dst.MCPServers[j] = *k.server
+					}
+				}
+				continue
+			}
+			dst.Extensions.SetOverride(k.ext[0], k.ext[1], k.patch)
+		}
+	}
+
+	if err := removeInherited(result, rules, report); err != nil {
+		return nil, nil, err
+	}
+	return result, report, nil
+}
+
+// recordOrigin attributes an item to the first include that provides it.
+func (r *MergeReport) recordOrigin(field, scope, item, name string) {
+	key := originKey(field, scope, item)
+	if _, ok := r.origins[key]; !ok {
+		r.origins[key] = name
+	}
+}
+
+// recordConflict adds or extends the conflict for an item.
+func (r *MergeReport) recordConflict(index map[string]int, field, scope, item, earlier, later string) {
+	key := originKey(field, scope, item)
+	i, ok := index[key]
+	if !ok {
+		index[key] = len(r.Conflicts)
+		r.Conflicts = append(r.Conflicts, MergeConflict{Field: field, Scope: scope, Item: item, Profiles: []string{earlier}})
+		i = index[key]
+	}
+	r.Conflicts[i].Profiles = append(r.Conflicts[i].Profiles, later)
+}
+
+// removeInherited applies the remove strategy to the merged profile.
+func removeInherited(p *Profile, rules map[string]MergeRule, report *MergeReport) error {
+	fields := make([]string, 0, len(rules))
+	for field, rule := range rules {
+		if rule.Strategy == MergeRemove {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		for _, item := range rules[field].Items {
+			found := false
+			for _, s := range scopesOf(p) {
+				var removed bool
+				switch field {
+				case MergeFieldPlugins:
+					s.settings.Plugins, removed = withoutString(s.settings.Plugins, item)
+				case MergeFieldMemory:
+					s.settings.Memory, removed = withoutString(s.settings.Memory, item)
+				case MergeFieldMCPServers:
+					s.settings.MCPServers, removed = withoutServer(s.settings.MCPServers, item)
+				case MergeFieldExtensions:
+					removed = removeExtension(s.settings.Extensions, item)
+				}
+				if removed {
+					found = true
+					delete(report.origins, originKey(field, s.scope, item))
+					setScope(p, s)
+				}
+			}
+			if !found {
+				return fmt.Errorf("merge rule removes %s %q, which no include provides", field, item)
+			}
+		}
+	}
+	return nil
+}
+
+// setScope writes back the slices of a top-level view returned by scopesOf.
+// Per-scope views share the profile's ScopeSettings and need no write-back.
+func setScope(p *Profile, s profileScope) {
+	if s.scope != "" {
+		return
+	}
+	p.Plugins = s.settings.Plugins
+	p.MCPServers = s.settings.MCPServers
+	p.Memory = s.settings.Memory
+}
+
+func withoutString(list []string, item string) ([]string, bool) {
+	var kept []string
+	removed := false
+	for _, s := range list {
+		if s == item {
+			removed = true
+			continue
+		}
+		kept = append(kept, s)
+	}
+	return kept, removed
+}
+
+func withoutServer(servers []MCPServer, name string) ([]MCPServer, bool) {
+	var kept []MCPServer
+	removed := false
+	for _, s := range servers {
+		if s.Name == name {
+			removed = true
+			continue
+		}
+		kept = append(kept, s)
+	}
+	return kept, removed
+}
+
+// removeExtension removes a "category/item" extension and its override.
+func removeExtension(e *ExtensionSettings, name string) bool {
+	category, item, ok := strings.Cut(name, "/")
+	if e == nil || !ok {
+		return false
+	}
+	items := e.itemsFor(category)
+	if items == nil {
+		return false
+	}
+	var removed bool
+	*items, removed = withoutString(*items, item)
+	if removed {
+		e.SetOverride(category, item, nil)
+	}
+	return removed
+}
@@ -0,0 +1,210 @@
+// ABOUTME: Tests for stack merge rules and the conflict report of include resolution
+// ABOUTME: Covers override, error, append, and remove strategies, provenance, and rule validation
+package profile
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/claudeup/claudeup/v5/internal/ext"
+)
+
+func mergeTestLoader() *mockLoader {
+	base := &Profile{
+		Name:       "base",
+		Plugins:    []string{"git@tools", "noisy@tools"},
+		MCPServers: []MCPServer{{Name: "notes", Command: "notes-v1"}, {Name: "db", Command: "db"}},
+		Extensions: &ExtensionSettings{Agents: []string{"reviewer"}},
+		Memory:     []string{"style"},
+	}
+	base.Extensions.SetOverride(ext.CategoryAgents, "reviewer", ext.FrontmatterPatch{"model": "sonnet"})
+	python := &Profile{
+		Name:       "python",
+		Plugins:    []string{"pytest@py"},
+		MCPServers: []MCPServer{{Name: "notes", Command: "notes-v2"}, {Name: "db", Command: "db"}},
+		Extensions: &ExtensionSettings{Agents: []string{"reviewer"}},
+	}
+	python.Extensions.SetOverride(ext.CategoryAgents, "reviewer", ext.FrontmatterPatch{"model": "opus"})
+	return &mockLoader{profiles: map[string]*Profile{
+		"base":   base,
+		"python": python,
+		"inner":  {Name: "inner", Includes: []string{"base", "python"}, Merge: map[string]MergeRule{MergeFieldMCPServers: {Strategy: MergeAppend}}},
+	}}
+}
+
+func TestResolveIncludesReportsConflicts(t *testing.T) {
+	stack := &Profile{Name: "team", Includes: []string{"base", "python"}}
+	resolved, report, err := ResolveIncludesWithReport(stack, mergeTestLoader())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Conflicts) != 2 {
+		t.Fatalf("expected conflicts for notes and the reviewer agent, got %+v", report.Conflicts)
+	}
+	notes := report.Conflicts[0]
+	if notes.Item != "notes" || notes.Kept != "python" || !reflect.DeepEqual(notes.Profiles, []string{"base", "python"}) {
+		t.Errorf("unexpected MCP conflict %+v", notes)
+	}
+	if want := `MCP server "notes" is defined differently by base and python; using python`; notes.String() != want {
+		t.Errorf("String() = %q, want %q", notes.String(), want)
+	}
+	if c := report.Conflicts[1]; c.Field != MergeFieldExtensions || c.Item != "agents/reviewer" || c.Kept != "python" {
+		t.Errorf("unexpected extension conflict %+v", c)
+	}
+
+	// Override is the default: the later include wins
+	if resolved.MCPServers[0].Command != "notes-v2" {
+		t.Errorf("expected the later definition, got %+v", resolved.MCPServers[0])
+	}
+	cases := []struct{ field, item, want string }{
+		{MergeFieldPlugins, "git@tools", "base"},
+		{MergeFieldPlugins, "pytest@py", "python"},
+		{MergeFieldMCPServers, "notes", "python"},
+		{MergeFieldMCPServers, "db", "base"},
+		{MergeFieldExtensions, "agents/reviewer", "python"},
+		{MergeFieldMemory, "style", "base"},
+	}
+	for _, c := range cases {
+		if got := report.Origin(c.field, "", c.item); got != c.want {
+			t.Errorf("Origin(%s, %s) = %q, want %q", c.field, c.item, got, c.want)
+		}
+	}
+}
+
+func TestMergeStrategies(t *testing.T) {
+	loader := mergeTestLoader()
+
+	t.Run("error", func(t *testing.T) {
+		stack := &Profile{Name: "team", Includes: []string{"base", "python"}, Merge: map[string]MergeRule{MergeFieldMCPServers: {Strategy: MergeError}}}
+		_, err := ResolveIncludes(stack, loader)
+		if err == nil || !strings.Contains(err.Error(), `MCP server "notes" is defined differently by base and python`) {
+			t.Errorf("expected a conflict error, got %v", err)
+		}
+	})
+
+	t.Run("append", func(t *testing.T) {
+		stack := &Profile{Name: "team", Includes: []string{"base", "python"}, Merge: map[string]MergeRule{
+			MergeFieldMCPServers: {Strategy: MergeAppend},
+			MergeFieldExtensions: {Strategy: MergeAppend},
+		}}
+		resolved, report, err := ResolveIncludesWithReport(stack, loader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resolved.MCPServers[0].Command != "notes-v1" {
+			t.Errorf("append should keep the first definition, got %+v", resolved.MCPServers[0])
+		}
+		if got := resolved.Extensions.Override(ext.CategoryAgents, "reviewer")["model"]; got != "sonnet" {
+			t.Errorf("append should keep the first override, got %v", got)
+		}
+		if report.Conflicts[0].Kept != "base" || report.Origin(MergeFieldMCPServers, "", "notes") != "base" {
+			t.Errorf("report should credit the kept definition: %+v", report.Conflicts[0])
+		}
+		if loader.profiles["python"].Extensions.Override(ext.CategoryAgents, "reviewer")["model"] != "opus" {
+			t.Error("included profiles must not be modified")
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		stack := &Profile{Name: "team", Includes: []string{"base", "python"}, Merge: map[string]MergeRule{
+			MergeFieldPlugins:    {Strategy: MergeRemove, Items: []string{"noisy@tools"}},
+			MergeFieldMCPServers: {Strategy: MergeRemove, Items: []string{"db"}},
+			MergeFieldExtensions: {Strategy: MergeRemove, Items: []string{"agents/reviewer"}},
+		}}
+		resolved, report, err := ResolveIncludesWithReport(stack, loader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"git@tools", "pytest@py"}; !reflect.DeepEqual(resolved.Plugins, want) {
+			t.Errorf("plugins = %v, want %v", resolved.Plugins, want)
+		}
+		if len(resolved.MCPServers) != 1 || resolved.MCPServers[0].Name != "notes" {
+			t.Errorf("expected db removed, got %+v", resolved.MCPServers)
+		}
+		if len(resolved.Extensions.Agents) != 0 || resolved.Extensions.Override(ext.CategoryAgents, "reviewer") != nil {
+			t.Errorf("expected the reviewer agent removed, got %+v", resolved.Extensions)
+		}
+		if report.Origin(MergeFieldPlugins, "", "noisy@tools") != "" {
+			t.Error("removed items should have no origin")
+		}
+	})
+
+	t.Run("remove of an item no include provides", func(t *testing.T) {
+		stack := &Profile{Name: "team", Includes: []string{"base"}, Merge: map[string]MergeRule{
+			MergeFieldPlugins: {Strategy: MergeRemove, Items: []string{"typo@tools"}},
+		}}
+		if _, err := ResolveIncludes(stack, loader); err == nil || !strings.Contains(err.Error(), `"typo@tools", which no include provides`) {
+			t.Errorf("expected an error for the unknown item, got %v", err)
+		}
+	})
+
+	t.Run("nested stack rules apply unless the including profile overrides them", func(t *testing.T) {
+		resolved, err := ResolveIncludes(&Profile{Name: "outer", Includes: []string{"inner"}}, loader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resolved.MCPServers[0].Command != "notes-v1" {
+			t.Errorf("the inner stack's append rule should apply, got %+v", resolved.MCPServers[0])
+		}
+
+		outer := &Profile{Name: "outer", Includes: []string{"inner"}, Merge: map[string]MergeRule{MergeFieldMCPServers: {Strategy: MergeOverride}}}
+		if resolved, err = ResolveIncludes(outer, loader); err != nil {
+			t.Fatal(err)
+		}
+		if resolved.MCPServers[0].Command != "notes-v2" {
+			t.Errorf("the outer rule should win, got %+v", resolved.MCPServers[0])
+		}
+	})
+}
+
+func TestMergeRuleValidation(t *testing.T) {
+	loader := mergeTestLoader()
+	cases := []struct {
+		name    string
+		profile *Profile
+		wantErr string
+	}{
+		{"rules without includes", &Profile{Name: "leaf", Merge: map[string]MergeRule{MergeFieldMCPServers: {Strategy: MergeError}}}, "only apply to stacks"},
+		{"unknown field", &Profile{Name: "s", Includes: []string{"base"}, Merge: map[string]MergeRule{"detect": {Strategy: MergeError}}}, `unknown merge field "detect"`},
+		{"unknown strategy", &Profile{Name: "s", Includes: []string{"base"}, Merge: map[string]MergeRule{MergeFieldMCPServers: {Strategy: "first"}}}, `unknown merge strategy "first"`},
+		{"conflict strategy on a union field", &Profile{Name: "s", Includes: []string{"base"}, Merge: map[string]MergeRule{MergeFieldPlugins: {Strategy: MergeError}}}, "only the \"remove\" strategy"},
+		{"remove without items", &Profile{Name: "s", Includes: []string{"base"}, Merge: map[string]MergeRule{MergeFieldPlugins: {Strategy: MergeRemove}}}, "removes nothing"},
+	}
+	for _, c := range cases {
+		if _, err := ResolveIncludes(c.profile, loader); err == nil || !strings.Contains(err.Error(), c.wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", c.name, c.wantErr, err)
+		}
+	}
+}
+
+func TestMergeRuleJSON(t *testing.T) {
+	var p Profile
+	data := `{"name": "team", "includes": ["base"], "merge": {"mcpServers": "error", "plugins": {"strategy": "remove", "items": ["noisy@tools"]}}}`
+	if err := json.Unmarshal([]byte(data), &p); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]MergeRule{
+		MergeFieldMCPServers: {Strategy: MergeError},
+		MergeFieldPlugins:    {Strategy: MergeRemove, Items: []string{"noisy@tools"}},
+	}
+	if !reflect.DeepEqual(p.Merge, want) {
+		t.Errorf("Merge = %+v, want %+v", p.Merge, want)
+	}
+	if p.HasConfigFields() {
+		t.Error("merge rules should not make a stack impure")
+	}
+
+	out, err := json.Marshal(p.Merge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(out); got != `{"mcpServers":"error","plugins":{"strategy":"remove","items":["noisy@tools"]}}` {
+		t.Errorf("marshaled rules = %s", got)
+	}
+	if clone := p.Clone("copy"); !mergeRulesEqual(clone.Merge, p.Merge) {
+		t.Error("Clone should copy merge rules")
+	}
+}
@@ -0,0 +1,821 @@
+// ABOUTME: CommandExecutor that edits Claude Code's config files directly instead of running the claude CLI
+// ABOUTME: Handles plugin install/uninstall, marketplace add/remove, and mcp add/remove
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+	"github.com/claudeup/claudeup/v5/internal/events"
+	"github.com/claudeup/claudeup/v5/internal/marketplace"
+)
+
+// Engines select how plugin, marketplace, and MCP commands are carried out.
+const (
+	EngineCLI    = "cli"    // run the claude CLI (DefaultExecutor)
+	EngineNative = "native" // edit config files directly (NativeExecutor)
+)
+
+// NewExecutor returns the CommandExecutor for engine. An empty engine
+// means the claude CLI.
+func NewExecutor(engine, claudeDir string) (CommandExecutor, error) {
+	switch engine {
+	case "", EngineCLI:
+		return &DefaultExecutor{ClaudeDir: claudeDir}, nil
+	case EngineNative:
+		return &NativeExecutor{ClaudeDir: claudeDir}, nil
+	}
+	return nil, fmt.Errorf("unknown engine %q (expected %s or %s)", engine, EngineCLI, EngineNative)
+}
+
+// executorClaudeDir returns the Claude directory an executor operates on,
+// or "" when it is not known (e.g. test doubles).
+func executorClaudeDir(executor CommandExecutor) string {
+	switch e := executor.(type) {
+	case *DefaultExecutor:
+		return e.ClaudeDir
+	case *NativeExecutor:
+		return e.ClaudeDir
+	}
+	return ""
+}
+
+// NativeExecutor performs the claude CLI commands claudeup relies on by
+// editing installed_plugins.json, known_marketplaces.json, the plugin cache,
+// settings files, .claude.json, and .mcp.json itself. Outputs mirror the
+// CLI's wording for the cases callers detect ("already installed",
+// "already exists"). Installing a plugin that is already there returns
+// ErrAlreadyInstalled and removing a missing item returns ErrNotInstalled,
+// like DefaultExecutor, so it is a drop-in replacement.
+type NativeExecutor struct {
+	ClaudeDir      string // Claude configuration directory
+	ClaudeJSONPath string // Defaults to <ClaudeDir>/.claude.json
+	ProjectDir     string // Project for project/local scope; defaults to the working directory
+}
+
+// Run performs the command and prints its output, like the CLI would.
+func (e *NativeExecutor) Run(args ...string) error {
+	output, err := e.RunWithOutput(args...)
+	if output != "" {
+		fmt.Println(output)
+	}
+	return err
+}
+
+// RunWithOutput performs the command and returns the message the CLI
+// would have printed. Failures return a non-nil error with the message
+// as output.
+func (e *NativeExecutor) RunWithOutput(args ...string) (string, error) {
+	output, err := e.dispatch(args)
+	if err != nil {
+		return err.Error(), err
+	}
+	return output, nil
+}
+
+func (e *NativeExecutor) dispatch(args []string) (string, error) {
+	command := strings.Join(args, " ")
+	switch {
+	case hasArgs(args, "plugin", "marketplace", "add"):
+		return e.marketplaceAdd(args[3:])
+	case hasArgs(args, "plugin", "marketplace", "remove"):
+		return e.marketplaceRemove(args[3:])
+	case hasArgs(args, "plugin", "install"):
+		return e.pluginInstall(args[2:])
+	case hasArgs(args, "plugin", "uninstall"):
+		return e.pluginUninstall(args[2:])
+	case hasArgs(args, "mcp", "add"):
+		return e.mcpAdd(args[2:])
+	case hasArgs(args, "mcp", "remove"):
+		return e.mcpRemove(args[2:])
+	}
+	return "", fmt.Errorf("native engine does not support 'claude %s'", command)
+}
+
+func hasArgs(args []string, prefix ...string) bool {
+	if len(args) < len(prefix) {
+		return false
+	}
+	for i, p := range prefix {
+		if args[i] != p {
+			return false
+		}
+	}
+	return true
+}
+
+// nativeArgs holds the flags and positional arguments of a command.
+// Arguments after "--" are positional even if they look like flags.
+type nativeArgs struct {
+	scope      string
+	env        []string
+	positional []string
+}
+
+func parseNativeArgs(args []string) (nativeArgs, error) {
+	var parsed nativeArgs
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			parsed.positional = append(parsed.positional, args[i+1:]...)
+			return parsed, nil
+		case arg == "-s" || arg == "--scope" || arg == "-e" || arg == "--env":
+			if i+1 >= len(args) {
+				return parsed, fmt.Errorf("flag %s requires a value", arg)
+			}
+			i++
+			if arg == "-s" || arg == "--scope" {
+				parsed.scope = args[i]
+			} else {
+				parsed.env = append(parsed.env, args[i])
+			}
+		case strings.HasPrefix(arg, "--scope="):
+			parsed.scope = strings.TrimPrefix(arg, "--scope=")
+		case strings.HasPrefix(arg, "-") && len(parsed.positional) == 0:
+			return parsed, fmt.Errorf("unsupported flag %s", arg)
+		default:
+			parsed.positional = append(parsed.positional, arg)
+		}
+	}
+	return parsed, nil
+}
+
+func (e *NativeExecutor) claudeJSONPath() string {
+	if e.ClaudeJSONPath != "" {
+		return e.ClaudeJSONPath
+	}
+	return filepath.Join(e.ClaudeDir, ".claude.json")
+}
+
+// projectDir returns the project for project/local scope operations.
+func (e *NativeExecutor) projectDir() (string, error) {
+	if e.ProjectDir != "" {
+		return filepath.Abs(e.ProjectDir)
+	}
+	return os.Getwd()
+}
+
+// scopeProject validates scope (empty means user) and returns the project
+// directory it applies to ("" for user scope).
+func (e *NativeExecutor) scopeProject(scope string) (string, string, error) {
+	if scope == "" {
+		scope = claude.ScopeUser
+	}
+	if err := claude.ValidateScope(scope); err != nil {
+		return "", "", err
+	}
+	if scope == claude.ScopeUser {
+		return scope, "", nil
+	}
+	dir, err := e.projectDir()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to determine project directory: %w", err)
+	}
+	return scope, dir, nil
+}
+
+func (e *NativeExecutor) pluginsDir() string {
+	return filepath.Join(e.ClaudeDir, "plugins")
+}
+
+// marketplaceAdd registers a marketplace. Directory sources are used in
+// place; GitHub and git sources are cloned into plugins/marketplaces/<name>.
+func (e *NativeExecutor) marketplaceAdd(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("usage: plugin marketplace add <source>")
+	}
+	m, err := ParseMarketplaceArg(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	registry, err := claude.LoadMarketplaces(e.ClaudeDir)
+	if err != nil {
+		return "", err
+	}
+	for name, meta := range registry {
+		if marketplaceIdentity(marketplaceFromSource(meta.Source)) == marketplaceIdentity(m) {
+			return fmt.Sprintf("Marketplace '%s' is already installed", name), nil
+		}
+	}
+
+	source := claude.MarketplaceSource{Source: m.Source, Repo: m.Repo, URL: m.URL, Ref: m.Ref}
+	var location string
+	if m.IsDirectory() {
+		source.Source = MarketplaceSourceDirectory
+		source.Path = marketplaceKey(m)
+		location = source.Path
+	} else {
+		location, err = e.cloneMarketplace(m)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	index, err := claude.LoadMarketplaceIndex(location)
+	if err != nil {
+		e.discardClone(location)
+		return "", fmt.Errorf("invalid marketplace %s: %w", m.Location(), err)
+	}
+	if !isLocalName(index.Name) {
+		e.discardClone(location)
+		return "", fmt.Errorf("invalid marketplace %s: name %q is not a single path element", m.Location(), index.Name)
+	}
+	if _, exists := registry[index.Name]; exists {
+		e.discardClone(location)
+		return "", fmt.Errorf("marketplace '%s' is already installed from a different source", index.Name)
+	}
+	if !m.IsDirectory() {
+		final := filepath.Join(e.pluginsDir(), "marketplaces", index.Name)
+		if !isWithin(filepath.Join(e.pluginsDir(), "marketplaces"), final) {
+			e.discardClone(location)
+			return "", fmt.Errorf("invalid marketplace name %q", index.Name)
+		}
+		if err := os.RemoveAll(final); err != nil {
+			e.discardClone(location)
+			return "", fmt.Errorf("failed to clear %s: %w", final, err)
+		}
+		if err := os.Rename(location, final); err != nil {
+			e.discardClone(location)
+			return "", fmt.Errorf("failed to move marketplace into place: %w", err)
+		}
+		location = final
+	}
+
+	registry[index.Name] = claude.MarketplaceMetadata{
+		Source:          source,
+		InstallLocation: location,
+		LastUpdated:     time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := claude.SaveMarketplaces(e.ClaudeDir, registry); err != nil {
+		return "", fmt.Errorf("failed to save marketplace registry: %w", err)
+	}
+	return fmt.Sprintf("Successfully added marketplace: %s", index.Name), nil
+}
+
+// cloneMarketplace clones a git marketplace into a temporary directory
+// under plugins/marketplaces and checks out its ref, if any. A branch ref is
+// checked out as a tracking branch so upgrades can pull it; tags and commits
+// are checked out detached.
+func (e *NativeExecutor) cloneMarketplace(m Marketplace) (string, error) {
+	url := m.URL
+	if m.Repo != "" {
+		url = "https://github.com/" + m.Repo + ".git"
+	}
+	parent := filepath.Join(e.pluginsDir(), "marketplaces")
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", parent, err)
+	}
+	tmp, err := os.MkdirTemp(parent, ".clone-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create clone directory: %w", err)
+	}
+	dir := filepath.Join(tmp, "repo")
+	if err := marketplace.Clone(url, dir); err != nil {
+		os.RemoveAll(tmp)
+		return "", fmt.Errorf("failed to clone marketplace %s: %w", m.Location(), err)
+	}
+	if m.Ref != "" {
+		var err error
+		if marketplace.IsRemoteBranch(dir, m.Ref) {
+			err = marketplace.CheckoutTrackingBranch(dir, m.Ref)
+		} else {
+			var commit string
+			commit, err = marketplace.ResolveRef(dir, m.Ref)
+			if err == nil {
+				err = marketplace.CheckoutDetached(dir, commit)
+			}
+		}
+		if err != nil {
+			os.RemoveAll(tmp)
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// discardClone removes a temporary clone made by cloneMarketplace.
+func (e *NativeExecutor) discardClone(location string) {
+	parent := filepath.Dir(location)
+	if strings.HasPrefix(filepath.Base(parent), ".clone-") {
+		os.RemoveAll(parent)
+	}
+}
+
+// marketplaceRemove unregisters a marketplace and deletes its clone.
+// Directory marketplaces are left on disk.
+func (e *NativeExecutor) marketplaceRemove(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("usage: plugin marketplace remove <name>")
+	}
+	name := args[0]
+	registry, err := claude.LoadMarketplaces(e.ClaudeDir)
+	if err != nil {
+		return "", err
+	}
+	meta, exists := registry[name]
+	if !exists {
+		return "", notInstalledf("marketplace '%s' not found", name)
+	}
+	delete(registry, name)
+	if err := claude.SaveMarketplaces(e.ClaudeDir, registry); err != nil {
+		return "", fmt.Errorf("failed to save marketplace registry: %w", err)
+	}
+	if !meta.Source.IsDirectory() && isWithin(filepath.Join(e.pluginsDir(), "marketplaces"), meta.InstallLocation) {
+		if err := os.RemoveAll(meta.InstallLocation); err != nil {
+			return "", fmt.Errorf("failed to delete %s: %w", meta.InstallLocation, err)
+		}
+	}
+	return fmt.Sprintf("Successfully removed marketplace: %s", name), nil
+}
+
+// pluginInstall copies a plugin from its marketplace into the plugin cache,
+// records it in installed_plugins.json, and enables it in the scope's settings.
+func (e *NativeExecutor) pluginInstall(args []string) (string, error) {
+	parsed, err := parseNativeArgs(args)
+	if err != nil {
+		return "", err
+	}
+	if len(parsed.positional) != 1 {
+		return "", fmt.Errorf("usage: plugin install [--scope <scope>] <plugin>@<marketplace>")
+	}
+	plugin := parsed.positional[0]
+	if err := ValidatePluginFormat(plugin); err != nil {
+		return "", err
+	}
+	scope, projectDir, err := e.scopeProject(parsed.scope)
+	if err != nil {
+		return "", err
+	}
+
+	registry, err := claude.LoadPlugins(e.ClaudeDir)
+	if err != nil {
+		return "", err
+	}
+	for _, sp := range registry.GetPluginsForContext([]string{scope}, projectDir) {
+		if sp.Name == plugin && sp.PathExists() {
+			return "", alreadyInstalledf("plugin '%s' is already installed at %s scope", plugin, scope)
+		}
+	}
+
+	atIdx := strings.LastIndex(plugin, "@")
+	pluginName, marketplaceName := plugin[:atIdx], plugin[atIdx+1:]
+	installPath, version, commit, err := e.cachePlugin(pluginName, marketplaceName)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	registry.RemovePluginInstance(plugin, scope, projectDir)
+	registry.Plugins[plugin] = append(registry.Plugins[plugin], claude.PluginMetadata{
+		Scope:        scope,
+		Version:      version,
+		InstalledAt:  now,
+		LastUpdated:  now,
+		InstallPath:  installPath,
+		GitCommitSha: commit,
+		ProjectPath:  projectDir,
+	})
+	if err := os.MkdirAll(e.pluginsDir(), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", e.pluginsDir(), err)
+	}
+	if err := claude.SavePlugins(e.ClaudeDir, registry); err != nil {
+		return "", fmt.Errorf("failed to save plugin registry: %w", err)
+	}
+
+	settings, err := claude.LoadSettingsForScope(scope, e.ClaudeDir, projectDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to load %s settings: %w", scope, err)
+	}
+	if !settings.IsPluginEnabled(plugin) {
+		settings.EnablePlugin(plugin)
+		if err := claude.SaveSettingsForScope(scope, e.ClaudeDir, projectDir, settings); err != nil {
+			return "", fmt.Errorf("failed to enable plugin in %s settings: %w", scope, err)
+		}
+	}
+
+	return fmt.Sprintf("Successfully installed plugin: %s (scope: %s)", plugin, scope), nil
+}
+
+// cachePlugin copies a marketplace plugin into
+// plugins/cache/<marketplace>/<plugin>/<version> and returns that path,
+// the version, and the git commit it came from (if known).
+func (e *NativeExecutor) cachePlugin(pluginName, marketplaceName string) (string, string, string, error) {
+	marketplaces, err := claude.LoadMarketplaces(e.ClaudeDir)
+	if err != nil {
+		return "", "", "", err
+	}
+	meta, exists := marketplaces[marketplaceName]
+	if !exists {
+		return "", "", "", fmt.Errorf("marketplace '%s' not found", marketplaceName)
+	}
+	index, err := claude.LoadMarketplaceIndex(meta.InstallLocation)
+	if err != nil {
+		return "", "", "", fmt.Errorf("marketplace '%s': %w", marketplaceName, err)
+	}
+	var entry *claude.MarketplacePluginInfo
+	for i := range index.Plugins {
+		if index.Plugins[i].Name == pluginName {
+			entry = &index.Plugins[i]
+			break
+		}
+	}
+	if entry == nil {
+		return "", "", "", fmt.Errorf("plugin '%s' not found in marketplace '%s'", pluginName, marketplaceName)
+	}
+
+	src := filepath.Join(meta.InstallLocation, "plugins", pluginName)
+	if !isWithin(meta.InstallLocation, src) {
+		return "", "", "", fmt.Errorf("invalid plugin name '%s'", pluginName)
+	}
+	commit := ""
+	if marketplace.IsGitRepo(meta.InstallLocation) {
+		commit, _ = marketplace.Head(meta.InstallLocation)
+	}
+	if entry.Source != nil && entry.Source.IsURL() {
+		tmp, err := os.MkdirTemp("", "claudeup-plugin-")
+		if err != nil {
+			return "", "", "", fmt.Errorf("failed to create clone directory: %w", err)
+		}
+		defer os.RemoveAll(tmp)
+		src = filepath.Join(tmp, "repo")
+		if err := marketplace.Clone(entry.Source.URL, src); err != nil {
+			return "", "", "", fmt.Errorf("failed to fetch plugin '%s': %w", pluginName, err)
+		}
+		commit, _ = marketplace.Head(src)
+	} else if entry.Source != nil && entry.Source.IsRelativePath() {
+		src = filepath.Join(meta.InstallLocation, entry.Source.Source)
+		if !isWithin(meta.InstallLocation, src) {
+			return "", "", "", fmt.Errorf("plugin '%s' source %q is outside its marketplace", pluginName, entry.Source.Source)
+		}
+	}
+	if info, err := os.Stat(src); err != nil || !info.IsDir() {
+		return "", "", "", fmt.Errorf("plugin '%s' source not found at %s", pluginName, src)
+	}
+
+	version := entry.Version
+	if version == "" {
+		version = pluginManifestVersion(src)
+	}
+	if version == "" && len(commit) >= 12 {
+		version = commit[:12]
+	}
+	if version == "" {
+		version = "unknown"
+	}
+
+	dest := filepath.Join(e.pluginsDir(), "cache", marketplaceName, pluginName, version)
+	if !isWithin(filepath.Join(e.pluginsDir(), "cache"), dest) {
+		return "", "", "", fmt.Errorf("invalid plugin name or version for '%s'", pluginName)
+	}
+	if err := os.RemoveAll(dest); err != nil {
+		return "", "", "", fmt.Errorf("failed to clear %s: %w", dest, err)
+	}
+	if err := copyPluginTree(src, dest); err != nil {
+		return "", "", "", fmt.Errorf("failed to copy plugin '%s' into cache: %w", pluginName, err)
+	}
+	return dest, version, commit, nil
+}
+
+// pluginManifestVersion reads the version from a plugin's
+// .claude-plugin/plugin.json, or returns "".
+func pluginManifestVersion(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, ".claude-plugin", "plugin.json"))
+	if err != nil {
+		return ""
+	}
+	var manifest struct {
+		Version string `json:"version"`
+	}
+	if json.Unmarshal(data, &manifest) != nil {
+		return ""
+	}
+	return manifest.Version
+}
+
+// copyPluginTree copies a plugin directory, skipping git metadata.
+// Symlinks are recreated rather than followed.
+func copyPluginTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir() && d.Name() == ".git":
+			return filepath.SkipDir
+		case d.IsDir():
+			return os.MkdirAll(target, 0755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
+
+// pluginUninstall removes a plugin's instance at a scope, disables it in
+// that scope's settings, and deletes its cache directory once no other
+// instance uses it.
+func (e *NativeExecutor) pluginUninstall(args []string) (string, error) {
+	parsed, err := parseNativeArgs(args)
+	if err != nil {
+		return "", err
+	}
+	if len(parsed.positional) != 1 {
+		return "", fmt.Errorf("usage: plugin uninstall [--scope <scope>] <plugin>")
+	}
+	plugin := parsed.positional[0]
+	scope, projectDir, err := e.scopeProject(parsed.scope)
+	if err != nil {
+		return "", err
+	}
+
+	registry, err := claude.LoadPlugins(e.ClaudeDir)
+	if err != nil {
+		return "", err
+	}
+	var installPath string
+	for _, sp := range registry.GetPluginsForContext([]string{scope}, projectDir) {
+		if sp.Name == plugin {
+			installPath = sp.InstallPath
+		}
+	}
+	if !registry.RemovePluginInstance(plugin, scope, projectDir) {
+		return "", notInstalledf("plugin '%s' not found in installed plugins at %s scope", plugin, scope)
+	}
+	if err := claude.SavePlugins(e.ClaudeDir, registry); err != nil {
+		return "", fmt.Errorf("failed to save plugin registry: %w", err)
+	}
+
+	settings, err := claude.LoadSettingsForScope(scope, e.ClaudeDir, projectDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to load %s settings: %w", scope, err)
+	}
+	if _, ok := settings.EnabledPlugins[plugin]; ok {
+		settings.RemovePlugin(plugin)
+		if err := claude.SaveSettingsForScope(scope, e.ClaudeDir, projectDir, settings); err != nil {
+			return "", fmt.Errorf("failed to update %s settings: %w", scope, err)
+		}
+	}
+
+	if installPath != "" && isWithin(filepath.Join(e.pluginsDir(), "cache"), installPath) && !installPathInUse(registry, installPath) {
+		if err := os.RemoveAll(installPath); err != nil {
+			return "", fmt.Errorf("failed to delete %s: %w", installPath, err)
+		}
+	}
+
+	return fmt.Sprintf("Successfully uninstalled plugin: %s (scope: %s)", plugin, scope), nil
+}
+
+func installPathInUse(registry *claude.PluginRegistry, path string) bool {
+	for _, instances := range registry.Plugins {
+		for _, inst := range instances {
+			if filepath.Clean(inst.InstallPath) == filepath.Clean(path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isWithin reports whether path is inside dir (and not dir itself).
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// isLocalName reports whether name is a single, local path element that can
+// be joined onto a directory without escaping it.
+func isLocalName(name string) bool {
+	return filepath.IsLocal(name) && !strings.ContainsAny(name, `/\`) && name != "." && name != ".."
+}
+
+// mcpConfig is a JSON file holding MCP servers: .claude.json (user servers
+// at the top level, local servers under projects[<dir>]) or a project's
+// .mcp.json. Other keys are preserved when the file is written back.
+type mcpConfig struct {
+	path  string
+	scope string
+	raw   map[string]interface{}
+	// servers is the mcpServers map inside raw that the scope edits.
+	servers map[string]interface{}
+}
+
+func (e *NativeExecutor) loadMCPConfig(scope, projectDir string) (*mcpConfig, error) {
+	cfg := &mcpConfig{path: e.claudeJSONPath(), scope: scope}
+	if scope == claude.ScopeProject {
+		cfg.path = filepath.Join(projectDir, MCPConfigFile)
+	}
+
+	data, err := os.ReadFile(cfg.path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		cfg.raw = make(map[string]interface{})
+	case err != nil:
+		return nil, fmt.Errorf("failed to read %s: %w", cfg.path, err)
+	default:
+		if err := json.Unmarshal(data, &cfg.raw); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", cfg.path, err)
+		}
+		if cfg.raw == nil {
+			cfg.raw = make(map[string]interface{})
+		}
+	}
+
+	parent := cfg.raw
+	if scope == claude.ScopeLocal {
+		projects, _ := cfg.raw["projects"].(map[string]interface{})
+		if projects == nil {
+			projects = make(map[string]interface{})
+			cfg.raw["projects"] = projects
+		}
+		project, _ := projects[projectDir].(map[string]interface{})
+		if project == nil {
+			project = make(map[string]interface{})
+			projects[projectDir] = project
+		}
+		parent = project
+	}
+	cfg.servers, _ = parent["mcpServers"].(map[string]interface{})
+	if cfg.servers == nil {
+		cfg.servers = make(map[string]interface{})
+		parent["mcpServers"] = cfg.servers
+	}
+	return cfg, nil
+}
+
+// save writes the config back through a temp file and a rename, so an
+// interrupted write never leaves .claude.json truncated. The file keeps its
+// permissions, and a symlinked file is written at its target.
+func (c *mcpConfig) save() error {
+	data, err := json.MarshalIndent(c.raw, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	return events.GlobalTracker().RecordFileWrite(
+		"mcp config write",
+		c.path,
+		c.scope,
+		func() error {
+			path := c.path
+			if resolved, err := filepath.EvalSymlinks(path); err == nil {
+				path = resolved
+			}
+			mode := os.FileMode(0644)
+			if info, err := os.Stat(path); err == nil {
+				mode = info.Mode().Perm()
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".write-*")
+			if err != nil {
+				return err
+			}
+			defer os.Remove(tmp.Name())
+			if _, err := tmp.Write(data); err != nil {
+				tmp.Close()
+				return err
+			}
+			if err := tmp.Close(); err != nil {
+				return err
+			}
+			if err := os.Chmod(tmp.Name(), mode); err != nil {
+				return err
+			}
+			return os.Rename(tmp.Name(), path)
+		},
+	)
+}
+
+// mcpAdd adds a stdio MCP server: mcp add <name> [-s scope] [-e KEY=VALUE]... -- <command> [args...]
+func (e *NativeExecutor) mcpAdd(args []string) (string, error) {
+	parsed, err := parseNativeArgs(args)
+	if err != nil {
+		return "", err
+	}
+	if len(parsed.positional) < 2 {
+		return "", fmt.Errorf("usage: mcp add <name> [-s <scope>] -- <command> [args...]")
+	}
+	name, command, commandArgs := parsed.positional[0], parsed.positional[1], parsed.positional[2:]
+	scope, projectDir, err := e.scopeProject(parsed.scope)
+	if err != nil {
+		return "", err
+	}
+
+	env := make(map[string]interface{})
+	for _, kv := range parsed.env {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return "", fmt.Errorf("invalid environment variable %q (expected KEY=VALUE)", kv)
+		}
+		env[key] = value
+	}
+
+	cfg, err := e.loadMCPConfig(scope, projectDir)
+	if err != nil {
+		return "", err
+	}
+	if _, exists := cfg.servers[name]; exists {
+		return "", fmt.Errorf("MCP server %s already exists in %s config", name, scope)
+	}
+	if commandArgs == nil {
+		commandArgs = []string{}
+	}
+	cfg.servers[name] = map[string]interface{}{
+		"type":    "stdio",
+		"command": command,
+		"args":    commandArgs,
+		"env":     env,
+	}
+	if err := cfg.save(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", cfg.path, err)
+	}
+	return fmt.Sprintf("Added stdio MCP server %s with command: %s to %s config", name, strings.Join(append([]string{command}, commandArgs...), " "), scope), nil
+}
+
+// mcpRemove removes an MCP server. Without a scope it searches local,
+// project, then user config, like the CLI, and fails if the name is
+// configured in more than one of them.
+func (e *NativeExecutor) mcpRemove(args []string) (string, error) {
+	parsed, err := parseNativeArgs(args)
+	if err != nil {
+		return "", err
+	}
+	if len(parsed.positional) != 1 {
+		return "", fmt.Errorf("usage: mcp remove <name> [-s <scope>]")
+	}
+	name := parsed.positional[0]
+
+	scopes := []string{claude.ScopeLocal, claude.ScopeProject, claude.ScopeUser}
+	if parsed.scope != "" {
+		scopes = []string{parsed.scope}
+	}
+	var matches []*mcpConfig
+	for _, s := range scopes {
+		scope, projectDir, err := e.scopeProject(s)
+		if err != nil {
+			return "", err
+		}
+		cfg, err := e.loadMCPConfig(scope, projectDir)
+		if err != nil {
+			return "", err
+		}
+		if _, exists := cfg.servers[name]; exists {
+			matches = append(matches, cfg)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", notInstalledf("no MCP server found with name: %s", name)
+	case 1:
+	default:
+		var found []string
+		for _, cfg := range matches {
+			found = append(found, cfg.scope)
+		}
+		return "", fmt.Errorf("MCP server %s exists in multiple scopes (%s); specify one with -s", name, strings.Join(found, ", "))
+	}
+
+	cfg := matches[0]
+	delete(cfg.servers, name)
+	if err := cfg.save(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", cfg.path, err)
+	}
+	return fmt.Sprintf("Removed MCP server %s from %s config", name, cfg.scope), nil
+}
@@ -0,0 +1,306 @@
+// ABOUTME: Tracks which installed items a profile introduced, using the ownership ledger
+// ABOUTME: Lists live plugins, MCP servers, marketplaces, hooks, and memory blocks and undoes ledger entries on reset
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+	"github.com/claudeup/claudeup/v5/internal/ledger"
+)
+
+// LiveItems lists the installed plugins, MCP servers, and marketplaces and
+// the managed CLAUDE.md blocks for the user scope and, when projectDir is
+// set, its project and local scopes, and the hooks in user settings.json.
+// Comparing the lists taken before and after an apply shows what the apply
+// introduced. Missing files count as empty. A source that exists but cannot
+// be read is left out of the list and reported in the error, so callers can
+// tell a partial list from a complete one.
+func LiveItems(claudeDir, claudeJSONPath, projectDir string) ([]ledger.Item, error) {
+	var items []ledger.Item
+	var errs []error
+
+	registry, err := claude.LoadPlugins(claudeDir)
+	switch {
+	case err == nil:
+		for _, sp := range registry.GetPluginsForContext([]string{"user", "project", "local"}, projectDir) {
+			if sp.Scope != "user" && projectDir == "" {
+				continue
+			}
+			items = append(items, ledger.NewItem(ledger.KindPlugin, sp.Name, sp.Scope, projectDir))
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		errs = append(errs, err)
+	}
+
+	for _, scope := range []string{"user", "project", "local"} {
+		if scope != "user" && projectDir == "" {
+			continue
+		}
+		var names []string
+		if scope == "local" {
+			names, err = readLocalMCPServerNames(claudeJSONPath, projectDir)
+		} else {
+			var servers []MCPServer
+			servers, err = ReadMCPServersForScope(claudeJSONPath, projectDir, scope)
+			for _, s := range servers {
+				names = append(names, s.Name)
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read %s-scope MCP servers: %w", scope, err))
+			continue
+		}
+		for _, name := range names {
+			items = append(items, ledger.NewItem(ledger.KindMCP, name, scope, projectDir))
+		}
+	}
+
+	if marketplaces, err := claude.LoadMarketplaces(claudeDir); err == nil {
+		for name := range marketplaces {
+			items = append(items, ledger.NewItem(ledger.KindMarketplace, name, "user", ""))
+		}
+	} else {
+		errs = append(errs, err)
+	}
+
+	if settings, err := claude.LoadSettingsOrEmpty(claudeDir); err == nil {
+		for eventType, hooks := range readSettingsHooks(settings) {
+			for _, h := range hooks {
+				items = append(items, hookItem(eventType, h))
+			}
+		}
+	} else {
+		errs = append(errs, err)
+	}
+
+	for _, scope := range []string{"user", "project", "local"} {
+		if scope != "user" && projectDir == "" {
+			continue
+		}
+		names, err := memoryBlockNames(scope, claudeDir, projectDir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, name := range names {
+			items = append(items, ledger.NewItem(ledger.KindMemory, name, scope, projectDir))
+		}
+	}
+
+	sortLedgerItems(items)
+	return items, errors.Join(errs...)
+}
+
+// readLocalMCPServerNames returns the local-scope MCP servers the claude CLI
+// keeps under projects[<projectDir>] in .claude.json.
+func readLocalMCPServerNames(claudeJSONPath, projectDir string) ([]string, error) {
+	data, err := os.ReadFile(claudeJSONPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var claudeJSON struct {
+		Projects map[string]ClaudeJSON `json:"projects"`
+	}
+	if err := json.Unmarshal(data, &claudeJSON); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", claudeJSONPath, err)
+	}
+	var names []string
+	for dir, project := range claudeJSON.Projects {
+		if !sameDir(dir, projectDir) {
+			continue
+		}
+		for name := range project.MCPServers {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// IntroducedItems returns the items in after that were not in before.
+func IntroducedItems(before, after []ledger.Item) []ledger.Item {
+	existing := make(map[ledger.Item]bool, len(before))
+	for _, item := range before {
+		existing[item] = true
+	}
+	var added []ledger.Item
+	for _, item := range after {
+		if !existing[item] {
+			added = append(added, item)
+		}
+	}
+	return added
+}
+
+// ledgerKindOrder lists plugins before the MCP servers and marketplaces
+// they may depend on, then hooks and memory blocks, which is also the order
+// reset removes them in.
+var ledgerKindOrder = map[string]int{ledger.KindPlugin: 0, ledger.KindMCP: 1, ledger.KindMarketplace: 2, ledger.KindHook: 3, ledger.KindMemory: 4}
+
+func sortLedgerItems(items []ledger.Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if ledgerKindOrder[a.Kind] != ledgerKindOrder[b.Kind] {
+			return ledgerKindOrder[a.Kind] < ledgerKindOrder[b.Kind]
+		}
+		if a.Scope != b.Scope {
+			return a.Scope < b.Scope
+		}
+		return a.Name < b.Name
+	})
+}
+
+// OwnedItems returns the ledger entries the profile introduced at the user
+// scope and projectDir's scopes, and whether the profile is tracked at all.
+// Profiles last applied before the ledger existed are not tracked.
+func OwnedItems(profileName, claudeupHome, projectDir string) ([]ledger.Entry, bool) {
+	book, err := ledger.Load(claudeupHome)
+	if err != nil || !book.Tracked(profileName) {
+		return nil, false
+	}
+	return book.Entries(profileName, projectDir), true
+}
+
+// resetFromLedger removes the items the ledger records the profile as having
+// introduced: plugins first, then MCP servers, marketplaces, hooks, and
+// managed CLAUDE.md blocks.
+// Items already gone count as removed. Removed items are forgotten in the
+// ledger.
+// Marketplaces that plugins the profile did not add still come from are
+// kept, and stay in the ledger for a later reset. So are marketplaces when
+// the plugin registry cannot be read to tell.
+func resetFromLedger(profile *Profile, book *ledger.File, claudeDir, projectDir, claudeupHome string, executor CommandExecutor, result *ResetResult) {
+	entries := book.Entries(profile.Name, projectDir)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return ledgerKindOrder[entries[i].Kind] < ledgerKindOrder[entries[j].Kind]
+	})
+
+	var hooks, memory []ledger.Entry
+	for _, e := range entries {
+		var args []string
+		switch e.Kind {
+		case ledger.KindPlugin:
+			args = []string{"plugin", "uninstall"}
+			if e.Scope != "user" {
+				args = append(args, "--scope", e.Scope)
+			}
+			args = append(args, e.Name)
+		case ledger.KindMCP:
+			args = []string{"mcp", "remove", e.Name, "-s", e.Scope}
+		case ledger.KindMarketplace:
+			inUse, err := marketplaceInUse(claudeDir, e.Name)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("kept marketplace %s: %w", e.Name, err))
+				continue
+			}
+			if inUse {
+				continue
+			}
+			args = []string{"plugin", "marketplace", "remove", e.Name}
+		case ledger.KindHook:
+			hooks = append(hooks, e)
+			continue
+		case ledger.KindMemory:
+			memory = append(memory, e)
+			continue
+		default:
+			continue
+		}
+
+		output, err := executor.RunWithOutput(args...)
+		if err != nil && !errors.Is(err, ErrNotInstalled) {
+			result.Errors = append(result.Errors, fmt.Errorf("failed to remove %s %s (%s scope): %w\n  Output: %s", e.Kind, e.Name, e.Scope, err, strings.TrimSpace(output)))
+			continue
+		}
+		switch e.Kind {
+		case ledger.KindPlugin:
+			result.PluginsRemoved = append(result.PluginsRemoved, e.Name)
+		case ledger.KindMCP:
+			result.MCPServersRemoved = append(result.MCPServersRemoved, e.Name)
+		case ledger.KindMarketplace:
+			result.MarketplacesRemoved = append(result.MarketplacesRemoved, e.Name)
+		}
+		book.Forget(profile.Name, e.Item)
+	}
+
+	if len(hooks) > 0 {
+		if err := removeOwnedHooks(claudeDir, hooks); err != nil {
+			result.Errors = append(result.Errors, err)
+		} else {
+			for _, e := range hooks {
+				result.HooksRemoved = append(result.HooksRemoved, e.Name)
+				book.Forget(profile.Name, e.Item)
+			}
+		}
+	}
+
+	for _, e := range memory {
+		if err := removeMemoryBlocks([]string{e.Name}, e.Scope, claudeDir, projectDir); err != nil {
+			result.Errors = append(result.Errors, err)
+			continue
+		}
+		result.MemoryRemoved = append(result.MemoryRemoved, e.Name)
+		book.Forget(profile.Name, e.Item)
+	}
+
+	if err := ledger.Save(claudeupHome, book); err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("failed to update ownership ledger: %w", err))
+	}
+}
+
+// removeOwnedHooks removes the hooks recorded in entries from user
+// settings.json. Hooks the user had before the profile added its own are
+// not in the ledger, so they stay even when they are identical.
+func removeOwnedHooks(claudeDir string, entries []ledger.Entry) error {
+	owned := make(map[ledger.Item]bool, len(entries))
+	for _, e := range entries {
+		owned[e.Item] = true
+	}
+
+	settings, err := claude.LoadSettingsOrEmpty(claudeDir)
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+	remove := make(map[string][]HookEntry)
+	for eventType, hooks := range readSettingsHooks(settings) {
+		for _, h := range hooks {
+			if owned[hookItem(eventType, h)] {
+				remove[eventType] = append(remove[eventType], h)
+			}
+		}
+	}
+	if len(remove) == 0 {
+		return nil
+	}
+	settings.RemoveHooks(settingsHooksMaps(remove))
+	if err := claude.SaveSettings(claudeDir, settings); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	return nil
+}
+
+// marketplaceInUse reports whether any installed plugin, at any scope,
+// comes from the named marketplace. It returns an error when the plugin
+// registry cannot be read, so callers keep the marketplace rather than guess.
+func marketplaceInUse(claudeDir, name string) (bool, error) {
+	registry, err := claude.LoadPlugins(claudeDir)
+	if err != nil {
+		return false, fmt.Errorf("cannot tell which plugins still use it: %w", err)
+	}
+	for plugin, instances := range registry.Plugins {
+		if strings.HasSuffix(plugin, "@"+name) && len(instances) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,228 @@
+// ABOUTME: Tests for ledger-driven ownership tracking and reset
+// ABOUTME: Verifies live item listing, introduced-item diffing, and that reset removes only owned items
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/claudeup/claudeup/v5/internal/ledger"
+)
+
+// liveItems lists the live items, failing the test if any source is unreadable.
+func liveItems(t *testing.T, claudeDir, claudeJSONPath, projectDir string) []ledger.Item {
+	t.Helper()
+	items, err := LiveItems(claudeDir, claudeJSONPath, projectDir)
+	if err != nil {
+		t.Fatalf("LiveItems: %v", err)
+	}
+	return items
+}
+
+func TestLiveItemsAndIntroducedItems(t *testing.T) {
+	tmpDir := t.TempDir()
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	claudeJSONPath := filepath.Join(tmpDir, ".claude.json")
+	projectDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(claudeDir, "plugins"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestJSON(t, filepath.Join(claudeDir, "plugins", "installed_plugins.json"), map[string]interface{}{
+		"version": 2,
+		"plugins": map[string]interface{}{
+			"mine@tools": []map[string]interface{}{{"scope": "user", "version": "1.0"}},
+		},
+	})
+	writeTestJSON(t, filepath.Join(claudeDir, "plugins", "known_marketplaces.json"), map[string]interface{}{})
+	writeTestJSON(t, claudeJSONPath, map[string]interface{}{})
+	before := liveItems(t, claudeDir, claudeJSONPath, projectDir)
+
+	writeTestJSON(t, filepath.Join(claudeDir, "plugins", "installed_plugins.json"), map[string]interface{}{
+		"version": 2,
+		"plugins": map[string]interface{}{
+			"mine@tools":  []map[string]interface{}{{"scope": "user", "version": "1.0"}},
+			"added@tools": []map[string]interface{}{{"scope": "project", "projectPath": projectDir, "version": "1.0"}},
+		},
+	})
+	writeTestJSON(t, filepath.Join(claudeDir, "plugins", "known_marketplaces.json"), map[string]interface{}{
+		"tools": map[string]interface{}{"source": map[string]interface{}{"source": "github", "repo": "acme/tools"}},
+	})
+	writeTestJSON(t, claudeJSONPath, map[string]interface{}{
+		"mcpServers": map[string]interface{}{"notes": map[string]interface{}{"command": "notes-server"}},
+		"projects": map[string]interface{}{
+			projectDir: map[string]interface{}{
+				"mcpServers": map[string]interface{}{"db": map[string]interface{}{"command": "db-server"}},
+			},
+		},
+	})
+	after := liveItems(t, claudeDir, claudeJSONPath, projectDir)
+
+	want := []ledger.Item{
+		ledger.NewItem(ledger.KindPlugin, "added@tools", "project", projectDir),
+		ledger.NewItem(ledger.KindMCP, "db", "local", projectDir),
+		ledger.NewItem(ledger.KindMCP, "notes", "user", ""),
+		ledger.NewItem(ledger.KindMarketplace, "tools", "user", ""),
+	}
+	if got := IntroducedItems(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("IntroducedItems() =\n  %+v\nwant\n  %+v", got, want)
+	}
+}
+
+func TestResetRemovesOnlyLedgerOwnedItems(t *testing.T) {
+	tmpDir := t.TempDir()
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	claudeupHome := filepath.Join(tmpDir, ".claudeup")
+	projectDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(claudeDir, "plugins"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The user installed extra@tools from the same marketplace themselves
+	writeTestJSON(t, filepath.Join(claudeDir, "plugins", "installed_plugins.json"), map[string]interface{}{
+		"version": 2,
+		"plugins": map[string]interface{}{
+			"owned@acme-tools": []map[string]interface{}{{"scope": "user", "version": "1.0"}},
+			"extra@acme-tools": []map[string]interface{}{{"scope": "user", "version": "1.0"}},
+		},
+	})
+	writeTestJSON(t, filepath.Join(tmpDir, ".claude.json"), map[string]interface{}{})
+
+	book, err := ledger.Load(claudeupHome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	book.Record("team", []ledger.Item{
+		ledger.NewItem(ledger.KindPlugin, "owned@acme-tools", "user", ""),
+		ledger.NewItem(ledger.KindMCP, "db", "local", projectDir),
+	}, time.Now())
+	if err := ledger.Save(claudeupHome, book); err != nil {
+		t.Fatal(err)
+	}
+
+	// The profile names a marketplace and MCP server it found already installed
+	p := &Profile{
+		Name:         "team",
+		Marketplaces: []Marketplace{{Source: "github", Repo: "acme/tools"}},
+		MCPServers:   []MCPServer{{Name: "shared", Command: "shared-server"}},
+	}
+	executor := &mockExecutor{}
+	result, err := ResetWithExecutor(p, claudeDir, filepath.Join(tmpDir, ".claude.json"), projectDir, claudeupHome, executor)
+	if err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+
+	wantCommands := [][]string{
+		{"plugin", "uninstall", "owned@acme-tools"},
+		{"mcp", "remove", "db", "-s", "local"},
+	}
+	if !reflect.DeepEqual(executor.commands, wantCommands) {
+		t.Errorf("commands = %v, want %v", executor.commands, wantCommands)
+	}
+	if len(result.PluginsRemoved) != 1 || len(result.MCPServersRemoved) != 1 || len(result.MarketplacesRemoved) != 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	// Removed items are forgotten; the profile stays tracked so a second
+	// reset removes nothing instead of falling back to marketplace matching
+	book, err = ledger.Load(claudeupHome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !book.Tracked("team") || len(book.Profiles["team"]) != 0 {
+		t.Errorf("ledger after reset = %+v", book.Profiles)
+	}
+	executor = &mockExecutor{}
+	if _, err := ResetWithExecutor(p, claudeDir, filepath.Join(tmpDir, ".claude.json"), projectDir, claudeupHome, executor); err != nil {
+		t.Fatal(err)
+	}
+	if len(executor.commands) != 0 {
+		t.Errorf("second reset issued commands: %v", executor.commands)
+	}
+}
+
+func TestResetFromLedgerKeepsFailedItems(t *testing.T) {
+	claudeupHome := t.TempDir()
+	book, _ := ledger.Load(claudeupHome)
+	stuck := ledger.NewItem(ledger.KindMarketplace, "tools", "user", "")
+	gone := ledger.NewItem(ledger.KindPlugin, "gone@tools", "user", "")
+	book.Record("p", []ledger.Item{stuck, gone}, time.Now())
+
+	executor := &mockExecutor{failOn: map[string]bool{"plugin marketplace remove": true}}
+	result := &ResetResult{}
+	resetFromLedger(&Profile{Name: "p"}, book, t.TempDir(), "", claudeupHome, executor, result)
+
+	if len(result.Errors) != 1 {
+		t.Fatalf("expected one error, got %v", result.Errors)
+	}
+	if book.Owner(stuck) != "p" || book.Owner(gone) != "" {
+		t.Errorf("failed removals should stay in the ledger: %+v", book.Profiles)
+	}
+}
+
+func TestResetFromLedgerKeepsMarketplaceWhenRegistryUnreadable(t *testing.T) {
+	claudeupHome := t.TempDir()
+	claudeDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(claudeDir, "plugins"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(claudeDir, "plugins", "installed_plugins.json"), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	book, _ := ledger.Load(claudeupHome)
+	marketplace := ledger.NewItem(ledger.KindMarketplace, "tools", "user", "")
+	book.Record("p", []ledger.Item{marketplace}, time.Now())
+
+	executor := &mockExecutor{}
+	result := &ResetResult{}
+	resetFromLedger(&Profile{Name: "p"}, book, claudeDir, "", claudeupHome, executor, result)
+
+	if len(executor.commands) != 0 {
+		t.Errorf("marketplace removed despite unreadable registry: %v", executor.commands)
+	}
+	if len(result.Errors) != 1 {
+		t.Errorf("expected one error, got %v", result.Errors)
+	}
+	if book.Owner(marketplace) != "p" {
+		t.Errorf("kept marketplace should stay in the ledger: %+v", book.Profiles)
+	}
+}
+
+func TestLiveItemsReportsUnreadableSources(t *testing.T) {
+	tmpDir := t.TempDir()
+	claudeDir := filepath.Join(tmpDir, ".claude")
+	claudeJSONPath := filepath.Join(tmpDir, ".claude.json")
+	if err := os.MkdirAll(filepath.Join(claudeDir, "plugins"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestJSON(t, filepath.Join(claudeDir, "plugins", "installed_plugins.json"), map[string]interface{}{
+		"version": 2,
+		"plugins": map[string]interface{}{
+			"mine@tools": []map[string]interface{}{{"scope": "user", "version": "1.0"}},
+		},
+	})
+	if err := os.WriteFile(filepath.Join(claudeDir, "plugins", "known_marketplaces.json"), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := LiveItems(claudeDir, claudeJSONPath, "")
+	if err == nil || !strings.Contains(err.Error(), "known_marketplaces.json") {
+		t.Errorf("expected an error naming known_marketplaces.json, got %v", err)
+	}
+	want := []ledger.Item{ledger.NewItem(ledger.KindPlugin, "mine@tools", "user", "")}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("items = %+v, want %+v", items, want)
+	}
+}
+
+func TestLiveItemsTreatsMissingClaudeDirAsEmpty(t *testing.T) {
+	tmpDir := t.TempDir()
+	items, err := LiveItems(filepath.Join(tmpDir, ".claude"), filepath.Join(tmpDir, ".claude.json"), t.TempDir())
+	if err != nil || len(items) != 0 {
+		t.Errorf("LiveItems() = %v, %v; want no items and no error", items, err)
+	}
+}
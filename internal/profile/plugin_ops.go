@@ -0,0 +1,379 @@
+// ABOUTME: Single-plugin install, uninstall, enable, and disable operations
+// ABOUTME: Keeps scope settings and installed_plugins.json consistent with each other
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+	"github.com/claudeup/claudeup/v5/internal/events"
+)
+
+// PluginOpOptions configures a single-plugin operation
+type PluginOpOptions struct {
+	Scope      string          // user, project, or local (empty means user)
+	ClaudeDir  string          // Claude configuration directory
+	ProjectDir string          // Required for project/local scope
+	Executor   CommandExecutor // Runs claude CLI commands (install/uninstall only)
+}
+
+// PluginOpResult describes the outcome of a single-plugin operation
+type PluginOpResult struct {
+	Plugin   string
+	Scope    string
+	Changed  bool     // False when the plugin was already in the requested state
+	Warnings []string // Non-fatal inconsistencies noticed after the operation
+}
+
+// validate normalizes the scope and checks the plugin name and options.
+func (o *PluginOpOptions) validate(plugin string) error {
+	if err := ValidatePluginFormat(plugin); err != nil {
+		return err
+	}
+	if o.Scope == "" {
+		o.Scope = claude.ScopeUser
+	}
+	if err := claude.ValidateScope(o.Scope); err != nil {
+		return err
+	}
+	if o.Scope != claude.ScopeUser && o.ProjectDir == "" {
+		return fmt.Errorf("%s scope requires a project directory", o.Scope)
+	}
+	return nil
+}
+
+// scopeArgs returns the --scope flag for non-user scopes. User scope is the
+// claude CLI default, so no flag is passed (matching InstallPluginsWithProgress).
+func (o *PluginOpOptions) scopeArgs() []string {
+	if o.Scope == claude.ScopeUser {
+		return nil
+	}
+	return []string{"--scope", o.Scope}
+}
+
+// installedAtScope reports whether the registry holds an instance of plugin
+// at the option's scope for the option's project.
+func (o *PluginOpOptions) installedAtScope(registry *claude.PluginRegistry, plugin string) bool {
+	for _, sp := range registry.GetPluginsForContext([]string{o.Scope}, o.ProjectDir) {
+		if sp.Name == plugin {
+			return true
+		}
+	}
+	return false
+}
+
+func pluginRegistryPath(claudeDir string) string {
+	return filepath.Join(claudeDir, "plugins", "installed_plugins.json")
+}
+
+// InstallPlugin installs a plugin at the given scope via the claude CLI and
+// makes sure it is enabled in that scope's settings file.
+// A plugin that is already installed is reported with Changed=false.
+func InstallPlugin(plugin string, opts PluginOpOptions) (*PluginOpResult, error) {
+	if err := opts.validate(plugin); err != nil {
+		return nil, err
+	}
+	if opts.Executor == nil {
+		return nil, fmt.Errorf("no command executor configured")
+	}
+
+	result := &PluginOpResult{Plugin: plugin, Scope: opts.Scope, Changed: true}
+
+	args := append([]string{"plugin", "install"}, opts.scopeArgs()...)
+	args = append(args, plugin)
+
+	err := events.GlobalTracker().RecordFileWrite(
+		"plugin install",
+		pluginRegistryPath(opts.ClaudeDir),
+		opts.Scope,
+		func() error {
+			output, err := opts.Executor.RunWithOutput(args...)
+			if err == nil {
+				return nil
+			}
+			if errors.Is(err, ErrAlreadyInstalled) {
+				result.Changed = false
+				return nil
+			}
+			return fmt.Errorf("failed to install plugin %s: %w\n  Output: %s", plugin, err, strings.TrimSpace(output))
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The claude CLI normally enables the plugin itself; make sure the
+	// scope's settings agree so profile status does not report drift.
+	settings, err := claude.LoadSettingsForScope(opts.Scope, opts.ClaudeDir, opts.ProjectDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s settings: %w", opts.Scope, err)
+	}
+	if !settings.IsPluginEnabled(plugin) {
+		settings.EnablePlugin(plugin)
+		if err := claude.SaveSettingsForScope(opts.Scope, opts.ClaudeDir, opts.ProjectDir, settings); err != nil {
+			return nil, fmt.Errorf("failed to enable plugin in %s settings: %w", opts.Scope, err)
+		}
+		result.Changed = true
+	}
+
+	registry, err := claude.LoadPlugins(opts.ClaudeDir)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("could not verify plugin registry: %v", err))
+	} else if !opts.installedAtScope(registry, plugin) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s is enabled but not recorded in installed_plugins.json at %s scope", plugin, opts.Scope))
+	}
+
+	return result, nil
+}
+
+// UninstallPlugin removes a plugin from the given scope via the claude CLI,
+// then removes any settings or registry entries the CLI left behind.
+// Returns an error if the plugin is neither installed nor configured at the scope.
+func UninstallPlugin(plugin string, opts PluginOpOptions) (*PluginOpResult, error) {
+	if err := opts.validate(plugin); err != nil {
+		return nil, err
+	}
+	if opts.Executor == nil {
+		return nil, fmt.Errorf("no command executor configured")
+	}
+
+	registry, err := claude.LoadPlugins(opts.ClaudeDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plugin registry: %w", err)
+	}
+	settings, err := claude.LoadSettingsForScope(opts.Scope, opts.ClaudeDir, opts.ProjectDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s settings: %w", opts.Scope, err)
+	}
+
+	_, inSettings := settings.EnabledPlugins[plugin]
+	if !opts.installedAtScope(registry, plugin) && !inSettings {
+		return nil, fmt.Errorf("plugin %s is not installed at %s scope", plugin, opts.Scope)
+	}
+
+	result := &PluginOpResult{Plugin: plugin, Scope: opts.Scope, Changed: true}
+
+	args := append([]string{"plugin", "uninstall"}, opts.scopeArgs()...)
+	args = append(args, plugin)
+
+	err = events.GlobalTracker().RecordFileWrite(
+		"plugin uninstall",
+		pluginRegistryPath(opts.ClaudeDir),
+		opts.Scope,
+		func() error {
+			output, err := opts.Executor.RunWithOutput(args...)
+			if err == nil {
+				return nil
+			}
+			// Stale settings entries make the executor report the plugin
+			// as not installed; the cleanup below still removes them.
+			if errors.Is(err, ErrNotInstalled) {
+				return nil
+			}
+			return fmt.Errorf("failed to uninstall plugin %s: %w\n  Output: %s", plugin, err, strings.TrimSpace(output))
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reload both files: the CLI may have rewritten them.
+	settings, err = claude.LoadSettingsForScope(opts.Scope, opts.ClaudeDir, opts.ProjectDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s settings: %w", opts.Scope, err)
+	}
+	if _, ok := settings.EnabledPlugins[plugin]; ok {
+		settings.RemovePlugin(plugin)
+		if err := claude.SaveSettingsForScope(opts.Scope, opts.ClaudeDir, opts.ProjectDir, settings); err != nil {
+			return nil, fmt.Errorf("failed to update %s settings: %w", opts.Scope, err)
+		}
+	}
+
+	registry, err = claude.LoadPlugins(opts.ClaudeDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plugin registry: %w", err)
+	}
+	if registry.RemovePluginInstance(plugin, opts.Scope, opts.ProjectDir) {
+		if err := claude.SavePlugins(opts.ClaudeDir, registry); err != nil {
+			return nil, fmt.Errorf("failed to update plugin registry: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// EnablePlugin enables an installed plugin in the given scope's settings file.
+// Enabling only touches settings, so the claude CLI is not invoked.
+func EnablePlugin(plugin string, opts PluginOpOptions) (*PluginOpResult, error) {
+	return setPluginEnabled(plugin, opts, true)
+}
+
+// DisablePlugin disables a plugin in the given scope's settings file without
+// uninstalling it. Disabling at a narrower scope overrides a broader one.
+func DisablePlugin(plugin string, opts PluginOpOptions) (*PluginOpResult, error) {
+	return setPluginEnabled(plugin, opts, false)
+}
+
+func setPluginEnabled(plugin string, opts PluginOpOptions, enabled bool) (*PluginOpResult, error) {
+	if err := opts.validate(plugin); err != nil {
+		return nil, err
+	}
+
+	registry, err := claude.LoadPlugins(opts.ClaudeDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plugin registry: %w", err)
+	}
+	if !registry.PluginExistsAtAnyScope(plugin) {
+		return nil, fmt.Errorf("plugin %s is not installed. Run 'claudeup plugin install %s' first", plugin, plugin)
+	}
+
+	settings, err := claude.LoadSettingsForScope(opts.Scope, opts.ClaudeDir, opts.ProjectDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s settings: %w", opts.Scope, err)
+	}
+
+	result := &PluginOpResult{Plugin: plugin, Scope: opts.Scope}
+	current, exists := settings.EnabledPlugins[plugin]
+	if exists && current == enabled {
+		return result, nil
+	}
+
+	if enabled {
+		settings.EnablePlugin(plugin)
+	} else {
+		settings.DisablePlugin(plugin)
+	}
+	if err := claude.SaveSettingsForScope(opts.Scope, opts.ClaudeDir, opts.ProjectDir, settings); err != nil {
+		return nil, fmt.Errorf("failed to update %s settings: %w", opts.Scope, err)
+	}
+
+	result.Changed = true
+	return result, nil
+}
+
+// MarketplaceForPlugin looks up the registered marketplace a plugin belongs to,
+// using the marketplace name after the plugin's last "@".
+// Returns false if the marketplace is not in known_marketplaces.json.
+func MarketplaceForPlugin(claudeDir, plugin string) (Marketplace, bool) {
+	atIdx := strings.LastIndex(plugin, "@")
+	if atIdx == -1 {
+		return Marketplace{}, false
+	}
+	registry, err := claude.LoadMarketplaces(claudeDir)
+	if err != nil {
+		return Marketplace{}, false
+	}
+	meta, ok := registry[plugin[atIdx+1:]]
+	if !ok {
+		return Marketplace{}, false
+	}
+	m := marketplaceFromSource(meta.Source)
+	if m.DisplayName() == "" {
+		return Marketplace{}, false
+	}
+	return m, true
+}
+
+// AddPlugin records plugin in the profile's settings for scope.
+// Legacy flat profiles keep using the flat plugin list for user scope; adding
+// a project or local plugin lifts them into PerScope first.
+// Returns true if the profile changed.
+func (p *Profile) AddPlugin(scope, plugin string) (bool, error) {
+	settings, err := p.editableScope(scope)
+	if err != nil {
+		return false, err
+	}
+	plugins := &p.Plugins
+	if settings != nil {
+		plugins = &settings.Plugins
+	}
+	for _, existing := range *plugins {
+		if existing == plugin {
+			return false, nil
+		}
+	}
+	*plugins = append(*plugins, plugin)
+	return true, nil
+}
+
+// RemovePlugin removes plugin from the profile's settings for scope.
+// Returns true if the profile changed.
+func (p *Profile) RemovePlugin(scope, plugin string) (bool, error) {
+	settings, err := p.editableScope(scope)
+	if err != nil {
+		return false, err
+	}
+	plugins := &p.Plugins
+	if settings != nil {
+		plugins = &settings.Plugins
+	}
+	for i, existing := range *plugins {
+		if existing == plugin {
+			*plugins = append((*plugins)[:i:i], (*plugins)[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// AddMarketplace appends m unless the profile already has a marketplace
+// with the same key. Returns true if the profile changed.
+func (p *Profile) AddMarketplace(m Marketplace) bool {
+	key := marketplaceKey(m)
+	for _, existing := range p.Marketplaces {
+		if marketplaceKey(existing) == key {
+			return false
+		}
+	}
+	p.Marketplaces = append(p.Marketplaces, m)
+	return true
+}
+
+// editableScope returns the ScopeSettings to edit for scope, creating it if
+// needed. Returns nil (with no error) when the flat user-scope fields of a
+// legacy profile should be edited instead.
+func (p *Profile) editableScope(scope string) (*ScopeSettings, error) {
+	if p.IsStack() {
+		return nil, fmt.Errorf("profile %q is a stack; add plugins to one of its included profiles instead", p.Name)
+	}
+	if err := claude.ValidateScope(scope); err != nil {
+		return nil, err
+	}
+
+	if p.PerScope == nil {
+		if scope == claude.ScopeUser {
+			return nil, nil
+		}
+		// Lift legacy flat fields into user scope so they keep their meaning
+		p.PerScope = &PerScopeSettings{}
+		if len(p.Plugins) > 0 || len(p.MCPServers) > 0 || p.Extensions != nil || p.Settings != nil || len(p.Memory) > 0 {
+			p.PerScope.User = &ScopeSettings{
+				Plugins:    p.Plugins,
+				MCPServers: p.MCPServers,
+				Extensions: p.Extensions,
+				Settings:   p.Settings,
+				Memory:     p.Memory,
+			}
+		}
+		p.Plugins = nil
+		p.MCPServers = nil
+		p.Extensions = nil
+		p.Settings = nil
+		p.Memory = nil
+	}
+
+	target := &p.PerScope.User
+	switch scope {
+	case claude.ScopeProject:
+		target = &p.PerScope.Project
+	case claude.ScopeLocal:
+		target = &p.PerScope.Local
+	}
+	if *target == nil {
+		*target = &ScopeSettings{}
+	}
+	return *target, nil
+}
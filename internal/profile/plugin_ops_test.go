@@ -0,0 +1,423 @@
+// ABOUTME: Tests for single-plugin install, uninstall, enable, and disable
+// ABOUTME: Uses a mock executor to verify settings and registry reconciliation
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+)
+
+// registryWritingExecutor simulates the claude CLI updating installed_plugins.json
+type registryWritingExecutor struct {
+	claudeDir string
+	output    string
+	err       error
+	commands  [][]string
+}
+
+func (e *registryWritingExecutor) Run(args ...string) error {
+	_, err := e.RunWithOutput(args...)
+	return err
+}
+
+func (e *registryWritingExecutor) RunWithOutput(args ...string) (string, error) {
+	e.commands = append(e.commands, args)
+	if e.err != nil {
+		return e.output, e.err
+	}
+	if len(args) >= 3 && args[0] == "plugin" && args[1] == "install" {
+		registry, err := claude.LoadPlugins(e.claudeDir)
+		if err != nil {
+			return "", err
+		}
+		scope := "user"
+		if args[2] == "--scope" {
+			scope = args[3]
+		}
+		registry.SetPlugin(args[len(args)-1], claude.PluginMetadata{Scope: scope, Version: "1.0.0"})
+		if err := claude.SavePlugins(e.claudeDir, registry); err != nil {
+			return "", err
+		}
+	}
+	return e.output, nil
+}
+
+func setupPluginOpsDir(t *testing.T, plugins map[string]interface{}, enabled map[string]bool) string {
+	t.Helper()
+	claudeDir := filepath.Join(t.TempDir(), ".claude")
+	pluginsDir := filepath.Join(claudeDir, "plugins")
+	if err := os.MkdirAll(pluginsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(t, filepath.Join(pluginsDir, "installed_plugins.json"), map[string]interface{}{
+		"version": 2,
+		"plugins": plugins,
+	})
+	writeJSON(t, filepath.Join(claudeDir, "settings.json"), map[string]interface{}{
+		"enabledPlugins": enabled,
+	})
+	return claudeDir
+}
+
+func TestInstallPluginEnablesInSettings(t *testing.T) {
+	claudeDir := setupPluginOpsDir(t, map[string]interface{}{}, map[string]bool{})
+	executor := &registryWritingExecutor{claudeDir: claudeDir}
+
+	result, err := InstallPlugin("tdd@workflows", PluginOpOptions{ClaudeDir: claudeDir, Executor: executor})
+	if err != nil {
+		t.Fatalf("InstallPlugin failed: %v", err)
+	}
+	if !result.Changed {
+		t.Error("expected Changed=true for a new install")
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", result.Warnings)
+	}
+
+	want := []string{"plugin", "install", "tdd@workflows"}
+	if len(executor.commands) != 1 || !slices.Equal(executor.commands[0], want) {
+		t.Errorf("commands = %v, want [%v]", executor.commands, want)
+	}
+
+	settings, err := claude.LoadSettings(claudeDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !settings.IsPluginEnabled("tdd@workflows") {
+		t.Error("plugin should be enabled in user settings")
+	}
+}
+
+func TestInstallPluginPassesScopeFlag(t *testing.T) {
+	claudeDir := setupPluginOpsDir(t, map[string]interface{}{}, map[string]bool{})
+	projectDir := t.TempDir()
+	executor := &registryWritingExecutor{claudeDir: claudeDir}
+
+	_, err := InstallPlugin("tdd@workflows", PluginOpOptions{
+		Scope:      "project",
+		ClaudeDir:  claudeDir,
+		ProjectDir: projectDir,
+		Executor:   executor,
+	})
+	if err != nil {
+		t.Fatalf("InstallPlugin failed: %v", err)
+	}
+
+	want := []string{"plugin", "install", "--scope", "project", "tdd@workflows"}
+	if !slices.Equal(executor.commands[0], want) {
+		t.Errorf("command = %v, want %v", executor.commands[0], want)
+	}
+
+	settings, err := claude.LoadSettingsForScope("project", claudeDir, projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !settings.IsPluginEnabled("tdd@workflows") {
+		t.Error("plugin should be enabled in project settings")
+	}
+}
+
+func TestInstallPluginAlreadyInstalled(t *testing.T) {
+	claudeDir := setupPluginOpsDir(t,
+		map[string]interface{}{"tdd@workflows": []map[string]interface{}{{"scope": "user"}}},
+		map[string]bool{"tdd@workflows": true},
+	)
+	executor := &registryWritingExecutor{
+		claudeDir: claudeDir,
+		output:    "Plugin tdd@workflows is already installed",
+		err:       alreadyInstalledf("Plugin tdd@workflows is already installed"),
+	}
+
+	result, err := InstallPlugin("tdd@workflows", PluginOpOptions{ClaudeDir: claudeDir, Executor: executor})
+	if err != nil {
+		t.Fatalf("already-installed should not be an error: %v", err)
+	}
+	if result.Changed {
+		t.Error("expected Changed=false when already installed and enabled")
+	}
+}
+
+func TestInstallPluginReportsCLIFailure(t *testing.T) {
+	claudeDir := setupPluginOpsDir(t, map[string]interface{}{}, map[string]bool{})
+	executor := &registryWritingExecutor{claudeDir: claudeDir, output: "marketplace not found", err: os.ErrInvalid}
+
+	_, err := InstallPlugin("tdd@workflows", PluginOpOptions{ClaudeDir: claudeDir, Executor: executor})
+	if err == nil || !strings.Contains(err.Error(), "marketplace not found") {
+		t.Errorf("expected error with CLI output, got %v", err)
+	}
+
+	settings, _ := claude.LoadSettings(claudeDir)
+	if settings.IsPluginEnabled("tdd@workflows") {
+		t.Error("failed install must not enable the plugin")
+	}
+}
+
+func TestInstallPluginWarnsWhenRegistryMissingEntry(t *testing.T) {
+	claudeDir := setupPluginOpsDir(t, map[string]interface{}{}, map[string]bool{})
+
+	result, err := InstallPlugin("tdd@workflows", PluginOpOptions{ClaudeDir: claudeDir, Executor: &mockExecutor{}})
+	if err != nil {
+		t.Fatalf("InstallPlugin failed: %v", err)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("expected one registry warning, got %v", result.Warnings)
+	}
+}
+
+func TestPluginOpsRejectInvalidInput(t *testing.T) {
+	claudeDir := setupPluginOpsDir(t, map[string]interface{}{}, map[string]bool{})
+
+	if _, err := InstallPlugin("no-marketplace", PluginOpOptions{ClaudeDir: claudeDir, Executor: &mockExecutor{}}); err == nil {
+		t.Error("expected error for plugin without @marketplace")
+	}
+	if _, err := EnablePlugin("p@mp", PluginOpOptions{Scope: "global", ClaudeDir: claudeDir}); err == nil {
+		t.Error("expected error for invalid scope")
+	}
+	if _, err := EnablePlugin("p@mp", PluginOpOptions{Scope: "project", ClaudeDir: claudeDir}); err == nil {
+		t.Error("expected error for project scope without project directory")
+	}
+}
+
+func TestUninstallPluginCleansUpLeftovers(t *testing.T) {
+	projectDir := t.TempDir()
+	claudeDir := setupPluginOpsDir(t,
+		map[string]interface{}{"tdd@workflows": []map[string]interface{}{
+			{"scope": "user"},
+			{"scope": "local", "projectPath": projectDir},
+		}},
+		map[string]bool{"tdd@workflows": true},
+	)
+	// The CLI "succeeds" without touching any files
+	executor := &mockExecutor{}
+
+	result, err := UninstallPlugin("tdd@workflows", PluginOpOptions{ClaudeDir: claudeDir, Executor: executor})
+	if err != nil {
+		t.Fatalf("UninstallPlugin failed: %v", err)
+	}
+	if !result.Changed {
+		t.Error("expected Changed=true")
+	}
+
+	want := []string{"plugin", "uninstall", "tdd@workflows"}
+	if !slices.Equal(executor.commands[0], want) {
+		t.Errorf("command = %v, want %v", executor.commands[0], want)
+	}
+
+	settings, _ := claude.LoadSettings(claudeDir)
+	if _, ok := settings.EnabledPlugins["tdd@workflows"]; ok {
+		t.Error("settings entry should be removed")
+	}
+
+	registry, _ := claude.LoadPlugins(claudeDir)
+	if registry.PluginExistsAtScope("tdd@workflows", "user") {
+		t.Error("user registry entry should be removed")
+	}
+	if !registry.PluginExistsAtScope("tdd@workflows", "local") {
+		t.Error("local registry entry must be preserved")
+	}
+}
+
+func TestUninstallPluginNotInstalled(t *testing.T) {
+	claudeDir := setupPluginOpsDir(t, map[string]interface{}{}, map[string]bool{})
+	executor := &mockExecutor{}
+
+	_, err := UninstallPlugin("tdd@workflows", PluginOpOptions{ClaudeDir: claudeDir, Executor: executor})
+	if err == nil || !strings.Contains(err.Error(), "not installed") {
+		t.Errorf("expected not installed error, got %v", err)
+	}
+	if len(executor.commands) != 0 {
+		t.Error("claude CLI should not run for a plugin that is not installed")
+	}
+}
+
+func TestUninstallPluginTreatsNotInstalledAsCleanup(t *testing.T) {
+	// Only a stale settings entry is left; the executor reports the plugin
+	// itself as not installed
+	claudeDir := setupPluginOpsDir(t, map[string]interface{}{}, map[string]bool{"tdd@workflows": true})
+	executor := &registryWritingExecutor{
+		claudeDir: claudeDir,
+		output:    `Plugin "tdd@workflows" not found in installed plugins`,
+		err:       notInstalledf(`Plugin "tdd@workflows" not found in installed plugins`),
+	}
+
+	if _, err := UninstallPlugin("tdd@workflows", PluginOpOptions{ClaudeDir: claudeDir, Executor: executor}); err != nil {
+		t.Fatalf("a plugin that is already uninstalled should not be an error: %v", err)
+	}
+	settings, _ := claude.LoadSettings(claudeDir)
+	if _, ok := settings.EnabledPlugins["tdd@workflows"]; ok {
+		t.Error("stale settings entry should be removed")
+	}
+}
+
+func TestUninstallPluginReportsCLIFailure(t *testing.T) {
+	claudeDir := setupPluginOpsDir(t,
+		map[string]interface{}{"tdd@workflows": []map[string]interface{}{{"scope": "user"}}},
+		map[string]bool{"tdd@workflows": true},
+	)
+	// Other "not found" failures are real errors
+	executor := &registryWritingExecutor{claudeDir: claudeDir, output: "marketplace not found", err: os.ErrInvalid}
+
+	_, err := UninstallPlugin("tdd@workflows", PluginOpOptions{ClaudeDir: claudeDir, Executor: executor})
+	if err == nil || !strings.Contains(err.Error(), "marketplace not found") {
+		t.Errorf("expected error with CLI output, got %v", err)
+	}
+}
+
+func TestEnableDisablePlugin(t *testing.T) {
+	projectDir := t.TempDir()
+	claudeDir := setupPluginOpsDir(t,
+		map[string]interface{}{"tdd@workflows": []map[string]interface{}{{"scope": "user"}}},
+		map[string]bool{"tdd@workflows": true},
+	)
+	opts := PluginOpOptions{Scope: "local", ClaudeDir: claudeDir, ProjectDir: projectDir}
+
+	result, err := DisablePlugin("tdd@workflows", opts)
+	if err != nil {
+		t.Fatalf("DisablePlugin failed: %v", err)
+	}
+	if !result.Changed {
+		t.Error("expected Changed=true when disabling at a new scope")
+	}
+	local, _ := claude.LoadSettingsForScope("local", claudeDir, projectDir)
+	if enabled, ok := local.EnabledPlugins["tdd@workflows"]; !ok || enabled {
+		t.Error("local settings should explicitly disable the plugin")
+	}
+
+	result, err = DisablePlugin("tdd@workflows", opts)
+	if err != nil {
+		t.Fatalf("DisablePlugin failed: %v", err)
+	}
+	if result.Changed {
+		t.Error("expected Changed=false when already disabled")
+	}
+
+	if _, err := EnablePlugin("tdd@workflows", opts); err != nil {
+		t.Fatalf("EnablePlugin failed: %v", err)
+	}
+	local, _ = claude.LoadSettingsForScope("local", claudeDir, projectDir)
+	if !local.IsPluginEnabled("tdd@workflows") {
+		t.Error("local settings should enable the plugin")
+	}
+
+	// User settings are untouched
+	user, _ := claude.LoadSettings(claudeDir)
+	if !user.IsPluginEnabled("tdd@workflows") {
+		t.Error("user settings should not change")
+	}
+}
+
+func TestEnablePluginRequiresInstall(t *testing.T) {
+	claudeDir := setupPluginOpsDir(t, map[string]interface{}{}, map[string]bool{})
+
+	_, err := EnablePlugin("tdd@workflows", PluginOpOptions{ClaudeDir: claudeDir})
+	if err == nil || !strings.Contains(err.Error(), "claudeup plugin install") {
+		t.Errorf("expected install hint, got %v", err)
+	}
+}
+
+func TestProfileAddRemovePlugin(t *testing.T) {
+	p := &Profile{Name: "legacy", Plugins: []string{"a@mp"}}
+
+	changed, err := p.AddPlugin("user", "b@mp")
+	if err != nil || !changed {
+		t.Fatalf("AddPlugin user: changed=%v err=%v", changed, err)
+	}
+	if p.PerScope != nil || !slices.Equal(p.Plugins, []string{"a@mp", "b@mp"}) {
+		t.Errorf("legacy user-scope add should edit flat plugins, got %+v", p)
+	}
+
+	changed, _ = p.AddPlugin("user", "b@mp")
+	if changed {
+		t.Error("adding a duplicate should report no change")
+	}
+
+	// Adding at project scope lifts flat fields into PerScope.User
+	if _, err := p.AddPlugin("project", "c@mp"); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Plugins) != 0 || p.PerScope == nil || p.PerScope.User == nil {
+		t.Fatalf("expected flat plugins lifted into PerScope.User, got %+v", p)
+	}
+	if !slices.Equal(p.PerScope.User.Plugins, []string{"a@mp", "b@mp"}) {
+		t.Errorf("user plugins = %v", p.PerScope.User.Plugins)
+	}
+	if !slices.Equal(p.PerScope.Project.Plugins, []string{"c@mp"}) {
+		t.Errorf("project plugins = %v", p.PerScope.Project.Plugins)
+	}
+
+	changed, _ = p.RemovePlugin("user", "a@mp")
+	if !changed || !slices.Equal(p.PerScope.User.Plugins, []string{"b@mp"}) {
+		t.Errorf("RemovePlugin user: changed=%v plugins=%v", changed, p.PerScope.User.Plugins)
+	}
+	changed, _ = p.RemovePlugin("local", "a@mp")
+	if changed {
+		t.Error("removing an absent plugin should report no change")
+	}
+}
+
+func TestProfileScopedSaveKeepsFlatSettingsAndMemory(t *testing.T) {
+	profilesDir := t.TempDir()
+	flat := &Profile{
+		Name:     "legacy",
+		Plugins:  []string{"a@mp"},
+		Settings: &SettingsBlock{Model: "opus", Env: map[string]string{"DEBUG": "1"}},
+		Memory:   []string{"go-style"},
+	}
+	if err := Save(profilesDir, flat); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Load(profilesDir, "legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.AddPlugin("project", "c@mp"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(profilesDir, p); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := Load(profilesDir, "legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Settings != nil || saved.Memory != nil {
+		t.Errorf("flat settings and memory should be lifted, got %+v %v", saved.Settings, saved.Memory)
+	}
+	user := saved.ForScope("user")
+	if user.Settings == nil || user.Settings.Model != "opus" || user.Settings.Env["DEBUG"] != "1" {
+		t.Errorf("user-scope settings lost after scoped save: %+v", user.Settings)
+	}
+	if !slices.Equal(user.Memory, []string{"go-style"}) {
+		t.Errorf("user-scope memory lost after scoped save: %v", user.Memory)
+	}
+	if got := saved.AsPerScope().PerScope.User.Settings; got == nil || got.Model != "opus" {
+		t.Errorf("AsPerScope user settings = %+v", got)
+	}
+}
+
+func TestProfileAddPluginRejectsStack(t *testing.T) {
+	p := &Profile{Name: "stack", Includes: []string{"base"}}
+	if _, err := p.AddPlugin("user", "a@mp"); err == nil {
+		t.Error("expected error when adding plugins to a stack profile")
+	}
+}
+
+func TestProfileAddMarketplaceDedupes(t *testing.T) {
+	p := &Profile{Marketplaces: []Marketplace{{Source: "github", Repo: "owner/repo"}}}
+	if p.AddMarketplace(Marketplace{Source: "github", Repo: "owner/repo"}) {
+		t.Error("duplicate marketplace should not be added")
+	}
+	if !p.AddMarketplace(Marketplace{Source: "git", URL: "https://example.com/mp.git"}) {
+		t.Error("new marketplace should be added")
+	}
+	if len(p.Marketplaces) != 2 {
+		t.Errorf("expected 2 marketplaces, got %d", len(p.Marketplaces))
+	}
+}
@@ -9,17 +9,19 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 
 	"github.com/claudeup/claudeup/v5/internal/events"
+	"github.com/claudeup/claudeup/v5/internal/ext"
 )
 
 // AmbiguousProfileError is returned when a profile name matches multiple files
 // in the profiles directory (e.g. both "profiles/api.json" and "profiles/backend/api.json").
 type AmbiguousProfileError struct {
 	Name  string   // the profile name that was searched for
-	Paths []string // relative paths of all matching profiles (forward-slash separated, without .json)
+	Paths []string // relative paths of all matching profiles (forward-slash separated, without extension)
 }
 
 func (e *AmbiguousProfileError) Error() string {
@@ -29,6 +31,12 @@ func (e *AmbiguousProfileError) Error() string {
 
 // Profile represents a Claude Code configuration profile
 type Profile struct {
+	// Schema is the optional "$schema" URL editors use for autocompletion.
+	Schema string `json:"$schema,omitempty"`
+	// SchemaVersion is the profile format version. Loaded profiles are
+	// migrated to CurrentSchemaVersion; saved profiles are stamped with it.
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+
 	Name           string         `json:"name"`
 	Description    string         `json:"description,omitempty"`
 	Includes       []string       `json:"includes,omitempty"`
@@ -49,6 +57,18 @@ type Profile struct {
 
 	// SettingsHooks contains hooks to merge into settings.json by event type
 	SettingsHooks map[string][]HookEntry `json:"settingsHooks,omitempty"`
+
+	// Settings contains other settings.json options (permissions, env, model, ...).
+	// Like the flat plugin list, it applies to the scope the profile is applied at.
+	Settings *SettingsBlock `json:"settings,omitempty"`
+
+	// Memory names CLAUDE.md fragments from the extension library to compose
+	// into the CLAUDE.md of the scope the profile is applied at.
+	Memory []string `json:"memory,omitempty"`
+
+	// Merge declares, per field, how a stack merges its includes: override,
+	// error, append, or remove. Only stacks may declare merge rules.
+	Merge map[string]MergeRule `json:"merge,omitempty"`
 }
 
 // PerScopeSettings organizes configuration by scope level.
@@ -64,6 +84,8 @@ type ScopeSettings struct {
 	Plugins    []string           `json:"plugins,omitempty"`
 	MCPServers []MCPServer        `json:"mcpServers,omitempty"`
 	Extensions *ExtensionSettings `json:"extensions,omitempty"`
+	Settings   *SettingsBlock     `json:"settings,omitempty"`
+	Memory     []string           `json:"memory,omitempty"`
 }
 
 // IsMultiScope returns true if this profile uses per-scope settings.
@@ -91,6 +113,8 @@ func (p *Profile) HasConfigFields() bool {
 		p.PerScope != nil ||
 		p.Extensions != nil ||
 		len(p.SettingsHooks) > 0 ||
+		p.Settings != nil ||
+		len(p.Memory) > 0 ||
 		len(p.Detect.Files) > 0 ||
 		len(p.Detect.Contains) > 0 ||
 		p.PostApply != nil ||
@@ -194,6 +218,7 @@ func (p *Profile) CombinedScopes() *Profile {
 			result.Extensions.Hooks = mergeStringSlice(result.Extensions.Hooks, scope.Extensions.Hooks)
 			result.Extensions.Rules = mergeStringSlice(result.Extensions.Rules, scope.Extensions.Rules)
 			result.Extensions.OutputStyles = mergeStringSlice(result.Extensions.OutputStyles, scope.Extensions.OutputStyles)
+			result.Extensions.Overrides = mergeOverrides(result.Extensions.Overrides, scope.Extensions.Overrides)
 		}
 	}
 
@@ -220,6 +245,8 @@ func (p *Profile) ForScope(scope string) *Profile {
 		if scope == "user" {
 			result.Plugins = p.Plugins
 			result.MCPServers = p.MCPServers
+			result.Settings = p.Settings
+			result.Memory = p.Memory
 		}
 		return result
 	}
@@ -238,6 +265,8 @@ func (p *Profile) ForScope(scope string) *Profile {
 		result.Plugins = settings.Plugins
 		result.MCPServers = settings.MCPServers
 		result.Extensions = settings.Extensions
+		result.Settings = settings.Settings
+		result.Memory = settings.Memory
 	}
 
 	return result
@@ -260,11 +289,13 @@ func (p *Profile) FilterToScope(scope string) {
 		p.PerScope.Local = nil
 		// Marketplaces are user-scoped; only keep those referenced by remaining plugins
 		p.filterMarketplacesToPlugins()
+		p.SettingsHooks = nil
 	case "local":
 		p.PerScope.User = nil
 		p.PerScope.Project = nil
 		// Marketplaces are user-scoped; only keep those referenced by remaining plugins
 		p.filterMarketplacesToPlugins()
+		p.SettingsHooks = nil
 	}
 	// Clear flat fields that may have been populated
 	p.Plugins = nil
@@ -324,13 +355,22 @@ func (p *Profile) ValidateMarketplaceRefs(registryKeys []string) error {
 //   - "always" (default): Hook runs every time the profile is applied
 //   - "first-run": Hook only runs if no plugins from the profile's marketplaces
 //     are currently enabled
+//   - "on-change": Hook only runs if the profile's content changed since the
+//     hook last succeeded
 //
-// Security note: Hooks execute arbitrary shell commands. Only use profiles
-// from trusted sources.
+// Hooks run in the profile's directory with a filtered environment and a
+// timeout, and each run is recorded in the events log (see RunHook).
+//
+// Security note: Hooks execute arbitrary shell commands. Unless a profile is
+// built in or signed by a trusted key, the trust policy decides whether it
+// is applied (see CheckTrust).
 type PostApplyHook struct {
-	Script    string `json:"script,omitempty"`    // Script path relative to profile (takes precedence)
-	Command   string `json:"command,omitempty"`   // Direct command to run (used if Script is empty)
-	Condition string `json:"condition,omitempty"` // "always" (default) or "first-run"
+	Script    string   `json:"script,omitempty"`    // Script path relative to profile (takes precedence)
+	Command   string   `json:"command,omitempty"`   // Direct command to run (used if Script is empty)
+	Condition string   `json:"condition,omitempty"` // "always" (default), "first-run", or "on-change"
+	Timeout   int      `json:"timeout,omitempty"`   // seconds; DefaultHookTimeout when unset
+	Env       []string `json:"env,omitempty"`       // Extra environment variables passed to the hook when the profile is trusted
+	Sandbox   bool     `json:"sandbox,omitempty"`   // Isolate the hook; it is refused where no sandbox is available
 }
 
 // MCPServer represents an MCP server configuration
@@ -347,14 +387,28 @@ type Marketplace struct {
 	Source string `json:"source"`
 	Repo   string `json:"repo,omitempty"` // Used for github sources
 	URL    string `json:"url,omitempty"`  // Used for git sources
+	Path   string `json:"path,omitempty"` // Used for directory sources
+	Ref    string `json:"ref,omitempty"`  // Branch, tag, or commit for github and git sources
 }
 
-// DisplayName returns the repo or URL for display purposes
-func (m Marketplace) DisplayName() string {
-	if m.Repo != "" {
+// Location returns the repo, URL, or directory path the marketplace comes from
+func (m Marketplace) Location() string {
+	switch {
+	case m.Repo != "":
 		return m.Repo
+	case m.URL != "":
+		return m.URL
+	}
+	return m.Path
+}
+
+// DisplayName returns the location, with "#ref" appended when a ref is set
+func (m Marketplace) DisplayName() string {
+	loc := m.Location()
+	if loc != "" && m.Ref != "" {
+		return loc + "#" + m.Ref
 	}
-	return m.URL
+	return loc
 }
 
 // SecretRef defines a secret requirement with multiple resolution sources
@@ -378,96 +432,26 @@ type DetectRules struct {
 	Contains map[string]string `json:"contains,omitempty"`
 }
 
-// profileJSON is the raw JSON shape used for unmarshaling profiles.
-// It accepts both the old "localItems" field and the new "extensions" field.
-type profileJSON struct {
-	Name           string                 `json:"name"`
-	Description    string                 `json:"description,omitempty"`
-	Includes       []string               `json:"includes,omitempty"`
-	MCPServers     []MCPServer            `json:"mcpServers,omitempty"`
-	Marketplaces   []Marketplace          `json:"marketplaces,omitempty"`
-	Plugins        []string               `json:"plugins,omitempty"`
-	SkipPluginDiff bool                   `json:"skipPluginDiff,omitempty"`
-	Detect         DetectRules            `json:"detect,omitempty"`
-	PostApply      *PostApplyHook         `json:"postApply,omitempty"`
-	PerScope       *perScopeSettingsJSON  `json:"perScope,omitempty"`
-	Extensions     *ExtensionSettings     `json:"extensions,omitempty"`
-	LocalItems     *ExtensionSettings     `json:"localItems,omitempty"` // deprecated field
-	SettingsHooks  map[string][]HookEntry `json:"settingsHooks,omitempty"`
-}
+// rawProfile has Profile's fields without its UnmarshalJSON, so the
+// migrated document can be decoded with the default rules.
+type rawProfile Profile
 
-// perScopeSettingsJSON is the raw JSON shape for per-scope settings,
-// accepting both "localItems" and "extensions" in each scope.
-type perScopeSettingsJSON struct {
-	User    *scopeSettingsJSON `json:"user,omitempty"`
-	Project *scopeSettingsJSON `json:"project,omitempty"`
-	Local   *scopeSettingsJSON `json:"local,omitempty"`
-}
-
-// scopeSettingsJSON is the raw JSON shape for a single scope,
-// accepting both "localItems" and "extensions".
-type scopeSettingsJSON struct {
-	Plugins    []string           `json:"plugins,omitempty"`
-	MCPServers []MCPServer        `json:"mcpServers,omitempty"`
-	Extensions *ExtensionSettings `json:"extensions,omitempty"`
-	LocalItems *ExtensionSettings `json:"localItems,omitempty"` // deprecated field
-}
-
-// UnmarshalJSON handles migration from the old "localItems" JSON field
-// to the new "extensions" field. Profiles saved before the rename used
-// "localItems"; this ensures they load correctly.
+// UnmarshalJSON upgrades the document to CurrentSchemaVersion through the
+// migration chain before decoding it, so profiles written by older
+// releases (such as those using "localItems") load unchanged.
 func (p *Profile) UnmarshalJSON(data []byte) error {
-	var raw profileJSON
-	if err := json.Unmarshal(data, &raw); err != nil {
+	migrated, err := migrateProfileJSON(data)
+	if err != nil {
 		return err
 	}
-
-	p.Name = raw.Name
-	p.Description = raw.Description
-	p.Includes = raw.Includes
-	p.MCPServers = raw.MCPServers
-	p.Marketplaces = raw.Marketplaces
-	p.Plugins = raw.Plugins
-	p.SkipPluginDiff = raw.SkipPluginDiff
-	p.Detect = raw.Detect
-	p.PostApply = raw.PostApply
-	p.SettingsHooks = raw.SettingsHooks
-
-	// Migrate top-level localItems → extensions
-	p.Extensions = raw.Extensions
-	if p.Extensions == nil && raw.LocalItems != nil {
-		p.Extensions = raw.LocalItems
-	}
-
-	// Migrate per-scope settings
-	if raw.PerScope != nil {
-		p.PerScope = &PerScopeSettings{
-			User:    migrateScopeSettings(raw.PerScope.User),
-			Project: migrateScopeSettings(raw.PerScope.Project),
-			Local:   migrateScopeSettings(raw.PerScope.Local),
-		}
+	var raw rawProfile
+	if err := json.Unmarshal(migrated, &raw); err != nil {
+		return err
 	}
-
+	*p = Profile(raw)
 	return nil
 }
 
-// migrateScopeSettings converts a scopeSettingsJSON (with possible old
-// "localItems" field) into a ScopeSettings with the canonical "extensions" field.
-func migrateScopeSettings(raw *scopeSettingsJSON) *ScopeSettings {
-	if raw == nil {
-		return nil
-	}
-	s := &ScopeSettings{
-		Plugins:    raw.Plugins,
-		MCPServers: raw.MCPServers,
-		Extensions: raw.Extensions,
-	}
-	if s.Extensions == nil && raw.LocalItems != nil {
-		s.Extensions = raw.LocalItems
-	}
-	return s
-}
-
 // ExtensionSettings contains extension patterns to enable.
 // These are items from ~/.claudeup/ext/ that get symlinked to ~/.claude/
 type ExtensionSettings struct {
@@ -477,12 +461,22 @@ type ExtensionSettings struct {
 	Hooks        []string `json:"hooks,omitempty"`
 	Rules        []string `json:"rules,omitempty"`
 	OutputStyles []string `json:"output-styles,omitempty"`
+
+	// Overrides maps category -> item -> frontmatter patch for items that
+	// are applied as variants. In profile JSON they are written inline as
+	// {"name": "reviewer", "set": {"model": "opus"}} entries.
+	Overrides map[string]map[string]ext.FrontmatterPatch `json:"-"`
 }
 
-// HookEntry represents a single hook configuration for settings.json
+// HookEntry represents a single hook configuration for settings.json.
+// Entries with the same Matcher are grouped under one matcher entry for
+// their event type; an empty Matcher applies to every tool.
 type HookEntry struct {
+	Matcher string `json:"matcher,omitempty"`
 	Type    string `json:"type"`
-	Command string `json:"command"`
+	Command string `json:"command,omitempty"`
+	Prompt  string `json:"prompt,omitempty"`
+	Timeout int    `json:"timeout,omitempty"` // seconds
 }
 
 // PreserveMCPSecrets restores $VAR references and Secrets metadata from an
@@ -594,20 +588,31 @@ func (p *Profile) PreserveFrom(existing *Profile) {
 	p.Extensions = existing.Extensions
 }
 
-// Save writes a profile to the profiles directory
+// Save writes a profile to the profiles directory, updating the root file
+// of that name in whichever format it is in, or creating a .json file.
 func Save(profilesDir string, p *Profile) error {
-	profilePath := filepath.Join(profilesDir, p.Name+".json")
+	return SaveToPath(RootProfilePath(profilesDir, p.Name), p)
+}
 
+// SaveToPath writes a profile to an explicit file path, such as one returned
+// by FindProfilePaths for a profile nested in a subdirectory. The format
+// follows the extension; when the file already exists as JSONC or YAML,
+// its comments are kept.
+func SaveToPath(profilePath string, p *Profile) error {
 	if err := os.MkdirAll(filepath.Dir(profilePath), 0755); err != nil {
 		return err
 	}
 
-	data, err := json.MarshalIndent(p, "", "  ")
+	format := FormatForPath(profilePath)
+	if format == "" {
+		format = FormatJSON
+	}
+	stamped := *p
+	stamped.SchemaVersion = CurrentSchemaVersion
+	data, err := encodeProfile(&stamped, format, fileComments(profilePath, format))
 	if err != nil {
 		return err
 	}
-	// Ensure trailing newline (POSIX text file convention)
-	data = append(data, '\n')
 
 	// Wrap file write with event tracking
 	return events.GlobalTracker().RecordFileWrite(
@@ -622,7 +627,7 @@ func Save(profilesDir string, p *Profile) error {
 
 // Load reads a profile from the profiles directory.
 // If name contains "/", it is treated as a relative path within profilesDir.
-// Otherwise, profilesDir is searched recursively for a matching .json file.
+// Otherwise, profilesDir is searched recursively for a matching profile file.
 // Returns an error if the name matches multiple profiles (ambiguous).
 func Load(profilesDir, name string) (*Profile, error) {
 	paths, err := FindProfilePaths(profilesDir, name)
@@ -643,7 +648,7 @@ func Load(profilesDir, name string) (*Profile, error) {
 			if err != nil {
 				rel = p
 			}
-			relPaths = append(relPaths, strings.TrimSuffix(filepath.ToSlash(rel), ".json"))
+			relPaths = append(relPaths, TrimProfileExt(filepath.ToSlash(rel)))
 		}
 		return nil, &AmbiguousProfileError{Name: name, Paths: relPaths}
 	}
@@ -658,15 +663,16 @@ type ProfileEntry struct {
 
 // DisplayName returns the profile's display name for listing.
 // For root profiles, this is just the profile name.
-// For nested profiles, this is the relative path without the .json extension.
+// For nested profiles, this is the relative path without the file extension.
 func (e ProfileEntry) DisplayName() string {
-	return strings.TrimSuffix(e.RelPath, ".json")
+	return TrimProfileExt(e.RelPath)
 }
 
 // FindProfilePaths walks profilesDir recursively and returns absolute paths
-// to .json files whose filename stem matches name.
+// to profile files (.json, .jsonc, .yaml, .yml) whose filename stem matches name.
 // If name contains a "/", it is treated as a relative path reference:
-// only profilesDir/name.json is checked (after validating the path stays within profilesDir).
+// only profilesDir/name with each extension is checked (after validating the
+// path stays within profilesDir).
 // Returns an empty slice (not an error) if profilesDir does not exist.
 // The profilesDir argument is resolved to an absolute path internally.
 func FindProfilePaths(profilesDir, name string) ([]string, error) {
@@ -681,15 +687,18 @@ func FindProfilePaths(profilesDir, name string) ([]string, error) {
 	if strings.Contains(name, "/") {
 		// Normalize to OS-specific separators for correct filepath operations
 		name = filepath.FromSlash(name)
-		target := filepath.Clean(filepath.Join(profilesDir, name+".json"))
+		target := filepath.Clean(filepath.Join(profilesDir, name))
 		// Validate the resolved path stays within profilesDir to prevent traversal
-		if target != profilesDir && !strings.HasPrefix(target, profilesDir+string(filepath.Separator)) {
+		if target == profilesDir || !strings.HasPrefix(target, profilesDir+string(filepath.Separator)) {
 			return nil, fmt.Errorf("invalid profile path %q: escapes profiles directory", name)
 		}
-		if _, err := os.Stat(target); err == nil {
-			return []string{target}, nil
+		matches := []string{}
+		for _, ext := range profileExtensions {
+			if _, err := os.Stat(target + ext); err == nil {
+				matches = append(matches, target+ext)
+			}
 		}
-		return []string{}, nil
+		return matches, nil
 	}
 
 	// Name-based search: walk recursively
@@ -708,11 +717,10 @@ func FindProfilePaths(profilesDir, name string) ([]string, error) {
 		if d.IsDir() {
 			return nil
 		}
-		if !strings.HasSuffix(d.Name(), ".json") {
+		if FormatForPath(d.Name()) == "" {
 			return nil
 		}
-		stem := strings.TrimSuffix(d.Name(), ".json")
-		if stem == name {
+		if TrimProfileExt(d.Name()) == name {
 			matches = append(matches, path)
 		}
 		return nil
@@ -725,22 +733,27 @@ func FindProfilePaths(profilesDir, name string) ([]string, error) {
 	return matches, nil
 }
 
-// LoadFromPath loads a profile from an absolute file path.
-// If the JSON does not contain a name field, the name is derived from the filename.
+// LoadFromPath loads a profile from an absolute file path, in the format
+// its extension names (JSON for unrecognised extensions).
+// If the document does not contain a name field, the name is derived from the filename.
 func LoadFromPath(path string) (*Profile, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
+	format := FormatForPath(path)
+	if format == "" {
+		format = FormatJSON
+	}
 	var p Profile
-	if err := json.Unmarshal(data, &p); err != nil {
+	if err := decodeProfile(data, format, &p); err != nil {
 		return nil, err
 	}
 
-	// Set name from filename if not present in JSON
+	// Set name from filename if not present in the document
 	if p.Name == "" {
-		p.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+		p.Name = TrimProfileExt(filepath.Base(path))
 	}
 
 	return &p, nil
@@ -775,7 +788,7 @@ func List(profilesDir string) ([]ProfileEntry, error) {
 		if d.IsDir() {
 			return nil
 		}
-		if !strings.HasSuffix(d.Name(), ".json") {
+		if FormatForPath(d.Name()) == "" {
 			return nil
 		}
 
@@ -815,9 +828,9 @@ type ProfileWithSource struct {
 
 // DisplayName returns the profile's display name for listing.
 // For root profiles, this is just the profile name.
-// For nested profiles, this is the relative path without the .json extension.
+// For nested profiles, this is the relative path without the file extension.
 func (p *ProfileWithSource) DisplayName() string {
-	return strings.TrimSuffix(p.RelPath, ".json")
+	return TrimProfileExt(p.RelPath)
 }
 
 // ListAll returns profiles from both user and project directories.
@@ -929,6 +942,13 @@ func (p *Profile) Clone(newName string) *Profile {
 		}
 	}
 
+	clone.Settings = cloneSettingsBlock(p.Settings)
+	clone.SettingsHooks = cloneSettingsHooks(p.SettingsHooks)
+	if len(p.Memory) > 0 {
+		clone.Memory = append([]string(nil), p.Memory...)
+	}
+	clone.Merge = cloneMergeRules(p.Merge)
+
 	// Deep copy PerScope
 	if p.PerScope != nil {
 		clone.PerScope = &PerScopeSettings{}
@@ -993,6 +1013,22 @@ func (p *Profile) Equal(other *Profile) bool {
 		return false
 	}
 
+	if !settingsBlockEqual(p.Settings, other.Settings) {
+		return false
+	}
+
+	if !settingsHooksEqual(p.SettingsHooks, other.SettingsHooks) {
+		return false
+	}
+
+	if !strSlicesEqual(p.Memory, other.Memory) {
+		return false
+	}
+
+	if !mergeRulesEqual(p.Merge, other.Merge) {
+		return false
+	}
+
 	// Compare PerScope
 	if !perScopeSettingsEqual(p.PerScope, other.PerScope) {
 		return false
@@ -1137,7 +1173,10 @@ func postApplyHookPtrEqual(a, b *PostApplyHook) bool {
 	}
 	return a.Script == b.Script &&
 		a.Command == b.Command &&
-		a.Condition == b.Condition
+		a.Condition == b.Condition &&
+		a.Timeout == b.Timeout &&
+		slices.Equal(a.Env, b.Env) &&
+		a.Sandbox == b.Sandbox
 }
 
 // GenerateDescription creates a human-readable description of the profile contents
@@ -1227,6 +1266,10 @@ func cloneScopeSettings(s *ScopeSettings) *ScopeSettings {
 	if s.Extensions != nil {
 		clone.Extensions = cloneExtensionSettings(s.Extensions)
 	}
+	clone.Settings = cloneSettingsBlock(s.Settings)
+	if len(s.Memory) > 0 {
+		clone.Memory = append([]string(nil), s.Memory...)
+	}
 	return clone
 }
 
@@ -1260,6 +1303,7 @@ func cloneExtensionSettings(l *ExtensionSettings) *ExtensionSettings {
 		clone.OutputStyles = make([]string, len(l.OutputStyles))
 		copy(clone.OutputStyles, l.OutputStyles)
 	}
+	clone.Overrides = cloneOverrides(l.Overrides)
 	return clone
 }
 
@@ -1300,6 +1344,12 @@ func scopeSettingsEqual(a, b *ScopeSettings) bool {
 	if !extensionSettingsEqual(a.Extensions, b.Extensions) {
 		return false
 	}
+	if !settingsBlockEqual(a.Settings, b.Settings) {
+		return false
+	}
+	if !strSlicesEqual(a.Memory, b.Memory) {
+		return false
+	}
 	return true
 }
 
@@ -1316,5 +1366,6 @@ func extensionSettingsEqual(a, b *ExtensionSettings) bool {
 		strSlicesEqual(a.Skills, b.Skills) &&
 		strSlicesEqual(a.Hooks, b.Hooks) &&
 		strSlicesEqual(a.Rules, b.Rules) &&
-		strSlicesEqual(a.OutputStyles, b.OutputStyles)
+		strSlicesEqual(a.OutputStyles, b.OutputStyles) &&
+		overridesEqual(a.Overrides, b.Overrides)
 }
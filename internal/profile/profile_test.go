@@ -821,6 +821,15 @@ func TestProfile_Equal_PostApplyHook_DifferentCondition(t *testing.T) {
 	}
 }
 
+func TestProfile_Equal_PostApplyHook_DifferentSandbox(t *testing.T) {
+	p1 := &Profile{Name: "test", PostApply: &PostApplyHook{Command: "echo", Timeout: 30}}
+	p2 := &Profile{Name: "test", PostApply: &PostApplyHook{Command: "echo", Timeout: 30, Sandbox: true}}
+
+	if p1.Equal(p2) {
+		t.Error("Profiles with different PostApply sandbox settings should not be equal")
+	}
+}
+
 func TestProfile_Equal_PostApplyHook_Identical(t *testing.T) {
 	p1 := &Profile{Name: "test", PostApply: &PostApplyHook{
 		Script:    "setup.sh",
@@ -1545,7 +1554,7 @@ func TestFindProfilePaths_MissingDir(t *testing.T) {
 	}
 }
 
-func TestFindProfilePaths_IgnoresNonJSON(t *testing.T) {
+func TestFindProfilePaths_IgnoresNonProfileFiles(t *testing.T) {
 	tmpDir := t.TempDir()
 	profilesDir := filepath.Join(tmpDir, "profiles")
 
@@ -1553,12 +1562,12 @@ func TestFindProfilePaths_IgnoresNonJSON(t *testing.T) {
 		t.Fatalf("Failed to create profiles dir: %v", err)
 	}
 
-	// Create non-JSON files with the same stem
+	// Create files with the same stem in formats profiles don't use
 	if err := os.WriteFile(filepath.Join(profilesDir, "api.txt"), []byte("not json"), 0644); err != nil {
 		t.Fatalf("Failed to write txt file: %v", err)
 	}
-	if err := os.WriteFile(filepath.Join(profilesDir, "api.yaml"), []byte("not json"), 0644); err != nil {
-		t.Fatalf("Failed to write yaml file: %v", err)
+	if err := os.WriteFile(filepath.Join(profilesDir, "api.toml"), []byte("not json"), 0644); err != nil {
+		t.Fatalf("Failed to write toml file: %v", err)
 	}
 
 	paths, err := FindProfilePaths(profilesDir, "api")
@@ -1567,7 +1576,7 @@ func TestFindProfilePaths_IgnoresNonJSON(t *testing.T) {
 	}
 
 	if len(paths) != 0 {
-		t.Errorf("Expected empty slice (non-JSON files should be ignored), got %v", paths)
+		t.Errorf("Expected empty slice (non-profile files should be ignored), got %v", paths)
 	}
 }
 
@@ -50,20 +50,39 @@ func (l *DirLoader) LoadProfile(name string) (*Profile, error) {
 //
 // If the profile has no includes, it is returned as-is.
 func ResolveIncludes(p *Profile, loader ProfileLoader) (*Profile, error) {
+	resolved, _, err := ResolveIncludesWithReport(p, loader)
+	return resolved, err
+}
+
+// ResolveIncludesWithReport resolves includes like ResolveIncludes and also
+// reports the conflicts the merge resolved and where each item came from.
+// Merge rules declared by the stack, or by stacks it includes, decide how
+// conflicts are resolved; the including profile's rule for a field wins.
+// The report is empty for profiles without includes.
+func ResolveIncludesWithReport(p *Profile, loader ProfileLoader) (*Profile, *MergeReport, error) {
 	if p == nil {
-		return nil, fmt.Errorf("cannot resolve includes: profile is nil")
+		return nil, nil, fmt.Errorf("cannot resolve includes: profile is nil")
+	}
+
+	if err := validateMergeRules(p); err != nil {
+		return nil, nil, err
 	}
 
 	if !p.IsStack() {
-		return p, nil
+		return p, &MergeReport{}, nil
 	}
 
 	if loader == nil {
-		return nil, fmt.Errorf("cannot resolve includes for stack profile %q: loader is nil", p.Name)
+		return nil, nil, fmt.Errorf("cannot resolve includes for stack profile %q: loader is nil", p.Name)
 	}
 
 	if err := validatePureStack(p); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	rules := cloneMergeRules(p.Merge)
+	if rules == nil {
+		rules = make(map[string]MergeRule)
 	}
 
 	// Collect all leaf profiles in include order
@@ -72,6 +91,7 @@ func ResolveIncludes(p *Profile, loader ProfileLoader) (*Profile, error) {
 	var visitingPath []string
 
 	var leaves []*Profile
+	var leafNames []string
 	var collectErr error
 
 	collectLeaves := func(name string) {}
@@ -121,6 +141,15 @@ func ResolveIncludes(p *Profile, loader ProfileLoader) (*Profile, error) {
 				collectErr = fmt.Errorf("included profile %q: %w", name, err)
 				return
 			}
+			if err := validateMergeRules(included); err != nil {
+				collectErr = fmt.Errorf("included profile %q: %w", name, err)
+				return
+			}
+			for field, rule := range included.Merge {
+				if _, ok := rules[field]; !ok {
+					rules[field] = rule
+				}
+			}
 			for _, sub := range included.Includes {
 				collectLeaves(sub)
 				if collectErr != nil {
@@ -128,7 +157,12 @@ func ResolveIncludes(p *Profile, loader ProfileLoader) (*Profile, error) {
 				}
 			}
 		} else {
+			if err := validateMergeRules(included); err != nil {
+				collectErr = fmt.Errorf("included profile %q: %w", name, err)
+				return
+			}
 			leaves = append(leaves, included)
+			leafNames = append(leafNames, name)
 		}
 
 		resolved[name] = included
@@ -137,16 +171,19 @@ func ResolveIncludes(p *Profile, loader ProfileLoader) (*Profile, error) {
 	for _, name := range p.Includes {
 		collectLeaves(name)
 		if collectErr != nil {
-			return nil, collectErr
+			return nil, nil, collectErr
 		}
 	}
 
-	result := mergeProfiles(leaves)
+	result, report, err := mergeLeaves(leaves, leafNames, rules)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to merge includes of %q: %w", p.Name, err)
+	}
 	result.Name = p.Name
 	result.Description = p.Description
 	result.Includes = nil
 
-	return result, nil
+	return result, report, nil
 }
 
 // validatePureStack checks that a stack profile has no config fields alongside includes.
@@ -174,6 +211,8 @@ func mergeProfile(dst, src *Profile) {
 	mergeFlatMCPServers(dst, src)
 	mergeExtensions(dst, src)
 	mergeSettingsHooks(dst, src)
+	dst.Settings = mergeSettingsBlock(dst.Settings, src.Settings)
+	dst.Memory = mergeStringSlice(dst.Memory, src.Memory)
 	mergeDetect(dst, src)
 
 	// SkipPluginDiff: OR semantics
@@ -240,7 +279,7 @@ func mergePerScope(dst, src *Profile) {
 }
 
 // mergeScopeSettings merges plugins (union, dedup), MCP servers (last-wins by name),
-// and extensions (union, dedup per category).
+// extensions (union, dedup per category), the settings block, and memory fragments.
 func mergeScopeSettings(dst, src *ScopeSettings) {
 	dst.Plugins = mergeStringSlice(dst.Plugins, src.Plugins)
 
@@ -269,7 +308,11 @@ func mergeScopeSettings(dst, src *ScopeSettings) {
 		dst.Extensions.Hooks = mergeStringSlice(dst.Extensions.Hooks, src.Extensions.Hooks)
 		dst.Extensions.Rules = mergeStringSlice(dst.Extensions.Rules, src.Extensions.Rules)
 		dst.Extensions.OutputStyles = mergeStringSlice(dst.Extensions.OutputStyles, src.Extensions.OutputStyles)
+		dst.Extensions.Overrides = mergeOverrides(dst.Extensions.Overrides, src.Extensions.Overrides)
 	}
+
+	dst.Settings = mergeSettingsBlock(dst.Settings, src.Settings)
+	dst.Memory = mergeStringSlice(dst.Memory, src.Memory)
 }
 
 // mergeFlatPlugins unions legacy flat plugins with dedup.
@@ -313,9 +356,11 @@ func mergeExtensions(dst, src *Profile) {
 	dst.Extensions.Hooks = mergeStringSlice(dst.Extensions.Hooks, src.Extensions.Hooks)
 	dst.Extensions.Rules = mergeStringSlice(dst.Extensions.Rules, src.Extensions.Rules)
 	dst.Extensions.OutputStyles = mergeStringSlice(dst.Extensions.OutputStyles, src.Extensions.OutputStyles)
+	dst.Extensions.Overrides = mergeOverrides(dst.Extensions.Overrides, src.Extensions.Overrides)
 }
 
-// mergeSettingsHooks unions hooks per event type, deduplicating by command.
+// mergeSettingsHooks unions hooks per event type, deduplicating by matcher
+// and command (or prompt).
 func mergeSettingsHooks(dst, src *Profile) {
 	if len(src.SettingsHooks) == 0 {
 		return
@@ -329,12 +374,12 @@ func mergeSettingsHooks(dst, src *Profile) {
 		existing := dst.SettingsHooks[event]
 		seen := make(map[string]bool)
 		for _, h := range existing {
-			seen[h.Command] = true
+			seen[h.key()] = true
 		}
 		for _, h := range srcHooks {
-			if !seen[h.Command] {
+			if !seen[h.key()] {
 				existing = append(existing, h)
-				seen[h.Command] = true
+				seen[h.key()] = true
 			}
 		}
 		dst.SettingsHooks[event] = existing
@@ -0,0 +1,52 @@
+//go:build linux
+
+// ABOUTME: Linux isolation for post-apply hooks, using bwrap when installed and namespaces otherwise
+// ABOUTME: bwrap gives a read-only filesystem and no network; bare namespaces only separate processes, so RunHook treats them as unsandboxed
+package profile
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// isolatedCommand builds a command that runs args isolated from the host.
+// With bwrap the whole filesystem, dir included, is read-only; the hook can
+// only write to a private /tmp and has no network. Without it, the hook gets its own user, PID, IPC, UTS, and mount
+// namespaces; it can still write wherever the user can, so RunHook only
+// uses this mode when unsandboxed hooks are allowed.
+func isolatedCommand(ctx context.Context, args []string, dir string) (*exec.Cmd, string) {
+	if bwrap, err := exec.LookPath("bwrap"); err == nil {
+		return exec.CommandContext(ctx, bwrap, bwrapArgs(args, dir)...), SandboxBwrap
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID |
+			syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}},
+	}
+	return cmd, SandboxNamespaces
+}
+
+// bwrapArgs returns the bwrap arguments that run args in dir. dir is
+// read-only: for saved profiles it is the profiles directory, which holds
+// other profiles and their signatures. The hook's scratch space is a
+// private /tmp, which TMPDIR points at.
+func bwrapArgs(args []string, dir string) []string {
+	bwrapArgs := []string{
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+		"--setenv", "TMPDIR", "/tmp",
+	}
+	if dir != "" {
+		// Bound after /tmp so built-in profile scripts extracted there stay visible
+		bwrapArgs = append(bwrapArgs, "--ro-bind", dir, dir, "--chdir", dir)
+	}
+	bwrapArgs = append(bwrapArgs, "--unshare-pid", "--unshare-ipc", "--unshare-uts", "--unshare-net", "--die-with-parent", "--")
+	return append(bwrapArgs, args...)
+}
@@ -0,0 +1,31 @@
+//go:build linux
+
+// ABOUTME: Tests for the bwrap arguments post-apply hooks run under
+// ABOUTME: Checks the profile directory is read-only and the hook gets scratch space and no network
+package profile
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBwrapArgsKeepProfileDirReadOnly(t *testing.T) {
+	args := bwrapArgs([]string{"sh", "setup.sh"}, "/home/u/.claudeup/profiles")
+	joined := strings.Join(args, " ")
+
+	if !strings.Contains(joined, "--ro-bind /home/u/.claudeup/profiles /home/u/.claudeup/profiles --chdir /home/u/.claudeup/profiles") {
+		t.Errorf("profile dir should be bound read-only: %s", joined)
+	}
+	if slices.Contains(args, "--bind") {
+		t.Errorf("nothing on the host should be writable: %s", joined)
+	}
+	for _, want := range []string{"--tmpfs /tmp", "--setenv TMPDIR /tmp", "--unshare-net"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("missing %q: %s", want, joined)
+		}
+	}
+	if !strings.HasSuffix(joined, "-- sh setup.sh") {
+		t.Errorf("command should follow --: %s", joined)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+// ABOUTME: Fallback for platforms without post-apply hook isolation
+// ABOUTME: Hooks that ask for a sandbox are refused by RunHook unless unsandboxed runs are allowed
+package profile
+
+import (
+	"context"
+	"os/exec"
+)
+
+// isolatedCommand runs args directly; isolation is only available on Linux.
+func isolatedCommand(ctx context.Context, args []string, dir string) (*exec.Cmd, string) {
+	return exec.CommandContext(ctx, args[0], args[1:]...), SandboxNone
+}
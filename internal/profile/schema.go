@@ -0,0 +1,225 @@
+// ABOUTME: JSON Schema for profile files, generated from the Profile types by reflection
+// ABOUTME: The same schema drives editor autocompletion and profile validate's key and type checks
+package profile
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/ext"
+)
+
+// SchemaURL is where the published profile JSON Schema is served. Profiles
+// can reference it with "$schema" for editor autocompletion.
+const SchemaURL = "https://claudeup.github.io/claudeup/profile.schema.json"
+
+// schemaNode is the subset of JSON Schema profile files need.
+type schemaNode struct {
+	Type        string // object, array, string, integer, boolean; "" accepts any value
+	Description string
+	Properties  map[string]*schemaNode
+	Additional  *schemaNode // value schema of objects used as maps
+	Items       *schemaNode
+	OneOf       []*schemaNode
+	Enum        []string
+	Deprecated  string // replacement for a deprecated key
+}
+
+// schemaEnums lists the allowed values of string fields, keyed by
+// "TypeName.jsonKey".
+var schemaEnums = map[string][]string{
+	"Marketplace.source":      {MarketplaceSourceGitHub, MarketplaceSourceGit, MarketplaceSourceDirectory},
+	"PostApplyHook.condition": {"always", "first-run", "on-change"},
+	"SecretSource.type":       {"env", "1password", "keychain"},
+	"HookEntry.type":          {"command", "prompt"},
+}
+
+// schemaDescriptions documents the top-level profile keys in the schema.
+var schemaDescriptions = map[string]string{
+	"$schema":        "URL of this schema, for editor autocompletion",
+	"schemaVersion":  "Profile format version",
+	"name":           "Profile name",
+	"description":    "Human-readable description",
+	"includes":       "Profiles this stack composes, merged left to right",
+	"mcpServers":     "MCP servers, applied at the scope the profile is applied at",
+	"marketplaces":   "Plugin marketplaces to register",
+	"plugins":        "Plugins (name@marketplace), applied at the scope the profile is applied at",
+	"skipPluginDiff": "Leave plugins alone when applying",
+	"detect":         "Rules that suggest this profile for a project",
+	"postApply":      "Hook to run after the profile is applied",
+	"perScope":       "Settings for the user, project, and local scopes",
+	"extensions":     "Extensions from the extension library to enable",
+	"settingsHooks":  "Hooks to merge into settings.json, by event",
+	"settings":       "Other settings.json options",
+	"memory":         "CLAUDE.md fragments from the extension library",
+	"merge":          "How a stack merges each field across its includes",
+}
+
+// profileSchema builds the schema of a profile document.
+func profileSchema() *schemaNode {
+	root := schemaForType(reflect.TypeOf(Profile{}))
+	root.Properties["localItems"] = &schemaNode{Type: "object", Deprecated: "extensions"}
+	for _, scope := range root.Properties["perScope"].Properties {
+		scope.Properties["localItems"] = &schemaNode{Type: "object", Deprecated: "extensions"}
+	}
+	for key, description := range schemaDescriptions {
+		if prop, ok := root.Properties[key]; ok {
+			prop.Description = description
+		}
+	}
+	return root
+}
+
+var (
+	extensionSettingsType = reflect.TypeOf(ExtensionSettings{})
+	mergeRuleType         = reflect.TypeOf(MergeRule{})
+)
+
+// schemaForType derives a schema from a Go type and its json tags.
+func schemaForType(t reflect.Type) *schemaNode {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case extensionSettingsType:
+		return extensionSettingsSchema()
+	case mergeRuleType:
+		return mergeRuleSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &schemaNode{Type: "string"}
+	case reflect.Bool:
+		return &schemaNode{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return &schemaNode{Type: "integer"}
+	case reflect.Slice:
+		return &schemaNode{Type: "array", Items: schemaForType(t.Elem())}
+	case reflect.Map:
+		return &schemaNode{Type: "object", Additional: schemaForType(t.Elem())}
+	case reflect.Struct:
+		node := &schemaNode{Type: "object", Properties: make(map[string]*schemaNode)}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			key := jsonKey(field)
+			if key == "" {
+				continue
+			}
+			prop := schemaForType(field.Type)
+			if values, ok := schemaEnums[t.Name()+"."+key]; ok {
+				prop.Enum = values
+			}
+			node.Properties[key] = prop
+		}
+		return node
+	}
+	return &schemaNode{}
+}
+
+// jsonKey returns the JSON key of an exported struct field, or "".
+func jsonKey(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	tag := field.Tag.Get("json")
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// extensionSettingsSchema describes extension lists, whose entries are item
+// names or {"name", "set"} objects.
+func extensionSettingsSchema() *schemaNode {
+	entry := &schemaNode{OneOf: []*schemaNode{
+		{Type: "string"},
+		{Type: "object", Properties: map[string]*schemaNode{
+			"name": {Type: "string"},
+			"set":  {Type: "object", Additional: &schemaNode{}},
+		}},
+	}}
+	node := &schemaNode{Type: "object", Properties: make(map[string]*schemaNode)}
+	for _, category := range ext.AllCategories() {
+		node.Properties[category] = &schemaNode{Type: "array", Items: entry}
+	}
+	return node
+}
+
+// mergeRuleSchema describes a merge rule: a bare strategy or an object.
+func mergeRuleSchema() *schemaNode {
+	strategies := []string{string(MergeOverride), string(MergeError), string(MergeAppend), string(MergeRemove)}
+	return &schemaNode{OneOf: []*schemaNode{
+		{Type: "string", Enum: strategies},
+		{Type: "object", Properties: map[string]*schemaNode{
+			"strategy": {Type: "string", Enum: strategies},
+			"items":    {Type: "array", Items: &schemaNode{Type: "string"}},
+		}},
+	}}
+}
+
+// JSONSchema returns the profile JSON Schema as a JSON-encodable document.
+func JSONSchema() map[string]interface{} {
+	doc := profileSchema().toJSON()
+	doc["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	doc["$id"] = SchemaURL
+	doc["title"] = "claudeup profile"
+	return doc
+}
+
+// toJSON renders the node in JSON Schema form. Objects with properties
+// reject unknown keys so editors flag typos.
+func (n *schemaNode) toJSON() map[string]interface{} {
+	doc := make(map[string]interface{})
+	if n.Type != "" {
+		doc["type"] = n.Type
+	}
+	if n.Description != "" {
+		doc["description"] = n.Description
+	}
+	if n.Deprecated != "" {
+		doc["deprecated"] = true
+		doc["description"] = "Deprecated: use " + n.Deprecated
+	}
+	if len(n.Enum) > 0 {
+		doc["enum"] = n.Enum
+	}
+	if n.Properties != nil {
+		props := make(map[string]interface{}, len(n.Properties))
+		for key, prop := range n.Properties {
+			props[key] = prop.toJSON()
+		}
+		doc["properties"] = props
+		doc["additionalProperties"] = false
+	}
+	if n.Additional != nil {
+		doc["additionalProperties"] = n.Additional.toJSON()
+	}
+	if n.Items != nil {
+		doc["items"] = n.Items.toJSON()
+	}
+	if len(n.OneOf) > 0 {
+		var options []interface{}
+		for _, option := range n.OneOf {
+			options = append(options, option.toJSON())
+		}
+		doc["oneOf"] = options
+	}
+	return doc
+}
+
+// sortedKeys returns the keys of an object schema in order.
+func (n *schemaNode) sortedKeys() []string {
+	keys := make([]string, 0, len(n.Properties))
+	for key := range n.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,116 @@
+// ABOUTME: Profile schema versions and the migration chain that upgrades older profile documents
+// ABOUTME: Each migration rewrites the raw JSON of one version into the next before decoding
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CurrentSchemaVersion is the profile format this release reads and writes.
+//
+// Versions:
+//  1. The original format (profiles without "schemaVersion"), which named
+//     extensions "localItems".
+//  2. "localItems" renamed to "extensions".
+const CurrentSchemaVersion = 2
+
+// schemaMigration upgrades a raw profile document by one version.
+type schemaMigration func(doc map[string]json.RawMessage) error
+
+// schemaMigrations[i] upgrades version i+1 to version i+2.
+var schemaMigrations = []schemaMigration{
+	migrateLocalItems,
+}
+
+// migrateProfileJSON upgrades a profile document to CurrentSchemaVersion.
+// Documents without "schemaVersion" are version 1. Documents from a newer
+// release are rejected rather than silently misread.
+func migrateProfileJSON(data []byte) ([]byte, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return data, nil
+	}
+
+	version, err := documentSchemaVersion(doc)
+	if err != nil {
+		return nil, err
+	}
+	if version == CurrentSchemaVersion {
+		return data, nil
+	}
+	for v := version; v < CurrentSchemaVersion; v++ {
+		if err := schemaMigrations[v-1](doc); err != nil {
+			return nil, fmt.Errorf("failed to migrate profile from schema version %d: %w", v, err)
+		}
+	}
+	doc["schemaVersion"] = json.RawMessage(fmt.Sprint(CurrentSchemaVersion))
+	return json.Marshal(doc)
+}
+
+// documentSchemaVersion reads and checks the "schemaVersion" of a raw document.
+func documentSchemaVersion(doc map[string]json.RawMessage) (int, error) {
+	raw, ok := doc["schemaVersion"]
+	if !ok {
+		return 1, nil
+	}
+	var version int
+	if err := json.Unmarshal(raw, &version); err != nil {
+		return 0, fmt.Errorf("schemaVersion must be an integer: %w", err)
+	}
+	if version < 1 {
+		return 0, fmt.Errorf("invalid schemaVersion %d", version)
+	}
+	if version > CurrentSchemaVersion {
+		return 0, fmt.Errorf("profile uses schema version %d, but this claudeup supports up to %d. Run: claudeup update", version, CurrentSchemaVersion)
+	}
+	return version, nil
+}
+
+// migrateLocalItems renames "localItems" to "extensions" at the top level
+// and in each scope. When both are present, "extensions" wins.
+func migrateLocalItems(doc map[string]json.RawMessage) error {
+	renameKey(doc, "localItems", "extensions")
+
+	raw, ok := doc["perScope"]
+	if !ok {
+		return nil
+	}
+	var scopes map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &scopes); err != nil || scopes == nil {
+		return nil // left for the decoder to report
+	}
+	for name, scopeRaw := range scopes {
+		var scope map[string]json.RawMessage
+		if err := json.Unmarshal(scopeRaw, &scope); err != nil || scope == nil {
+			continue
+		}
+		renameKey(scope, "localItems", "extensions")
+		data, err := json.Marshal(scope)
+		if err != nil {
+			return err
+		}
+		scopes[name] = data
+	}
+	data, err := json.Marshal(scopes)
+	if err != nil {
+		return err
+	}
+	doc["perScope"] = data
+	return nil
+}
+
+// renameKey moves doc[from] to doc[to] unless to is already set.
+func renameKey(doc map[string]json.RawMessage, from, to string) {
+	value, ok := doc[from]
+	if !ok {
+		return
+	}
+	delete(doc, from)
+	if _, exists := doc[to]; !exists {
+		doc[to] = value
+	}
+}
@@ -0,0 +1,497 @@
+// ABOUTME: Typed settings.json block that profiles carry (permissions, env, model, status line)
+// ABOUTME: Applies it with per-key merge or replace semantics, reads it back from live settings, and diffs it
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+)
+
+// SettingsBlock holds the settings.json options a profile manages beyond
+// enabledPlugins and hooks. Scalars always replace the live value. Permission
+// lists are unioned with the live lists and env is merged per variable,
+// unless the key is named in Replace.
+type SettingsBlock struct {
+	Permissions           *PermissionSettings `json:"permissions,omitempty"`
+	Env                   map[string]string   `json:"env,omitempty"`
+	Model                 string              `json:"model,omitempty"`
+	OutputStyle           string              `json:"outputStyle,omitempty"`
+	StatusLine            *StatusLine         `json:"statusLine,omitempty"`
+	Language              string              `json:"language,omitempty"`
+	IncludeCoAuthoredBy   *bool               `json:"includeCoAuthoredBy,omitempty"`
+	AlwaysThinkingEnabled *bool               `json:"alwaysThinkingEnabled,omitempty"`
+	CleanupPeriodDays     *int                `json:"cleanupPeriodDays,omitempty"`
+
+	// Replace names keys whose live value is replaced by the profile's
+	// instead of merged: "env", "permissions", or a single permission list
+	// such as "permissions.allow". A replaced key the profile leaves empty
+	// is cleared.
+	Replace []string `json:"replace,omitempty"`
+}
+
+// PermissionSettings mirrors the permissions object in settings.json.
+type PermissionSettings struct {
+	Allow                 []string `json:"allow,omitempty"`
+	Deny                  []string `json:"deny,omitempty"`
+	Ask                   []string `json:"ask,omitempty"`
+	DefaultMode           string   `json:"defaultMode,omitempty"`
+	AdditionalDirectories []string `json:"additionalDirectories,omitempty"`
+}
+
+// StatusLine mirrors the statusLine object in settings.json.
+type StatusLine struct {
+	Type    string `json:"type"`
+	Command string `json:"command,omitempty"`
+	Padding *int   `json:"padding,omitempty"`
+}
+
+// settingsBlockKeys are the top-level settings.json keys a SettingsBlock covers.
+var settingsBlockKeys = []string{
+	"permissions",
+	"env",
+	"model",
+	"outputStyle",
+	"statusLine",
+	"language",
+	"includeCoAuthoredBy",
+	"alwaysThinkingEnabled",
+	"cleanupPeriodDays",
+}
+
+// permissionListKeys are the permission lists, in settings.json order.
+var permissionListKeys = []string{"allow", "deny", "ask", "additionalDirectories"}
+
+func (p *PermissionSettings) list(key string) []string {
+	if p == nil {
+		return nil
+	}
+	switch key {
+	case "allow":
+		return p.Allow
+	case "deny":
+		return p.Deny
+	case "ask":
+		return p.Ask
+	case "additionalDirectories":
+		return p.AdditionalDirectories
+	}
+	return nil
+}
+
+func (p *PermissionSettings) setList(key string, values []string) {
+	switch key {
+	case "allow":
+		p.Allow = values
+	case "deny":
+		p.Deny = values
+	case "ask":
+		p.Ask = values
+	case "additionalDirectories":
+		p.AdditionalDirectories = values
+	}
+}
+
+// Validate checks that every Replace entry names a mergeable key.
+func (b *SettingsBlock) Validate() error {
+	if b == nil {
+		return nil
+	}
+	for _, key := range b.Replace {
+		valid := key == "env" || key == "permissions"
+		for _, list := range permissionListKeys {
+			if key == "permissions."+list {
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("settings.replace: %q is not a mergeable key (use env, permissions, or permissions.<list>)", key)
+		}
+	}
+	return nil
+}
+
+// replaces reports whether key, or its parent object, is in Replace.
+func (b *SettingsBlock) replaces(key string) bool {
+	for _, r := range b.Replace {
+		if r == key || strings.HasPrefix(key, r+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// scalars returns the block's replace-only keys as decoded JSON values, the
+// same shape claude.Settings holds them in.
+func (b *SettingsBlock) scalars() map[string]interface{} {
+	if b == nil {
+		return nil
+	}
+	fields := decodeJSONObject(b)
+	delete(fields, "permissions")
+	delete(fields, "env")
+	delete(fields, "replace")
+	return fields
+}
+
+// decodeJSONObject round-trips v through JSON into a generic object.
+func decodeJSONObject(v interface{}) map[string]interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// applySettingsBlock writes block into settings. Nothing outside the keys
+// the block mentions is touched.
+func applySettingsBlock(settings *claude.Settings, block *SettingsBlock) error {
+	if block == nil {
+		return nil
+	}
+	if err := block.Validate(); err != nil {
+		return err
+	}
+
+	for key, value := range block.scalars() {
+		settings.Set(key, value)
+	}
+
+	if block.Env != nil || block.replaces("env") {
+		env := make(map[string]interface{})
+		if existing, ok := settings.Get("env"); ok && !block.replaces("env") {
+			if m, ok := existing.(map[string]interface{}); ok {
+				for k, v := range m {
+					env[k] = v
+				}
+			}
+		}
+		for k, v := range block.Env {
+			env[k] = v
+		}
+		if len(env) == 0 {
+			settings.Delete("env")
+		} else {
+			settings.Set("env", env)
+		}
+	}
+
+	if block.Permissions != nil || block.replaces("permissions") || hasPermissionReplace(block) {
+		perms := make(map[string]interface{})
+		if existing, ok := settings.Get("permissions"); ok {
+			if m, ok := existing.(map[string]interface{}); ok {
+				for k, v := range m {
+					perms[k] = v
+				}
+			}
+		}
+		for _, key := range permissionListKeys {
+			want := block.Permissions.list(key)
+			var merged []string
+			if !block.replaces("permissions." + key) {
+				merged = stringList(perms[key])
+			}
+			merged = mergeStringSlice(merged, want)
+			if len(merged) == 0 {
+				if block.replaces("permissions." + key) {
+					delete(perms, key)
+				}
+				continue
+			}
+			values := make([]interface{}, len(merged))
+			for i, v := range merged {
+				values[i] = v
+			}
+			perms[key] = values
+		}
+		if block.Permissions != nil && block.Permissions.DefaultMode != "" {
+			perms["defaultMode"] = block.Permissions.DefaultMode
+		}
+		if len(perms) == 0 {
+			settings.Delete("permissions")
+		} else {
+			settings.Set("permissions", perms)
+		}
+	}
+
+	return nil
+}
+
+func hasPermissionReplace(block *SettingsBlock) bool {
+	for _, r := range block.Replace {
+		if strings.HasPrefix(r, "permissions.") {
+			return true
+		}
+	}
+	return false
+}
+
+// stringList converts a decoded JSON array to strings, skipping non-strings.
+func stringList(v interface{}) []string {
+	items, _ := v.([]interface{})
+	var out []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// applyScopeSettingsBlock loads the settings file for scope, applies block,
+// and saves it.
+func applyScopeSettingsBlock(block *SettingsBlock, scope, claudeDir, projectDir string) error {
+	if block == nil {
+		return nil
+	}
+	settings, err := claude.LoadSettingsForScope(scope, claudeDir, projectDir)
+	if err != nil {
+		return fmt.Errorf("failed to load %s settings: %w", scope, err)
+	}
+	if err := applySettingsBlock(settings, block); err != nil {
+		return err
+	}
+	if err := claude.SaveSettingsForScope(scope, claudeDir, projectDir, settings); err != nil {
+		return fmt.Errorf("failed to save %s settings: %w", scope, err)
+	}
+	return nil
+}
+
+// readSettingsBlock captures the keys a SettingsBlock covers from live
+// settings. Values of an unexpected type are skipped. Returns nil when none
+// are set.
+func readSettingsBlock(settings *claude.Settings) *SettingsBlock {
+	if settings == nil {
+		return nil
+	}
+	block := &SettingsBlock{}
+	found := false
+	for _, key := range settingsBlockKeys {
+		value, ok := settings.Get(key)
+		if !ok {
+			continue
+		}
+		data, err := json.Marshal(map[string]interface{}{key: value})
+		if err != nil {
+			continue
+		}
+		if json.Unmarshal(data, block) == nil {
+			found = true
+		}
+	}
+	if !found || reflect.DeepEqual(block, &SettingsBlock{}) {
+		return nil
+	}
+	return block
+}
+
+// mergeSettingsBlock layers src onto dst for stacks: permission lists and
+// Replace are unioned, env is merged per variable, and scalars are last-wins.
+func mergeSettingsBlock(dst, src *SettingsBlock) *SettingsBlock {
+	if src == nil {
+		return dst
+	}
+	if dst == nil {
+		dst = &SettingsBlock{}
+	}
+
+	if src.Permissions != nil {
+		if dst.Permissions == nil {
+			dst.Permissions = &PermissionSettings{}
+		}
+		for _, key := range permissionListKeys {
+			dst.Permissions.setList(key, mergeStringSlice(dst.Permissions.list(key), src.Permissions.list(key)))
+		}
+		if src.Permissions.DefaultMode != "" {
+			dst.Permissions.DefaultMode = src.Permissions.DefaultMode
+		}
+	}
+
+	for k, v := range src.Env {
+		if dst.Env == nil {
+			dst.Env = make(map[string]string)
+		}
+		dst.Env[k] = v
+	}
+
+	if src.Model != "" {
+		dst.Model = src.Model
+	}
+	if src.OutputStyle != "" {
+		dst.OutputStyle = src.OutputStyle
+	}
+	if src.StatusLine != nil {
+		dst.StatusLine = src.StatusLine
+	}
+	if src.Language != "" {
+		dst.Language = src.Language
+	}
+	if src.IncludeCoAuthoredBy != nil {
+		dst.IncludeCoAuthoredBy = src.IncludeCoAuthoredBy
+	}
+	if src.AlwaysThinkingEnabled != nil {
+		dst.AlwaysThinkingEnabled = src.AlwaysThinkingEnabled
+	}
+	if src.CleanupPeriodDays != nil {
+		dst.CleanupPeriodDays = src.CleanupPeriodDays
+	}
+
+	dst.Replace = mergeStringSlice(dst.Replace, src.Replace)
+	return dst
+}
+
+// cloneSettingsBlock deep-copies a SettingsBlock.
+func cloneSettingsBlock(b *SettingsBlock) *SettingsBlock {
+	if b == nil {
+		return nil
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil
+	}
+	clone := &SettingsBlock{}
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil
+	}
+	return clone
+}
+
+// settingsBlockEqual compares two blocks by their JSON form, so nil and
+// empty lists are equal.
+func settingsBlockEqual(a, b *SettingsBlock) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return reflect.DeepEqual(decodeJSONObject(a), decodeJSONObject(b))
+}
+
+// diffSettings reports where live settings fall short of the saved block.
+// Only keys the profile sets are compared: a missing list entry or env
+// variable is "removed", a differing value is "modified", and live extras
+// are "added" only for keys the profile replaces.
+func diffSettings(saved, live *SettingsBlock) []DiffItem {
+	if saved == nil {
+		return nil
+	}
+	var items []DiffItem
+
+	savedScalars := saved.scalars()
+	liveScalars := live.scalars()
+	keys := make([]string, 0, len(savedScalars))
+	for k := range savedScalars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		liveValue, ok := liveScalars[k]
+		if !ok {
+			items = append(items, DiffItem{Op: DiffRemoved, Kind: DiffSetting, Name: k, Detail: patchValue(savedScalars[k], true)})
+		} else if !reflect.DeepEqual(savedScalars[k], liveValue) {
+			items = append(items, DiffItem{Op: DiffModified, Kind: DiffSetting, Name: k,
+				Detail: patchValue(savedScalars[k], true) + " → " + patchValue(liveValue, true)})
+		}
+	}
+
+	var liveEnv map[string]string
+	if live != nil {
+		liveEnv = live.Env
+	}
+	envKeys := make([]string, 0, len(saved.Env))
+	for k := range saved.Env {
+		envKeys = append(envKeys, k)
+	}
+	sort.Strings(envKeys)
+	for _, k := range envKeys {
+		liveValue, ok := liveEnv[k]
+		if !ok {
+			items = append(items, DiffItem{Op: DiffRemoved, Kind: DiffSetting, Name: "env." + k})
+		} else if liveValue != saved.Env[k] {
+			items = append(items, DiffItem{Op: DiffModified, Kind: DiffSetting, Name: "env." + k,
+				Detail: saved.Env[k] + " → " + liveValue})
+		}
+	}
+	if saved.replaces("env") {
+		var extras []string
+		for k := range liveEnv {
+			if _, ok := saved.Env[k]; !ok {
+				extras = append(extras, k)
+			}
+		}
+		sort.Strings(extras)
+		for _, k := range extras {
+			items = append(items, DiffItem{Op: DiffAdded, Kind: DiffSetting, Name: "env." + k})
+		}
+	}
+
+	var livePerms *PermissionSettings
+	if live != nil {
+		livePerms = live.Permissions
+	}
+	for _, key := range permissionListKeys {
+		detail := "permissions." + key
+		for _, d := range diffStringSet(saved.Permissions.list(key), livePerms.list(key), DiffSetting) {
+			if d.Op == DiffAdded && !saved.replaces(detail) {
+				continue
+			}
+			d.Detail = detail
+			items = append(items, d)
+		}
+	}
+	if saved.Permissions != nil && saved.Permissions.DefaultMode != "" {
+		liveMode := ""
+		if livePerms != nil {
+			liveMode = livePerms.DefaultMode
+		}
+		switch {
+		case liveMode == "":
+			items = append(items, DiffItem{Op: DiffRemoved, Kind: DiffSetting, Name: "permissions.defaultMode", Detail: saved.Permissions.DefaultMode})
+		case liveMode != saved.Permissions.DefaultMode:
+			items = append(items, DiffItem{Op: DiffModified, Kind: DiffSetting, Name: "permissions.defaultMode",
+				Detail: saved.Permissions.DefaultMode + " → " + liveMode})
+		}
+	}
+
+	return items
+}
+
+// Lines renders the block as "key: value" lines in settings.json order, for display.
+func (b *SettingsBlock) Lines() []string {
+	if b == nil {
+		return nil
+	}
+	var lines []string
+	for _, key := range permissionListKeys {
+		if list := b.Permissions.list(key); len(list) > 0 {
+			lines = append(lines, fmt.Sprintf("permissions.%s: %s", key, strings.Join(list, ", ")))
+		}
+	}
+	if b.Permissions != nil && b.Permissions.DefaultMode != "" {
+		lines = append(lines, "permissions.defaultMode: "+b.Permissions.DefaultMode)
+	}
+	envKeys := make([]string, 0, len(b.Env))
+	for k := range b.Env {
+		envKeys = append(envKeys, k)
+	}
+	sort.Strings(envKeys)
+	for _, k := range envKeys {
+		lines = append(lines, fmt.Sprintf("env.%s: %s", k, b.Env[k]))
+	}
+	scalars := b.scalars()
+	for _, key := range settingsBlockKeys {
+		if value, ok := scalars[key]; ok {
+			lines = append(lines, fmt.Sprintf("%s: %s", key, patchValue(value, true)))
+		}
+	}
+	if len(b.Replace) > 0 {
+		lines = append(lines, "replace: "+strings.Join(b.Replace, ", "))
+	}
+	return lines
+}
@@ -0,0 +1,213 @@
+// ABOUTME: Tests for the typed settings.json block carried by profiles
+// ABOUTME: Covers merge and replace semantics on apply, snapshots, stack merging, and diffs
+package profile
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+)
+
+func writeSettingsJSON(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplySettingsBlockMergesByDefault(t *testing.T) {
+	claudeDir := t.TempDir()
+	writeSettingsJSON(t, filepath.Join(claudeDir, "settings.json"), `{
+  "env": {"KEEP": "1", "DEBUG": "0"},
+  "permissions": {"allow": ["Read"], "deny": ["Bash(rm:*)"], "disableBypassPermissionsMode": "disable"},
+  "theme": "dark"
+}`)
+
+	block := &SettingsBlock{
+		Permissions: &PermissionSettings{Allow: []string{"Read", "Bash(go test:*)"}, DefaultMode: "acceptEdits"},
+		Env:         map[string]string{"DEBUG": "1"},
+		Model:       "opus",
+		StatusLine:  &StatusLine{Type: "command", Command: "~/bin/status"},
+	}
+	if err := applyScopeSettingsBlock(block, "user", claudeDir, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := claude.LoadSettingsForScope("user", claudeDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := readSettingsBlock(settings)
+	want := &SettingsBlock{
+		Permissions: &PermissionSettings{
+			Allow:       []string{"Read", "Bash(go test:*)"},
+			Deny:        []string{"Bash(rm:*)"},
+			DefaultMode: "acceptEdits",
+		},
+		Env:        map[string]string{"KEEP": "1", "DEBUG": "1"},
+		Model:      "opus",
+		StatusLine: &StatusLine{Type: "command", Command: "~/bin/status"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("settings after apply:\n got %+v\nwant %+v", got, want)
+	}
+
+	// Keys the block does not manage are left alone
+	if theme, _ := settings.Get("theme"); theme != "dark" {
+		t.Errorf("theme = %v, want dark", theme)
+	}
+	perms, _ := settings.Get("permissions")
+	if perms.(map[string]interface{})["disableBypassPermissionsMode"] != "disable" {
+		t.Errorf("unmanaged permission key lost: %v", perms)
+	}
+}
+
+func TestApplySettingsBlockReplace(t *testing.T) {
+	projectDir := t.TempDir()
+	writeSettingsJSON(t, filepath.Join(projectDir, ".claude", "settings.json"), `{
+  "env": {"OLD": "1"},
+  "permissions": {"allow": ["Read"], "deny": ["Bash(rm:*)"]}
+}`)
+
+	block := &SettingsBlock{
+		Permissions: &PermissionSettings{Allow: []string{"Edit"}},
+		Env:         map[string]string{"NEW": "1"},
+		Replace:     []string{"env", "permissions.allow", "permissions.deny"},
+	}
+	if err := applyScopeSettingsBlock(block, "project", "", projectDir); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, _ := claude.LoadSettingsForScope("project", "", projectDir)
+	got := readSettingsBlock(settings)
+	if !reflect.DeepEqual(got.Env, map[string]string{"NEW": "1"}) {
+		t.Errorf("env = %v", got.Env)
+	}
+	if !reflect.DeepEqual(got.Permissions, &PermissionSettings{Allow: []string{"Edit"}}) {
+		t.Errorf("permissions = %+v", got.Permissions)
+	}
+
+	bad := &SettingsBlock{Replace: []string{"model"}}
+	if err := applyScopeSettingsBlock(bad, "project", "", projectDir); err == nil {
+		t.Error("expected error for a replace entry that is not mergeable")
+	}
+}
+
+func TestSettingsBlockProfileJSON(t *testing.T) {
+	input := `{
+  "name": "team",
+  "perScope": {
+    "project": {
+      "settings": {
+        "permissions": {"allow": ["Bash(make:*)"]},
+        "model": "sonnet",
+        "replace": ["permissions.allow"]
+      }
+    }
+  }
+}`
+	var p Profile
+	if err := json.Unmarshal([]byte(input), &p); err != nil {
+		t.Fatal(err)
+	}
+	block := p.ForScope("project").Settings
+	if block == nil || block.Model != "sonnet" || block.Permissions.Allow[0] != "Bash(make:*)" {
+		t.Fatalf("project settings = %+v", block)
+	}
+
+	clone := p.Clone("copy")
+	if !clone.Equal(&p) {
+		t.Error("clone should equal original")
+	}
+	clone.PerScope.Project.Settings.Model = "opus"
+	if clone.Equal(&p) || p.PerScope.Project.Settings.Model != "sonnet" {
+		t.Error("clone should be independent of the original")
+	}
+}
+
+func TestMergeSettingsBlockForStacks(t *testing.T) {
+	base := &Profile{Settings: &SettingsBlock{
+		Permissions: &PermissionSettings{Allow: []string{"Read"}},
+		Env:         map[string]string{"A": "1", "B": "1"},
+		Model:       "sonnet",
+	}}
+	team := &Profile{Settings: &SettingsBlock{
+		Permissions: &PermissionSettings{Allow: []string{"Edit"}, Deny: []string{"WebFetch"}},
+		Env:         map[string]string{"B": "2"},
+		Model:       "opus",
+		Replace:     []string{"env"},
+	}}
+
+	merged := mergeProfiles([]*Profile{base, team})
+	want := &SettingsBlock{
+		Permissions: &PermissionSettings{Allow: []string{"Read", "Edit"}, Deny: []string{"WebFetch"}},
+		Env:         map[string]string{"A": "1", "B": "2"},
+		Model:       "opus",
+		Replace:     []string{"env"},
+	}
+	if !reflect.DeepEqual(merged.Settings, want) {
+		t.Errorf("merged settings:\n got %+v\nwant %+v", merged.Settings, want)
+	}
+}
+
+func TestDiffSettings(t *testing.T) {
+	saved := &SettingsBlock{
+		Permissions: &PermissionSettings{Allow: []string{"Read", "Edit"}, Deny: []string{"WebFetch"}},
+		Env:         map[string]string{"DEBUG": "1", "REGION": "eu"},
+		Model:       "opus",
+		Replace:     []string{"permissions.deny"},
+	}
+	live := &SettingsBlock{
+		Permissions: &PermissionSettings{Allow: []string{"Read", "Bash(ls)"}, Deny: []string{"WebFetch", "Bash(curl:*)"}},
+		Env:         map[string]string{"DEBUG": "0", "EXTRA": "x"},
+		Model:       "sonnet",
+	}
+
+	var got []string
+	for _, item := range diffSettings(saved, live) {
+		got = append(got, string(item.Op)+" "+item.Name+" ("+item.Detail+")")
+	}
+	want := []string{
+		"modified model (opus → sonnet)",
+		"modified env.DEBUG (1 → 0)",
+		"removed env.REGION ()",
+		"removed Edit (permissions.allow)",
+		"added Bash(curl:*) (permissions.deny)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("diffSettings:\n got %s\nwant %s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if items := diffSettings(nil, live); len(items) != 0 {
+		t.Errorf("profiles without settings should not diff, got %+v", items)
+	}
+}
+
+func TestSnapshotAllScopesCapturesSettings(t *testing.T) {
+	claudeDir := t.TempDir()
+	projectDir := t.TempDir()
+	writeSettingsJSON(t, filepath.Join(claudeDir, "settings.json"), `{"model": "opus", "theme": "dark"}`)
+	writeSettingsJSON(t, filepath.Join(projectDir, ".claude", "settings.local.json"), `{"env": {"DEBUG": "1"}}`)
+
+	p, err := SnapshotAllScopes("live", claudeDir, filepath.Join(claudeDir, ".claude.json"), projectDir, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.PerScope.User == nil || p.PerScope.User.Settings == nil || p.PerScope.User.Settings.Model != "opus" {
+		t.Errorf("user settings = %+v", p.PerScope.User)
+	}
+	if p.PerScope.Local == nil || p.PerScope.Local.Settings.Env["DEBUG"] != "1" {
+		t.Errorf("local settings = %+v", p.PerScope.Local)
+	}
+	if p.PerScope.Project != nil {
+		t.Errorf("project scope should be empty, got %+v", p.PerScope.Project)
+	}
+}
@@ -0,0 +1,226 @@
+// ABOUTME: Hook entries that profiles merge into settings.json, keyed by event, matcher, and command
+// ABOUTME: Converts hooks for claude.Settings, reads them back from live settings, and diffs them
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+	"github.com/claudeup/claudeup/v5/internal/ledger"
+)
+
+// key identifies a hook within its event type by matcher and command, or by
+// prompt for prompt hooks.
+func (h HookEntry) key() string {
+	if h.Command != "" {
+		return h.Matcher + "\x00command:" + h.Command
+	}
+	return h.Matcher + "\x00prompt:" + h.Prompt
+}
+
+// label is the command or prompt shown for a hook.
+func (h HookEntry) label() string {
+	if h.Command != "" {
+		return h.Command
+	}
+	return h.Prompt
+}
+
+// hookItem is the ownership ledger item for a hook in user settings.json,
+// named "Event: command" with the matcher, if any, appended.
+func hookItem(eventType string, h HookEntry) ledger.Item {
+	name := eventType + ": " + h.label()
+	if h.Matcher != "" {
+		name += " (matcher " + h.Matcher + ")"
+	}
+	return ledger.NewItem(ledger.KindHook, name, "user", "")
+}
+
+// normalized fills in the "command" type that apply writes for hooks that
+// omit it, so diffs compare what actually lands in settings.json.
+func (h HookEntry) normalized() HookEntry {
+	if h.Type == "" {
+		h.Type = "command"
+	}
+	return h
+}
+
+// settingsMap converts a hook to the map format claude.Settings.MergeHooks takes.
+func (h HookEntry) settingsMap() map[string]interface{} {
+	h = h.normalized()
+	m := map[string]interface{}{"type": h.Type}
+	if h.Matcher != "" {
+		m["matcher"] = h.Matcher
+	}
+	if h.Command != "" {
+		m["command"] = h.Command
+	}
+	if h.Prompt != "" {
+		m["prompt"] = h.Prompt
+	}
+	if h.Timeout > 0 {
+		m["timeout"] = h.Timeout
+	}
+	return m
+}
+
+// settingsHooksMaps converts profile hooks for claude.Settings.MergeHooks
+// and RemoveHooks.
+func settingsHooksMaps(hooks map[string][]HookEntry) map[string][]map[string]interface{} {
+	out := make(map[string][]map[string]interface{}, len(hooks))
+	for eventType, entries := range hooks {
+		for _, entry := range entries {
+			out[eventType] = append(out[eventType], entry.settingsMap())
+		}
+	}
+	return out
+}
+
+// hookGroupJSON is a matcher entry under an event type in settings.json.
+type hookGroupJSON struct {
+	Matcher string      `json:"matcher"`
+	Hooks   []HookEntry `json:"hooks"`
+}
+
+// readSettingsHooks flattens the hooks in settings.json into entries per
+// event type, carrying each group's matcher. Returns nil when there are none.
+func readSettingsHooks(settings *claude.Settings) map[string][]HookEntry {
+	if settings == nil {
+		return nil
+	}
+	raw, ok := settings.Get("hooks")
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var events map[string][]hookGroupJSON
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil
+	}
+
+	hooks := make(map[string][]HookEntry)
+	for eventType, groups := range events {
+		for _, group := range groups {
+			for _, h := range group.Hooks {
+				if h.Command == "" && h.Prompt == "" {
+					continue
+				}
+				h.Matcher = group.Matcher
+				hooks[eventType] = append(hooks[eventType], h)
+			}
+		}
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+	return hooks
+}
+
+// cloneSettingsHooks deep-copies hooks per event type.
+func cloneSettingsHooks(hooks map[string][]HookEntry) map[string][]HookEntry {
+	if len(hooks) == 0 {
+		return nil
+	}
+	clone := make(map[string][]HookEntry, len(hooks))
+	for eventType, entries := range hooks {
+		clone[eventType] = append([]HookEntry(nil), entries...)
+	}
+	return clone
+}
+
+// settingsHooksEqual compares hooks, treating nil and empty as equal.
+func settingsHooksEqual(a, b map[string][]HookEntry) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// diffSettingsHooks computes added/removed/modified hooks per event type,
+// matching hooks by matcher and command (or prompt). A profile without hooks
+// does not manage them, so live hooks are not reported against it.
+func diffSettingsHooks(saved, live map[string][]HookEntry) []DiffItem {
+	if len(saved) == 0 {
+		return nil
+	}
+
+	var items []DiffItem
+	for _, eventType := range sortedHookEvents(saved, live) {
+		savedByKey := make(map[string]HookEntry)
+		for _, h := range saved[eventType] {
+			savedByKey[h.key()] = h.normalized()
+		}
+		liveByKey := make(map[string]HookEntry)
+		for _, h := range live[eventType] {
+			liveByKey[h.key()] = h.normalized()
+		}
+
+		for _, l := range live[eventType] {
+			l = l.normalized()
+			s, exists := savedByKey[l.key()]
+			switch {
+			case !exists:
+				items = append(items, DiffItem{Op: DiffAdded, Kind: DiffHook, Name: l.label(), Detail: hookContext(eventType, l)})
+			case !reflect.DeepEqual(s, l):
+				items = append(items, DiffItem{Op: DiffModified, Kind: DiffHook, Name: l.label(),
+					Detail: hookContext(eventType, l) + ", " + hookChanges(s, l)})
+			}
+		}
+		for _, s := range saved[eventType] {
+			if _, exists := liveByKey[s.key()]; !exists {
+				items = append(items, DiffItem{Op: DiffRemoved, Kind: DiffHook, Name: s.label(), Detail: hookContext(eventType, s)})
+			}
+		}
+	}
+	return items
+}
+
+// sortedHookEvents returns the event types present in any of the hook maps, sorted.
+func sortedHookEvents(hookSets ...map[string][]HookEntry) []string {
+	seen := make(map[string]bool)
+	var events []string
+	for _, hooks := range hookSets {
+		for eventType := range hooks {
+			if !seen[eventType] {
+				seen[eventType] = true
+				events = append(events, eventType)
+			}
+		}
+	}
+	sort.Strings(events)
+	return events
+}
+
+// hookContext names a hook's event type and matcher for diff details.
+func hookContext(eventType string, h HookEntry) string {
+	if h.Matcher == "" {
+		return eventType
+	}
+	return fmt.Sprintf("%s, matcher %s", eventType, h.Matcher)
+}
+
+// hookChanges lists "field: profile → live" for a hook's changed fields.
+func hookChanges(saved, live HookEntry) string {
+	var changes []string
+	if saved.Type != live.Type {
+		changes = append(changes, fmt.Sprintf("type: %s → %s", saved.Type, live.Type))
+	}
+	if saved.Timeout != live.Timeout {
+		changes = append(changes, fmt.Sprintf("timeout: %s → %s", hookTimeout(saved.Timeout), hookTimeout(live.Timeout)))
+	}
+	return strings.Join(changes, ", ")
+}
+
+func hookTimeout(seconds int) string {
+	if seconds == 0 {
+		return "(not set)"
+	}
+	return fmt.Sprintf("%ds", seconds)
+}
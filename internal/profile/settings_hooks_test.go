@@ -0,0 +1,199 @@
+// ABOUTME: Tests for profile hooks with matchers and timeouts in settings.json
+// ABOUTME: Covers idempotent apply, snapshot round-trip, diffs, and removal on reset from the ledger
+package profile
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
+	"github.com/claudeup/claudeup/v5/internal/ledger"
+)
+
+func testProfileHooks() map[string][]HookEntry {
+	return map[string][]HookEntry{
+		"PreToolUse": {
+			{Matcher: "Edit|Write", Type: "command", Command: "gofmt-check", Timeout: 30},
+		},
+		"Stop": {
+			{Type: "prompt", Prompt: "Summarize the session"},
+		},
+	}
+}
+
+func TestApplySettingsHooksRoundTrip(t *testing.T) {
+	claudeDir := t.TempDir()
+	writeSettingsJSON(t, filepath.Join(claudeDir, "settings.json"), `{
+  "hooks": {
+    "PreToolUse": [{"matcher": "Bash", "hooks": [{"type": "command", "command": "audit"}]}]
+  }
+}`)
+
+	p := &Profile{Name: "hooks", SettingsHooks: testProfileHooks()}
+	for i := 0; i < 2; i++ {
+		if err := applySettingsHooks(p, claudeDir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	settings, err := claude.LoadSettings(claudeDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := readSettingsHooks(settings)
+	want := map[string][]HookEntry{
+		"PreToolUse": {
+			{Matcher: "Bash", Type: "command", Command: "audit"},
+			{Matcher: "Edit|Write", Type: "command", Command: "gofmt-check", Timeout: 30},
+		},
+		"Stop": {
+			{Type: "prompt", Prompt: "Summarize the session"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("hooks after applying twice:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestResetRemovesOnlyProfileHooks(t *testing.T) {
+	claudeDir := t.TempDir()
+	writeSettingsJSON(t, filepath.Join(claudeDir, "settings.json"), `{
+  "hooks": {
+    "PreToolUse": [{"matcher": "Edit|Write", "hooks": [{"type": "command", "command": "audit"}]}]
+  }
+}`)
+
+	p := &Profile{Name: "hooks", SettingsHooks: testProfileHooks()}
+	if err := applySettingsHooks(p, claudeDir); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := resetSettingsHooks(p, claudeDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRemoved := []string{"PreToolUse: gofmt-check", "Stop: Summarize the session"}
+	if !reflect.DeepEqual(removed, wantRemoved) {
+		t.Errorf("removed = %v, want %v", removed, wantRemoved)
+	}
+
+	settings, _ := claude.LoadSettings(claudeDir)
+	got := readSettingsHooks(settings)
+	want := map[string][]HookEntry{
+		"PreToolUse": {{Matcher: "Edit|Write", Type: "command", Command: "audit"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("hooks after reset:\n got %+v\nwant %+v", got, want)
+	}
+
+	// A second reset has nothing left to remove
+	removed, err = resetSettingsHooks(p, claudeDir)
+	if err != nil || removed != nil {
+		t.Errorf("second reset = %v, %v", removed, err)
+	}
+}
+
+func TestResetRemovesHooksFromLedger(t *testing.T) {
+	claudeDir := t.TempDir()
+	claudeupHome := t.TempDir()
+	claudeJSON := filepath.Join(claudeDir, ".claude.json")
+	// The user already has the profile's gofmt-check hook
+	writeSettingsJSON(t, filepath.Join(claudeDir, "settings.json"), `{
+  "hooks": {
+    "PreToolUse": [{"matcher": "Edit|Write", "hooks": [{"type": "command", "command": "gofmt-check", "timeout": 30}]}]
+  }
+}`)
+
+	apply := func(p *Profile) {
+		t.Helper()
+		before := liveItems(t, claudeDir, claudeJSON, "")
+		if err := applySettingsHooks(p, claudeDir); err != nil {
+			t.Fatal(err)
+		}
+		book, err := ledger.Load(claudeupHome)
+		if err != nil {
+			t.Fatal(err)
+		}
+		book.Record(p.Name, IntroducedItems(before, liveItems(t, claudeDir, claudeJSON, "")), time.Now())
+		if err := ledger.Save(claudeupHome, book); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// An earlier version of the profile added a lint hook it no longer lists
+	apply(&Profile{Name: "hooks", SettingsHooks: map[string][]HookEntry{"Stop": {{Command: "lint"}}}})
+	p := &Profile{Name: "hooks", SettingsHooks: testProfileHooks()}
+	apply(p)
+
+	result, err := ResetWithExecutor(p, claudeDir, claudeJSON, "", claudeupHome, &mockExecutor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRemoved := []string{"Stop: lint", "Stop: Summarize the session"}
+	if !reflect.DeepEqual(result.HooksRemoved, wantRemoved) {
+		t.Errorf("removed = %v, want %v", result.HooksRemoved, wantRemoved)
+	}
+
+	settings, _ := claude.LoadSettings(claudeDir)
+	want := map[string][]HookEntry{
+		"PreToolUse": {{Matcher: "Edit|Write", Type: "command", Command: "gofmt-check", Timeout: 30}},
+	}
+	if got := readSettingsHooks(settings); !reflect.DeepEqual(got, want) {
+		t.Errorf("hooks after reset:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDiffSettingsHooks(t *testing.T) {
+	saved := map[string][]HookEntry{
+		"PreToolUse": {
+			{Matcher: "Edit|Write", Command: "gofmt-check", Timeout: 30},
+			{Matcher: "Bash", Command: "audit"},
+		},
+	}
+	live := map[string][]HookEntry{
+		"PreToolUse": {
+			{Matcher: "Edit|Write", Type: "command", Command: "gofmt-check", Timeout: 60},
+		},
+		"Stop": {
+			{Type: "command", Command: "notify"},
+		},
+	}
+
+	var got []string
+	for _, item := range diffSettingsHooks(saved, live) {
+		got = append(got, string(item.Op)+" "+item.Name+" ("+item.Detail+")")
+	}
+	want := []string{
+		"modified gofmt-check (PreToolUse, matcher Edit|Write, timeout: 30s → 60s)",
+		"removed audit (PreToolUse, matcher Bash)",
+		"added notify (Stop)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("diffSettingsHooks:\n got %s\nwant %s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if items := diffSettingsHooks(nil, live); len(items) != 0 {
+		t.Errorf("profiles without hooks should not diff, got %+v", items)
+	}
+}
+
+func TestMergeSettingsHooksPerMatcher(t *testing.T) {
+	base := &Profile{SettingsHooks: map[string][]HookEntry{
+		"PreToolUse": {{Matcher: "Bash", Type: "command", Command: "audit"}},
+	}}
+	team := &Profile{SettingsHooks: map[string][]HookEntry{
+		"PreToolUse": {
+			{Matcher: "Bash", Type: "command", Command: "audit"},
+			{Matcher: "Edit", Type: "command", Command: "audit"},
+		},
+	}}
+
+	merged := mergeProfiles([]*Profile{base, team})
+	if n := len(merged.SettingsHooks["PreToolUse"]); n != 2 {
+		t.Errorf("expected the same command under two matchers to be kept separately, got %d hooks: %+v",
+			n, merged.SettingsHooks["PreToolUse"])
+	}
+}
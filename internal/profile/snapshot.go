@@ -37,11 +37,7 @@ type MarketplaceMetadata struct {
 }
 
 // MarketplaceSource represents the source of a marketplace
-type MarketplaceSource struct {
-	Source string `json:"source"`
-	Repo   string `json:"repo,omitempty"`
-	URL    string `json:"url,omitempty"`
-}
+type MarketplaceSource = claude.MarketplaceSource
 
 // SnapshotOptions controls how a snapshot is taken
 type SnapshotOptions struct {
@@ -173,29 +169,17 @@ func readMarketplaces(claudeDir string, plugins []string) ([]Marketplace, error)
 		if usedNames != nil && !usedNames[name] {
 			continue
 		}
-		m := Marketplace{
-			Source: meta.Source.Source,
-			Repo:   meta.Source.Repo,
-			URL:    meta.Source.URL,
-		}
-		// Filter out invalid marketplaces (no repo or url)
+		m := marketplaceFromSource(meta.Source)
+		// Filter out invalid marketplaces (no repo, url, or path)
 		if m.DisplayName() == "" {
 			continue
 		}
 		marketplaces = append(marketplaces, m)
 	}
 
-	// Sort by repo (or URL for git sources) for consistent output
+	// Sort by repo (or URL/path for git and directory sources) for consistent output
 	sort.Slice(marketplaces, func(i, j int) bool {
-		keyI := marketplaces[i].Repo
-		if keyI == "" {
-			keyI = marketplaces[i].URL
-		}
-		keyJ := marketplaces[j].Repo
-		if keyJ == "" {
-			keyJ = marketplaces[j].URL
-		}
-		return keyI < keyJ
+		return marketplaces[i].DisplayName() < marketplaces[j].DisplayName()
 	})
 
 	return marketplaces, nil
@@ -271,6 +255,17 @@ func ReadExtensions(claudeDir, claudeupHome string) (*ExtensionSettings, error)
 		return nil, nil
 	}
 
+	// Carry frontmatter overrides of enabled variants
+	variants, err := manager.LoadVariants()
+	if err != nil {
+		return nil, err
+	}
+	for category, patches := range variants {
+		for _, item := range settings.Items(category) {
+			settings.SetOverride(category, item, patches[item])
+		}
+	}
+
 	return settings, nil
 }
 
@@ -349,11 +344,15 @@ func SnapshotAllScopes(name, claudeDir, claudeJSONPath, projectDir, claudeupHome
 	// Capture user scope
 	userPlugins, _ := readPluginsForScope(claudeDir, projectDir, "user")
 	userMCP, _ := ReadMCPServersForScope(claudeJSONPath, projectDir, "user")
+	userSettings := readSettingsBlockForScope(claudeDir, projectDir, "user")
+	userMemory := readMemoryForScope("user", claudeDir, projectDir)
 	allPlugins = append(allPlugins, userPlugins...)
-	if len(userPlugins) > 0 || len(userMCP) > 0 {
+	if len(userPlugins) > 0 || len(userMCP) > 0 || userSettings != nil || len(userMemory) > 0 {
 		p.PerScope.User = &ScopeSettings{
 			Plugins:    userPlugins,
 			MCPServers: userMCP,
+			Settings:   userSettings,
+			Memory:     userMemory,
 		}
 	}
 
@@ -361,11 +360,15 @@ func SnapshotAllScopes(name, claudeDir, claudeJSONPath, projectDir, claudeupHome
 	if hasDistinctProjectScope {
 		projectPlugins, _ := readPluginsForScope(claudeDir, projectDir, "project")
 		projectMCP, _ := ReadMCPServersForScope(claudeJSONPath, projectDir, "project")
+		projectSettings := readSettingsBlockForScope(claudeDir, projectDir, "project")
+		projectMemory := readMemoryForScope("project", claudeDir, projectDir)
 		allPlugins = append(allPlugins, projectPlugins...)
-		if len(projectPlugins) > 0 || len(projectMCP) > 0 {
+		if len(projectPlugins) > 0 || len(projectMCP) > 0 || projectSettings != nil || len(projectMemory) > 0 {
 			p.PerScope.Project = &ScopeSettings{
 				Plugins:    projectPlugins,
 				MCPServers: projectMCP,
+				Settings:   projectSettings,
+				Memory:     projectMemory,
 			}
 		}
 	}
@@ -374,11 +377,15 @@ func SnapshotAllScopes(name, claudeDir, claudeJSONPath, projectDir, claudeupHome
 	if hasDistinctProjectScope {
 		localPlugins, _ := readPluginsForScope(claudeDir, projectDir, "local")
 		localMCP, _ := ReadMCPServersForScope(claudeJSONPath, projectDir, "local")
+		localSettings := readSettingsBlockForScope(claudeDir, projectDir, "local")
+		localMemory := readMemoryForScope("local", claudeDir, projectDir)
 		allPlugins = append(allPlugins, localPlugins...)
-		if len(localPlugins) > 0 || len(localMCP) > 0 {
+		if len(localPlugins) > 0 || len(localMCP) > 0 || localSettings != nil || len(localMemory) > 0 {
 			p.PerScope.Local = &ScopeSettings{
 				Plugins:    localPlugins,
 				MCPServers: localMCP,
+				Settings:   localSettings,
+				Memory:     localMemory,
 			}
 		}
 	}
@@ -389,6 +396,11 @@ func SnapshotAllScopes(name, claudeDir, claudeJSONPath, projectDir, claudeupHome
 		p.Marketplaces = marketplaces
 	}
 
+	// Hooks are always user-scoped; applySettingsHooks writes them to user settings
+	if userHooks, err := claude.LoadSettingsForScope("user", claudeDir, projectDir); err == nil {
+		p.SettingsHooks = readSettingsHooks(userHooks)
+	}
+
 	// Read user-scoped extensions from enabled.json into PerScope.User
 	userExtensions, err := ReadExtensions(claudeDir, claudeupHome)
 	if err == nil && userExtensions != nil {
@@ -398,7 +410,7 @@ func SnapshotAllScopes(name, claudeDir, claudeJSONPath, projectDir, claudeupHome
 		p.PerScope.User.Extensions = userExtensions
 	}
 
-	// Read project-scoped extensions from project .claude/{agents,rules}/
+	// Read project-scoped extensions from project .claude/{category}/
 	if hasDistinctProjectScope {
 		projectExtensions := ReadProjectExtensions(projectDir)
 		if projectExtensions != nil {
@@ -415,6 +427,16 @@ func SnapshotAllScopes(name, claudeDir, claudeJSONPath, projectDir, claudeupHome
 	return p, nil
 }
 
+// readSettingsBlockForScope captures the settings block from a scope's
+// settings file. Unreadable files yield nil, like the other snapshot readers.
+func readSettingsBlockForScope(claudeDir, projectDir, scope string) *SettingsBlock {
+	settings, err := claude.LoadSettingsForScope(scope, claudeDir, projectDir)
+	if err != nil {
+		return nil
+	}
+	return readSettingsBlock(settings)
+}
+
 // sameDir returns true if a and b resolve to the same directory after
 // cleaning and resolving symlinks.
 func sameDir(a, b string) bool {
@@ -426,14 +448,15 @@ func sameDir(a, b string) bool {
 	return ra == rb
 }
 
-// ReadProjectExtensions scans .claude/{agents,rules}/ in the project directory
-// for regular files (not symlinks). Regular files are project-scoped extensions;
-// symlinks are user-scoped extensions managed by claudeup and should be skipped.
+// ReadProjectExtensions scans .claude/{category}/ in the project directory
+// for regular files (not symlinks), and for skill directories containing SKILL.md.
+// Regular files are project-scoped extensions; symlinks are user-scoped
+// extensions managed by claudeup and should be skipped.
 func ReadProjectExtensions(projectDir string) *ExtensionSettings {
 	settings := &ExtensionSettings{}
 	hasItems := false
 
-	for _, category := range []string{"agents", "rules"} {
+	for _, category := range ext.AllCategories() {
 		dir := filepath.Join(projectDir, ".claude", category)
 		entries, err := os.ReadDir(dir)
 		if err != nil {
@@ -458,7 +481,13 @@ func ReadProjectExtensions(projectDir string) *ExtensionSettings {
 				continue
 			}
 
-			// Skip directories and other non-regular files
+			// Skills are directories; other categories are regular files
+			if category == ext.CategorySkills && info.IsDir() {
+				if _, err := os.Stat(filepath.Join(path, "SKILL.md")); err == nil {
+					items = append(items, name)
+				}
+				continue
+			}
 			if !info.Mode().IsRegular() {
 				continue
 			}
@@ -470,10 +499,18 @@ func ReadProjectExtensions(projectDir string) *ExtensionSettings {
 
 		if len(items) > 0 {
 			switch category {
-			case "agents":
+			case ext.CategoryAgents:
 				settings.Agents = items
-			case "rules":
+			case ext.CategoryCommands:
+				settings.Commands = items
+			case ext.CategorySkills:
+				settings.Skills = items
+			case ext.CategoryHooks:
+				settings.Hooks = items
+			case ext.CategoryRules:
 				settings.Rules = items
+			case ext.CategoryOutputStyles:
+				settings.OutputStyles = items
 			}
 			hasItems = true
 		}
@@ -482,5 +519,14 @@ func ReadProjectExtensions(projectDir string) *ExtensionSettings {
 	if !hasItems {
 		return nil
 	}
+
+	// Carry frontmatter overrides recorded for copied variants
+	if manifest, err := ext.LoadProjectManifest(projectDir); err == nil {
+		for category, records := range manifest {
+			for _, item := range settings.Items(category) {
+				settings.SetOverride(category, item, records[item].Set)
+			}
+		}
+	}
 	return settings
 }
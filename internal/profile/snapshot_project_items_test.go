@@ -248,3 +248,31 @@ func TestSnapshotAllScopesPutsUserItemsInPerScopeUser(t *testing.T) {
 		t.Errorf("expected flat Extensions to be nil for multi-scope snapshot, got %v", profile.Extensions)
 	}
 }
+
+func TestReadProjectExtensionsCapturesAllCategories(t *testing.T) {
+	projectDir := t.TempDir()
+
+	claudeDir := filepath.Join(projectDir, ".claude")
+	mustMkdir(t, filepath.Join(claudeDir, "commands"))
+	mustWriteFile(t, filepath.Join(claudeDir, "commands", "deploy.md"), "Deploy")
+	mustMkdir(t, filepath.Join(claudeDir, "skills", "notes"))
+	mustWriteFile(t, filepath.Join(claudeDir, "skills", "notes", "SKILL.md"), "# Notes")
+	mustMkdir(t, filepath.Join(claudeDir, "skills", "scratch"))
+	mustMkdir(t, filepath.Join(claudeDir, "output-styles"))
+	mustWriteFile(t, filepath.Join(claudeDir, "output-styles", "terse.md"), "# Terse")
+
+	items := ReadProjectExtensions(projectDir)
+	if items == nil {
+		t.Fatal("expected non-nil ExtensionSettings")
+	}
+
+	if len(items.Commands) != 1 || items.Commands[0] != "deploy.md" {
+		t.Errorf("expected commands [deploy.md], got %v", items.Commands)
+	}
+	if len(items.Skills) != 1 || items.Skills[0] != "notes" {
+		t.Errorf("expected skills [notes], got %v", items.Skills)
+	}
+	if len(items.OutputStyles) != 1 || items.OutputStyles[0] != "terse.md" {
+		t.Errorf("expected output-styles [terse.md], got %v", items.OutputStyles)
+	}
+}
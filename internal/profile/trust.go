@@ -0,0 +1,360 @@
+// ABOUTME: Signed profiles: detached minisign signatures next to profile files, and the trusted key store
+// ABOUTME: Decides whether a profile that runs commands (hooks, MCP servers) is signed by a trusted key
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/claudeup/claudeup/v5/internal/minisign"
+)
+
+// SignatureExt is appended to a profile's file name for its detached
+// signature, as minisign does ("team.json.minisig").
+const SignatureExt = minisign.Extension
+
+// TrustPolicy decides what happens when a profile that runs commands is
+// not signed by a trusted key.
+type TrustPolicy string
+
+const (
+	TrustRefuse TrustPolicy = "refuse" // don't apply it
+	TrustPrompt TrustPolicy = "prompt" // ask before applying it (the default)
+	TrustAllow  TrustPolicy = "allow"  // apply it without asking
+)
+
+// ParseTrustPolicy validates a policy name; "" is the default policy.
+func ParseTrustPolicy(s string) (TrustPolicy, error) {
+	switch policy := TrustPolicy(s); policy {
+	case "":
+		return TrustPrompt, nil
+	case TrustRefuse, TrustPrompt, TrustAllow:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown trust policy %q (use refuse, prompt, or allow)", s)
+}
+
+// ErrUnsigned is the SignatureStatus error of a profile with no signature file.
+var ErrUnsigned = errors.New("not signed")
+
+// TrustedKey is a public key in the trust store and the name it was
+// trusted under.
+type TrustedKey struct {
+	Name string
+	minisign.PublicKey
+}
+
+// TrustStore holds the public keys whose signatures are trusted, one
+// minisign public key file per signer.
+type TrustStore struct {
+	Dir string
+}
+
+// NewTrustStore returns the trust store in claudeupHome.
+func NewTrustStore(claudeupHome string) *TrustStore {
+	return &TrustStore{Dir: filepath.Join(claudeupHome, "trusted-keys")}
+}
+
+// List returns the trusted keys by name.
+func (s *TrustStore) List() ([]TrustedKey, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []TrustedKey
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pub" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.Dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		key, err := minisign.ParsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		keys = append(keys, TrustedKey{Name: strings.TrimSuffix(entry.Name(), ".pub"), PublicKey: *key})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	return keys, nil
+}
+
+// Add trusts a key under name. A key can be trusted under one name only.
+func (s *TrustStore) Add(name string, key *minisign.PublicKey) error {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid signer name %q", name)
+	}
+	keys, err := s.List()
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if k.ID == key.ID {
+			return fmt.Errorf("key %s is already trusted as %q", key.IDString(), k.Name)
+		}
+		if k.Name == name {
+			return fmt.Errorf("a key is already trusted as %q", name)
+		}
+	}
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.Dir, name+".pub"), key.Marshal(), 0644)
+}
+
+// Remove stops trusting the key with the given name or key ID.
+func (s *TrustStore) Remove(nameOrID string) (*TrustedKey, error) {
+	keys, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		if k.Name == nameOrID || strings.EqualFold(k.IDString(), nameOrID) {
+			if err := os.Remove(filepath.Join(s.Dir, k.Name+".pub")); err != nil {
+				return nil, err
+			}
+			return &k, nil
+		}
+	}
+	return nil, fmt.Errorf("no trusted key named %q", nameOrID)
+}
+
+// SignatureStatus is the result of verifying a profile's signature.
+type SignatureStatus struct {
+	Signer  string    // trusted name of the key that signed the profile
+	KeyID   string    // ID of the key that signed the profile, trusted or not
+	Signed  time.Time // when it was signed, if the trusted comment says
+	BuiltIn bool      // built-in profiles ship with claudeup and are trusted
+	Err     error     // why the profile is not trusted; ErrUnsigned without a signature
+}
+
+// Trusted reports whether the profile is built in or signed by a trusted key.
+func (s SignatureStatus) Trusted() bool {
+	return s.BuiltIn || (s.Signer != "" && s.Err == nil)
+}
+
+// String describes the status for display.
+func (s SignatureStatus) String() string {
+	switch {
+	case s.BuiltIn:
+		return "built-in"
+	case s.Trusted():
+		return fmt.Sprintf("%s (key %s)", s.Signer, s.KeyID)
+	case errors.Is(s.Err, ErrUnsigned):
+		return "unsigned"
+	}
+	return "untrusted: " + s.Err.Error()
+}
+
+// VerifyFile checks the signature next to a profile file against the
+// trusted keys. The signed "file:" comment must name the file, so a valid
+// signature copied next to another profile, or kept after a rename, is
+// rejected.
+func VerifyFile(path string, store *TrustStore) SignatureStatus {
+	sigData, err := os.ReadFile(path + SignatureExt)
+	if errors.Is(err, fs.ErrNotExist) {
+		return SignatureStatus{Err: ErrUnsigned}
+	}
+	if err != nil {
+		return SignatureStatus{Err: err}
+	}
+	sig, err := minisign.ParseSignature(sigData)
+	if err != nil {
+		return SignatureStatus{Err: err}
+	}
+	status := SignatureStatus{KeyID: minisign.PublicKey{ID: sig.KeyID}.IDString()}
+
+	keys, err := store.List()
+	if err != nil {
+		status.Err = fmt.Errorf("cannot read trusted keys: %w", err)
+		return status
+	}
+	var signer *TrustedKey
+	for i := range keys {
+		if keys[i].ID == sig.KeyID {
+			signer = &keys[i]
+		}
+	}
+	if signer == nil {
+		status.Err = fmt.Errorf("signed by key %s, which is not trusted", status.KeyID)
+		return status
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	if err := signer.Verify(data, sig); err != nil {
+		if errors.Is(err, minisign.ErrMismatch) {
+			err = fmt.Errorf("%w; the profile changed after it was signed", err)
+		}
+		status.Err = err
+		return status
+	}
+	if name := sig.File(); name != filepath.Base(path) {
+		status.Err = fmt.Errorf("signature is for %q, not %q", name, filepath.Base(path))
+		return status
+	}
+	status.Signer = signer.Name
+	status.Signed = sig.Timestamp()
+	return status
+}
+
+// SignFile writes a detached signature for the profile file at path and
+// returns the signature's path.
+func SignFile(path string, key *minisign.SecretKey) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	comment := fmt.Sprintf("timestamp:%d\tfile:%s", time.Now().Unix(), filepath.Base(path))
+	sigPath := path + SignatureExt
+	if err := os.WriteFile(sigPath, minisign.Sign(data, key, comment), 0644); err != nil {
+		return "", err
+	}
+	return sigPath, nil
+}
+
+// CommandFields lists the fields of a profile that run commands when it is
+// applied or afterwards: its post-apply hook, settings hooks, status line
+// command, and MCP servers, at the top level or in any scope.
+func (p *Profile) CommandFields() []string {
+	var fields []string
+	if p.PostApply != nil && (p.PostApply.Script != "" || p.PostApply.Command != "") {
+		fields = append(fields, "postApply")
+	}
+	if len(p.SettingsHooks) > 0 {
+		fields = append(fields, "settingsHooks")
+	}
+	servers := p.MCPServers
+	blocks := []*SettingsBlock{p.Settings}
+	if p.PerScope != nil {
+		for _, s := range []*ScopeSettings{p.PerScope.User, p.PerScope.Project, p.PerScope.Local} {
+			if s != nil {
+				servers = append(servers, s.MCPServers...)
+				blocks = append(blocks, s.Settings)
+			}
+		}
+	}
+	for _, block := range blocks {
+		if block != nil && block.StatusLine != nil && block.StatusLine.Command != "" {
+			fields = append(fields, "statusLine")
+			break
+		}
+	}
+	for _, server := range servers {
+		if server.Command != "" {
+			fields = append(fields, "mcpServers")
+			break
+		}
+	}
+	return fields
+}
+
+// ProfileTrust is the signature of one profile taking part in an apply.
+type ProfileTrust struct {
+	Name     string
+	Path     string // "" for built-in profiles
+	Status   SignatureStatus
+	Commands []string // CommandFields of the profile
+}
+
+// CheckTrust verifies the profiles named, and every profile they include,
+// against the trust store. Names resolve as for apply: a saved profile,
+// preferring one at the root of profilesDir, or a built-in one.
+func CheckTrust(profilesDir string, names []string, store *TrustStore) ([]ProfileTrust, error) {
+	var results []ProfileTrust
+	seen := make(map[string]bool)
+	var visit func(name string) error
+	visit = func(name string) error {
+		if seen[name] {
+			return nil
+		}
+		seen[name] = true
+
+		entry := ProfileTrust{Name: name}
+		var p *Profile
+		path, err := trustProfilePath(profilesDir, name)
+		if err != nil {
+			return err
+		}
+		if path != "" {
+			if p, err = LoadFromPath(path); err != nil {
+				return fmt.Errorf("failed to load profile %q: %w", name, err)
+			}
+			entry.Path = path
+			entry.Status = VerifyFile(path, store)
+		} else {
+			if p, err = GetEmbeddedProfile(name); err != nil {
+				return fmt.Errorf("profile %q not found", name)
+			}
+			entry.Status = SignatureStatus{BuiltIn: true}
+		}
+		entry.Commands = p.CommandFields()
+		results = append(results, entry)
+
+		for _, include := range p.Includes {
+			if err := visit(include); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// trustProfilePath finds the saved profile for name, or "" for a built-in.
+func trustProfilePath(profilesDir, name string) (string, error) {
+	paths, err := FindProfilePaths(profilesDir, name)
+	if err != nil {
+		return "", err
+	}
+	if len(paths) == 1 {
+		return paths[0], nil
+	}
+	root, err := filepath.Abs(profilesDir)
+	if err != nil {
+		return "", err
+	}
+	relPaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if filepath.Dir(path) == root {
+			return path, nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		relPaths = append(relPaths, TrimProfileExt(filepath.ToSlash(rel)))
+	}
+	if len(paths) > 1 {
+		return "", &AmbiguousProfileError{Name: name, Paths: relPaths}
+	}
+	return "", nil
+}
+
+// UntrustedCommands returns the profiles that run commands but are not
+// built in or signed by a trusted key.
+func UntrustedCommands(results []ProfileTrust) []ProfileTrust {
+	var untrusted []ProfileTrust
+	for _, r := range results {
+		if len(r.Commands) > 0 && !r.Status.Trusted() {
+			untrusted = append(untrusted, r)
+		}
+	}
+	return untrusted
+}
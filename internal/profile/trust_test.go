@@ -0,0 +1,148 @@
+// ABOUTME: Tests for profile signatures, the trusted key store, and trust enforcement for hooks
+// ABOUTME: Covers tampering, unknown keys, include checks, and RunHook refusal
+package profile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/claudeup/claudeup/v5/internal/minisign"
+)
+
+func signedProfile(t *testing.T, dir string, p *Profile) (string, *minisign.SecretKey) {
+	t.Helper()
+	if err := Save(dir, p); err != nil {
+		t.Fatal(err)
+	}
+	key, err := minisign.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, p.Name+".json")
+	if _, err := SignFile(path, key); err != nil {
+		t.Fatal(err)
+	}
+	return path, key
+}
+
+func TestVerifyFile(t *testing.T) {
+	dir := t.TempDir()
+	store := &TrustStore{Dir: filepath.Join(dir, "trusted-keys")}
+	path, key := signedProfile(t, dir, &Profile{Name: "team", PostApply: &PostApplyHook{Command: "make setup"}})
+
+	if status := VerifyFile(path, store); status.Trusted() || !strings.Contains(status.Err.Error(), "not trusted") {
+		t.Errorf("a key outside the store should not be trusted: %+v", status)
+	}
+
+	public := key.Public()
+	if err := store.Add("platform", &public); err != nil {
+		t.Fatal(err)
+	}
+	status := VerifyFile(path, store)
+	if !status.Trusted() || status.Signer != "platform" || status.KeyID != public.IDString() || status.Signed.IsZero() {
+		t.Errorf("expected a trusted signature from platform, got %+v", status)
+	}
+	if err := store.Add("again", &public); err == nil {
+		t.Error("a key should be trusted under one name only")
+	}
+
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if status := VerifyFile(path, store); status.Trusted() || !strings.Contains(status.Err.Error(), "does not match") {
+		t.Errorf("an edited profile should fail verification: %+v", status)
+	}
+
+	// A signature moved next to another profile names the file it signed
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	renamed := filepath.Join(dir, "renamed.json")
+	for _, suffix := range []string{"", SignatureExt} {
+		if err := os.Rename(path+suffix, renamed+suffix); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if status := VerifyFile(renamed, store); status.Trusted() || !strings.Contains(status.Err.Error(), `signature is for "team.json"`) {
+		t.Errorf("a renamed profile should fail verification: %+v", status)
+	}
+
+	if status := VerifyFile(filepath.Join(dir, "missing.json"), store); !errors.Is(status.Err, ErrUnsigned) || status.String() != "unsigned" {
+		t.Errorf("expected unsigned, got %+v", status)
+	}
+}
+
+func TestCheckTrust(t *testing.T) {
+	dir := t.TempDir()
+	store := &TrustStore{Dir: filepath.Join(dir, "trusted-keys")}
+	_, key := signedProfile(t, dir, &Profile{Name: "signed", SettingsHooks: map[string][]HookEntry{"Stop": {{Type: "command", Command: "notify"}}}})
+	public := key.Public()
+	if err := store.Add("platform", &public); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []*Profile{
+		{Name: "servers", MCPServers: []MCPServer{{Name: "db", Command: "db-mcp"}}},
+		{Name: "plain", Plugins: []string{"a@m"}},
+		{Name: "stack", Includes: []string{"signed", "servers", "plain"}},
+	} {
+		if err := Save(dir, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := CheckTrust(dir, []string{"stack"}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected the stack and its three includes, got %+v", results)
+	}
+	untrusted := UntrustedCommands(results)
+	if len(untrusted) != 1 || untrusted[0].Name != "servers" || strings.Join(untrusted[0].Commands, ",") != "mcpServers" {
+		t.Errorf("only the unsigned include with MCP servers should be untrusted, got %+v", untrusted)
+	}
+
+	results, err = CheckTrust(dir, []string{"default"}, store)
+	if err != nil || !results[0].Status.BuiltIn || !results[0].Status.Trusted() {
+		t.Errorf("built-in profiles should be trusted: %+v, %v", results, err)
+	}
+}
+
+func TestCommandFields(t *testing.T) {
+	p := &Profile{
+		Name:     "status",
+		Settings: &SettingsBlock{Model: "opus"},
+		PerScope: &PerScopeSettings{Project: &ScopeSettings{
+			Settings: &SettingsBlock{StatusLine: &StatusLine{Type: "command", Command: "statusline.sh"}},
+		}},
+	}
+	if got := strings.Join(p.CommandFields(), ","); got != "statusLine" {
+		t.Errorf("a per-scope status line command should count, got %q", got)
+	}
+	p.PerScope = nil
+	if got := p.CommandFields(); len(got) != 0 {
+		t.Errorf("settings without commands should not count, got %v", got)
+	}
+}
+
+func TestRunHookEnforcesTrust(t *testing.T) {
+	p := &Profile{Name: "team", PostApply: &PostApplyHook{Command: "true"}}
+
+	refused := HookOptions{TrustPolicy: TrustRefuse}
+	if ShouldRunHook(p, "", "", "", refused) {
+		t.Error("ShouldRunHook should skip hooks the policy refuses")
+	}
+	if err := RunHook(p, refused); !errors.Is(err, ErrUntrustedHook) {
+		t.Errorf("RunHook should refuse, got %v", err)
+	}
+	if err := RunHook(p, HookOptions{TrustPolicy: TrustRefuse, Trusted: true}); err != nil {
+		t.Errorf("trusted hooks should run: %v", err)
+	}
+	if err := RunHook(p, HookOptions{TrustPolicy: TrustAllow}); err != nil {
+		t.Errorf("the allow policy should run hooks: %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+// ABOUTME: Validation of profile documents for profile validate, located by JSON pointers
+// ABOUTME: Checks keys and types against the schema, plugin formats, marketplace refs, and includes
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidationIssue is a problem found in a profile document. Pointer is a
+// JSON pointer (RFC 6901) to the offending value, "" for the whole document.
+type ValidationIssue struct {
+	Pointer string
+	Message string
+	Warning bool
+}
+
+// String renders the issue as "pointer: message".
+func (i ValidationIssue) String() string {
+	if i.Pointer == "" {
+		return i.Message
+	}
+	return i.Pointer + ": " + i.Message
+}
+
+// ValidateOptions supplies the context checks beyond the document need.
+type ValidateOptions struct {
+	// Name is used when the document has no "name", as when loading by path.
+	Name string
+	// Format is the document's format; "" means JSON.
+	Format string
+	// RegistryKeys are the installed marketplaces plugin refs may resolve to.
+	RegistryKeys []string
+	// Loader resolves includes to find cycles and missing profiles. A nil
+	// Loader skips the include checks.
+	Loader ProfileLoader
+}
+
+// ValidateDocument checks a profile document and returns its issues,
+// errors and warnings together. Unknown keys, wrong types,
+// an unsupported schemaVersion, malformed plugins, plugin marketplace refs
+// that resolve to neither a profile marketplace nor an installed one, bad
+// merge rules, and include cycles are errors; deprecated keys are warnings.
+func ValidateDocument(data []byte, opts ValidateOptions) []ValidationIssue {
+	if opts.Format != "" {
+		converted, err := DocumentJSON(data, opts.Format)
+		if err != nil {
+			return []ValidationIssue{{Message: err.Error()}}
+		}
+		data = converted
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return []ValidationIssue{{Message: describeJSONError(data, err)}}
+	}
+
+	var issues []ValidationIssue
+	profileSchema().check(doc, "", &issues)
+	if hasErrors(issues) {
+		return issues
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return append(issues, ValidationIssue{Message: err.Error()})
+	}
+	if _, err := documentSchemaVersion(raw); err != nil {
+		return append(issues, ValidationIssue{Pointer: "/schemaVersion", Message: err.Error()})
+	}
+
+	var p Profile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return append(issues, ValidationIssue{Message: err.Error()})
+	}
+	if p.Name == "" {
+		p.Name = opts.Name
+	}
+
+	for _, loc := range pluginLocations(&p) {
+		for i, plugin := range loc.plugins {
+			pointer := fmt.Sprintf("%s/%d", loc.pointer, i)
+			if err := ValidatePluginFormat(plugin); err != nil {
+				issues = append(issues, ValidationIssue{Pointer: pointer, Message: err.Error()})
+				continue
+			}
+			if err := ValidatePluginMarketplaces([]string{plugin}, p.Marketplaces, opts.RegistryKeys); err != nil {
+				ref := plugin[strings.LastIndex(plugin, "@")+1:]
+				issues = append(issues, ValidationIssue{Pointer: pointer, Message: fmt.Sprintf(
+					"marketplace %q is neither listed in the profile's marketplaces nor installed", ref)})
+			}
+		}
+	}
+
+	if err := validateMergeRules(&p); err != nil {
+		return append(issues, ValidationIssue{Pointer: "/merge", Message: err.Error()})
+	}
+	if p.IsStack() && opts.Loader != nil {
+		if _, err := ResolveIncludes(&p, opts.Loader); err != nil {
+			issues = append(issues, ValidationIssue{Pointer: "/includes", Message: err.Error()})
+		}
+	}
+	return issues
+}
+
+// pluginList is a plugin list in a profile and its JSON pointer.
+type pluginList struct {
+	pointer string
+	plugins []string
+}
+
+func pluginLocations(p *Profile) []pluginList {
+	lists := []pluginList{{"/plugins", p.Plugins}}
+	if p.PerScope != nil {
+		for _, s := range []profileScope{{"user", p.PerScope.User}, {"project", p.PerScope.Project}, {"local", p.PerScope.Local}} {
+			if s.settings != nil {
+				lists = append(lists, pluginList{"/perScope/" + s.scope + "/plugins", s.settings.Plugins})
+			}
+		}
+	}
+	return lists
+}
+
+func hasErrors(issues []ValidationIssue) bool {
+	for _, issue := range issues {
+		if !issue.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// describeJSONError adds the line and column to JSON syntax errors.
+func describeJSONError(data []byte, err error) string {
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return "invalid JSON: " + err.Error()
+	}
+	// Offset counts the bytes read up to and including the offending one
+	offset := int(syntaxErr.Offset) - 1
+	if offset > len(data) {
+		offset = len(data)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	column := offset - bytes.LastIndexByte(data[:offset], '\n')
+	return fmt.Sprintf("invalid JSON at line %d, column %d: %v", line, column, err)
+}
+
+// check validates a decoded JSON value against the schema node, appending
+// issues located at pointer.
+func (n *schemaNode) check(value interface{}, pointer string, issues *[]ValidationIssue) {
+	if value == nil {
+		return // null decodes to the zero value
+	}
+	if len(n.OneOf) > 0 {
+		var kinds []string
+		for _, option := range n.OneOf {
+			if jsonKind(value) == option.Type {
+				option.check(value, pointer, issues)
+				return
+			}
+			kinds = append(kinds, option.Type)
+		}
+		*issues = append(*issues, ValidationIssue{Pointer: pointer, Message: fmt.Sprintf("expected %s, got %s", strings.Join(kinds, " or "), jsonKind(value))})
+		return
+	}
+	if n.Type == "" {
+		return
+	}
+	if kind := jsonKind(value); kind != n.Type {
+		*issues = append(*issues, ValidationIssue{Pointer: pointer, Message: fmt.Sprintf("expected %s, got %s", n.Type, kind)})
+		return
+	}
+
+	switch v := value.(type) {
+	case string:
+		if len(n.Enum) > 0 && !containsString(n.Enum, v) {
+			*issues = append(*issues, ValidationIssue{Pointer: pointer, Message: fmt.Sprintf("%q is not one of %s", v, strings.Join(n.Enum, ", "))})
+		}
+	case []interface{}:
+		for i, item := range v {
+			n.Items.check(item, fmt.Sprintf("%s/%d", pointer, i), issues)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := pointer + "/" + escapePointer(key)
+			if prop, ok := n.Properties[key]; ok {
+				if prop.Deprecated != "" {
+					*issues = append(*issues, ValidationIssue{Pointer: child, Warning: true, Message: fmt.Sprintf("deprecated; use %q", prop.Deprecated)})
+					continue
+				}
+				prop.check(v[key], child, issues)
+				continue
+			}
+			if n.Additional != nil {
+				n.Additional.check(v[key], child, issues)
+				continue
+			}
+			message := fmt.Sprintf("unknown key %q", key)
+			if suggestion := closestKey(key, n.sortedKeys()); suggestion != "" {
+				message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			*issues = append(*issues, ValidationIssue{Pointer: child, Message: message})
+		}
+	}
+}
+
+// jsonKind names the JSON type of a value decoded with UseNumber.
+func jsonKind(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	}
+	return "null"
+}
+
+// escapePointer escapes a key for use in a JSON pointer.
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// closestKey suggests a known key for a misspelled one: a case-insensitive
+// match, or the nearest key within two edits.
+func closestKey(key string, known []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range known {
+		if strings.EqualFold(candidate, key) {
+			return candidate
+		}
+		if d := editDistance(strings.ToLower(key), strings.ToLower(candidate)); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(b)]
+}
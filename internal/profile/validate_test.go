@@ -0,0 +1,145 @@
+// ABOUTME: Tests for profile schema versions, the generated JSON Schema, and document validation
+// ABOUTME: Covers the migration chain, JSON pointer locations of issues, and the published schema copy
+package profile
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMigrateProfileJSON(t *testing.T) {
+	legacy := `{
+		"name": "old",
+		"localItems": {"agents": ["reviewer"]},
+		"perScope": {"project": {"localItems": {"rules": ["style"]}, "plugins": ["a@m"]}}
+	}`
+	var p Profile
+	if err := json.Unmarshal([]byte(legacy), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", p.SchemaVersion, CurrentSchemaVersion)
+	}
+	if p.Extensions == nil || !reflect.DeepEqual(p.Extensions.Agents, []string{"reviewer"}) {
+		t.Errorf("top-level localItems not migrated: %+v", p.Extensions)
+	}
+	if ext := p.PerScope.Project.Extensions; ext == nil || !reflect.DeepEqual(ext.Rules, []string{"style"}) {
+		t.Errorf("per-scope localItems not migrated: %+v", ext)
+	}
+
+	future := `{"name": "new", "schemaVersion": 99}`
+	if err := json.Unmarshal([]byte(future), &p); err == nil || !strings.Contains(err.Error(), "supports up to") {
+		t.Errorf("expected an error for a newer schema version, got %v", err)
+	}
+}
+
+func TestSaveStampsSchemaVersion(t *testing.T) {
+	dir := t.TempDir()
+	if err := Save(dir, &Profile{Name: "stamped", Plugins: []string{"a@m"}}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "stamped.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["schemaVersion"] != float64(CurrentSchemaVersion) {
+		t.Errorf("schemaVersion = %v, want %d", doc["schemaVersion"], CurrentSchemaVersion)
+	}
+}
+
+func TestValidateDocument(t *testing.T) {
+	cases := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{"valid", `{"name": "ok", "marketplaces": [{"source": "github", "repo": "acme/tools"}], "plugins": ["lint@tools"]}`, nil},
+		{"unknown key with suggestion", `{"name": "x", "mcpservers": []}`, []string{`/mcpservers: unknown key "mcpservers" (did you mean "mcpServers"?)`}},
+		{"nested unknown key", `{"perScope": {"user": {"plugin": ["a@m"]}}}`, []string{`/perScope/user/plugin: unknown key "plugin" (did you mean "plugins"?)`}},
+		{"wrong type", `{"plugins": "a@m"}`, []string{"/plugins: expected array, got string"}},
+		{"bad enum", `{"merge": {"mcpServers": "first"}}`, []string{`/merge/mcpServers: "first" is not one of override, error, append, remove`}},
+		{"deprecated key", `{"localItems": {"agents": ["a"]}}`, []string{`/localItems: deprecated; use "extensions"`}},
+		{"bad plugin format", `{"plugins": ["lint"]}`, []string{`/plugins/0: invalid plugin format "lint": expected name@marketplace-ref`}},
+		{"unresolved marketplace", `{"perScope": {"local": {"plugins": ["lint@nowhere"]}}}`, []string{`/perScope/local/plugins/0: marketplace "nowhere" is neither listed in the profile's marketplaces nor installed`}},
+		{"installed marketplace resolves", `{"plugins": ["lint@installed"]}`, nil},
+		{"future schema version", `{"schemaVersion": 7}`, []string{"/schemaVersion: profile uses schema version 7"}},
+		{"merge rules on a leaf", `{"name": "leaf", "merge": {"mcpServers": "error"}}`, []string{"/merge: profile \"leaf\" has merge rules but no includes"}},
+		{"syntax error", "{\n  \"name\": \"x\",\n  \"plugins\": [,]\n}", []string{"invalid JSON at line 3, column 15"}},
+		{"escaped pointer", `{"settingsHooks": {"a/b": [{"type": "command", "colour": "red"}]}}`, []string{`/settingsHooks/a~1b/0/colour: unknown key "colour"`}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			issues := ValidateDocument([]byte(c.doc), ValidateOptions{RegistryKeys: []string{"installed"}})
+			if len(issues) != len(c.want) {
+				t.Fatalf("got %d issues %v, want %d", len(issues), issues, len(c.want))
+			}
+			for i, want := range c.want {
+				if !strings.HasPrefix(issues[i].String(), want) {
+					t.Errorf("issue %d = %q, want prefix %q", i, issues[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateDocumentIncludes(t *testing.T) {
+	loader := &mockLoader{profiles: map[string]*Profile{
+		"a": {Name: "a", Includes: []string{"b"}},
+		"b": {Name: "b", Includes: []string{"a"}},
+	}}
+
+	issues := ValidateDocument([]byte(`{"name": "top", "includes": ["a"]}`), ValidateOptions{Loader: loader})
+	if len(issues) != 1 || issues[0].Pointer != "/includes" || !strings.Contains(issues[0].Message, "include cycle detected") {
+		t.Errorf("expected an include cycle at /includes, got %v", issues)
+	}
+
+	issues = ValidateDocument([]byte(`{"name": "top", "includes": ["missing"]}`), ValidateOptions{Loader: loader})
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, `"missing"`) {
+		t.Errorf("expected a missing include, got %v", issues)
+	}
+}
+
+func TestEmbeddedProfilesValidate(t *testing.T) {
+	entries, err := embeddedProfiles.ReadDir("profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		data, err := GetEmbeddedProfileData(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, issue := range ValidateDocument(data, ValidateOptions{Name: name}) {
+			t.Errorf("%s: %s", name, issue)
+		}
+	}
+}
+
+// The schema under docs/ is served for editors; regenerate it with
+// "claudeup profile schema > docs/profile.schema.json" when this fails.
+func TestPublishedSchemaIsCurrent(t *testing.T) {
+	published, err := os.ReadFile(filepath.Join("..", "..", "docs", "profile.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := json.MarshalIndent(JSONSchema(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(published)) != string(generated) {
+		t.Error("docs/profile.schema.json is out of date")
+	}
+}
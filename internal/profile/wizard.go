@@ -14,6 +14,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/claude"
 )
 
 // ErrGumCanceled indicates the user canceled a gum prompt.
@@ -122,11 +124,7 @@ func sanitizeForGum(s string) error {
 
 // knownMarketplaceEntry represents a marketplace entry in known_marketplaces.json
 type knownMarketplaceEntry struct {
-	Source struct {
-		Source string `json:"source"`
-		Repo   string `json:"repo,omitempty"`
-		URL    string `json:"url,omitempty"`
-	} `json:"source"`
+	Source claude.MarketplaceSource `json:"source"`
 }
 
 // loadKnownMarketplaces reads marketplaces from ~/.claude/plugins/known_marketplaces.json
@@ -150,6 +148,8 @@ func loadKnownMarketplaces() ([]Marketplace, error) {
 			Source: entry.Source.Source,
 			Repo:   entry.Source.Repo,
 			URL:    entry.Source.URL,
+			Path:   entry.Source.Path,
+			Ref:    entry.Source.Ref,
 		})
 	}
 
@@ -189,7 +189,7 @@ func GetAvailableMarketplaces() []Marketplace {
 }
 
 // filterValidMarketplaces removes marketplaces with empty display names.
-// This handles malformed entries where both repo and url are empty.
+// This handles malformed entries where repo, url, and path are all empty.
 func filterValidMarketplaces(marketplaces []Marketplace) []Marketplace {
 	result := make([]Marketplace, 0, len(marketplaces))
 	for _, m := range marketplaces {
@@ -655,12 +655,8 @@ func listPluginsFromMarketplace(marketplace Marketplace) ([]string, error) {
 	}
 
 	var knownMarketplaces map[string]struct {
-		Source struct {
-			Source string `json:"source"`
-			Repo   string `json:"repo,omitempty"`
-			URL    string `json:"url,omitempty"`
-		} `json:"source"`
-		InstallLocation string `json:"installLocation"`
+		Source          claude.MarketplaceSource `json:"source"`
+		InstallLocation string                   `json:"installLocation"`
 	}
 
 	if err := json.Unmarshal(data, &knownMarketplaces); err != nil {
@@ -670,18 +666,9 @@ func listPluginsFromMarketplace(marketplace Marketplace) ([]string, error) {
 	// Find matching marketplace by comparing source details
 	var marketplacePath string
 	for _, entry := range knownMarketplaces {
-		if entry.Source.Source == marketplace.Source {
-			match := false
-			if marketplace.Repo != "" && entry.Source.Repo == marketplace.Repo {
-				match = true
-			} else if marketplace.URL != "" && entry.Source.URL == marketplace.URL {
-				match = true
-			}
-
-			if match {
-				marketplacePath = entry.InstallLocation
-				break
-			}
+		if marketplaceKey(marketplaceFromSource(entry.Source)) == marketplaceKey(marketplace) {
+			marketplacePath = entry.InstallLocation
+			break
 		}
 	}
 
@@ -3,6 +3,7 @@
 package profile
 
 import (
+	"context"
 	"sync"
 )
 
@@ -27,6 +28,13 @@ type JobResult struct {
 // RunWorkerPoolWithCallback executes jobs and calls callback after each completion
 // Useful for updating progress UI as jobs complete
 func RunWorkerPoolWithCallback(jobs []Job, workers int, callback func(JobResult)) []JobResult {
+	return RunWorkerPoolContext(context.Background(), jobs, workers, callback)
+}
+
+// RunWorkerPoolContext is RunWorkerPoolWithCallback with cancellation. Once ctx
+// is done, jobs that have not started are reported with ctx.Err() instead of
+// running; jobs already running should watch ctx themselves.
+func RunWorkerPoolContext(ctx context.Context, jobs []Job, workers int, callback func(JobResult)) []JobResult {
 	if len(jobs) == 0 {
 		return nil
 	}
@@ -48,7 +56,9 @@ func RunWorkerPoolWithCallback(jobs []Job, workers int, callback func(JobResult)
 					Name: job.Name,
 					Type: job.Type,
 				}
-				if err := job.Execute(); err != nil {
+				if err := ctx.Err(); err != nil {
+					result.Error = err
+				} else if err := job.Execute(); err != nil {
 					result.Error = err
 					result.Success = false
 				} else {
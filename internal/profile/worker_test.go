@@ -3,6 +3,8 @@
 package profile
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync/atomic"
 	"testing"
@@ -99,3 +101,31 @@ func TestWorkerPoolPreservesJobName(t *testing.T) {
 		t.Errorf("expected job name preserved, got %s", results[0].Name)
 	}
 }
+
+func TestWorkerPoolContextSkipsJobsAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var executed int32
+
+	jobs := []Job{
+		{Name: "first", Execute: func() error { atomic.AddInt32(&executed, 1); cancel(); return nil }},
+		{Name: "second", Execute: func() error { atomic.AddInt32(&executed, 1); return nil }},
+		{Name: "third", Execute: func() error { atomic.AddInt32(&executed, 1); return nil }},
+	}
+
+	results := RunWorkerPoolContext(ctx, jobs, 1, nil)
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if executed != 1 {
+		t.Errorf("expected only the first job to run, got %d", executed)
+	}
+	for _, r := range results {
+		if r.Name == "first" {
+			continue
+		}
+		if r.Success || !errors.Is(r.Error, context.Canceled) {
+			t.Errorf("job %s: expected context.Canceled, got success=%v err=%v", r.Name, r.Success, r.Error)
+		}
+	}
+}
@@ -1,27 +1,148 @@
 // ABOUTME: Self-update functionality for claudeup CLI
-// ABOUTME: Downloads and replaces binary from GitHub releases
+// ABOUTME: Downloads and replaces binary from GitHub releases or a mirror, keeping the old one for rollback
 package selfupdate
 
 import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/claudeup/claudeup/v5/internal/minisign"
 )
 
 const (
-	DefaultAPIURL   = "https://api.github.com/repos/claudeup/claudeup/releases/latest"
+	DefaultAPIURL   = "https://api.github.com/repos/claudeup/claudeup/releases"
 	DefaultAssetURL = "https://github.com/claudeup/claudeup/releases/download"
 )
 
-// CheckLatestVersion queries the GitHub API for the latest release version
+// Release channels.
+const (
+	ChannelStable = "stable" // releases only (the default)
+	ChannelBeta   = "beta"   // releases and pre-releases
+)
+
+// ParseChannel validates a channel name; "" is the stable channel.
+func ParseChannel(s string) (string, error) {
+	switch s {
+	case "", ChannelStable:
+		return ChannelStable, nil
+	case ChannelBeta:
+		return ChannelBeta, nil
+	}
+	return "", fmt.Errorf("unknown update channel %q (use stable or beta)", s)
+}
+
+// releasePublicKey is the minisign public key release checksums are signed
+// with. Release builds set it with
+// -ldflags "-X github.com/claudeup/claudeup/v5/internal/selfupdate.releasePublicKey=<key>".
+var releasePublicKey string
+
+// ReleaseKey returns the key release checksums are signed with, or nil for
+// builds made without one.
+func ReleaseKey() (*minisign.PublicKey, error) {
+	if releasePublicKey == "" {
+		return nil, nil
+	}
+	key, err := minisign.ParsePublicKey([]byte(releasePublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid release key in this build: %w", err)
+	}
+	return key, nil
+}
+
+// Source is where releases are published: GitHub, or a mirror that serves
+// the same layout under one base URL.
+type Source struct {
+	APIURL   string // release list in the GitHub API format, with the newest stable release at APIURL/latest
+	AssetURL string // release files at AssetURL/<version>/<name>
+}
+
+// NewSource returns the GitHub source, or for a non-empty baseURL a mirror
+// serving <baseURL>/releases, <baseURL>/releases/latest, and
+// <baseURL>/releases/download/<version>/<name>.
+func NewSource(baseURL string) Source {
+	if baseURL == "" {
+		return Source{APIURL: DefaultAPIURL, AssetURL: DefaultAssetURL}
+	}
+	base := strings.TrimSuffix(baseURL, "/")
+	return Source{APIURL: base + "/releases", AssetURL: base + "/releases/download"}
+}
+
+// LatestVersion returns the newest version on the channel.
+func (s Source) LatestVersion(channel string) (string, error) {
+	if channel != ChannelBeta {
+		return CheckLatestVersion(s.APIURL + "/latest")
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	req, err := http.NewRequest("GET", s.APIURL+"?per_page=100", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch releases: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("release list returned status %d", resp.StatusCode)
+	}
+
+	var releases []struct {
+		TagName string `json:"tag_name"`
+		Draft   bool   `json:"draft"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return "", fmt.Errorf("failed to parse releases: %w", err)
+	}
+
+	latest := ""
+	for _, r := range releases {
+		if !r.Draft && (latest == "" || CompareVersions(r.TagName, latest) > 0) {
+			latest = r.TagName
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no releases found")
+	}
+	return latest, nil
+}
+
+// BinaryURL returns the download URL of the binary for the current platform.
+// The version must be in semver format (e.g., "v1.2.3").
+func (s Source) BinaryURL(version string) string {
+	return fmt.Sprintf("%s/%s/%s", s.AssetURL, version, binaryName())
+}
+
+// ChecksumsURL returns the checksums file URL for a version. Its signature
+// is at the same URL plus minisign.Extension.
+func (s Source) ChecksumsURL(version string) string {
+	return fmt.Sprintf("%s/%s/checksums.txt", s.AssetURL, version)
+}
+
+// binaryName is the release asset name for the current platform.
+func binaryName() string {
+	name := fmt.Sprintf("claudeup-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// CheckLatestVersion queries a GitHub API "latest release" URL for its version
 func CheckLatestVersion(apiURL string) (string, error) {
 	client := &http.Client{Timeout: 10 * time.Second}
 
@@ -108,12 +229,6 @@ func VerifyChecksum(filePath, expectedHash string) error {
 	return nil
 }
 
-// GetBinaryURL returns the download URL for the current platform.
-// The version must be in semver format (e.g., "v1.2.3").
-func GetBinaryURL(version string) string {
-	return fmt.Sprintf("%s/%s/claudeup-%s-%s", DefaultAssetURL, version, runtime.GOOS, runtime.GOARCH)
-}
-
 // ValidateVersion checks that a version string is in valid semver format
 func ValidateVersion(version string) error {
 	// Must not be empty
@@ -129,11 +244,6 @@ func ValidateVersion(version string) error {
 	return nil
 }
 
-// GetChecksumsURL returns the checksums file URL for a version
-func GetChecksumsURL(version string) string {
-	return fmt.Sprintf("%s/%s/checksums.txt", DefaultAssetURL, version)
-}
-
 // ReplaceBinary atomically replaces the current binary with a new one.
 // On failure, attempts to rollback to the original.
 // Handles cross-filesystem moves by falling back to copy-then-delete.
@@ -202,30 +312,52 @@ type UpdateResult struct {
 	Error error
 }
 
-// Update checks for and applies updates to the claudeup binary
-func Update(currentVersion, latestVersion, binaryPath string) UpdateResult {
+// Options controls where Update gets a release and how it checks it.
+type Options struct {
+	Source     Source
+	PublicKey  *minisign.PublicKey // checksums.txt must be signed by it
+	BinaryPath string              // binary to replace; "" for the running executable
+	BackupDir  string              // where the replaced binary is kept for Rollback; "" keeps none
+	Pinned     bool                // install targetVersion even when it is older than the current one
+
+	// InsecureSkipVerify allows an update without a PublicKey, checking
+	// the download against checksums.txt alone.
+	InsecureSkipVerify bool
+}
+
+// ErrNoReleaseKey is returned by Update when there is no key to check the
+// release's signature with and InsecureSkipVerify is not set.
+var ErrNoReleaseKey = errors.New("this build of claudeup has no release signing key, so the update can't be verified")
+
+// Update replaces the claudeup binary with targetVersion, after checking
+// the download against the release's checksums.txt and that file's
+// signature against opts.PublicKey.
+func Update(currentVersion, targetVersion string, opts Options) UpdateResult {
 	result := UpdateResult{
 		OldVersion: currentVersion,
-		NewVersion: latestVersion,
+		NewVersion: targetVersion,
 	}
 
 	// Check if update needed
-	if !IsNewer(currentVersion, latestVersion) {
-		result.AlreadyUpToDate = true
+	if opts.Pinned {
+		result.AlreadyUpToDate = currentVersion == targetVersion
+	} else {
+		result.AlreadyUpToDate = !IsNewer(currentVersion, targetVersion)
+	}
+	if result.AlreadyUpToDate {
+		return result
+	}
+	if opts.PublicKey == nil && !opts.InsecureSkipVerify {
+		result.Error = ErrNoReleaseKey
 		return result
 	}
 
 	// If no binary path provided, detect it
+	binaryPath := opts.BinaryPath
 	if binaryPath == "" {
 		var err error
-		binaryPath, err = os.Executable()
-		if err != nil {
-			result.Error = fmt.Errorf("failed to detect binary path: %w", err)
-			return result
-		}
-		binaryPath, err = filepath.EvalSymlinks(binaryPath)
-		if err != nil {
-			result.Error = fmt.Errorf("failed to resolve binary path: %w", err)
+		if binaryPath, err = executablePath(); err != nil {
+			result.Error = err
 			return result
 		}
 	}
@@ -239,24 +371,22 @@ func Update(currentVersion, latestVersion, binaryPath string) UpdateResult {
 	defer os.RemoveAll(tempDir)
 
 	// Validate version format before using in URLs
-	if err := ValidateVersion(latestVersion); err != nil {
+	if err := ValidateVersion(targetVersion); err != nil {
 		result.Error = fmt.Errorf("invalid version format: %w", err)
 		return result
 	}
 
-	// Download new binary
-	binaryURL := GetBinaryURL(latestVersion)
-	newBinaryPath, err := DownloadBinary(binaryURL, tempDir)
+	// Download and check checksums first; a missing release fails here
+	expectedHash, err := fetchExpectedChecksum(opts.Source.ChecksumsURL(targetVersion), targetVersion, opts.PublicKey)
 	if err != nil {
-		result.Error = fmt.Errorf("failed to download binary: %w", err)
+		result.Error = fmt.Errorf("failed to get checksum: %w", err)
 		return result
 	}
 
-	// Download and parse checksums
-	checksumsURL := GetChecksumsURL(latestVersion)
-	expectedHash, err := fetchExpectedChecksum(checksumsURL, latestVersion)
+	// Download new binary
+	newBinaryPath, err := DownloadBinary(opts.Source.BinaryURL(targetVersion), tempDir)
 	if err != nil {
-		result.Error = fmt.Errorf("failed to get checksum: %w", err)
+		result.Error = fmt.Errorf("failed to download binary: %w", err)
 		return result
 	}
 
@@ -266,6 +396,14 @@ func Update(currentVersion, latestVersion, binaryPath string) UpdateResult {
 		return result
 	}
 
+	// Keep the current binary so the update can be rolled back
+	if opts.BackupDir != "" {
+		if err := saveBackup(opts.BackupDir, binaryPath, currentVersion); err != nil {
+			result.Error = fmt.Errorf("failed to keep the current binary: %w", err)
+			return result
+		}
+	}
+
 	// Replace binary
 	if err := ReplaceBinary(binaryPath, newBinaryPath); err != nil {
 		result.Error = err
@@ -275,31 +413,119 @@ func Update(currentVersion, latestVersion, binaryPath string) UpdateResult {
 	return result
 }
 
-// fetchExpectedChecksum downloads checksums.txt and extracts the hash for current platform
-func fetchExpectedChecksum(checksumsURL, version string) (string, error) {
-	client := &http.Client{Timeout: 30 * time.Second}
+// executablePath returns the running binary's path with symlinks resolved.
+func executablePath() (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to detect binary path: %w", err)
+	}
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve binary path: %w", err)
+	}
+	return path, nil
+}
 
-	resp, err := client.Get(checksumsURL)
+// Files Update keeps in its backup directory.
+const (
+	backupBinaryFile = "claudeup.previous"
+	backupInfoFile   = "previous.json"
+)
+
+// Backup describes the binary the last update replaced.
+type Backup struct {
+	Version    string    `json:"version"`
+	BinaryPath string    `json:"binaryPath"`
+	ReplacedAt time.Time `json:"replacedAt"`
+}
+
+// saveBackup copies the binary at binaryPath into dir, replacing any
+// earlier backup.
+func saveBackup(dir, binaryPath, version string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := copyFile(binaryPath, filepath.Join(dir, backupBinaryFile)); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(Backup{Version: version, BinaryPath: binaryPath, ReplacedAt: time.Now().UTC()}, "", "  ")
 	if err != nil {
-		return "", fmt.Errorf("failed to download checksums: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
+	return os.WriteFile(filepath.Join(dir, backupInfoFile), append(data, '\n'), 0644)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("checksums download failed with status %d", resp.StatusCode)
+// LoadBackup returns the binary kept in dir by the last update, or nil if
+// there is none.
+func LoadBackup(dir string) (*Backup, error) {
+	data, err := os.ReadFile(filepath.Join(dir, backupInfoFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var backup Backup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", backupInfoFile, err)
 	}
+	if _, err := os.Stat(filepath.Join(dir, backupBinaryFile)); err != nil {
+		return nil, fmt.Errorf("previous binary is missing: %w", err)
+	}
+	return &backup, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+// Rollback puts the binary kept in dir back where the last update
+// installed over it, then discards the backup.
+func Rollback(dir string) (*Backup, error) {
+	backup, err := LoadBackup(dir)
+	if err != nil {
+		return nil, err
+	}
+	if backup == nil {
+		return nil, fmt.Errorf("no previous version to roll back to")
+	}
+	if err := ReplaceBinary(backup.BinaryPath, filepath.Join(dir, backupBinaryFile)); err != nil {
+		return nil, err
+	}
+	os.Remove(filepath.Join(dir, backupInfoFile))
+	return backup, nil
+}
+
+// fetchExpectedChecksum downloads checksums.txt, checks its signature when
+// key is set, and extracts the hash for the current platform. The release
+// workflow signs with the trusted comment "claudeup <tag>", so a signature
+// naming another release is rejected rather than replayed for version.
+func fetchExpectedChecksum(checksumsURL, version string, key *minisign.PublicKey) (string, error) {
+	body, err := fetchFile(checksumsURL)
 	if err != nil {
-		return "", fmt.Errorf("failed to read checksums: %w", err)
+		return "", fmt.Errorf("checksums %w", err)
+	}
+
+	if key != nil {
+		sigData, err := fetchFile(checksumsURL + minisign.Extension)
+		if err != nil {
+			return "", fmt.Errorf("checksums signature %w", err)
+		}
+		sig, err := minisign.ParseSignature(sigData)
+		if err != nil {
+			return "", fmt.Errorf("checksums signature: %w", err)
+		}
+		if err := key.Verify(body, sig); err != nil {
+			return "", fmt.Errorf("checksums signature: %w", err)
+		}
+		if want := "claudeup " + version; strings.TrimSpace(sig.TrustedComment) != want {
+			return "", fmt.Errorf("checksums signature is for %q, expected %q", sig.TrustedComment, want)
+		}
 	}
 
-	// Parse checksums.txt format: "hash  filename"
-	binaryName := fmt.Sprintf("claudeup-%s-%s", runtime.GOOS, runtime.GOARCH)
+	// Parse checksums.txt format: "hash  filename", where sha256sum marks
+	// binary-mode entries with a leading "*" on the filename
+	name := binaryName()
 	lines := strings.Split(string(body), "\n")
 	for _, line := range lines {
-		if strings.HasSuffix(line, binaryName) {
-			parts := strings.Fields(line)
+		parts := strings.Fields(line)
+		if len(parts) > 0 && strings.TrimPrefix(parts[len(parts)-1], "*") == name {
 			if len(parts) != 2 {
 				return "", fmt.Errorf("invalid checksum line format: expected 2 fields, got %d", len(parts))
 			}
@@ -318,5 +544,26 @@ func fetchExpectedChecksum(checksumsURL, version string) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("checksum not found for %s", binaryName)
+	return "", fmt.Errorf("checksum not found for %s", name)
+}
+
+// fetchFile downloads a small release file.
+func fetchFile(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed with status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+	return body, nil
 }
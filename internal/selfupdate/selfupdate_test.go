@@ -3,6 +3,8 @@
 package selfupdate
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io/fs"
 	"net/http"
@@ -10,6 +12,9 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/internal/minisign"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -148,12 +153,195 @@ var _ = Describe("ReplaceBinary", func() {
 	})
 })
 
+// releaseServer serves releases in the GitHub API layout NewSource expects
+// of a mirror. Each release has a binary for this platform, checksums.txt,
+// and, when signed by key, checksums.txt.minisig.
+type releaseServer struct {
+	*httptest.Server
+	releases []string // tags, with pre-releases containing "-"
+	files    map[string][]byte
+}
+
+func newReleaseServer(key *minisign.SecretKey, tags ...string) *releaseServer {
+	rs := &releaseServer{releases: tags, files: map[string][]byte{}}
+	for _, tag := range tags {
+		binary := []byte("claudeup " + tag)
+		sum := sha256.Sum256(binary)
+		checksums := []byte(fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), binaryName()))
+		rs.files["/releases/download/"+tag+"/"+binaryName()] = binary
+		rs.files["/releases/download/"+tag+"/checksums.txt"] = checksums
+		if key != nil {
+			rs.files["/releases/download/"+tag+"/checksums.txt.minisig"] = minisign.Sign(checksums, key, "claudeup "+tag)
+		}
+	}
+	rs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases":
+			fmt.Fprint(w, "[")
+			for i, tag := range rs.releases {
+				if i > 0 {
+					fmt.Fprint(w, ",")
+				}
+				fmt.Fprintf(w, `{"tag_name": %q, "prerelease": %t}`, tag, IsPrerelease(tag))
+			}
+			fmt.Fprint(w, "]")
+		case "/releases/latest":
+			latest := ""
+			for _, tag := range rs.releases {
+				if !IsPrerelease(tag) && (latest == "" || CompareVersions(tag, latest) > 0) {
+					latest = tag
+				}
+			}
+			fmt.Fprintf(w, `{"tag_name": %q}`, latest)
+		default:
+			data, ok := rs.files[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		}
+	}))
+	return rs
+}
+
 var _ = Describe("Update", func() {
+	var (
+		key        *minisign.SecretKey
+		server     *releaseServer
+		binaryPath string
+		backupDir  string
+		opts       Options
+	)
+
+	BeforeEach(func() {
+		var err error
+		key, err = minisign.GenerateKey()
+		Expect(err).NotTo(HaveOccurred())
+		server = newReleaseServer(key, "v1.0.0", "v1.1.0", "v1.2.0-beta.1")
+
+		tempDir := GinkgoT().TempDir()
+		binaryPath = filepath.Join(tempDir, "claudeup")
+		Expect(os.WriteFile(binaryPath, []byte("claudeup v1.0.0"), 0755)).To(Succeed())
+		backupDir = filepath.Join(tempDir, "backup")
+
+		public := key.Public()
+		opts = Options{Source: NewSource(server.URL), PublicKey: &public, BinaryPath: binaryPath, BackupDir: backupDir}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
 	It("returns AlreadyUpToDate when versions match", func() {
-		result := Update("v1.0.0", "v1.0.0", "")
+		result := Update("v1.0.0", "v1.0.0", Options{})
 		Expect(result.AlreadyUpToDate).To(BeTrue())
 		Expect(result.Error).NotTo(HaveOccurred())
 	})
+
+	It("picks the newest version on each channel", func() {
+		stable, err := opts.Source.LatestVersion(ChannelStable)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stable).To(Equal("v1.1.0"))
+
+		beta, err := opts.Source.LatestVersion(ChannelBeta)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(beta).To(Equal("v1.2.0-beta.1"))
+	})
+
+	It("installs a release with a signed checksum and keeps the old binary", func() {
+		result := Update("v1.0.0", "v1.1.0", opts)
+
+		Expect(result.Error).NotTo(HaveOccurred())
+		Expect(os.ReadFile(binaryPath)).To(Equal([]byte("claudeup v1.1.0")))
+		backup, err := LoadBackup(backupDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backup.Version).To(Equal("v1.0.0"))
+		Expect(backup.BinaryPath).To(Equal(binaryPath))
+	})
+
+	It("rolls back to the binary the update replaced", func() {
+		Expect(Update("v1.0.0", "v1.1.0", opts).Error).NotTo(HaveOccurred())
+
+		backup, err := Rollback(backupDir)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backup.Version).To(Equal("v1.0.0"))
+		Expect(os.ReadFile(binaryPath)).To(Equal([]byte("claudeup v1.0.0")))
+		Expect(LoadBackup(backupDir)).To(BeNil())
+
+		_, err = Rollback(backupDir)
+		Expect(err).To(MatchError(ContainSubstring("no previous version")))
+	})
+
+	It("installs an older pinned version only when pinned", func() {
+		Expect(Update("v1.1.0", "v1.0.0", opts).AlreadyUpToDate).To(BeTrue())
+
+		opts.Pinned = true
+		result := Update("v1.1.0", "v1.0.0", opts)
+
+		Expect(result.Error).NotTo(HaveOccurred())
+		Expect(os.ReadFile(binaryPath)).To(Equal([]byte("claudeup v1.0.0")))
+	})
+
+	It("refuses checksums signed by another key", func() {
+		other, _ := minisign.GenerateKey()
+		public := other.Public()
+		opts.PublicKey = &public
+
+		result := Update("v1.0.0", "v1.1.0", opts)
+
+		Expect(result.Error).To(MatchError(ContainSubstring("checksums signature: signed by key")))
+		Expect(os.ReadFile(binaryPath)).To(Equal([]byte("claudeup v1.0.0")))
+	})
+
+	It("refuses tampered checksums", func() {
+		server.files["/releases/download/v1.1.0/checksums.txt"] = append(server.files["/releases/download/v1.1.0/checksums.txt"], '\n')
+
+		result := Update("v1.0.0", "v1.1.0", opts)
+
+		Expect(result.Error).To(MatchError(ContainSubstring("signature does not match")))
+	})
+
+	It("refuses a signed release replayed under another tag", func() {
+		for _, file := range []string{"checksums.txt", "checksums.txt.minisig", binaryName()} {
+			server.files["/releases/download/v1.1.0/"+file] = server.files["/releases/download/v1.0.0/"+file]
+		}
+
+		result := Update("v1.0.0", "v1.1.0", opts)
+
+		Expect(result.Error).To(MatchError(ContainSubstring(`checksums signature is for "claudeup v1.0.0", expected "claudeup v1.1.0"`)))
+	})
+
+	It("refuses unsigned checksums when a key is set", func() {
+		delete(server.files, "/releases/download/v1.1.0/checksums.txt.minisig")
+
+		result := Update("v1.0.0", "v1.1.0", opts)
+
+		Expect(result.Error).To(MatchError(ContainSubstring("checksums signature download failed with status 404")))
+	})
+
+	It("refuses to update without a key unless verification is skipped", func() {
+		delete(server.files, "/releases/download/v1.1.0/checksums.txt.minisig")
+		opts.PublicKey = nil
+
+		result := Update("v1.0.0", "v1.1.0", opts)
+		Expect(result.Error).To(MatchError(ErrNoReleaseKey))
+		Expect(os.ReadFile(binaryPath)).To(Equal([]byte("claudeup v1.0.0")))
+
+		opts.InsecureSkipVerify = true
+		Expect(Update("v1.0.0", "v1.1.0", opts).Error).NotTo(HaveOccurred())
+		Expect(os.ReadFile(binaryPath)).To(Equal([]byte("claudeup v1.1.0")))
+	})
+
+	It("reports a missing release", func() {
+		opts.Pinned = true
+
+		result := Update("v1.0.0", "v9.9.9", opts)
+
+		Expect(result.Error).To(MatchError(ContainSubstring("status 404")))
+		Expect(LoadBackup(backupDir)).To(BeNil())
+	})
 })
 
 var _ = Describe("ValidateVersion", func() {
@@ -207,7 +395,22 @@ var _ = Describe("fetchExpectedChecksum", func() {
 			w.Write([]byte(checksumContent))
 		}))
 
-		hash, err := fetchExpectedChecksum(server.URL, "v1.0.0")
+		hash, err := fetchExpectedChecksum(server.URL, "v1.0.0", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hash).To(Equal(validHash))
+	})
+
+	It("matches the platform binary by its exact file name", func() {
+		binaryName := fmt.Sprintf("claudeup-%s-%s", runtime.GOOS, runtime.GOARCH)
+		evilHash := strings.Repeat("e", 64)
+		validHash := strings.Repeat("a", 64)
+		checksumContent := fmt.Sprintf("%s  evil-%s\n%s  %s\n", evilHash, binaryName, validHash, binaryName)
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(checksumContent))
+		}))
+
+		hash, err := fetchExpectedChecksum(server.URL, "v1.0.0", nil)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(hash).To(Equal(validHash))
 	})
@@ -220,7 +423,7 @@ var _ = Describe("fetchExpectedChecksum", func() {
 			w.Write([]byte(checksumContent))
 		}))
 
-		_, err := fetchExpectedChecksum(server.URL, "v1.0.0")
+		_, err := fetchExpectedChecksum(server.URL, "v1.0.0", nil)
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("checksum not found for"))
 	})
@@ -234,7 +437,7 @@ var _ = Describe("fetchExpectedChecksum", func() {
 			w.Write([]byte(checksumContent))
 		}))
 
-		_, err := fetchExpectedChecksum(server.URL, "v1.0.0")
+		_, err := fetchExpectedChecksum(server.URL, "v1.0.0", nil)
 		Expect(err).To(HaveOccurred())
 		// Either "not found" because the suffix doesn't match, or "invalid format"
 	})
@@ -247,7 +450,7 @@ var _ = Describe("fetchExpectedChecksum", func() {
 			w.Write([]byte(checksumContent))
 		}))
 
-		_, err := fetchExpectedChecksum(server.URL, "v1.0.0")
+		_, err := fetchExpectedChecksum(server.URL, "v1.0.0", nil)
 		Expect(err).To(HaveOccurred())
 		// Will return "not found" because line parsing fails validation
 	})
@@ -257,7 +460,7 @@ var _ = Describe("fetchExpectedChecksum", func() {
 			w.WriteHeader(http.StatusNotFound)
 		}))
 
-		_, err := fetchExpectedChecksum(server.URL, "v1.0.0")
+		_, err := fetchExpectedChecksum(server.URL, "v1.0.0", nil)
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("status 404"))
 	})
@@ -14,25 +14,76 @@ func IsNewer(localVersion, remoteVersion string) bool {
 		return true
 	}
 
-	local := parseVersion(localVersion)
-	remote := parseVersion(remoteVersion)
+	return CompareVersions(remoteVersion, localVersion) > 0
+}
 
-	// Compare major.minor.patch
-	if remote[0] > local[0] {
-		return true
-	}
-	if remote[0] < local[0] {
-		return false
+// CompareVersions returns -1, 0, or 1 as a is older than, the same as, or
+// newer than b. A pre-release ("v1.2.0-beta.1") is older than its release,
+// and pre-releases compare by their dot-separated parts, numerically where
+// both parts are numbers.
+func CompareVersions(a, b string) int {
+	va, vb := parseVersion(a), parseVersion(b)
+	for i := range va {
+		if va[i] != vb[i] {
+			return sign(va[i] - vb[i])
+		}
 	}
 
-	if remote[1] > local[1] {
-		return true
+	pa, pb := prerelease(a), prerelease(b)
+	switch {
+	case pa == pb:
+		return 0
+	case pa == "":
+		return 1
+	case pb == "":
+		return -1
 	}
-	if remote[1] < local[1] {
-		return false
+	partsA, partsB := strings.Split(pa, "."), strings.Split(pb, ".")
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		if c := comparePrereleasePart(partsA[i], partsB[i]); c != 0 {
+			return c
+		}
 	}
+	return sign(len(partsA) - len(partsB))
+}
+
+// IsPrerelease reports whether the version has a pre-release suffix.
+func IsPrerelease(v string) bool {
+	return prerelease(v) != ""
+}
 
-	return remote[2] > local[2]
+// prerelease returns the part of a version after its first "-", without
+// build metadata.
+func prerelease(v string) string {
+	v, _, _ = strings.Cut(v, "+")
+	_, pre, _ := strings.Cut(v, "-")
+	return pre
+}
+
+// comparePrereleasePart orders numeric parts numerically and before
+// alphanumeric ones, which compare as strings.
+func comparePrereleasePart(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return sign(na - nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
 }
 
 // parseVersion extracts major, minor, patch from version string
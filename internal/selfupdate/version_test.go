@@ -46,4 +46,19 @@ var _ = Describe("IsNewer", func() {
 	It("handles (devel) version as always outdated", func() {
 		Expect(IsNewer("(devel)", "v1.0.0")).To(BeTrue())
 	})
+
+	It("treats a release as newer than its pre-releases", func() {
+		Expect(IsNewer("v1.2.0-beta.2", "v1.2.0")).To(BeTrue())
+		Expect(IsNewer("v1.2.0", "v1.2.0-beta.2")).To(BeFalse())
+	})
+})
+
+var _ = Describe("CompareVersions", func() {
+	It("orders pre-releases by their parts", func() {
+		Expect(CompareVersions("v1.2.0-beta.10", "v1.2.0-beta.2")).To(Equal(1))
+		Expect(CompareVersions("v1.2.0-alpha", "v1.2.0-beta")).To(Equal(-1))
+		Expect(CompareVersions("v1.2.0-beta", "v1.2.0-beta.1")).To(Equal(-1))
+		Expect(CompareVersions("v1.2.0-rc.1", "v1.2.0-rc.1")).To(Equal(0))
+		Expect(CompareVersions("v1.3.0-beta.1", "v1.2.0")).To(Equal(1))
+	})
 })
@@ -0,0 +1,159 @@
+// ABOUTME: Acceptance tests for the backup command group
+// ABOUTME: Tests creating, listing, restoring with a diff, and pruning backup generations
+package acceptance
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/claudeup/claudeup/v5/internal/profile"
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var backupIDPattern = regexp.MustCompile(`Created backup (\S+)`)
+
+var _ = Describe("backup", func() {
+	var (
+		env        *helpers.TestEnv
+		projectDir string
+	)
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+		projectDir = env.ProjectDir("app")
+	})
+
+	createBackup := func(args ...string) string {
+		result := env.RunInDir(projectDir, append([]string{"backup", "create"}, args...)...)
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		match := backupIDPattern.FindStringSubmatch(result.Stdout)
+		Expect(match).To(HaveLen(2), result.Stdout)
+		return match[1]
+	}
+
+	It("reports when there are no backups", func() {
+		result := env.Run("backup", "list")
+
+		Expect(result.ExitCode).To(Equal(0))
+		Expect(result.Stdout).To(ContainSubstring("No backups"))
+	})
+
+	It("captures user and project files and lists the generation", func() {
+		env.CreateLocalScopeSettings(projectDir, map[string]bool{"local@m": true})
+		env.WriteFile(projectDir, ".mcp.json", `{"mcpServers":{}}`)
+
+		id := createBackup("--reason", "before experiment")
+
+		Expect(filepath.Join(env.ClaudeupDir, "backups", "generations", id, "manifest.json")).To(BeAnExistingFile())
+		result := env.Run("backup", "list")
+		Expect(result.ExitCode).To(Equal(0))
+		Expect(result.Stdout).To(ContainSubstring(id))
+		Expect(result.Stdout).To(ContainSubstring("before experiment"))
+		Expect(result.Stdout).To(ContainSubstring(projectDir))
+	})
+
+	It("shows a diff and restores the selected generation", func() {
+		env.CreateSettings(map[string]bool{"keep@m": true})
+		env.WriteFile(env.ClaudeDir, ".claude.json", `{"userID":"abc","mcpServers":{"db":{"command":"db-mcp"}}}`)
+		id := createBackup()
+
+		env.CreateSettings(map[string]bool{"other@m": true})
+		env.WriteFile(env.ClaudeDir, ".claude.json", `{"userID":"abc","numStartups":5}`)
+
+		result := env.RunInDir(projectDir, "backup", "restore", "--at", id, "-y")
+
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring(filepath.Join(env.ClaudeDir, "settings.json")))
+		Expect(result.Stdout).To(ContainSubstring("enabledPlugins"))
+		Expect(result.Stdout).To(ContainSubstring("+ mcpServers"))
+		Expect(result.Stdout).To(ContainSubstring("Previous state saved as backup"))
+		Expect(env.IsPluginEnabled("keep@m")).To(BeTrue())
+		Expect(env.IsPluginEnabled("other@m")).To(BeFalse())
+
+		claudeJSON := helpers.LoadJSON(filepath.Join(env.ClaudeDir, ".claude.json"))
+		Expect(claudeJSON).To(HaveKey("mcpServers"))
+		Expect(claudeJSON).To(HaveKeyWithValue("numStartups", BeNumerically("==", 5)))
+	})
+
+	It("leaves files alone when the restore is declined", func() {
+		env.CreateSettings(map[string]bool{"keep@m": true})
+		createBackup()
+		env.CreateSettings(map[string]bool{"other@m": true})
+
+		result := env.RunInDirWithInput(projectDir, "n\n", "backup", "restore")
+
+		Expect(result.ExitCode).To(Equal(0))
+		Expect(result.Stdout).To(ContainSubstring("Restore cancelled"))
+		Expect(env.IsPluginEnabled("other@m")).To(BeTrue())
+	})
+
+	It("reports when the files already match", func() {
+		createBackup()
+
+		result := env.RunInDir(projectDir, "backup", "restore", "-y")
+
+		Expect(result.ExitCode).To(Equal(0))
+		Expect(result.Stdout).To(ContainSubstring("Nothing to restore"))
+	})
+
+	It("rejects an unknown --at value", func() {
+		createBackup()
+
+		result := env.Run("backup", "restore", "--at", "not-a-backup", "-y")
+
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stderr).To(ContainSubstring("no backup matches"))
+	})
+
+	It("prunes all but the newest generations", func() {
+		for i := 0; i < 3; i++ {
+			createBackup()
+		}
+
+		result := env.Run("backup", "prune", "--keep", "1")
+
+		Expect(result.ExitCode).To(Equal(0))
+		Expect(result.Stdout).To(ContainSubstring("Removed 2 backup(s)"))
+		entries, err := os.ReadDir(filepath.Join(env.ClaudeupDir, "backups", "generations"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+	})
+
+	It("remembers the retention chosen with create --keep", func() {
+		createBackup("--keep", "2")
+		createBackup()
+		createBackup()
+
+		entries, err := os.ReadDir(filepath.Join(env.ClaudeupDir, "backups", "generations"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+	})
+
+	It("does not prune when restoring", func() {
+		env.CreateSettings(map[string]bool{"keep@m": true})
+		id := createBackup("--keep", "1")
+		env.CreateSettings(map[string]bool{"other@m": true})
+
+		result := env.RunInDir(projectDir, "backup", "restore", "--at", id, "-y")
+
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(filepath.Join(env.ClaudeupDir, "backups", "generations", id)).To(BeADirectory())
+		entries, err := os.ReadDir(filepath.Join(env.ClaudeupDir, "backups", "generations"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+	})
+
+	It("is created by profile apply --replace", func() {
+		env.CreateProfile(&profile.Profile{Name: "replacement", Plugins: []string{"plugin-a@market"}})
+		env.CreateSettings(map[string]bool{"before@m": true})
+
+		result := env.RunInDir(projectDir, "profile", "apply", "replacement", "--replace", "-y")
+
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		list := env.Run("backup", "list")
+		Expect(list.Stdout).To(ContainSubstring("profile apply replacement --replace"))
+	})
+})
@@ -0,0 +1,65 @@
+// ABOUTME: Acceptance tests for extensions lint and the checks run on enable
+// ABOUTME: Verifies file:line reporting, exit codes, and warnings after enabling
+package acceptance
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("extensions lint", func() {
+	var (
+		env       *helpers.TestEnv
+		agentsDir string
+	)
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+		agentsDir = filepath.Join(env.ClaudeupDir, "ext", "agents")
+		Expect(os.MkdirAll(agentsDir, 0755)).To(Succeed())
+		env.WriteFile(agentsDir, "reviewer.md", "---\nname: reviewer\ndescription: Reviews code\n---\n# Reviewer\n")
+	})
+
+	It("reports no problems for valid items", func() {
+		result := env.Run("extensions", "lint")
+
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("No problems found"))
+	})
+
+	It("reports errors with file and line and exits non-zero", func() {
+		env.WriteFile(agentsDir, "broken.md", "---\nname: broken\ndescription: x: y\n---\n")
+
+		result := env.Run("extensions", "lint", "agents")
+
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stdout).To(ContainSubstring(filepath.Join(agentsDir, "broken.md") + ":3: error: invalid frontmatter"))
+		Expect(result.Stderr).To(ContainSubstring("1 error(s), 0 warning(s)"))
+	})
+
+	It("flags hook scripts that are not executable", func() {
+		hooksDir := filepath.Join(env.ClaudeupDir, "ext", "hooks")
+		Expect(os.MkdirAll(hooksDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(hooksDir, "format.sh"), []byte("#!/bin/sh\n"), 0644)).To(Succeed())
+
+		result := env.Run("extensions", "lint", "hooks")
+
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stdout).To(ContainSubstring("hook script is not executable"))
+	})
+
+	It("warns about problems when enabling, without blocking", func() {
+		skillDir := filepath.Join(env.ClaudeupDir, "ext", "skills", "notes")
+		Expect(os.MkdirAll(skillDir, 0755)).To(Succeed())
+		env.WriteFile(skillDir, "README.md", "not a skill")
+
+		result := env.Run("extensions", "enable", "skills", "notes")
+
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("missing SKILL.md"))
+	})
+})
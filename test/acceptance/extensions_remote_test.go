@@ -0,0 +1,104 @@
+// ABOUTME: Acceptance tests for syncing extension storage through a git remote
+// ABOUTME: Two isolated environments push to and pull from a shared bare repository
+package acceptance
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("extensions remote", func() {
+	var (
+		laptop  *helpers.TestEnv
+		desktop *helpers.TestEnv
+		remote  string
+	)
+
+	BeforeEach(func() {
+		if _, err := exec.LookPath("git"); err != nil {
+			Skip("git not installed")
+		}
+		// Keep the developer's git config (identity, signing) out of the test
+		gitConfig := filepath.Join(GinkgoT().TempDir(), "gitconfig")
+		Expect(os.WriteFile(gitConfig, nil, 0644)).To(Succeed())
+		GinkgoT().Setenv("GIT_CONFIG_GLOBAL", gitConfig)
+		GinkgoT().Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+		remote = filepath.Join(GinkgoT().TempDir(), "library.git")
+		Expect(exec.Command("git", "init", "--quiet", "--bare", remote).Run()).To(Succeed())
+
+		laptop = helpers.NewTestEnv(binaryPath)
+		desktop = helpers.NewTestEnv(binaryPath)
+	})
+
+	writeItem := func(env *helpers.TestEnv, category, name, content string) {
+		dir := filepath.Join(env.ClaudeupDir, "ext", category)
+		Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+		env.WriteFile(dir, name, content)
+	}
+
+	It("moves extensions and enabled state to another machine", func() {
+		writeItem(laptop, "agents", "reviewer.md", "# Reviewer")
+		laptop.WriteFile(laptop.ClaudeupDir, "enabled.json", `{"agents":{"reviewer.md":true}}`)
+		Expect(laptop.Run("extensions", "remote", "add", remote, "--sync-enabled").ExitCode).To(Equal(0))
+
+		result := laptop.Run("extensions", "push")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("agents/reviewer.md"))
+		Expect(result.Stdout).To(ContainSubstring("Pushed extension library"))
+
+		Expect(desktop.Run("extensions", "remote", "add", remote, "--sync-enabled").ExitCode).To(Equal(0))
+		result = desktop.Run("extensions", "pull")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("Updated enabled.json"))
+
+		link, err := os.Readlink(filepath.Join(desktop.ClaudeDir, "agents", "reviewer.md"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(link).To(Equal(filepath.Join(desktop.ClaudeupDir, "ext", "agents", "reviewer.md")))
+	})
+
+	It("refuses to push over changes from another machine", func() {
+		Expect(laptop.Run("extensions", "remote", "add", remote).ExitCode).To(Equal(0))
+		Expect(desktop.Run("extensions", "remote", "add", remote).ExitCode).To(Equal(0))
+		writeItem(laptop, "rules", "style.md", "# Style")
+		Expect(laptop.Run("extensions", "push").ExitCode).To(Equal(0))
+
+		writeItem(desktop, "rules", "tests.md", "# Tests")
+		result := desktop.Run("extensions", "push")
+
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stderr).To(ContainSubstring("extensions pull"))
+	})
+
+	It("lists conflicting items and leaves local files alone", func() {
+		Expect(laptop.Run("extensions", "remote", "add", remote).ExitCode).To(Equal(0))
+		Expect(desktop.Run("extensions", "remote", "add", remote).ExitCode).To(Equal(0))
+		writeItem(laptop, "rules", "style.md", "# Style\n")
+		Expect(laptop.Run("extensions", "push").ExitCode).To(Equal(0))
+		Expect(desktop.Run("extensions", "pull").ExitCode).To(Equal(0))
+
+		writeItem(laptop, "rules", "style.md", "# Laptop style\n")
+		Expect(laptop.Run("extensions", "push").ExitCode).To(Equal(0))
+		writeItem(desktop, "rules", "style.md", "# Desktop style\n")
+
+		result := desktop.Run("extensions", "pull")
+
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stdout + result.Stderr).To(ContainSubstring("rules/style.md"))
+		data, err := os.ReadFile(filepath.Join(desktop.ClaudeupDir, "ext", "rules", "style.md"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal("# Desktop style\n"))
+	})
+
+	It("explains how to configure a remote when none is set", func() {
+		result := laptop.Run("extensions", "push")
+
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stderr).To(ContainSubstring("extensions remote add"))
+	})
+})
@@ -0,0 +1,80 @@
+// ABOUTME: Acceptance tests for refreshing installed extensions from their source
+// ABOUTME: Installs from a local directory, changes it, and runs extensions update
+package acceptance
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("extensions update", func() {
+	var (
+		env       *helpers.TestEnv
+		sourceDir string
+		stored    string
+	)
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+		sourceDir = filepath.Join(GinkgoT().TempDir(), "rules")
+		Expect(os.MkdirAll(sourceDir, 0755)).To(Succeed())
+		env.WriteFile(sourceDir, "style.md", "# Style\nUse tabs.\n")
+		env.WriteFile(sourceDir, "tests.md", "# Tests\n")
+		stored = filepath.Join(env.ClaudeupDir, "ext", "rules", "style.md")
+
+		result := env.Run("extensions", "install", "rules", sourceDir)
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+	})
+
+	It("records where installed items came from", func() {
+		sources := helpers.LoadJSON(filepath.Join(env.ClaudeupDir, "ext-sources.json"))
+
+		rules := sources["rules"].(map[string]interface{})
+		style := rules["style.md"].(map[string]interface{})
+		Expect(style["source"]).To(Equal(sourceDir))
+		Expect(style["path"]).To(Equal("style.md"))
+		Expect(style["hash"]).To(HavePrefix("sha256:"))
+	})
+
+	It("reports everything up to date when nothing changed", func() {
+		result := env.Run("extensions", "update")
+
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("All 2 item(s) are up to date"))
+	})
+
+	It("refreshes unmodified items from their source", func() {
+		env.WriteFile(sourceDir, "style.md", "# Style\nUse spaces.\n")
+
+		result := env.Run("extensions", "update", "rules")
+
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("Updated: rules/style.md"))
+		data, err := os.ReadFile(stored)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("Use spaces."))
+	})
+
+	It("shows a diff and keeps local edits unless confirmed", func() {
+		Expect(os.WriteFile(stored, []byte("# Style\nUse my own style.\n"), 0644)).To(Succeed())
+		env.WriteFile(sourceDir, "style.md", "# Style\nUse spaces.\n")
+
+		result := env.RunWithInput("n\n", "extensions", "update", "rules", "style.md")
+
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("- Use my own style."))
+		Expect(result.Stdout).To(ContainSubstring("+ Use spaces."))
+		Expect(result.Stdout).To(ContainSubstring("Kept local version"))
+		data, _ := os.ReadFile(stored)
+		Expect(string(data)).To(ContainSubstring("my own style"))
+
+		result = env.Run("extensions", "update", "rules", "style.md", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		data, _ = os.ReadFile(stored)
+		Expect(string(data)).To(ContainSubstring("Use spaces."))
+	})
+})
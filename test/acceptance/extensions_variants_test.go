@@ -0,0 +1,99 @@
+// ABOUTME: Acceptance tests for extension variants declared in profiles
+// ABOUTME: Applies frontmatter overrides at user and project scope and checks profile diff
+package acceptance
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("extension variants", func() {
+	var env *helpers.TestEnv
+
+	writeProfile := func(name string, p map[string]interface{}) {
+		data, _ := json.MarshalIndent(p, "", "  ")
+		Expect(os.WriteFile(filepath.Join(env.ProfilesDir, name+".json"), data, 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+		agents := filepath.Join(env.ClaudeupDir, "ext", "agents")
+		Expect(os.MkdirAll(agents, 0755)).To(Succeed())
+		env.WriteFile(agents, "reviewer.md", "---\nname: reviewer\ndescription: Reviews code\nmodel: sonnet\n---\nReview the change.\n")
+	})
+
+	It("links overridden items to a patched copy at user scope", func() {
+		writeProfile("team", map[string]interface{}{
+			"name": "team",
+			"perScope": map[string]interface{}{
+				"user": map[string]interface{}{
+					"extensions": map[string]interface{}{
+						"agents": []interface{}{map[string]interface{}{"name": "reviewer.md", "set": map[string]string{"model": "opus"}}},
+					},
+				},
+			},
+		})
+
+		result := env.Run("profile", "apply", "team", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+
+		data, err := os.ReadFile(filepath.Join(env.ClaudeDir, "agents", "reviewer.md"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("model: opus"))
+		Expect(string(data)).To(ContainSubstring("Review the change."))
+
+		library, _ := os.ReadFile(filepath.Join(env.ClaudeupDir, "ext", "agents", "reviewer.md"))
+		Expect(string(library)).To(ContainSubstring("model: sonnet"))
+
+		result = env.Run("profile", "diff", "team")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("matches live state"))
+
+		// The profile now asks for a different model than what is live
+		writeProfile("team", map[string]interface{}{
+			"name": "team",
+			"perScope": map[string]interface{}{
+				"user": map[string]interface{}{
+					"extensions": map[string]interface{}{
+						"agents": []interface{}{map[string]interface{}{"name": "reviewer.md", "set": map[string]string{"model": "haiku"}}},
+					},
+				},
+			},
+		})
+		result = env.Run("profile", "diff", "team")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("extension: reviewer.md (agents, model: haiku → opus)"))
+	})
+
+	It("copies overridden items with the patch applied at project scope", func() {
+		projectDir := env.ProjectDir("variant-project")
+		Expect(os.MkdirAll(filepath.Join(projectDir, ".claude"), 0755)).To(Succeed())
+		writeProfile("team", map[string]interface{}{
+			"name": "team",
+			"perScope": map[string]interface{}{
+				"project": map[string]interface{}{
+					"extensions": map[string]interface{}{
+						"agents": []interface{}{map[string]interface{}{"name": "reviewer.md", "set": map[string]string{"model": "opus"}}},
+					},
+				},
+			},
+		})
+
+		result := env.RunInDir(projectDir, "profile", "apply", "team", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+
+		data, err := os.ReadFile(filepath.Join(projectDir, ".claude", "agents", "reviewer.md"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("model: opus"))
+
+		result = env.RunInDir(projectDir, "profile", "status")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("reviewer.md (set model=opus)"))
+		Expect(result.Stdout).NotTo(ContainSubstring("Extension drift:"))
+	})
+})
@@ -0,0 +1,108 @@
+// ABOUTME: Acceptance tests for export and import of a whole installation
+// ABOUTME: Tests moving the ext library and state between isolated environments
+package acceptance
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("export and import", func() {
+	var (
+		source  *helpers.TestEnv
+		target  *helpers.TestEnv
+		archive string
+		token   string
+	)
+
+	BeforeEach(func() {
+		source = helpers.NewTestEnv(binaryPath)
+		target = helpers.NewTestEnv(binaryPath)
+		archive = filepath.Join(GinkgoT().TempDir(), "machine.tar.gz")
+		token = "ghp_" + strings.Repeat("x", 36)
+
+		rulesDir := filepath.Join(source.ClaudeupDir, "ext", "rules")
+		hooksDir := filepath.Join(source.ClaudeupDir, "ext", "hooks")
+		Expect(os.MkdirAll(rulesDir, 0755)).To(Succeed())
+		Expect(os.MkdirAll(hooksDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(rulesDir, "style.md"), []byte("# Style"), 0644)).To(Succeed())
+		hook := "#!/bin/sh\nexport GH=" + token + "\n" + filepath.Join(source.ClaudeupDir, "ext", "hooks", "lib.sh") + "\n"
+		Expect(os.WriteFile(filepath.Join(hooksDir, "notify.sh"), []byte(hook), 0755)).To(Succeed())
+		source.WriteFile(source.ClaudeupDir, "enabled.json", `{"rules":{"style.md":true}}`)
+		source.WriteFile(source.ProfilesDir, "mine.json", `{"name":"mine","plugins":["tool@market"]}`)
+	})
+
+	exportSource := func() {
+		result := source.Run("export", "-o", archive)
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+	}
+
+	It("exports the library and reports stripped secrets", func() {
+		result := source.Run("export", "-o", archive)
+
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("Exported 4 file(s)"))
+		Expect(result.Stdout).To(ContainSubstring("Removed 1 secret(s)"))
+		Expect(result.Stdout).To(ContainSubstring("ext/hooks/notify.sh"))
+		Expect(archive).To(BeAnExistingFile())
+	})
+
+	It("imports into another installation, rewriting paths and linking extensions", func() {
+		exportSource()
+
+		result := target.Run("import", archive, "-y")
+
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("Imported 4 file(s)"))
+		Expect(target.ProfileExists("mine")).To(BeTrue())
+
+		hook, err := os.ReadFile(filepath.Join(target.ClaudeupDir, "ext", "hooks", "notify.sh"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(hook)).To(ContainSubstring(filepath.Join(target.ClaudeupDir, "ext", "hooks", "lib.sh")))
+		Expect(string(hook)).NotTo(ContainSubstring(source.ClaudeupDir))
+		Expect(string(hook)).NotTo(ContainSubstring(token))
+
+		link, err := os.Readlink(filepath.Join(target.ClaudeDir, "rules", "style.md"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(link).To(Equal(filepath.Join(target.ClaudeupDir, "ext", "rules", "style.md")))
+	})
+
+	It("stops on conflicting files unless --force is given", func() {
+		exportSource()
+		target.WriteFile(target.ProfilesDir, "mine.json", `{"name":"mine"}`)
+
+		result := target.Run("import", archive, "-y")
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stderr).To(ContainSubstring("--force"))
+		Expect(target.LoadProfile("mine").Plugins).To(BeEmpty())
+
+		result = target.Run("import", archive, "--force", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(target.LoadProfile("mine").Plugins).To(ConsistOf("tool@market"))
+	})
+
+	It("reports when everything already matches", func() {
+		exportSource()
+		Expect(target.Run("import", archive, "-y").ExitCode).To(Equal(0))
+
+		result := target.Run("import", archive, "-y")
+
+		Expect(result.ExitCode).To(Equal(0))
+		Expect(result.Stdout).To(ContainSubstring("All files already match"))
+	})
+
+	It("rejects files that are not exports", func() {
+		bogus := filepath.Join(GinkgoT().TempDir(), "bogus.tar.gz")
+		Expect(os.WriteFile(bogus, []byte("hello"), 0644)).To(Succeed())
+
+		result := target.Run("import", bogus)
+
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stderr).To(ContainSubstring("not a claudeup export"))
+	})
+})
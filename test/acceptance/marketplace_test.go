@@ -0,0 +1,219 @@
+// ABOUTME: Acceptance tests for the marketplace command group
+// ABOUTME: Covers list, show, remove safety checks, pinning with upgrade, and directory sources
+package acceptance
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("marketplace", func() {
+	var env *helpers.TestEnv
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+	})
+
+	Describe("list", func() {
+		It("reports when no marketplaces are installed", func() {
+			result := env.Run("marketplace", "list")
+			Expect(result.ExitCode).To(Equal(0))
+			Expect(result.Stdout).To(ContainSubstring("No marketplaces installed"))
+		})
+
+		It("shows source, plugin count, and location", func() {
+			env.CreateMarketplace("acme-marketplace", "acme/marketplace")
+			env.CreateMarketplacePlugin("acme-marketplace", "my-plugin", "1.0.0")
+
+			result := env.Run("marketplace", "list")
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+			Expect(result.Stdout).To(ContainSubstring("acme-marketplace"))
+			Expect(result.Stdout).To(ContainSubstring("acme/marketplace"))
+			Expect(result.Stdout).To(MatchRegexp(`acme-marketplace\s+acme/marketplace\s+-\s+-\s+1\s`))
+			Expect(result.Stdout).To(ContainSubstring(filepath.Join(env.ClaudeDir, "plugins", "marketplaces", "acme-marketplace")))
+		})
+	})
+
+	Describe("show", func() {
+		It("lists the marketplace's plugins with install status", func() {
+			env.CreateMarketplace("acme-marketplace", "acme/marketplace")
+			env.CreateMarketplacePlugin("acme-marketplace", "my-plugin", "1.0.0")
+			env.CreateInstalledPlugins(map[string]interface{}{
+				"my-plugin@acme-marketplace": []interface{}{
+					map[string]interface{}{"scope": "user", "version": "1.0.0"},
+				},
+			})
+
+			result := env.Run("marketplace", "show", "acme/marketplace")
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+			Expect(result.Stdout).To(ContainSubstring("acme-marketplace"))
+			Expect(result.Stdout).To(ContainSubstring("Plugins (1)"))
+			Expect(result.Stdout).To(MatchRegexp(`my-plugin 1\.0\.0 installed`))
+		})
+
+		It("fails for an unknown marketplace", func() {
+			result := env.Run("marketplace", "show", "missing")
+			Expect(result.ExitCode).NotTo(Equal(0))
+			Expect(result.Stderr).To(ContainSubstring(`marketplace "missing" not found`))
+		})
+	})
+
+	Describe("remove", func() {
+		It("refuses while enabled plugins depend on the marketplace", func() {
+			env.CreateMarketplace("acme-marketplace", "acme/marketplace")
+			env.CreateInstalledPlugins(map[string]interface{}{
+				"my-plugin@acme-marketplace": []interface{}{
+					map[string]interface{}{"scope": "user", "version": "1.0.0"},
+				},
+			})
+
+			result := env.Run("marketplace", "remove", "acme-marketplace")
+			Expect(result.ExitCode).NotTo(Equal(0))
+			Expect(result.Stderr).To(ContainSubstring("my-plugin@acme-marketplace (user)"))
+			Expect(result.Stderr).To(ContainSubstring("--cascade"))
+			Expect(helpers.LoadJSON(filepath.Join(env.ClaudeDir, "plugins", "known_marketplaces.json"))).To(HaveKey("acme-marketplace"))
+		})
+	})
+
+	Describe("pin", func() {
+		var marketplaceDir, firstSHA, secondSHA string
+
+		git := func(dir string, args ...string) string {
+			cmd := exec.Command("git", append([]string{"-C", dir, "-c", "commit.gpgsign=false", "-c", "tag.gpgsign=false"}, args...)...)
+			cmd.Env = append(os.Environ(),
+				"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+				"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+			)
+			output, err := cmd.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(output))
+			return strings.TrimSpace(string(output))
+		}
+
+		BeforeEach(func() {
+			bareRepo := filepath.Join(env.TempDir, "bare-repo.git")
+			git(env.TempDir, "init", "--bare", "--initial-branch=main", bareRepo)
+
+			env.CreateMarketplace("acme-marketplace", "acme/marketplace")
+			marketplaceDir = filepath.Join(env.ClaudeDir, "plugins", "marketplaces", "acme-marketplace")
+			git(env.TempDir, "clone", bareRepo, marketplaceDir)
+			git(marketplaceDir, "checkout", "-b", "main")
+
+			Expect(os.WriteFile(filepath.Join(marketplaceDir, "README.md"), []byte("one"), 0644)).To(Succeed())
+			git(marketplaceDir, "add", ".")
+			git(marketplaceDir, "commit", "-m", "first")
+			git(marketplaceDir, "tag", "v1.0.0")
+			firstSHA = git(marketplaceDir, "rev-parse", "HEAD")
+
+			Expect(os.WriteFile(filepath.Join(marketplaceDir, "README.md"), []byte("two"), 0644)).To(Succeed())
+			git(marketplaceDir, "commit", "-am", "second")
+			secondSHA = git(marketplaceDir, "rev-parse", "HEAD")
+			git(marketplaceDir, "push", "--tags", "origin", "main")
+		})
+
+		It("checks out the ref and records the pin", func() {
+			result := env.Run("marketplace", "pin", "acme-marketplace", "v1.0.0")
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+			Expect(result.Stdout).To(ContainSubstring("Pinned acme-marketplace at v1.0.0 (" + firstSHA[:7] + ")"))
+			Expect(git(marketplaceDir, "rev-parse", "HEAD")).To(Equal(firstSHA))
+
+			pins := helpers.LoadJSON(filepath.Join(env.ClaudeupDir, "marketplace-pins.json"))
+			Expect(pins).To(HaveKey("acme-marketplace"))
+
+			result = env.Run("marketplace", "list")
+			Expect(result.Stdout).To(ContainSubstring(firstSHA[:7] + " (pinned v1.0.0)"))
+		})
+
+		It("is respected by upgrade and marketplace update", func() {
+			Expect(env.Run("marketplace", "pin", "acme-marketplace", "v1.0.0").ExitCode).To(Equal(0))
+
+			result := env.Run("upgrade", "acme-marketplace")
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+			Expect(result.Stdout).To(ContainSubstring("acme-marketplace: Pinned at v1.0.0"))
+			Expect(result.Stdout).NotTo(ContainSubstring("Unknown target"))
+
+			result = env.Run("marketplace", "update")
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+			Expect(result.Stdout).To(ContainSubstring("Pinned at v1.0.0 (skipped)"))
+
+			Expect(git(marketplaceDir, "rev-parse", "HEAD")).To(Equal(firstSHA))
+		})
+
+		It("returns to the default branch with --clear", func() {
+			Expect(env.Run("marketplace", "pin", "acme-marketplace", firstSHA[:7]).ExitCode).To(Equal(0))
+
+			result := env.Run("marketplace", "pin", "acme-marketplace", "--clear")
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+			Expect(result.Stdout).To(ContainSubstring("Unpinned acme-marketplace (now on main)"))
+			Expect(git(marketplaceDir, "rev-parse", "HEAD")).To(Equal(secondSHA))
+
+			pins := helpers.LoadJSON(filepath.Join(env.ClaudeupDir, "marketplace-pins.json"))
+			Expect(pins).NotTo(HaveKey("acme-marketplace"))
+		})
+
+		It("rejects unknown refs", func() {
+			result := env.Run("marketplace", "pin", "acme-marketplace", "v9.9.9")
+			Expect(result.ExitCode).NotTo(Equal(0))
+			Expect(result.Stderr).To(ContainSubstring(`ref "v9.9.9" not found`))
+			Expect(git(marketplaceDir, "rev-parse", "HEAD")).To(Equal(secondSHA))
+		})
+
+		It("requires a ref unless clearing", func() {
+			result := env.Run("marketplace", "pin", "acme-marketplace")
+			Expect(result.ExitCode).NotTo(Equal(0))
+			Expect(result.Stderr).To(ContainSubstring("missing ref"))
+		})
+	})
+
+	Describe("directory sources", func() {
+		var localDir string
+
+		BeforeEach(func() {
+			localDir = filepath.Join(env.TempDir, "local-marketplace")
+			env.CreateDirectoryMarketplace("local-marketplace", localDir)
+		})
+
+		It("lists the directory as the source", func() {
+			result := env.Run("marketplace", "list")
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+			Expect(result.Stdout).To(ContainSubstring(localDir))
+		})
+
+		It("is skipped by upgrade and marketplace update", func() {
+			result := env.Run("upgrade")
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+			Expect(result.Stdout).To(ContainSubstring("local-marketplace: Local directory"))
+
+			result = env.Run("marketplace", "update")
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+			Expect(result.Stdout).To(ContainSubstring("Local directory (skipped)"))
+		})
+
+		It("cannot be pinned", func() {
+			result := env.Run("marketplace", "pin", "local-marketplace", "v1.0.0")
+			Expect(result.ExitCode).NotTo(Equal(0))
+			Expect(result.Stderr).To(ContainSubstring("is a local directory"))
+		})
+
+		It("is captured by profile save with its path", func() {
+			env.CreateInstalledPlugins(map[string]interface{}{
+				"my-plugin@local-marketplace": []interface{}{
+					map[string]interface{}{"scope": "user", "version": "1.0.0"},
+				},
+			})
+
+			result := env.Run("profile", "save", "with-local", "-y")
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+
+			saved := env.LoadProfile("with-local")
+			Expect(saved.Marketplaces).To(HaveLen(1))
+			Expect(saved.Marketplaces[0].Source).To(Equal("directory"))
+			Expect(saved.Marketplaces[0].Path).To(Equal(localDir))
+		})
+	})
+})
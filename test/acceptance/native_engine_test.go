@@ -0,0 +1,88 @@
+// ABOUTME: Acceptance tests for the native install engine selected with --engine native
+// ABOUTME: Applies profiles and installs plugins by editing config files without the claude CLI
+package acceptance
+
+import (
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("--engine native", func() {
+	var (
+		env            *helpers.TestEnv
+		marketplaceDir string
+	)
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+		marketplaceDir = filepath.Join(env.TempDir, "native-marketplace")
+		helpers.WriteJSON(filepath.Join(marketplaceDir, ".claude-plugin", "marketplace.json"), map[string]interface{}{
+			"name": "native-tools",
+			"plugins": []map[string]string{
+				{"name": "formatter", "source": "./plugins/formatter", "version": "2.1.0"},
+			},
+		})
+		helpers.WriteJSON(filepath.Join(marketplaceDir, "plugins", "formatter", ".claude-plugin", "plugin.json"), map[string]string{
+			"name": "formatter", "version": "2.1.0",
+		})
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
+	It("applies marketplaces, plugins, and MCP servers without the claude CLI", func() {
+		helpers.WriteJSON(filepath.Join(env.ProfilesDir, "native.json"), map[string]interface{}{
+			"name":         "native",
+			"marketplaces": []map[string]string{{"source": "directory", "path": marketplaceDir}},
+			"plugins":      []string{"formatter@native-tools"},
+			"mcpServers":   []map[string]interface{}{{"name": "notes", "command": "notes-server", "args": []string{"--stdio"}}},
+		})
+
+		result := env.RunWithEnv(map[string]string{"PATH": "/nonexistent"}, "profile", "apply", "native", "-y", "--engine", "native")
+		Expect(result.ExitCode).To(Equal(0), result.Stdout+result.Stderr)
+
+		known := helpers.LoadJSON(filepath.Join(env.ClaudeDir, "plugins", "known_marketplaces.json"))
+		Expect(known).To(HaveKey("native-tools"))
+
+		installed := helpers.LoadJSON(filepath.Join(env.ClaudeDir, "plugins", "installed_plugins.json"))
+		instances := installed["plugins"].(map[string]interface{})["formatter@native-tools"].([]interface{})
+		Expect(instances).To(HaveLen(1))
+		instance := instances[0].(map[string]interface{})
+		Expect(instance["version"]).To(Equal("2.1.0"))
+		Expect(instance["installPath"]).To(Equal(filepath.Join(env.ClaudeDir, "plugins", "cache", "native-tools", "formatter", "2.1.0")))
+		Expect(filepath.Join(instance["installPath"].(string), ".claude-plugin", "plugin.json")).To(BeAnExistingFile())
+		Expect(env.IsPluginEnabled("formatter@native-tools")).To(BeTrue())
+
+		claudeJSON := helpers.LoadJSON(filepath.Join(env.ClaudeDir, ".claude.json"))
+		Expect(claudeJSON["mcpServers"]).To(HaveKey("notes"))
+
+		result = env.RunWithEnv(map[string]string{"PATH": "/nonexistent"}, "profile", "apply", "native", "-y", "--engine", "native", "--reinstall")
+		Expect(result.ExitCode).To(Equal(0), result.Stdout+result.Stderr)
+		Expect(result.Stdout).NotTo(ContainSubstring("failed"))
+	})
+
+	It("installs and uninstalls a single plugin", func() {
+		env.CreateDirectoryMarketplace("native-tools", marketplaceDir)
+
+		result := env.Run("plugin", "install", "formatter@native-tools", "--engine", "native")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(env.IsPluginEnabled("formatter@native-tools")).To(BeTrue())
+
+		result = env.Run("plugin", "uninstall", "formatter@native-tools", "--engine", "native")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		installed := helpers.LoadJSON(filepath.Join(env.ClaudeDir, "plugins", "installed_plugins.json"))
+		Expect(installed["plugins"]).NotTo(HaveKey("formatter@native-tools"))
+		Expect(filepath.Join(env.ClaudeDir, "plugins", "cache", "native-tools", "formatter", "2.1.0")).NotTo(BeADirectory())
+	})
+
+	It("rejects unknown engines", func() {
+		env.CreateDirectoryMarketplace("native-tools", marketplaceDir)
+		result := env.Run("plugin", "install", "formatter@native-tools", "--engine", "docker")
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stderr).To(ContainSubstring(`unknown engine "docker"`))
+	})
+})
@@ -0,0 +1,127 @@
+// ABOUTME: Acceptance tests for plugin install, uninstall, enable, and disable
+// ABOUTME: Covers scope flags, settings updates, and --save-to profile recording
+package acceptance
+
+import (
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/internal/profile"
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("plugin enable/disable", func() {
+	var env *helpers.TestEnv
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+		env.CreateMarketplace("acme-marketplace", "acme/marketplace")
+		env.CreateInstalledPlugins(map[string]interface{}{
+			"my-plugin@acme-marketplace": []interface{}{
+				map[string]interface{}{"scope": "user", "version": "1.0.0"},
+			},
+		})
+	})
+
+	It("disables and re-enables a plugin at user scope", func() {
+		result := env.Run("plugin", "disable", "my-plugin@acme-marketplace")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("Disabled my-plugin@acme-marketplace (user scope)"))
+		Expect(env.IsPluginEnabled("my-plugin@acme-marketplace")).To(BeFalse())
+
+		result = env.Run("plugin", "enable", "my-plugin@acme-marketplace")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("Enabled my-plugin@acme-marketplace (user scope)"))
+		Expect(env.IsPluginEnabled("my-plugin@acme-marketplace")).To(BeTrue())
+	})
+
+	It("reports when the plugin is already enabled", func() {
+		result := env.Run("plugin", "enable", "my-plugin@acme-marketplace")
+		Expect(result.ExitCode).To(Equal(0))
+		Expect(result.Stdout).To(ContainSubstring("already enabled"))
+	})
+
+	It("disables at local scope without touching user settings", func() {
+		projectDir := env.ProjectDir("app")
+
+		result := env.RunInDir(projectDir, "plugin", "disable", "my-plugin@acme-marketplace", "--local")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+
+		local := helpers.LoadJSON(filepath.Join(projectDir, ".claude", "settings.local.json"))
+		Expect(local["enabledPlugins"]).To(HaveKeyWithValue("my-plugin@acme-marketplace", false))
+		Expect(env.IsPluginEnabled("my-plugin@acme-marketplace")).To(BeTrue())
+	})
+
+	It("rejects plugins that are not installed", func() {
+		result := env.Run("plugin", "enable", "other@acme-marketplace")
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stderr).To(ContainSubstring("claudeup plugin install other@acme-marketplace"))
+	})
+
+	It("rejects plugin names without a marketplace", func() {
+		result := env.Run("plugin", "install", "my-plugin")
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stderr).To(ContainSubstring("expected name@marketplace-ref"))
+	})
+
+	It("rejects multiple scope flags", func() {
+		result := env.Run("plugin", "disable", "my-plugin@acme-marketplace", "--user", "--local")
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stderr).To(ContainSubstring("cannot specify multiple scope flags"))
+	})
+
+	It("fails to uninstall a plugin that is not installed at the scope", func() {
+		projectDir := env.ProjectDir("app")
+
+		result := env.RunInDir(projectDir, "plugin", "uninstall", "my-plugin@acme-marketplace", "--project")
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stderr).To(ContainSubstring("not installed at project scope"))
+	})
+
+	Describe("--save-to", func() {
+		BeforeEach(func() {
+			env.CreateProfile(&profile.Profile{
+				Name:    "work",
+				Plugins: []string{"my-plugin@acme-marketplace"},
+			})
+		})
+
+		It("removes an uninstalled plugin from the profile", func() {
+			result := env.Run("plugin", "uninstall", "my-plugin@acme-marketplace", "--save-to", "work")
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+			Expect(result.Stdout).To(ContainSubstring(`Updated profile "work"`))
+
+			Expect(env.LoadProfile("work").Plugins).To(BeEmpty())
+		})
+
+		It("is rejected by disable and leaves the profile and settings alone", func() {
+			result := env.Run("plugin", "disable", "my-plugin@acme-marketplace", "--save-to", "work")
+			Expect(result.ExitCode).NotTo(Equal(0))
+			Expect(result.Stderr).To(ContainSubstring("--save-to is not supported by disable"))
+
+			Expect(env.LoadProfile("work").Plugins).To(ConsistOf("my-plugin@acme-marketplace"))
+			Expect(env.IsPluginEnabled("my-plugin@acme-marketplace")).To(BeTrue())
+		})
+
+		It("adds an enabled plugin and its marketplace at the chosen scope", func() {
+			projectDir := env.ProjectDir("app")
+			env.CreateProjectScopeSettings(projectDir, map[string]bool{"my-plugin@acme-marketplace": false})
+
+			result := env.RunInDir(projectDir, "plugin", "enable", "my-plugin@acme-marketplace", "--project", "--save-to", "work")
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+
+			saved := env.LoadProfile("work")
+			Expect(saved.PerScope).NotTo(BeNil())
+			Expect(saved.PerScope.User.Plugins).To(ConsistOf("my-plugin@acme-marketplace"))
+			Expect(saved.PerScope.Project.Plugins).To(ConsistOf("my-plugin@acme-marketplace"))
+			Expect(saved.Marketplaces).To(ContainElement(profile.Marketplace{Repo: "acme/marketplace"}))
+		})
+
+		It("fails for an unknown profile", func() {
+			result := env.Run("plugin", "enable", "my-plugin@acme-marketplace", "--save-to", "missing")
+			Expect(result.ExitCode).NotTo(Equal(0))
+			Expect(result.Stderr).To(ContainSubstring(`profile "missing" not found`))
+		})
+	})
+})
@@ -0,0 +1,69 @@
+// ABOUTME: Acceptance tests for YAML and JSONC profiles and profile convert
+// ABOUTME: Verifies commented profiles are listed and validated, and comments survive conversion
+package acceptance
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("YAML and JSONC profiles", func() {
+	var env *helpers.TestEnv
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+		Expect(os.MkdirAll(env.ProfilesDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(env.ProfilesDir, "team.yaml"), []byte(`# Shared by the platform team
+description: Team setup
+marketplaces:
+  - source: github
+    repo: acme/tools
+plugins:
+  # Same linter CI runs
+  - lint@tools
+`), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
+	It("lists and validates a YAML profile", func() {
+		result := env.Run("profile", "list")
+		Expect(result.ExitCode).To(Equal(0))
+		Expect(result.Stdout).To(ContainSubstring("team"))
+		Expect(result.Stdout).To(ContainSubstring("Team setup"))
+
+		result = env.Run("profile", "validate", "team")
+		Expect(result.ExitCode).To(Equal(0), result.Stdout+result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("Profile is valid"))
+	})
+
+	It("converts a profile and carries its comments over", func() {
+		result := env.Run("profile", "convert", "team", "--to", "jsonc")
+
+		Expect(result.ExitCode).To(Equal(0), result.Stdout+result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring(`Converted profile "team" to jsonc`))
+		Expect(filepath.Join(env.ProfilesDir, "team.yaml")).NotTo(BeAnExistingFile())
+
+		data, err := os.ReadFile(filepath.Join(env.ProfilesDir, "team.jsonc"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("// Shared by the platform team"))
+		Expect(string(data)).To(ContainSubstring("    // Same linter CI runs\n    \"lint@tools\""))
+
+		result = env.Run("profile", "show", "team")
+		Expect(result.ExitCode).To(Equal(0), result.Stdout+result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("lint@tools"))
+	})
+
+	It("rejects an unknown format", func() {
+		result := env.Run("profile", "convert", "team", "--to", "toml")
+
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stderr).To(ContainSubstring(`unknown profile format "toml"`))
+	})
+})
@@ -0,0 +1,100 @@
+// ABOUTME: Acceptance tests for how post-apply hooks run and are recorded
+// ABOUTME: Verifies hook output lands in the events log and on-change hooks rerun only after edits
+package acceptance
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/internal/config"
+	"github.com/claudeup/claudeup/v5/internal/profile"
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("post-apply hook runs", func() {
+	var env *helpers.TestEnv
+
+	createProfile := func(plugins ...string) {
+		env.CreateProfile(&profile.Profile{
+			Name:         "team",
+			Marketplaces: []profile.Marketplace{{Source: "github", Repo: "test/fake-marketplace"}},
+			Plugins:      plugins,
+			PostApply: &profile.PostApplyHook{
+				Command:   `echo "hook ran in $(basename "$PWD")"; echo "token=${GITHUB_TOKEN:-unset}" >&2`,
+				Condition: "on-change",
+			},
+		})
+	}
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+		createProfile()
+		Expect(env.Run("profile", "trust", "policy", "allow").ExitCode).To(Equal(0))
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
+	It("records the hook's output and exit code in the events log", func() {
+		result := env.RunWithEnv(map[string]string{"GITHUB_TOKEN": "secret"}, "profile", "apply", "team", "-y")
+		Expect(result.Stdout).To(ContainSubstring("hook ran in profiles"))
+		Expect(result.Stderr).To(ContainSubstring("token=unset"))
+
+		result = env.Run("events", "--operation", "hook run")
+
+		Expect(result.ExitCode).To(Equal(0), result.Stdout+result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("HOOK RUN"))
+		Expect(result.Stdout).To(ContainSubstring("Profile: team"))
+		Expect(result.Stdout).To(ContainSubstring("Exit code: 0"))
+		Expect(result.Stdout).To(ContainSubstring("hook ran in profiles"))
+		Expect(result.Stdout).To(ContainSubstring("token=unset"))
+	})
+
+	It("runs a nested profile's hook in the directory of the file it verified", func() {
+		nested := filepath.Join(env.ProfilesDir, "nested")
+		Expect(os.MkdirAll(nested, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(nested, "team.json"),
+			[]byte(`{"name": "team", "postApply": {"command": "echo \"nested hook ran in $(basename \"$PWD\")\""}}`), 0644)).To(Succeed())
+
+		result := env.Run("profile", "apply", "nested/team", "-y")
+
+		Expect(result.ExitCode).To(Equal(0), result.Stdout+result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("nested hook ran in nested"))
+		Expect(result.Stdout).NotTo(ContainSubstring("hook ran in profiles"))
+	})
+
+	It("reruns an on-change hook only after the profile changes", func() {
+		Expect(env.Run("profile", "apply", "team", "-y").Stdout).To(ContainSubstring("hook ran in"))
+		Expect(env.Run("profile", "apply", "team", "-y").Stdout).NotTo(ContainSubstring("hook ran in"))
+
+		createProfile("lint@fake-marketplace")
+
+		Expect(env.Run("profile", "apply", "team", "-y").Stdout).To(ContainSubstring("hook ran in"))
+	})
+
+	It("passes variables an unsigned profile asks for only when the user allows them", func() {
+		env.CreateProfile(&profile.Profile{
+			Name: "secrets",
+			PostApply: &profile.PostApplyHook{
+				Command: `echo "token=${NPM_TOKEN:-unset}"`,
+				Env:     []string{"NPM_TOKEN"},
+			},
+		})
+		extraEnv := map[string]string{"NPM_TOKEN": "npm-secret"}
+
+		result := env.RunWithEnv(extraEnv, "profile", "apply", "secrets", "-y")
+		Expect(result.Stdout).To(ContainSubstring("token=unset"))
+		Expect(result.Stderr).To(ContainSubstring("not passing NPM_TOKEN to the post-apply hook"))
+
+		cfg := config.DefaultConfig()
+		cfg.Preferences.UnsignedProfiles = "allow"
+		cfg.Preferences.HookEnv = []string{"NPM_TOKEN"}
+		Expect(config.SaveTo(env.ClaudeupDir, cfg)).To(Succeed())
+
+		result = env.RunWithEnv(extraEnv, "profile", "apply", "secrets", "-y")
+		Expect(result.Stdout).To(ContainSubstring("token=npm-secret"))
+	})
+})
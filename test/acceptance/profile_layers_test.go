@@ -0,0 +1,127 @@
+// ABOUTME: Acceptance tests for applying several profiles together as layers
+// ABOUTME: Covers layered apply, per-layer attribution in status and diff, and profile remove-layer
+package acceptance
+
+import (
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("profile layers", func() {
+	var env *helpers.TestEnv
+
+	run := func(args ...string) *helpers.Result {
+		result := env.Run(append(args, "--engine", "native")...)
+		Expect(result.ExitCode).To(Equal(0), result.Stdout+result.Stderr)
+		return result
+	}
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+		marketplaceDir := filepath.Join(env.TempDir, "layer-marketplace")
+		var plugins []map[string]string
+		for _, name := range []string{"formatter", "linter", "scanner"} {
+			plugins = append(plugins, map[string]string{"name": name, "source": "./plugins/" + name, "version": "1.0.0"})
+			helpers.WriteJSON(filepath.Join(marketplaceDir, "plugins", name, ".claude-plugin", "plugin.json"), map[string]string{
+				"name": name, "version": "1.0.0",
+			})
+		}
+		helpers.WriteJSON(filepath.Join(marketplaceDir, ".claude-plugin", "marketplace.json"), map[string]interface{}{
+			"name": "layer-tools", "plugins": plugins,
+		})
+
+		helpers.WriteJSON(filepath.Join(env.ProfilesDir, "base.json"), map[string]interface{}{
+			"name":         "base",
+			"marketplaces": []map[string]string{{"source": "directory", "path": marketplaceDir}},
+			"plugins":      []string{"formatter@layer-tools"},
+		})
+		helpers.WriteJSON(filepath.Join(env.ProfilesDir, "security.json"), map[string]interface{}{
+			"name":       "security",
+			"plugins":    []string{"scanner@layer-tools", "linter@layer-tools"},
+			"mcpServers": []map[string]interface{}{{"name": "audit", "command": "audit-server", "scope": "user"}},
+		})
+		helpers.WriteJSON(filepath.Join(env.ProfilesDir, "python-dev.json"), map[string]interface{}{
+			"name":    "python-dev",
+			"plugins": []string{"linter@layer-tools"},
+		})
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
+	It("applies the layers together and attributes items to each layer", func() {
+		run("profile", "apply", "base", "security", "python-dev", "--user", "-y")
+
+		Expect(env.IsPluginEnabled("formatter@layer-tools")).To(BeTrue())
+		Expect(env.IsPluginEnabled("scanner@layer-tools")).To(BeTrue())
+		Expect(env.IsPluginEnabled("linter@layer-tools")).To(BeTrue())
+
+		crumbs := helpers.LoadJSON(filepath.Join(env.ClaudeupDir, "last-applied.json"))
+		user := crumbs["user"].(map[string]interface{})
+		Expect(user["profile"]).To(Equal("base+security+python-dev"))
+		Expect(user["layers"]).To(Equal([]interface{}{"base", "security", "python-dev"}))
+
+		result := run("profile", "status")
+		Expect(result.Stdout).To(ContainSubstring("Last applied: base + security + python-dev"))
+		Expect(result.Stdout).To(ContainSubstring("formatter@layer-tools (added by base)"))
+		Expect(result.Stdout).To(ContainSubstring("linter@layer-tools (added by security)"))
+
+		result = run("profile", "list")
+		Expect(result.Stdout).To(MatchRegexp(`python-dev\s.*\(applied\)`))
+
+		run("plugin", "uninstall", "scanner@layer-tools")
+		result = run("profile", "diff")
+		Expect(result.Stdout).To(ContainSubstring(`Comparing against "base+security+python-dev"`))
+		Expect(result.Stdout).To(MatchRegexp(`plugin: scanner@layer-tools.*\(from security\)`))
+		Expect(result.Stdout).To(ContainSubstring("claudeup profile apply base security python-dev"))
+	})
+
+	It("removes one layer without touching the others", func() {
+		run("profile", "apply", "base", "security", "python-dev", "--user", "-y")
+
+		result := run("profile", "remove-layer", "security", "-y")
+		Expect(result.Stdout).To(ContainSubstring("Plugin: scanner@layer-tools"))
+		Expect(result.Stdout).To(ContainSubstring("MCP: audit"))
+		Expect(result.Stdout).NotTo(ContainSubstring("Plugin: linter"))
+
+		Expect(env.IsPluginEnabled("scanner@layer-tools")).To(BeFalse())
+		Expect(env.IsPluginEnabled("formatter@layer-tools")).To(BeTrue())
+		Expect(env.IsPluginEnabled("linter@layer-tools")).To(BeTrue())
+		claudeJSON := helpers.LoadJSON(filepath.Join(env.ClaudeDir, ".claude.json"))
+		Expect(claudeJSON["mcpServers"]).NotTo(HaveKey("audit"))
+
+		crumbs := helpers.LoadJSON(filepath.Join(env.ClaudeupDir, "last-applied.json"))
+		user := crumbs["user"].(map[string]interface{})
+		Expect(user["layers"]).To(Equal([]interface{}{"base", "python-dev"}))
+
+		// The linter now belongs to python-dev, the remaining layer declaring it
+		result = run("profile", "status")
+		Expect(result.Stdout).To(ContainSubstring("linter@layer-tools (added by python-dev)"))
+	})
+
+	It("refuses to remove a layer that applies settings", func() {
+		helpers.WriteJSON(filepath.Join(env.ProfilesDir, "security.json"), map[string]interface{}{
+			"name":     "security",
+			"plugins":  []string{"scanner@layer-tools"},
+			"settings": map[string]interface{}{"model": "opus"},
+		})
+		run("profile", "apply", "base", "security", "--user", "-y")
+
+		result := env.Run("profile", "remove-layer", "security", "-y", "--engine", "native")
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stderr).To(ContainSubstring(`layer "security" also applies settings`))
+		Expect(result.Stderr).To(ContainSubstring("claudeup profile apply base --replace"))
+		Expect(env.IsPluginEnabled("scanner@layer-tools")).To(BeTrue())
+	})
+
+	It("rejects removing a profile that is not a layer", func() {
+		run("profile", "apply", "base", "--user", "-y")
+		result := env.Run("profile", "remove-layer", "base", "-y")
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stderr).To(ContainSubstring(`"base" is not a layer`))
+	})
+})
@@ -0,0 +1,135 @@
+// ABOUTME: Acceptance tests for the ownership ledger behind profile reset and status
+// ABOUTME: Verifies reset removes only what an apply introduced and status shows owners
+package acceptance
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("profile ownership ledger", func() {
+	var (
+		env            *helpers.TestEnv
+		marketplaceDir string
+	)
+
+	run := func(args ...string) *helpers.Result {
+		result := env.Run(append(args, "--engine", "native")...)
+		Expect(result.ExitCode).To(Equal(0), result.Stdout+result.Stderr)
+		return result
+	}
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+		marketplaceDir = filepath.Join(env.TempDir, "ledger-marketplace")
+		helpers.WriteJSON(filepath.Join(marketplaceDir, ".claude-plugin", "marketplace.json"), map[string]interface{}{
+			"name": "ledger-tools",
+			"plugins": []map[string]string{
+				{"name": "formatter", "source": "./plugins/formatter", "version": "1.0.0"},
+				{"name": "linter", "source": "./plugins/linter", "version": "1.0.0"},
+			},
+		})
+		for _, name := range []string{"formatter", "linter"} {
+			helpers.WriteJSON(filepath.Join(marketplaceDir, "plugins", name, ".claude-plugin", "plugin.json"), map[string]string{
+				"name": name, "version": "1.0.0",
+			})
+		}
+
+		// The user already runs the "shared" MCP server before applying
+		helpers.WriteJSON(filepath.Join(env.ClaudeDir, ".claude.json"), map[string]interface{}{
+			"mcpServers": map[string]interface{}{"shared": map[string]interface{}{"command": "shared-server"}},
+		})
+		helpers.WriteJSON(filepath.Join(env.ProfilesDir, "team.json"), map[string]interface{}{
+			"name":         "team",
+			"marketplaces": []map[string]string{{"source": "directory", "path": marketplaceDir}},
+			"plugins":      []string{"formatter@ledger-tools"},
+			"mcpServers": []map[string]interface{}{
+				{"name": "shared", "command": "shared-server"},
+				{"name": "notes", "command": "notes-server"},
+			},
+		})
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
+	It("records what apply introduced and shows owners in status", func() {
+		run("profile", "apply", "team", "-y")
+
+		ledger := helpers.LoadJSON(filepath.Join(env.ClaudeupDir, "ledger.json"))
+		entries := ledger["profiles"].(map[string]interface{})["team"].([]interface{})
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.(map[string]interface{})["name"].(string))
+		}
+		Expect(names).To(ConsistOf("formatter@ledger-tools", "notes", "ledger-tools"))
+
+		result := run("profile", "status")
+		Expect(result.Stdout).To(ContainSubstring("formatter@ledger-tools (added by team)"))
+		Expect(result.Stdout).To(MatchRegexp(`notes \(notes-server\) \(added by team\)`))
+		Expect(result.Stdout).NotTo(MatchRegexp(`shared \(shared-server\) \(added by`))
+	})
+
+	It("records what setup applies on a fresh install", func() {
+		Expect(os.Remove(filepath.Join(env.ClaudeDir, ".claude.json"))).To(Succeed())
+
+		run("setup", "--profile", "team", "-y")
+
+		ledger := helpers.LoadJSON(filepath.Join(env.ClaudeupDir, "ledger.json"))
+		entries := ledger["profiles"].(map[string]interface{})["team"].([]interface{})
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.(map[string]interface{})["name"].(string))
+		}
+		Expect(names).To(ContainElements("formatter@ledger-tools", "ledger-tools"))
+	})
+
+	It("leaves the ledger alone when installed items cannot be read", func() {
+		run("profile", "apply", "team", "-y")
+		before, err := os.ReadFile(filepath.Join(env.ClaudeupDir, "ledger.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(env.ClaudeDir, "plugins", "known_marketplaces.json"), []byte("{not json"), 0644)).To(Succeed())
+
+		result := env.Run("profile", "apply", "team", "-y", "--engine", "native")
+
+		Expect(result.Stdout + result.Stderr).To(ContainSubstring("Ownership ledger not updated"))
+		after, err := os.ReadFile(filepath.Join(env.ClaudeupDir, "ledger.json"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(after)).To(Equal(string(before)))
+	})
+
+	It("resets only the items the profile added", func() {
+		run("profile", "apply", "team", "-y")
+		run("plugin", "install", "linter@ledger-tools")
+
+		result := run("profile", "reset", "team", "-y")
+		Expect(result.Stdout).To(ContainSubstring("Plugin: formatter@ledger-tools"))
+		Expect(result.Stdout).NotTo(ContainSubstring("linter"))
+
+		installed := helpers.LoadJSON(filepath.Join(env.ClaudeDir, "plugins", "installed_plugins.json"))
+		Expect(installed["plugins"]).NotTo(HaveKey("formatter@ledger-tools"))
+		Expect(installed["plugins"]).To(HaveKey("linter@ledger-tools"))
+
+		claudeJSON := helpers.LoadJSON(filepath.Join(env.ClaudeDir, ".claude.json"))
+		Expect(claudeJSON["mcpServers"]).To(HaveKey("shared"))
+		Expect(claudeJSON["mcpServers"]).NotTo(HaveKey("notes"))
+
+		// The marketplace still serves the independently installed linter
+		known := helpers.LoadJSON(filepath.Join(env.ClaudeDir, "plugins", "known_marketplaces.json"))
+		Expect(known).To(HaveKey("ledger-tools"))
+	})
+
+	It("carries ownership across a profile rename", func() {
+		run("profile", "apply", "team", "-y")
+		run("profile", "rename", "team", "squad", "-y")
+
+		result := run("profile", "reset", "squad", "-y")
+		Expect(result.Stdout).To(ContainSubstring("Plugin: formatter@ledger-tools"))
+		Expect(env.IsPluginEnabled("formatter@ledger-tools")).To(BeFalse())
+	})
+})
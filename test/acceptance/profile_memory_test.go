@@ -0,0 +1,106 @@
+// ABOUTME: Acceptance tests for composing CLAUDE.md memory files from profile fragments
+// ABOUTME: Applies fragments as managed blocks, reports drift in diff, and re-captures them on save
+package acceptance
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("profile memory", func() {
+	var env *helpers.TestEnv
+
+	writeProfile := func(name string, p map[string]interface{}) {
+		data, _ := json.MarshalIndent(p, "", "  ")
+		Expect(os.WriteFile(filepath.Join(env.ProfilesDir, name+".json"), data, 0644)).To(Succeed())
+	}
+
+	readText := func(path string) string {
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		return string(data)
+	}
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+		memoryDir := filepath.Join(env.ClaudeupDir, "ext", "memory")
+		Expect(os.MkdirAll(memoryDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(memoryDir, "go-style.md"), []byte("Use gofmt.\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(memoryDir, "team.md"), []byte("Open PRs against main.\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(env.ClaudeDir, "CLAUDE.md"), []byte("# My notes\n"), 0644)).To(Succeed())
+	})
+
+	It("composes user CLAUDE.md from fragments and keeps hand-written content", func() {
+		writeProfile("go", map[string]interface{}{"name": "go", "memory": []string{"go-style"}})
+
+		result := env.Run("profile", "show", "go")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("Memory:"))
+
+		result = env.Run("profile", "apply", "go", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+
+		claudeMD := filepath.Join(env.ClaudeDir, "CLAUDE.md")
+		Expect(readText(claudeMD)).To(Equal(
+			"# My notes\n\n<!-- claudeup:begin go-style -->\nUse gofmt.\n<!-- claudeup:end go-style -->\n"))
+
+		result = env.Run("profile", "apply", "go", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("No changes needed"))
+
+		result = env.Run("profile", "diff", "go")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("matches live state"))
+
+		// Editing the managed block by hand is reported as drift
+		Expect(os.WriteFile(claudeMD, []byte(strings.Replace(readText(claudeMD), "Use gofmt.", "Use gofmt and vet.", 1)), 0644)).To(Succeed())
+		result = env.Run("profile", "diff", "go")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("memory: go-style (CLAUDE.md differs from library)"))
+	})
+
+	It("re-captures edited managed blocks on save", func() {
+		writeProfile("go", map[string]interface{}{"name": "go", "memory": []string{"go-style"}})
+		result := env.Run("profile", "apply", "go", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+
+		claudeMD := filepath.Join(env.ClaudeDir, "CLAUDE.md")
+		Expect(os.WriteFile(claudeMD, []byte(strings.Replace(readText(claudeMD), "Use gofmt.", "Use gofmt and vet.", 1)), 0644)).To(Succeed())
+
+		result = env.Run("profile", "save", "snap", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring(`Updated memory fragment "go-style"`))
+
+		saved := helpers.LoadJSON(filepath.Join(env.ProfilesDir, "snap.json"))
+		user := saved["perScope"].(map[string]interface{})["user"].(map[string]interface{})
+		Expect(user["memory"]).To(Equal([]interface{}{"go-style"}))
+		Expect(readText(filepath.Join(env.ClaudeupDir, "ext", "memory", "go-style.md"))).To(Equal("Use gofmt and vet.\n"))
+
+		result = env.Run("profile", "diff", "snap")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("matches live state"))
+	})
+
+	It("writes project-scope fragments to the project CLAUDE.md", func() {
+		projectDir := env.ProjectDir("memory-project")
+		writeProfile("team", map[string]interface{}{
+			"name": "team",
+			"perScope": map[string]interface{}{
+				"project": map[string]interface{}{"memory": []string{"team"}},
+			},
+		})
+
+		result := env.RunInDir(projectDir, "profile", "apply", "team", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+
+		Expect(readText(filepath.Join(projectDir, "CLAUDE.md"))).To(ContainSubstring(
+			"<!-- claudeup:begin team -->\nOpen PRs against main.\n<!-- claudeup:end team -->"))
+		Expect(readText(filepath.Join(env.ClaudeDir, "CLAUDE.md"))).To(Equal("# My notes\n"))
+	})
+})
@@ -0,0 +1,94 @@
+// ABOUTME: Acceptance tests for copying extensions into a project with a profile
+// ABOUTME: Covers commands and skills at project scope, drift in profile status, and safe re-apply
+package acceptance
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("project-scope extensions", func() {
+	var (
+		env        *helpers.TestEnv
+		projectDir string
+		extDir     string
+	)
+
+	writeExt := func(rel, content string) {
+		path := filepath.Join(extDir, rel)
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+		projectDir = env.ProjectDir("ext-project")
+		Expect(os.MkdirAll(filepath.Join(projectDir, ".claude"), 0755)).To(Succeed())
+		extDir = filepath.Join(env.ClaudeupDir, "ext")
+
+		writeExt("commands/deploy.md", "Deploy the app\n")
+		writeExt("commands/release.md", "Cut a release\n")
+		writeExt("skills/notes/SKILL.md", "---\nname: notes\ndescription: Takes notes\n---\n")
+
+		profile := map[string]interface{}{
+			"name": "team",
+			"perScope": map[string]interface{}{
+				"project": map[string]interface{}{
+					"extensions": map[string]interface{}{
+						"commands": []string{"*"},
+						"skills":   []string{"notes"},
+					},
+				},
+			},
+		}
+		data, _ := json.MarshalIndent(profile, "", "  ")
+		Expect(os.WriteFile(filepath.Join(env.ProfilesDir, "team.json"), data, 0644)).To(Succeed())
+
+		result := env.RunInDir(projectDir, "profile", "apply", "team", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+	})
+
+	It("copies commands and skills and records them in the manifest", func() {
+		Expect(filepath.Join(projectDir, ".claude", "commands", "deploy.md")).To(BeAnExistingFile())
+		Expect(filepath.Join(projectDir, ".claude", "skills", "notes", "SKILL.md")).To(BeAnExistingFile())
+
+		manifest := helpers.LoadJSON(filepath.Join(projectDir, ".claude", ".claudeup-ext.json"))
+		commands := manifest["commands"].(map[string]interface{})
+		deploy := commands["deploy.md"].(map[string]interface{})
+		Expect(deploy["source"]).To(Equal("commands/deploy.md"))
+		Expect(deploy["hash"]).To(HavePrefix("sha256:"))
+		Expect(manifest["skills"]).To(HaveKey("notes"))
+	})
+
+	It("reports drift in profile status and keeps local edits on re-apply", func() {
+		edited := filepath.Join(projectDir, ".claude", "commands", "deploy.md")
+		Expect(os.WriteFile(edited, []byte("Deploy our way\n"), 0644)).To(Succeed())
+		writeExt("commands/deploy.md", "Deploy the app, carefully\n")
+		writeExt("commands/release.md", "Cut a signed release\n")
+
+		result := env.RunInDir(projectDir, "profile", "status")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("Extension drift:"))
+		Expect(result.Stdout).To(ContainSubstring("commands/deploy.md (edited locally, out of date with the extension library)"))
+		Expect(result.Stdout).To(ContainSubstring("commands/release.md (out of date with the extension library)"))
+		Expect(result.Stdout).NotTo(ContainSubstring("skills/notes"))
+
+		result = env.RunInDir(projectDir, "profile", "apply", "team", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout + result.Stderr).To(ContainSubstring("Kept commands/deploy.md"))
+
+		data, _ := os.ReadFile(edited)
+		Expect(string(data)).To(Equal("Deploy our way\n"))
+		data, _ = os.ReadFile(filepath.Join(projectDir, ".claude", "commands", "release.md"))
+		Expect(string(data)).To(Equal("Cut a signed release\n"))
+
+		result = env.RunInDir(projectDir, "profile", "status")
+		Expect(result.Stdout).NotTo(ContainSubstring("commands/release.md"))
+		Expect(result.Stdout).To(ContainSubstring("commands/deploy.md (edited locally"))
+	})
+})
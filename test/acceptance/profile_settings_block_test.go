@@ -0,0 +1,141 @@
+// ABOUTME: Acceptance tests for the typed settings block in profiles
+// ABOUTME: Applies permissions, env, and model options per scope and checks show, diff, and save
+package acceptance
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("profile settings block", func() {
+	var env *helpers.TestEnv
+
+	writeProfile := func(name string, p map[string]interface{}) {
+		data, _ := json.MarshalIndent(p, "", "  ")
+		Expect(os.WriteFile(filepath.Join(env.ProfilesDir, name+".json"), data, 0644)).To(Succeed())
+	}
+
+	readSettings := func(path string) map[string]interface{} {
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		var settings map[string]interface{}
+		Expect(json.Unmarshal(data, &settings)).To(Succeed())
+		return settings
+	}
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+		Expect(os.WriteFile(filepath.Join(env.ClaudeDir, "settings.json"),
+			[]byte(`{"permissions": {"allow": ["Read"]}, "env": {"KEEP": "1"}, "theme": "dark"}`), 0644)).To(Succeed())
+	})
+
+	It("merges the user-scope block into settings.json and reports drift", func() {
+		writeProfile("team", map[string]interface{}{
+			"name": "team",
+			"perScope": map[string]interface{}{
+				"user": map[string]interface{}{
+					"settings": map[string]interface{}{
+						"permissions": map[string]interface{}{"allow": []string{"Bash(go test:*)"}},
+						"env":         map[string]string{"GOFLAGS": "-count=1"},
+						"model":       "opus",
+					},
+				},
+			},
+		})
+
+		result := env.Run("profile", "show", "team")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("Settings:"))
+		Expect(result.Stdout).To(ContainSubstring("permissions.allow: Bash(go test:*)"))
+		Expect(result.Stdout).To(ContainSubstring("model: opus"))
+
+		result = env.Run("profile", "apply", "team", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+
+		settings := readSettings(filepath.Join(env.ClaudeDir, "settings.json"))
+		Expect(settings["model"]).To(Equal("opus"))
+		Expect(settings["theme"]).To(Equal("dark"))
+		Expect(settings["env"]).To(Equal(map[string]interface{}{"KEEP": "1", "GOFLAGS": "-count=1"}))
+		Expect(settings["permissions"]).To(HaveKeyWithValue("allow", []interface{}{"Read", "Bash(go test:*)"}))
+
+		result = env.Run("profile", "diff", "team")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("matches live state"))
+
+		settings["model"] = "sonnet"
+		data, _ := json.Marshal(settings)
+		Expect(os.WriteFile(filepath.Join(env.ClaudeDir, "settings.json"), data, 0644)).To(Succeed())
+
+		result = env.Run("profile", "diff", "team")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("setting: model (opus → sonnet)"))
+	})
+
+	It("replaces keys named in replace at project scope", func() {
+		projectDir := env.ProjectDir("settings-project")
+		Expect(os.MkdirAll(filepath.Join(projectDir, ".claude"), 0755)).To(Succeed())
+		env.WriteFile(filepath.Join(projectDir, ".claude"), "settings.json",
+			`{"permissions": {"allow": ["WebFetch"], "deny": ["Bash(rm:*)"]}}`)
+
+		writeProfile("strict", map[string]interface{}{
+			"name": "strict",
+			"perScope": map[string]interface{}{
+				"project": map[string]interface{}{
+					"settings": map[string]interface{}{
+						"permissions": map[string]interface{}{"allow": []string{"Read", "Edit"}},
+						"replace":     []string{"permissions.allow"},
+					},
+				},
+			},
+		})
+
+		result := env.RunInDir(projectDir, "profile", "apply", "strict", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+
+		settings := readSettings(filepath.Join(projectDir, ".claude", "settings.json"))
+		Expect(settings["permissions"]).To(Equal(map[string]interface{}{
+			"allow": []interface{}{"Read", "Edit"},
+			"deny":  []interface{}{"Bash(rm:*)"},
+		}))
+
+		// User settings are untouched by a project-scope block
+		user := readSettings(filepath.Join(env.ClaudeDir, "settings.json"))
+		Expect(user["permissions"]).To(HaveKeyWithValue("allow", []interface{}{"Read"}))
+	})
+
+	It("applies a flat profile's block even when plugins already match", func() {
+		writeProfile("flat", map[string]interface{}{
+			"name":     "flat",
+			"settings": map[string]interface{}{"outputStyle": "Explanatory"},
+		})
+
+		result := env.Run("profile", "apply", "flat", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("outputStyle"))
+
+		settings := readSettings(filepath.Join(env.ClaudeDir, "settings.json"))
+		Expect(settings["outputStyle"]).To(Equal("Explanatory"))
+
+		result = env.Run("profile", "apply", "flat", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("No changes needed"))
+	})
+
+	It("captures settings when saving a profile", func() {
+		result := env.Run("profile", "save", "snap", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+
+		saved := helpers.LoadJSON(filepath.Join(env.ProfilesDir, "snap.json"))
+		Expect(saved).To(HaveKey("perScope"))
+		user := saved["perScope"].(map[string]interface{})["user"].(map[string]interface{})
+		Expect(user["settings"]).To(Equal(map[string]interface{}{
+			"permissions": map[string]interface{}{"allow": []interface{}{"Read"}},
+			"env":         map[string]interface{}{"KEEP": "1"},
+		}))
+	})
+})
@@ -0,0 +1,161 @@
+// ABOUTME: Acceptance tests for profile hooks with matchers and timeouts
+// ABOUTME: Applies hooks idempotently, shows and diffs them, captures them on save, and removes the ones it added on reset
+package acceptance
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("profile settings hooks", func() {
+	var env *helpers.TestEnv
+
+	settingsPath := func() string {
+		return filepath.Join(env.ClaudeDir, "settings.json")
+	}
+
+	readHooks := func() map[string]interface{} {
+		settings := helpers.LoadJSON(settingsPath())
+		hooks, _ := settings["hooks"].(map[string]interface{})
+		return hooks
+	}
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+		Expect(os.WriteFile(settingsPath(), []byte(`{
+  "hooks": {
+    "PreToolUse": [{"matcher": "Bash", "hooks": [{"type": "command", "command": "audit"}]}]
+  }
+}`), 0644)).To(Succeed())
+
+		profile := map[string]interface{}{
+			"name": "guarded",
+			"settingsHooks": map[string]interface{}{
+				"PreToolUse": []map[string]interface{}{
+					{"matcher": "Edit|Write", "type": "command", "command": "gofmt-check", "timeout": 30},
+				},
+				"Stop": []map[string]interface{}{
+					{"type": "command", "command": "notify-done"},
+				},
+			},
+		}
+		data, _ := json.MarshalIndent(profile, "", "  ")
+		Expect(os.WriteFile(filepath.Join(env.ProfilesDir, "guarded.json"), data, 0644)).To(Succeed())
+	})
+
+	It("merges hooks into matcher groups once, however often it is applied", func() {
+		result := env.Run("profile", "show", "guarded")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("Hooks:"))
+		Expect(result.Stdout).To(ContainSubstring("gofmt-check"))
+		Expect(result.Stdout).To(ContainSubstring("matcher Edit|Write, timeout 30s"))
+
+		for i := 0; i < 2; i++ {
+			result = env.Run("profile", "apply", "guarded", "-y")
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		}
+
+		hooks := readHooks()
+		Expect(hooks["PreToolUse"]).To(Equal([]interface{}{
+			map[string]interface{}{"matcher": "Bash", "hooks": []interface{}{
+				map[string]interface{}{"type": "command", "command": "audit"},
+			}},
+			map[string]interface{}{"matcher": "Edit|Write", "hooks": []interface{}{
+				map[string]interface{}{"type": "command", "command": "gofmt-check", "timeout": float64(30)},
+			}},
+		}))
+		Expect(hooks["Stop"]).To(HaveLen(1))
+	})
+
+	It("captures hooks with matchers and timeouts on save and reports drift", func() {
+		result := env.Run("profile", "apply", "guarded", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+
+		result = env.Run("profile", "save", "snap", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("Hooks"))
+
+		saved := helpers.LoadJSON(filepath.Join(env.ProfilesDir, "snap.json"))
+		savedHooks := saved["settingsHooks"].(map[string]interface{})
+		Expect(savedHooks["PreToolUse"]).To(ContainElement(map[string]interface{}{
+			"matcher": "Edit|Write", "type": "command", "command": "gofmt-check", "timeout": float64(30),
+		}))
+		Expect(savedHooks["PreToolUse"]).To(ContainElement(map[string]interface{}{
+			"matcher": "Bash", "type": "command", "command": "audit",
+		}))
+
+		result = env.Run("profile", "diff", "snap")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("matches live state"))
+
+		// Dropping a hook from settings.json shows up as drift
+		settings := helpers.LoadJSON(settingsPath())
+		delete(settings["hooks"].(map[string]interface{}), "Stop")
+		data, _ := json.Marshal(settings)
+		Expect(os.WriteFile(settingsPath(), data, 0644)).To(Succeed())
+
+		result = env.Run("profile", "diff", "snap")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("hook: notify-done (Stop)"))
+	})
+
+	It("removes exactly the profile's hooks on reset", func() {
+		result := env.Run("profile", "apply", "guarded", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+
+		result = env.Run("profile", "reset", "guarded", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("Hook: PreToolUse: gofmt-check"))
+		Expect(result.Stdout).To(ContainSubstring("Removed 2 hooks"))
+
+		Expect(readHooks()).To(Equal(map[string]interface{}{
+			"PreToolUse": []interface{}{
+				map[string]interface{}{"matcher": "Bash", "hooks": []interface{}{
+					map[string]interface{}{"type": "command", "command": "audit"},
+				}},
+			},
+		}))
+	})
+
+	It("keeps a hook the user already had and removes hooks an earlier version added", func() {
+		// The user already runs the profile's gofmt-check hook
+		Expect(os.WriteFile(settingsPath(), []byte(`{
+  "hooks": {
+    "PreToolUse": [{"matcher": "Edit|Write", "hooks": [{"type": "command", "command": "gofmt-check", "timeout": 30}]}]
+  }
+}`), 0644)).To(Succeed())
+		result := env.Run("profile", "apply", "guarded", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+
+		// The profile drops notify-done after it was applied
+		profile := map[string]interface{}{
+			"name": "guarded",
+			"settingsHooks": map[string]interface{}{
+				"PreToolUse": []map[string]interface{}{
+					{"matcher": "Edit|Write", "type": "command", "command": "gofmt-check", "timeout": 30},
+				},
+			},
+		}
+		data, _ := json.MarshalIndent(profile, "", "  ")
+		Expect(os.WriteFile(filepath.Join(env.ProfilesDir, "guarded.json"), data, 0644)).To(Succeed())
+
+		result = env.Run("profile", "reset", "guarded", "-y")
+		Expect(result.ExitCode).To(Equal(0), result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("Hook: Stop: notify-done"))
+		Expect(result.Stdout).NotTo(ContainSubstring("gofmt-check"))
+		Expect(result.Stdout).To(ContainSubstring("Removed 1 hooks"))
+
+		Expect(readHooks()).To(Equal(map[string]interface{}{
+			"PreToolUse": []interface{}{
+				map[string]interface{}{"matcher": "Edit|Write", "hooks": []interface{}{
+					map[string]interface{}{"type": "command", "command": "gofmt-check", "timeout": float64(30)},
+				}},
+			},
+		}))
+	})
+})
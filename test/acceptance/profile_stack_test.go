@@ -194,4 +194,50 @@ var _ = Describe("Composable Stack Profiles", func() {
 			})
 		})
 	})
+
+	Describe("merge conflicts", func() {
+		BeforeEach(func() {
+			env.CreateProfile(&profile.Profile{
+				Name:       "notes-v1",
+				Plugins:    []string{"plugin-a@marketplace", "noisy@marketplace"},
+				MCPServers: []profile.MCPServer{{Name: "notes", Command: "notes-v1"}},
+			})
+			env.CreateProfile(&profile.Profile{
+				Name:       "notes-v2",
+				MCPServers: []profile.MCPServer{{Name: "notes", Command: "notes-v2"}},
+			})
+		})
+
+		It("reports conflicts and shows where each resolved item came from", func() {
+			env.CreateProfile(&profile.Profile{
+				Name:     "team",
+				Includes: []string{"notes-v1", "notes-v2"},
+				Merge: map[string]profile.MergeRule{
+					"plugins": {Strategy: profile.MergeRemove, Items: []string{"noisy@marketplace"}},
+				},
+			})
+
+			result := env.Run("profile", "show", "team", "--resolved")
+
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+			Expect(result.Stdout).To(ContainSubstring("plugins: remove noisy@marketplace"))
+			Expect(result.Stdout).To(ContainSubstring(`MCP server "notes" is defined differently by notes-v1 and notes-v2; using notes-v2`))
+			Expect(result.Stdout).To(MatchRegexp(`plugin-a@marketplace.*\(from notes-v1\)`))
+			Expect(result.Stdout).To(MatchRegexp(`notes \(notes-v2\).*\(from notes-v2\)`))
+			Expect(result.Stdout).NotTo(ContainSubstring("- noisy@marketplace"))
+		})
+
+		It("refuses to apply a stack whose conflict strategy is error", func() {
+			env.CreateProfile(&profile.Profile{
+				Name:     "strict",
+				Includes: []string{"notes-v1", "notes-v2"},
+				Merge:    map[string]profile.MergeRule{"mcpServers": {Strategy: profile.MergeError}},
+			})
+
+			result := env.Run("profile", "apply", "strict", "-y")
+
+			Expect(result.ExitCode).NotTo(Equal(0))
+			Expect(result.Stderr).To(ContainSubstring(`merge conflict: MCP server "notes" is defined differently by notes-v1 and notes-v2`))
+		})
+	})
 })
@@ -0,0 +1,111 @@
+// ABOUTME: Acceptance tests for signed profiles, the trusted key store, and the unsigned-profile policy
+// ABOUTME: Verifies apply refuses or prompts for unsigned profiles that run commands and accepts signed ones
+package acceptance
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/internal/profile"
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("profile signing and trust", func() {
+	var env *helpers.TestEnv
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+		// A marketplace gives apply a diff, so it gets as far as the hook
+		env.CreateProfile(&profile.Profile{
+			Name:         "team",
+			Description:  "Team setup",
+			Marketplaces: []profile.Marketplace{{Source: "github", Repo: "test/fake-marketplace"}},
+			PostApply:    &profile.PostApplyHook{Command: "exit 1", Condition: "always"},
+		})
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
+	signAndTrust := func() {
+		result := env.Run("profile", "keygen")
+		Expect(result.ExitCode).To(Equal(0), result.Stdout+result.Stderr)
+		result = env.Run("profile", "sign", "team")
+		Expect(result.ExitCode).To(Equal(0), result.Stdout+result.Stderr)
+		result = env.Run("profile", "trust", "add", filepath.Join(env.ClaudeupDir, "keys", "signing.pub"), "--name", "platform")
+		Expect(result.ExitCode).To(Equal(0), result.Stdout+result.Stderr)
+	}
+
+	It("refuses unsigned profiles that run commands under the refuse policy", func() {
+		Expect(env.Run("profile", "trust", "policy", "refuse").ExitCode).To(Equal(0))
+
+		result := env.Run("profile", "apply", "team", "-y")
+
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stderr).To(ContainSubstring(`profile "team" runs commands (postApply) but is unsigned`))
+		Expect(result.Stdout).NotTo(ContainSubstring("Post-apply hook failed"))
+	})
+
+	It("counts a per-scope status line command as running commands", func() {
+		env.CreateProfile(&profile.Profile{
+			Name: "status",
+			PerScope: &profile.PerScopeSettings{User: &profile.ScopeSettings{
+				Settings: &profile.SettingsBlock{StatusLine: &profile.StatusLine{Type: "command", Command: "statusline.sh"}},
+			}},
+		})
+		Expect(env.Run("profile", "trust", "policy", "refuse").ExitCode).To(Equal(0))
+
+		result := env.Run("profile", "apply", "status", "-y")
+
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stderr).To(ContainSubstring(`profile "status" runs commands (statusLine) but is unsigned`))
+	})
+
+	It("asks before applying an unsigned profile under the default policy", func() {
+		result := env.RunWithInput("n\n", "profile", "apply", "team")
+
+		Expect(result.Stdout).To(ContainSubstring("not signed by a trusted key"))
+		Expect(result.Stdout).To(ContainSubstring("team: postApply (unsigned)"))
+		Expect(result.Stdout).To(ContainSubstring("Cancelled."))
+	})
+
+	It("applies a profile signed by a trusted key and shows its signer", func() {
+		signAndTrust()
+		Expect(env.Run("profile", "trust", "policy", "refuse").ExitCode).To(Equal(0))
+
+		result := env.Run("profile", "show", "team")
+		Expect(result.ExitCode).To(Equal(0))
+		Expect(result.Stdout).To(MatchRegexp(`Signed by: platform \(key [0-9A-F]{16}\)`))
+
+		// The hook is allowed to run, and fails as written
+		result = env.Run("profile", "apply", "team", "-y")
+		Expect(result.Stdout).To(ContainSubstring("Post-apply hook failed"))
+	})
+
+	It("no longer trusts a profile edited after signing", func() {
+		signAndTrust()
+		path := filepath.Join(env.ProfilesDir, "team.json")
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(path, append(data, ' '), 0644)).To(Succeed())
+
+		result := env.Run("profile", "show", "team")
+
+		Expect(result.Stdout).To(ContainSubstring("signature does not match"))
+	})
+
+	It("lists and removes trusted keys", func() {
+		signAndTrust()
+
+		result := env.Run("profile", "trust", "list")
+		Expect(result.Stdout).To(ContainSubstring("Unsigned profiles: prompt"))
+		Expect(result.Stdout).To(ContainSubstring("platform"))
+
+		result = env.Run("profile", "trust", "remove", "platform")
+		Expect(result.ExitCode).To(Equal(0))
+		Expect(env.Run("profile", "show", "team").Stdout).To(ContainSubstring("which is not trusted"))
+	})
+})
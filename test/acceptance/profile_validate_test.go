@@ -0,0 +1,66 @@
+// ABOUTME: Acceptance tests for profile validate and profile schema
+// ABOUTME: Verifies problems are reported by JSON pointer and valid profiles pass
+package acceptance
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/test/helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("profile validate", func() {
+	var env *helpers.TestEnv
+
+	BeforeEach(func() {
+		env = helpers.NewTestEnv(binaryPath)
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
+	It("reports each problem with its JSON pointer and exits non-zero", func() {
+		path := filepath.Join(env.TempDir, "team.json")
+		Expect(os.WriteFile(path, []byte(`{
+  "name": "team",
+  "mcpservers": [],
+  "perScope": {"project": {"plugins": ["linter"]}}
+}`), 0644)).To(Succeed())
+
+		result := env.Run("profile", "validate", path)
+
+		Expect(result.ExitCode).NotTo(Equal(0))
+		Expect(result.Stdout).To(ContainSubstring(`/mcpservers: unknown key "mcpservers" (did you mean "mcpServers"?)`))
+		Expect(result.Stderr).To(ContainSubstring("1 error"))
+		Expect(result.Stderr).NotTo(ContainSubstring("Usage:"))
+	})
+
+	It("accepts a saved profile by name", func() {
+		data, err := json.Marshal(map[string]interface{}{
+			"name":         "good",
+			"marketplaces": []map[string]string{{"source": "github", "repo": "acme/tools"}},
+			"plugins":      []string{"linter@tools"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(env.ProfilesDir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(env.ProfilesDir, "good.json"), data, 0644)).To(Succeed())
+
+		result := env.Run("profile", "validate", "good")
+
+		Expect(result.ExitCode).To(Equal(0), result.Stdout+result.Stderr)
+		Expect(result.Stdout).To(ContainSubstring("Profile is valid"))
+	})
+
+	It("prints the JSON Schema", func() {
+		result := env.Run("profile", "schema")
+
+		Expect(result.ExitCode).To(Equal(0))
+		var schema map[string]interface{}
+		Expect(json.Unmarshal([]byte(result.Stdout), &schema)).To(Succeed())
+		Expect(schema["$id"]).To(Equal("https://claudeup.github.io/claudeup/profile.schema.json"))
+	})
+})
@@ -3,6 +3,13 @@
 package acceptance
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/claudeup/claudeup/v5/internal/config"
 	"github.com/claudeup/claudeup/v5/test/helpers"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -15,6 +22,10 @@ var _ = Describe("update", func() {
 		env = helpers.NewTestEnv(binaryPath)
 	})
 
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
 	Describe("help output", func() {
 		It("shows usage information", func() {
 			result := env.Run("update", "--help")
@@ -23,4 +34,105 @@ var _ = Describe("update", func() {
 			Expect(result.Stdout).To(ContainSubstring("Usage:"))
 		})
 	})
+
+	Describe("--rollback", func() {
+		It("fails when no update has been made", func() {
+			result := env.Run("update", "--rollback")
+			Expect(result.ExitCode).NotTo(Equal(0))
+			Expect(result.Stderr).To(ContainSubstring("no previous version to roll back to"))
+		})
+	})
+
+	Describe("--channel", func() {
+		It("rejects unknown channels without saving them", func() {
+			result := env.Run("update", "--channel", "nightly")
+			Expect(result.ExitCode).NotTo(Equal(0))
+			Expect(result.Stderr).To(ContainSubstring("nightly"))
+			cfg, err := config.LoadFrom(env.ClaudeupDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Preferences.UpdateChannel).To(BeEmpty())
+		})
+	})
+
+	Describe("--base-url", func() {
+		It("remembers the mirror and checks it for the latest release", func() {
+			mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/claudeup/releases" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(`[{"tag_name": "v0.0.1-beta.1", "prerelease": true}]`))
+			}))
+			defer mirror.Close()
+
+			result := env.Run("update", "--channel", "beta", "--base-url", mirror.URL+"/claudeup", "--insecure-skip-verify")
+
+			Expect(result.ExitCode).To(Equal(0), result.Stdout+result.Stderr)
+			Expect(result.Stdout).To(ContainSubstring("Update channel: beta"))
+			Expect(result.Stdout).To(ContainSubstring("Already up to date"))
+			cfg, err := config.LoadFrom(env.ClaudeupDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Preferences.UpdateChannel).To(Equal("beta"))
+			Expect(cfg.Preferences.UpdateBaseURL).To(Equal(mirror.URL + "/claudeup"))
+		})
+	})
+
+	Describe("builds without a release key", func() {
+		It("refuse a mirror unless verification is skipped", func() {
+			result := env.Run("update", "--base-url", "https://mirror.example.com/claudeup")
+
+			Expect(result.ExitCode).NotTo(Equal(0))
+			Expect(result.Stderr).To(ContainSubstring("no release signing key"))
+			Expect(result.Stderr).To(ContainSubstring("install.sh"))
+			cfg, err := config.LoadFrom(env.ClaudeupDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Preferences.UpdateBaseURL).To(BeEmpty())
+		})
+
+		It("refuse a mirror saved in config.json", func() {
+			cfg := config.DefaultConfig()
+			cfg.Preferences.UpdateBaseURL = "https://mirror.example.com/claudeup"
+			Expect(config.SaveTo(env.ClaudeupDir, cfg)).To(Succeed())
+
+			result := env.Run("update")
+
+			Expect(result.ExitCode).NotTo(Equal(0))
+			Expect(result.Stderr).To(ContainSubstring("the configured mirror https://mirror.example.com/claudeup/releases"))
+		})
+	})
+
+	Describe("background check", func() {
+		It("caches the latest release for the update notice", func() {
+			mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/releases/latest" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(`{"tag_name": "v99.0.0"}`))
+			}))
+			defer mirror.Close()
+			cfg := config.DefaultConfig()
+			cfg.Preferences.UpdateBaseURL = mirror.URL
+			Expect(config.SaveTo(env.ClaudeupDir, cfg)).To(Succeed())
+
+			result := env.Run("update-check")
+
+			Expect(result.ExitCode).To(Equal(0), result.Stdout+result.Stderr)
+			data, err := os.ReadFile(filepath.Join(env.ClaudeupDir, "update-notice.json"))
+			Expect(err).NotTo(HaveOccurred())
+			var notice map[string]interface{}
+			Expect(json.Unmarshal(data, &notice)).To(Succeed())
+			Expect(notice).To(HaveKeyWithValue("latestVersion", "v99.0.0"))
+			Expect(notice).To(HaveKey("checkedAt"))
+		})
+
+		It("prints no notice when output is not a terminal", func() {
+			Expect(os.WriteFile(filepath.Join(env.ClaudeupDir, "update-notice.json"),
+				[]byte(`{"checkedAt": "2099-01-01T00:00:00Z", "latestVersion": "v99.0.0"}`), 0644)).To(Succeed())
+
+			result := env.Run("status")
+
+			Expect(result.Stdout + result.Stderr).NotTo(ContainSubstring("Updates available"))
+		})
+	})
 })
@@ -246,6 +246,99 @@ var _ = Describe("upgrade", func() {
 		})
 	})
 
+	Describe("interactive upgrade and rollback", func() {
+		var marketplaceDir, cacheDir, initialSHA string
+
+		git := func(dir string, args ...string) string {
+			cmd := exec.Command("git", append([]string{"-C", dir, "-c", "commit.gpgsign=false"}, args...)...)
+			cmd.Env = append(os.Environ(),
+				"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+				"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+			)
+			output, err := cmd.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(output))
+			return strings.TrimSpace(string(output))
+		}
+
+		BeforeEach(func() {
+			bareRepo := filepath.Join(env.TempDir, "bare-repo.git")
+			git(env.TempDir, "init", "--bare", bareRepo)
+
+			marketplaceDir = filepath.Join(env.ClaudeDir, "plugins", "marketplaces", "test-marketplace")
+			git(env.TempDir, "clone", bareRepo, marketplaceDir)
+			pluginDir := filepath.Join(marketplaceDir, "plugins", "test-plugin")
+			Expect(os.MkdirAll(pluginDir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(pluginDir, "plugin.json"), []byte(`{"name":"test-plugin","version":"1.0.0"}`), 0644)).To(Succeed())
+			git(marketplaceDir, "add", ".")
+			git(marketplaceDir, "commit", "-m", "initial")
+			git(marketplaceDir, "push", "origin", "HEAD")
+			initialSHA = git(marketplaceDir, "rev-parse", "HEAD")
+
+			cacheDir = filepath.Join(env.ClaudeDir, "plugins", "cache", "test-plugin")
+			Expect(os.MkdirAll(cacheDir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cacheDir, "plugin.json"), []byte(`{"name":"test-plugin","version":"1.0.0"}`), 0644)).To(Succeed())
+
+			env.CreateKnownMarketplaces(map[string]interface{}{
+				"test-marketplace": map[string]interface{}{
+					"source":          map[string]interface{}{"repo": bareRepo},
+					"installLocation": marketplaceDir,
+				},
+			})
+			env.CreateInstalledPlugins(map[string]interface{}{
+				"test-plugin@test-marketplace": []interface{}{
+					map[string]interface{}{
+						"scope":        "user",
+						"version":      "1.0.0",
+						"installPath":  cacheDir,
+						"gitCommitSha": initialSHA,
+					},
+				},
+			})
+
+			tempClone := filepath.Join(env.TempDir, "temp-clone")
+			git(env.TempDir, "clone", bareRepo, tempClone)
+			Expect(os.WriteFile(filepath.Join(tempClone, "plugins", "test-plugin", "plugin.json"), []byte(`{"name":"test-plugin","version":"2.0.0"}`), 0644)).To(Succeed())
+			git(tempClone, "commit", "-am", "bump test-plugin to 2.0.0")
+			git(tempClone, "push", "origin", "HEAD")
+		})
+
+		It("previews incoming commits and changed plugin files", func() {
+			result := env.Run("upgrade", "--interactive", "--yes")
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+			Expect(result.Stdout).To(ContainSubstring("Incoming Changes"))
+			Expect(result.Stdout).To(ContainSubstring("bump test-plugin to 2.0.0"))
+			Expect(result.Stdout).To(ContainSubstring("test-plugin (1 changed)"))
+			Expect(result.Stdout).To(ContainSubstring("plugins/test-plugin/plugin.json"))
+			Expect(os.ReadFile(filepath.Join(cacheDir, "plugin.json"))).To(ContainSubstring("2.0.0"))
+		})
+
+		It("leaves a rejected marketplace untouched", func() {
+			result := env.RunWithInput("n\n", "upgrade", "--interactive")
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+			Expect(result.Stdout).To(ContainSubstring("test-marketplace: Skipped"))
+			Expect(git(marketplaceDir, "rev-parse", "HEAD")).To(Equal(initialSHA))
+			Expect(os.ReadFile(filepath.Join(cacheDir, "plugin.json"))).To(ContainSubstring("1.0.0"))
+		})
+
+		It("restores the previous plugin copy with --rollback", func() {
+			Expect(env.Run("upgrade").ExitCode).To(Equal(0))
+			Expect(os.ReadFile(filepath.Join(cacheDir, "plugin.json"))).To(ContainSubstring("2.0.0"))
+
+			result := env.Run("upgrade", "--rollback", "test-plugin@test-marketplace")
+			Expect(result.ExitCode).To(Equal(0), result.Stderr)
+			Expect(result.Stdout).To(ContainSubstring("test-plugin@test-marketplace (user): Restored"))
+			Expect(os.ReadFile(filepath.Join(cacheDir, "plugin.json"))).To(ContainSubstring("1.0.0"))
+
+			registry := helpers.LoadJSON(filepath.Join(env.ClaudeDir, "plugins", "installed_plugins.json"))
+			instance := registry["plugins"].(map[string]interface{})["test-plugin@test-marketplace"].([]interface{})[0].(map[string]interface{})
+			Expect(instance["gitCommitSha"]).To(Equal(initialSHA))
+
+			result = env.Run("upgrade", "--rollback", "test-plugin@test-marketplace")
+			Expect(result.ExitCode).NotTo(Equal(0))
+			Expect(result.Stderr).To(ContainSubstring("no upgrade backup"))
+		})
+	})
+
 	Describe("stale plugin registry cleanup", func() {
 		It("removes stale entries when confirmed with --yes", func() {
 			env = helpers.NewTestEnv(binaryPath)
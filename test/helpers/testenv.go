@@ -561,6 +561,35 @@ func (e *TestEnv) CreateMarketplace(name, repo string) {
 	Expect(os.WriteFile(marketplacesPath, jsonData, 0644)).To(Succeed())
 }
 
+// CreateDirectoryMarketplace registers a local directory marketplace whose
+// install location is the directory itself
+func (e *TestEnv) CreateDirectoryMarketplace(name, dir string) {
+	pluginsDir := filepath.Join(e.ClaudeDir, "plugins")
+	Expect(os.MkdirAll(pluginsDir, 0755)).To(Succeed())
+	Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+
+	marketplacesPath := filepath.Join(pluginsDir, "known_marketplaces.json")
+	var marketplaces map[string]interface{}
+	if data, err := os.ReadFile(marketplacesPath); err == nil {
+		_ = json.Unmarshal(data, &marketplaces)
+	}
+	if marketplaces == nil {
+		marketplaces = make(map[string]interface{})
+	}
+
+	marketplaces[name] = map[string]interface{}{
+		"source": map[string]interface{}{
+			"source": "directory",
+			"path":   dir,
+		},
+		"installLocation": dir,
+	}
+
+	jsonData, err := json.MarshalIndent(marketplaces, "", "  ")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(os.WriteFile(marketplacesPath, jsonData, 0644)).To(Succeed())
+}
+
 // CreateMarketplacePlugin creates a plugin in a marketplace's directory structure
 func (e *TestEnv) CreateMarketplacePlugin(marketplace, pluginName, version string) {
 	pluginsDir := filepath.Join(e.ClaudeDir, "plugins")